@@ -40,6 +40,10 @@ const (
 	// NOTE: This reason does not apply to ProxmoxVM (this state happens before the ProxmoxVM is actually created).
 	WaitingForBootstrapDataReason = "WaitingForBootstrapData"
 
+	// WaitingForMachineDeletionReason (Severity=Info) documents a deleted ProxmoxMachine whose VM is kept
+	// until the node of its Machine is drained and the deletion hooks of the Machine are removed.
+	WaitingForMachineDeletionReason = "WaitingForMachineDeletion"
+
 	// WaitingForStaticIPAllocationReason (Severity=Info) documents a ProxmoxVM waiting for the allocation of
 	// a static IP address.
 	WaitingForStaticIPAllocationReason = "WaitingForStaticIPAllocation"
@@ -52,6 +56,16 @@ const (
 	// are automatically re-tried by the controller.
 	CloningFailedReason = "CloningFailed"
 
+	// InsufficientStorageReason (Severity=Warning) documents a clone which is not started, because its storages
+	// don't have enough space left for the disks of the VM. The clone is retried once there is enough space.
+	InsufficientStorageReason = "InsufficientStorage"
+
+	// AdoptingReason documents (Severity=Info) a ProxmoxMachine adopting an existing VM.
+	AdoptingReason = "Adopting"
+
+	// AdoptionFailedReason (Severity=Warning) documents a ProxmoxMachine which could not adopt an existing VM.
+	AdoptionFailedReason = "AdoptionFailed"
+
 	// PoweringOnReason documents (Severity=Info) a ProxmoxMachine/ProxmoxVM currently executing the power on sequence.
 	PoweringOnReason = "PoweringOn"
 
@@ -60,6 +74,13 @@ const (
 	// are automatically re-tried by the controller.
 	PoweringOnFailedReason = "PoweringOnFailed"
 
+	// PoweringOffReason documents (Severity=Info) a ProxmoxMachine currently powering off its VM,
+	// as its desired power state is off.
+	PoweringOffReason = "PoweringOff"
+
+	// PoweredOffReason documents (Severity=Info) a ProxmoxMachine whose VM is kept powered off.
+	PoweredOffReason = "PoweredOff"
+
 	// VMProvisionStarted used for starting vm provisioning.
 	VMProvisionStarted = "VMProvisionStarted"
 
@@ -79,10 +100,94 @@ const (
 	// UnknownReason (Severity=Warning) documents the ProxmoxVM Unknown.
 	UnknownReason = "Unknown"
 
+	// GuestAgentAddressesCondition documents the discovery of the machine addresses through the QEMU guest agent.
+	GuestAgentAddressesCondition clusterv1.ConditionType = "GuestAgentAddresses"
+
+	// WaitingForGuestAgentReason (Severity=Info) documents a ProxmoxMachine waiting for the QEMU guest agent
+	// to report the addresses of the machine for the first time.
+	// The severity is Warning if the machine does not wait for the agent, because the agent check is skipped.
+	WaitingForGuestAgentReason = "WaitingForGuestAgent"
+
+	// GuestAgentUnavailableReason (Severity=Warning) documents the QEMU guest agent not responding anymore.
+	// The addresses discovered earlier are kept until it responds again.
+	GuestAgentUnavailableReason = "GuestAgentUnavailable"
+
+	// NetworkDevicesCondition documents whether the network devices added to a running ProxmoxMachine are attached.
+	// The condition is only set if a device could not be hot-plugged.
+	NetworkDevicesCondition clusterv1.ConditionType = "NetworkDevices"
+
+	// RebootRequiredReason (Severity=Warning) documents network devices which were added to a running VM without
+	// network hotplug. Proxmox attaches them on the next reboot of the VM.
+	RebootRequiredReason = "RebootRequired"
+
+	// VMResizedCondition documents the CPU and memory changes applied to the running VM of a ProxmoxMachine.
+	// The condition is only set for machines with AllowInPlaceResize enabled, once their VM was resized.
+	// Its reason is ResizedWithRebootReason if the VM was rebooted to apply the changes.
+	VMResizedCondition clusterv1.ConditionType = "VMResized"
+
+	// ResizingReason (Severity=Info) documents CPU and memory changes being hot-plugged into the running VM.
+	ResizingReason = "Resizing"
+
+	// RebootingReason (Severity=Info) documents the VM being rebooted to apply CPU and memory changes,
+	// which can't be hot-plugged. The pending changes are documented with the RebootRequiredReason before.
+	RebootingReason = "Rebooting"
+
+	// ResizedWithRebootReason documents the VM which was rebooted to apply the CPU and memory changes.
+	// It is set on the True VMResizedCondition.
+	ResizedWithRebootReason = "ResizedWithReboot"
+
+	// VMMigratedCondition documents the migration of the VM of a ProxmoxMachine to another Proxmox node.
+	// The condition is only set once a migration was requested.
+	VMMigratedCondition clusterv1.ConditionType = "VMMigrated"
+
+	// MigratingReason (Severity=Info) documents the VM of a ProxmoxMachine being migrated to another node.
+	MigratingReason = "Migrating"
+
+	// MigrationFailedReason (Severity=Warning) documents a migration which could not be started or did not
+	// move the VM. The controller retries the migration as long as it is requested.
+	MigrationFailedReason = "MigrationFailed"
+
+	// VMSnapshotCondition documents the snapshot of the VM taken before a ProxmoxMachine is deleted.
+	// The condition is only set for machines with PreDeleteSnapshot enabled.
+	VMSnapshotCondition clusterv1.ConditionType = "VMSnapshot"
+
+	// SnapshottingReason (Severity=Info) documents the pre-delete snapshot of the VM being taken.
+	SnapshottingReason = "Snapshotting"
+
+	// SnapshotFailedReason (Severity=Warning) documents a pre-delete snapshot which failed.
+	// The controller retries the snapshot before the VM is deleted.
+	SnapshotFailedReason = "SnapshotFailed"
+
+	// VMClonedCondition documents the clone of the VM of a ProxmoxMachine, or the adoption of an existing VM.
+	VMClonedCondition clusterv1.ConditionType = "VMCloned"
+
+	// VMStartedCondition documents the VM of a ProxmoxMachine being powered on.
+	VMStartedCondition clusterv1.ConditionType = "VMStarted"
+
+	// IPAddressAssignedCondition documents the assignment of the static IP addresses of a ProxmoxMachine.
+	IPAddressAssignedCondition clusterv1.ConditionType = "IPAddressAssigned"
+
+	// BootstrapDataInjectedCondition documents the injection of the bootstrap data into the VM of a ProxmoxMachine.
+	BootstrapDataInjectedCondition clusterv1.ConditionType = "BootstrapDataInjected"
+
+	// GuestAgentReadyCondition documents the QEMU guest agent of the VM responding.
+	// The condition is not set for machines which skip the guest agent check.
+	GuestAgentReadyCondition clusterv1.ConditionType = "GuestAgentReady"
+
+	// BootstrapDataInjectionFailedReason (Severity=Warning) documents bootstrap data which could not be injected.
+	// The controller retries the injection.
+	BootstrapDataInjectionFailedReason = "BootstrapDataInjectionFailed"
+
 	// MissingControlPlaneEndpointReason (Severity=Warning) documents the missing Control Plane endpoint when Cluster is backed by an externally managed Control Plane.
 	MissingControlPlaneEndpointReason = "MissingControlPlaneEndpoint"
 )
 
+const (
+	// PausedCondition documents a ProxmoxMachine not being reconciled, because it or its cluster is paused.
+	// The condition is removed once the reconciliation resumes.
+	PausedCondition clusterv1.ConditionType = "Paused"
+)
+
 const (
 	// ProxmoxClusterReady documents the status of ProxmoxCluster and its underlying resources.
 	ProxmoxClusterReady clusterv1.ConditionType = "ClusterReady"
@@ -90,4 +195,12 @@ const (
 	// ProxmoxUnreachableReason (Severity=Error) documents a controller detecting
 	// issues with Proxmox reachability.
 	ProxmoxUnreachableReason = "ProxmoxUnreachable"
+
+	// InvalidFailureDomainReason (Severity=Error) documents a failure domain
+	// referencing Proxmox nodes which do not exist.
+	InvalidFailureDomainReason = "InvalidFailureDomain"
+
+	// InvalidIPConfigReason (Severity=Error) documents an ip config with overlapping addresses,
+	// or a gateway outside of its prefix, which was not rejected by the webhook.
+	InvalidIPConfigReason = "InvalidIPConfig"
 )
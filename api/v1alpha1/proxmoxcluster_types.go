@@ -17,6 +17,8 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"slices"
+
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/ptr"
@@ -42,8 +44,15 @@ type ProxmoxClusterSpec struct {
 	// +kubebuilder:validation:XValidation:rule="self.port > 0 && self.port < 65536",message="port must be within 1-65535"
 	ControlPlaneEndpoint *clusterv1.APIEndpoint `json:"controlPlaneEndpoint"`
 
+	// ControlPlaneEndpointIPAM allocates the host of the ControlPlaneEndpoint from the IP pools of the cluster.
+	// If both ipv4Config and ipv6Config are set, one address of each family is allocated for a dual-stack endpoint.
+	// +optional
+	ControlPlaneEndpointIPAM *ControlPlaneEndpointIPAM `json:"controlPlaneEndpointIPAM,omitempty"`
+
 	// ExternalManagedControlPlane can be enabled to allow externally managed Control Planes to patch the
 	// Proxmox cluster with the Load Balancer IP provided by Control Plane provider.
+	// It also applies to endpoints managed by the user, e.g. a kube-vip address set after the cluster was created:
+	// no address is allocated for the endpoint, and the cluster is ready once the endpoint is set.
 	ExternalManagedControlPlane bool `json:"externalManagedControlPlane,omitempty"`
 
 	// AllowedNodes specifies all Proxmox nodes which will be considered
@@ -57,24 +66,95 @@ type ProxmoxClusterSpec struct {
 	// +optional
 	SchedulerHints *SchedulerHints `json:"schedulerHints,omitempty"`
 
+	// FailureDomains maps failure domain names, e.g. datacenters, to the Proxmox nodes backing them.
+	// Machines which were assigned a failure domain by Cluster API are only scheduled on its nodes.
+	// +optional
+	FailureDomains map[string]FailureDomainSpec `json:"failureDomains,omitempty"`
+
+	// Pool is the Proxmox resource pool the VMs of this cluster are added to.
+	// It is only used if the ProxmoxMachine does not specify a pool itself.
+	// +optional
+	Pool *string `json:"pool,omitempty"`
+
+	// Storage is the Proxmox storage full clones of the VMs of this cluster are placed on,
+	// instead of the storage of the template. It is only used if neither the ProxmoxMachine
+	// nor its failure domain specify a storage. Linked clones ignore it.
+	// +optional
+	Storage *string `json:"storage,omitempty"`
+
+	// StorageHeadroomGB is the space in gigabyte, which must be left on a storage after the disks of a full clone
+	// are placed on it. Clones are not started before their storages have enough space left.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	StorageHeadroomGB *int32 `json:"storageHeadroomGb,omitempty"`
+
 	// IPv4Config contains information about available IPV4 address pools and the gateway.
 	// This can be combined with ipv6Config in order to enable dual stack.
-	// Either IPv4Config or IPv6Config must be provided.
+	// At least one of IPv4Config, IPv6Config or a pool reference must be provided.
 	// +optional
 	// +kubebuilder:validation:XValidation:rule="self.addresses.size() > 0",message="IPv4Config addresses must be provided"
 	IPv4Config *IPConfigSpec `json:"ipv4Config,omitempty"`
 
 	// IPv6Config contains information about available IPV6 address pools and the gateway.
 	// This can be combined with ipv4Config in order to enable dual stack.
-	// Either IPv4Config or IPv6Config must be provided.
+	// At least one of IPv4Config, IPv6Config or a pool reference must be provided.
 	// +optional
 	// +kubebuilder:validation:XValidation:rule="self.addresses.size() > 0",message="IPv6Config addresses must be provided"
 	IPv6Config *IPConfigSpec `json:"ipv6Config,omitempty"`
 
+	// IPv4PoolRef references an external IPAM pool, implementing the Cluster API IPAM contract,
+	// which provides the IPv4 addresses of the default network devices instead of an InClusterIPPool
+	// created from ipv4Config. Mutually exclusive with ipv4Config.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="has(self.apiGroup)",message="ipv4PoolRef requires an apiGroup"
+	IPv4PoolRef *corev1.TypedLocalObjectReference `json:"ipv4PoolRef,omitempty"`
+
+	// IPv6PoolRef references an external IPAM pool, implementing the Cluster API IPAM contract,
+	// which provides the IPv6 addresses of the default network devices instead of an InClusterIPPool
+	// created from ipv6Config. Mutually exclusive with ipv6Config.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="has(self.apiGroup)",message="ipv6PoolRef requires an apiGroup"
+	IPv6PoolRef *corev1.TypedLocalObjectReference `json:"ipv6PoolRef,omitempty"`
+
 	// DNSServers contains information about nameservers used by the machines.
 	// +kubebuilder:validation:MinItems=1
 	DNSServers []string `json:"dnsServers"`
 
+	// SearchDomains contains the DNS search domains used by the machines.
+	// Duplicate entries are rendered only once.
+	// +optional
+	// +kubebuilder:validation:MinItems=1
+	SearchDomains []string `json:"searchDomains,omitempty"`
+
+	// NTPServers are the NTP servers, hostnames or IP addresses, cloud-init configures on the machines.
+	// The ntp module is left out of the user data if no servers are set.
+	// +optional
+	// +kubebuilder:validation:MinItems=1
+	NTPServers []string `json:"ntpServers,omitempty"`
+
+	// SSHAuthorizedKeys are OpenSSH public keys authorized for the default user of all machines,
+	// in addition to the keys of the bootstrap provider.
+	// +optional
+	// +kubebuilder:validation:MinItems=1
+	SSHAuthorizedKeys []string `json:"sshAuthorizedKeys,omitempty"`
+
+	// VMNameTemplate is the Go template the names of new VMs are rendered from, e.g.
+	// `{{ .ClusterName }}-{{ .Role }}-{{ .ShortUID }}`. See ProxmoxMachineSpec.VMNameTemplate.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	VMNameTemplate *string `json:"vmNameTemplate,omitempty"`
+
+	// VMIDRange is the range of VMIDs new VMs of the cluster are cloned to, unless the machine sets its own.
+	// VMIDs below 100 are reserved by Proxmox and cannot be part of the range.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="self.end >= self.start",message="end should be greater than or equal to start"
+	VMIDRange *VMIDRange `json:"vmIDRange,omitempty"`
+
+	// MachineDefaults are applied to the ProxmoxMachines of this cluster which leave the fields unset,
+	// before their VMs are cloned. Values set on the machine take precedence.
+	// +optional
+	MachineDefaults *ProxmoxMachineDefaults `json:"machineDefaults,omitempty"`
+
 	// NodeCloneSpec is the configuration pertaining to all items configurable
 	// in the configuration and cloning of a proxmox VM. Multiple types of nodes can be specified.
 	// +optional
@@ -102,6 +182,123 @@ type ProxmoxClusterCloneSpec struct {
 	VirtualIPNetworkInterface string `json:"virtualIPNetworkInterface,omitempty"`
 }
 
+// ControlPlaneEndpointIPAM configures the allocation of the control plane endpoint addresses.
+type ControlPlaneEndpointIPAM struct {
+	// PrimaryFamily is the IP family of the address used as ControlPlaneEndpoint host.
+	// The address of the other family, if any, is only reported in the status.
+	// +kubebuilder:validation:Enum=ipv4;ipv6
+	// +kubebuilder:default=ipv4
+	// +optional
+	PrimaryFamily string `json:"primaryFamily,omitempty"`
+
+	// Port is the port of the ControlPlaneEndpoint, e.g. the port a load balancer in front of the
+	// API servers listens on. Defaults to 6443.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +kubebuilder:default=6443
+	// +optional
+	Port int32 `json:"port,omitempty"`
+}
+
+// FailureDomainSpec describes the Proxmox nodes forming a failure domain.
+type FailureDomainSpec struct {
+	// Nodes are the Proxmox nodes belonging to this failure domain.
+	// +kubebuilder:validation:MinItems=1
+	Nodes []string `json:"nodes"`
+
+	// ControlPlane determines if the failure domain is suitable for control plane machines.
+	// +kubebuilder:default=true
+	// +optional
+	ControlPlane *bool `json:"controlPlane,omitempty"`
+
+	// Storage for full clones within this failure domain.
+	// It is only used if the ProxmoxMachine does not specify a storage itself.
+	// +optional
+	Storage *string `json:"storage,omitempty"`
+}
+
+// ProxmoxMachineDefaults are the defaults of the ProxmoxMachines of a cluster.
+// The fields mirror those of the ProxmoxMachineSpec. A field the machine sets overrides the default,
+// slices replace the default as a whole instead of being merged with it.
+type ProxmoxMachineDefaults struct {
+	// NumSockets is the default number of CPU sockets of the VMs.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	NumSockets int32 `json:"numSockets,omitempty"`
+
+	// NumCores is the default number of cores per CPU socket of the VMs.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	NumCores int32 `json:"numCores,omitempty"`
+
+	// CPUType is the default emulated CPU type of the VMs, e.g. host or x86-64-v2-AES.
+	// +optional
+	CPUType string `json:"cpuType,omitempty"`
+
+	// MemoryMiB is the default size of the memory of the VMs, in MiB.
+	// +kubebuilder:validation:MultipleOf=8
+	// +optional
+	MemoryMiB int32 `json:"memoryMiB,omitempty"`
+
+	// Disks are the default disks of the VMs. The boot volume and the additional volumes
+	// are defaulted separately.
+	// +optional
+	Disks *DefaultStorage `json:"disks,omitempty"`
+
+	// Tags are the default Proxmox tags of the VMs.
+	// +kubebuilder:validation:items:Pattern=`^[a-zA-Z0-9_][a-zA-Z0-9_+.-]*$`
+	// +listType=set
+	// +optional
+	Tags []string `json:"tags,omitempty"`
+}
+
+// DefaultStorage are the default disks of the ProxmoxMachines of a cluster.
+// Unlike the disks of a machine, they can be changed at any time, which only affects new machines.
+type DefaultStorage struct {
+	// BootVolume is the default size of the boot volume.
+	// +optional
+	BootVolume *DiskSize `json:"bootVolume,omitempty"`
+
+	// AdditionalVolumes are the default additional disks.
+	// +kubebuilder:validation:MaxItems=30
+	// +optional
+	AdditionalVolumes []DiskSpec `json:"additionalVolumes,omitempty"`
+}
+
+// ApplyTo sets the fields of the machine spec which are unset to the defaults.
+func (d *ProxmoxMachineDefaults) ApplyTo(spec *ProxmoxMachineSpec) {
+	if spec.NumSockets == 0 {
+		spec.NumSockets = d.NumSockets
+	}
+	if spec.NumCores == 0 {
+		spec.NumCores = d.NumCores
+	}
+	if spec.CPUType == "" {
+		spec.CPUType = d.CPUType
+	}
+	if spec.MemoryMiB == 0 {
+		spec.MemoryMiB = d.MemoryMiB
+	}
+	if len(spec.Tags) == 0 {
+		spec.Tags = slices.Clone(d.Tags)
+	}
+
+	if d.Disks == nil {
+		return
+	}
+	if spec.Disks == nil {
+		spec.Disks = &Storage{}
+	}
+	if spec.Disks.BootVolume == nil {
+		spec.Disks.BootVolume = d.Disks.BootVolume.DeepCopy()
+	}
+	if len(spec.Disks.AdditionalVolumes) == 0 {
+		for _, volume := range d.Disks.AdditionalVolumes {
+			spec.Disks.AdditionalVolumes = append(spec.Disks.AdditionalVolumes, *volume.DeepCopy())
+		}
+	}
+}
+
 // IPConfigSpec contains information about available IP config.
 type IPConfigSpec struct {
 	// Addresses is a list of IP addresses that can be assigned. This set of
@@ -130,8 +327,36 @@ type SchedulerHints struct {
 	// By default 100% of a node's memory will be used for allocation.
 	// +optional
 	MemoryAdjustment *uint64 `json:"memoryAdjustment,omitempty"`
+
+	// AntiAffinity spreads the machines of the control plane, or of a single MachineDeployment,
+	// across distinct Proxmox nodes. Machines are only co-located on the same node if none of
+	// the allowed nodes without a peer has enough memory left.
+	// +optional
+	AntiAffinity bool `json:"antiAffinity,omitempty"`
+
+	// Strategy defines which resources of a node are weighed when placing a VM.
+	// `memory` distributes VMs round-robin as long as memory allows it, `cpu` prefers
+	// the node with the lowest CPU usage, and `balanced` weighs free memory and CPU equally.
+	// Defaults to `memory`.
+	// +optional
+	Strategy SchedulerStrategy `json:"strategy,omitempty"`
 }
 
+// SchedulerStrategy is the resource weighting used by the scheduler.
+// +kubebuilder:validation:Enum=memory;cpu;balanced
+type SchedulerStrategy string
+
+const (
+	// SchedulerStrategyMemory schedules VMs round-robin, constrained by the available memory.
+	SchedulerStrategyMemory SchedulerStrategy = "memory"
+
+	// SchedulerStrategyCPU schedules VMs on the node with the lowest CPU usage.
+	SchedulerStrategyCPU SchedulerStrategy = "cpu"
+
+	// SchedulerStrategyBalanced weighs the free memory and CPU of a node equally.
+	SchedulerStrategyBalanced SchedulerStrategy = "balanced"
+)
+
 // GetMemoryAdjustment returns the memory adjustment percentage to use within the scheduler.
 func (sh *SchedulerHints) GetMemoryAdjustment() uint64 {
 	memoryAdjustment := uint64(100)
@@ -143,6 +368,19 @@ func (sh *SchedulerHints) GetMemoryAdjustment() uint64 {
 	return memoryAdjustment
 }
 
+// GetStrategy returns the scheduling strategy, defaulting to memory.
+func (sh *SchedulerHints) GetStrategy() SchedulerStrategy {
+	if sh == nil || sh.Strategy == "" {
+		return SchedulerStrategyMemory
+	}
+	return sh.Strategy
+}
+
+// IsAntiAffinityEnabled returns whether the scheduler should spread peer machines across nodes.
+func (sh *SchedulerHints) IsAntiAffinityEnabled() bool {
+	return sh != nil && sh.AntiAffinity
+}
+
 // ProxmoxClusterStatus defines the observed state of a ProxmoxCluster.
 type ProxmoxClusterStatus struct {
 	// Ready indicates that the cluster is ready.
@@ -154,11 +392,24 @@ type ProxmoxClusterStatus struct {
 	// +optional
 	InClusterIPPoolRef []corev1.LocalObjectReference `json:"inClusterIpPoolRef,omitempty"`
 
+	// ControlPlaneEndpointAddresses are the addresses allocated for the control plane endpoint,
+	// one per IP family. The primary address comes first.
+	// +optional
+	ControlPlaneEndpointAddresses []string `json:"controlPlaneEndpointAddresses,omitempty"`
+
 	// NodeLocations keeps track of which nodes have been selected
 	// for different machines.
 	// +optional
 	NodeLocations *NodeLocations `json:"nodeLocations,omitempty"`
 
+	// FailureDomains lists the failure domains machines can be placed in.
+	// +optional
+	FailureDomains clusterv1.FailureDomains `json:"failureDomains,omitempty"`
+
+	// RetainedVMs are the VMs kept after their machine was deleted.
+	// +optional
+	RetainedVMs []RetainedVM `json:"retainedVMs,omitempty"`
+
 	// FailureReason will be set in the event that there is a terminal problem
 	// reconciling the Machine and will contain a succinct value suitable
 	// for machine interpretation.
@@ -202,6 +453,22 @@ type ProxmoxClusterStatus struct {
 	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
 }
 
+// RetainedVM is a VM kept after its machine was deleted.
+type RetainedVM struct {
+	// Name is the name of the VM, which is the name of the deleted ProxmoxMachine.
+	Name string `json:"name"`
+
+	// VMID is the id of the VM.
+	VMID int64 `json:"vmID"`
+
+	// Node is the Proxmox node the VM was retained on.
+	Node string `json:"node"`
+
+	// ExpiresAt is the time the VM is destroyed. The VM is kept if unset.
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+}
+
 // NodeLocations holds information about the deployment state of
 // control plane and worker nodes in Proxmox.
 type NodeLocations struct {
@@ -236,7 +503,7 @@ type ProxmoxCluster struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	// +kubebuilder:validation:XValidation:rule="self.ipv4Config != null || self.ipv6Config != null",message="at least one ip config must be set, either ipv4Config or ipv6Config"
+	// +kubebuilder:validation:XValidation:rule="self.ipv4Config != null || self.ipv6Config != null || self.ipv4PoolRef != null || self.ipv6PoolRef != null",message="at least one ip config must be set, either ipv4Config, ipv6Config, ipv4PoolRef or ipv6PoolRef"
 	Spec   ProxmoxClusterSpec   `json:"spec,omitempty"`
 	Status ProxmoxClusterStatus `json:"status,omitempty"`
 }
@@ -260,6 +527,16 @@ func (c *ProxmoxCluster) SetConditions(conditions clusterv1.Conditions) {
 	c.Status.Conditions = conditions
 }
 
+// HasIPv4 returns whether the default network devices get IPv4 addresses, either from ipv4Config or an external pool.
+func (c *ProxmoxCluster) HasIPv4() bool {
+	return c.Spec.IPv4Config != nil || c.Spec.IPv4PoolRef != nil
+}
+
+// HasIPv6 returns whether the default network devices get IPv6 addresses, either from ipv6Config or an external pool.
+func (c *ProxmoxCluster) HasIPv6() bool {
+	return c.Spec.IPv6Config != nil || c.Spec.IPv6PoolRef != nil
+}
+
 // SetInClusterIPPoolRef will set the reference to the provided InClusterIPPool.
 // If nil was provided, the status field will be cleared.
 func (c *ProxmoxCluster) SetInClusterIPPoolRef(pool client.Object) {
@@ -386,6 +663,23 @@ func (c *ProxmoxCluster) GetNode(machineName string, isControlPlane bool) string
 	return ""
 }
 
+// AddRetainedVM adds a retained VM to the status, unless it is already tracked.
+func (c *ProxmoxCluster) AddRetainedVM(vm RetainedVM) {
+	for _, retained := range c.Status.RetainedVMs {
+		if retained.VMID == vm.VMID {
+			return
+		}
+	}
+	c.Status.RetainedVMs = append(c.Status.RetainedVMs, vm)
+}
+
+// RemoveRetainedVM removes a retained VM from the status.
+func (c *ProxmoxCluster) RemoveRetainedVM(vmID int64) {
+	c.Status.RetainedVMs = slices.DeleteFunc(c.Status.RetainedVMs, func(vm RetainedVM) bool {
+		return vm.VMID == vmID
+	})
+}
+
 func (c *ProxmoxCluster) addNodeLocation(loc NodeLocation, isControlPlane bool) {
 	if isControlPlane {
 		c.Status.NodeLocations.ControlPlane = append(c.Status.NodeLocations.ControlPlane, loc)
@@ -125,6 +125,20 @@ var _ = Describe("ProxmoxCluster Test", func() {
 		})
 	})
 
+	Context("VMIDRange", func() {
+		It("Should not allow reserved VMIDs", func() {
+			dc := defaultCluster()
+			dc.Spec.VMIDRange = &VMIDRange{Start: 99, End: 200}
+			Expect(k8sClient.Create(context.Background(), dc)).Should(MatchError(ContainSubstring("should be greater than or equal to 100")))
+		})
+
+		It("Should only allow spec.vmIDRange.end >= spec.vmIDRange.start", func() {
+			dc := defaultCluster()
+			dc.Spec.VMIDRange = &VMIDRange{Start: 201, End: 200}
+			Expect(k8sClient.Create(context.Background(), dc)).Should(MatchError(ContainSubstring("should be greater than or equal to start")))
+		})
+	})
+
 	Context("IPv4Config", func() {
 		It("Should not allow empty addresses", func() {
 			dc := defaultCluster()
@@ -251,3 +265,71 @@ func TestSetInClusterIPPoolRef(t *testing.T) {
 	cl.SetInClusterIPPoolRef(pool)
 	require.Equal(t, cl.Status.InClusterIPPoolRef[0].Name, pool.GetName())
 }
+
+func TestProxmoxMachineDefaultsApplyTo(t *testing.T) {
+	defaults := &ProxmoxMachineDefaults{
+		NumSockets: 2,
+		NumCores:   4,
+		CPUType:    "host",
+		MemoryMiB:  8192,
+		Disks: &DefaultStorage{
+			BootVolume:        &DiskSize{Disk: "scsi0", SizeGB: 50},
+			AdditionalVolumes: []DiskSpec{{SizeGB: 100, StoragePool: "local-lvm"}},
+		},
+		Tags: []string{"k8s", "prod"},
+	}
+
+	// unset fields are defaulted.
+	spec := ProxmoxMachineSpec{}
+	defaults.ApplyTo(&spec)
+	require.Equal(t, int32(2), spec.NumSockets)
+	require.Equal(t, int32(4), spec.NumCores)
+	require.Equal(t, "host", spec.CPUType)
+	require.Equal(t, int32(8192), spec.MemoryMiB)
+	require.Equal(t, &DiskSize{Disk: "scsi0", SizeGB: 50}, spec.Disks.BootVolume)
+	require.Equal(t, []DiskSpec{{SizeGB: 100, StoragePool: "local-lvm"}}, spec.Disks.AdditionalVolumes)
+	require.Equal(t, []string{"k8s", "prod"}, spec.Tags)
+
+	// the machine doesn't share the defaults.
+	spec.Tags[0] = "changed"
+	spec.Disks.BootVolume.SizeGB = 60
+	require.Equal(t, []string{"k8s", "prod"}, defaults.Tags)
+	require.Equal(t, int32(50), defaults.Disks.BootVolume.SizeGB)
+}
+
+func TestProxmoxMachineDefaultsApplyToPrecedence(t *testing.T) {
+	defaults := &ProxmoxMachineDefaults{
+		NumSockets: 2,
+		NumCores:   4,
+		CPUType:    "host",
+		MemoryMiB:  8192,
+		Disks: &DefaultStorage{
+			BootVolume:        &DiskSize{Disk: "scsi0", SizeGB: 50},
+			AdditionalVolumes: []DiskSpec{{SizeGB: 100, StoragePool: "local-lvm"}},
+		},
+		Tags: []string{"k8s", "prod"},
+	}
+
+	spec := ProxmoxMachineSpec{
+		NumCores:  8,
+		MemoryMiB: 16384,
+		Disks: &Storage{
+			AdditionalVolumes: []DiskSpec{{SizeGB: 20, StoragePool: "ceph"}},
+		},
+		Tags: []string{"gpu"},
+	}
+	defaults.ApplyTo(&spec)
+
+	// values of the machine override scalars of the defaults.
+	require.Equal(t, int32(2), spec.NumSockets)
+	require.Equal(t, int32(8), spec.NumCores)
+	require.Equal(t, "host", spec.CPUType)
+	require.Equal(t, int32(16384), spec.MemoryMiB)
+
+	// slices of the machine replace the defaults, they are not merged.
+	require.Equal(t, []string{"gpu"}, spec.Tags)
+	require.Equal(t, []DiskSpec{{SizeGB: 20, StoragePool: "ceph"}}, spec.Disks.AdditionalVolumes)
+
+	// the boot volume is defaulted separately from the additional volumes.
+	require.Equal(t, &DiskSize{Disk: "scsi0", SizeGB: 50}, spec.Disks.BootVolume)
+}
@@ -34,6 +34,17 @@ const (
 	// ProxmoxMachine before removing it from the API Server.
 	MachineFinalizer = "proxmoxmachine.infrastructure.cluster.x-k8s.io"
 
+	// DefaultSnapshotName is the default name of the snapshot taken before a machine is deleted.
+	DefaultSnapshotName = "capmox-predelete"
+
+	// RetainedVMTag is the tag of VMs retained after their machine was deleted.
+	RetainedVMTag = "capmox_retained"
+
+	// MigrateToNodeAnnotation requests the migration of the VM of a ProxmoxMachine to the Proxmox node
+	// given as value. With an empty value, the scheduler picks one of the other allowed nodes.
+	// The annotation is removed once the VM runs on the node.
+	MigrateToNodeAnnotation = "proxmoxmachine.infrastructure.cluster.x-k8s.io/migrate-to"
+
 	// DefaultReconcilerRequeue is the default value for the reconcile retry.
 	DefaultReconcilerRequeue = 10 * time.Second
 
@@ -73,6 +84,20 @@ type ProxmoxMachineSpec struct {
 	// +optional
 	VirtualMachineID *int64 `json:"virtualMachineID,omitempty"`
 
+	// ExistingVMID is the id of an existing VM to adopt, instead of cloning a new one from the template.
+	// The VM is renamed after the ProxmoxMachine, tagged for the cluster and its config is reconciled,
+	// but its disks are kept and no bootstrap data is provided.
+	// +kubebuilder:validation:Minimum=100
+	// +optional
+	ExistingVMID *int64 `json:"existingVMID,omitempty"`
+
+	// DeletionPolicy decides what happens to a VM adopted with ExistingVMID when the machine is deleted.
+	// `Delete` destroys the VM, `Detach` only removes the cluster tag and keeps it running.
+	// VMs cloned by the provider are always destroyed. Defaults to `Delete`.
+	// +kubebuilder:validation:Enum=Delete;Detach
+	// +optional
+	DeletionPolicy VMDeletionPolicy `json:"deletionPolicy,omitempty"`
+
 	// NumSockets is the number of CPU sockets in a virtual machine.
 	// Defaults to the property value in the template from which the virtual machine is cloned.
 	// +kubebuilder:validation:Minimum=1
@@ -80,17 +105,42 @@ type ProxmoxMachineSpec struct {
 	NumSockets int32 `json:"numSockets,omitempty"`
 
 	// NumCores is the number of cores per CPU socket in a virtual machine.
+	// The VM gets NumSockets × NumCores vCPUs.
 	// Defaults to the property value in the template from which the virtual machine is cloned.
 	// +kubebuilder:validation:Minimum=1
 	// +optional
 	NumCores int32 `json:"numCores,omitempty"`
 
+	// CPUType is the emulated CPU type of a virtual machine, e.g. host or x86-64-v2-AES.
+	// Defaults to the property value in the template from which the virtual machine is cloned.
+	// +optional
+	CPUType string `json:"cpuType,omitempty"`
+
+	// NUMA exposes the NUMA topology to the guest, with one NUMA node per socket.
+	// If unset, the setting of the template is kept.
+	// +optional
+	NUMA bool `json:"numa,omitempty"`
+
 	// MemoryMiB is the size of a virtual machine's memory, in MiB.
 	// Defaults to the property value in the template from which the virtual machine is cloned.
 	// +kubebuilder:validation:MultipleOf=8
 	// +optional
 	MemoryMiB int32 `json:"memoryMiB,omitempty"`
 
+	// MinMemoryMiB is the minimum size of a virtual machine's memory, in MiB.
+	// Setting it enables memory ballooning between MinMemoryMiB and MemoryMiB,
+	// otherwise ballooning is disabled.
+	// +kubebuilder:validation:MultipleOf=8
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinMemoryMiB *int32 `json:"minMemoryMiB,omitempty"`
+
+	// Hugepages backs the memory of the VM with hugepages of the given size in MiB,
+	// or with any size available on the node. MemoryMiB must be a multiple of the page size.
+	// +kubebuilder:validation:Enum=any;"2";"1024"
+	// +optional
+	Hugepages Hugepages `json:"hugepages,omitempty"`
+
 	// Disks contains a set of disk configuration options,
 	// which will be applied before the first startup.
 	//
@@ -101,6 +151,140 @@ type ProxmoxMachineSpec struct {
 	// +optional
 	Network *NetworkSpec `json:"network,omitempty"`
 
+	// NetworkConfigVersion is the version of the cloud-init network-config rendered for the VM.
+	// Use `v1` for images whose cloud-init does not understand version 2, which lacks support
+	// for VRFs, routing tables and routing policies. Defaults to `v2`.
+	// +kubebuilder:validation:Enum=v1;v2
+	// +kubebuilder:default=v2
+	// +optional
+	NetworkConfigVersion NetworkConfigVersion `json:"networkConfigVersion,omitempty"`
+
+	// CloudInitType is the layout of the cloud-init drive, which selects the datasource cloud-init reads
+	// in the guest. Use `configdrive2` for images which only search for an OpenStack config drive,
+	// their network configuration is rendered as OpenStack network data, and ignores NetworkConfigVersion.
+	// Defaults to `nocloud`.
+	// +kubebuilder:validation:Enum=nocloud;configdrive2
+	// +kubebuilder:default=nocloud
+	// +optional
+	CloudInitType CloudInitType `json:"cloudInitType,omitempty"`
+
+	// IPv4 pins the IPv4 address of the default network device, instead of claiming it
+	// from the IPv4 pool of the cluster.
+	// +optional
+	IPv4 *IPAddressSpec `json:"ipv4,omitempty"`
+
+	// IPv6 pins the IPv6 address of the default network device, instead of claiming it
+	// from the IPv6 pool of the cluster.
+	// +optional
+	IPv6 *IPAddressSpec `json:"ipv6,omitempty"`
+
+	// FirewallRules are the rules of the Proxmox firewall of the VM, evaluated in order.
+	// If set, they replace all rules of the VM firewall, which is enabled with a default inbound
+	// policy of DROP. Rules only apply to network devices with the firewall enabled.
+	// +listType=atomic
+	// +optional
+	FirewallRules []FirewallRuleSpec `json:"firewallRules,omitempty"`
+
+	// PCIDevices are host PCI devices, e.g. GPUs, passed through to the VM as hostpci0 to hostpciN.
+	// +kubebuilder:validation:MaxItems=16
+	// +optional
+	PCIDevices []PCIDeviceSpec `json:"pciDevices,omitempty"`
+
+	// EnableGuestAgent enables the QEMU guest agent of the VM.
+	// The addresses reported by the agent are added to the machine addresses,
+	// which allows to discover addresses assigned by DHCP.
+	// +optional
+	EnableGuestAgent bool `json:"enableGuestAgent,omitempty"`
+
+	// Tags are added to the Proxmox tags of the VM, together with a `cluster_<name>` tag
+	// for the owning cluster. Tags set on the VM by other means are kept.
+	// +kubebuilder:validation:items:Pattern=`^[a-zA-Z0-9_][a-zA-Z0-9_+.-]*$`
+	// +listType=set
+	// +optional
+	Tags []string `json:"tags,omitempty"`
+
+	// BIOS is the firmware of the VM. If unset, the firmware of the template is kept.
+	// OVMF enables UEFI boot and requires an EFIDisk.
+	// +kubebuilder:validation:Enum=seabios;ovmf
+	// +optional
+	BIOS BIOS `json:"bios,omitempty"`
+
+	// EFIDisk is the disk storing the EFI variables of VMs booting with OVMF.
+	// +optional
+	EFIDisk *EFIDisk `json:"efiDisk,omitempty"`
+
+	// SerialConsole adds a serial port (serial0) to the VM, which many cloud images use as console.
+	// Defaults to true for machines bootstrapped with cloud-config.
+	// A serial port of the template is not removed.
+	// +optional
+	SerialConsole *bool `json:"serialConsole,omitempty"`
+
+	// VGA is the display type of the VM. If unset, the display of the template is kept.
+	// serial0 uses the serial console as display.
+	// +kubebuilder:validation:Enum=std;cirrus;vmware;qxl;qxl2;qxl3;qxl4;virtio;virtio-gl;serial0;serial1;serial2;serial3;none
+	// +optional
+	VGA string `json:"vga,omitempty"`
+
+	// Hotplug lists the devices which can be added to the running VM.
+	// If unset, the hotplug setting of the template is kept, which defaults to network, disk and usb.
+	// Network hotplug allows to add additional network devices without a reboot.
+	// +listType=set
+	// +optional
+	Hotplug []HotplugFeature `json:"hotplug,omitempty"`
+
+	// AllowInPlaceResize applies changes of numSockets, numCores and memoryMiB to the running VM.
+	// Otherwise they only apply to VMs which were not started yet.
+	// Added memory is hot-plugged if memory hotplug and NUMA are enabled, other changes reboot the VM.
+	// +optional
+	AllowInPlaceResize bool `json:"allowInPlaceResize,omitempty"`
+
+	// SCSIController is the SCSI controller of the VM. If unset, the controller of the template is kept.
+	// +kubebuilder:validation:Enum=lsi;lsi53c810;virtio-scsi-pci;virtio-scsi-single;megasas;pvscsi
+	// +optional
+	SCSIController SCSIController `json:"scsiController,omitempty"`
+
+	// MachineType is the QEMU machine type of the VM, e.g. q35 or a versioned one like pc-q35-8.1.
+	// If unset, the machine type of the template is kept, which is i440fx (pc) unless configured otherwise.
+	// PCI express passthrough requires a q35 machine type.
+	// +kubebuilder:validation:Pattern=`^(pc|q35|pc-(i440fx|q35)-[0-9]+\.[0-9]+(\+pve[0-9]+)?)$`
+	// +optional
+	MachineType string `json:"machineType,omitempty"`
+
+	// TPM adds a TPM state device, e.g. for Windows guests.
+	// Like all disks of the VM, the TPM state is deleted together with the VM.
+	// +optional
+	TPM *TPMSpec `json:"tpm,omitempty"`
+
+	// CDROM configures the CD-ROM drive ide2 of the VM.
+	// Unlike most other settings, it is also applied to running VMs.
+	// +optional
+	CDROM *CDROMSpec `json:"cdrom,omitempty"`
+
+	// BootOrder lists the devices the VM boots from, in order, e.g. scsi0, net0 or ide2.
+	// Every device must exist in the config of the VM, which includes the devices added by this spec.
+	// If unset, the boot order of the template is kept.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:items:Pattern=`^(ide|sata|scsi|virtio|net|hostpci|usb)[0-9]+$`
+	// +listType=set
+	// +optional
+	BootOrder []string `json:"bootOrder,omitempty"`
+
+	// OnBoot starts the VM when its Proxmox node boots, so the machine comes back after a reboot of the node
+	// without waiting for the controller. Unlike most other settings, it is also applied to existing VMs.
+	// Defaults to true.
+	// +optional
+	OnBoot *bool `json:"onBoot,omitempty"`
+
+	// Startup sets the order and the delays in which the Proxmox node starts and stops the VM.
+	// Like OnBoot, it is also applied to existing VMs.
+	// +optional
+	Startup *StartupSpec `json:"startup,omitempty"`
+
+	// Protected sets the protection flag of the VM, which prevents it and its disks from being destroyed manually.
+	// The protection is removed when the machine is deleted, or Protected is unset.
+	// +optional
+	Protected bool `json:"protected,omitempty"`
+
 	// VMIDRange is the range of VMIDs to use for VMs.
 	// +optional
 	// +kubebuilder:validation:XValidation:rule="self.end >= self.start",message="end should be greater than or equal to start"
@@ -110,9 +294,300 @@ type ProxmoxMachineSpec struct {
 	// +optional
 	Checks *ProxmoxMachineChecks `json:"checks,omitempty"`
 
+	// NTPServers overrides the NTP servers of the cluster for this machine.
+	// They are ignored for machines bootstrapped with Ignition.
+	// +optional
+	// +kubebuilder:validation:MinItems=1
+	NTPServers []string `json:"ntpServers,omitempty"`
+
+	// SSHAuthorizedKeys are OpenSSH public keys authorized for the default user of this machine,
+	// in addition to the keys of the cluster and the bootstrap provider.
+	// They are ignored for machines bootstrapped with Ignition.
+	// +optional
+	// +kubebuilder:validation:MinItems=1
+	SSHAuthorizedKeys []string `json:"sshAuthorizedKeys,omitempty"`
+
+	// DisableDefaultUser keeps cloud-init from creating the default user of the image,
+	// e.g. for images which create their admin user themselves. Requires LoginUser.
+	// It is ignored for machines bootstrapped with Ignition.
+	// +optional
+	DisableDefaultUser bool `json:"disableDefaultUser,omitempty"`
+
+	// LoginUser is an existing user of the image, which the SSH authorized keys are added to
+	// instead of the default user. It is only allowed with DisableDefaultUser.
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	LoginUser string `json:"loginUser,omitempty"`
+
+	// Files are written to the VM by cloud-init before the bootstrap commands run,
+	// after the files of the bootstrap provider. They are ignored for machines bootstrapped with Ignition.
+	// +listType=map
+	// +listMapKey=path
+	// +optional
+	Files []FileSpec `json:"files,omitempty"`
+
+	// Mounts are devices which cloud-init partitions, formats and mounts on first boot,
+	// e.g. the additional volumes. They are ignored for machines bootstrapped with Ignition.
+	// +listType=map
+	// +listMapKey=device
+	// +kubebuilder:validation:XValidation:rule="self.all(m, m.fsType == 'swap' ? !has(m.mountPoint) : has(m.mountPoint))",message="mountPoint must be set, except for swap"
+	// +optional
+	Mounts []MountSpec `json:"mounts,omitempty"`
+
+	// AdditionalUserData is cloud-config merged into the user data of the bootstrap provider,
+	// e.g. to write registry mirror configs or to run scripts. Lists like write_files and runcmd
+	// are appended to the ones of the bootstrap data, other keys replace them.
+	// It is ignored for machines bootstrapped with Ignition.
+	// +optional
+	AdditionalUserData string `json:"additionalUserData,omitempty"`
+
+	// VendorData is cloud-config passed to cloud-init as vendor-data, e.g. for defaults shared by all machines.
+	// cloud-init applies it before the user data, so any key of the user data or of additionalUserData overrides it.
+	// It is ignored for machines bootstrapped with Ignition.
+	// +optional
+	VendorData string `json:"vendorData,omitempty"`
+
 	// MetadataSettings defines the metadata settings for this machine's VM.
 	// +optional
 	MetadataSettings *MetadataSettings `json:"metadataSettings,omitempty"`
+
+	// PreDeleteSnapshot takes a snapshot of the VM before the machine is deleted.
+	// Proxmox deletes the snapshots along with the VM, so combine it with RetainDisks to keep the snapshot.
+	// +optional
+	PreDeleteSnapshot bool `json:"preDeleteSnapshot,omitempty"`
+
+	// SnapshotName is the name of the pre-delete snapshot. Like the Description, it is a Go template,
+	// which can refer to the `.ClusterName`, `.Namespace`, `.MachineName` and `.Name` of the ProxmoxMachine.
+	// Characters not allowed in Proxmox snapshot names are replaced and the name is cut to 40 characters.
+	// Defaults to `capmox-predelete`.
+	// +optional
+	SnapshotName *string `json:"snapshotName,omitempty"`
+
+	// VMNameTemplate is the Go template the name of a new VM is rendered from, overriding the one of the cluster.
+	// It can refer to the `.ClusterName`, `.Namespace`, `.MachineName` and `.Name` of the ProxmoxMachine,
+	// the `.Role` of the machine, `control-plane` or `node`, and the `.ShortUID`, the first 8 characters
+	// of the UID of the ProxmoxMachine. Characters not allowed in DNS labels are replaced with dashes
+	// and the name is cut to 63 characters. VMs are named after the ProxmoxMachine if no template is set.
+	// The name is rendered once, changing the template does not rename existing VMs.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	VMNameTemplate *string `json:"vmNameTemplate,omitempty"`
+
+	// ShutdownTimeout enables shutting the guest down gracefully via ACPI, or the QEMU guest agent if enabled,
+	// when the machine is deleted. The VM is stopped forcefully if it is still running after the timeout,
+	// e.g. because the guest does not respond to ACPI. VMs are stopped forcefully right away if unset.
+	// +optional
+	ShutdownTimeout *metav1.Duration `json:"shutdownTimeout,omitempty"`
+
+	// DesiredPowerState is the power state the VM is kept in once the machine is provisioned.
+	// Powering the VM off keeps the VM, its bootstrap and the machine, the guest is shut down like on deletion,
+	// respecting the ShutdownTimeout. The VM is powered on if unset.
+	// +optional
+	// +kubebuilder:validation:Enum=on;off
+	DesiredPowerState PowerState `json:"desiredPowerState,omitempty"`
+
+	// RetainDisks keeps the VM with its disks and snapshots when the machine is deleted, instead of destroying it.
+	// The VM is stopped and tagged as retained, and is no longer managed by the machine.
+	// +optional
+	RetainDisks bool `json:"retainDisks,omitempty"`
+
+	// SnapshotTTL is how long a VM retained with a pre-delete snapshot is kept.
+	// Once it expires, the ProxmoxCluster controller destroys the VM along with its snapshots.
+	// Retained VMs are kept until they are removed manually if unset.
+	// +optional
+	SnapshotTTL *metav1.Duration `json:"snapshotTTL,omitempty"`
+}
+
+// VMDeletionPolicy decides what happens to an adopted VM when its machine is deleted.
+type VMDeletionPolicy string
+
+// Supported deletion policies.
+const (
+	VMDeletionPolicyDelete VMDeletionPolicy = "Delete"
+	VMDeletionPolicyDetach VMDeletionPolicy = "Detach"
+)
+
+// NetworkConfigVersion is the version of the cloud-init network-config schema.
+type NetworkConfigVersion string
+
+// Supported network-config versions.
+const (
+	NetworkConfigVersionV1 NetworkConfigVersion = "v1"
+	NetworkConfigVersionV2 NetworkConfigVersion = "v2"
+)
+
+// CloudInitType is the layout of the cloud-init drive of a VM.
+type CloudInitType string
+
+// Supported cloud-init types. The `opennebula` type of Proxmox is not supported,
+// as its context variables can't express the network configuration of the provider.
+const (
+	CloudInitTypeNoCloud      CloudInitType = "nocloud"
+	CloudInitTypeConfigDrive2 CloudInitType = "configdrive2"
+)
+
+// BIOS is the firmware of a VM.
+type BIOS string
+
+// Supported firmwares.
+const (
+	BIOSSeaBIOS BIOS = "seabios"
+	BIOSOVMF    BIOS = "ovmf"
+)
+
+// Hugepages is the size of the hugepages backing the memory of a VM.
+type Hugepages string
+
+// Supported hugepage sizes.
+const (
+	HugepagesAny Hugepages = "any"
+	Hugepages2M  Hugepages = "2"
+	Hugepages1G  Hugepages = "1024"
+)
+
+// EFIDisk contains the values for the EFI disk of a VM.
+type EFIDisk struct {
+	// Storage is the Proxmox storage the EFI disk is created on.
+	// +kubebuilder:validation:MinLength=1
+	Storage string `json:"storage"`
+
+	// PreEnrolledKeys enrolls the default distribution and Microsoft keys,
+	// which enables Secure Boot.
+	// +optional
+	PreEnrolledKeys bool `json:"preEnrolledKeys,omitempty"`
+}
+
+// TPMVersion is the version of a TPM.
+type TPMVersion string
+
+// Supported TPM versions.
+const (
+	TPMVersion12 TPMVersion = "1.2"
+	TPMVersion20 TPMVersion = "2.0"
+)
+
+// TPMSpec contains the values for the TPM state device of a VM.
+type TPMSpec struct {
+	// Version is the version of the TPM.
+	// +kubebuilder:validation:Enum="1.2";"2.0"
+	// +kubebuilder:default="2.0"
+	// +optional
+	Version TPMVersion `json:"version,omitempty"`
+
+	// StoragePool is the Proxmox storage the TPM state is created on.
+	// +kubebuilder:validation:MinLength=1
+	StoragePool string `json:"storagePool"`
+}
+
+// CDROMSpec contains the values for the CD-ROM drive of a VM.
+type CDROMSpec struct {
+	// ISO is the volume in the drive. This is either an ISO image like `local:iso/drivers.iso`,
+	// which must exist on the node of the VM, the Proxmox cloud-init drive on a storage like
+	// `local-lvm:cloudinit`, or `none` for an empty drive.
+	// +kubebuilder:validation:Pattern=`^(none|[a-zA-Z0-9][a-zA-Z0-9_.-]*:(cloudinit|iso/.+))$`
+	ISO string `json:"iso"`
+}
+
+// FileSpec is a file written to the VM by cloud-init.
+// +kubebuilder:validation:XValidation:rule="has(self.content) != has(self.contentFrom)",message="exactly one of content or contentFrom must be set"
+type FileSpec struct {
+	// Path is the absolute path of the file.
+	// +kubebuilder:validation:Pattern=`^/.+`
+	Path string `json:"path"`
+
+	// Content is the content of the file.
+	// +optional
+	Content *string `json:"content,omitempty"`
+
+	// ContentFrom reads the content of the file from a Secret or ConfigMap in the namespace of the machine,
+	// e.g. for sensitive values.
+	// +optional
+	ContentFrom *FileContentSource `json:"contentFrom,omitempty"`
+
+	// Permissions are the permissions of the file in octal notation, e.g. 0644.
+	// +kubebuilder:validation:Pattern=`^0?[0-7]{3,4}$`
+	// +optional
+	Permissions string `json:"permissions,omitempty"`
+
+	// Owner is the owner of the file, e.g. root:root.
+	// +optional
+	Owner string `json:"owner,omitempty"`
+}
+
+// MountSpec is a device which is partitioned, formatted and mounted by cloud-init.
+type MountSpec struct {
+	// Device is the block device, e.g. /dev/disk/by-id/scsi-0QEMU_QEMU_HARDDISK_drive-scsi1 for the
+	// disk attached as scsi1. It gets a single partition, unless it already has a partition table.
+	// +kubebuilder:validation:Pattern=`^/dev/.+`
+	Device string `json:"device"`
+
+	// FSType is the filesystem the partition is formatted with,
+	// unless it already has one.
+	// +kubebuilder:validation:Enum=ext4;xfs;btrfs;swap
+	FSType FilesystemType `json:"fsType"`
+
+	// MountPoint is the absolute path the filesystem is mounted at. It must not be set for swap.
+	// +kubebuilder:validation:Pattern=`^/.+`
+	// +optional
+	MountPoint string `json:"mountPoint,omitempty"`
+
+	// Options are the mount options. Defaults to defaults,nofail, or sw for swap.
+	// +optional
+	Options string `json:"options,omitempty"`
+}
+
+// FilesystemType is the filesystem of a mount.
+type FilesystemType string
+
+// Supported filesystems.
+const (
+	FilesystemExt4  FilesystemType = "ext4"
+	FilesystemXFS   FilesystemType = "xfs"
+	FilesystemBtrfs FilesystemType = "btrfs"
+	FilesystemSwap  FilesystemType = "swap"
+)
+
+// FileContentSource references a key of a Secret or ConfigMap.
+type FileContentSource struct {
+	// Kind is the kind of the referenced object.
+	// +kubebuilder:validation:Enum=Secret;ConfigMap
+	Kind FileContentSourceKind `json:"kind"`
+
+	// Name is the name of the referenced object.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Key is the key holding the content.
+	// +kubebuilder:validation:MinLength=1
+	Key string `json:"key"`
+}
+
+// FileContentSourceKind is the kind of object the content of a file is read from.
+type FileContentSourceKind string
+
+// Supported kinds of file content sources.
+const (
+	FileContentSourceSecret    FileContentSourceKind = "Secret"
+	FileContentSourceConfigMap FileContentSourceKind = "ConfigMap"
+)
+
+// StartupSpec is the startup and shutdown behavior of a VM when its Proxmox node boots or shuts down.
+// +kubebuilder:validation:XValidation:rule="has(self.order) || has(self.up) || has(self.down)",message="at least one of order, up or down must be set"
+type StartupSpec struct {
+	// Order is the position of the VM in the startup order. VMs are shut down in the reverse order.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Order *int32 `json:"order,omitempty"`
+
+	// Up is the delay in seconds before the next VM is started.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Up *int32 `json:"up,omitempty"`
+
+	// Down is the timeout in seconds to wait for the VM to shut down.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Down *int32 `json:"down,omitempty"`
 }
 
 // Storage is the physical storage on the node.
@@ -120,13 +595,179 @@ type Storage struct {
 	// BootVolume defines the storage size for the boot volume.
 	// This field is optional, and should only be set if you want
 	// to change the size of the boot volume.
-	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="Value is immutable"
+	// Only the throttling can be changed later on.
+	// +kubebuilder:validation:XValidation:rule="self.disk == oldSelf.disk && self.sizeGb == oldSelf.sizeGb",message="Value is immutable"
 	// +optional
 	BootVolume *DiskSize `json:"bootVolume,omitempty"`
 
-	// TODO Intended to add handling for additional volumes,
-	// which will be added to the node.
-	// e.g. AdditionalVolumes []DiskSize.
+	// AdditionalVolumes defines additional disks, which are created on the given
+	// storage and attached as scsi1 to scsiN in order.
+	// The VM is not cloned if the template already uses one of these slots.
+	// The disks are removed together with the VM. Only the throttling can be changed later on.
+	// +kubebuilder:validation:XValidation:rule="self.map(d, d.sizeGb) == oldSelf.map(d, d.sizeGb) && self.map(d, d.storagePool) == oldSelf.map(d, d.storagePool) && self.map(d, has(d.format)) == oldSelf.map(d, has(d.format)) && self.filter(d, has(d.format)).map(d, d.format) == oldSelf.filter(d, has(d.format)).map(d, d.format)",message="Value is immutable"
+	// +kubebuilder:validation:MaxItems=30
+	// +optional
+	AdditionalVolumes []DiskSpec `json:"additionalVolumes,omitempty"`
+}
+
+// DiskSpec contains the values for an additional disk.
+type DiskSpec struct {
+	// SizeGB defines the size in gigabyte.
+	// +kubebuilder:validation:Minimum=1
+	SizeGB int32 `json:"sizeGb"`
+
+	// StoragePool is the Proxmox storage the disk is created on.
+	// +kubebuilder:validation:MinLength=1
+	StoragePool string `json:"storagePool"`
+
+	// Format is the disk format. Only applies to file based storages.
+	// +kubebuilder:validation:Enum=raw;qcow2;vmdk
+	// +optional
+	Format *TargetFileStorageFormat `json:"format,omitempty"`
+
+	// Cache is the cache mode of the disk. If unset, the default of Proxmox is used.
+	// +kubebuilder:validation:Enum=none;writethrough;writeback;unsafe;directsync
+	// +optional
+	Cache DiskCache `json:"cache,omitempty"`
+
+	// Discard passes TRIM requests of the guest to the storage,
+	// which frees space on thin-provisioned storages.
+	// +optional
+	Discard bool `json:"discard,omitempty"`
+
+	// SSDEmulation presents the disk as solid-state drive to the guest.
+	// +optional
+	SSDEmulation bool `json:"ssd,omitempty"`
+
+	// IOThread gives the disk its own IO thread.
+	// SCSI disks require the virtio-scsi-single controller.
+	// +optional
+	IOThread bool `json:"iothread,omitempty"`
+
+	// Throttle limits the IO of the disk.
+	// +optional
+	Throttle *DiskThrottle `json:"throttle,omitempty"`
+}
+
+// HotplugFeature is a kind of device which can be hot-plugged into a running VM.
+// +kubebuilder:validation:Enum=network;disk;usb;memory;cpu;cloudinit
+type HotplugFeature string
+
+// Supported hotplug features.
+const (
+	HotplugNetwork   HotplugFeature = "network"
+	HotplugDisk      HotplugFeature = "disk"
+	HotplugUSB       HotplugFeature = "usb"
+	HotplugMemory    HotplugFeature = "memory"
+	HotplugCPU       HotplugFeature = "cpu"
+	HotplugCloudInit HotplugFeature = "cloudinit"
+)
+
+// SCSIController is the SCSI controller of a VM.
+type SCSIController string
+
+// Supported SCSI controllers.
+const (
+	SCSIControllerLSI               SCSIController = "lsi"
+	SCSIControllerLSI53C810         SCSIController = "lsi53c810"
+	SCSIControllerVirtIO            SCSIController = "virtio-scsi-pci"
+	SCSIControllerVirtIOSingle      SCSIController = "virtio-scsi-single"
+	SCSIControllerMegaRAID          SCSIController = "megasas"
+	SCSIControllerVMwareParavirtual SCSIController = "pvscsi"
+)
+
+// DiskCache is the cache mode of a disk.
+type DiskCache string
+
+// Supported cache modes.
+const (
+	DiskCacheNone         DiskCache = "none"
+	DiskCacheWriteThrough DiskCache = "writethrough"
+	DiskCacheWriteBack    DiskCache = "writeback"
+	DiskCacheUnsafe       DiskCache = "unsafe"
+	DiskCacheDirectSync   DiskCache = "directsync"
+)
+
+// DiskThrottle limits the IO of a disk. Unset limits are removed from the disk,
+// an empty throttle removes all limits.
+type DiskThrottle struct {
+	// IOPSRead is the maximum number of read operations per second.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	IOPSRead *int32 `json:"iopsRead,omitempty"`
+
+	// IOPSWrite is the maximum number of write operations per second.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	IOPSWrite *int32 `json:"iopsWrite,omitempty"`
+
+	// MBpsRead is the maximum read throughput in MB/s.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MBpsRead *int32 `json:"mbpsRead,omitempty"`
+
+	// MBpsWrite is the maximum write throughput in MB/s.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MBpsWrite *int32 `json:"mbpsWrite,omitempty"`
+}
+
+// PCIDeviceSpec defines a host PCI device passed through to the VM.
+// Resource mappings should be preferred, as they allow to place the VM on any node
+// providing the mapping. Raw device ids require the VM to be pinned to a target node.
+// +kubebuilder:validation:XValidation:rule="has(self.mapping) != has(self.deviceID)",message="exactly one of mapping or deviceID must be set"
+type PCIDeviceSpec struct {
+	// Mapping is the name of a Proxmox PCI resource mapping.
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	Mapping *string `json:"mapping,omitempty"`
+
+	// DeviceID is the raw host PCI id, e.g. 0000:01:00.0.
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	DeviceID *string `json:"deviceID,omitempty"`
+
+	// PCIE passes the device as PCI express device. Requires the q35 machine type.
+	// +optional
+	PCIE bool `json:"pcie,omitempty"`
+
+	// ROMBar makes the firmware ROM visible to the guest. Proxmox enables it by default.
+	// +optional
+	ROMBar *bool `json:"romBar,omitempty"`
+
+	// PrimaryGPU marks the device as primary GPU of the VM (x-vga).
+	// +optional
+	PrimaryGPU bool `json:"primaryGPU,omitempty"`
+}
+
+// FirewallRuleSpec defines a rule of the Proxmox VM firewall.
+type FirewallRuleSpec struct {
+	// Direction is the direction of the traffic the rule matches.
+	// +kubebuilder:validation:Enum=in;out
+	Direction string `json:"direction"`
+
+	// Action is applied to matching traffic.
+	// +kubebuilder:validation:Enum=ACCEPT;DROP;REJECT
+	// +kubebuilder:default=ACCEPT
+	// +optional
+	Action string `json:"action,omitempty"`
+
+	// Protocol is the IP protocol of the traffic, given by name like tcp, udp or icmp, or by number.
+	// +kubebuilder:validation:Pattern=`^([a-z][a-z0-9-]*|[0-9]+)$`
+	// +optional
+	Protocol string `json:"protocol,omitempty"`
+
+	// Port is the destination port or port range, e.g. 6443 or 30000:32767. Requires tcp or udp.
+	// +optional
+	Port string `json:"port,omitempty"`
+
+	// Source is the source address or CIDR of the traffic.
+	// +optional
+	Source string `json:"source,omitempty"`
+
+	// Destination is the destination address or CIDR of the traffic.
+	// +optional
+	Destination string `json:"destination,omitempty"`
 }
 
 // DiskSize is contains values for the disk device and size.
@@ -143,6 +784,29 @@ type DiskSize struct {
 	//
 	// +kubebuilder:validation:Minimum=5
 	SizeGB int32 `json:"sizeGb"`
+
+	// Cache is the cache mode of the disk. If unset, the cache mode is not changed.
+	// +kubebuilder:validation:Enum=none;writethrough;writeback;unsafe;directsync
+	// +optional
+	Cache DiskCache `json:"cache,omitempty"`
+
+	// Discard passes TRIM requests of the guest to the storage,
+	// which frees space on thin-provisioned storages.
+	// +optional
+	Discard bool `json:"discard,omitempty"`
+
+	// SSDEmulation presents the disk as solid-state drive to the guest.
+	// +optional
+	SSDEmulation bool `json:"ssd,omitempty"`
+
+	// IOThread gives the disk its own IO thread.
+	// SCSI disks require the virtio-scsi-single controller.
+	// +optional
+	IOThread bool `json:"iothread,omitempty"`
+
+	// Throttle limits the IO of the disk.
+	// +optional
+	Throttle *DiskThrottle `json:"throttle,omitempty"`
 }
 
 // TargetFileStorageFormat the target format of the cloned disk.
@@ -165,8 +829,8 @@ type VirtualMachineCloneSpec struct {
 	// Setting the `Target` field will tell Proxmox to clone the
 	// VM on that target node.
 	//
-	// When Target is not set and the ProxmoxCluster contains
-	// a set of `AllowedNodes`, the algorithm will instead evenly
+	// When Target is not set and the ProxmoxMachine or the ProxmoxCluster
+	// contains a set of `AllowedNodes`, the algorithm will instead evenly
 	// distribute the VMs across the nodes from that list.
 	//
 	// If neither a `Target` nor `AllowedNodes` was set, the VM
@@ -179,7 +843,16 @@ type VirtualMachineCloneSpec struct {
 	// +optional
 	TemplateID *int32 `json:"templateID,omitempty"`
 
-	// Description for the new VM.
+	// TemplateName is the name of the vm_template used for cloning a new VM, instead of its TemplateID.
+	// If templates of that name exist on several nodes, the one on the target node is used,
+	// or else the one on the SourceNode.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	TemplateName *string `json:"templateName,omitempty"`
+
+	// Description for the new VM. It is kept up to date on the VM.
+	// The description is a Go template, which can refer to the `.ClusterName`,
+	// `.Namespace`, `.MachineName` and `.Name` of the ProxmoxMachine.
 	// +optional
 	Description *string `json:"description,omitempty"`
 
@@ -192,11 +865,14 @@ type VirtualMachineCloneSpec struct {
 	// Full Create a full copy of all disks.
 	// This is always done when you clone a normal VM.
 	// Create a Full clone by default.
+	// Setting it to false creates a linked clone, which requires the source
+	// to be a template and keeps the disks on the storage of the template.
 	// +kubebuilder:default=true
 	// +optional
 	Full *bool `json:"full,omitempty"`
 
 	// Pool Add the new VM to the specified pool.
+	// Overrides the pool of the ProxmoxCluster. The pool must exist.
 	// +optional
 	Pool *string `json:"pool,omitempty"`
 
@@ -204,13 +880,21 @@ type VirtualMachineCloneSpec struct {
 	// +optional
 	SnapName *string `json:"snapName,omitempty"`
 
-	// Storage for full clone.
+	// Storage for full clone. The disks of the clone are placed on it, instead of the storage of the template.
+	// Overrides the storage of the failure domain and of the ProxmoxCluster.
+	// The storage must be active on the node the VM is cloned to and hold VM images.
 	// +optional
 	Storage *string `json:"storage,omitempty"`
 
 	// Target node. Only allowed if the original VM is on shared storage.
 	// +optional
 	Target *string `json:"target,omitempty"`
+
+	// AllowedNodes restricts the Proxmox nodes this machine can be scheduled on.
+	// It narrows down the AllowedNodes of the ProxmoxCluster, nodes which are
+	// not allowed by the cluster are never used.
+	// +optional
+	AllowedNodes []string `json:"allowedNodes,omitempty"`
 }
 
 // NetworkSpec defines the virtual machine's network configuration.
@@ -221,6 +905,19 @@ type NetworkSpec struct {
 	// +optional
 	Default *NetworkDevice `json:"default,omitempty"`
 
+	// Routes are the static routes of the default network device.
+	// They live here rather than on NetworkDevice, as NetworkDevice is shared with
+	// AdditionalDevices, which already carry their routes in their interface config
+	// (`additionalDevices[].routes`).
+	// +optional
+	// +kubebuilder:validation:MinItems=1
+	Routes []RouteSpec `json:"routes,omitempty"`
+
+	// SearchDomains overrides the DNS search domains of the cluster for this machine.
+	// +optional
+	// +kubebuilder:validation:MinItems=1
+	SearchDomains []string `json:"searchDomains,omitempty"`
+
 	// AdditionalDevices defines additional network devices bound to the virtual machine.
 	// +optional
 	// +listType=map
@@ -340,11 +1037,126 @@ type VirtualNetworkDevices struct {
 	// +listType=map
 	// +listMapKey=name
 	VRFs []VRFDevice `json:"vrfs,omitempty"`
+
+	// Bonds aggregate network devices of the guest into a bond.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Bonds []BondSpec `json:"bonds,omitempty"`
+
+	// VLANs are 802.1q sub-interfaces of network devices or bonds in the guest.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	VLANs []GuestVLANSpec `json:"vlans,omitempty"`
+
+	// Bridges are Linux bridges of network devices or bonds in the guest.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Bridges []GuestBridgeSpec `json:"bridges,omitempty"`
+}
+
+// BondSpec defines a Linux bond of network devices in the guest.
+// The bond takes over the addresses, routes and nameservers of its first interface,
+// its members are left without addresses.
+type BondSpec struct {
+	// Name is the name of the bond in the guest.
+	// Must be unique within the virtual machine.
+	// +kubebuilder:validation:MinLength=3
+	Name string `json:"name"`
+
+	// Interfaces is the list of proxmox network devices aggregated by the bond, e.g. net0 and net1.
+	// +kubebuilder:validation:MinItems=1
+	Interfaces []string `json:"interfaces"`
+
+	// Mode is the bonding mode.
+	// +kubebuilder:validation:Enum=balance-rr;active-backup;balance-xor;broadcast;802.3ad;balance-tlb;balance-alb
+	Mode BondMode `json:"mode"`
+
+	// MIIMonitorInterval is the interval in milliseconds in which the link state of the interfaces is checked.
+	// +optional
+	MIIMonitorInterval *uint32 `json:"miimon,omitempty"`
+}
+
+// PowerState is the desired power state of a VM.
+type PowerState string
+
+const (
+	// PowerStateOn keeps the VM running.
+	PowerStateOn PowerState = "on"
+
+	// PowerStateOff keeps the VM stopped.
+	PowerStateOff PowerState = "off"
+)
+
+// BondMode is the bonding mode of a bond.
+type BondMode string
+
+// Supported bonding modes.
+const (
+	BondModeBalanceRR    BondMode = "balance-rr"
+	BondModeActiveBackup BondMode = "active-backup"
+	BondModeBalanceXOR   BondMode = "balance-xor"
+	BondModeBroadcast    BondMode = "broadcast"
+	BondMode8023AD       BondMode = "802.3ad"
+	BondModeBalanceTLB   BondMode = "balance-tlb"
+	BondModeBalanceALB   BondMode = "balance-alb"
+)
+
+// GuestBridgeSpec defines a Linux bridge in the guest, not to be confused with the bridge of a network device.
+// The bridge takes over the addresses, routes and nameservers of its first interface,
+// its members are left without addresses.
+type GuestBridgeSpec struct {
+	// Name is the name of the bridge in the guest.
+	// Must be unique within the virtual machine.
+	// +kubebuilder:validation:MinLength=3
+	Name string `json:"name"`
+
+	// Interfaces is the list of proxmox network devices and bonds attached to the bridge, e.g. net0 and bond0.
+	// +kubebuilder:validation:MinItems=1
+	Interfaces []string `json:"interfaces"`
+
+	// STP enables the spanning tree protocol on the bridge.
+	// +optional
+	STP *bool `json:"stp,omitempty"`
+
+	// ForwardDelay is the time in seconds the bridge spends in the listening and learning states.
+	// +optional
+	// +kubebuilder:validation:Maximum=30
+	ForwardDelay *uint32 `json:"forwardDelay,omitempty"`
+}
+
+// GuestVLANSpec defines an 802.1q VLAN sub-interface in the guest.
+// Unlike the VLAN of a network device, the traffic is tagged by the guest.
+type GuestVLANSpec struct {
+	// Name is the name of the VLAN interface in the guest, e.g. eth0.100.
+	// Must be unique within the virtual machine.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Link is the parent interface of the VLAN, either a proxmox network device like net0 or a bond.
+	// +kubebuilder:validation:MinLength=1
+	Link string `json:"link"`
+
+	// ID is the VLAN id.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=4094
+	ID uint16 `json:"id"`
+
+	// IPv4 is the static IPv4 address of the VLAN interface.
+	// +optional
+	IPv4 *IPAddressSpec `json:"ipv4,omitempty"`
+
+	// IPv6 is the static IPv6 address of the VLAN interface.
+	// +optional
+	IPv6 *IPAddressSpec `json:"ipv6,omitempty"`
 }
 
 // NetworkDevice defines the required details of a virtual machine network device.
 type NetworkDevice struct {
 	// Bridge is the network bridge to attach to the machine.
+	// Bridges which are not named vmbrN are VNets of the Proxmox SDN.
 	// +kubebuilder:validation:MinLength=1
 	Bridge string `json:"bridge"`
 
@@ -354,25 +1166,79 @@ type NetworkDevice struct {
 	// +kubebuilder:default=virtio
 	Model *string `json:"model,omitempty"`
 
+	// MACAddress is the MAC address of the network device, rendered as `<model>=<mac>` on the device.
+	// Proxmox generates a random one if omitted. Multicast and broadcast addresses are rejected.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^([0-9A-Fa-f]{2}:){5}[0-9A-Fa-f]{2}$`
+	MACAddress string `json:"macAddress,omitempty"`
+
 	// MTU is the network device Maximum Transmission Unit.
 	// When set to 1, virtio devices inherit the MTU value from the underlying bridge.
 	// +optional
 	MTU MTU `json:"mtu,omitempty"`
 
-	// VLAN is the network L2 VLAN.
+	// VLAN is the network L2 VLAN tag, rendered as `tag=<vlan>` on the device.
+	// Every device carries its own tag. Omit the field for untagged traffic.
 	// +optional
 	// +kubebuilder:validation:Minimum=1
 	// +kubebuilder:validation:Maximum=4094
 	VLAN *uint16 `json:"vlan,omitempty"`
+
+	// Firewall enables the Proxmox firewall on the device, rendered as `firewall=1` on the device.
+	// See FirewallRules of the ProxmoxMachine.
+	// +optional
+	Firewall bool `json:"firewall,omitempty"`
+
+	// Queues is the number of packet queues of the device, rendered as `queues=<n>` on the device.
+	// Only virtio devices support multiple queues. Match it to the number of vCPUs of the guest,
+	// which has to enable multiqueue with `ethtool -L <interface> combined <n>`.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=64
+	Queues *uint8 `json:"queues,omitempty"`
+
+	// RateLimitMBps caps the throughput of the device in MB/s, rendered as `rate=<limit>` on the device.
+	// Fractional values like 12.5 are allowed. Changes are also applied to running VMs.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^([0-9]*[1-9][0-9]*(\.[0-9]+)?|[0-9]+\.[0-9]*[1-9][0-9]*)$`
+	RateLimitMBps *string `json:"rateLimitMBps,omitempty"`
+
+	// IPv6Mode is the way the guest configures the IPv6 address of the device.
+	// In static mode the address is taken from the IPv6 pool, or the static IPv6 address of the default device.
+	// In dhcp and slaac mode, no address is claimed and the guest acquires it through DHCPv6
+	// or router advertisements instead. Defaults to static.
+	// +optional
+	IPv6Mode IPv6Mode `json:"ipv6Mode,omitempty"`
+
+	// DHCP4 configures the guest to acquire the IPv4 address of the device through DHCP.
+	// No address is claimed for the device. The address is only known to the controller
+	// if the QEMU guest agent is enabled.
+	// +optional
+	DHCP4 bool `json:"dhcp4,omitempty"`
 }
 
+// IPv6Mode is the way a guest configures the IPv6 address of a network device.
+// +kubebuilder:validation:Enum=static;dhcp;slaac
+type IPv6Mode string
+
+const (
+	// IPv6ModeStatic configures the address claimed from an IP pool.
+	IPv6ModeStatic IPv6Mode = "static"
+
+	// IPv6ModeDHCP acquires the address through DHCPv6.
+	IPv6ModeDHCP IPv6Mode = "dhcp"
+
+	// IPv6ModeSLAAC derives the address from router advertisements.
+	IPv6ModeSLAAC IPv6Mode = "slaac"
+)
+
 // MTU is the network device Maximum Transmission Unit. MTUs below 1280 break IPv6.
 // +optional
 // +kubebuilder:validation:XValidation:rule="self == 1 || ( self >= 576 && self <= 65520)",message="invalid MTU value"
 type MTU *uint16
 
 // AdditionalNetworkDevice the definition of a Proxmox network device.
-// +kubebuilder:validation:XValidation:rule="self.ipv4PoolRef != null || self.ipv6PoolRef != null",message="at least one pool reference must be set, either ipv4PoolRef or ipv6PoolRef"
+// +kubebuilder:validation:XValidation:rule="self.ipv4PoolRef != null || self.ipv6PoolRef != null || (has(self.dhcp4) && self.dhcp4) || (has(self.ipv6Mode) && self.ipv6Mode != 'static')",message="at least one pool reference must be set, either ipv4PoolRef or ipv6PoolRef, unless dhcp4 is enabled or ipv6Mode is dhcp or slaac"
 type AdditionalNetworkDevice struct {
 	NetworkDevice `json:",inline"`
 
@@ -401,10 +1267,29 @@ type ProxmoxMachineStatus struct {
 	// +optional
 	VMStatus VirtualMachineState `json:"vmStatus,omitempty"`
 
+	// PowerState is the power state of the virtual machine as reported by Proxmox,
+	// e.g. running, paused or stopped.
+	// +optional
+	PowerState string `json:"powerState,omitempty"`
+
 	// BootstrapDataProvided whether the virtual machine has an injected bootstrap data.
 	// +optional
 	BootstrapDataProvided *bool `json:"bootstrapDataProvided,omitempty"`
 
+	// AllocatedVMID is the VMID allocated from the VMIDRange for the VM to be cloned to.
+	// It is reused by later attempts to clone the VM as long as it is free.
+	// +optional
+	AllocatedVMID *int64 `json:"allocatedVMID,omitempty"`
+
+	// VMName is the name of the VM in Proxmox, rendered from the VMNameTemplate when the VM was cloned.
+	// It is empty for VMs named after the ProxmoxMachine.
+	// +optional
+	VMName string `json:"vmName,omitempty"`
+
+	// Adopted is set if the VM existed before and was adopted with ExistingVMID.
+	// +optional
+	Adopted bool `json:"adopted,omitempty"`
+
 	// IPAddresses are the IP addresses used to access the virtual machine.
 	// +optional
 	IPAddresses map[string]IPAddress `json:"ipAddresses,omitempty"`
@@ -415,10 +1300,14 @@ type ProxmoxMachineStatus struct {
 	Network []NetworkStatus `json:"network,omitempty"`
 
 	// ProxmoxNode is the name of the proxmox node, which was chosen for this
-	// machine to be deployed on.
+	// machine to be deployed on. It follows the VM when it is migrated.
 	// +optional
 	ProxmoxNode *string `json:"proxmoxNode,omitempty"`
 
+	// VMID is the Proxmox identifier of the machine's VM.
+	// +optional
+	VMID *int64 `json:"vmID,omitempty"`
+
 	// TaskRef is a managed object reference to a Task related to the ProxmoxMachine.
 	// This value is set automatically at runtime and should not be set or
 	// modified by users.
@@ -483,6 +1372,21 @@ type IPAddress struct {
 	IPV6 string `json:"ipv6,omitempty"`
 }
 
+// IPAddressSpec is a static IP address of a network device.
+type IPAddressSpec struct {
+	// Address is the IP address.
+	// +kubebuilder:validation:MinLength=1
+	Address string `json:"address"`
+
+	// Prefix is the network prefix to use.
+	// +kubebuilder:validation:Maximum=128
+	Prefix int `json:"prefix"`
+
+	// Gateway is the default gateway of the network.
+	// +optional
+	Gateway string `json:"gateway,omitempty"`
+}
+
 // VMIDRange defines the range of VMIDs to use for VMs.
 type VMIDRange struct {
 	// VMIDRangeStart is the start of the VMID range to use for VMs.
@@ -518,6 +1422,10 @@ type MetadataSettings struct {
 // +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".metadata.labels.cluster\\.x-k8s\\.io/cluster-name",description="Cluster to which this ProxmoxMachine belongs"
 // +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.ready",description="Machine ready status"
 // +kubebuilder:printcolumn:name="Node",type="string",JSONPath=".status.proxmoxNode",description="Proxmox Node that the machine was deployed on"
+// +kubebuilder:printcolumn:name="VMID",type="integer",JSONPath=".status.vmID",description="Proxmox VMID of the machine"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.powerState",description="Power state of the VM"
+// +kubebuilder:printcolumn:name="IP",type="string",JSONPath=".status.ipAddresses.net0.ipv4",description="IPv4 address of the default network device"
+// +kubebuilder:printcolumn:name="IPv6",type="string",JSONPath=".status.ipAddresses.net0.ipv6",description="IPv6 address of the default network device",priority=1
 // +kubebuilder:printcolumn:name="Provider_ID",type="string",JSONPath=".spec.providerID",description="Provider ID"
 // +kubebuilder:printcolumn:name="Machine",type="string",JSONPath=".metadata.ownerReferences[?(@.kind==\"Machine\")].name",description="Machine object which owns with this ProxmoxMachine"
 
@@ -71,6 +71,51 @@ var _ = Describe("ProxmoxMachine Test", func() {
 		})
 	})
 
+	Context("CPU", func() {
+		It("Should not allow less than one socket", func() {
+			dm := defaultMachine()
+			dm.Spec.NumSockets = -1
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("spec.numSockets in body should be greater than or equal to 1")))
+		})
+		It("Should not allow less than one core", func() {
+			dm := defaultMachine()
+			dm.Spec.NumCores = -1
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("spec.numCores in body should be greater than or equal to 1")))
+		})
+	})
+
+	Context("SCSIController", func() {
+		It("Should not allow unknown controllers", func() {
+			dm := defaultMachine()
+			dm.Spec.SCSIController = "virtio-blk"
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("spec.scsiController: Unsupported value")))
+		})
+	})
+
+	Context("VGA", func() {
+		It("Should not allow unknown display types", func() {
+			dm := defaultMachine()
+			dm.Spec.VGA = "vga"
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("spec.vga: Unsupported value")))
+		})
+	})
+
+	Context("Hotplug", func() {
+		It("Should not allow unknown hotplug features", func() {
+			dm := defaultMachine()
+			dm.Spec.Hotplug = []HotplugFeature{HotplugNetwork, "pci"}
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("spec.hotplug[1]: Unsupported value")))
+		})
+	})
+
+	Context("Hugepages", func() {
+		It("Should not allow unknown hugepage sizes", func() {
+			dm := defaultMachine()
+			dm.Spec.Hugepages = "4"
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("spec.hugepages: Unsupported value")))
+		})
+	})
+
 	Context("Disks", func() {
 		It("Should not allow updates to disks", func() {
 			dm := defaultMachine()
@@ -79,6 +124,25 @@ var _ = Describe("ProxmoxMachine Test", func() {
 			Expect(k8sClient.Update(context.Background(), dm)).Should(MatchError(ContainSubstring("is immutable")))
 		})
 
+		It("Should allow updates to the disk throttle", func() {
+			dm := defaultMachine()
+			dm.Spec.Disks.AdditionalVolumes = []DiskSpec{{SizeGB: 50, StoragePool: "local-lvm"}}
+			Expect(k8sClient.Create(context.Background(), dm)).To(Succeed())
+
+			dm.Spec.Disks.BootVolume.Throttle = &DiskThrottle{IOPSRead: ptr.To(int32(500))}
+			dm.Spec.Disks.AdditionalVolumes[0].Throttle = &DiskThrottle{MBpsWrite: ptr.To(int32(100))}
+			Expect(k8sClient.Update(context.Background(), dm)).To(Succeed())
+
+			dm.Spec.Disks.AdditionalVolumes[0].StoragePool = "ceph"
+			Expect(k8sClient.Update(context.Background(), dm)).Should(MatchError(ContainSubstring("is immutable")))
+		})
+
+		It("Should not allow unknown cache modes", func() {
+			dm := defaultMachine()
+			dm.Spec.Disks.BootVolume.Cache = "writearound"
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("spec.disks.bootVolume.cache: Unsupported value")))
+		})
+
 		It("Should not allow negative or less than minimum values", func() {
 			dm := defaultMachine()
 
@@ -91,6 +155,17 @@ var _ = Describe("ProxmoxMachine Test", func() {
 	})
 
 	Context("Network", func() {
+		It("Should only allow positive rate limits", func() {
+			dm := defaultMachine()
+			dm.Spec.Network = &NetworkSpec{
+				Default: &NetworkDevice{Bridge: "vmbr0", RateLimitMBps: ptr.To("0.0")},
+			}
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("spec.network.default.rateLimitMBps in body should match")))
+
+			dm.Spec.Network.Default.RateLimitMBps = ptr.To("12.5")
+			Expect(k8sClient.Create(context.Background(), dm)).To(Succeed())
+		})
+
 		It("Should set default bridge", func() {
 			dm := defaultMachine()
 			dm.Spec.Network = &NetworkSpec{
@@ -297,6 +372,59 @@ var _ = Describe("ProxmoxMachine Test", func() {
 		})
 	})
 
+	Context("Tags", func() {
+		It("Should not allow tags with invalid characters", func() {
+			dm := defaultMachine()
+			dm.Spec.Tags = []string{"capi", "team;a"}
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("spec.tags[1] in body should match")))
+		})
+		It("Should not allow tags starting with a dash", func() {
+			dm := defaultMachine()
+			dm.Spec.Tags = []string{"-capi"}
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("spec.tags[0] in body should match")))
+		})
+	})
+
+	Context("TPM", func() {
+		It("Should default the TPM version to 2.0", func() {
+			dm := defaultMachine()
+			dm.Spec.TPM = &TPMSpec{StoragePool: "local-lvm"}
+			Expect(k8sClient.Create(context.Background(), dm)).To(Succeed())
+			Expect(dm.Spec.TPM.Version).To(Equal(TPMVersion20))
+		})
+		It("Should not allow unknown TPM versions", func() {
+			dm := defaultMachine()
+			dm.Spec.TPM = &TPMSpec{StoragePool: "local-lvm", Version: "3.0"}
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("spec.tpm.version: Unsupported value")))
+		})
+		It("Should require a storage pool for the TPM", func() {
+			dm := defaultMachine()
+			dm.Spec.TPM = &TPMSpec{Version: TPMVersion12}
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("spec.tpm.storagePool")))
+		})
+	})
+
+	Context("CDROM", func() {
+		It("Should not allow volumes other than iso images, cloudinit or none", func() {
+			dm := defaultMachine()
+			dm.Spec.CDROM = &CDROMSpec{ISO: "local-lvm:vm-100-disk-0"}
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("spec.cdrom.iso in body should match")))
+		})
+	})
+
+	Context("MachineType", func() {
+		It("Should allow versioned machine types", func() {
+			dm := defaultMachine()
+			dm.Spec.MachineType = "pc-q35-8.1"
+			Expect(k8sClient.Create(context.Background(), dm)).To(Succeed())
+		})
+		It("Should not allow unknown machine types", func() {
+			dm := defaultMachine()
+			dm.Spec.MachineType = "virt"
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("spec.machineType in body should match")))
+		})
+	})
+
 	Context("VMIDRange", func() {
 		It("Should only allow spec.vmIDRange.start >= 100", func() {
 			dm := defaultMachine()
@@ -6,6 +6,7 @@ package v1alpha1
 
 import (
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/errors"
@@ -28,9 +29,96 @@ func (in *AdditionalNetworkDevice) DeepCopy() *AdditionalNetworkDevice {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BondSpec) DeepCopyInto(out *BondSpec) {
+	*out = *in
+	if in.Interfaces != nil {
+		in, out := &in.Interfaces, &out.Interfaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MIIMonitorInterval != nil {
+		in, out := &in.MIIMonitorInterval, &out.MIIMonitorInterval
+		*out = new(uint32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BondSpec.
+func (in *BondSpec) DeepCopy() *BondSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BondSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CDROMSpec) DeepCopyInto(out *CDROMSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CDROMSpec.
+func (in *CDROMSpec) DeepCopy() *CDROMSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CDROMSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlaneEndpointIPAM) DeepCopyInto(out *ControlPlaneEndpointIPAM) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneEndpointIPAM.
+func (in *ControlPlaneEndpointIPAM) DeepCopy() *ControlPlaneEndpointIPAM {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlaneEndpointIPAM)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DefaultStorage) DeepCopyInto(out *DefaultStorage) {
+	*out = *in
+	if in.BootVolume != nil {
+		in, out := &in.BootVolume, &out.BootVolume
+		*out = new(DiskSize)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AdditionalVolumes != nil {
+		in, out := &in.AdditionalVolumes, &out.AdditionalVolumes
+		*out = make([]DiskSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DefaultStorage.
+func (in *DefaultStorage) DeepCopy() *DefaultStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(DefaultStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DiskSize) DeepCopyInto(out *DiskSize) {
 	*out = *in
+	if in.Throttle != nil {
+		in, out := &in.Throttle, &out.Throttle
+		*out = new(DiskThrottle)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiskSize.
@@ -43,6 +131,221 @@ func (in *DiskSize) DeepCopy() *DiskSize {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiskSpec) DeepCopyInto(out *DiskSpec) {
+	*out = *in
+	if in.Format != nil {
+		in, out := &in.Format, &out.Format
+		*out = new(TargetFileStorageFormat)
+		**out = **in
+	}
+	if in.Throttle != nil {
+		in, out := &in.Throttle, &out.Throttle
+		*out = new(DiskThrottle)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiskThrottle) DeepCopyInto(out *DiskThrottle) {
+	*out = *in
+	if in.IOPSRead != nil {
+		in, out := &in.IOPSRead, &out.IOPSRead
+		*out = new(int32)
+		**out = **in
+	}
+	if in.IOPSWrite != nil {
+		in, out := &in.IOPSWrite, &out.IOPSWrite
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MBpsRead != nil {
+		in, out := &in.MBpsRead, &out.MBpsRead
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MBpsWrite != nil {
+		in, out := &in.MBpsWrite, &out.MBpsWrite
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiskThrottle.
+func (in *DiskThrottle) DeepCopy() *DiskThrottle {
+	if in == nil {
+		return nil
+	}
+	out := new(DiskThrottle)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiskSpec.
+func (in *DiskSpec) DeepCopy() *DiskSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DiskSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EFIDisk) DeepCopyInto(out *EFIDisk) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EFIDisk.
+func (in *EFIDisk) DeepCopy() *EFIDisk {
+	if in == nil {
+		return nil
+	}
+	out := new(EFIDisk)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FailureDomainSpec) DeepCopyInto(out *FailureDomainSpec) {
+	*out = *in
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ControlPlane != nil {
+		in, out := &in.ControlPlane, &out.ControlPlane
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FailureDomainSpec.
+func (in *FailureDomainSpec) DeepCopy() *FailureDomainSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FailureDomainSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FileContentSource) DeepCopyInto(out *FileContentSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FileContentSource.
+func (in *FileContentSource) DeepCopy() *FileContentSource {
+	if in == nil {
+		return nil
+	}
+	out := new(FileContentSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FileSpec) DeepCopyInto(out *FileSpec) {
+	*out = *in
+	if in.Content != nil {
+		in, out := &in.Content, &out.Content
+		*out = new(string)
+		**out = **in
+	}
+	if in.ContentFrom != nil {
+		in, out := &in.ContentFrom, &out.ContentFrom
+		*out = new(FileContentSource)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FileSpec.
+func (in *FileSpec) DeepCopy() *FileSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FileSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirewallRuleSpec) DeepCopyInto(out *FirewallRuleSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirewallRuleSpec.
+func (in *FirewallRuleSpec) DeepCopy() *FirewallRuleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FirewallRuleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GuestBridgeSpec) DeepCopyInto(out *GuestBridgeSpec) {
+	*out = *in
+	if in.Interfaces != nil {
+		in, out := &in.Interfaces, &out.Interfaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.STP != nil {
+		in, out := &in.STP, &out.STP
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ForwardDelay != nil {
+		in, out := &in.ForwardDelay, &out.ForwardDelay
+		*out = new(uint32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GuestBridgeSpec.
+func (in *GuestBridgeSpec) DeepCopy() *GuestBridgeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GuestBridgeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GuestVLANSpec) DeepCopyInto(out *GuestVLANSpec) {
+	*out = *in
+	if in.IPv4 != nil {
+		in, out := &in.IPv4, &out.IPv4
+		*out = new(IPAddressSpec)
+		**out = **in
+	}
+	if in.IPv6 != nil {
+		in, out := &in.IPv6, &out.IPv6
+		*out = new(IPAddressSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GuestVLANSpec.
+func (in *GuestVLANSpec) DeepCopy() *GuestVLANSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GuestVLANSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IPAddress) DeepCopyInto(out *IPAddress) {
 	*out = *in
@@ -58,6 +361,21 @@ func (in *IPAddress) DeepCopy() *IPAddress {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IPAddressSpec) DeepCopyInto(out *IPAddressSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPAddressSpec.
+func (in *IPAddressSpec) DeepCopy() *IPAddressSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(IPAddressSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IPConfigSpec) DeepCopyInto(out *IPConfigSpec) {
 	*out = *in
@@ -134,6 +452,21 @@ func (in *MetadataSettings) DeepCopy() *MetadataSettings {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MountSpec) DeepCopyInto(out *MountSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MountSpec.
+func (in *MountSpec) DeepCopy() *MountSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MountSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetworkDevice) DeepCopyInto(out *NetworkDevice) {
 	*out = *in
@@ -152,6 +485,16 @@ func (in *NetworkDevice) DeepCopyInto(out *NetworkDevice) {
 		*out = new(uint16)
 		**out = **in
 	}
+	if in.Queues != nil {
+		in, out := &in.Queues, &out.Queues
+		*out = new(uint8)
+		**out = **in
+	}
+	if in.RateLimitMBps != nil {
+		in, out := &in.RateLimitMBps, &out.RateLimitMBps
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkDevice.
@@ -172,6 +515,16 @@ func (in *NetworkSpec) DeepCopyInto(out *NetworkSpec) {
 		*out = new(NetworkDevice)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]RouteSpec, len(*in))
+		copy(*out, *in)
+	}
+	if in.SearchDomains != nil {
+		in, out := &in.SearchDomains, &out.SearchDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.AdditionalDevices != nil {
 		in, out := &in.AdditionalDevices, &out.AdditionalDevices
 		*out = make([]AdditionalNetworkDevice, len(*in))
@@ -253,6 +606,36 @@ func (in *NodeLocations) DeepCopy() *NodeLocations {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PCIDeviceSpec) DeepCopyInto(out *PCIDeviceSpec) {
+	*out = *in
+	if in.Mapping != nil {
+		in, out := &in.Mapping, &out.Mapping
+		*out = new(string)
+		**out = **in
+	}
+	if in.DeviceID != nil {
+		in, out := &in.DeviceID, &out.DeviceID
+		*out = new(string)
+		**out = **in
+	}
+	if in.ROMBar != nil {
+		in, out := &in.ROMBar, &out.ROMBar
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PCIDeviceSpec.
+func (in *PCIDeviceSpec) DeepCopy() *PCIDeviceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PCIDeviceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProxmoxCluster) DeepCopyInto(out *ProxmoxCluster) {
 	*out = *in
@@ -347,6 +730,11 @@ func (in *ProxmoxClusterSpec) DeepCopyInto(out *ProxmoxClusterSpec) {
 		*out = new(v1beta1.APIEndpoint)
 		**out = **in
 	}
+	if in.ControlPlaneEndpointIPAM != nil {
+		in, out := &in.ControlPlaneEndpointIPAM, &out.ControlPlaneEndpointIPAM
+		*out = new(ControlPlaneEndpointIPAM)
+		**out = **in
+	}
 	if in.AllowedNodes != nil {
 		in, out := &in.AllowedNodes, &out.AllowedNodes
 		*out = make([]string, len(*in))
@@ -357,6 +745,28 @@ func (in *ProxmoxClusterSpec) DeepCopyInto(out *ProxmoxClusterSpec) {
 		*out = new(SchedulerHints)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.FailureDomains != nil {
+		in, out := &in.FailureDomains, &out.FailureDomains
+		*out = make(map[string]FailureDomainSpec, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Pool != nil {
+		in, out := &in.Pool, &out.Pool
+		*out = new(string)
+		**out = **in
+	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(string)
+		**out = **in
+	}
+	if in.StorageHeadroomGB != nil {
+		in, out := &in.StorageHeadroomGB, &out.StorageHeadroomGB
+		*out = new(int32)
+		**out = **in
+	}
 	if in.IPv4Config != nil {
 		in, out := &in.IPv4Config, &out.IPv4Config
 		*out = new(IPConfigSpec)
@@ -367,11 +777,51 @@ func (in *ProxmoxClusterSpec) DeepCopyInto(out *ProxmoxClusterSpec) {
 		*out = new(IPConfigSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.IPv4PoolRef != nil {
+		in, out := &in.IPv4PoolRef, &out.IPv4PoolRef
+		*out = new(v1.TypedLocalObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IPv6PoolRef != nil {
+		in, out := &in.IPv6PoolRef, &out.IPv6PoolRef
+		*out = new(v1.TypedLocalObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.DNSServers != nil {
 		in, out := &in.DNSServers, &out.DNSServers
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.SearchDomains != nil {
+		in, out := &in.SearchDomains, &out.SearchDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NTPServers != nil {
+		in, out := &in.NTPServers, &out.NTPServers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SSHAuthorizedKeys != nil {
+		in, out := &in.SSHAuthorizedKeys, &out.SSHAuthorizedKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.VMNameTemplate != nil {
+		in, out := &in.VMNameTemplate, &out.VMNameTemplate
+		*out = new(string)
+		**out = **in
+	}
+	if in.VMIDRange != nil {
+		in, out := &in.VMIDRange, &out.VMIDRange
+		*out = new(VMIDRange)
+		**out = **in
+	}
+	if in.MachineDefaults != nil {
+		in, out := &in.MachineDefaults, &out.MachineDefaults
+		*out = new(ProxmoxMachineDefaults)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.CloneSpec != nil {
 		in, out := &in.CloneSpec, &out.CloneSpec
 		*out = new(ProxmoxClusterCloneSpec)
@@ -402,11 +852,30 @@ func (in *ProxmoxClusterStatus) DeepCopyInto(out *ProxmoxClusterStatus) {
 		*out = make([]v1.LocalObjectReference, len(*in))
 		copy(*out, *in)
 	}
+	if in.ControlPlaneEndpointAddresses != nil {
+		in, out := &in.ControlPlaneEndpointAddresses, &out.ControlPlaneEndpointAddresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.NodeLocations != nil {
 		in, out := &in.NodeLocations, &out.NodeLocations
 		*out = new(NodeLocations)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.FailureDomains != nil {
+		in, out := &in.FailureDomains, &out.FailureDomains
+		*out = make(v1beta1.FailureDomains, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.RetainedVMs != nil {
+		in, out := &in.RetainedVMs, &out.RetainedVMs
+		*out = make([]RetainedVM, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.FailureReason != nil {
 		in, out := &in.FailureReason, &out.FailureReason
 		*out = new(errors.ClusterStatusError)
@@ -579,6 +1048,31 @@ func (in *ProxmoxMachineChecks) DeepCopy() *ProxmoxMachineChecks {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxmoxMachineDefaults) DeepCopyInto(out *ProxmoxMachineDefaults) {
+	*out = *in
+	if in.Disks != nil {
+		in, out := &in.Disks, &out.Disks
+		*out = new(DefaultStorage)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxmoxMachineDefaults.
+func (in *ProxmoxMachineDefaults) DeepCopy() *ProxmoxMachineDefaults {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxmoxMachineDefaults)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProxmoxMachineList) DeepCopyInto(out *ProxmoxMachineList) {
 	*out = *in
@@ -625,6 +1119,16 @@ func (in *ProxmoxMachineSpec) DeepCopyInto(out *ProxmoxMachineSpec) {
 		*out = new(int64)
 		**out = **in
 	}
+	if in.ExistingVMID != nil {
+		in, out := &in.ExistingVMID, &out.ExistingVMID
+		*out = new(int64)
+		**out = **in
+	}
+	if in.MinMemoryMiB != nil {
+		in, out := &in.MinMemoryMiB, &out.MinMemoryMiB
+		*out = new(int32)
+		**out = **in
+	}
 	if in.Disks != nil {
 		in, out := &in.Disks, &out.Disks
 		*out = new(Storage)
@@ -635,6 +1139,73 @@ func (in *ProxmoxMachineSpec) DeepCopyInto(out *ProxmoxMachineSpec) {
 		*out = new(NetworkSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.IPv4 != nil {
+		in, out := &in.IPv4, &out.IPv4
+		*out = new(IPAddressSpec)
+		**out = **in
+	}
+	if in.IPv6 != nil {
+		in, out := &in.IPv6, &out.IPv6
+		*out = new(IPAddressSpec)
+		**out = **in
+	}
+	if in.FirewallRules != nil {
+		in, out := &in.FirewallRules, &out.FirewallRules
+		*out = make([]FirewallRuleSpec, len(*in))
+		copy(*out, *in)
+	}
+	if in.PCIDevices != nil {
+		in, out := &in.PCIDevices, &out.PCIDevices
+		*out = make([]PCIDeviceSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.EFIDisk != nil {
+		in, out := &in.EFIDisk, &out.EFIDisk
+		*out = new(EFIDisk)
+		**out = **in
+	}
+	if in.SerialConsole != nil {
+		in, out := &in.SerialConsole, &out.SerialConsole
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Hotplug != nil {
+		in, out := &in.Hotplug, &out.Hotplug
+		*out = make([]HotplugFeature, len(*in))
+		copy(*out, *in)
+	}
+	if in.TPM != nil {
+		in, out := &in.TPM, &out.TPM
+		*out = new(TPMSpec)
+		**out = **in
+	}
+	if in.CDROM != nil {
+		in, out := &in.CDROM, &out.CDROM
+		*out = new(CDROMSpec)
+		**out = **in
+	}
+	if in.BootOrder != nil {
+		in, out := &in.BootOrder, &out.BootOrder
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.OnBoot != nil {
+		in, out := &in.OnBoot, &out.OnBoot
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Startup != nil {
+		in, out := &in.Startup, &out.Startup
+		*out = new(StartupSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.VMIDRange != nil {
 		in, out := &in.VMIDRange, &out.VMIDRange
 		*out = new(VMIDRange)
@@ -645,11 +1216,53 @@ func (in *ProxmoxMachineSpec) DeepCopyInto(out *ProxmoxMachineSpec) {
 		*out = new(ProxmoxMachineChecks)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.NTPServers != nil {
+		in, out := &in.NTPServers, &out.NTPServers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SSHAuthorizedKeys != nil {
+		in, out := &in.SSHAuthorizedKeys, &out.SSHAuthorizedKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Files != nil {
+		in, out := &in.Files, &out.Files
+		*out = make([]FileSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Mounts != nil {
+		in, out := &in.Mounts, &out.Mounts
+		*out = make([]MountSpec, len(*in))
+		copy(*out, *in)
+	}
 	if in.MetadataSettings != nil {
 		in, out := &in.MetadataSettings, &out.MetadataSettings
 		*out = new(MetadataSettings)
 		**out = **in
 	}
+	if in.SnapshotName != nil {
+		in, out := &in.SnapshotName, &out.SnapshotName
+		*out = new(string)
+		**out = **in
+	}
+	if in.VMNameTemplate != nil {
+		in, out := &in.VMNameTemplate, &out.VMNameTemplate
+		*out = new(string)
+		**out = **in
+	}
+	if in.ShutdownTimeout != nil {
+		in, out := &in.ShutdownTimeout, &out.ShutdownTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.SnapshotTTL != nil {
+		in, out := &in.SnapshotTTL, &out.SnapshotTTL
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxmoxMachineSpec.
@@ -675,6 +1288,11 @@ func (in *ProxmoxMachineStatus) DeepCopyInto(out *ProxmoxMachineStatus) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.AllocatedVMID != nil {
+		in, out := &in.AllocatedVMID, &out.AllocatedVMID
+		*out = new(int64)
+		**out = **in
+	}
 	if in.IPAddresses != nil {
 		in, out := &in.IPAddresses, &out.IPAddresses
 		*out = make(map[string]IPAddress, len(*in))
@@ -694,6 +1312,11 @@ func (in *ProxmoxMachineStatus) DeepCopyInto(out *ProxmoxMachineStatus) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.VMID != nil {
+		in, out := &in.VMID, &out.VMID
+		*out = new(int64)
+		**out = **in
+	}
 	if in.TaskRef != nil {
 		in, out := &in.TaskRef, &out.TaskRef
 		*out = new(string)
@@ -820,6 +1443,25 @@ func (in *ProxmoxMachineTemplateSpec) DeepCopy() *ProxmoxMachineTemplateSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetainedVM) DeepCopyInto(out *RetainedVM) {
+	*out = *in
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetainedVM.
+func (in *RetainedVM) DeepCopy() *RetainedVM {
+	if in == nil {
+		return nil
+	}
+	out := new(RetainedVM)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RouteSpec) DeepCopyInto(out *RouteSpec) {
 	*out = *in
@@ -902,13 +1544,50 @@ func (in *SchedulerHints) DeepCopy() *SchedulerHints {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StartupSpec) DeepCopyInto(out *StartupSpec) {
+	*out = *in
+	if in.Order != nil {
+		in, out := &in.Order, &out.Order
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Up != nil {
+		in, out := &in.Up, &out.Up
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Down != nil {
+		in, out := &in.Down, &out.Down
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StartupSpec.
+func (in *StartupSpec) DeepCopy() *StartupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(StartupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Storage) DeepCopyInto(out *Storage) {
 	*out = *in
 	if in.BootVolume != nil {
 		in, out := &in.BootVolume, &out.BootVolume
 		*out = new(DiskSize)
-		**out = **in
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AdditionalVolumes != nil {
+		in, out := &in.AdditionalVolumes, &out.AdditionalVolumes
+		*out = make([]DiskSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
@@ -922,6 +1601,21 @@ func (in *Storage) DeepCopy() *Storage {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TPMSpec) DeepCopyInto(out *TPMSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TPMSpec.
+func (in *TPMSpec) DeepCopy() *TPMSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TPMSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VMIDRange) DeepCopyInto(out *VMIDRange) {
 	*out = *in
@@ -988,6 +1682,11 @@ func (in *VirtualMachineCloneSpec) DeepCopyInto(out *VirtualMachineCloneSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.TemplateName != nil {
+		in, out := &in.TemplateName, &out.TemplateName
+		*out = new(string)
+		**out = **in
+	}
 	if in.Description != nil {
 		in, out := &in.Description, &out.Description
 		*out = new(string)
@@ -1023,6 +1722,11 @@ func (in *VirtualMachineCloneSpec) DeepCopyInto(out *VirtualMachineCloneSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.AllowedNodes != nil {
+		in, out := &in.AllowedNodes, &out.AllowedNodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualMachineCloneSpec.
@@ -1045,6 +1749,27 @@ func (in *VirtualNetworkDevices) DeepCopyInto(out *VirtualNetworkDevices) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Bonds != nil {
+		in, out := &in.Bonds, &out.Bonds
+		*out = make([]BondSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VLANs != nil {
+		in, out := &in.VLANs, &out.VLANs
+		*out = make([]GuestVLANSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Bridges != nil {
+		in, out := &in.Bridges, &out.Bridges
+		*out = make([]GuestBridgeSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualNetworkDevices.
@@ -24,12 +24,14 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/go-logr/logr"
-	"github.com/luthermonson/go-proxmox"
 	"github.com/spf13/pflag"
+	"golang.org/x/time/rate"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/env"
 	ipamicv1 "sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
@@ -38,7 +40,9 @@ import (
 	"sigs.k8s.io/cluster-api/feature"
 	"sigs.k8s.io/cluster-api/util/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	ctrlwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
 
@@ -48,6 +52,7 @@ import (
 
 	infrastructurev1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/controller"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/metrics"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/tlshelper"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/webhook"
 	capmox "github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
@@ -62,6 +67,7 @@ var (
 	metricsAddr          string
 	enableLeaderElection bool
 	enableWebhooks       bool
+	webhookCheckProxmox  bool
 	probeAddr            string
 
 	// ProxmoxURL env variable that defines the Proxmox host.
@@ -70,9 +76,22 @@ var (
 	ProxmoxTokenID string
 	// ProxmoxSecret env variable that defines the Proxmox secret for the given token id.
 	ProxmoxSecret string
+	// ProxmoxUsername env variable that defines the Proxmox user for ticket based authentication.
+	ProxmoxUsername string
+	// ProxmoxPassword env variable that defines the password of the Proxmox user.
+	ProxmoxPassword string
 
 	proxmoxInsecure     bool
 	proxmoxRootCertFile string
+	proxmoxTaskTimeout  time.Duration
+
+	proxmoxClusterConcurrency int
+	proxmoxMachineConcurrency int
+
+	proxmoxClusterRetryBackoff    time.Duration
+	proxmoxClusterMaxRetryBackoff time.Duration
+	proxmoxMachineRetryBackoff    time.Duration
+	proxmoxMachineMaxRetryBackoff time.Duration
 )
 
 func init() {
@@ -133,6 +152,8 @@ func main() {
 		os.Exit(1)
 	}
 
+	ctrlmetrics.Registry.MustRegister(metrics.NewMachineCollector(mgr.GetCache()))
+
 	if setupErr := setupReconcilers(ctx, mgr, pmoxClient); setupErr != nil {
 		setupLog.Error(err, "unable to setup reconcilers")
 		os.Exit(1)
@@ -147,6 +168,13 @@ func main() {
 			setupLog.Error(err, "unable to create webhook", "webhook", "ProxmoxMachine")
 			os.Exit(1)
 		}
+		if err = (&webhook.ProxmoxMachineTemplate{
+			ValidateBackend: webhookCheckProxmox,
+			ProxmoxClient:   pmoxClient,
+		}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ProxmoxMachineTemplate")
+			os.Exit(1)
+		}
 	}
 	// +kubebuilder:scaffold:builder
 
@@ -172,7 +200,10 @@ func setupReconcilers(ctx context.Context, mgr ctrl.Manager, proxmoxClient capmo
 		Scheme:        mgr.GetScheme(),
 		Recorder:      mgr.GetEventRecorderFor("proxmoxcluster-controller"),
 		ProxmoxClient: proxmoxClient,
-	}).SetupWithManager(ctx, mgr); err != nil {
+	}).SetupWithManager(ctx, mgr, ctrlcontroller.Options{
+		MaxConcurrentReconciles: proxmoxClusterConcurrency,
+		RateLimiter:             controllerRateLimiter(proxmoxClusterRetryBackoff, proxmoxClusterMaxRetryBackoff),
+	}); err != nil {
 		return fmt.Errorf("setting up ProxmoxCluster controller: %w", err)
 	}
 	if err := (&controller.ProxmoxMachineReconciler{
@@ -180,24 +211,52 @@ func setupReconcilers(ctx context.Context, mgr ctrl.Manager, proxmoxClient capmo
 		Scheme:        mgr.GetScheme(),
 		Recorder:      mgr.GetEventRecorderFor("proxmoxmachine-controller"),
 		ProxmoxClient: proxmoxClient,
-	}).SetupWithManager(mgr); err != nil {
+		TaskTimeout:   proxmoxTaskTimeout,
+	}).SetupWithManager(mgr, ctrlcontroller.Options{
+		MaxConcurrentReconciles: proxmoxMachineConcurrency,
+		RateLimiter:             controllerRateLimiter(proxmoxMachineRetryBackoff, proxmoxMachineMaxRetryBackoff),
+	}); err != nil {
 		return fmt.Errorf("setting up ProxmoxMachine controller: %w", err)
 	}
 
 	return nil
 }
 
+// controllerRateLimiter returns the rate limiter of controller-runtime with a different backoff.
+// Like the default, it also limits all retries of a controller to 10 per second, with bursts of 100.
+func controllerRateLimiter(baseDelay, maxDelay time.Duration) workqueue.RateLimiter {
+	return workqueue.NewMaxOfRateLimiter(
+		workqueue.NewItemExponentialFailureRateLimiter(baseDelay, maxDelay),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
+	)
+}
+
 func setupProxmoxClient(ctx context.Context, logger logr.Logger) (capmox.Client, error) {
+	// the root certificates are checked even without credentials, so a broken file is noticed at startup.
+	rootCerts, err := tlshelper.SystemRootsWithFile(proxmoxRootCertFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading cert pool: %w", err)
+	}
+
 	// we return nil if the env variables are not set
 	// so the proxmoxcontroller can create the client later from spec.credentialsRef
 	// or fail the cluster if no credentials found
-	if ProxmoxURL == "" || ProxmoxTokenID == "" || ProxmoxSecret == "" {
+	creds := goproxmox.Credentials{
+		TokenID:  ProxmoxTokenID,
+		Secret:   ProxmoxSecret,
+		Username: ProxmoxUsername,
+		Password: ProxmoxPassword,
+	}
+	if ProxmoxURL == "" || creds.IsEmpty() {
 		return nil, nil
 	}
 
-	rootCerts, err := tlshelper.SystemRootsWithFile(proxmoxRootCertFile)
-	if err != nil {
-		return nil, fmt.Errorf("loading cert pool: %w", err)
+	if err := creds.Validate(); err != nil {
+		return nil, err
+	}
+
+	if proxmoxInsecure {
+		logger.Info("TLS verification of the Proxmox API is disabled, use --proxmox-insecure=false to enable it", "url", ProxmoxURL)
 	}
 
 	tr := &http.Transport{
@@ -207,11 +266,15 @@ func setupProxmoxClient(ctx context.Context, logger logr.Logger) (capmox.Client,
 		},
 	}
 
-	httpClient := &http.Client{Transport: tr}
-	return goproxmox.NewAPIClient(ctx, logger, ProxmoxURL,
-		proxmox.WithHTTPClient(httpClient),
-		proxmox.WithAPIToken(ProxmoxTokenID, ProxmoxSecret),
-	)
+	options, err := creds.Options(ProxmoxURL, &http.Client{Transport: goproxmox.NewRetryTransport(tr)})
+	if err != nil {
+		return nil, err
+	}
+	client, err := goproxmox.NewAPIClient(ctx, logger, ProxmoxURL, options...)
+	if err != nil {
+		return nil, err
+	}
+	return capmox.NewCachedClient(client, capmox.DefaultCacheTTL), nil
 }
 
 func initFlagsAndEnv(fs *pflag.FlagSet) {
@@ -220,12 +283,44 @@ func initFlagsAndEnv(fs *pflag.FlagSet) {
 	ProxmoxURL = env.GetString("PROXMOX_URL", "")
 	ProxmoxTokenID = env.GetString("PROXMOX_TOKEN", "")
 	ProxmoxSecret = env.GetString("PROXMOX_SECRET", "")
+	ProxmoxUsername = env.GetString("PROXMOX_USERNAME", "")
+	ProxmoxPassword = env.GetString("PROXMOX_PASSWORD", "")
 
 	fs.BoolVar(&proxmoxInsecure, "proxmox-insecure",
 		env.GetString("PROXMOX_INSECURE", "true") == "true",
 		"Skip TLS verification when connecting to Proxmox")
 	fs.StringVar(&proxmoxRootCertFile, "proxmox-root-cert-file", "",
-		"Root-Certificate to use to verify server TLS certificate")
+		"PEM bundle of root certificates to verify the server TLS certificate with, in addition to the system pool")
+	fs.IntVar(&goproxmox.DefaultRetryOptions.MaxRetries, "proxmox-api-max-retries", goproxmox.DefaultRetryOptions.MaxRetries,
+		"Number of times a read request to the Proxmox API is retried on transient errors")
+	fs.DurationVar(&goproxmox.DefaultRetryOptions.InitialBackoff, "proxmox-api-retry-backoff", goproxmox.DefaultRetryOptions.InitialBackoff,
+		"Time to wait before retrying a failed request to the Proxmox API, doubled with every retry")
+	fs.DurationVar(&goproxmox.DefaultRetryOptions.MaxBackoff, "proxmox-api-max-retry-backoff", goproxmox.DefaultRetryOptions.MaxBackoff,
+		"Maximum time to wait between retries of a request to the Proxmox API")
+	fs.Float32Var(&goproxmox.DefaultRateLimitOptions.QPS, "proxmox-api-qps", goproxmox.DefaultRateLimitOptions.QPS,
+		"Maximum number of requests per second to the Proxmox API of all clusters. Zero disables the limit")
+	fs.IntVar(&goproxmox.DefaultRateLimitOptions.Burst, "proxmox-api-burst", goproxmox.DefaultRateLimitOptions.Burst,
+		"Maximum number of requests sent to the Proxmox API at once, before the rate limit applies")
+	fs.Float32Var(&goproxmox.DefaultRateLimitOptions.NodeQPS, "proxmox-api-node-qps", goproxmox.DefaultRateLimitOptions.NodeQPS,
+		"Maximum number of requests per second to each Proxmox node, in addition to --proxmox-api-qps. Zero disables the limit")
+	fs.IntVar(&goproxmox.DefaultRateLimitOptions.NodeBurst, "proxmox-api-node-burst", goproxmox.DefaultRateLimitOptions.NodeBurst,
+		"Maximum number of requests sent to a Proxmox node at once, before the node rate limit applies")
+	fs.DurationVar(&capmox.DefaultCacheTTL, "proxmox-cache-ttl", capmox.DefaultCacheTTL,
+		"Time the nodes, templates, pools and ISO images of Proxmox are cached for. Zero disables the cache")
+	fs.DurationVar(&proxmoxTaskTimeout, "proxmox-task-timeout", 20*time.Minute,
+		"Time after which a running Proxmox task, e.g. a clone, is stopped and considered failed")
+	fs.IntVar(&proxmoxClusterConcurrency, "proxmoxcluster-concurrency", 10,
+		"Number of ProxmoxClusters to process simultaneously")
+	fs.IntVar(&proxmoxMachineConcurrency, "proxmoxmachine-concurrency", 10,
+		"Number of ProxmoxMachines to process simultaneously")
+	fs.DurationVar(&proxmoxClusterRetryBackoff, "proxmoxcluster-retry-backoff", time.Second,
+		"Time to wait before retrying a ProxmoxCluster which failed to reconcile, doubled with every retry")
+	fs.DurationVar(&proxmoxClusterMaxRetryBackoff, "proxmoxcluster-max-retry-backoff", 5*time.Minute,
+		"Maximum time to wait between retries of a ProxmoxCluster which failed to reconcile")
+	fs.DurationVar(&proxmoxMachineRetryBackoff, "proxmoxmachine-retry-backoff", time.Second,
+		"Time to wait before retrying a ProxmoxMachine which failed to reconcile, doubled with every retry")
+	fs.DurationVar(&proxmoxMachineMaxRetryBackoff, "proxmoxmachine-max-retry-backoff", 5*time.Minute,
+		"Maximum time to wait between retries of a ProxmoxMachine which failed to reconcile")
 
 	fs.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	fs.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -234,6 +329,9 @@ func initFlagsAndEnv(fs *pflag.FlagSet) {
 			"Enabling this will ensure there is only one active controller manager.")
 	fs.BoolVar(&enableWebhooks, "enable-webhooks", true,
 		"If true, run webhook server alongside manager")
+	fs.BoolVar(&webhookCheckProxmox, "webhook-check-proxmox", false,
+		"If true, the webhook checks the nodes and vm templates of ProxmoxMachineTemplates against the Proxmox API of their cluster. "+
+			"Templates are still admitted with a warning if the Proxmox API is not reachable.")
 
 	feature.MutableGates.AddFlag(fs)
 }
@@ -20,13 +20,16 @@ package controller
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/ptr"
@@ -40,12 +43,14 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/metrics"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/kubernetes/ipam"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
@@ -65,14 +70,14 @@ type ProxmoxClusterReconciler struct {
 }
 
 // SetupWithManager sets up the controller with the Manager.
-func (r *ProxmoxClusterReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+func (r *ProxmoxClusterReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&infrav1alpha1.ProxmoxCluster{}).
+		WithOptions(options).
 		WithEventFilter(predicates.ResourceNotPaused(ctrl.LoggerFrom(ctx))).
 		Watches(&clusterv1.Cluster{},
 			handler.EnqueueRequestsFromMapFunc(clusterutil.ClusterToInfrastructureMapFunc(ctx, infrav1alpha1.GroupVersion.WithKind(infrav1alpha1.ProxmoxClusterKind), mgr.GetClient(), &infrav1alpha1.ProxmoxCluster{})),
 			builder.WithPredicates(predicates.ClusterUnpaused(ctrl.LoggerFrom(ctx)))).
-		WithEventFilter(predicates.ResourceIsNotExternallyManaged(ctrl.LoggerFrom(ctx))).
 		Complete(r)
 }
 
@@ -82,6 +87,7 @@ func (r *ProxmoxClusterReconciler) SetupWithManager(ctx context.Context, mgr ctr
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=proxmoxclusters/finalizers,verbs=update
 
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=proxmoxmachines,verbs=get;list;watch
 
 // +kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=inclusterippools,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=globalinclusterippools,verbs=get;list;watch;create;update;patch;delete
@@ -96,6 +102,12 @@ func (r *ProxmoxClusterReconciler) SetupWithManager(ctx context.Context, mgr ctr
 func (r *ProxmoxClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
 	logger := log.FromContext(ctx)
 
+	start := time.Now()
+	var clusterName string
+	defer func() {
+		metrics.ObserveReconcile("proxmoxcluster", req.Namespace, clusterName, start, reterr)
+	}()
+
 	proxmoxCluster := &infrav1alpha1.ProxmoxCluster{}
 	if err := r.Client.Get(ctx, req.NamespacedName, proxmoxCluster); err != nil {
 		if apierrors.IsNotFound(err) {
@@ -116,6 +128,7 @@ func (r *ProxmoxClusterReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 
 	logger = logger.WithValues("cluster", klog.KObj(cluster))
 	ctx = ctrl.LoggerInto(ctx, logger)
+	clusterName = cluster.Name
 
 	if annotations.IsPaused(cluster, proxmoxCluster) {
 		logger.Info("ProxmoxCluster or owning Cluster is marked as paused, not reconciling")
@@ -176,10 +189,16 @@ func (r *ProxmoxClusterReconciler) reconcileDelete(ctx context.Context, clusterS
 		return ctrl.Result{RequeueAfter: infrav1alpha1.DefaultReconcilerRequeue}, nil
 	}
 
+	if err := clusterScope.IPAMHelper.DeleteControlPlaneEndpointClaims(ctx); err != nil {
+		return reconcile.Result{}, err
+	}
+
 	if err := r.reconcileDeleteCredentialsSecret(ctx, clusterScope); err != nil {
 		return reconcile.Result{}, err
 	}
 
+	clusterScope.ReleaseProxmoxClient()
+
 	clusterScope.Info("cluster deleted successfully")
 	ctrlutil.RemoveFinalizer(clusterScope.ProxmoxCluster, infrav1alpha1.ClusterFinalizer)
 	return ctrl.Result{}, nil
@@ -191,7 +210,40 @@ func (r *ProxmoxClusterReconciler) reconcileNormal(ctx context.Context, clusterS
 	// If the ProxmoxCluster doesn't have our finalizer, add it.
 	ctrlutil.AddFinalizer(clusterScope.ProxmoxCluster, infrav1alpha1.ClusterFinalizer)
 
-	if clusterScope.ProxmoxCluster.Spec.ExternalManagedControlPlane {
+	// when a Cluster is marked failed cause the Proxmox client is nil.
+	// the cluster doesn't reconcile the failed state if we restart the controller.
+	// so we need to check if the ProxmoxClient is not nil and the ProxmoxCluster has a failure reason.
+	err := r.reconcileFailedClusterState(clusterScope)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// The infrastructure of externally managed clusters, i.e. the control plane endpoint and the pools of the
+	// machines, is provided by someone else. The nodes and credentials are still required by the machines.
+	externallyManaged := annotations.IsExternallyManaged(clusterScope.ProxmoxCluster)
+	if externallyManaged {
+		clusterScope.Logger.V(4).Info("ProxmoxCluster is externally managed, skipping IPAM and control plane endpoint")
+	} else {
+		// clusters created before the webhook validated the ip configs are not turned into broken pools.
+		if err := validateIPConfigs(clusterScope.ProxmoxCluster); err != nil {
+			clusterScope.Error(err, "Invalid cluster IPAM config, not reconciling")
+			conditions.MarkFalse(clusterScope.ProxmoxCluster, infrav1alpha1.ProxmoxClusterReady, infrav1alpha1.InvalidIPConfigReason, clusterv1.ConditionSeverityError, err.Error())
+			return ctrl.Result{}, nil
+		}
+
+		res, err := r.reconcileIPAM(ctx, clusterScope)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		if !res.IsZero() {
+			return res, nil
+		}
+	}
+
+	// externally managed endpoints are awaited after the pools were reconciled,
+	// as the machines providing the endpoint, e.g. with kube-vip, need their addresses.
+	if clusterScope.ProxmoxCluster.Spec.ExternalManagedControlPlane || externallyManaged {
 		if clusterScope.ProxmoxCluster.Spec.ControlPlaneEndpoint == nil {
 			clusterScope.Logger.Info("ProxmoxCluster is not ready, missing or waiting for a ControlPlaneEndpoint")
 
@@ -215,21 +267,20 @@ func (r *ProxmoxClusterReconciler) reconcileNormal(ctx context.Context, clusterS
 		}
 	}
 
-	// when a Cluster is marked failed cause the Proxmox client is nil.
-	// the cluster doesn't reconcile the failed state if we restart the controller.
-	// so we need to check if the ProxmoxClient is not nil and the ProxmoxCluster has a failure reason.
-	err := r.reconcileFailedClusterState(clusterScope)
-	if err != nil {
-		return ctrl.Result{}, err
-	}
+	if !externallyManaged {
+		res, err := r.reconcileControlPlaneEndpoint(ctx, clusterScope)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
 
-	res, err := r.reconcileIPAM(ctx, clusterScope)
-	if err != nil {
-		return ctrl.Result{}, err
+		if !res.IsZero() {
+			return res, nil
+		}
 	}
 
-	if !res.IsZero() {
-		return res, nil
+	// claims are owned by their ProxmoxMachine, but leak if it was removed without running its finalizer.
+	if err := clusterScope.IPAMHelper.DeleteOrphanedIPAddressClaims(ctx, clusterScope.Cluster.GetName()); err != nil {
+		return ctrl.Result{}, err
 	}
 
 	if err := r.reconcileNormalCredentialsSecret(ctx, clusterScope); err != nil {
@@ -241,11 +292,20 @@ func (r *ProxmoxClusterReconciler) reconcileNormal(ctx context.Context, clusterS
 		return reconcile.Result{}, err
 	}
 
+	if err := r.reconcileFailureDomains(ctx, clusterScope); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	res, err := r.reconcileRetainedVMs(ctx, clusterScope)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
 	conditions.MarkTrue(clusterScope.ProxmoxCluster, infrav1alpha1.ProxmoxClusterReady)
 
 	clusterScope.ProxmoxCluster.Status.Ready = true
 
-	return ctrl.Result{}, nil
+	return res, nil
 }
 
 func (r *ProxmoxClusterReconciler) reconcileFailedClusterState(clusterScope *scope.ClusterScope) error {
@@ -276,6 +336,89 @@ func (r *ProxmoxClusterReconciler) reconcileFailedClusterState(clusterScope *sco
 	return nil
 }
 
+// reconcileFailureDomains ensures all nodes of the failure domains exist
+// and reports the failure domains to Cluster API.
+func (r *ProxmoxClusterReconciler) reconcileFailureDomains(ctx context.Context, clusterScope *scope.ClusterScope) error {
+	failureDomains := clusterScope.ProxmoxCluster.Spec.FailureDomains
+	if len(failureDomains) == 0 {
+		clusterScope.ProxmoxCluster.Status.FailureDomains = nil
+		return nil
+	}
+
+	nodes, err := clusterScope.ProxmoxClient.ListNodes(ctx)
+	if err != nil {
+		conditions.MarkFalse(clusterScope.ProxmoxCluster, infrav1alpha1.ProxmoxClusterReady, infrav1alpha1.ProxmoxUnreachableReason, clusterv1.ConditionSeverityError, err.Error())
+		return err
+	}
+
+	status := make(clusterv1.FailureDomains, len(failureDomains))
+	for name, fd := range failureDomains {
+		for _, node := range fd.Nodes {
+			if !slices.Contains(nodes, node) {
+				err := errors.Errorf("node %q of failure domain %q does not exist", node, name)
+				conditions.MarkFalse(clusterScope.ProxmoxCluster, infrav1alpha1.ProxmoxClusterReady, infrav1alpha1.InvalidFailureDomainReason, clusterv1.ConditionSeverityError, err.Error())
+				return err
+			}
+		}
+
+		status[name] = clusterv1.FailureDomainSpec{
+			ControlPlane: ptr.Deref(fd.ControlPlane, true),
+		}
+	}
+
+	clusterScope.ProxmoxCluster.Status.FailureDomains = status
+	return nil
+}
+
+// reconcileRetainedVMs destroys the VMs retained after their machine was deleted once they expire.
+// The VM is only destroyed if it still is the retained one, its VMID may have been reused otherwise.
+func (r *ProxmoxClusterReconciler) reconcileRetainedVMs(ctx context.Context, clusterScope *scope.ClusterScope) (reconcile.Result, error) {
+	var res reconcile.Result
+	requeueAfter := func(d time.Duration) {
+		if res.RequeueAfter == 0 || d < res.RequeueAfter {
+			res.RequeueAfter = d
+		}
+	}
+
+	for _, vm := range slices.Clone(clusterScope.ProxmoxCluster.Status.RetainedVMs) {
+		if vm.ExpiresAt == nil {
+			continue
+		}
+		if remaining := time.Until(vm.ExpiresAt.Time); remaining > 0 {
+			requeueAfter(remaining)
+			continue
+		}
+
+		free, err := clusterScope.ProxmoxClient.CheckID(ctx, vm.VMID)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if free {
+			clusterScope.Info("retained VM is gone", "vm", vm.Name, "vmid", vm.VMID)
+			clusterScope.ProxmoxCluster.RemoveRetainedVM(vm.VMID)
+			continue
+		}
+
+		resource, err := clusterScope.ProxmoxClient.FindVMResource(ctx, uint64(vm.VMID))
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if resource.Name != vm.Name || !slices.Contains(strings.Split(resource.Tags, ";"), infrav1alpha1.RetainedVMTag) {
+			clusterScope.Info("VMID of retained VM was reused, not deleting it", "vm", vm.Name, "vmid", vm.VMID)
+			clusterScope.ProxmoxCluster.RemoveRetainedVM(vm.VMID)
+			continue
+		}
+
+		clusterScope.Info("deleting expired retained VM", "vm", vm.Name, "vmid", vm.VMID, "node", resource.Node)
+		if _, err := clusterScope.ProxmoxClient.DeleteVM(ctx, resource.Node, vm.VMID); err != nil {
+			return reconcile.Result{}, errors.Wrapf(err, "failed to delete retained VM %s", vm.Name)
+		}
+		requeueAfter(infrav1alpha1.DefaultReconcilerRequeue)
+	}
+
+	return res, nil
+}
+
 func (r *ProxmoxClusterReconciler) reconcileIPAM(ctx context.Context, clusterScope *scope.ClusterScope) (reconcile.Result, error) {
 	if err := clusterScope.IPAMHelper.CreateOrUpdateInClusterIPPool(ctx); err != nil {
 		if errors.Is(err, ipam.ErrMissingAddresses) {
@@ -311,6 +454,81 @@ func (r *ProxmoxClusterReconciler) reconcileIPAM(ctx context.Context, clusterSco
 	return reconcile.Result{}, nil
 }
 
+// validateIPConfigs returns the errors of the ip configs of the cluster, see ipam.ValidateIPConfig.
+func validateIPConfigs(cluster *infrav1alpha1.ProxmoxCluster) error {
+	var allErrs field.ErrorList
+	if cluster.Spec.IPv4Config != nil {
+		allErrs = append(allErrs, ipam.ValidateIPConfig(field.NewPath("spec", "ipv4Config"), cluster.Spec.IPv4Config, true)...)
+	}
+	if cluster.Spec.IPv6Config != nil {
+		allErrs = append(allErrs, ipam.ValidateIPConfig(field.NewPath("spec", "ipv6Config"), cluster.Spec.IPv6Config, false)...)
+	}
+	return allErrs.ToAggregate()
+}
+
+// reconcileControlPlaneEndpoint claims an address of every configured IP family for the control plane endpoint,
+// if its allocation is enabled, and uses the address of the primary family as ControlPlaneEndpoint host.
+func (r *ProxmoxClusterReconciler) reconcileControlPlaneEndpoint(ctx context.Context, clusterScope *scope.ClusterScope) (reconcile.Result, error) {
+	endpointIPAM := clusterScope.ProxmoxCluster.Spec.ControlPlaneEndpointIPAM
+	if endpointIPAM == nil || clusterScope.ProxmoxCluster.Spec.ExternalManagedControlPlane {
+		return reconcile.Result{}, nil
+	}
+
+	formats := []string{infrav1alpha1.IPV4Format, infrav1alpha1.IPV6Format}
+	if endpointIPAM.PrimaryFamily == "ipv6" {
+		slices.Reverse(formats)
+	}
+
+	var addresses []string
+	for _, format := range formats {
+		if format == infrav1alpha1.IPV4Format && !clusterScope.ProxmoxCluster.HasIPv4() ||
+			format == infrav1alpha1.IPV6Format && !clusterScope.ProxmoxCluster.HasIPv6() {
+			continue
+		}
+
+		if err := clusterScope.IPAMHelper.CreateControlPlaneEndpointClaim(ctx, format, clusterScope.Cluster.GetName()); err != nil {
+			return reconcile.Result{}, errors.Wrapf(err, "unable to claim %s control plane endpoint address", format)
+		}
+
+		key := client.ObjectKey{
+			Namespace: clusterScope.Namespace(),
+			Name:      ipam.ControlPlaneEndpointClaimName(clusterScope.ProxmoxCluster, format),
+		}
+		address, err := clusterScope.IPAMHelper.GetIPAddress(ctx, key)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				clusterScope.Info("waiting for control plane endpoint address", "format", format)
+				return ctrl.Result{RequeueAfter: infrav1alpha1.DefaultReconcilerRequeue}, nil
+			}
+			return reconcile.Result{}, err
+		}
+
+		addresses = append(addresses, address.Spec.Address)
+	}
+
+	if len(addresses) == 0 {
+		return reconcile.Result{}, errors.New("control plane endpoint allocation requires an ipv4 or ipv6 pool")
+	}
+
+	clusterScope.ProxmoxCluster.Status.ControlPlaneEndpointAddresses = addresses
+
+	endpoint := clusterScope.ProxmoxCluster.Spec.ControlPlaneEndpoint
+	if endpoint == nil {
+		endpoint = &clusterv1.APIEndpoint{}
+		clusterScope.ProxmoxCluster.Spec.ControlPlaneEndpoint = endpoint
+	}
+	endpoint.Host = addresses[0]
+	if endpoint.Port == 0 {
+		endpoint.Port = endpointIPAM.Port
+	}
+	// clusters created before the port was configurable do not have it defaulted.
+	if endpoint.Port == 0 {
+		endpoint.Port = ControlPlaneEndpointPort
+	}
+
+	return reconcile.Result{}, nil
+}
+
 func (r *ProxmoxClusterReconciler) reconcileNormalCredentialsSecret(ctx context.Context, clusterScope *scope.ClusterScope) error {
 	proxmoxCluster := clusterScope.ProxmoxCluster
 	if !hasCredentialsRef(proxmoxCluster) {
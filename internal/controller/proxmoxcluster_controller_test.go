@@ -24,6 +24,8 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/luthermonson/go-proxmox"
+	"github.com/stretchr/testify/mock"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -202,6 +204,199 @@ var _ = Describe("Controller Test", func() {
 				WithPolling(time.Second).
 				Should(Succeed())
 		})
+		It("Should allocate a dual-stack ControlPlaneEndpoint", func() {
+			cl := buildProxmoxCluster(clusterName)
+			cl.Spec.ControlPlaneEndpoint = nil
+			cl.Spec.ControlPlaneEndpointIPAM = &infrav1.ControlPlaneEndpointIPAM{PrimaryFamily: "ipv6"}
+			cl.Spec.IPv6Config = &infrav1.IPConfigSpec{
+				Addresses: []string{"2001:db8::/64"},
+				Prefix:    64,
+				Gateway:   "2001:db8::1",
+			}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cl)).NotTo(HaveOccurred())
+
+			helper := ipam.NewHelper(k8sClient, &cl)
+
+			// the claims are fulfilled by the in-cluster ipam provider, which is not running.
+			for format, address := range map[string]string{infrav1.IPV4Format: "10.10.10.11", infrav1.IPV6Format: "2001:db8::11"} {
+				name := ipam.ControlPlaneEndpointClaimName(&cl, format)
+				g.Eventually(func(g Gomega) {
+					g.Expect(k8sClient.Get(testEnv.GetContext(), client.ObjectKey{Namespace: testNS, Name: name}, &ipamv1.IPAddressClaim{})).To(Succeed())
+				}).WithTimeout(time.Second * 10).
+					WithPolling(time.Second).
+					Should(Succeed())
+
+				pool, err := helper.GetDefaultInClusterIPPool(testEnv.GetContext(), format)
+				g.Expect(err).ToNot(HaveOccurred())
+				ipAddress := dummyIPAddress(k8sClient, &cl, pool.GetName())
+				ipAddress.SetName(name)
+				ipAddress.Spec.ClaimRef.Name = name
+				ipAddress.Spec.Address = address
+				g.Expect(k8sClient.Create(testEnv.GetContext(), ipAddress)).To(Succeed())
+			}
+
+			assertClusterIsReady(testEnv.GetContext(), g, clusterName)
+
+			g.Expect(k8sClient.Get(testEnv.GetContext(), client.ObjectKeyFromObject(&cl), &cl)).To(Succeed())
+			g.Expect(cl.Spec.ControlPlaneEndpoint).ToNot(BeNil())
+			g.Expect(cl.Spec.ControlPlaneEndpoint.Host).To(Equal("2001:db8::11"))
+			g.Expect(cl.Spec.ControlPlaneEndpoint.Port).To(BeEquivalentTo(ControlPlaneEndpointPort))
+			g.Expect(cl.Status.ControlPlaneEndpointAddresses).To(Equal([]string{"2001:db8::11", "10.10.10.11"}))
+
+			cleanupResources(testEnv.GetContext(), g, cl)
+
+			// claims are not garbage collected by envtest, so they have to be deleted by the controller.
+			for _, format := range []string{infrav1.IPV4Format, infrav1.IPV6Format} {
+				err := k8sClient.Get(testEnv.GetContext(), client.ObjectKey{Namespace: testNS, Name: ipam.ControlPlaneEndpointClaimName(&cl, format)}, &ipamv1.IPAddressClaim{})
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			}
+		})
+
+		It("Should allocate a ControlPlaneEndpoint with a custom port", func() {
+			cl := buildProxmoxCluster(clusterName)
+			cl.Spec.ControlPlaneEndpoint = nil
+			cl.Spec.ControlPlaneEndpointIPAM = &infrav1.ControlPlaneEndpointIPAM{Port: 443}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cl)).NotTo(HaveOccurred())
+
+			helper := ipam.NewHelper(k8sClient, &cl)
+
+			// the claim is fulfilled by the in-cluster ipam provider, which is not running.
+			name := ipam.ControlPlaneEndpointClaimName(&cl, infrav1.IPV4Format)
+			g.Eventually(func(g Gomega) {
+				g.Expect(k8sClient.Get(testEnv.GetContext(), client.ObjectKey{Namespace: testNS, Name: name}, &ipamv1.IPAddressClaim{})).To(Succeed())
+			}).WithTimeout(time.Second * 10).
+				WithPolling(time.Second).
+				Should(Succeed())
+
+			pool, err := helper.GetDefaultInClusterIPPool(testEnv.GetContext(), infrav1.IPV4Format)
+			g.Expect(err).ToNot(HaveOccurred())
+			ipAddress := dummyIPAddress(k8sClient, &cl, pool.GetName())
+			ipAddress.SetName(name)
+			ipAddress.Spec.ClaimRef.Name = name
+			ipAddress.Spec.Address = "10.10.10.11"
+			g.Expect(k8sClient.Create(testEnv.GetContext(), ipAddress)).To(Succeed())
+
+			assertClusterIsReady(testEnv.GetContext(), g, clusterName)
+
+			g.Expect(k8sClient.Get(testEnv.GetContext(), client.ObjectKeyFromObject(&cl), &cl)).To(Succeed())
+			g.Expect(cl.Spec.ControlPlaneEndpoint).ToNot(BeNil())
+			g.Expect(cl.Spec.ControlPlaneEndpoint.Host).To(Equal("10.10.10.11"))
+			g.Expect(cl.Spec.ControlPlaneEndpoint.Port).To(BeEquivalentTo(443))
+
+			cleanupResources(testEnv.GetContext(), g, cl)
+		})
+
+		It("Should wait for an externally managed ControlPlaneEndpoint", func() {
+			cl := buildProxmoxCluster(clusterName)
+			cl.Spec.ControlPlaneEndpoint = nil
+			cl.Spec.ExternalManagedControlPlane = true
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cl)).NotTo(HaveOccurred())
+			defer cleanupResources(testEnv.GetContext(), g, cl)
+
+			// the pools are required by the machines providing the endpoint.
+			g.Eventually(func(g Gomega) {
+				g.Expect(k8sClient.Get(testEnv.GetContext(), client.ObjectKeyFromObject(&cl), &cl)).To(Succeed())
+				g.Expect(cl.Status.InClusterIPPoolRef).NotTo(BeEmpty())
+				g.Expect(conditions.GetReason(&cl, infrav1.ProxmoxClusterReady)).To(Equal(infrav1.MissingControlPlaneEndpointReason))
+			}).WithTimeout(time.Second * 10).
+				WithPolling(time.Second).
+				Should(Succeed())
+			g.Expect(cl.Status.Ready).To(BeFalse())
+
+			// the controller keeps requeueing the cluster, so the update might conflict.
+			g.Eventually(func(g Gomega) {
+				g.Expect(k8sClient.Get(testEnv.GetContext(), client.ObjectKeyFromObject(&cl), &cl)).To(Succeed())
+				cl.Spec.ControlPlaneEndpoint = &clusterv1.APIEndpoint{Host: "10.10.10.11", Port: 6443}
+				g.Expect(k8sClient.Update(testEnv.GetContext(), &cl)).To(Succeed())
+			}).WithTimeout(time.Second * 10).
+				WithPolling(time.Second).
+				Should(Succeed())
+
+			assertClusterIsReady(testEnv.GetContext(), g, clusterName)
+
+			// no address is claimed for an externally managed endpoint.
+			name := ipam.ControlPlaneEndpointClaimName(&cl, infrav1.IPV4Format)
+			err := k8sClient.Get(testEnv.GetContext(), client.ObjectKey{Namespace: testNS, Name: name}, &ipamv1.IPAddressClaim{})
+			g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("Should not create IPAM resources of an externally managed cluster", func() {
+			cl := buildProxmoxCluster(clusterName)
+			cl.SetAnnotations(map[string]string{clusterv1.ManagedByAnnotation: "external"})
+			cl.Spec.IPv4Config = nil
+			cl.Spec.IPv4PoolRef = &corev1.TypedLocalObjectReference{
+				APIGroup: ptr.To("ipam.cluster.x-k8s.io"),
+				Kind:     "InfobloxIPPool",
+				Name:     "infoblox-v4",
+			}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cl)).NotTo(HaveOccurred())
+			defer cleanupResources(testEnv.GetContext(), g, cl)
+
+			assertClusterIsReady(testEnv.GetContext(), g, clusterName)
+
+			var pools ipamicv1.InClusterIPPoolList
+			g.Expect(k8sClient.List(testEnv.GetContext(), &pools, client.InNamespace(testNS))).To(Succeed())
+			g.Expect(pools.Items).To(BeEmpty())
+
+			g.Expect(k8sClient.Get(testEnv.GetContext(), client.ObjectKeyFromObject(&cl), &cl)).To(Succeed())
+			g.Expect(cl.Status.InClusterIPPoolRef).To(BeEmpty())
+			g.Expect(conditions.IsTrue(&cl, infrav1.ProxmoxClusterReady)).To(BeTrue())
+		})
+
+		It("Should delete IPAddressClaims of deleted machines", func() {
+			cl := buildProxmoxCluster(clusterName)
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cl)).NotTo(HaveOccurred())
+			defer cleanupResources(testEnv.GetContext(), g, cl)
+
+			assertClusterIsReady(testEnv.GetContext(), g, clusterName)
+
+			machine := &infrav1.ProxmoxMachine{
+				ObjectMeta: metav1.ObjectMeta{Name: "orphan", Namespace: testNS},
+				Spec: infrav1.ProxmoxMachineSpec{
+					VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{SourceNode: "pve1"},
+				},
+			}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), machine)).To(Succeed())
+
+			helper := ipam.NewHelper(k8sClient, &cl)
+			g.Expect(helper.CreateIPAddressClaim(testEnv.GetContext(), machine, infrav1.DefaultNetworkDevice, infrav1.IPV4Format, "test", nil)).To(Succeed())
+
+			// the machine is removed without its finalizer, envtest doesn't garbage collect the claim.
+			g.Expect(k8sClient.Delete(testEnv.GetContext(), machine)).To(Succeed())
+
+			// orphans are collected on the next reconciliation of the cluster.
+			g.Expect(k8sClient.Get(testEnv.GetContext(), client.ObjectKeyFromObject(&cl), &cl)).To(Succeed())
+			cl.SetAnnotations(map[string]string{"test": "reconcile"})
+			g.Expect(k8sClient.Update(testEnv.GetContext(), &cl)).To(Succeed())
+
+			name := ipam.IPAddressClaimName(machine, infrav1.DefaultNetworkDevice, infrav1.IPV4Format)
+			g.Eventually(func(g Gomega) {
+				err := k8sClient.Get(testEnv.GetContext(), client.ObjectKey{Namespace: testNS, Name: name}, &ipamv1.IPAddressClaim{})
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			}).WithTimeout(time.Second * 20).
+				WithPolling(time.Second).
+				Should(Succeed())
+		})
+
+		It("Should report an invalid ip config", func() {
+			cl := buildProxmoxCluster(clusterName)
+			cl.Spec.IPv4Config.Gateway = "10.10.11.1"
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cl)).NotTo(HaveOccurred())
+
+			defer cleanupResources(testEnv.GetContext(), g, cl)
+
+			g.Eventually(func(g Gomega) {
+				var res infrav1.ProxmoxCluster
+				g.Expect(k8sClient.Get(testEnv.GetContext(), client.ObjectKeyFromObject(&cl), &res)).To(Succeed())
+
+				g.Expect(conditions.GetReason(&res, infrav1.ProxmoxClusterReady)).To(Equal(infrav1.InvalidIPConfigReason))
+				g.Expect(conditions.GetMessage(&res, infrav1.ProxmoxClusterReady)).To(ContainSubstring("spec.ipv4Config.gateway"))
+				g.Expect(res.Status.InClusterIPPoolRef).To(BeEmpty())
+			}).WithTimeout(time.Second * 20).
+				WithPolling(time.Second).
+				Should(Succeed())
+		})
+
 		It("Should reconcile failed cluster state", func() {
 			cl := buildProxmoxCluster(clusterName)
 			cl.Status.FailureReason = ptr.To(clustererrors.InvalidConfigurationClusterError)
@@ -226,6 +421,67 @@ var _ = Describe("Controller Test", func() {
 
 		})
 	})
+
+	Context("Failure domains", func() {
+		It("Should report failure domains with existing nodes", func() {
+			proxmoxClient.EXPECT().ListNodes(mock.Anything).Return([]string{"pve1", "pve2"}, nil).Maybe()
+
+			cl := buildProxmoxCluster(clusterName)
+			cl.Spec.FailureDomains = map[string]infrav1.FailureDomainSpec{
+				"dc1": {Nodes: []string{"pve1"}},
+				"dc2": {Nodes: []string{"pve2"}, ControlPlane: ptr.To(false)},
+			}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cl)).NotTo(HaveOccurred())
+			defer cleanupResources(testEnv.GetContext(), g, cl)
+
+			g.Eventually(func(g Gomega) {
+				var res infrav1.ProxmoxCluster
+				g.Expect(k8sClient.Get(testEnv.GetContext(), client.ObjectKeyFromObject(&cl), &res)).To(Succeed())
+
+				g.Expect(res.Status.FailureDomains).To(Equal(clusterv1.FailureDomains{
+					"dc1": {ControlPlane: true},
+					"dc2": {ControlPlane: false},
+				}))
+			}).WithTimeout(time.Second * 20).
+				WithPolling(time.Second).
+				Should(Succeed())
+		})
+	})
+
+	Context("Retained VMs", func() {
+		It("Should delete expired retained VMs", func() {
+			proxmoxClient.EXPECT().CheckID(mock.Anything, int64(1234)).Return(false, nil).Once()
+			proxmoxClient.EXPECT().FindVMResource(mock.Anything, uint64(1234)).Return(&proxmox.ClusterResource{
+				Name: "retained", Node: "pve1", Tags: "cluster_test;" + infrav1.RetainedVMTag,
+			}, nil).Once()
+			proxmoxClient.EXPECT().DeleteVM(mock.Anything, "pve1", int64(1234)).Return(&proxmox.Task{}, nil).Once()
+			proxmoxClient.EXPECT().CheckID(mock.Anything, int64(1234)).Return(true, nil).Maybe()
+
+			cl := buildProxmoxCluster(clusterName)
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cl)).NotTo(HaveOccurred())
+			defer cleanupResources(testEnv.GetContext(), g, cl)
+
+			assertClusterIsReady(testEnv.GetContext(), g, clusterName)
+
+			g.Eventually(func(g Gomega) {
+				g.Expect(k8sClient.Get(testEnv.GetContext(), client.ObjectKeyFromObject(&cl), &cl)).To(Succeed())
+				cl.Status.RetainedVMs = []infrav1.RetainedVM{
+					{Name: "retained", VMID: 1234, Node: "pve1", ExpiresAt: ptr.To(metav1.NewTime(time.Now().Add(-time.Minute)))},
+				}
+				g.Expect(k8sClient.Status().Update(testEnv.GetContext(), &cl)).To(Succeed())
+			}).WithTimeout(time.Second * 20).
+				WithPolling(time.Second).
+				Should(Succeed())
+
+			g.Eventually(func(g Gomega) {
+				var res infrav1.ProxmoxCluster
+				g.Expect(k8sClient.Get(testEnv.GetContext(), client.ObjectKeyFromObject(&cl), &res)).To(Succeed())
+				g.Expect(res.Status.RetainedVMs).To(BeEmpty())
+			}).WithTimeout(time.Second * 20).
+				WithPolling(time.Second).
+				Should(Succeed())
+		})
+	})
 })
 
 var _ = Describe("External Credentials Tests", func() {
@@ -358,7 +614,7 @@ func buildProxmoxCluster(name string) infrav1.ProxmoxCluster {
 				Addresses: []string{
 					"10.10.10.2-10.10.10.10",
 					"10.10.10.100-10.10.10.125",
-					"10.10.10.192/64",
+					"10.10.10.192/26",
 				},
 				Gateway: "10.10.10.1",
 				Prefix:  24,
@@ -437,7 +693,7 @@ func createProxmoxCluster() *infrav1.ProxmoxCluster {
 				Addresses: []string{
 					"10.10.10.2-10.10.10.10",
 					"10.10.10.100-10.10.10.125",
-					"10.10.10.192/64",
+					"10.10.10.192/26",
 				},
 				Gateway: "10.10.10.1",
 				Prefix:  24,
@@ -18,9 +18,14 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
@@ -29,14 +34,17 @@ import (
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/metrics"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/service/taskservice"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/service/vmservice"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/kubernetes/ipam"
@@ -44,18 +52,24 @@ import (
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
 )
 
+// failedBeforeCloneRequeue is the time after which a machine which failed before its VM was cloned is checked again.
+const failedBeforeCloneRequeue = time.Minute
+
 // ProxmoxMachineReconciler reconciles a ProxmoxMachine object.
 type ProxmoxMachineReconciler struct {
 	client.Client
 	Scheme        *runtime.Scheme
 	Recorder      record.EventRecorder
 	ProxmoxClient proxmox.Client
+	// TaskTimeout is the time after which a running Proxmox task is considered failed.
+	TaskTimeout time.Duration
 }
 
 // SetupWithManager sets up the controller with the Manager.
-func (r *ProxmoxMachineReconciler) SetupWithManager(mgr ctrl.Manager) error {
+func (r *ProxmoxMachineReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&infrav1alpha1.ProxmoxMachine{}).
+		WithOptions(options).
 		Watches(
 			&clusterv1.Machine{},
 			handler.EnqueueRequestsFromMapFunc(util.MachineToInfrastructureMapFunc(infrav1alpha1.GroupVersion.WithKind(infrav1alpha1.ProxmoxMachineKind))),
@@ -68,6 +82,7 @@ func (r *ProxmoxMachineReconciler) SetupWithManager(mgr ctrl.Manager) error {
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=proxmoxmachines/finalizers,verbs=update
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines;machines/status,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=secrets;,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=events,verbs=get;list;watch;create;update;patch
 
 // +kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=ipaddresses,verbs=get;list;watch
@@ -81,6 +96,12 @@ func (r *ProxmoxMachineReconciler) SetupWithManager(mgr ctrl.Manager) error {
 func (r *ProxmoxMachineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
 	logger := log.FromContext(ctx)
 
+	start := time.Now()
+	var clusterName string
+	defer func() {
+		metrics.ObserveReconcile("proxmoxmachine", req.Namespace, clusterName, start, reterr)
+	}()
+
 	// Fetch the ProxmoxMachine instance.
 	proxmoxMachine := &infrav1alpha1.ProxmoxMachine{}
 	err := r.Get(ctx, req.NamespacedName, proxmoxMachine)
@@ -110,12 +131,15 @@ func (r *ProxmoxMachineReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		return ctrl.Result{}, nil
 	}
 
-	if annotations.IsPaused(cluster, proxmoxMachine) {
-		logger.Info("ProxmoxMachine or linked Cluster is marked as paused, not reconciling")
-		return ctrl.Result{}, nil
+	if paused, err := r.reconcilePaused(ctx, cluster, proxmoxMachine); err != nil || paused {
+		if paused {
+			logger.Info("ProxmoxMachine or linked Cluster is marked as paused, not reconciling")
+		}
+		return ctrl.Result{}, err
 	}
 
 	logger = logger.WithValues("cluster", klog.KObj(cluster))
+	clusterName = cluster.Name
 
 	infraCluster, err := r.getInfraCluster(ctx, &logger, cluster, proxmoxMachine)
 	if err != nil {
@@ -135,6 +159,8 @@ func (r *ProxmoxMachineReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		ProxmoxMachine: proxmoxMachine,
 		IPAMHelper:     ipam.NewHelper(r.Client, infraCluster.ProxmoxCluster),
 		Logger:         &logger,
+		TaskTimeout:    r.TaskTimeout,
+		Recorder:       r.Recorder,
 	})
 	if err != nil {
 		logger.Error(err, "failed to create scope")
@@ -155,8 +181,39 @@ func (r *ProxmoxMachineReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	return r.reconcileNormal(ctx, machineScope, infraCluster)
 }
 
+// reconcilePaused reports a paused ProxmoxMachine in the Paused condition, and removes it once reconciliation resumes.
+// A paused ProxmoxMachine is left alone entirely, its finalizer is kept even if it is deleted.
+func (r *ProxmoxMachineReconciler) reconcilePaused(ctx context.Context, cluster *clusterv1.Cluster, proxmoxMachine *infrav1alpha1.ProxmoxMachine) (bool, error) {
+	paused := annotations.IsPaused(cluster, proxmoxMachine)
+	if paused == conditions.Has(proxmoxMachine, infrav1alpha1.PausedCondition) {
+		return paused, nil
+	}
+
+	patchHelper, err := patch.NewHelper(proxmoxMachine, r.Client)
+	if err != nil {
+		return paused, err
+	}
+
+	if paused {
+		conditions.MarkTrue(proxmoxMachine, infrav1alpha1.PausedCondition)
+	} else {
+		conditions.Delete(proxmoxMachine, infrav1alpha1.PausedCondition)
+	}
+
+	return paused, patchHelper.Patch(ctx, proxmoxMachine, patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{
+		infrav1alpha1.PausedCondition,
+	}})
+}
+
 func (r *ProxmoxMachineReconciler) reconcileDelete(ctx context.Context, machineScope *scope.MachineScope) (ctrl.Result, error) {
 	machineScope.Logger.Info("Handling deleted ProxmoxMachine")
+
+	if reason := machineDeletionPending(machineScope.Cluster, machineScope.Machine); reason != "" {
+		machineScope.Logger.Info("Keeping the VM until the Machine is ready for deletion", "reason", reason)
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.WaitingForMachineDeletionReason, clusterv1.ConditionSeverityInfo, reason)
+		return reconcile.Result{RequeueAfter: infrav1alpha1.DefaultReconcilerRequeue}, nil
+	}
+
 	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, clusterv1.DeletingReason, clusterv1.ConditionSeverityInfo, "")
 
 	err := vmservice.DeleteVM(ctx, machineScope)
@@ -167,11 +224,48 @@ func (r *ProxmoxMachineReconciler) reconcileDelete(ctx context.Context, machineS
 	return reconcile.Result{RequeueAfter: infrav1alpha1.DefaultReconcilerRequeue}, nil
 }
 
+// machineDeletionPending returns why the VM of a deleted ProxmoxMachine must be kept, or an empty string.
+// Cluster API only deletes the ProxmoxMachine once the node of the Machine is drained and its deletion hooks are done.
+// A ProxmoxMachine deleted otherwise waits for the same, so the VM is not destroyed under running workloads.
+// Nodes are not drained if the Machine itself is not deleted, or the whole cluster is.
+func machineDeletionPending(cluster *clusterv1.Cluster, machine *clusterv1.Machine) string {
+	var hooks []string
+	for name := range machine.GetAnnotations() {
+		if strings.HasPrefix(name, clusterv1.PreDrainDeleteHookAnnotationPrefix) || strings.HasPrefix(name, clusterv1.PreTerminateDeleteHookAnnotationPrefix) {
+			hooks = append(hooks, name)
+		}
+	}
+	if len(hooks) > 0 {
+		slices.Sort(hooks)
+		return fmt.Sprintf("waiting for the deletion hooks %s of machine %s", strings.Join(hooks, ", "), machine.GetName())
+	}
+
+	if machine.DeletionTimestamp.IsZero() || machine.Status.NodeRef == nil || !cluster.DeletionTimestamp.IsZero() {
+		return ""
+	}
+	if _, exclude := machine.GetAnnotations()[clusterv1.ExcludeNodeDrainingAnnotation]; exclude {
+		return ""
+	}
+
+	// Cluster API gives up on draining once the NodeDrainTimeout passed since the first attempt.
+	drained := conditions.Get(machine, clusterv1.DrainingSucceededCondition)
+	if drained != nil {
+		if drained.Status == corev1.ConditionTrue {
+			return ""
+		}
+		if timeout := machine.Spec.NodeDrainTimeout; timeout != nil && timeout.Duration > 0 && time.Since(drained.LastTransitionTime.Time) >= timeout.Duration {
+			return ""
+		}
+	}
+	return fmt.Sprintf("waiting for node %s of machine %s to be drained", machine.Status.NodeRef.Name, machine.GetName())
+}
+
 func (r *ProxmoxMachineReconciler) reconcileNormal(ctx context.Context, machineScope *scope.MachineScope, clusterScope *scope.ClusterScope) (reconcile.Result, error) {
 	clusterScope.Logger.V(4).Info("Reconciling ProxmoxMachine")
 
 	// If the ProxmoxMachine is in an error state, return early.
-	if machineScope.HasFailed() {
+	// Failures before the VM was cloned are checked again, as they may resolve, e.g. once the missing template exists.
+	if machineScope.HasFailed() && !failedBeforeClone(machineScope) {
 		machineScope.Info("Error state detected, skipping reconciliation")
 		return ctrl.Result{}, nil
 	}
@@ -198,16 +292,24 @@ func (r *ProxmoxMachineReconciler) reconcileNormal(ctx context.Context, machineS
 		}
 	}
 
+	applyMachineDefaults(clusterScope, machineScope)
+
+	// A failure which occurs again is set again by ReconcileVM.
+	failure := machineScope.ProxmoxMachine.Status.FailureMessage
+	machineScope.ClearFailure()
+
 	// find the vm
 	// Get or create the VM.
 	vm, err := vmservice.ReconcileVM(ctx, machineScope)
+	if failure != nil && !machineScope.HasFailed() {
+		machineScope.Info("Failure resolved", "failure", *failure)
+	}
 	if err != nil {
 		if requeueErr := new(taskservice.RequeueError); errors.As(err, &requeueErr) {
-			machineScope.Error(err, "Requeue requested")
+			machineScope.V(4).Info("Requeue requested", "reason", err.Error())
 			return reconcile.Result{RequeueAfter: requeueErr.RequeueAfter()}, nil
 		}
-		machineScope.Logger.Error(err, "error reconciling VM")
-		return reconcile.Result{}, errors.Wrapf(err, "failed to reconcile VM")
+		return reconcileVMError(machineScope, err)
 	}
 	machineScope.ProxmoxMachine.Status.VMStatus = vm.State
 
@@ -229,6 +331,42 @@ func (r *ProxmoxMachineReconciler) reconcileNormal(ctx context.Context, machineS
 	return reconcile.Result{}, nil
 }
 
+// applyMachineDefaults sets the unset fields of the machine to the machine defaults of the cluster.
+// The defaults only apply to new VMs, changing them doesn't change existing VMs.
+func applyMachineDefaults(clusterScope *scope.ClusterScope, machineScope *scope.MachineScope) {
+	defaults := clusterScope.ProxmoxCluster.Spec.MachineDefaults
+	if defaults == nil || machineScope.ProxmoxMachine.Spec.VirtualMachineID != nil || machineScope.ProxmoxMachine.Spec.ExistingVMID != nil {
+		return
+	}
+	defaults.ApplyTo(&machineScope.ProxmoxMachine.Spec)
+}
+
+// reconcileVMError handles an error of reconciling the VM. Terminal errors, which have set the failure
+// reason of the machine, are not retried: retrying a misconfiguration doesn't fix it. Failures before the clone
+// are only checked again after failedBeforeCloneRequeue, as they may be fixed outside of the cluster.
+// All other errors are returned, so the controller retries them with a capped exponential backoff.
+func reconcileVMError(machineScope *scope.MachineScope, err error) (ctrl.Result, error) {
+	if machineScope.HasFailed() {
+		machineScope.Logger.Error(err, "VM failed terminally, not retrying")
+		machineScope.Warnf("ReconcileFailed", "Failed to reconcile VM terminally: %v", err)
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.VMProvisionFailedReason, clusterv1.ConditionSeverityError, err.Error())
+		if failedBeforeClone(machineScope) {
+			return reconcile.Result{RequeueAfter: failedBeforeCloneRequeue}, nil
+		}
+		return reconcile.Result{}, nil
+	}
+
+	machineScope.Logger.Error(err, "error reconciling VM")
+	machineScope.Warnf("ReconcileFailed", "Failed to reconcile VM: %v", err)
+	return reconcile.Result{}, errors.Wrapf(err, "failed to reconcile VM")
+}
+
+// failedBeforeClone returns whether the ProxmoxMachine failed before its VM was cloned.
+// These failures are about the preconditions of the clone, like the template, the storage or the free memory of the nodes.
+func failedBeforeClone(machineScope *scope.MachineScope) bool {
+	return machineScope.HasFailed() && machineScope.ProxmoxMachine.Spec.VirtualMachineID == nil
+}
+
 func (r *ProxmoxMachineReconciler) getInfraCluster(ctx context.Context, logger *logr.Logger, cluster *clusterv1.Cluster, proxmoxMachine *infrav1alpha1.ProxmoxMachine) (*scope.ClusterScope, error) {
 	var clusterScope *scope.ClusterScope
 	var err error
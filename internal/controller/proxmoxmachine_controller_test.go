@@ -18,15 +18,28 @@ package controller
 
 import (
 	"context"
+	"errors"
+	"time"
 
+	"github.com/go-logr/logr"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/kubernetes/ipam"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/proxmoxtest"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
 )
 
 var _ = Describe("ProxmoxMachineReconciler", func() {
@@ -53,5 +66,327 @@ var _ = Describe("ProxmoxMachineReconciler", func() {
 			Expect(result.RequeueAfter).To(BeZero())
 			Expect(result.Requeue).To(BeFalse())
 		})
+
+		It("should not touch a paused machine", func() {
+			ctx := context.Background()
+			// any call to Proxmox fails the test.
+			reconciler := &ProxmoxMachineReconciler{
+				Client:        k8sClient,
+				Scheme:        runtime.NewScheme(),
+				ProxmoxClient: proxmoxtest.NewMockClient(GinkgoT()),
+			}
+
+			cluster := &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "paused", Namespace: testNS},
+				Spec: clusterv1.ClusterSpec{
+					InfrastructureRef: &corev1.ObjectReference{Kind: "ProxmoxCluster", Name: "paused"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, cluster)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, cluster)
+
+			machine := &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "paused",
+					Namespace: testNS,
+					Labels:    map[string]string{clusterv1.ClusterNameLabel: cluster.Name},
+				},
+				Spec: clusterv1.MachineSpec{
+					ClusterName: cluster.Name,
+					Bootstrap:   clusterv1.Bootstrap{DataSecretName: ptr.To("paused")},
+				},
+			}
+			Expect(k8sClient.Create(ctx, machine)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, machine)
+
+			proxmoxMachine := &infrav1.ProxmoxMachine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "paused",
+					Namespace:   testNS,
+					Annotations: map[string]string{clusterv1.PausedAnnotation: ""},
+					OwnerReferences: []metav1.OwnerReference{{
+						APIVersion: clusterv1.GroupVersion.String(),
+						Kind:       "Machine",
+						Name:       machine.Name,
+						UID:        machine.UID,
+					}},
+				},
+				Spec: infrav1.ProxmoxMachineSpec{
+					VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{SourceNode: "pve1"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, proxmoxMachine)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, proxmoxMachine)
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(proxmoxMachine)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(proxmoxMachine), proxmoxMachine)).To(Succeed())
+			Expect(conditions.IsTrue(proxmoxMachine, infrav1.PausedCondition)).To(BeTrue())
+			Expect(proxmoxMachine.Finalizers).To(BeEmpty())
+
+			// resuming removes the condition.
+			proxmoxMachine.SetAnnotations(nil)
+			Expect(k8sClient.Update(ctx, proxmoxMachine)).To(Succeed())
+			_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(proxmoxMachine)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(proxmoxMachine), proxmoxMachine)).To(Succeed())
+			Expect(conditions.Has(proxmoxMachine, infrav1.PausedCondition)).To(BeFalse())
+		})
+
+		It("should keep the VM while the machine has a pre-terminate hook", func() {
+			ctx := context.Background()
+			// deleting the VM fails the test.
+			reconciler := &ProxmoxMachineReconciler{
+				Client:        k8sClient,
+				Scheme:        runtime.NewScheme(),
+				ProxmoxClient: proxmoxtest.NewMockClient(GinkgoT()),
+			}
+
+			cluster := &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "hooked", Namespace: testNS},
+				Spec: clusterv1.ClusterSpec{
+					InfrastructureRef: &corev1.ObjectReference{Kind: "ProxmoxCluster", Name: "hooked"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, cluster)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, cluster)
+
+			proxmoxCluster := &infrav1.ProxmoxCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "hooked", Namespace: testNS},
+				Spec:       infrav1.ProxmoxClusterSpec{DNSServers: []string{"8.8.8.8"}},
+			}
+			Expect(k8sClient.Create(ctx, proxmoxCluster)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, proxmoxCluster)
+
+			machine := &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "hooked",
+					Namespace:   testNS,
+					Labels:      map[string]string{clusterv1.ClusterNameLabel: cluster.Name},
+					Annotations: map[string]string{clusterv1.PreTerminateDeleteHookAnnotationPrefix + "/backup": ""},
+				},
+				Spec: clusterv1.MachineSpec{
+					ClusterName: cluster.Name,
+					Bootstrap:   clusterv1.Bootstrap{DataSecretName: ptr.To("hooked")},
+				},
+			}
+			Expect(k8sClient.Create(ctx, machine)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, machine)
+
+			proxmoxMachine := &infrav1.ProxmoxMachine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "hooked",
+					Namespace:  testNS,
+					Finalizers: []string{infrav1.MachineFinalizer},
+					OwnerReferences: []metav1.OwnerReference{{
+						APIVersion: clusterv1.GroupVersion.String(),
+						Kind:       "Machine",
+						Name:       machine.Name,
+						UID:        machine.UID,
+					}},
+				},
+				Spec: infrav1.ProxmoxMachineSpec{
+					VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{SourceNode: "pve1"},
+					VirtualMachineID:        ptr.To[int64](100),
+				},
+			}
+			Expect(k8sClient.Create(ctx, proxmoxMachine)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, proxmoxMachine)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(proxmoxMachine), proxmoxMachine)).To(Succeed())
+				proxmoxMachine.SetFinalizers(nil)
+				Expect(k8sClient.Update(ctx, proxmoxMachine)).To(Succeed())
+			})
+
+			result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(proxmoxMachine)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(infrav1.DefaultReconcilerRequeue))
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(proxmoxMachine), proxmoxMachine)).To(Succeed())
+			Expect(proxmoxMachine.Finalizers).To(ContainElement(infrav1.MachineFinalizer))
+			Expect(conditions.GetReason(proxmoxMachine, infrav1.VMProvisionedCondition)).To(Equal(infrav1.WaitingForMachineDeletionReason))
+			Expect(conditions.GetMessage(proxmoxMachine, infrav1.VMProvisionedCondition)).To(ContainSubstring("pre-terminate.delete.hook.machine.cluster.x-k8s.io/backup"))
+		})
+	})
+
+	Context("machineDeletionPending", func() {
+		deletedMachine := func() *clusterv1.Machine {
+			return &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", DeletionTimestamp: ptr.To(metav1.Now())},
+				Status:     clusterv1.MachineStatus{NodeRef: &corev1.ObjectReference{Name: "node"}},
+			}
+		}
+
+		It("should wait for the node to be drained", func() {
+			machine := deletedMachine()
+			Expect(machineDeletionPending(&clusterv1.Cluster{}, machine)).To(Equal("waiting for node node of machine test to be drained"))
+
+			conditions.MarkTrue(machine, clusterv1.DrainingSucceededCondition)
+			Expect(machineDeletionPending(&clusterv1.Cluster{}, machine)).To(BeEmpty())
+		})
+
+		It("should not wait once the drain timed out", func() {
+			machine := deletedMachine()
+			machine.Spec.NodeDrainTimeout = &metav1.Duration{Duration: time.Minute}
+			conditions.Set(machine, &clusterv1.Condition{
+				Type:               clusterv1.DrainingSucceededCondition,
+				Status:             corev1.ConditionFalse,
+				LastTransitionTime: metav1.NewTime(time.Now().Add(-2 * time.Minute)),
+			})
+			Expect(machineDeletionPending(&clusterv1.Cluster{}, machine)).To(BeEmpty())
+		})
+
+		It("should not wait for nodes which are not drained", func() {
+			machine := deletedMachine()
+			machine.SetAnnotations(map[string]string{clusterv1.ExcludeNodeDrainingAnnotation: ""})
+			Expect(machineDeletionPending(&clusterv1.Cluster{}, machine)).To(BeEmpty())
+
+			Expect(machineDeletionPending(&clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: ptr.To(metav1.Now())}}, deletedMachine())).To(BeEmpty())
+			Expect(machineDeletionPending(&clusterv1.Cluster{}, &clusterv1.Machine{})).To(BeEmpty())
+		})
+	})
+
+	Context("applyMachineDefaults", func() {
+		It("should persist the defaults of the cluster along with the cloned VM", func() {
+			ctx := context.Background()
+			clusterScope := &scope.ClusterScope{ProxmoxCluster: &infrav1.ProxmoxCluster{
+				Spec: infrav1.ProxmoxClusterSpec{MachineDefaults: &infrav1.ProxmoxMachineDefaults{
+					NumCores:  4,
+					MemoryMiB: 8192,
+					Tags:      []string{"capmox"},
+				}},
+			}}
+
+			proxmoxMachine := &infrav1.ProxmoxMachine{
+				ObjectMeta: metav1.ObjectMeta{Name: "defaulted", Namespace: testNS},
+				Spec: infrav1.ProxmoxMachineSpec{
+					VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{SourceNode: "pve1"},
+					MemoryMiB:               2048,
+				},
+			}
+			Expect(k8sClient.Create(ctx, proxmoxMachine)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, proxmoxMachine)
+
+			machineScope, err := scope.NewMachineScope(scope.MachineScopeParams{
+				Client:         k8sClient,
+				Cluster:        &clusterv1.Cluster{},
+				Machine:        &clusterv1.Machine{},
+				InfraCluster:   clusterScope,
+				ProxmoxMachine: proxmoxMachine,
+				IPAMHelper:     &ipam.Helper{},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			applyMachineDefaults(clusterScope, machineScope)
+			machineScope.SetVirtualMachineID(100)
+			Expect(machineScope.PatchObject()).To(Succeed())
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(proxmoxMachine), proxmoxMachine)).To(Succeed())
+			Expect(proxmoxMachine.Spec.VirtualMachineID).To(HaveValue(BeEquivalentTo(100)))
+			Expect(proxmoxMachine.Spec.NumCores).To(BeEquivalentTo(4))
+			Expect(proxmoxMachine.Spec.MemoryMiB).To(BeEquivalentTo(2048))
+			Expect(proxmoxMachine.Spec.Tags).To(Equal([]string{"capmox"}))
+
+			// changed defaults don't change the machines whose VM is cloned.
+			clusterScope.ProxmoxCluster.Spec.MachineDefaults.NumCores = 8
+			applyMachineDefaults(clusterScope, machineScope)
+			Expect(proxmoxMachine.Spec.NumCores).To(BeEquivalentTo(4))
+		})
+	})
+
+	Context("reconcileVMError", func() {
+		machineScope := func() *scope.MachineScope {
+			return &scope.MachineScope{
+				Logger:         ptr.To(logr.Discard()),
+				ProxmoxMachine: &infrav1.ProxmoxMachine{ObjectMeta: metav1.ObjectMeta{Name: "test"}},
+				Recorder:       record.NewFakeRecorder(10),
+			}
+		}
+
+		It("should stop requeueing a missing template", func() {
+			ctx := context.Background()
+			mockClient := proxmoxtest.NewMockClient(GinkgoT())
+			mockClient.EXPECT().ListVMResources(mock.Anything).Return(nil, nil).Once()
+			reconciler := &ProxmoxMachineReconciler{Client: k8sClient, ProxmoxClient: mockClient}
+
+			proxmoxMachine := &infrav1.ProxmoxMachine{
+				ObjectMeta: metav1.ObjectMeta{Name: "missing-template", Namespace: testNS},
+				Spec: infrav1.ProxmoxMachineSpec{
+					VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{
+						SourceNode: "pve1",
+						TemplateID: ptr.To[int32](123),
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, proxmoxMachine)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(proxmoxMachine), proxmoxMachine)).To(Succeed())
+				proxmoxMachine.SetFinalizers(nil)
+				Expect(k8sClient.Update(ctx, proxmoxMachine)).To(Succeed())
+				Expect(k8sClient.Delete(ctx, proxmoxMachine)).To(Succeed())
+			})
+
+			clusterScope := &scope.ClusterScope{
+				Logger:         ptr.To(logr.Discard()),
+				ProxmoxCluster: &infrav1.ProxmoxCluster{},
+				ProxmoxClient:  mockClient,
+			}
+			ms, err := scope.NewMachineScope(scope.MachineScopeParams{
+				Client:         k8sClient,
+				Cluster:        &clusterv1.Cluster{Status: clusterv1.ClusterStatus{InfrastructureReady: true}},
+				Machine:        &clusterv1.Machine{Spec: clusterv1.MachineSpec{Bootstrap: clusterv1.Bootstrap{DataSecretName: ptr.To("missing-template")}}},
+				InfraCluster:   clusterScope,
+				ProxmoxMachine: proxmoxMachine,
+				IPAMHelper:     &ipam.Helper{},
+				Recorder:       record.NewFakeRecorder(10),
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			// the clone looks up the template first, and classifies a missing one as terminal.
+			res, err := reconciler.reconcileNormal(ctx, ms, clusterScope)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.RequeueAfter).To(Equal(failedBeforeCloneRequeue))
+			Expect(ms.ProxmoxMachine.Status.FailureReason).To(HaveValue(Equal(capierrors.InvalidConfigurationMachineError)))
+			Expect(ms.ProxmoxMachine.Status.FailureMessage).To(HaveValue(ContainSubstring("no vm template 123 exists")))
+			Expect(conditions.IsFalse(ms.ProxmoxMachine, infrav1.VMProvisionedCondition)).To(BeTrue())
+			Expect(conditions.GetReason(ms.ProxmoxMachine, infrav1.VMProvisionedCondition)).To(Equal(infrav1.VMProvisionFailedReason))
+		})
+
+		It("should not requeue a machine which failed after the clone", func() {
+			ms := machineScope()
+			ms.SetVirtualMachineID(100)
+			err := errors.New("cloud-init failed")
+			ms.SetFailureMessage(err)
+			ms.SetFailureReason(capierrors.CreateMachineError)
+
+			res, err := reconcileVMError(ms, err)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).To(Equal(ctrl.Result{}))
+		})
+
+		It("should keep retrying transient errors", func() {
+			ms := machineScope()
+
+			_, err := reconcileVMError(ms, errors.New("596 Connection timed out"))
+			Expect(err).To(MatchError(ContainSubstring("596 Connection timed out")))
+			Expect(ms.HasFailed()).To(BeFalse())
+		})
+	})
+
+	Context("failedBeforeClone", func() {
+		It("should check failures again until the VM is cloned", func() {
+			ms := &scope.MachineScope{ProxmoxMachine: &infrav1.ProxmoxMachine{}}
+			Expect(failedBeforeClone(ms)).To(BeFalse())
+
+			ms.SetFailureMessage(errors.New("vm template not found"))
+			ms.SetFailureReason(capierrors.InvalidConfigurationMachineError)
+			Expect(failedBeforeClone(ms)).To(BeTrue())
+
+			ms.SetVirtualMachineID(100)
+			Expect(failedBeforeClone(ms)).To(BeFalse())
+
+			ms.ClearFailure()
+			Expect(ms.HasFailed()).To(BeFalse())
+		})
 	})
 })
@@ -25,6 +25,7 @@ import (
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
@@ -73,7 +74,7 @@ var _ = BeforeSuite(func() {
 		Recorder:      &record.FakeRecorder{},
 		ProxmoxClient: testEnv.ProxmoxClient,
 	}
-	Expect(proxmoxClusterReconciler.SetupWithManager(testEnv.GetContext(), testEnv.Manager)).To(Succeed())
+	Expect(proxmoxClusterReconciler.SetupWithManager(testEnv.GetContext(), testEnv.Manager, controller.Options{})).To(Succeed())
 
 	proxmoxMachineReconciler := ProxmoxMachineReconciler{
 		Client:        k8sClient,
@@ -81,7 +82,7 @@ var _ = BeforeSuite(func() {
 		Recorder:      &record.FakeRecorder{},
 		ProxmoxClient: testEnv.ProxmoxClient,
 	}
-	Expect(proxmoxMachineReconciler.SetupWithManager(testEnv.Manager)).To(Succeed())
+	Expect(proxmoxMachineReconciler.SetupWithManager(testEnv.Manager, controller.Options{})).To(Succeed())
 
 	go func() {
 		defer GinkgoRecover()
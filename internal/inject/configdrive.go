@@ -0,0 +1,107 @@
+/*
+Copyright 2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inject
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/diskfs/go-diskfs/filesystem/iso9660"
+	"github.com/luthermonson/go-proxmox"
+	"github.com/pkg/errors"
+)
+
+const (
+	// configDriveLabel is the volume label cloud-init searches for an OpenStack config drive.
+	configDriveLabel = "config-2"
+	configDriveDir   = "/openstack/latest"
+	isoBlockSize     = 2048
+)
+
+// injectConfigDrive injects an OpenStack config drive version 2 into the VirtualMachine.
+// The ISO is named like the NoCloud ISO of go-proxmox, so it is deleted the same way once it is unmounted.
+func (i *ISOInjector) injectConfigDrive(ctx context.Context, userData, metadata, vendorData, network []byte) error {
+	if i.ProxmoxClient == nil {
+		return errors.New("proxmox client is not defined")
+	}
+
+	files := map[string][]byte{
+		"user_data":         userData,
+		"meta_data.json":    metadata,
+		"network_data.json": network,
+	}
+	// cloud-init reads vendor_data.json as a JSON string holding the vendor-data.
+	if len(vendorData) > 0 {
+		encoded, err := json.Marshal(string(vendorData))
+		if err != nil {
+			return errors.Wrap(err, "unable to encode vendor-data")
+		}
+		files["vendor_data.json"] = encoded
+	}
+
+	dir, err := os.MkdirTemp("", "config-drive")
+	if err != nil {
+		return errors.Wrap(err, "unable to create config drive")
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, fmt.Sprintf(proxmox.UserDataISOFormat, i.VirtualMachine.VMID))
+	if err := makeConfigDriveISO(path, files); err != nil {
+		return errors.Wrap(err, "unable to create config drive")
+	}
+
+	if err := i.ProxmoxClient.MountCloudInitISO(ctx, i.VirtualMachine, CloudInitISODevice, path); err != nil {
+		return errors.Wrap(err, "unable to inject config drive")
+	}
+	return nil
+}
+
+// makeConfigDriveISO writes an ISO labeled config-2 to path, with the files in openstack/latest.
+func makeConfigDriveISO(path string, files map[string][]byte) error {
+	iso, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer iso.Close()
+
+	fs, err := iso9660.Create(iso, 0, 0, isoBlockSize, "")
+	if err != nil {
+		return err
+	}
+
+	if err := fs.Mkdir(configDriveDir); err != nil {
+		return err
+	}
+
+	for name, content := range files {
+		file, err := fs.OpenFile(configDriveDir+"/"+name, os.O_CREATE|os.O_RDWR)
+		if err != nil {
+			return err
+		}
+		if _, err := file.Write(content); err != nil {
+			return err
+		}
+	}
+
+	return fs.Finalize(iso9660.FinalizeOptions{
+		RockRidge:        true,
+		VolumeIdentifier: configDriveLabel,
+	})
+}
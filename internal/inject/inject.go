@@ -25,12 +25,13 @@ import (
 
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/cloudinit"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/ignition"
+	capmox "github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
 )
 
 // CloudInitISODevice default device used to inject cdrom iso.
 const CloudInitISODevice = "ide0"
 
-// ISOInjector used to Inject cloudinit userdata, metadata and network-config into a Proxmox VirtualMachine.
+// ISOInjector used to Inject cloudinit userdata, metadata, vendor-data and network-config into a Proxmox VirtualMachine.
 type ISOInjector struct {
 	VirtualMachine *proxmox.VirtualMachine
 
@@ -38,8 +39,16 @@ type ISOInjector struct {
 
 	MetaRenderer    cloudinit.Renderer
 	NetworkRenderer cloudinit.Renderer
+	// VendorRenderer is optional, no vendor-data is injected without it.
+	VendorRenderer cloudinit.Renderer
 
 	IgnitionEnricher *ignition.Enricher
+
+	// Type is the layout of the cloud-init ISO, defaults to NoCloudType.
+	// The renderers must render the metadata and network data of the layout.
+	Type CloudInitType
+	// ProxmoxClient uploads the ISO, it is only required with ConfigDrive2Type.
+	ProxmoxClient capmox.Client
 }
 
 // Inject injects cloudinit userdata, metadata and network-config into a Proxmox VirtualMachine.
@@ -67,8 +76,21 @@ func (i *ISOInjector) injectCloudInit(ctx context.Context) error {
 		return errors.Wrap(err, "unable to render network-config")
 	}
 
-	// Inject an ISO with userdata, metadata and network-config into the VirtualMachine.
-	err = i.VirtualMachine.CloudInit(ctx, CloudInitISODevice, string(i.BootstrapData), string(metadata), "", string(network))
+	// Render vendor-data.
+	var vendorData []byte
+	if i.VendorRenderer != nil {
+		vendorData, err = i.VendorRenderer.Render()
+		if err != nil {
+			return errors.Wrap(err, "unable to render vendor-data")
+		}
+	}
+
+	if i.Type == ConfigDrive2Type {
+		return i.injectConfigDrive(ctx, i.BootstrapData, metadata, vendorData, network)
+	}
+
+	// Inject an ISO with userdata, metadata, vendor-data and network-config into the VirtualMachine.
+	err = i.VirtualMachine.CloudInit(ctx, CloudInitISODevice, string(i.BootstrapData), string(metadata), string(vendorData), string(network))
 	if err != nil {
 		return errors.Wrap(err, "unable to inject CloudInit ISO")
 	}
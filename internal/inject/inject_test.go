@@ -2,6 +2,7 @@ package inject
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"testing"
@@ -158,6 +159,101 @@ func TestISOInjectorInjectCloudInit_Errors(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestISOInjectorInjectConfigDrive(t *testing.T) {
+	client := newTestClient(t)
+
+	vm := &proxmox.VirtualMachine{
+		Node: "pve",
+		VMID: proxmox.StringOrUint64(100),
+		VirtualMachineConfig: &proxmox.VirtualMachineConfig{
+			Agent:     "1",
+			TagsSlice: []string{"my-vm"},
+			Tags:      "my-vm",
+		},
+	}
+
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf(`=~/nodes/%s/status`, "pve"),
+		newJSONResponder(200, proxmox.Node{Name: "pve"}, 2))
+
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf(`=~/nodes/%s/qemu/%d/status/current`, "pve", 100),
+		newJSONResponder(200, vm, 1))
+
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf(`=~/nodes/%s/qemu/%d/config`, "pve", 100),
+		newJSONResponder(200, vm.VirtualMachineConfig, 1))
+
+	vm, err := client.GetVM(context.Background(), "pve", 100)
+	require.NoError(t, err)
+
+	injector := &ISOInjector{
+		VirtualMachine: vm,
+		BootstrapData:  []byte("#cloud-config\n"),
+		MetaRenderer:   cloudinit.NewConfigDriveMetadata("xxx-xxxx", "my-custom-vm", "1.2.3", true),
+		NetworkRenderer: cloudinit.NewNetworkData([]types.NetworkConfigData{
+			{
+				Type:       "ethernet",
+				Name:       "eth0",
+				MacAddress: "92:60:a0:5b:22:c2",
+				IPAddress:  "10.1.1.6/24",
+				Gateway:    "10.1.1.1",
+				DNSServers: []string{"8.8.8.8", "8.8.4.4"},
+			},
+		}),
+		VendorRenderer: cloudinit.NewVendorData("package_update: true\n"),
+		Type:           ConfigDrive2Type,
+		ProxmoxClient:  client,
+	}
+
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf(`=~/nodes/%s/storage`, "pve"),
+		newJSONResponder(200, &proxmox.Storages{{Name: "iso", Content: "iso"}}, 1))
+
+	ptask := &proxmox.Task{
+		UPID:      "UPID:pve:003B4235:1DF4ABCA:667C1C45:vncproxy:103:root@pam:",
+		Type:      "upload",
+		User:      "foo",
+		Status:    "completed",
+		Node:      "pve",
+		IsRunning: false,
+	}
+
+	httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf(`=~/nodes/%s/storage/iso/upload`, "pve"),
+		newJSONResponder(200, ptask.UPID, 1))
+
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf(`=~/nodes/%s/tasks/%s/status`, "pve", string(ptask.UPID)),
+		newJSONResponder(200, ptask, 4))
+
+	var mounted string
+	httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf(`=~/nodes/%s/qemu/%d/config`, "pve", 100),
+		func(req *http.Request) (*http.Response, error) {
+			var options map[string]any
+			if err := json.NewDecoder(req.Body).Decode(&options); err != nil {
+				return nil, err
+			}
+			if device, ok := options[CloudInitISODevice].(string); ok {
+				mounted = device
+			}
+			return httpmock.NewJsonResponse(200, map[string]any{"data": ptask.UPID})
+		})
+
+	err = injector.Inject(context.Background(), "cloud-config")
+	require.NoError(t, err)
+	require.Equal(t, "iso:iso/user-data-100.iso,media=cdrom", mounted)
+	require.Equal(t, 1, httpmock.GetCallCountInfo()[fmt.Sprintf(`POST =~/nodes/%s/storage/iso/upload`, "pve")])
+}
+
+func TestISOInjectorInjectConfigDrive_Errors(t *testing.T) {
+	injector := &ISOInjector{
+		VirtualMachine:  &proxmox.VirtualMachine{Node: "pve", VMID: proxmox.StringOrUint64(100)},
+		BootstrapData:   []byte(""),
+		MetaRenderer:    cloudinit.NewConfigDriveMetadata("xxx-xxxx", "my-custom-vm", "1.2.3", false),
+		NetworkRenderer: cloudinit.NewNetworkData([]types.NetworkConfigData{{Type: "ethernet", Name: "eth0", MacAddress: "92:60:a0:5b:22:c2", DHCP4: true}}),
+		Type:            ConfigDrive2Type,
+	}
+
+	// missing proxmox client
+	err := injector.Inject(context.Background(), "cloud-config")
+	require.ErrorContains(t, err, "proxmox client is not defined")
+}
+
 func TestISOInjectorInjectIgnition(t *testing.T) {
 	client := newTestClient(t)
 
@@ -14,3 +14,13 @@ const (
 	// IgnitionFormat represents the Ignition format.
 	IgnitionFormat BootstrapDataFormat = ignition.FormatIgnition
 )
+
+// CloudInitType represents the layout of the injected cloud-init ISO.
+type CloudInitType string
+
+const (
+	// NoCloudType represents a NoCloud ISO, labeled cidata.
+	NoCloudType CloudInitType = "nocloud"
+	// ConfigDrive2Type represents an OpenStack config drive version 2, labeled config-2.
+	ConfigDrive2Type CloudInitType = "configdrive2"
+)
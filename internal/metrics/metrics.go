@@ -0,0 +1,134 @@
+/*
+Copyright 2023-2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics defines the Prometheus metrics of the controllers.
+// They are served by the metrics endpoint of the manager, next to the metrics of controller-runtime.
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+)
+
+// the phases of the ProxmoxMachines counted in the machines gauge, next to the VM states.
+const (
+	phaseDeleting = "deleting"
+	phaseFailed   = "failed"
+)
+
+var (
+	reconcileTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "capmox_reconcile_total",
+		Help: "Number of reconciles, by controller, namespace, cluster and result.",
+	}, []string{"controller", "namespace", "cluster", "result"})
+
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "capmox_reconcile_duration_seconds",
+		Help:    "Duration of reconciles, by controller.",
+		Buckets: prometheus.ExponentialBuckets(0.01, 2, 12),
+	}, []string{"controller"})
+
+	taskDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "capmox_proxmox_task_duration_seconds",
+		Help:    "Duration of finished Proxmox tasks, e.g. qmclone, by task type and result.",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 12),
+	}, []string{"type", "result"})
+
+	machinesDesc = prometheus.NewDesc("capmox_machines",
+		"Number of ProxmoxMachines, by namespace, cluster and phase.",
+		[]string{"namespace", "cluster", "phase"}, nil)
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(reconcileTotal, reconcileDuration, taskDuration)
+}
+
+// ObserveReconcile records a reconcile of the given controller, which started at start and returned err.
+func ObserveReconcile(controller, namespace, cluster string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	reconcileTotal.WithLabelValues(controller, namespace, cluster, result).Inc()
+	reconcileDuration.WithLabelValues(controller).Observe(time.Since(start).Seconds())
+}
+
+// ObserveTask records a finished Proxmox task of the given type.
+func ObserveTask(taskType string, failed bool, duration time.Duration) {
+	result := "success"
+	if failed {
+		result = "error"
+	}
+	taskDuration.WithLabelValues(taskType, result).Observe(duration.Seconds())
+}
+
+// machineCollector counts the ProxmoxMachines in each phase whenever the metrics are scraped.
+type machineCollector struct {
+	reader client.Reader
+}
+
+// NewMachineCollector returns a collector of the ProxmoxMachines gauge.
+// The machines are listed with reader on every scrape, so it has to be the cache of the manager,
+// which the reconcilers keep up to date anyway. The listed machines are not copied, and must not be modified.
+func NewMachineCollector(reader client.Reader) prometheus.Collector {
+	return &machineCollector{reader: reader}
+}
+
+// Describe implements prometheus.Collector.
+func (c *machineCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- machinesDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *machineCollector) Collect(ch chan<- prometheus.Metric) {
+	var machines infrav1alpha1.ProxmoxMachineList
+	if err := c.reader.List(context.Background(), &machines, client.UnsafeDisableDeepCopy); err != nil {
+		ch <- prometheus.NewInvalidMetric(machinesDesc, err)
+		return
+	}
+
+	type key struct{ namespace, cluster, phase string }
+	counts := map[key]int{}
+	for i := range machines.Items {
+		m := &machines.Items[i]
+		counts[key{m.GetNamespace(), m.GetLabels()[clusterv1.ClusterNameLabel], machinePhase(m)}]++
+	}
+
+	for k, count := range counts {
+		ch <- prometheus.MustNewConstMetric(machinesDesc, prometheus.GaugeValue, float64(count), k.namespace, k.cluster, k.phase)
+	}
+}
+
+// machinePhase returns the lifecycle phase of a ProxmoxMachine.
+func machinePhase(m *infrav1alpha1.ProxmoxMachine) string {
+	switch {
+	case !m.GetDeletionTimestamp().IsZero():
+		return phaseDeleting
+	case m.Status.FailureReason != nil:
+		return phaseFailed
+	case m.Status.VMStatus == "":
+		return string(infrav1alpha1.VirtualMachineStatePending)
+	default:
+		return string(m.Status.VMStatus)
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2023-2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+)
+
+func newProxmoxMachine(name, cluster string, state infrav1alpha1.VirtualMachineState) *infrav1alpha1.ProxmoxMachine {
+	return &infrav1alpha1.ProxmoxMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: metav1.NamespaceDefault,
+			Labels:    map[string]string{clusterv1.ClusterNameLabel: cluster},
+		},
+		Status: infrav1alpha1.ProxmoxMachineStatus{VMStatus: state},
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	ObserveReconcile("proxmoxmachine", metav1.NamespaceDefault, "test", time.Now(), nil)
+	ObserveReconcile("proxmoxmachine", metav1.NamespaceDefault, "test", time.Now(), errors.New("unreachable"))
+	ObserveTask("qmclone", false, 42*time.Second)
+
+	families, err := ctrlmetrics.Registry.Gather()
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(families))
+	for _, family := range families {
+		names = append(names, family.GetName())
+	}
+	require.Subset(t, names, []string{
+		"capmox_reconcile_total",
+		"capmox_reconcile_duration_seconds",
+		"capmox_proxmox_task_duration_seconds",
+	})
+
+	require.Equal(t, float64(1), testutil.ToFloat64(reconcileTotal.WithLabelValues("proxmoxmachine", metav1.NamespaceDefault, "test", "error")))
+}
+
+func TestMachineCollector(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, infrav1alpha1.AddToScheme(scheme))
+
+	failed := newProxmoxMachine("failed", "test", infrav1alpha1.VirtualMachineStatePending)
+	failed.Status.FailureReason = ptr.To(capierrors.CreateMachineError)
+
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newProxmoxMachine("ready-1", "test", infrav1alpha1.VirtualMachineStateReady),
+		newProxmoxMachine("ready-2", "test", infrav1alpha1.VirtualMachineStateReady),
+		newProxmoxMachine("new", "test", ""),
+		newProxmoxMachine("other", "other", infrav1alpha1.VirtualMachineStateReady),
+		failed,
+	).Build()
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewMachineCollector(k8sClient))
+
+	expected := `
+# HELP capmox_machines Number of ProxmoxMachines, by namespace, cluster and phase.
+# TYPE capmox_machines gauge
+capmox_machines{cluster="other",namespace="default",phase="ready"} 1
+capmox_machines{cluster="test",namespace="default",phase="failed"} 1
+capmox_machines{cluster="test",namespace="default",phase="pending"} 1
+capmox_machines{cluster="test",namespace="default",phase="ready"} 2
+`
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "capmox_machines"))
+}
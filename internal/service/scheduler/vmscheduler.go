@@ -21,9 +21,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"sort"
 
 	"github.com/go-logr/logr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util"
 
 	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
@@ -47,16 +49,99 @@ func (err InsufficientMemoryError) Error() string {
 // It requires the machine's ProxmoxCluster to have at least 1 allowed node.
 func ScheduleVM(ctx context.Context, machineScope *scope.MachineScope) (string, error) {
 	client := machineScope.InfraCluster.ProxmoxClient
-	allowedNodes := machineScope.InfraCluster.ProxmoxCluster.Spec.AllowedNodes
+	allowedNodes, err := machineScope.GetAllowedNodes()
+	if err != nil {
+		return "", err
+	}
 	schedulerHints := machineScope.InfraCluster.ProxmoxCluster.Spec.SchedulerHints
-	locations := machineScope.InfraCluster.ProxmoxCluster.Status.NodeLocations.Workers
-	if util.IsControlPlaneMachine(machineScope.Machine) {
-		locations = machineScope.InfraCluster.ProxmoxCluster.Status.NodeLocations.ControlPlane
+	locations, err := peerLocations(ctx, machineScope)
+	if err != nil {
+		return "", err
 	}
 
 	return selectNode(ctx, client, machineScope.ProxmoxMachine, locations, allowedNodes, schedulerHints)
 }
 
+// ScheduleMigration decides which node the VM of a ProxmoxMachine should be migrated to.
+// A requested target must be one of the allowed nodes and fit the machine.
+// Without a target, one of the other allowed nodes is picked.
+func ScheduleMigration(ctx context.Context, machineScope *scope.MachineScope, target string) (string, error) {
+	client := machineScope.InfraCluster.ProxmoxClient
+	allowedNodes, err := machineScope.GetAllowedNodes()
+	if err != nil {
+		return "", err
+	}
+
+	if target != "" {
+		if len(allowedNodes) > 0 && !slices.Contains(allowedNodes, target) {
+			return "", fmt.Errorf("node %s is not allowed for machine %s", target, machineScope.Name())
+		}
+		allowedNodes = []string{target}
+	} else {
+		current := machineScope.VirtualMachine.Node
+		allowedNodes = slices.DeleteFunc(slices.Clone(allowedNodes), func(node string) bool { return node == current })
+		if len(allowedNodes) == 0 {
+			return "", fmt.Errorf("no other node is allowed for machine %s", machineScope.Name())
+		}
+	}
+
+	schedulerHints := machineScope.InfraCluster.ProxmoxCluster.Spec.SchedulerHints
+	locations, err := peerLocations(ctx, machineScope)
+	if err != nil {
+		return "", err
+	}
+
+	return selectNode(ctx, client, machineScope.ProxmoxMachine, locations, allowedNodes, schedulerHints)
+}
+
+// peerLocations returns the locations of the machines the machine should be spread across.
+func peerLocations(ctx context.Context, machineScope *scope.MachineScope) ([]infrav1.NodeLocation, error) {
+	nodeLocations := machineScope.InfraCluster.ProxmoxCluster.Status.NodeLocations
+	if nodeLocations == nil {
+		return nil, nil
+	}
+	if util.IsControlPlaneMachine(machineScope.Machine) {
+		return nodeLocations.ControlPlane, nil
+	}
+
+	locations := nodeLocations.Workers
+	if machineScope.InfraCluster.ProxmoxCluster.Spec.SchedulerHints.IsAntiAffinityEnabled() {
+		// workers only need to be spread within their own MachineDeployment
+		return machineDeploymentLocations(ctx, machineScope, locations)
+	}
+	return locations, nil
+}
+
+// machineDeploymentLocations filters the given locations down to the machines that belong to
+// the same MachineDeployment as the machine being scheduled.
+func machineDeploymentLocations(ctx context.Context, machineScope *scope.MachineScope, locations []infrav1.NodeLocation) ([]infrav1.NodeLocation, error) {
+	deployment, ok := machineScope.Machine.GetLabels()[clusterv1.MachineDeploymentNameLabel]
+	if !ok {
+		return locations, nil
+	}
+
+	machines, err := machineScope.InfraCluster.ListProxmoxMachinesForCluster(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	peers := make(map[string]struct{})
+	for _, m := range machines {
+		if m.GetLabels()[clusterv1.MachineDeploymentNameLabel] == deployment {
+			peers[m.GetName()] = struct{}{}
+		}
+	}
+
+	var filtered []infrav1.NodeLocation
+	for _, nl := range locations {
+		if _, ok := peers[nl.Machine.Name]; ok {
+			filtered = append(filtered, nl)
+		}
+	}
+
+	return filtered, nil
+}
+
 func selectNode(
 	ctx context.Context,
 	client resourceClient,
@@ -65,6 +150,8 @@ func selectNode(
 	allowedNodes []string,
 	schedulerHints *infrav1.SchedulerHints,
 ) (string, error) {
+	strategy := schedulerHints.GetStrategy()
+
 	byMemory := make(sortByAvailableMemory, len(allowedNodes))
 	for i, nodeName := range allowedNodes {
 		mem, err := client.GetReservableMemoryBytes(ctx, nodeName, schedulerHints.GetMemoryAdjustment())
@@ -72,6 +159,14 @@ func selectNode(
 			return "", err
 		}
 		byMemory[i] = nodeInfo{Name: nodeName, AvailableMemory: mem}
+
+		if strategy != infrav1.SchedulerStrategyMemory {
+			cpu, err := client.GetNodeCPUUsage(ctx, nodeName)
+			if err != nil {
+				return "", err
+			}
+			byMemory[i].CPUUsage = cpu
+		}
 	}
 
 	sort.Sort(byMemory)
@@ -103,9 +198,23 @@ func selectNode(
 	sort.Sort(byReplicas)
 
 	decision := byMemory[0].Name
-	if requestedMemory < byReplicas[0].AvailableMemory {
+	if strategy != infrav1.SchedulerStrategyMemory {
+		var err error
+		decision, err = selectNodeByScore(byReplicas, requestedMemory, strategy, schedulerHints.IsAntiAffinityEnabled())
+		if err != nil {
+			return "", err
+		}
+	} else if requestedMemory < byReplicas[0].AvailableMemory {
 		// distribute round-robin when memory allows it
 		decision = byReplicas[0].Name
+	} else if schedulerHints.IsAntiAffinityEnabled() {
+		// prefer the least occupied node that still fits the machine over co-location
+		for _, info := range byReplicas {
+			if requestedMemory < info.AvailableMemory {
+				decision = info.Name
+				break
+			}
+		}
 	}
 
 	if logger := logr.FromContextOrDiscard(ctx); logger.V(4).Enabled() {
@@ -114,6 +223,7 @@ func selectNode(
 			"byReplicas", byReplicas.String(),
 			"byMemory", byMemory.String(),
 			"requestedMemory", requestedMemory,
+			"strategy", strategy,
 			"resultNode", decision,
 		)
 	}
@@ -121,14 +231,73 @@ func selectNode(
 	return decision, nil
 }
 
+// selectNodeByScore picks the node with the highest weighted score of free memory and idle CPU
+// among the nodes which fit the requested memory. The nodes must be sorted by replicas.
+// With anti-affinity, nodes hosting fewer peers always win over a higher score.
+func selectNodeByScore(nodes []nodeInfo, requestedMemory uint64, strategy infrav1.SchedulerStrategy, antiAffinity bool) (string, error) {
+	memoryWeight, cpuWeight := 0.5, 0.5
+	if strategy == infrav1.SchedulerStrategyCPU {
+		memoryWeight, cpuWeight = 0, 1
+	}
+
+	var maxMemory uint64
+	var maxMemoryNode string
+	for _, info := range nodes {
+		if maxMemoryNode == "" || info.AvailableMemory > maxMemory {
+			maxMemory, maxMemoryNode = info.AvailableMemory, info.Name
+		}
+	}
+
+	score := func(info nodeInfo) float64 {
+		memoryScore := 1.0
+		if maxMemory > 0 {
+			memoryScore = float64(info.AvailableMemory) / float64(maxMemory)
+		}
+		cpuScore := 1 - min(max(info.CPUUsage, 0), 1)
+		return memoryWeight*memoryScore + cpuWeight*cpuScore
+	}
+
+	var best *nodeInfo
+	for i := range nodes {
+		info := &nodes[i]
+		// same as the memory strategy, a node must not be filled up completely
+		if requestedMemory >= info.AvailableMemory {
+			continue
+		}
+		if best == nil {
+			best = info
+			continue
+		}
+		if antiAffinity && info.ScheduledVMs != best.ScheduledVMs {
+			// nodes are sorted by replicas, the best node can't have more peers
+			break
+		}
+		if score(*info) > score(*best) {
+			best = info
+		}
+	}
+
+	if best == nil {
+		return "", InsufficientMemoryError{
+			node:      maxMemoryNode,
+			available: maxMemory,
+			requested: requestedMemory,
+		}
+	}
+
+	return best.Name, nil
+}
+
 type resourceClient interface {
 	GetReservableMemoryBytes(context.Context, string, uint64) (uint64, error)
+	GetNodeCPUUsage(context.Context, string) (float64, error)
 }
 
 type nodeInfo struct {
-	Name            string `json:"node"`
-	AvailableMemory uint64 `json:"mem"`
-	ScheduledVMs    int    `json:"vms"`
+	Name            string  `json:"node"`
+	AvailableMemory uint64  `json:"mem"`
+	CPUUsage        float64 `json:"cpu"`
+	ScheduledVMs    int     `json:"vms"`
 }
 
 type sortByReplicas []nodeInfo
@@ -136,7 +305,14 @@ type sortByReplicas []nodeInfo
 func (a sortByReplicas) Len() int      { return len(a) }
 func (a sortByReplicas) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
 func (a sortByReplicas) Less(i, j int) bool {
-	return a[i].ScheduledVMs < a[j].ScheduledVMs
+	if a[i].ScheduledVMs != a[j].ScheduledVMs {
+		return a[i].ScheduledVMs < a[j].ScheduledVMs
+	}
+	// break ties by memory and name to keep the decision deterministic
+	if a[i].AvailableMemory != a[j].AvailableMemory {
+		return a[i].AvailableMemory > a[j].AvailableMemory
+	}
+	return a[i].Name < a[j].Name
 }
 
 func (a sortByReplicas) String() string {
@@ -150,7 +326,10 @@ func (a sortByAvailableMemory) Len() int      { return len(a) }
 func (a sortByAvailableMemory) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
 func (a sortByAvailableMemory) Less(i, j int) bool {
 	// more available memory = lower index
-	return a[i].AvailableMemory > a[j].AvailableMemory
+	if a[i].AvailableMemory != a[j].AvailableMemory {
+		return a[i].AvailableMemory > a[j].AvailableMemory
+	}
+	return a[i].Name < a[j].Name
 }
 
 func (a sortByAvailableMemory) String() string {
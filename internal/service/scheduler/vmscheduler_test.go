@@ -32,6 +32,23 @@ func (c fakeResourceClient) GetReservableMemoryBytes(_ context.Context, nodeName
 	return c[nodeName], nil
 }
 
+func (c fakeResourceClient) GetNodeCPUUsage(context.Context, string) (float64, error) {
+	return 0, nil
+}
+
+type fakeNodeStatsClient struct {
+	memory map[string]uint64
+	cpu    map[string]float64
+}
+
+func (c fakeNodeStatsClient) GetReservableMemoryBytes(_ context.Context, nodeName string, _ uint64) (uint64, error) {
+	return c.memory[nodeName], nil
+}
+
+func (c fakeNodeStatsClient) GetNodeCPUUsage(_ context.Context, nodeName string) (float64, error) {
+	return c.cpu[nodeName], nil
+}
+
 func miBytes(in uint64) uint64 {
 	return in * 1024 * 1024
 }
@@ -95,3 +112,148 @@ func TestSelectNode(t *testing.T) {
 		require.Equal(t, expectMem, availableMem)
 	})
 }
+
+func TestSelectNode_AntiAffinity(t *testing.T) {
+	allowedNodes := []string{"pve1", "pve2", "pve3"}
+	const requestMiB = 8
+	availableMem := map[string]uint64{
+		"pve1": miBytes(4),
+		"pve2": miBytes(10),
+		"pve3": miBytes(50),
+	}
+	locations := []infrav1.NodeLocation{
+		{Node: "pve2"},
+		{Node: "pve3"},
+		{Node: "pve3"},
+	}
+
+	proxmoxMachine := &infrav1.ProxmoxMachine{
+		Spec: infrav1.ProxmoxMachineSpec{
+			MemoryMiB: requestMiB,
+		},
+	}
+	client := fakeResourceClient(availableMem)
+
+	t.Run("disabled", func(t *testing.T) {
+		node, err := selectNode(context.Background(), client, proxmoxMachine, locations, allowedNodes, &infrav1.SchedulerHints{})
+		require.NoError(t, err)
+		require.Equal(t, "pve3", node)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		node, err := selectNode(context.Background(), client, proxmoxMachine, locations, allowedNodes, &infrav1.SchedulerHints{AntiAffinity: true})
+		require.NoError(t, err)
+		require.Equal(t, "pve2", node)
+	})
+}
+
+func TestSelectNode_Deterministic(t *testing.T) {
+	allowedNodes := []string{"pve3", "pve1", "pve2"}
+	availableMem := map[string]uint64{
+		"pve1": miBytes(20),
+		"pve2": miBytes(20),
+		"pve3": miBytes(20),
+	}
+
+	proxmoxMachine := &infrav1.ProxmoxMachine{
+		Spec: infrav1.ProxmoxMachineSpec{
+			MemoryMiB: 8,
+		},
+	}
+
+	for i := 0; i < 10; i++ {
+		node, err := selectNode(context.Background(), fakeResourceClient(availableMem), proxmoxMachine, nil, allowedNodes, &infrav1.SchedulerHints{AntiAffinity: true})
+		require.NoError(t, err)
+		require.Equal(t, "pve1", node)
+	}
+}
+
+func TestSelectNode_Strategy(t *testing.T) {
+	allowedNodes := []string{"pve1", "pve2", "pve3"}
+	client := fakeNodeStatsClient{
+		memory: map[string]uint64{
+			"pve1": miBytes(64),
+			"pve2": miBytes(40),
+			"pve3": miBytes(4),
+		},
+		cpu: map[string]float64{
+			"pve1": 0.95,
+			"pve2": 0.30,
+			"pve3": 0.01,
+		},
+	}
+	locations := []infrav1.NodeLocation{{Node: "pve2"}}
+
+	proxmoxMachine := &infrav1.ProxmoxMachine{
+		Spec: infrav1.ProxmoxMachineSpec{
+			MemoryMiB: 8,
+		},
+	}
+
+	tests := []struct {
+		name         string
+		hints        *infrav1.SchedulerHints
+		expectedNode string
+	}{
+		{
+			name:         "memory",
+			hints:        &infrav1.SchedulerHints{Strategy: infrav1.SchedulerStrategyMemory},
+			expectedNode: "pve1",
+		},
+		{
+			// pve3 is idle, but does not have enough memory left
+			name:         "cpu",
+			hints:        &infrav1.SchedulerHints{Strategy: infrav1.SchedulerStrategyCPU},
+			expectedNode: "pve2",
+		},
+		{
+			// pve1: 0.5*1 + 0.5*0.05, pve2: 0.5*0.625 + 0.5*0.7
+			name:         "balanced",
+			hints:        &infrav1.SchedulerHints{Strategy: infrav1.SchedulerStrategyBalanced},
+			expectedNode: "pve2",
+		},
+		{
+			name:         "cpu with anti-affinity",
+			hints:        &infrav1.SchedulerHints{Strategy: infrav1.SchedulerStrategyCPU, AntiAffinity: true},
+			expectedNode: "pve1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node, err := selectNode(context.Background(), client, proxmoxMachine, locations, allowedNodes, test.hints)
+			require.NoError(t, err)
+			require.Equal(t, test.expectedNode, node)
+		})
+	}
+}
+
+func TestSelectNode_Strategy_ExactlyFullNode(t *testing.T) {
+	allowedNodes := []string{"pve1", "pve2"}
+	client := fakeNodeStatsClient{
+		memory: map[string]uint64{
+			"pve1": miBytes(8),
+			"pve2": miBytes(16),
+		},
+		cpu: map[string]float64{
+			"pve1": 0.01,
+			"pve2": 0.90,
+		},
+	}
+
+	proxmoxMachine := &infrav1.ProxmoxMachine{
+		Spec: infrav1.ProxmoxMachineSpec{
+			MemoryMiB: 8,
+		},
+	}
+	hints := &infrav1.SchedulerHints{Strategy: infrav1.SchedulerStrategyCPU}
+
+	// like the memory strategy, the cpu strategy must not fill up pve1 completely
+	node, err := selectNode(context.Background(), client, proxmoxMachine, nil, allowedNodes, hints)
+	require.NoError(t, err)
+	require.Equal(t, "pve2", node)
+
+	client.memory["pve2"] = miBytes(8)
+	_, err = selectNode(context.Background(), client, proxmoxMachine, nil, allowedNodes, hints)
+	require.ErrorAs(t, err, &InsufficientMemoryError{})
+}
@@ -20,6 +20,7 @@ package taskservice
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/luthermonson/go-proxmox"
@@ -29,6 +30,7 @@ import (
 	"sigs.k8s.io/cluster-api/util/conditions"
 
 	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/metrics"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
 )
 
@@ -45,6 +47,15 @@ const (
 	TaskInfoStateError      = TaskInfoState("error")
 )
 
+const (
+	// minTaskRequeue and maxTaskRequeue bound the interval in which running tasks are polled.
+	minTaskRequeue = 5 * time.Second
+	maxTaskRequeue = time.Minute
+
+	// taskLogExcerptLines is the number of task log lines reported when a task fails.
+	taskLogExcerptLines = 5
+)
+
 var (
 	// ErrTaskNotFound task is not found.
 	ErrTaskNotFound = errors.New("task not found")
@@ -78,12 +89,12 @@ func ReconcileInFlightTask(ctx context.Context, machineScope *scope.MachineScope
 	}
 	machineScope.Logger.V(4).Info("reconciling task", "task", t)
 
-	return checkAndRetryTask(machineScope, t)
+	return checkAndRetryTask(ctx, machineScope, t)
 }
 
 // checkAndRetryTask verifies whether the task exists and if the task should be reconciled.
 // This is determined by the task state retryAfter value set.
-func checkAndRetryTask(scope *scope.MachineScope, task *proxmox.Task) (bool, error) {
+func checkAndRetryTask(ctx context.Context, scope *scope.MachineScope, task *proxmox.Task) (bool, error) {
 	// Make sure to requeue if no task was found.
 	if task == nil {
 		scope.Logger.V(4).Info("task is nil, requeueing")
@@ -102,34 +113,90 @@ func checkAndRetryTask(scope *scope.MachineScope, task *proxmox.Task) (bool, err
 
 	switch {
 	case task.IsRunning:
+		running := time.Since(task.StartTime)
+		if scope.TaskTimeout > 0 && !task.StartTime.IsZero() && running > scope.TaskTimeout {
+			logger.Info("task timed out, stopping it", "description", task.Type, "timeout", scope.TaskTimeout)
+			if err := scope.InfraCluster.ProxmoxClient.StopTask(ctx, string(task.UPID)); err != nil {
+				return false, errors.Wrapf(err, "unable to stop task %s", task.UPID)
+			}
+			return failTask(ctx, scope, task, fmt.Sprintf("task %s timed out after %s", task.Type, scope.TaskTimeout))
+		}
+
 		logger.Info("task is still pending", "description", task.Type)
-		return true, nil
+		reportRunningTask(scope, task, running)
+		return false, NewRequeueError(fmt.Sprintf("task %s is still running", task.Type), taskRequeueAfter(running))
 	case task.IsSuccessful:
 		logger.Info("task is a success", "description", task.Type)
+		metrics.ObserveTask(task.Type, false, taskDuration(task))
+		if task.Type == "qmclone" {
+			scope.Eventf("CloneCompleted", "Cloned VM %d in %s", scope.GetVirtualMachineID(), taskDuration(task).Round(time.Second))
+		}
 		scope.ProxmoxMachine.Status.TaskRef = nil
 		return false, nil
 	case task.IsFailed:
 		logger.Info("task failed", "description", task.Type)
+		if scope.ProxmoxMachine.Status.RetryAfter.IsZero() {
+			metrics.ObserveTask(task.Type, true, taskDuration(task))
+		}
 
-		// NOTE: When a task fails there is not simple way to understand which operation is failing (e.g. cloning or powering on)
-		// so we are reporting failures using a dedicated reason until we find a better solution.
 		var errorMessage string
-
 		if task.ExitStatus != "OK" {
 			errorMessage = task.ExitStatus
 		}
-		conditions.MarkFalse(scope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.TaskFailure, clusterv1.ConditionSeverityInfo, errorMessage)
-
-		// Instead of directly requeuing the failed task, wait for the RetryAfter duration to pass
-		// before resetting the taskRef from the ProxmoxMachine status.
-		if scope.ProxmoxMachine.Status.RetryAfter.IsZero() {
-			scope.ProxmoxMachine.Status.RetryAfter = metav1.Time{Time: time.Now().Add(1 * time.Minute)}
-		} else {
-			scope.ProxmoxMachine.Status.TaskRef = nil
-			scope.ProxmoxMachine.Status.RetryAfter = metav1.Time{}
-		}
-		return true, nil
+		return failTask(ctx, scope, task, errorMessage)
 	default:
 		return false, NewRequeueError(fmt.Sprintf("unknown task state %q for %q", task.ExitStatus, scope.ProxmoxMachine.Name), infrav1alpha1.DefaultReconcilerRequeue)
 	}
 }
+
+// failTask reports a failed task in the VMProvisioned condition, together with the last lines of its log.
+func failTask(ctx context.Context, scope *scope.MachineScope, task *proxmox.Task, errorMessage string) (bool, error) {
+	// NOTE: When a task fails there is not simple way to understand which operation is failing (e.g. cloning or powering on)
+	// so we are reporting failures using a dedicated reason until we find a better solution.
+	if lines, err := scope.InfraCluster.ProxmoxClient.GetTaskLog(ctx, string(task.UPID), taskLogExcerptLines); err != nil {
+		scope.Logger.V(4).Info("unable to get task log", "error", err.Error())
+	} else if len(lines) > 0 {
+		errorMessage = strings.TrimSpace(errorMessage + "\n" + strings.Join(lines, "\n"))
+	}
+	conditions.MarkFalse(scope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.TaskFailure, clusterv1.ConditionSeverityInfo, errorMessage)
+	if scope.ProxmoxMachine.Status.RetryAfter.IsZero() {
+		scope.Warnf("TaskFailed", "Proxmox task %s failed, retrying in 1m: %s", task.Type, errorMessage)
+	}
+
+	// Instead of directly requeuing the failed task, wait for the RetryAfter duration to pass
+	// before resetting the taskRef from the ProxmoxMachine status.
+	if scope.ProxmoxMachine.Status.RetryAfter.IsZero() {
+		scope.ProxmoxMachine.Status.RetryAfter = metav1.Time{Time: time.Now().Add(1 * time.Minute)}
+	} else {
+		scope.ProxmoxMachine.Status.TaskRef = nil
+		scope.ProxmoxMachine.Status.RetryAfter = metav1.Time{}
+	}
+	return true, nil
+}
+
+// reportRunningTask adds the running task to the message of the VMProvisioned condition,
+// keeping the reason of the operation that started it.
+func reportRunningTask(scope *scope.MachineScope, task *proxmox.Task, running time.Duration) {
+	if !conditions.Has(scope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition) ||
+		conditions.IsTrue(scope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition) {
+		return
+	}
+	reason := conditions.GetReason(scope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition)
+	conditions.MarkFalse(scope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, reason, clusterv1.ConditionSeverityInfo,
+		"waiting for task %s (%s), running for %s", task.Type, task.UPID, running.Round(time.Second))
+}
+
+// taskDuration returns how long a finished task ran. Proxmox does not always report the end time
+// of a task, in that case it ended just now.
+func taskDuration(task *proxmox.Task) time.Duration {
+	if task.Duration > 0 || task.StartTime.IsZero() {
+		return task.Duration
+	}
+	return time.Since(task.StartTime)
+}
+
+// taskRequeueAfter returns the time to wait before polling a task again.
+// Long running tasks, like full clones, are polled less often.
+func taskRequeueAfter(running time.Duration) time.Duration {
+	return min(max(running/4, minTaskRequeue), maxTaskRequeue)
+}
@@ -0,0 +1,111 @@
+package taskservice
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/luthermonson/go-proxmox"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/proxmoxtest"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+const testUPID = "UPID:node1:000D6BDA:041E0A54:654A5A1D:qmclone:101:root@pam:"
+
+func setupTaskTest(t *testing.T) (*scope.MachineScope, *proxmoxtest.MockClient) {
+	proxmoxClient := proxmoxtest.NewMockClient(t)
+	logger := logr.Discard()
+
+	machineScope := &scope.MachineScope{
+		Logger: &logger,
+		InfraCluster: &scope.ClusterScope{
+			ProxmoxClient: proxmoxClient,
+		},
+		ProxmoxMachine: &infrav1alpha1.ProxmoxMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "test"},
+			Status: infrav1alpha1.ProxmoxMachineStatus{
+				TaskRef: ptr.To(testUPID),
+			},
+		},
+		TaskTimeout: 20 * time.Minute,
+		Recorder:    record.NewFakeRecorder(10),
+	}
+	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.CloningReason, clusterv1.ConditionSeverityInfo, "")
+
+	return machineScope, proxmoxClient
+}
+
+func TestReconcileInFlightTask_Running(t *testing.T) {
+	machineScope, proxmoxClient := setupTaskTest(t)
+	task := &proxmox.Task{UPID: testUPID, Type: "qmclone", IsRunning: true, StartTime: time.Now().Add(-2 * time.Minute)}
+	proxmoxClient.EXPECT().GetTask(context.Background(), testUPID).Return(task, nil).Once()
+
+	_, err := ReconcileInFlightTask(context.Background(), machineScope)
+	requeueErr := new(RequeueError)
+	require.True(t, errors.As(err, &requeueErr))
+	require.Equal(t, 30*time.Second, requeueErr.RequeueAfter().Round(time.Second))
+
+	require.Equal(t, infrav1alpha1.CloningReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition))
+	require.Contains(t, conditions.GetMessage(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition), testUPID)
+	require.Equal(t, testUPID, *machineScope.ProxmoxMachine.Status.TaskRef)
+}
+
+func TestReconcileInFlightTask_TimedOut(t *testing.T) {
+	machineScope, proxmoxClient := setupTaskTest(t)
+	task := &proxmox.Task{UPID: testUPID, Type: "qmclone", IsRunning: true, StartTime: time.Now().Add(-time.Hour)}
+	proxmoxClient.EXPECT().GetTask(context.Background(), testUPID).Return(task, nil).Once()
+	proxmoxClient.EXPECT().StopTask(context.Background(), testUPID).Return(nil).Once()
+	proxmoxClient.EXPECT().GetTaskLog(context.Background(), testUPID, taskLogExcerptLines).Return([]string{"transferred 1.0 GiB of 32.0 GiB"}, nil).Once()
+
+	requeue, err := ReconcileInFlightTask(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+
+	require.Equal(t, infrav1alpha1.TaskFailure, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition))
+	require.Equal(t, "task qmclone timed out after 20m0s\ntransferred 1.0 GiB of 32.0 GiB", conditions.GetMessage(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition))
+	require.False(t, machineScope.ProxmoxMachine.Status.RetryAfter.IsZero())
+}
+
+func TestReconcileInFlightTask_Failed(t *testing.T) {
+	machineScope, proxmoxClient := setupTaskTest(t)
+	task := &proxmox.Task{UPID: testUPID, Type: "qmclone", IsFailed: true, ExitStatus: "clone failed"}
+	proxmoxClient.EXPECT().GetTask(context.Background(), testUPID).Return(task, nil).Once()
+	proxmoxClient.EXPECT().GetTaskLog(context.Background(), testUPID, taskLogExcerptLines).Return([]string{"no space left on device", "TASK ERROR: clone failed"}, nil).Once()
+
+	requeue, err := ReconcileInFlightTask(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+
+	require.Equal(t, infrav1alpha1.TaskFailure, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition))
+	require.Equal(t, "clone failed\nno space left on device\nTASK ERROR: clone failed", conditions.GetMessage(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition))
+	require.Contains(t, <-machineScope.Recorder.(*record.FakeRecorder).Events, "Warning TaskFailed Proxmox task qmclone failed")
+}
+
+func TestReconcileInFlightTask_CloneCompleted(t *testing.T) {
+	machineScope, proxmoxClient := setupTaskTest(t)
+	machineScope.ProxmoxMachine.Spec.VirtualMachineID = ptr.To(int64(101))
+	task := &proxmox.Task{UPID: testUPID, Type: "qmclone", IsSuccessful: true, Duration: 90 * time.Second}
+	proxmoxClient.EXPECT().GetTask(context.Background(), testUPID).Return(task, nil).Once()
+
+	requeue, err := ReconcileInFlightTask(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.Nil(t, machineScope.ProxmoxMachine.Status.TaskRef)
+	require.Equal(t, "Normal CloneCompleted Cloned VM 101 in 1m30s", <-machineScope.Recorder.(*record.FakeRecorder).Events)
+}
+
+func TestTaskRequeueAfter(t *testing.T) {
+	require.Equal(t, minTaskRequeue, taskRequeueAfter(time.Second))
+	require.Equal(t, 15*time.Second, taskRequeueAfter(time.Minute))
+	require.Equal(t, maxTaskRequeue, taskRequeueAfter(time.Hour))
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2023-2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/luthermonson/go-proxmox"
+	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+// adoptVM takes over the existing VM referenced by ExistingVMID, instead of cloning a new one.
+// The VM is renamed after the ProxmoxMachine, so it is found like a cloned VM afterwards,
+// and tagged for the cluster. The VM is considered provisioned, so no bootstrap data is provided.
+func adoptVM(ctx context.Context, machineScope *scope.MachineScope) (requeue bool, err error) {
+	vmID := *machineScope.ProxmoxMachine.Spec.ExistingVMID
+
+	vm, err := getAdoptableVM(ctx, machineScope, vmID)
+	if err != nil {
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.AdoptionFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return false, errors.Wrapf(err, "unable to adopt VM %d", vmID)
+	}
+
+	machineScope.Logger.Info("adopting virtual machine", "vmid", vmID, "name", vm.Name, "node", vm.Node)
+
+	tags := splitTags(vm.VirtualMachineConfig.Tags)
+	if !slices.Contains(tags, clusterTag(machineScope)) {
+		tags = append(tags, clusterTag(machineScope))
+	}
+
+	task, err := machineScope.InfraCluster.ProxmoxClient.ConfigureVM(ctx, vm,
+		proxmox.VirtualMachineOption{Name: optionName, Value: machineScope.ProxmoxMachine.GetName()},
+		proxmox.VirtualMachineOption{Name: optionTags, Value: strings.Join(tags, tagSeparator)},
+	)
+	if err != nil {
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.AdoptionFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return false, errors.Wrapf(err, "unable to adopt VM %d", vmID)
+	}
+
+	machineScope.SetVirtualMachineID(vmID)
+	machineScope.ProxmoxMachine.Status.ProxmoxNode = ptr.To(vm.Node)
+	machineScope.InfraCluster.ProxmoxCluster.UpdateNodeLocation(machineScope.Name(), vm.Node, util.IsControlPlaneMachine(machineScope.Machine))
+	machineScope.ProxmoxMachine.Status.Adopted = true
+	machineScope.ProxmoxMachine.Status.BootstrapDataProvided = ptr.To(true)
+
+	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.AdoptingReason, clusterv1.ConditionSeverityInfo, "")
+	machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(task.UPID))
+	return true, nil
+}
+
+// getAdoptableVM looks up the VM with the given id and makes sure it can be adopted.
+// Templates and VMs of other clusters are not adopted.
+func getAdoptableVM(ctx context.Context, machineScope *scope.MachineScope, vmID int64) (*proxmox.VirtualMachine, error) {
+	rsc, err := machineScope.InfraCluster.ProxmoxClient.FindVMResource(ctx, uint64(vmID))
+	if err != nil {
+		return nil, err
+	}
+	if rsc.Template != 0 {
+		return nil, errors.Errorf("vm %d is a template", vmID)
+	}
+
+	vm, err := machineScope.InfraCluster.ProxmoxClient.GetVM(ctx, rsc.Node, vmID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tag := range splitTags(vm.VirtualMachineConfig.Tags) {
+		if strings.HasPrefix(tag, "cluster_") && tag != clusterTag(machineScope) {
+			return nil, errors.Errorf("vm %d belongs to another cluster (tag %s)", vmID, tag)
+		}
+	}
+
+	return vm, nil
+}
+
+// detachVM removes the cluster tag from an adopted VM, instead of destroying it.
+// It returns true once the VM is detached, or there is no VM to detach.
+func detachVM(ctx context.Context, machineScope *scope.MachineScope) (bool, error) {
+	vm, err := getVMForDeletion(ctx, machineScope)
+	if err != nil {
+		return false, err
+	}
+	if vm == nil {
+		return true, nil
+	}
+
+	if done, err := reconcileDeletionTask(ctx, machineScope); err != nil || !done {
+		return false, err
+	}
+
+	tags := splitTags(vm.VirtualMachineConfig.Tags)
+	if !slices.Contains(tags, clusterTag(machineScope)) {
+		return true, nil
+	}
+
+	machineScope.Logger.Info("detaching adopted virtual machine")
+	tags = slices.DeleteFunc(tags, func(tag string) bool { return tag == clusterTag(machineScope) })
+	task, err := machineScope.InfraCluster.ProxmoxClient.ConfigureVM(ctx, vm, proxmox.VirtualMachineOption{
+		Name:  optionTags,
+		Value: strings.Join(tags, tagSeparator),
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to detach VM %s", machineScope.Name())
+	}
+
+	machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(task.UPID))
+	return false, nil
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2023-2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luthermonson/go-proxmox"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+)
+
+func newHandBuiltVM() *proxmox.VirtualMachine {
+	vm := newRunningVM()
+	vm.Name = "hand-built"
+	vm.VMID = 456
+	vm.Node = "node2"
+	vm.VirtualMachineConfig.Name = "hand-built"
+	vm.VirtualMachineConfig.Tags = "legacy"
+	return vm
+}
+
+func TestEnsureVirtualMachine_AdoptExistingVM(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.ExistingVMID = ptr.To(int64(456))
+	vm := newHandBuiltVM()
+
+	proxmoxClient.EXPECT().FindVMResource(context.Background(), uint64(456)).Return(&proxmox.ClusterResource{Name: "hand-built", Node: "node2"}, nil).Once()
+	proxmoxClient.EXPECT().GetVM(context.Background(), "node2", int64(456)).Return(vm, nil).Once()
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm,
+		proxmox.VirtualMachineOption{Name: optionName, Value: "test"},
+		proxmox.VirtualMachineOption{Name: optionTags, Value: "legacy;cluster_test"},
+	).Return(newTask(), nil).Once()
+
+	requeue, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+
+	require.Equal(t, int64(456), machineScope.ProxmoxMachine.GetVirtualMachineID())
+	require.Equal(t, "node2", *machineScope.ProxmoxMachine.Status.ProxmoxNode)
+	require.Equal(t, "node2", machineScope.InfraCluster.ProxmoxCluster.GetNode(machineScope.Name(), false))
+	require.True(t, machineScope.ProxmoxMachine.Status.Adopted)
+	require.True(t, *machineScope.ProxmoxMachine.Status.BootstrapDataProvided)
+	require.Equal(t, infrav1alpha1.AdoptingReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition))
+}
+
+func TestAdoptVM_Template(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.ExistingVMID = ptr.To(int64(456))
+
+	proxmoxClient.EXPECT().FindVMResource(context.Background(), uint64(456)).Return(&proxmox.ClusterResource{Name: "template", Node: "node2", Template: 1}, nil).Once()
+
+	_, err := adoptVM(context.Background(), machineScope)
+	require.ErrorContains(t, err, "vm 456 is a template")
+	require.Equal(t, infrav1alpha1.AdoptionFailedReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition))
+	require.Nil(t, machineScope.ProxmoxMachine.Spec.VirtualMachineID)
+}
+
+func TestAdoptVM_OtherCluster(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.ExistingVMID = ptr.To(int64(456))
+	vm := newHandBuiltVM()
+	vm.VirtualMachineConfig.Tags = "cluster_other"
+
+	proxmoxClient.EXPECT().FindVMResource(context.Background(), uint64(456)).Return(&proxmox.ClusterResource{Name: "hand-built", Node: "node2"}, nil).Once()
+	proxmoxClient.EXPECT().GetVM(context.Background(), "node2", int64(456)).Return(vm, nil).Once()
+
+	_, err := adoptVM(context.Background(), machineScope)
+	require.ErrorContains(t, err, "belongs to another cluster")
+	require.False(t, machineScope.ProxmoxMachine.Status.Adopted)
+}
+
+func TestDeleteVM_DetachAdoptedVM(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.VirtualMachineID = ptr.To(int64(123))
+	machineScope.ProxmoxMachine.Spec.ExistingVMID = ptr.To(int64(123))
+	machineScope.ProxmoxMachine.Spec.DeletionPolicy = infrav1alpha1.VMDeletionPolicyDetach
+	machineScope.ProxmoxMachine.Status.Adopted = true
+	vm := newRunningVM()
+	vm.VirtualMachineConfig.Tags = "legacy;cluster_test"
+
+	proxmoxClient.EXPECT().GetVM(context.TODO(), "node1", int64(123)).Return(vm, nil).Once()
+	proxmoxClient.EXPECT().ConfigureVM(context.TODO(), vm, proxmox.VirtualMachineOption{Name: optionTags, Value: "legacy"}).Return(newTask(), nil).Once()
+	require.NoError(t, DeleteVM(context.TODO(), machineScope))
+	require.Contains(t, machineScope.ProxmoxMachine.Finalizers, infrav1alpha1.MachineFinalizer)
+
+	detached := newRunningVM()
+	detached.VirtualMachineConfig.Tags = "legacy"
+	proxmoxClient.EXPECT().GetVM(context.TODO(), "node1", int64(123)).Return(detached, nil).Once()
+	proxmoxClient.EXPECT().GetTask(context.TODO(), "result").Return(&proxmox.Task{IsSuccessful: true}, nil).Once()
+	require.NoError(t, DeleteVM(context.TODO(), machineScope))
+	require.Empty(t, machineScope.ProxmoxMachine.Finalizers)
+}
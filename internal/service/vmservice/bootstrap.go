@@ -19,6 +19,7 @@ package vmservice
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/luthermonson/go-proxmox"
@@ -26,12 +27,14 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/utils/ptr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capierrors "sigs.k8s.io/cluster-api/errors"
 	"sigs.k8s.io/cluster-api/util/conditions"
 
 	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/inject"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/cloudinit"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/ignition"
+	capmox "github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/types"
 )
@@ -39,12 +42,14 @@ import (
 func reconcileBootstrapData(ctx context.Context, machineScope *scope.MachineScope) (requeue bool, err error) {
 	if ptr.Deref(machineScope.ProxmoxMachine.Status.BootstrapDataProvided, false) {
 		// skip machine already have the bootstrap data.
+		conditions.MarkTrue(machineScope.ProxmoxMachine, infrav1alpha1.BootstrapDataInjectedCondition)
 		return false, nil
 	}
 
 	if !machineHasIPAddress(machineScope.ProxmoxMachine) {
 		// skip machine doesn't have an IpAddress yet.
 		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.WaitingForStaticIPAllocationReason, clusterv1.ConditionSeverityWarning, "no ip address")
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.BootstrapDataInjectedCondition, infrav1alpha1.WaitingForStaticIPAllocationReason, clusterv1.ConditionSeverityInfo, "no ip address")
 		return true, nil
 	}
 
@@ -78,28 +83,93 @@ func reconcileBootstrapData(ctx context.Context, machineScope *scope.MachineScop
 	machineScope.Logger.V(4).Info("reconciling BootstrapData.", "format", format)
 
 	// Inject userdata based on the format
-	if ptr.Deref(format, "") == ignition.FormatIgnition {
+	switch ptr.Deref(format, "") {
+	case ignition.FormatIgnition:
 		err = injectIgnition(ctx, machineScope, bootstrapData, biosUUID, nicData, kubernetesVersion)
-	} else if ptr.Deref(format, "") == cloudinit.FormatCloudConfig {
+	case cloudinit.FormatCloudConfig:
 		err = injectCloudInit(ctx, machineScope, bootstrapData, biosUUID, nicData, kubernetesVersion)
+	default:
+		err = errors.Errorf("unsupported bootstrap data format %q", ptr.Deref(format, ""))
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.VMProvisionFailedReason, clusterv1.ConditionSeverityError, err.Error())
+		machineScope.SetFailureMessage(err)
+		machineScope.SetFailureReason(capierrors.InvalidConfigurationMachineError)
+		return false, err
 	}
 	if err != nil {
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.BootstrapDataInjectedCondition, infrav1alpha1.BootstrapDataInjectionFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
 		return false, errors.Wrap(err, "failed to inject bootstrap data")
 	}
 
 	machineScope.ProxmoxMachine.Status.BootstrapDataProvided = ptr.To(true)
+	conditions.MarkTrue(machineScope.ProxmoxMachine, infrav1alpha1.BootstrapDataInjectedCondition)
 
 	return false, nil
 }
 
 func injectCloudInit(ctx context.Context, machineScope *scope.MachineScope, bootstrapData []byte, biosUUID string, nicData []types.NetworkConfigData, kubernetesVersion string) error {
-	// create network renderer
-	network := cloudinit.NewNetworkConfig(nicData)
+	files, err := getFiles(ctx, machineScope)
+	if err != nil {
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.VMProvisionFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return err
+	}
 
-	// create metadata renderer
-	metadata := cloudinit.NewMetadata(biosUUID, machineScope.Name(), kubernetesVersion, ptr.Deref(machineScope.ProxmoxMachine.Spec.MetadataSettings, infrav1alpha1.MetadataSettings{ProviderIDInjection: false}).ProviderIDInjection)
+	bootstrapData, err = cloudinit.SetNTPServers(bootstrapData, getNTPServers(machineScope))
+	if err != nil {
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.VMProvisionFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return errors.Wrap(err, "unable to set ntp servers in user data")
+	}
 
-	injector := getISOInjector(machineScope.VirtualMachine, bootstrapData, metadata, network)
+	if machineScope.ProxmoxMachine.Spec.DisableDefaultUser {
+		bootstrapData, err = cloudinit.DisableDefaultUser(bootstrapData, machineScope.ProxmoxMachine.Spec.LoginUser, getSSHAuthorizedKeys(machineScope))
+	} else {
+		bootstrapData, err = cloudinit.AddSSHAuthorizedKeys(bootstrapData, getSSHAuthorizedKeys(machineScope))
+	}
+	if err != nil {
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.VMProvisionFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return errors.Wrap(err, "unable to add ssh authorized keys to user data")
+	}
+
+	bootstrapData, err = cloudinit.AddFiles(bootstrapData, files)
+	if err != nil {
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.VMProvisionFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return errors.Wrap(err, "unable to add files to user data")
+	}
+
+	bootstrapData, err = cloudinit.AddMounts(bootstrapData, getMounts(machineScope))
+	if err != nil {
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.VMProvisionFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return errors.Wrap(err, "unable to add mounts to user data")
+	}
+
+	bootstrapData, err = cloudinit.MergeUserData(bootstrapData, machineScope.ProxmoxMachine.Spec.AdditionalUserData)
+	if err != nil {
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.VMProvisionFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return errors.Wrap(err, "unable to merge additional user data")
+	}
+
+	injectProviderID := ptr.Deref(machineScope.ProxmoxMachine.Spec.MetadataSettings, infrav1alpha1.MetadataSettings{ProviderIDInjection: false}).ProviderIDInjection
+
+	// create vendor-data renderer
+	vendor := cloudinit.NewVendorData(machineScope.ProxmoxMachine.Spec.VendorData)
+
+	var injector isoInjector
+	if machineScope.ProxmoxMachine.Spec.CloudInitType == infrav1alpha1.CloudInitTypeConfigDrive2 {
+		// a config drive carries its metadata and network configuration as OpenStack JSON.
+		metadata := cloudinit.NewConfigDriveMetadata(biosUUID, machineScope.Name(), kubernetesVersion, injectProviderID)
+		network := cloudinit.NewNetworkData(nicData)
+		injector = getConfigDriveInjector(machineScope.VirtualMachine, machineScope.InfraCluster.ProxmoxClient, bootstrapData, metadata, network, vendor)
+	} else {
+		// create network renderer
+		var network cloudinit.Renderer = cloudinit.NewNetworkConfig(nicData)
+		if machineScope.ProxmoxMachine.Spec.NetworkConfigVersion == infrav1alpha1.NetworkConfigVersionV1 {
+			network = cloudinit.NewNetworkConfigV1(nicData)
+		}
+
+		// create metadata renderer
+		metadata := cloudinit.NewMetadata(biosUUID, machineScope.Name(), kubernetesVersion, injectProviderID)
+
+		injector = getISOInjector(machineScope.VirtualMachine, bootstrapData, metadata, network, vendor)
+	}
 	if err := injector.Inject(ctx, inject.CloudConfigFormat); err != nil {
 		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.VMProvisionFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
 		return errors.Wrap(err, "cloud-init iso inject failed")
@@ -132,12 +202,25 @@ type isoInjector interface {
 	Inject(ctx context.Context, format inject.BootstrapDataFormat) error
 }
 
-func defaultISOInjector(vm *proxmox.VirtualMachine, bootStrapData []byte, metadata, network cloudinit.Renderer) isoInjector {
+func defaultISOInjector(vm *proxmox.VirtualMachine, bootStrapData []byte, metadata, network, vendor cloudinit.Renderer) isoInjector {
+	return &inject.ISOInjector{
+		VirtualMachine:  vm,
+		BootstrapData:   bootStrapData,
+		MetaRenderer:    metadata,
+		NetworkRenderer: network,
+		VendorRenderer:  vendor,
+	}
+}
+
+func defaultConfigDriveInjector(vm *proxmox.VirtualMachine, client capmox.Client, bootStrapData []byte, metadata, network, vendor cloudinit.Renderer) isoInjector {
 	return &inject.ISOInjector{
 		VirtualMachine:  vm,
 		BootstrapData:   bootStrapData,
 		MetaRenderer:    metadata,
 		NetworkRenderer: network,
+		VendorRenderer:  vendor,
+		Type:            inject.ConfigDrive2Type,
+		ProxmoxClient:   client,
 	}
 }
 
@@ -151,11 +234,47 @@ func defaultIgnitionISOInjector(vm *proxmox.VirtualMachine, metadata cloudinit.R
 
 var (
 	getISOInjector         = defaultISOInjector
+	getConfigDriveInjector = defaultConfigDriveInjector
 	getIgnitionISOInjector = defaultIgnitionISOInjector
 )
 
-// getBootstrapData obtains a machine's bootstrap data from the relevant K8s secret and returns the data.
-// TODO: Add format return if ignition will be supported.
+// getFiles returns the files of the ProxmoxMachine, with the content read from the referenced Secrets and ConfigMaps.
+func getFiles(ctx context.Context, machineScope *scope.MachineScope) ([]cloudinit.File, error) {
+	files := make([]cloudinit.File, 0, len(machineScope.ProxmoxMachine.Spec.Files))
+	for _, spec := range machineScope.ProxmoxMachine.Spec.Files {
+		file := cloudinit.File{
+			Path:        spec.Path,
+			Content:     ptr.Deref(spec.Content, ""),
+			Permissions: spec.Permissions,
+			Owner:       spec.Owner,
+		}
+		if source := spec.ContentFrom; source != nil {
+			content, err := machineScope.GetFileContent(ctx, *source)
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to read content of file %s", spec.Path)
+			}
+			file.Content = content
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+func getMounts(machineScope *scope.MachineScope) []cloudinit.Mount {
+	mounts := make([]cloudinit.Mount, 0, len(machineScope.ProxmoxMachine.Spec.Mounts))
+	for _, spec := range machineScope.ProxmoxMachine.Spec.Mounts {
+		mounts = append(mounts, cloudinit.Mount{
+			Device:     spec.Device,
+			FSType:     string(spec.FSType),
+			MountPoint: spec.MountPoint,
+			Options:    spec.Options,
+		})
+	}
+	return mounts
+}
+
+// getBootstrapData obtains a machine's bootstrap data and its format from the relevant K8s secret.
+// The format defaults to cloud-config if the secret does not specify it.
 func getBootstrapData(ctx context.Context, scope *scope.MachineScope) ([]byte, *string, error) {
 	if scope.Machine.Spec.Bootstrap.DataSecretName == nil {
 		scope.Logger.Info("machine has no bootstrap data.")
@@ -184,7 +303,7 @@ func getBootstrapData(ctx context.Context, scope *scope.MachineScope) ([]byte, *
 func getNetworkConfigData(ctx context.Context, machineScope *scope.MachineScope) ([]types.NetworkConfigData, error) {
 	// provide a default in case network is not defined
 	network := ptr.Deref(machineScope.ProxmoxMachine.Spec.Network, infrav1alpha1.NetworkSpec{})
-	networkConfigData := make([]types.NetworkConfigData, 0, 1+len(network.AdditionalDevices)+len(network.VRFs))
+	networkConfigData := make([]types.NetworkConfigData, 0, 1+len(network.AdditionalDevices)+len(network.Bonds)+len(network.Bridges)+len(network.VLANs)+len(network.VRFs))
 
 	defaultConfig, err := getDefaultNetworkDevice(ctx, machineScope)
 	if err != nil {
@@ -198,6 +317,25 @@ func getNetworkConfigData(ctx context.Context, machineScope *scope.MachineScope)
 	}
 	networkConfigData = append(networkConfigData, additionalConfig...)
 
+	// bonds, bridges and vlans go before the VRFs, which may enslave them.
+	bondConfig, err := getBondDevices(network, networkConfigData)
+	if err != nil {
+		return nil, err
+	}
+	networkConfigData = append(networkConfigData, bondConfig...)
+
+	bridgeConfig, err := getBridgeDevices(network, networkConfigData)
+	if err != nil {
+		return nil, err
+	}
+	networkConfigData = append(networkConfigData, bridgeConfig...)
+
+	vlanConfig, err := getVLANDevices(network, networkConfigData)
+	if err != nil {
+		return nil, err
+	}
+	networkConfigData = append(networkConfigData, vlanConfig...)
+
 	virtualConfig, err := getVirtualNetworkDevices(ctx, machineScope, network, networkConfigData)
 	if err != nil {
 		return nil, err
@@ -237,6 +375,37 @@ func getRoutingPolicyData(rules []infrav1alpha1.RoutingPolicySpec) *[]types.FIBR
 	return &routingPolicyData
 }
 
+// getNTPServers returns the NTP servers of the machine, falling back to the ones of the cluster.
+func getNTPServers(machineScope *scope.MachineScope) []string {
+	if servers := machineScope.ProxmoxMachine.Spec.NTPServers; len(servers) > 0 {
+		return servers
+	}
+	return machineScope.InfraCluster.ProxmoxCluster.Spec.NTPServers
+}
+
+// getSSHAuthorizedKeys returns the SSH keys of the cluster followed by the ones of the machine.
+func getSSHAuthorizedKeys(machineScope *scope.MachineScope) []string {
+	keys := slices.Clone(machineScope.InfraCluster.ProxmoxCluster.Spec.SSHAuthorizedKeys)
+	return append(keys, machineScope.ProxmoxMachine.Spec.SSHAuthorizedKeys...)
+}
+
+// getSearchDomains returns the DNS search domains of the machine, falling back to the ones of the cluster.
+// Duplicates are dropped case-insensitively, keeping the first occurrence.
+func getSearchDomains(machineScope *scope.MachineScope) []string {
+	domains := machineScope.InfraCluster.ProxmoxCluster.Spec.SearchDomains
+	if network := machineScope.ProxmoxMachine.Spec.Network; network != nil && len(network.SearchDomains) > 0 {
+		domains = network.SearchDomains
+	}
+
+	var searchDomains []string
+	for _, domain := range domains {
+		if !slices.ContainsFunc(searchDomains, func(s string) bool { return strings.EqualFold(s, domain) }) {
+			searchDomains = append(searchDomains, domain)
+		}
+	}
+	return searchDomains
+}
+
 func getNetworkConfigDataForDevice(ctx context.Context, machineScope *scope.MachineScope, device string) (*types.NetworkConfigData, error) {
 	nets := machineScope.VirtualMachine.VirtualMachineConfig.MergeNets()
 	// For nics supporting multiple IP addresses, we need to cut the '-inet' or '-inet6' part,
@@ -247,23 +416,32 @@ func getNetworkConfigDataForDevice(ctx context.Context, machineScope *scope.Mach
 		machineScope.Logger.Error(errors.New("unable to extract mac address"), "device has no mac address", "device", device)
 		return nil, errors.New("unable to extract mac address")
 	}
-	// retrieve IPAddress.
-	ipAddr, err := findIPAddress(ctx, machineScope, device)
-	if err != nil {
-		return nil, errors.Wrapf(err, "unable to find IPAddress, device=%s", device)
+	var ip, gw string
+	var metric *uint32
+	if static := staticIPAddressForDevice(machineScope, device); static != nil {
+		ip = IPAddressWithPrefix(static.Address, static.Prefix)
+		gw = static.Gateway
+	} else {
+		// retrieve IPAddress.
+		ipAddr, err := findIPAddress(ctx, machineScope, device)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to find IPAddress, device=%s", device)
+		}
+
+		ip = IPAddressWithPrefix(ipAddr.Spec.Address, ipAddr.Spec.Prefix)
+		gw = ipAddr.Spec.Gateway
+		metric, err = findIPAddressGatewayMetric(ctx, machineScope, ipAddr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error converting metric annotation, kind=%s, name=%s", ipAddr.Spec.PoolRef.Kind, ipAddr.Spec.PoolRef.Name)
+		}
 	}
 
 	dns := machineScope.InfraCluster.ProxmoxCluster.Spec.DNSServers
-	ip := IPAddressWithPrefix(ipAddr.Spec.Address, ipAddr.Spec.Prefix)
-	gw := ipAddr.Spec.Gateway
-	metric, err := findIPAddressGatewayMetric(ctx, machineScope, ipAddr)
-	if err != nil {
-		return nil, errors.Wrapf(err, "error converting metric annotation, kind=%s, name=%s", ipAddr.Spec.PoolRef.Kind, ipAddr.Spec.PoolRef.Name)
-	}
 
 	cloudinitNetworkConfigData := &types.NetworkConfigData{
-		MacAddress: macAddress,
-		DNSServers: dns,
+		MacAddress:    macAddress,
+		DNSServers:    dns,
+		SearchDomains: getSearchDomains(machineScope),
 	}
 
 	// If it's an IPv6 address, we must set Gateway6 and IPV6Address instead
@@ -280,11 +458,23 @@ func getNetworkConfigDataForDevice(ctx context.Context, machineScope *scope.Mach
 	return cloudinitNetworkConfigData, nil
 }
 
+// staticIPAddressForDevice returns the static address of the default network device for the
+// DefaultNetworkDeviceIPV4 and DefaultNetworkDeviceIPV6 devices.
+func staticIPAddressForDevice(machineScope *scope.MachineScope, device string) *infrav1alpha1.IPAddressSpec {
+	switch device {
+	case DefaultNetworkDeviceIPV4:
+		return staticIPAddress(machineScope, infrav1alpha1.IPV4Format)
+	case DefaultNetworkDeviceIPV6:
+		return staticIPAddress(machineScope, infrav1alpha1.IPV6Format)
+	}
+	return nil
+}
+
 func getDefaultNetworkDevice(ctx context.Context, machineScope *scope.MachineScope) ([]types.NetworkConfigData, error) {
 	var config types.NetworkConfigData
 
 	// default network device ipv4.
-	if machineScope.InfraCluster.ProxmoxCluster.Spec.IPv4Config != nil {
+	if hasDefaultIPv4(machineScope) {
 		conf, err := getNetworkConfigDataForDevice(ctx, machineScope, DefaultNetworkDeviceIPV4)
 		if err != nil {
 			return nil, errors.Wrapf(err, "unable to get network config data for device=%s", DefaultNetworkDeviceIPV4)
@@ -293,7 +483,7 @@ func getDefaultNetworkDevice(ctx context.Context, machineScope *scope.MachineSco
 	}
 
 	// default network device ipv6.
-	if machineScope.InfraCluster.ProxmoxCluster.Spec.IPv6Config != nil {
+	if hasDefaultIPv6(machineScope) {
 		conf, err := getNetworkConfigDataForDevice(ctx, machineScope, DefaultNetworkDeviceIPV6)
 		if err != nil {
 			return nil, errors.Wrapf(err, "unable to get network config data for device=%s", DefaultNetworkDeviceIPV6)
@@ -311,6 +501,23 @@ func getDefaultNetworkDevice(ctx context.Context, machineScope *scope.MachineSco
 		}
 	}
 
+	// the guest acquires its addresses by itself.
+	if dhcp4, mode := defaultDHCP4(machineScope), defaultIPv6Mode(machineScope); dhcp4 || dynamicIPv6(mode) {
+		if len(config.MacAddress) == 0 {
+			nets := machineScope.VirtualMachine.VirtualMachineConfig.MergeNets()
+			config.MacAddress = extractMACAddress(nets[infrav1alpha1.DefaultNetworkDevice])
+			if len(config.MacAddress) == 0 {
+				return nil, errors.New("unable to extract mac address")
+			}
+			config.DNSServers = machineScope.InfraCluster.ProxmoxCluster.Spec.DNSServers
+			config.SearchDomains = getSearchDomains(machineScope)
+		}
+		config.DHCP4 = dhcp4
+		if dynamicIPv6(mode) {
+			setIPv6Mode(&config, mode)
+		}
+	}
+
 	// Default Network Device lacks a datastructure to transport MTU.
 	// We can use the Proxmox Device MTU instead to enable non virtio devices
 	// the usage of jumbo frames. This has the minor drawback of coalescing proxmox
@@ -321,6 +528,9 @@ func getDefaultNetworkDevice(ctx context.Context, machineScope *scope.MachineSco
 				config.LinkMTU = network.Default.MTU
 			}
 		}
+		if len(network.Routes) > 0 {
+			config.Routes = *getRoutingData(network.Routes)
+		}
 	}
 
 	config.Name = "eth0"
@@ -330,6 +540,13 @@ func getDefaultNetworkDevice(ctx context.Context, machineScope *scope.MachineSco
 	return []types.NetworkConfigData{config}, nil
 }
 
+// setIPv6Mode configures the guest to acquire the ipv6 address through DHCPv6 or SLAAC.
+// Router advertisements are accepted in both modes, as DHCPv6 doesn't provide routes.
+func setIPv6Mode(config *types.NetworkConfigData, mode infrav1alpha1.IPv6Mode) {
+	config.DHCP6 = mode == infrav1alpha1.IPv6ModeDHCP
+	config.AcceptRA = true
+}
+
 func getCommonInterfaceConfig(ctx context.Context, machineScope *scope.MachineScope, ciconfig *types.NetworkConfigData, ifconfig infrav1alpha1.InterfaceConfig) error {
 	if len(ifconfig.DNSServers) != 0 {
 		ciconfig.DNSServers = ifconfig.DNSServers
@@ -400,16 +617,150 @@ func getVirtualNetworkDevices(_ context.Context, _ *scope.MachineScope, network
 	return networkConfigData, nil
 }
 
+// getBondDevices returns the bonds of the network devices in data. A bond takes over the addresses,
+// routes and nameservers of its first interface, they are removed from all of its interfaces.
+func getBondDevices(network infrav1alpha1.NetworkSpec, data []types.NetworkConfigData) ([]types.NetworkConfigData, error) {
+	networkConfigData := make([]types.NetworkConfigData, 0, len(network.Bonds))
+
+	for _, bond := range network.Bonds {
+		var config types.NetworkConfigData
+
+		for i, child := range bond.Interfaces {
+			j := slices.IndexFunc(data, func(net types.NetworkConfigData) bool {
+				return net.Type == "ethernet" && (net.Name == child || net.ProxName == child)
+			})
+			if j < 0 {
+				return nil, errors.Errorf("unable to find bond interface=%s child interface %s", bond.Name, child)
+			}
+
+			if i == 0 {
+				config = data[j]
+			}
+			data[j] = withoutAddressing(data[j])
+			data[j].Bond = bond.Name
+			config.Interfaces = append(config.Interfaces, data[j].Name)
+		}
+
+		config.Type = "bond"
+		config.Name = bond.Name
+		config.ProxName = ""
+		config.MacAddress = ""
+		config.BondMode = string(bond.Mode)
+		config.BondMIIMon = bond.MIIMonitorInterval
+		networkConfigData = append(networkConfigData, config)
+	}
+	return networkConfigData, nil
+}
+
+// getBridgeDevices returns the bridges of the network devices and bonds in data. Like a bond, a bridge takes over
+// the addresses, routes and nameservers of its first interface, they are removed from all of its interfaces.
+func getBridgeDevices(network infrav1alpha1.NetworkSpec, data []types.NetworkConfigData) ([]types.NetworkConfigData, error) {
+	networkConfigData := make([]types.NetworkConfigData, 0, len(network.Bridges))
+
+	for _, bridge := range network.Bridges {
+		var config types.NetworkConfigData
+
+		for i, child := range bridge.Interfaces {
+			j := slices.IndexFunc(data, func(net types.NetworkConfigData) bool {
+				return (net.Type == "ethernet" || net.Type == "bond") && (net.Name == child || net.ProxName == child)
+			})
+			if j < 0 {
+				return nil, errors.Errorf("unable to find bridge interface=%s child interface %s", bridge.Name, child)
+			}
+
+			if i == 0 {
+				config = data[j]
+			}
+			data[j] = withoutAddressing(data[j])
+			data[j].Bridge = bridge.Name
+			config.Interfaces = append(config.Interfaces, data[j].Name)
+		}
+
+		config = types.NetworkConfigData{
+			Type:          "bridge",
+			Name:          bridge.Name,
+			Interfaces:    config.Interfaces,
+			BridgeSTP:     bridge.STP,
+			ForwardDelay:  bridge.ForwardDelay,
+			DHCP4:         config.DHCP4,
+			DHCP6:         config.DHCP6,
+			AcceptRA:      config.AcceptRA,
+			IPAddress:     config.IPAddress,
+			IPV6Address:   config.IPV6Address,
+			Gateway:       config.Gateway,
+			Metric:        config.Metric,
+			Gateway6:      config.Gateway6,
+			Metric6:       config.Metric6,
+			DNSServers:    config.DNSServers,
+			SearchDomains: config.SearchDomains,
+			Routes:        config.Routes,
+			FIBRules:      config.FIBRules,
+			LinkMTU:       config.LinkMTU,
+		}
+		networkConfigData = append(networkConfigData, config)
+	}
+	return networkConfigData, nil
+}
+
+// withoutAddressing returns the network config data of a device enslaved to a bond or bridge,
+// which leaves the addresses, routes and nameservers to its master.
+func withoutAddressing(data types.NetworkConfigData) types.NetworkConfigData {
+	return types.NetworkConfigData{
+		ProxName:   data.ProxName,
+		MacAddress: data.MacAddress,
+		Type:       data.Type,
+		Name:       data.Name,
+		Interfaces: data.Interfaces,
+		LinkMTU:    data.LinkMTU,
+		Bond:       data.Bond,
+		BondMode:   data.BondMode,
+		BondMIIMon: data.BondMIIMon,
+	}
+}
+
+// getVLANDevices returns the vlans on top of the network devices and bonds in data.
+func getVLANDevices(network infrav1alpha1.NetworkSpec, data []types.NetworkConfigData) ([]types.NetworkConfigData, error) {
+	networkConfigData := make([]types.NetworkConfigData, 0, len(network.VLANs))
+
+	for _, vlan := range network.VLANs {
+		j := slices.IndexFunc(data, func(net types.NetworkConfigData) bool {
+			return (net.Type == "ethernet" || net.Type == "bond" || net.Type == "bridge") && (net.Name == vlan.Link || net.ProxName == vlan.Link)
+		})
+		if j < 0 {
+			return nil, errors.Errorf("unable to find vlan interface=%s link %s", vlan.Name, vlan.Link)
+		}
+
+		config := types.NetworkConfigData{
+			Type:   "vlan",
+			Name:   vlan.Name,
+			Link:   data[j].Name,
+			VLANID: vlan.ID,
+		}
+		if vlan.IPv4 != nil {
+			config.IPAddress = IPAddressWithPrefix(vlan.IPv4.Address, vlan.IPv4.Prefix)
+			config.Gateway = vlan.IPv4.Gateway
+		}
+		if vlan.IPv6 != nil {
+			config.IPV6Address = IPAddressWithPrefix(vlan.IPv6.Address, vlan.IPv6.Prefix)
+			config.Gateway6 = vlan.IPv6.Gateway
+		}
+		networkConfigData = append(networkConfigData, config)
+	}
+	return networkConfigData, nil
+}
+
 func getAdditionalNetworkDevices(ctx context.Context, machineScope *scope.MachineScope, network infrav1alpha1.NetworkSpec) ([]types.NetworkConfigData, error) {
 	networkConfigData := make([]types.NetworkConfigData, 0, len(network.AdditionalDevices))
 
+	nets := machineScope.VirtualMachine.VirtualMachineConfig.MergeNets()
+
 	// additional network devices append after the provisioning interface
 	var index = 1
 	// additional network devices.
 	for _, nic := range network.AdditionalDevices {
 		var config = ptr.To(types.NetworkConfigData{})
 
-		if nic.IPv4PoolRef != nil {
+		if nic.IPv4PoolRef != nil && !nic.DHCP4 {
 			device := fmt.Sprintf("%s-%s", nic.Name, infrav1alpha1.DefaultSuffix)
 			conf, err := getNetworkConfigDataForDevice(ctx, machineScope, device)
 			if err != nil {
@@ -421,7 +772,7 @@ func getAdditionalNetworkDevices(ctx context.Context, machineScope *scope.Machin
 			config = conf
 		}
 
-		if nic.IPv6PoolRef != nil {
+		if nic.IPv6PoolRef != nil && !dynamicIPv6(nic.IPv6Mode) {
 			suffix := infrav1alpha1.DefaultSuffix + "6"
 			device := fmt.Sprintf("%s-%s", nic.Name, suffix)
 			conf, err := getNetworkConfigDataForDevice(ctx, machineScope, device)
@@ -436,16 +787,32 @@ func getAdditionalNetworkDevices(ctx context.Context, machineScope *scope.Machin
 			config.Gateway6 = conf.Gateway6
 		}
 
+		config.DHCP4 = nic.DHCP4
+		if dynamicIPv6(nic.IPv6Mode) {
+			setIPv6Mode(config, nic.IPv6Mode)
+		}
+
 		err := getCommonInterfaceConfig(ctx, machineScope, config, nic.InterfaceConfig)
 		if err != nil {
 			return nil, errors.Wrapf(err, "unable to get network config data for device=%s", nic.Name)
 		}
 
+		// Like for the default device, fall back to the Proxmox device MTU
+		// unless the interface explicitly sets a link MTU.
+		if config.LinkMTU == nil && nic.MTU != nil && *nic.MTU >= 576 {
+			config.LinkMTU = nic.MTU
+		}
+
 		config.Name = fmt.Sprintf("eth%d", index)
 		index++
 		config.Type = "ethernet"
 		config.ProxName = nic.Name
 
+		// devices without a static ipv4 address only learn their mac address here.
+		if len(config.MacAddress) == 0 {
+			config.MacAddress = extractMACAddress(nets[nic.Name])
+		}
+
 		if len(config.MacAddress) > 0 {
 			networkConfigData = append(networkConfigData, *config)
 		}
@@ -19,6 +19,7 @@ package vmservice
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/luthermonson/go-proxmox"
@@ -26,12 +27,14 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/ptr"
+	capierrors "sigs.k8s.io/cluster-api/errors"
 	"sigs.k8s.io/cluster-api/util/conditions"
 
 	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/inject"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/cloudinit"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/ignition"
+	capmox "github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/types"
 )
@@ -62,7 +65,7 @@ func TestReconcileBootstrapData_MissingMACAddress(t *testing.T) {
 
 func TestReconcileBootstrapData_NoNetworkConfig_UpdateStatus(t *testing.T) {
 	machineScope, _, kubeClient := setupReconcilerTest(t)
-	getISOInjector = func(_ *proxmox.VirtualMachine, _ []byte, _, _ cloudinit.Renderer) isoInjector {
+	getISOInjector = func(_ *proxmox.VirtualMachine, _ []byte, _, _, _ cloudinit.Renderer) isoInjector {
 		return FakeISOInjector{}
 	}
 	t.Cleanup(func() { getISOInjector = defaultISOInjector })
@@ -81,6 +84,349 @@ func TestReconcileBootstrapData_NoNetworkConfig_UpdateStatus(t *testing.T) {
 	require.True(t, *machineScope.ProxmoxMachine.Status.BootstrapDataProvided)
 }
 
+func TestReconcileBootstrapData_AdditionalUserData(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.AdditionalUserData = "#cloud-config\nruncmd:\n  - systemctl restart containerd\n"
+
+	var userData []byte
+	getISOInjector = func(_ *proxmox.VirtualMachine, bootstrapData []byte, _, _, _ cloudinit.Renderer) isoInjector {
+		userData = bootstrapData
+		return FakeISOInjector{}
+	}
+	t.Cleanup(func() { getISOInjector = defaultISOInjector })
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0")
+	vm.VirtualMachineConfig.SMBios1 = biosUUID
+	machineScope.SetVirtualMachine(vm)
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+	createBootstrapSecret(t, kubeClient, machineScope, cloudinit.FormatCloudConfig)
+
+	secret := &corev1.Secret{}
+	require.NoError(t, machineScope.GetBootstrapSecret(context.Background(), secret))
+	secret.Data["value"] = []byte("#cloud-config\nruncmd:\n  - kubeadm join\n")
+	require.NoError(t, kubeClient.Update(context.Background(), secret))
+
+	requeue, err := reconcileBootstrapData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.Equal(t, "#cloud-config\nruncmd:\n  - kubeadm join\n  - systemctl restart containerd\n", string(userData))
+}
+
+func TestReconcileBootstrapData_NetworkConfigV1(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.NetworkConfigVersion = infrav1alpha1.NetworkConfigVersionV1
+
+	var network cloudinit.Renderer
+	getISOInjector = func(_ *proxmox.VirtualMachine, _ []byte, _, networkRenderer, _ cloudinit.Renderer) isoInjector {
+		network = networkRenderer
+		return FakeISOInjector{}
+	}
+	t.Cleanup(func() { getISOInjector = defaultISOInjector })
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0")
+	vm.VirtualMachineConfig.SMBios1 = biosUUID
+	machineScope.SetVirtualMachine(vm)
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+	createBootstrapSecret(t, kubeClient, machineScope, cloudinit.FormatCloudConfig)
+
+	requeue, err := reconcileBootstrapData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.IsType(t, &cloudinit.NetworkConfigV1{}, network)
+
+	rendered, err := network.Render()
+	require.NoError(t, err)
+	require.Contains(t, string(rendered), "version: 1")
+	require.Contains(t, string(rendered), "mac_address: A6:23:64:4D:84:CB")
+}
+
+func TestReconcileBootstrapData_CloudInitTypeNoCloud(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.CloudInitType = infrav1alpha1.CloudInitTypeNoCloud
+
+	var metadata, network cloudinit.Renderer
+	getISOInjector = func(_ *proxmox.VirtualMachine, _ []byte, metadataRenderer, networkRenderer, _ cloudinit.Renderer) isoInjector {
+		metadata, network = metadataRenderer, networkRenderer
+		return FakeISOInjector{}
+	}
+	t.Cleanup(func() { getISOInjector = defaultISOInjector })
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0")
+	vm.VirtualMachineConfig.SMBios1 = biosUUID
+	machineScope.SetVirtualMachine(vm)
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+	createBootstrapSecret(t, kubeClient, machineScope, cloudinit.FormatCloudConfig)
+
+	requeue, err := reconcileBootstrapData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.IsType(t, &cloudinit.Metadata{}, metadata)
+	require.IsType(t, &cloudinit.NetworkConfig{}, network)
+}
+
+func TestReconcileBootstrapData_CloudInitTypeConfigDrive2(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.CloudInitType = infrav1alpha1.CloudInitTypeConfigDrive2
+	// the network config version only applies to nocloud.
+	machineScope.ProxmoxMachine.Spec.NetworkConfigVersion = infrav1alpha1.NetworkConfigVersionV1
+
+	var metadata, network cloudinit.Renderer
+	getConfigDriveInjector = func(_ *proxmox.VirtualMachine, client capmox.Client, _ []byte, metadataRenderer, networkRenderer, _ cloudinit.Renderer) isoInjector {
+		require.Equal(t, machineScope.InfraCluster.ProxmoxClient, client)
+		metadata, network = metadataRenderer, networkRenderer
+		return FakeISOInjector{}
+	}
+	t.Cleanup(func() { getConfigDriveInjector = defaultConfigDriveInjector })
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0")
+	vm.VirtualMachineConfig.SMBios1 = biosUUID
+	machineScope.SetVirtualMachine(vm)
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+	createBootstrapSecret(t, kubeClient, machineScope, cloudinit.FormatCloudConfig)
+
+	requeue, err := reconcileBootstrapData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.IsType(t, &cloudinit.ConfigDriveMetadata{}, metadata)
+	require.IsType(t, &cloudinit.NetworkData{}, network)
+
+	rendered, err := network.Render()
+	require.NoError(t, err)
+	require.Contains(t, string(rendered), `"ethernet_mac_address":"A6:23:64:4D:84:CB"`)
+	require.Contains(t, string(rendered), `"ip_address":"10.10.10.10"`)
+}
+
+func TestReconcileBootstrapData_VendorData(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.VendorData = "#cloud-config\npackage_update: true\n"
+
+	var vendor cloudinit.Renderer
+	getISOInjector = func(_ *proxmox.VirtualMachine, _ []byte, _, _, vendorRenderer cloudinit.Renderer) isoInjector {
+		vendor = vendorRenderer
+		return FakeISOInjector{}
+	}
+	t.Cleanup(func() { getISOInjector = defaultISOInjector })
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0")
+	vm.VirtualMachineConfig.SMBios1 = biosUUID
+	machineScope.SetVirtualMachine(vm)
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+	createBootstrapSecret(t, kubeClient, machineScope, cloudinit.FormatCloudConfig)
+
+	requeue, err := reconcileBootstrapData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+
+	rendered, err := vendor.Render()
+	require.NoError(t, err)
+	require.Equal(t, "#cloud-config\npackage_update: true\n", string(rendered))
+}
+
+func TestReconcileBootstrapData_LargeUserData(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+
+	var userData []byte
+	getISOInjector = func(_ *proxmox.VirtualMachine, bootstrapData []byte, _, _, _ cloudinit.Renderer) isoInjector {
+		userData = bootstrapData
+		return FakeISOInjector{}
+	}
+	t.Cleanup(func() { getISOInjector = defaultISOInjector })
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0")
+	vm.VirtualMachineConfig.SMBios1 = biosUUID
+	machineScope.SetVirtualMachine(vm)
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+	createBootstrapSecret(t, kubeClient, machineScope, cloudinit.FormatCloudConfig)
+
+	// the NoCloud ISO has no size limit, so large user data is written as is.
+	secret := &corev1.Secret{}
+	require.NoError(t, machineScope.GetBootstrapSecret(context.Background(), secret))
+	secret.Data["value"] = []byte("#cloud-config\nruncmd:\n" + strings.Repeat("  - kubeadm join\n", 1<<16))
+	require.NoError(t, kubeClient.Update(context.Background(), secret))
+
+	requeue, err := reconcileBootstrapData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.True(t, strings.HasPrefix(string(userData), "#cloud-config\n"))
+	require.Greater(t, len(userData), 1<<19)
+	require.True(t, conditions.IsTrue(machineScope.ProxmoxMachine, infrav1alpha1.BootstrapDataInjectedCondition))
+}
+
+func TestReconcileBootstrapData_NTPServers(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.InfraCluster.ProxmoxCluster.Spec.NTPServers = []string{"0.pool.ntp.org"}
+	machineScope.ProxmoxMachine.Spec.NTPServers = []string{"10.0.0.1", "ntp.example.com"}
+
+	var userData []byte
+	getISOInjector = func(_ *proxmox.VirtualMachine, bootstrapData []byte, _, _, _ cloudinit.Renderer) isoInjector {
+		userData = bootstrapData
+		return FakeISOInjector{}
+	}
+	t.Cleanup(func() { getISOInjector = defaultISOInjector })
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0")
+	vm.VirtualMachineConfig.SMBios1 = biosUUID
+	machineScope.SetVirtualMachine(vm)
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+	createBootstrapSecret(t, kubeClient, machineScope, cloudinit.FormatCloudConfig)
+
+	secret := &corev1.Secret{}
+	require.NoError(t, machineScope.GetBootstrapSecret(context.Background(), secret))
+	secret.Data["value"] = []byte("#cloud-config\nruncmd:\n  - kubeadm join\n")
+	require.NoError(t, kubeClient.Update(context.Background(), secret))
+
+	requeue, err := reconcileBootstrapData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.Equal(t, `#cloud-config
+runcmd:
+  - kubeadm join
+ntp:
+  enabled: true
+  servers:
+    - 10.0.0.1
+    - ntp.example.com
+`, string(userData))
+}
+
+func TestReconcileBootstrapData_SSHAuthorizedKeys(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.InfraCluster.ProxmoxCluster.Spec.SSHAuthorizedKeys = []string{"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAICluster cluster"}
+	machineScope.ProxmoxMachine.Spec.SSHAuthorizedKeys = []string{"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBootstrap machine"}
+
+	var userData []byte
+	getISOInjector = func(_ *proxmox.VirtualMachine, bootstrapData []byte, _, _, _ cloudinit.Renderer) isoInjector {
+		userData = bootstrapData
+		return FakeISOInjector{}
+	}
+	t.Cleanup(func() { getISOInjector = defaultISOInjector })
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0")
+	vm.VirtualMachineConfig.SMBios1 = biosUUID
+	machineScope.SetVirtualMachine(vm)
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+	createBootstrapSecret(t, kubeClient, machineScope, cloudinit.FormatCloudConfig)
+
+	secret := &corev1.Secret{}
+	require.NoError(t, machineScope.GetBootstrapSecret(context.Background(), secret))
+	secret.Data["value"] = []byte("#cloud-config\nssh_authorized_keys:\n  - ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBootstrap bootstrap\n")
+	require.NoError(t, kubeClient.Update(context.Background(), secret))
+
+	requeue, err := reconcileBootstrapData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.Equal(t, `#cloud-config
+ssh_authorized_keys:
+  - ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBootstrap bootstrap
+  - ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAICluster cluster
+`, string(userData))
+}
+
+func TestReconcileBootstrapData_Mounts(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Mounts = []infrav1alpha1.MountSpec{
+		{Device: "/dev/sdb", FSType: infrav1alpha1.FilesystemExt4, MountPoint: "/var/lib/etcd", Options: "noatime"},
+	}
+
+	var userData []byte
+	getISOInjector = func(_ *proxmox.VirtualMachine, bootstrapData []byte, _, _, _ cloudinit.Renderer) isoInjector {
+		userData = bootstrapData
+		return FakeISOInjector{}
+	}
+	t.Cleanup(func() { getISOInjector = defaultISOInjector })
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0")
+	vm.VirtualMachineConfig.SMBios1 = biosUUID
+	machineScope.SetVirtualMachine(vm)
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+	createBootstrapSecret(t, kubeClient, machineScope, cloudinit.FormatCloudConfig)
+
+	secret := &corev1.Secret{}
+	require.NoError(t, machineScope.GetBootstrapSecret(context.Background(), secret))
+	secret.Data["value"] = []byte("#cloud-config\nruncmd:\n  - kubeadm join\n")
+	require.NoError(t, kubeClient.Update(context.Background(), secret))
+
+	requeue, err := reconcileBootstrapData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.Contains(t, string(userData), "disk_setup:\n  /dev/sdb:\n")
+	require.Contains(t, string(userData), "  - label: capmox0\n    filesystem: ext4\n    device: /dev/sdb\n")
+	require.Contains(t, string(userData), "  - - LABEL=capmox0\n    - /var/lib/etcd\n    - ext4\n    - noatime\n")
+}
+
+func TestGetNTPServers(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	require.Empty(t, getNTPServers(machineScope))
+
+	machineScope.InfraCluster.ProxmoxCluster.Spec.NTPServers = []string{"0.pool.ntp.org"}
+	require.Equal(t, []string{"0.pool.ntp.org"}, getNTPServers(machineScope))
+
+	machineScope.ProxmoxMachine.Spec.NTPServers = []string{"10.0.0.1"}
+	require.Equal(t, []string{"10.0.0.1"}, getNTPServers(machineScope))
+}
+
+func TestReconcileBootstrapData_Files(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Files = []infrav1alpha1.FileSpec{
+		{Path: "/etc/sysctl.d/90-kubelet.conf", Content: ptr.To("vm.overcommit_memory=1"), Permissions: "0644"},
+		{Path: "/etc/containerd/conf.d/mirror.toml", ContentFrom: &infrav1alpha1.FileContentSource{
+			Kind: infrav1alpha1.FileContentSourceConfigMap, Name: "containerd", Key: "mirror.toml",
+		}},
+	}
+
+	var userData []byte
+	getISOInjector = func(_ *proxmox.VirtualMachine, bootstrapData []byte, _, _, _ cloudinit.Renderer) isoInjector {
+		userData = bootstrapData
+		return FakeISOInjector{}
+	}
+	t.Cleanup(func() { getISOInjector = defaultISOInjector })
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0")
+	vm.VirtualMachineConfig.SMBios1 = biosUUID
+	machineScope.SetVirtualMachine(vm)
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+	createBootstrapSecret(t, kubeClient, machineScope, cloudinit.FormatCloudConfig)
+
+	secret := &corev1.Secret{}
+	require.NoError(t, machineScope.GetBootstrapSecret(context.Background(), secret))
+	secret.Data["value"] = []byte("#cloud-config\nruncmd:\n  - kubeadm join\n")
+	require.NoError(t, kubeClient.Update(context.Background(), secret))
+
+	// the content of the configmap is missing at first.
+	requeue, err := reconcileBootstrapData(context.Background(), machineScope)
+	require.ErrorContains(t, err, "unable to read content of file /etc/containerd/conf.d/mirror.toml")
+	require.False(t, requeue)
+
+	require.NoError(t, kubeClient.Create(context.Background(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "containerd", Namespace: machineScope.Namespace()},
+		Data:       map[string]string{"mirror.toml": "[mirror]"},
+	}))
+
+	requeue, err = reconcileBootstrapData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.Equal(t, `#cloud-config
+runcmd:
+  - kubeadm join
+write_files:
+  - path: /etc/sysctl.d/90-kubelet.conf
+    content: vm.overcommit_memory=1
+    permissions: "0644"
+  - path: /etc/containerd/conf.d/mirror.toml
+    content: '[mirror]'
+`, string(userData))
+}
+
 func TestReconcileBootstrapData_UpdateStatus(t *testing.T) {
 	machineScope, _, kubeClient := setupReconcilerTest(t)
 	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
@@ -101,7 +447,7 @@ func TestReconcileBootstrapData_UpdateStatus(t *testing.T) {
 	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
 	createIP4AddressResource(t, kubeClient, machineScope, "net1", "10.100.10.10")
 	createBootstrapSecret(t, kubeClient, machineScope, cloudinit.FormatCloudConfig)
-	getISOInjector = func(_ *proxmox.VirtualMachine, _ []byte, _, _ cloudinit.Renderer) isoInjector {
+	getISOInjector = func(_ *proxmox.VirtualMachine, _ []byte, _, _, _ cloudinit.Renderer) isoInjector {
 		return FakeISOInjector{}
 	}
 	t.Cleanup(func() { getISOInjector = defaultISOInjector })
@@ -121,7 +467,7 @@ func TestReconcileBootstrapData_BadInjector(t *testing.T) {
 	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
 	createBootstrapSecret(t, kubeClient, machineScope, cloudinit.FormatCloudConfig)
 
-	getISOInjector = func(_ *proxmox.VirtualMachine, _ []byte, _, _ cloudinit.Renderer) isoInjector {
+	getISOInjector = func(_ *proxmox.VirtualMachine, _ []byte, _, _, _ cloudinit.Renderer) isoInjector {
 		return FakeISOInjector{Error: errors.New("bad FakeISOInjector")}
 	}
 	t.Cleanup(func() { getISOInjector = defaultISOInjector })
@@ -194,6 +540,19 @@ func TestGetNetworkConfigDataForDevice_MissingIPAddress(t *testing.T) {
 	require.Nil(t, cfg)
 }
 
+func TestGetNetworkConfigDataForDevice_StaticIPAddress(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.IPv4 = &infrav1alpha1.IPAddressSpec{Address: "10.0.0.15", Prefix: 24, Gateway: "10.0.0.1"}
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0")
+	machineScope.SetVirtualMachine(vm)
+
+	cfg, err := getNetworkConfigDataForDevice(context.Background(), machineScope, DefaultNetworkDeviceIPV4)
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.15/24", cfg.IPAddress)
+	require.Equal(t, "10.0.0.1", cfg.Gateway)
+	require.Nil(t, cfg.Metric)
+}
+
 func TestGetNetworkConfigDataForDevice_MissingMACAddress(t *testing.T) {
 	machineScope, _, _ := setupReconcilerTest(t)
 	machineScope.SetVirtualMachine(newStoppedVM())
@@ -321,6 +680,274 @@ func TestGetVirtualNetworkDevices_VRFDevice_MissingInterface(t *testing.T) {
 	require.Nil(t, cfg)
 }
 
+func TestGetAdditionalNetworkDevices_DeviceMTU(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+
+	networkSpec := infrav1alpha1.NetworkSpec{
+		AdditionalDevices: []infrav1alpha1.AdditionalNetworkDevice{
+			{
+				NetworkDevice: infrav1alpha1.NetworkDevice{Bridge: "vmbr1", Model: ptr.To("virtio"), MTU: ptr.To(uint16(9000))},
+				Name:          "net1",
+				InterfaceConfig: infrav1alpha1.InterfaceConfig{
+					IPv4PoolRef: &corev1.TypedLocalObjectReference{
+						APIGroup: ptr.To("ipam.cluster.x-k8s.io"),
+						Kind:     "InClusterIPPool",
+						Name:     "sample",
+					},
+				},
+			},
+			{
+				NetworkDevice: infrav1alpha1.NetworkDevice{Bridge: "vmbr2", Model: ptr.To("virtio"), MTU: ptr.To(uint16(1))},
+				Name:          "net2",
+				InterfaceConfig: infrav1alpha1.InterfaceConfig{
+					IPv4PoolRef: &corev1.TypedLocalObjectReference{
+						APIGroup: ptr.To("ipam.cluster.x-k8s.io"),
+						Kind:     "InClusterIPPool",
+						Name:     "sample-inherit",
+					},
+				},
+			},
+		},
+	}
+	machineScope.ProxmoxMachine.Spec.Network = &networkSpec
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0", "virtio=AA:23:64:4D:84:CD,bridge=vmbr1,mtu=9000", "virtio=AA:23:64:4D:84:CE,bridge=vmbr2,mtu=1")
+	machineScope.SetVirtualMachine(vm)
+	createIPPools(t, kubeClient, machineScope)
+	createIP4AddressResource(t, kubeClient, machineScope, "net1", "10.0.0.10")
+	createIP4AddressResource(t, kubeClient, machineScope, "net2", "10.0.1.10")
+
+	cfg, err := getAdditionalNetworkDevices(context.Background(), machineScope, networkSpec)
+	require.NoError(t, err)
+	require.Len(t, cfg, 2)
+	require.Equal(t, ptr.To(uint16(9000)), cfg[0].LinkMTU)
+	// MTU 1 inherits from the bridge and must not end up in the guest.
+	require.Nil(t, cfg[1].LinkMTU)
+}
+
+func TestGetNetworkConfigData_Routes(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		Routes: []infrav1alpha1.RouteSpec{
+			{To: "10.200.0.0/16", Via: "10.10.10.254", Metric: 50},
+			{To: "10.201.0.0/16", Via: "10.10.10.254"},
+		},
+		AdditionalDevices: []infrav1alpha1.AdditionalNetworkDevice{
+			{
+				NetworkDevice: infrav1alpha1.NetworkDevice{Bridge: "vmbr1", Model: ptr.To("virtio")},
+				Name:          "net1",
+				InterfaceConfig: infrav1alpha1.InterfaceConfig{
+					IPv4PoolRef: &corev1.TypedLocalObjectReference{
+						APIGroup: ptr.To("ipam.cluster.x-k8s.io"),
+						Kind:     "InClusterIPPool",
+						Name:     "sample",
+					},
+					Routing: infrav1alpha1.Routing{
+						Routes: []infrav1alpha1.RouteSpec{
+							{To: "172.24.16.0/24", Via: "10.0.0.1"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0", "virtio=AA:23:64:4D:84:CD,bridge=vmbr1")
+	machineScope.SetVirtualMachine(vm)
+	createIPPools(t, kubeClient, machineScope)
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+	createIP4AddressResource(t, kubeClient, machineScope, "net1", "10.0.0.10")
+
+	cfg, err := getNetworkConfigData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.Len(t, cfg, 2)
+	require.Equal(t, []types.RoutingData{
+		{To: "10.200.0.0/16", Via: "10.10.10.254", Metric: 50},
+		{To: "10.201.0.0/16", Via: "10.10.10.254"},
+	}, cfg[0].Routes)
+	require.Equal(t, []types.RoutingData{{To: "172.24.16.0/24", Via: "10.0.0.1"}}, cfg[1].Routes)
+
+	network, err := cloudinit.NewNetworkConfig(cfg).Render()
+	require.NoError(t, err)
+	require.Contains(t, string(network), `- { "to": "10.200.0.0/16",  "via": "10.10.10.254",  "metric": 50, }`)
+	require.Contains(t, string(network), `- { "to": "10.201.0.0/16",  "via": "10.10.10.254", }`)
+	require.Contains(t, string(network), `- { "to": "172.24.16.0/24",  "via": "10.0.0.1", }`)
+}
+
+func TestGetNetworkConfigData_Bond(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		Routes: []infrav1alpha1.RouteSpec{{To: "10.200.0.0/16", Via: "10.10.10.254"}},
+		AdditionalDevices: []infrav1alpha1.AdditionalNetworkDevice{
+			{
+				NetworkDevice:   infrav1alpha1.NetworkDevice{Bridge: "vmbr1", Model: ptr.To("virtio"), DHCP4: true},
+				Name:            "net1",
+				InterfaceConfig: infrav1alpha1.InterfaceConfig{},
+			},
+		},
+		VirtualNetworkDevices: infrav1alpha1.VirtualNetworkDevices{
+			Bonds: []infrav1alpha1.BondSpec{{
+				Name:               "bond0",
+				Interfaces:         []string{"net0", "net1"},
+				Mode:               infrav1alpha1.BondModeActiveBackup,
+				MIIMonitorInterval: ptr.To(uint32(100)),
+			}},
+		},
+	}
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0", "virtio=AA:23:64:4D:84:CD,bridge=vmbr1")
+	machineScope.SetVirtualMachine(vm)
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+
+	cfg, err := getNetworkConfigData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.Len(t, cfg, 3)
+
+	// the addressing moved from the members to the bond.
+	require.Equal(t, types.NetworkConfigData{ProxName: "net0", MacAddress: "A6:23:64:4D:84:CB", Type: "ethernet", Name: "eth0", Bond: "bond0"}, cfg[0])
+	require.Equal(t, types.NetworkConfigData{ProxName: "net1", MacAddress: "AA:23:64:4D:84:CD", Type: "ethernet", Name: "eth1", Bond: "bond0"}, cfg[1])
+
+	bond := cfg[2]
+	require.Equal(t, "bond", bond.Type)
+	require.Equal(t, "bond0", bond.Name)
+	require.Empty(t, bond.MacAddress)
+	require.Equal(t, []string{"eth0", "eth1"}, bond.Interfaces)
+	require.Equal(t, "active-backup", bond.BondMode)
+	require.Equal(t, ptr.To(uint32(100)), bond.BondMIIMon)
+	require.True(t, strings.HasPrefix(bond.IPAddress, "10.10.10.10/"))
+	require.Equal(t, []types.RoutingData{{To: "10.200.0.0/16", Via: "10.10.10.254"}}, bond.Routes)
+
+	network, err := cloudinit.NewNetworkConfig(cfg).Render()
+	require.NoError(t, err)
+	require.Contains(t, string(network), "  bonds:\n    bond0:\n      interfaces:\n        - eth0\n        - eth1\n      parameters:\n        mode: active-backup\n")
+}
+
+func TestGetBondDevices_MissingInterface(t *testing.T) {
+	networkSpec := infrav1alpha1.NetworkSpec{
+		VirtualNetworkDevices: infrav1alpha1.VirtualNetworkDevices{
+			Bonds: []infrav1alpha1.BondSpec{{
+				Name:       "bond0",
+				Interfaces: []string{"net0", "net1"},
+				Mode:       infrav1alpha1.BondModeActiveBackup,
+			}},
+		},
+	}
+	networkConfigData := []types.NetworkConfigData{{Type: "ethernet", Name: "eth0", ProxName: "net0"}}
+
+	cfg, err := getBondDevices(networkSpec, networkConfigData)
+	require.ErrorContains(t, err, "unable to find bond interface=bond0 child interface net1")
+	require.Nil(t, cfg)
+}
+
+func TestGetBridgeDevices(t *testing.T) {
+	networkSpec := infrav1alpha1.NetworkSpec{
+		VirtualNetworkDevices: infrav1alpha1.VirtualNetworkDevices{
+			Bridges: []infrav1alpha1.GuestBridgeSpec{{
+				Name:         "br0",
+				Interfaces:   []string{"net0", "bond0"},
+				STP:          ptr.To(true),
+				ForwardDelay: ptr.To(uint32(4)),
+			}},
+		},
+	}
+	networkConfigData := []types.NetworkConfigData{
+		{Type: "ethernet", Name: "eth0", ProxName: "net0", MacAddress: "A6:23:64:4D:84:CB", IPAddress: "10.10.10.10/24", Gateway: "10.10.10.1", DNSServers: []string{"8.8.8.8"}},
+		{Type: "ethernet", Name: "eth1", ProxName: "net1", MacAddress: "AA:23:64:4D:84:CD", Bond: "bond0"},
+		{Type: "bond", Name: "bond0", Interfaces: []string{"eth1"}, BondMode: "active-backup", DHCP4: true},
+	}
+
+	cfg, err := getBridgeDevices(networkSpec, networkConfigData)
+	require.NoError(t, err)
+	require.Equal(t, []types.NetworkConfigData{{
+		Type:         "bridge",
+		Name:         "br0",
+		Interfaces:   []string{"eth0", "bond0"},
+		BridgeSTP:    ptr.To(true),
+		ForwardDelay: ptr.To(uint32(4)),
+		IPAddress:    "10.10.10.10/24",
+		Gateway:      "10.10.10.1",
+		DNSServers:   []string{"8.8.8.8"},
+	}}, cfg)
+
+	// the addressing moved from the members to the bridge.
+	require.Equal(t, types.NetworkConfigData{Type: "ethernet", Name: "eth0", ProxName: "net0", MacAddress: "A6:23:64:4D:84:CB", Bridge: "br0"}, networkConfigData[0])
+	require.Equal(t, types.NetworkConfigData{Type: "bond", Name: "bond0", Interfaces: []string{"eth1"}, BondMode: "active-backup", Bridge: "br0"}, networkConfigData[2])
+}
+
+func TestGetBridgeDevices_MissingInterface(t *testing.T) {
+	networkSpec := infrav1alpha1.NetworkSpec{
+		VirtualNetworkDevices: infrav1alpha1.VirtualNetworkDevices{
+			Bridges: []infrav1alpha1.GuestBridgeSpec{{Name: "br0", Interfaces: []string{"net1"}}},
+		},
+	}
+	networkConfigData := []types.NetworkConfigData{{Type: "ethernet", Name: "eth0", ProxName: "net0"}}
+
+	cfg, err := getBridgeDevices(networkSpec, networkConfigData)
+	require.ErrorContains(t, err, "unable to find bridge interface=br0 child interface net1")
+	require.Nil(t, cfg)
+}
+
+func TestGetVLANDevices(t *testing.T) {
+	networkSpec := infrav1alpha1.NetworkSpec{
+		VirtualNetworkDevices: infrav1alpha1.VirtualNetworkDevices{
+			VLANs: []infrav1alpha1.GuestVLANSpec{
+				{Name: "eth0.100", Link: "net0", ID: 100, IPv4: &infrav1alpha1.IPAddressSpec{Address: "10.20.0.5", Prefix: 24}},
+				{Name: "bond0.200", Link: "bond0", ID: 200, IPv6: &infrav1alpha1.IPAddressSpec{Address: "2001:db8::5", Prefix: 64, Gateway: "2001:db8::1"}},
+			},
+		},
+	}
+	networkConfigData := []types.NetworkConfigData{
+		{Type: "ethernet", Name: "eth0", ProxName: "net0", DHCP4: true},
+		{Type: "ethernet", Name: "eth1", ProxName: "net1", Bond: "bond0"},
+		{Type: "bond", Name: "bond0", Interfaces: []string{"eth1"}, DHCP4: true},
+	}
+
+	cfg, err := getVLANDevices(networkSpec, networkConfigData)
+	require.NoError(t, err)
+	require.Equal(t, []types.NetworkConfigData{
+		{Type: "vlan", Name: "eth0.100", Link: "eth0", VLANID: 100, IPAddress: "10.20.0.5/24"},
+		{Type: "vlan", Name: "bond0.200", Link: "bond0", VLANID: 200, IPV6Address: "2001:db8::5/64", Gateway6: "2001:db8::1"},
+	}, cfg)
+}
+
+func TestGetVLANDevices_MissingLink(t *testing.T) {
+	networkSpec := infrav1alpha1.NetworkSpec{
+		VirtualNetworkDevices: infrav1alpha1.VirtualNetworkDevices{
+			VLANs: []infrav1alpha1.GuestVLANSpec{{Name: "eth1.100", Link: "net1", ID: 100}},
+		},
+	}
+	networkConfigData := []types.NetworkConfigData{{Type: "ethernet", Name: "eth0", ProxName: "net0"}}
+
+	cfg, err := getVLANDevices(networkSpec, networkConfigData)
+	require.ErrorContains(t, err, "unable to find vlan interface=eth1.100 link net1")
+	require.Nil(t, cfg)
+}
+
+func TestGetNetworkConfigData_SearchDomains(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.InfraCluster.ProxmoxCluster.Spec.SearchDomains = []string{"example.com", "Example.COM", "cluster.local"}
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0")
+	machineScope.SetVirtualMachine(vm)
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+
+	cfg, err := getNetworkConfigData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.Len(t, cfg, 1)
+	require.Equal(t, []string{"example.com", "cluster.local"}, cfg[0].SearchDomains)
+
+	// the machine's search domains replace the cluster's.
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		SearchDomains: []string{"machine.example.com"},
+	}
+	cfg, err = getNetworkConfigData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.Len(t, cfg, 1)
+	require.Equal(t, []string{"machine.example.com"}, cfg[0].SearchDomains)
+}
+
 func TestReconcileBootstrapData_DualStack(t *testing.T) {
 	machineScope, _, kubeClient := setupReconcilerTest(t)
 	machineScope.InfraCluster.ProxmoxCluster.Spec.IPv6Config = &infrav1alpha1.IPConfigSpec{
@@ -337,7 +964,7 @@ func TestReconcileBootstrapData_DualStack(t *testing.T) {
 	createIP6AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "2001:db8::2")
 
 	createBootstrapSecret(t, kubeClient, machineScope, cloudinit.FormatCloudConfig)
-	getISOInjector = func(_ *proxmox.VirtualMachine, _ []byte, _, _ cloudinit.Renderer) isoInjector {
+	getISOInjector = func(_ *proxmox.VirtualMachine, _ []byte, _, _, _ cloudinit.Renderer) isoInjector {
 		return FakeISOInjector{}
 	}
 	t.Cleanup(func() { getISOInjector = defaultISOInjector })
@@ -389,7 +1016,7 @@ func TestReconcileBootstrapData_DualStack_AdditionalDevices(t *testing.T) {
 	createIP4AddressResource(t, kubeClient, machineScope, "net1", "10.0.0.10")
 	createIP6AddressResource(t, kubeClient, machineScope, "net1", "2001:db8::9")
 	createBootstrapSecret(t, kubeClient, machineScope, cloudinit.FormatCloudConfig)
-	getISOInjector = func(_ *proxmox.VirtualMachine, _ []byte, _, _ cloudinit.Renderer) isoInjector {
+	getISOInjector = func(_ *proxmox.VirtualMachine, _ []byte, _, _, _ cloudinit.Renderer) isoInjector {
 		return FakeISOInjector{}
 	}
 	t.Cleanup(func() { getISOInjector = defaultISOInjector })
@@ -434,7 +1061,7 @@ func TestReconcileBootstrapData_VirtualDevices_VRF(t *testing.T) {
 	createIP4AddressResource(t, kubeClient, machineScope, "net1", "10.100.10.10")
 
 	createBootstrapSecret(t, kubeClient, machineScope, cloudinit.FormatCloudConfig)
-	getISOInjector = func(_ *proxmox.VirtualMachine, _ []byte, _, _ cloudinit.Renderer) isoInjector {
+	getISOInjector = func(_ *proxmox.VirtualMachine, _ []byte, _, _, _ cloudinit.Renderer) isoInjector {
 		return FakeISOInjector{}
 	}
 	t.Cleanup(func() { getISOInjector = defaultISOInjector })
@@ -500,7 +1127,7 @@ func TestReconcileBootstrapData_Format_CloudConfig(t *testing.T) {
 	createBootstrapSecret(t, kubeClient, machineScope, cloudinit.FormatCloudConfig)
 	machineScope.SetVirtualMachine(vm)
 
-	getISOInjector = func(_ *proxmox.VirtualMachine, _ []byte, _, _ cloudinit.Renderer) isoInjector {
+	getISOInjector = func(_ *proxmox.VirtualMachine, _ []byte, _, _, _ cloudinit.Renderer) isoInjector {
 		return FakeISOInjector{}
 	}
 	t.Cleanup(func() { getISOInjector = defaultISOInjector })
@@ -517,6 +1144,36 @@ func TestReconcileBootstrapData_Format_CloudConfig(t *testing.T) {
 	require.Nil(t, err)
 }
 
+func TestReconcileBootstrapData_Format_Unsupported(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0")
+	vm.VirtualMachineConfig.SMBios1 = biosUUID
+	machineScope.SetVirtualMachine(vm)
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+
+	machineScope.Machine.Spec.Bootstrap.DataSecretName = ptr.To(machineScope.Name())
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      machineScope.Name(),
+			Namespace: machineScope.Namespace(),
+		},
+		Data: map[string][]byte{
+			"value":  []byte("data"),
+			"format": []byte("butane"),
+		},
+	}
+	require.NoError(t, kubeClient.Create(context.Background(), secret))
+
+	requeue, err := reconcileBootstrapData(context.Background(), machineScope)
+	require.ErrorContains(t, err, `unsupported bootstrap data format "butane"`)
+	require.False(t, requeue)
+	require.Nil(t, machineScope.ProxmoxMachine.Status.BootstrapDataProvided)
+	require.Equal(t, capierrors.InvalidConfigurationMachineError, *machineScope.ProxmoxMachine.Status.FailureReason)
+	requireConditionIsFalse(t, machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition)
+}
+
 func TestReconcileBootstrapData_Format_Ignition(t *testing.T) {
 	machineScope, _, kubeClient := setupReconcilerTest(t)
 
@@ -545,7 +1202,7 @@ func TestReconcileBootstrapData_Format_Ignition(t *testing.T) {
 }
 
 func TestDefaultISOInjector(t *testing.T) {
-	injector := defaultISOInjector(newRunningVM(), []byte("data"), cloudinit.NewMetadata(biosUUID, "test", "1.2.3", true), cloudinit.NewNetworkConfig(nil))
+	injector := defaultISOInjector(newRunningVM(), []byte("data"), cloudinit.NewMetadata(biosUUID, "test", "1.2.3", true), cloudinit.NewNetworkConfig(nil), cloudinit.NewVendorData(""))
 
 	require.NotEmpty(t, injector)
 	require.Equal(t, []byte("data"), injector.(*inject.ISOInjector).BootstrapData)
@@ -561,3 +1218,44 @@ func TestIgnitionISOInjector(t *testing.T) {
 	require.NotNil(t, injector.(*inject.ISOInjector).IgnitionEnricher)
 	require.Equal(t, []byte("data"), injector.(*inject.ISOInjector).IgnitionEnricher.BootstrapData)
 }
+
+func TestGetAdditionalNetworkDevices_IPv6SLAAC(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+
+	networkSpec := infrav1alpha1.NetworkSpec{
+		AdditionalDevices: []infrav1alpha1.AdditionalNetworkDevice{
+			{
+				NetworkDevice: infrav1alpha1.NetworkDevice{Bridge: "vmbr1", Model: ptr.To("virtio"), IPv6Mode: infrav1alpha1.IPv6ModeSLAAC},
+				Name:          "net1",
+			},
+		},
+	}
+	machineScope.ProxmoxMachine.Spec.Network = &networkSpec
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0", "virtio=AA:23:64:4D:84:CD,bridge=vmbr1")
+	machineScope.SetVirtualMachine(vm)
+
+	cfg, err := getAdditionalNetworkDevices(context.Background(), machineScope, networkSpec)
+	require.NoError(t, err)
+	require.Len(t, cfg, 1)
+	require.Equal(t, "AA:23:64:4D:84:CD", cfg[0].MacAddress)
+	require.True(t, cfg[0].AcceptRA)
+	require.False(t, cfg[0].DHCP6)
+	require.Empty(t, cfg[0].IPV6Address)
+}
+
+func TestGetDefaultNetworkDevice_DHCP4(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		Default: &infrav1alpha1.NetworkDevice{Bridge: "vmbr0", DHCP4: true},
+	}
+	machineScope.SetVirtualMachine(newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0"))
+
+	cfg, err := getDefaultNetworkDevice(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.Len(t, cfg, 1)
+	require.Equal(t, "A6:23:64:4D:84:CB", cfg[0].MacAddress)
+	require.True(t, cfg[0].DHCP4)
+	require.Empty(t, cfg[0].IPAddress)
+	require.Equal(t, machineScope.InfraCluster.ProxmoxCluster.Spec.DNSServers, cfg[0].DNSServers)
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"strings"
+
+	"github.com/luthermonson/go-proxmox"
+	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+// cloneLock is the lock Proxmox holds on a VM while it is being cloned.
+const cloneLock = "clone"
+
+// cloneIncomplete reports whether the machine started to clone its VM, but the clone never completed,
+// e.g. because the clone task failed or was stopped after it timed out.
+func cloneIncomplete(machineScope *scope.MachineScope) bool {
+	machine := machineScope.ProxmoxMachine
+	return machine.Spec.ExistingVMID == nil && !machine.Status.Adopted &&
+		conditions.Has(machine, infrav1alpha1.VMClonedCondition) &&
+		!conditions.IsTrue(machine, infrav1alpha1.VMClonedCondition)
+}
+
+// ownedByMachine reports whether the VM was cloned for the machine. The VM must have the name of the machine
+// and must not carry the tag of another cluster, so VMs which are not managed by the machine are never touched.
+func ownedByMachine(machineScope *scope.MachineScope, vm *proxmox.VirtualMachine) bool {
+	if vm.VirtualMachineConfig == nil || vm.VirtualMachineConfig.Name != vmName(machineScope) {
+		return false
+	}
+	for _, tag := range splitTags(vm.VirtualMachineConfig.Tags) {
+		if strings.HasPrefix(tag, "cluster_") && !strings.EqualFold(tag, clusterTag(machineScope)) {
+			return false
+		}
+	}
+	return true
+}
+
+// cleanupIncompleteClone deletes the VM of a clone, which was left behind locked by a failed clone task.
+// The clone is started again once the VM is deleted.
+func cleanupIncompleteClone(ctx context.Context, machineScope *scope.MachineScope, vm *proxmox.VirtualMachine) (requeue bool, err error) {
+	if !ownedByMachine(machineScope, vm) {
+		return false, errors.Errorf("vm %d is locked by an incomplete clone, but is not owned by the machine", vm.VMID)
+	}
+
+	task, err := machineScope.InfraCluster.ProxmoxClient.DeleteVM(ctx, vm.Node, int64(vm.VMID))
+	if err != nil {
+		return false, errors.Wrapf(err, "unable to delete vm %d left behind by an incomplete clone", vm.VMID)
+	}
+	machineScope.Warnf("IncompleteCloneDeleted", "Deleting VM %d on node %s left behind by an incomplete clone", vm.VMID, vm.Node)
+
+	if err := resetClone(machineScope); err != nil {
+		return false, err
+	}
+	machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(task.UPID))
+	return true, nil
+}
+
+// retryMissingClone starts the clone again, if the VM of an incomplete clone doesn't exist anymore,
+// e.g. because Proxmox removed it after the clone task failed.
+// It reports whether the clone is retried.
+func retryMissingClone(ctx context.Context, machineScope *scope.MachineScope) (bool, error) {
+	vmID := machineScope.GetVirtualMachineID()
+	free, err := machineScope.InfraCluster.ProxmoxClient.CheckID(ctx, vmID)
+	if err != nil {
+		return false, errors.Wrapf(err, "unable to check vmid %d", vmID)
+	}
+	if !free {
+		return false, nil
+	}
+
+	machineScope.Warnf("CloneRetried", "VM %d of the incomplete clone does not exist, cloning it again", vmID)
+	return true, resetClone(machineScope)
+}
+
+// resetClone forgets the VM of an incomplete clone, so the next reconcile clones the VM again.
+// The allocated VMID is kept, so the clone reuses it once it is free.
+func resetClone(machineScope *scope.MachineScope) error {
+	machineScope.ProxmoxMachine.Spec.VirtualMachineID = nil
+	machineScope.ProxmoxMachine.Status.VMID = nil
+	machineScope.ProxmoxMachine.Status.ProxmoxNode = nil
+	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMClonedCondition, infrav1alpha1.CloningReason, clusterv1.ConditionSeverityInfo, "retrying incomplete clone")
+
+	machineScope.InfraCluster.ProxmoxCluster.RemoveNodeLocation(machineScope.Name(), util.IsControlPlaneMachine(machineScope.Machine))
+	return machineScope.InfraCluster.PatchObject()
+}
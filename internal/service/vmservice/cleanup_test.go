@@ -0,0 +1,125 @@
+/*
+Copyright 2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/luthermonson/go-proxmox"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+// setupIncompleteClone prepares a machine, whose clone of VM 123 on node1 was started, but never completed.
+func setupIncompleteClone(t *testing.T, machineScope *scope.MachineScope) *record.FakeRecorder {
+	t.Helper()
+	recorder := record.NewFakeRecorder(1)
+	machineScope.Recorder = recorder
+	machineScope.SetVirtualMachineID(123)
+	machineScope.ProxmoxMachine.Status.ProxmoxNode = ptr.To("node1")
+	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMClonedCondition, infrav1alpha1.CloningReason, clusterv1.ConditionSeverityInfo, "")
+	return recorder
+}
+
+func newLockedClone() *proxmox.VirtualMachine {
+	vm := newStoppedVM()
+	vm.VMID = 123
+	vm.VirtualMachineConfig.Name = "test"
+	vm.Lock = cloneLock
+	return vm
+}
+
+func TestEnsureVirtualMachine_DeleteIncompleteClone(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	recorder := setupIncompleteClone(t, machineScope)
+	task := newTask()
+	proxmoxClient.EXPECT().GetVM(context.Background(), "node1", int64(123)).Return(newLockedClone(), nil).Once()
+	proxmoxClient.EXPECT().DeleteVM(context.Background(), "node1", int64(123)).Return(task, nil).Once()
+
+	requeue, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Nil(t, machineScope.ProxmoxMachine.Spec.VirtualMachineID)
+	require.Nil(t, machineScope.ProxmoxMachine.Status.ProxmoxNode)
+	require.Equal(t, string(task.UPID), *machineScope.ProxmoxMachine.Status.TaskRef)
+	require.False(t, conditions.IsTrue(machineScope.ProxmoxMachine, infrav1alpha1.VMClonedCondition))
+	require.Equal(t, "Warning IncompleteCloneDeleted Deleting VM 123 on node node1 left behind by an incomplete clone", <-recorder.Events)
+}
+
+func TestEnsureVirtualMachine_IncompleteCloneOfOtherCluster(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	setupIncompleteClone(t, machineScope)
+	vm := newLockedClone()
+	vm.VirtualMachineConfig.Tags = "cluster_other"
+	proxmoxClient.EXPECT().GetVM(context.Background(), "node1", int64(123)).Return(vm, nil).Once()
+
+	_, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.ErrorContains(t, err, "not owned by the machine")
+	require.Equal(t, int64(123), machineScope.GetVirtualMachineID())
+}
+
+func TestEnsureVirtualMachine_CompletedCloneIsKept(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	setupIncompleteClone(t, machineScope)
+	vm := newLockedClone()
+	vm.Lock = ""
+	proxmoxClient.EXPECT().GetVM(context.Background(), "node1", int64(123)).Return(vm, nil).Once()
+
+	requeue, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.True(t, conditions.IsTrue(machineScope.ProxmoxMachine, infrav1alpha1.VMClonedCondition))
+}
+
+func TestEnsureVirtualMachine_RetryMissingClone(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	recorder := setupIncompleteClone(t, machineScope)
+	proxmoxClient.EXPECT().GetVM(context.Background(), "node1", int64(123)).Return(nil, errors.New("vm does not exist")).Once()
+	proxmoxClient.EXPECT().CheckID(context.Background(), int64(123)).Return(true, nil).Once()
+
+	requeue, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Nil(t, machineScope.ProxmoxMachine.Spec.VirtualMachineID)
+	require.Nil(t, machineScope.ProxmoxMachine.Status.VMID)
+	require.Equal(t, "Warning CloneRetried VM 123 of the incomplete clone does not exist, cloning it again", <-recorder.Events)
+}
+
+func TestOwnedByMachine(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+
+	vm := newLockedClone()
+	require.True(t, ownedByMachine(machineScope, vm))
+
+	vm.VirtualMachineConfig.Tags = "cluster_test;foo"
+	require.True(t, ownedByMachine(machineScope, vm))
+
+	vm.VirtualMachineConfig.Tags = "cluster_other"
+	require.False(t, ownedByMachine(machineScope, vm))
+
+	vm.VirtualMachineConfig.Tags = ""
+	vm.VirtualMachineConfig.Name = "unrelated"
+	require.False(t, ownedByMachine(machineScope, vm))
+}
@@ -18,8 +18,13 @@ package vmservice
 
 import (
 	"context"
+	"fmt"
 	"strings"
+	"time"
 
+	"github.com/luthermonson/go-proxmox"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/conditions"
@@ -32,18 +37,54 @@ import (
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
 )
 
+const (
+	// taskTypeShutdown is the type of Proxmox tasks shutting down a VM.
+	taskTypeShutdown = "qmshutdown"
+	// taskTypeStop is the type of Proxmox tasks stopping a VM.
+	taskTypeStop = "qmstop"
+)
+
 // DeleteVM implements the logic of destroying a VM.
+// With PreDeleteSnapshot, a snapshot of the VM is taken first. Adopted VMs with the Detach policy
+// are only untagged. With ShutdownTimeout, the guest is shut down gracefully before the VM is destroyed.
+// With RetainDisks, the VM is kept stopped instead of being destroyed, and tracked in the status of the ProxmoxCluster.
 func DeleteVM(ctx context.Context, machineScope *scope.MachineScope) error {
+	if machineScope.ProxmoxMachine.Spec.PreDeleteSnapshot {
+		done, err := reconcilePreDeleteSnapshot(ctx, machineScope)
+		if err != nil || !done {
+			return err
+		}
+	}
+
+	if machineScope.ProxmoxMachine.Status.Adopted && machineScope.ProxmoxMachine.Spec.DeletionPolicy == infrav1alpha1.VMDeletionPolicyDetach {
+		detached, err := detachVM(ctx, machineScope)
+		if err != nil || !detached {
+			return err
+		}
+		return finalizeDeletion(ctx, machineScope)
+	}
+
+	if machineScope.ProxmoxMachine.Spec.ShutdownTimeout != nil {
+		stopped, err := shutdownVM(ctx, machineScope)
+		if err != nil || !stopped {
+			return err
+		}
+	}
+
+	if machineScope.ProxmoxMachine.Spec.RetainDisks {
+		retained, err := retainVM(ctx, machineScope)
+		if err != nil || !retained {
+			return err
+		}
+		return finalizeDeletion(ctx, machineScope)
+	}
+
 	vmID := machineScope.ProxmoxMachine.GetVirtualMachineID()
 	node := machineScope.LocateProxmoxNode()
 
 	if _, err := machineScope.InfraCluster.ProxmoxClient.DeleteVM(ctx, node, vmID); err != nil {
 		if VMNotFound(err) || errors.Is(err, goproxmox.ErrVMIDFree) {
-			// remove machine from cluster status
-			machineScope.InfraCluster.ProxmoxCluster.RemoveNodeLocation(machineScope.Name(), util.IsControlPlaneMachine(machineScope.Machine))
-			// The VM is deleted so remove the finalizer.
-			ctrlutil.RemoveFinalizer(machineScope.ProxmoxMachine, infrav1alpha1.MachineFinalizer)
-			return machineScope.InfraCluster.PatchObject()
+			return finalizeDeletion(ctx, machineScope)
 		}
 		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, clusterv1.DeletionFailedReason, clusterv1.ConditionSeverityWarning, "")
 		return err
@@ -52,6 +93,222 @@ func DeleteVM(ctx context.Context, machineScope *scope.MachineScope) error {
 	return nil
 }
 
+// finalizeDeletion cleans up after the VM is gone and removes the finalizer.
+func finalizeDeletion(ctx context.Context, machineScope *scope.MachineScope) error {
+	// remove machine from cluster status
+	machineScope.InfraCluster.ProxmoxCluster.RemoveNodeLocation(machineScope.Name(), util.IsControlPlaneMachine(machineScope.Machine))
+	// release the addresses of the network devices, before the finalizer is removed.
+	released, err := releaseIPAddresses(ctx, machineScope)
+	if err != nil {
+		return err
+	}
+	if !released {
+		machineScope.Info("waiting for the ip addresses to be released")
+		return machineScope.InfraCluster.PatchObject()
+	}
+	// The VM is deleted so remove the finalizer.
+	ctrlutil.RemoveFinalizer(machineScope.ProxmoxMachine, infrav1alpha1.MachineFinalizer)
+	return machineScope.InfraCluster.PatchObject()
+}
+
+// getVMForDeletion returns the VM of the machine, or nil if it does not exist (anymore).
+func getVMForDeletion(ctx context.Context, machineScope *scope.MachineScope) (*proxmox.VirtualMachine, error) {
+	vmID := machineScope.ProxmoxMachine.GetVirtualMachineID()
+	// the VM was never created.
+	if vmID < 100 {
+		return nil, nil
+	}
+
+	vm, err := machineScope.InfraCluster.ProxmoxClient.GetVM(ctx, machineScope.LocateProxmoxNode(), vmID)
+	if err != nil {
+		if VMNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return vm, nil
+}
+
+// reconcileDeletionTask checks the task started while deleting the machine.
+// It returns true once there is no task running anymore.
+func reconcileDeletionTask(ctx context.Context, machineScope *scope.MachineScope) (bool, error) {
+	if machineScope.ProxmoxMachine.Status.TaskRef == nil {
+		return true, nil
+	}
+
+	task, err := machineScope.InfraCluster.ProxmoxClient.GetTask(ctx, *machineScope.ProxmoxMachine.Status.TaskRef)
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case task.IsRunning:
+		machineScope.Logger.V(4).Info("task is still pending", "description", task.Type)
+		return false, nil
+	case task.IsFailed:
+		machineScope.ProxmoxMachine.Status.TaskRef = nil
+		return false, errors.Errorf("task %s failed: %s", task.Type, task.ExitStatus)
+	default:
+		machineScope.ProxmoxMachine.Status.TaskRef = nil
+		return true, nil
+	}
+}
+
+// reconcilePreDeleteSnapshot takes the snapshot of the VM before it is deleted.
+// It returns true once the snapshot exists, or there is no VM to take it from.
+func reconcilePreDeleteSnapshot(ctx context.Context, machineScope *scope.MachineScope) (bool, error) {
+	if conditions.IsTrue(machineScope.ProxmoxMachine, infrav1alpha1.VMSnapshotCondition) {
+		return true, nil
+	}
+
+	vm, err := getVMForDeletion(ctx, machineScope)
+	if err != nil {
+		return false, err
+	}
+	if vm == nil {
+		return true, nil
+	}
+
+	if done, err := reconcileDeletionTask(ctx, machineScope); err != nil {
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMSnapshotCondition, infrav1alpha1.SnapshotFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return false, err
+	} else if !done {
+		return false, nil
+	}
+
+	name := renderSnapshotName(machineScope)
+	exists, err := machineScope.InfraCluster.ProxmoxClient.SnapshotExists(ctx, vm, name)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		machineScope.Logger.Info("pre-delete snapshot taken", "snapshot", name)
+		conditions.MarkTrue(machineScope.ProxmoxMachine, infrav1alpha1.VMSnapshotCondition)
+		return true, nil
+	}
+
+	machineScope.Logger.Info("taking pre-delete snapshot", "snapshot", name)
+	task, err := machineScope.InfraCluster.ProxmoxClient.CreateSnapshot(ctx, vm, name,
+		fmt.Sprintf("taken before deletion of machine %s", machineScope.Name()))
+	if err != nil {
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMSnapshotCondition, infrav1alpha1.SnapshotFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return false, errors.Wrapf(err, "failed to take snapshot of VM %s", machineScope.Name())
+	}
+
+	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMSnapshotCondition, infrav1alpha1.SnapshottingReason, clusterv1.ConditionSeverityInfo, "taking snapshot %s", name)
+	machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(task.UPID))
+	return false, nil
+}
+
+// shutdownVM shuts the guest down gracefully, and stops the VM forcefully if the shutdown fails,
+// e.g. because the guest did not power off within the ShutdownTimeout or does not respond to ACPI.
+// It returns true once the VM is not running anymore, or there is no VM to shut down.
+func shutdownVM(ctx context.Context, machineScope *scope.MachineScope) (bool, error) {
+	timeout := machineScope.ProxmoxMachine.Spec.ShutdownTimeout.Duration
+
+	// the task is checked before the VM, so the VM reflects the outcome of the task.
+	var shutdownFailed bool
+	if ref := machineScope.ProxmoxMachine.Status.TaskRef; ref != nil {
+		task, err := machineScope.InfraCluster.ProxmoxClient.GetTask(ctx, *ref)
+		if err != nil {
+			return false, err
+		}
+
+		switch {
+		case task.IsRunning:
+			machineScope.Logger.V(4).Info("task is still pending", "description", task.Type)
+			return false, nil
+		case task.IsFailed && task.Type == taskTypeShutdown:
+			machineScope.ProxmoxMachine.Status.TaskRef = nil
+			machineScope.Warnf("ShutdownFailed", "Guest did not shut down within %s, stopping the VM: %s", timeout, task.ExitStatus)
+			shutdownFailed = true
+		case task.IsFailed:
+			machineScope.ProxmoxMachine.Status.TaskRef = nil
+			return false, errors.Errorf("task %s failed: %s", task.Type, task.ExitStatus)
+		default:
+			machineScope.ProxmoxMachine.Status.TaskRef = nil
+			switch task.Type {
+			case taskTypeShutdown:
+				machineScope.Eventf("ShutdownCompleted", "Guest shut down gracefully")
+			case taskTypeStop:
+				machineScope.Eventf("VMStopped", "Stopped the VM forcefully")
+			}
+		}
+	}
+
+	vm, err := getVMForDeletion(ctx, machineScope)
+	if err != nil {
+		return false, err
+	}
+	if vm == nil || !vm.IsRunning() {
+		return true, nil
+	}
+
+	if shutdownFailed || timeout <= 0 {
+		task, err := machineScope.InfraCluster.ProxmoxClient.StopVM(ctx, vm)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to stop VM %s", machineScope.Name())
+		}
+		machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(task.UPID))
+		return false, nil
+	}
+
+	machineScope.Logger.Info("shutting down virtual machine", "timeout", timeout)
+	task, err := machineScope.InfraCluster.ProxmoxClient.ShutdownVM(ctx, vm, timeout)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to shut down VM %s", machineScope.Name())
+	}
+	machineScope.Eventf("ShutdownStarted", "Shutting down the guest, stopping the VM after %s", timeout)
+	machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(task.UPID))
+	return false, nil
+}
+
+// retainVM stops and tags the VM instead of destroying it, and adds it to the retained VMs of the cluster.
+// It returns true once the VM is retained, or there is no VM to retain.
+func retainVM(ctx context.Context, machineScope *scope.MachineScope) (bool, error) {
+	vm, err := getVMForDeletion(ctx, machineScope)
+	if err != nil {
+		return false, err
+	}
+	if vm == nil {
+		return true, nil
+	}
+
+	if done, err := reconcileDeletionTask(ctx, machineScope); err != nil || !done {
+		return false, err
+	}
+
+	if !vm.HasTag(infrav1alpha1.RetainedVMTag) {
+		task, err := machineScope.InfraCluster.ProxmoxClient.TagVM(ctx, vm, infrav1alpha1.RetainedVMTag)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to tag VM %s as retained", machineScope.Name())
+		}
+		machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(task.UPID))
+		return false, nil
+	}
+
+	if vm.IsRunning() {
+		machineScope.Logger.Info("stopping retained virtual machine")
+		task, err := machineScope.InfraCluster.ProxmoxClient.StopVM(ctx, vm)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to stop VM %s", machineScope.Name())
+		}
+		machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(task.UPID))
+		return false, nil
+	}
+
+	retained := infrav1alpha1.RetainedVM{
+		Name: vm.Name,
+		VMID: int64(vm.VMID),
+		Node: vm.Node,
+	}
+	if ttl := machineScope.ProxmoxMachine.Spec.SnapshotTTL; ttl != nil {
+		retained.ExpiresAt = ptr.To(metav1.NewTime(time.Now().Add(ttl.Duration)))
+	}
+	machineScope.InfraCluster.ProxmoxCluster.AddRetainedVM(retained)
+	return true, nil
+}
+
 // VMNotFound checks if the given err is related to that the VM is not found in Proxmox.
 func VMNotFound(err error) bool {
 	return strings.Contains(err.Error(), "does not exist")
@@ -20,12 +20,21 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/luthermonson/go-proxmox"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/kubernetes/ipam"
 )
 
 func TestDeleteVM_SuccessNotFound(t *testing.T) {
@@ -43,3 +52,193 @@ func TestDeleteVM_SuccessNotFound(t *testing.T) {
 	require.Empty(t, machineScope.ProxmoxMachine.Finalizers)
 	require.Empty(t, machineScope.InfraCluster.ProxmoxCluster.GetNode(machineScope.Name(), false))
 }
+
+func TestDeleteVM_ReleasesIPAddresses(t *testing.T) {
+	machineScope, proxmoxClient, kubeClient := setupReconcilerTest(t)
+	vm := newRunningVM()
+	machineScope.ProxmoxMachine.Spec.VirtualMachineID = ptr.To(int64(vm.VMID))
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		AdditionalDevices: []infrav1alpha1.AdditionalNetworkDevice{
+			{Name: "net1", InterfaceConfig: infrav1alpha1.InterfaceConfig{IPv6PoolRef: &corev1.TypedLocalObjectReference{Kind: "GlobalInClusterIPPool", Name: "ipv6pool"}}},
+		},
+	}
+	machineScope.InfraCluster.ProxmoxCluster.AddNodeLocation(infrav1alpha1.NodeLocation{
+		Machine: corev1.LocalObjectReference{Name: machineScope.Name()},
+		Node:    "node1",
+	}, false)
+
+	claims := []string{
+		ipam.IPAddressClaimName(machineScope.ProxmoxMachine, infrav1alpha1.DefaultNetworkDevice, infrav1alpha1.IPV4Format),
+		ipam.IPAddressClaimName(machineScope.ProxmoxMachine, "net1", infrav1alpha1.IPV6Format),
+	}
+	for _, name := range claims {
+		require.NoError(t, kubeClient.Create(context.Background(), &ipamv1.IPAddressClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: machineScope.Namespace()},
+		}))
+	}
+
+	// the IPAddress of the default device is still being released.
+	address := &ipamv1.IPAddress{
+		ObjectMeta: metav1.ObjectMeta{Name: claims[0], Namespace: machineScope.Namespace()},
+		Spec:       ipamv1.IPAddressSpec{Address: "10.10.10.10", Prefix: 24},
+	}
+	require.NoError(t, kubeClient.Create(context.Background(), address))
+	machineScope.ProxmoxMachine.Finalizers = []string{infrav1alpha1.MachineFinalizer}
+
+	proxmoxClient.EXPECT().DeleteVM(context.TODO(), "node1", int64(123)).Return(nil, errors.New("vm does not exist: some reason")).Times(3)
+
+	require.NoError(t, DeleteVM(context.TODO(), machineScope))
+	for _, name := range claims {
+		err := kubeClient.Get(context.Background(), client.ObjectKey{Name: name, Namespace: machineScope.Namespace()}, &ipamv1.IPAddressClaim{})
+		require.True(t, apierrors.IsNotFound(err), "claim %s was not released", name)
+	}
+	require.Contains(t, machineScope.ProxmoxMachine.Finalizers, infrav1alpha1.MachineFinalizer)
+
+	// the finalizer is kept until the address is gone.
+	require.NoError(t, DeleteVM(context.TODO(), machineScope))
+	require.Contains(t, machineScope.ProxmoxMachine.Finalizers, infrav1alpha1.MachineFinalizer)
+
+	require.NoError(t, kubeClient.Delete(context.Background(), address))
+	require.NoError(t, DeleteVM(context.TODO(), machineScope))
+	require.Empty(t, machineScope.ProxmoxMachine.Finalizers)
+}
+
+func TestDeleteVM_PreDeleteSnapshot(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	vm := newRunningVM()
+	machineScope.ProxmoxMachine.Spec.VirtualMachineID = ptr.To(int64(vm.VMID))
+	machineScope.ProxmoxMachine.Spec.PreDeleteSnapshot = true
+	machineScope.ProxmoxMachine.Spec.SnapshotName = ptr.To("before {{ .Name }}")
+
+	proxmoxClient.EXPECT().GetVM(context.TODO(), "node1", int64(123)).Return(vm, nil).Times(3)
+	proxmoxClient.EXPECT().SnapshotExists(context.TODO(), vm, "before-test").Return(false, nil).Once()
+	proxmoxClient.EXPECT().CreateSnapshot(context.TODO(), vm, "before-test", "taken before deletion of machine test").Return(newTask(), nil).Once()
+
+	// the snapshot is taken before the VM is deleted.
+	require.NoError(t, DeleteVM(context.TODO(), machineScope))
+	require.Equal(t, infrav1alpha1.SnapshottingReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMSnapshotCondition))
+	require.Equal(t, "result", *machineScope.ProxmoxMachine.Status.TaskRef)
+
+	proxmoxClient.EXPECT().GetTask(context.TODO(), "result").Return(&proxmox.Task{IsRunning: true}, nil).Once()
+	require.NoError(t, DeleteVM(context.TODO(), machineScope))
+
+	proxmoxClient.EXPECT().GetTask(context.TODO(), "result").Return(&proxmox.Task{IsSuccessful: true}, nil).Once()
+	proxmoxClient.EXPECT().SnapshotExists(context.TODO(), vm, "before-test").Return(true, nil).Once()
+	proxmoxClient.EXPECT().DeleteVM(context.TODO(), "node1", int64(123)).Return(newTask(), nil).Once()
+	require.NoError(t, DeleteVM(context.TODO(), machineScope))
+	require.True(t, conditions.IsTrue(machineScope.ProxmoxMachine, infrav1alpha1.VMSnapshotCondition))
+}
+
+func TestDeleteVM_PreDeleteSnapshotFailed(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	vm := newRunningVM()
+	machineScope.ProxmoxMachine.Spec.VirtualMachineID = ptr.To(int64(vm.VMID))
+	machineScope.ProxmoxMachine.Spec.PreDeleteSnapshot = true
+	machineScope.ProxmoxMachine.Status.TaskRef = ptr.To("result")
+
+	proxmoxClient.EXPECT().GetVM(context.TODO(), "node1", int64(123)).Return(vm, nil).Once()
+	proxmoxClient.EXPECT().GetTask(context.TODO(), "result").Return(&proxmox.Task{Type: "qmsnapshot", IsFailed: true, ExitStatus: "no space left"}, nil).Once()
+
+	require.ErrorContains(t, DeleteVM(context.TODO(), machineScope), "no space left")
+	require.Equal(t, infrav1alpha1.SnapshotFailedReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMSnapshotCondition))
+	require.Nil(t, machineScope.ProxmoxMachine.Status.TaskRef)
+}
+
+func TestDeleteVM_RetainDisks(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	vm := newRunningVM()
+	machineScope.ProxmoxMachine.Spec.VirtualMachineID = ptr.To(int64(vm.VMID))
+	machineScope.ProxmoxMachine.Spec.RetainDisks = true
+	machineScope.ProxmoxMachine.Spec.SnapshotTTL = &metav1.Duration{Duration: time.Hour}
+	machineScope.ProxmoxMachine.Finalizers = []string{infrav1alpha1.MachineFinalizer}
+
+	proxmoxClient.EXPECT().GetVM(context.TODO(), "node1", int64(123)).Return(vm, nil).Once()
+	proxmoxClient.EXPECT().TagVM(context.TODO(), vm, infrav1alpha1.RetainedVMTag).Return(newTask(), nil).Once()
+	require.NoError(t, DeleteVM(context.TODO(), machineScope))
+
+	tagged := newRunningVM()
+	tagged.VirtualMachineConfig.Tags = "cluster_test;" + infrav1alpha1.RetainedVMTag
+	proxmoxClient.EXPECT().GetVM(context.TODO(), "node1", int64(123)).Return(tagged, nil).Once()
+	proxmoxClient.EXPECT().GetTask(context.TODO(), "result").Return(&proxmox.Task{IsSuccessful: true}, nil).Once()
+	proxmoxClient.EXPECT().StopVM(context.TODO(), tagged).Return(newTask(), nil).Once()
+	require.NoError(t, DeleteVM(context.TODO(), machineScope))
+	require.Contains(t, machineScope.ProxmoxMachine.Finalizers, infrav1alpha1.MachineFinalizer)
+
+	stopped := newStoppedVM()
+	stopped.VMID = 123
+	stopped.VirtualMachineConfig.Tags = tagged.VirtualMachineConfig.Tags
+	proxmoxClient.EXPECT().GetVM(context.TODO(), "node1", int64(123)).Return(stopped, nil).Once()
+	proxmoxClient.EXPECT().GetTask(context.TODO(), "result").Return(&proxmox.Task{IsSuccessful: true}, nil).Once()
+	require.NoError(t, DeleteVM(context.TODO(), machineScope))
+
+	require.Empty(t, machineScope.ProxmoxMachine.Finalizers)
+	retained := machineScope.InfraCluster.ProxmoxCluster.Status.RetainedVMs
+	require.Len(t, retained, 1)
+	require.Equal(t, int64(123), retained[0].VMID)
+	require.Equal(t, "node1", retained[0].Node)
+	require.True(t, retained[0].ExpiresAt.After(time.Now()))
+}
+
+func TestDeleteVM_ShutdownTimeout(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	vm := newRunningVM()
+	machineScope.ProxmoxMachine.Spec.VirtualMachineID = ptr.To(int64(vm.VMID))
+	machineScope.ProxmoxMachine.Spec.ShutdownTimeout = &metav1.Duration{Duration: 2 * time.Minute}
+	recorder := record.NewFakeRecorder(2)
+	machineScope.Recorder = recorder
+
+	proxmoxClient.EXPECT().GetVM(context.TODO(), "node1", int64(123)).Return(vm, nil).Once()
+	proxmoxClient.EXPECT().ShutdownVM(context.TODO(), vm, 2*time.Minute).Return(newTask(), nil).Once()
+	require.NoError(t, DeleteVM(context.TODO(), machineScope))
+	require.Equal(t, "result", *machineScope.ProxmoxMachine.Status.TaskRef)
+	require.Equal(t, "Normal ShutdownStarted Shutting down the guest, stopping the VM after 2m0s", <-recorder.Events)
+
+	proxmoxClient.EXPECT().GetTask(context.TODO(), "result").Return(&proxmox.Task{Type: "qmshutdown", IsRunning: true}, nil).Once()
+	require.NoError(t, DeleteVM(context.TODO(), machineScope))
+
+	stopped := newStoppedVM()
+	stopped.VMID = 123
+	proxmoxClient.EXPECT().GetTask(context.TODO(), "result").Return(&proxmox.Task{Type: "qmshutdown", IsSuccessful: true}, nil).Once()
+	proxmoxClient.EXPECT().GetVM(context.TODO(), "node1", int64(123)).Return(stopped, nil).Once()
+	proxmoxClient.EXPECT().DeleteVM(context.TODO(), "node1", int64(123)).Return(newTask(), nil).Once()
+	require.NoError(t, DeleteVM(context.TODO(), machineScope))
+	require.Nil(t, machineScope.ProxmoxMachine.Status.TaskRef)
+	require.Equal(t, "Normal ShutdownCompleted Guest shut down gracefully", <-recorder.Events)
+}
+
+func TestDeleteVM_ShutdownTimeoutExceeded(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	vm := newRunningVM()
+	machineScope.ProxmoxMachine.Spec.VirtualMachineID = ptr.To(int64(vm.VMID))
+	machineScope.ProxmoxMachine.Spec.ShutdownTimeout = &metav1.Duration{Duration: time.Minute}
+	machineScope.ProxmoxMachine.Status.TaskRef = ptr.To("result")
+	recorder := record.NewFakeRecorder(2)
+	machineScope.Recorder = recorder
+
+	// the guest does not respond to ACPI, so the shutdown times out.
+	proxmoxClient.EXPECT().GetTask(context.TODO(), "result").Return(&proxmox.Task{Type: "qmshutdown", IsFailed: true, ExitStatus: "VM quit/powerdown failed"}, nil).Once()
+	proxmoxClient.EXPECT().GetVM(context.TODO(), "node1", int64(123)).Return(vm, nil).Once()
+	proxmoxClient.EXPECT().StopVM(context.TODO(), vm).Return(newTask(), nil).Once()
+	require.NoError(t, DeleteVM(context.TODO(), machineScope))
+	require.Equal(t, "result", *machineScope.ProxmoxMachine.Status.TaskRef)
+	require.Equal(t, "Warning ShutdownFailed Guest did not shut down within 1m0s, stopping the VM: VM quit/powerdown failed", <-recorder.Events)
+
+	stopped := newStoppedVM()
+	stopped.VMID = 123
+	proxmoxClient.EXPECT().GetTask(context.TODO(), "result").Return(&proxmox.Task{Type: "qmstop", IsSuccessful: true}, nil).Once()
+	proxmoxClient.EXPECT().GetVM(context.TODO(), "node1", int64(123)).Return(stopped, nil).Once()
+	proxmoxClient.EXPECT().DeleteVM(context.TODO(), "node1", int64(123)).Return(newTask(), nil).Once()
+	require.NoError(t, DeleteVM(context.TODO(), machineScope))
+	require.Equal(t, "Normal VMStopped Stopped the VM forcefully", <-recorder.Events)
+}
+
+func TestRenderSnapshotName(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	require.Equal(t, infrav1alpha1.DefaultSnapshotName, renderSnapshotName(machineScope))
+
+	machineScope.ProxmoxMachine.Spec.SnapshotName = ptr.To("{{ .ClusterName }}.{{ .Name }}")
+	require.Equal(t, "test-test", renderSnapshotName(machineScope))
+
+	machineScope.ProxmoxMachine.Spec.SnapshotName = ptr.To("1-{{ .Name }}-with-a-name-longer-than-forty-characters")
+	require.Equal(t, "s1-test-with-a-name-longer-than-forty-ch", renderSnapshotName(machineScope))
+}
@@ -53,7 +53,7 @@ func FindVM(ctx context.Context, scope *scope.MachineScope) (*proxmox.VirtualMac
 			scope.Error(err, "unable to find vm")
 			return nil, ErrVMNotFound
 		}
-		if vm.Name != scope.ProxmoxMachine.GetName() {
+		if vm.Name != vmName(scope) {
 			scope.Error(err, "vm is not initialized yet")
 			return nil, ErrVMNotInitialized
 		}
@@ -103,8 +103,8 @@ func updateVMLocation(ctx context.Context, s *scope.MachineScope) error {
 	// If there is a machine with an ID that doesn't match name of the
 	// Proxmox machine, we need to stop right there.
 	machineName := s.ProxmoxMachine.GetName()
-	if vm.VirtualMachineConfig.Name != machineName {
-		err := fmt.Errorf("expected VM name to match %q but it was %q", vm.Name, machineName)
+	if name := vmName(s); vm.VirtualMachineConfig.Name != name {
+		err := fmt.Errorf("expected VM name to match %q but it was %q", vm.Name, name)
 		s.SetFailureMessage(err)
 		s.SetFailureReason(capierrors.MachineStatusError("UnkownMachine"))
 		return err
@@ -40,6 +40,23 @@ func TestFindVM_FindByNodeAndID(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestFindVM_FindByVMName(t *testing.T) {
+	ctx := context.TODO()
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	vm := newRunningVM()
+	vm.Name = "test-node-3f2a9c1e"
+	machineScope.ProxmoxMachine.Spec.VirtualMachineID = ptr.To(int64(vm.VMID))
+
+	proxmoxClient.EXPECT().GetVM(ctx, "node1", int64(123)).Return(vm, nil).Twice()
+
+	_, err := FindVM(ctx, machineScope)
+	require.ErrorIs(t, err, ErrVMNotInitialized)
+
+	machineScope.ProxmoxMachine.Status.VMName = "test-node-3f2a9c1e"
+	_, err = FindVM(ctx, machineScope)
+	require.NoError(t, err)
+}
+
 func TestFindVM_FindByNodeLocationsAndID(t *testing.T) {
 	ctx := context.TODO()
 	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
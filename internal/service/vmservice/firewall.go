@@ -0,0 +1,111 @@
+/*
+Copyright 2023-2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+
+	"github.com/luthermonson/go-proxmox"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+// firewallPolicyIn is the inbound policy of VMs with firewall rules,
+// which drops all traffic not accepted by a rule.
+const firewallPolicyIn = "DROP"
+
+// reconcileFirewall enables the VM firewall and replaces its rules, if they differ from the FirewallRules of the machine.
+// VMs without FirewallRules are left untouched.
+func reconcileFirewall(ctx context.Context, machineScope *scope.MachineScope) error {
+	desired := firewallRules(machineScope.ProxmoxMachine.Spec.FirewallRules)
+	if len(desired) == 0 {
+		return nil
+	}
+
+	client := machineScope.InfraCluster.ProxmoxClient
+	vm := machineScope.VirtualMachine
+
+	if err := client.EnableFirewall(ctx, vm, firewallPolicyIn); err != nil {
+		return err
+	}
+
+	current, err := client.GetFirewallRules(ctx, vm)
+	if err != nil {
+		return err
+	}
+
+	if firewallRulesEqual(current, desired) {
+		return nil
+	}
+
+	machineScope.V(4).Info("reconciling virtual machine firewall rules")
+
+	// delete from the bottom, so the positions of the remaining rules don't change.
+	for i := len(current) - 1; i >= 0; i-- {
+		if err := client.DeleteFirewallRule(ctx, vm, current[i].Pos); err != nil {
+			return err
+		}
+	}
+
+	// Proxmox inserts new rules at the top.
+	for i := len(desired) - 1; i >= 0; i-- {
+		if err := client.AddFirewallRule(ctx, vm, desired[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// firewallRules converts the FirewallRules of a machine into enabled Proxmox firewall rules.
+func firewallRules(specs []infrav1alpha1.FirewallRuleSpec) []*proxmox.FirewallRule {
+	rules := make([]*proxmox.FirewallRule, 0, len(specs))
+	for _, spec := range specs {
+		action := spec.Action
+		if action == "" {
+			action = "ACCEPT"
+		}
+
+		rules = append(rules, &proxmox.FirewallRule{
+			Type:   spec.Direction,
+			Action: action,
+			Proto:  spec.Protocol,
+			Dport:  spec.Port,
+			Source: spec.Source,
+			Dest:   spec.Destination,
+			Enable: 1,
+		})
+	}
+	return rules
+}
+
+// firewallRulesEqual compares the fields of the rules managed through FirewallRules, including their order.
+func firewallRulesEqual(current, desired []*proxmox.FirewallRule) bool {
+	if len(current) != len(desired) {
+		return false
+	}
+
+	for i := range current {
+		c, d := current[i], desired[i]
+		if c.Type != d.Type || c.Action != d.Action || c.Proto != d.Proto || c.Dport != d.Dport ||
+			c.Source != d.Source || c.Dest != d.Dest || c.Enable != d.Enable || c.Macro != "" || c.Iface != "" {
+			return false
+		}
+	}
+	return true
+}
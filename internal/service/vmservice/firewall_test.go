@@ -0,0 +1,76 @@
+/*
+Copyright 2023-2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luthermonson/go-proxmox"
+	"github.com/stretchr/testify/require"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+)
+
+func TestReconcileFirewall_NoRules(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.SetVirtualMachine(newRunningVM())
+
+	require.NoError(t, reconcileFirewall(context.Background(), machineScope))
+}
+
+func TestReconcileFirewall_UpToDate(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.FirewallRules = []infrav1alpha1.FirewallRuleSpec{
+		{Direction: "in", Protocol: "tcp", Port: "6443"},
+	}
+	vm := newRunningVM()
+	machineScope.SetVirtualMachine(vm)
+
+	proxmoxClient.EXPECT().EnableFirewall(context.Background(), vm, "DROP").Return(nil).Once()
+	proxmoxClient.EXPECT().GetFirewallRules(context.Background(), vm).Return([]*proxmox.FirewallRule{
+		{Pos: 0, Type: "in", Action: "ACCEPT", Proto: "tcp", Dport: "6443", Enable: 1},
+	}, nil).Once()
+
+	require.NoError(t, reconcileFirewall(context.Background(), machineScope))
+}
+
+func TestReconcileFirewall_ReplacesDriftedRules(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.FirewallRules = []infrav1alpha1.FirewallRuleSpec{
+		{Direction: "in", Protocol: "tcp", Port: "22", Source: "10.0.0.0/8"},
+		{Direction: "in", Action: "DROP", Protocol: "icmp"},
+	}
+	vm := newRunningVM()
+	machineScope.SetVirtualMachine(vm)
+
+	proxmoxClient.EXPECT().EnableFirewall(context.Background(), vm, "DROP").Return(nil).Once()
+	proxmoxClient.EXPECT().GetFirewallRules(context.Background(), vm).Return([]*proxmox.FirewallRule{
+		{Pos: 0, Type: "in", Action: "ACCEPT", Proto: "tcp", Dport: "22", Enable: 1},
+		{Pos: 1, Type: "out", Action: "ACCEPT", Enable: 1},
+	}, nil).Once()
+
+	// existing rules are deleted bottom up, new rules are added in reverse order.
+	deleteFirst := proxmoxClient.EXPECT().DeleteFirewallRule(context.Background(), vm, 1).Return(nil).Once()
+	deleteSecond := proxmoxClient.EXPECT().DeleteFirewallRule(context.Background(), vm, 0).Return(nil).Once().NotBefore(deleteFirst)
+	addFirst := proxmoxClient.EXPECT().AddFirewallRule(context.Background(), vm,
+		&proxmox.FirewallRule{Type: "in", Action: "DROP", Proto: "icmp", Enable: 1}).Return(nil).Once().NotBefore(deleteSecond)
+	proxmoxClient.EXPECT().AddFirewallRule(context.Background(), vm,
+		&proxmox.FirewallRule{Type: "in", Action: "ACCEPT", Proto: "tcp", Dport: "22", Source: "10.0.0.0/8", Enable: 1}).Return(nil).Once().NotBefore(addFirst)
+
+	require.NoError(t, reconcileFirewall(context.Background(), machineScope))
+}
@@ -158,6 +158,31 @@ func setupReconcilerTest(t *testing.T) (*scope.MachineScope, *proxmoxtest.MockCl
 	return machineScope, mockClient, kubeClient
 }
 
+// expectVMResources expects the VMs of the cluster to be listed, e.g. to look up the template of a new VM.
+func expectVMResources(proxmoxClient *proxmoxtest.MockClient, resources ...*proxmox.ClusterResource) {
+	proxmoxClient.EXPECT().ListVMResources(context.Background()).Return(resources, nil).Once()
+}
+
+func newTemplateResource(vmID uint64, node string) *proxmox.ClusterResource {
+	return &proxmox.ClusterResource{
+		Type:     "qemu",
+		VMID:     vmID,
+		Name:     "template",
+		Node:     node,
+		Status:   "stopped",
+		Template: 1,
+	}
+}
+
+// newStorages returns active storages with 1TiB left, which hold VM images.
+func newStorages(names ...string) []*proxmox.Storage {
+	storages := make([]*proxmox.Storage, 0, len(names))
+	for _, name := range names {
+		storages = append(storages, &proxmox.Storage{Name: name, Node: "node1", Content: "images,rootdir", Enabled: 1, Active: 1, Avail: 1 << 40})
+	}
+	return storages
+}
+
 func getIPSuffix(addr string) string {
 	suffix := infrav1alpha1.DefaultSuffix
 	ip := netip.MustParseAddr(addr)
@@ -258,7 +283,9 @@ func newVMResource() *proxmox.ClusterResource {
 func newRunningVM() *proxmox.VirtualMachine {
 	return &proxmox.VirtualMachine{
 		VirtualMachineConfig: &proxmox.VirtualMachineConfig{
-			Name: "test",
+			Name:   "test",
+			Tags:   "cluster_test",
+			OnBoot: 1,
 		},
 		Name:      "test",
 		Node:      "node1",
@@ -20,9 +20,12 @@ import (
 	"context"
 	"fmt"
 	"net/netip"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
+	"go4.org/netipx"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/utils/ptr"
@@ -38,31 +41,63 @@ import (
 func reconcileIPAddresses(ctx context.Context, machineScope *scope.MachineScope) (requeue bool, err error) {
 	if machineScope.ProxmoxMachine.Status.IPAddresses != nil {
 		// skip machine has IpAddress already.
+		conditions.MarkTrue(machineScope.ProxmoxMachine, infrav1alpha1.IPAddressAssignedCondition)
 		return false, nil
 	}
 	machineScope.Logger.V(4).Info("reconciling IPAddresses.")
 	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.WaitingForStaticIPAllocationReason, clusterv1.ConditionSeverityInfo, "")
+	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.IPAddressAssignedCondition, infrav1alpha1.WaitingForStaticIPAllocationReason, clusterv1.ConditionSeverityInfo, "")
 
 	addresses := make(map[string]infrav1alpha1.IPAddress)
 
+	// the missing claims of all devices are created in the same reconcile,
+	// instead of one claim per reconcile.
 	// default device.
-	if requeue, err = handleDefaultDevice(ctx, machineScope, addresses); err != nil || requeue {
+	pending, err := handleDefaultDevice(ctx, machineScope, addresses)
+	if err != nil {
 		return true, errors.Wrap(err, "unable to handle default device")
 	}
 
 	if machineScope.ProxmoxMachine.Spec.Network != nil {
-		if requeue, err = handleAdditionalDevices(ctx, machineScope, addresses); err != nil || requeue {
+		if requeue, err = handleAdditionalDevices(ctx, machineScope, addresses); err != nil {
 			return true, errors.Wrap(err, "unable to handle additional devices")
 		}
+		pending = pending || requeue
+	}
+
+	if pending {
+		return true, nil
 	}
 
 	// update the status.IpAddr.
 	machineScope.Logger.V(4).Info("updating ProxmoxMachine.status.ipAddresses.")
 	machineScope.ProxmoxMachine.Status.IPAddresses = addresses
+	conditions.MarkTrue(machineScope.ProxmoxMachine, infrav1alpha1.IPAddressAssignedCondition)
+	if formatted := formatIPAddresses(addresses); formatted != "" {
+		machineScope.Eventf("IPAddressesAssigned", "Assigned IP addresses %s", formatted)
+	}
 
 	return true, nil
 }
 
+// formatIPAddresses lists the static addresses of all devices, e.g. "net0=10.0.0.10,2001:db8::10".
+func formatIPAddresses(addresses map[string]infrav1alpha1.IPAddress) string {
+	devices := make([]string, 0, len(addresses))
+	for device := range addresses {
+		devices = append(devices, device)
+	}
+	slices.Sort(devices)
+
+	formatted := make([]string, 0, len(devices))
+	for _, device := range devices {
+		ips := slices.DeleteFunc([]string{addresses[device].IPV4, addresses[device].IPV6}, func(ip string) bool { return ip == "" })
+		if len(ips) > 0 {
+			formatted = append(formatted, device+"="+strings.Join(ips, ","))
+		}
+	}
+	return strings.Join(formatted, " ")
+}
+
 func findIPAddress(ctx context.Context, machineScope *scope.MachineScope, device string) (*ipamv1.IPAddress, error) {
 	key := client.ObjectKey{
 		Namespace: machineScope.Namespace(),
@@ -93,7 +128,14 @@ func formatIPAddressName(name, device string) string {
 }
 
 func machineHasIPAddress(machine *infrav1alpha1.ProxmoxMachine) bool {
-	return machine.Status.IPAddresses[infrav1alpha1.DefaultNetworkDevice] != (infrav1alpha1.IPAddress{})
+	if machine.Status.IPAddresses[infrav1alpha1.DefaultNetworkDevice] != (infrav1alpha1.IPAddress{}) {
+		return true
+	}
+	// the guest acquires the addresses of a dynamic default device by itself,
+	// so there is nothing to wait for once the ip addresses are reconciled.
+	network := machine.Spec.Network
+	return machine.Status.IPAddresses != nil && network != nil && network.Default != nil &&
+		(network.Default.DHCP4 || dynamicIPv6(network.Default.IPv6Mode))
 }
 
 func handleIPAddressForDevice(ctx context.Context, machineScope *scope.MachineScope, device, format string, ipamRef *corev1.TypedLocalObjectReference) (string, error) {
@@ -120,6 +162,12 @@ func handleIPAddressForDevice(ctx context.Context, machineScope *scope.MachineSc
 
 	machineScope.Logger.V(4).Info("IPAddress found, ", "ip", ip, "device", device)
 
+	return tagIPAddress(ctx, machineScope, device, ip)
+}
+
+// tagIPAddress adds the ip tag of the default network device to the VM.
+// It returns an empty ip while the tag is being added.
+func tagIPAddress(ctx context.Context, machineScope *scope.MachineScope, device, ip string) (string, error) {
 	// format ipTag as `ip_net0_<ipv4/6-address>`
 	// to add it to the VM.
 	ipTag := fmt.Sprintf("ip_%s_%s", device, ip)
@@ -138,59 +186,204 @@ func handleIPAddressForDevice(ctx context.Context, machineScope *scope.MachineSc
 	return ip, nil
 }
 
+// handleDefaultDevice collects the addresses of the default network device.
+// It reports whether any of them is still pending.
 func handleDefaultDevice(ctx context.Context, machineScope *scope.MachineScope, addresses map[string]infrav1alpha1.IPAddress) (bool, error) {
+	pending := false
+
 	// default network device ipv4.
-	if machineScope.InfraCluster.ProxmoxCluster.Spec.IPv4Config != nil {
-		ip, err := handleIPAddressForDevice(ctx, machineScope, infrav1alpha1.DefaultNetworkDevice, infrav1alpha1.IPV4Format, nil)
-		if err != nil || ip == "" {
+	if hasDefaultIPv4(machineScope) {
+		ip, err := handleDefaultIPAddress(ctx, machineScope, infrav1alpha1.IPV4Format)
+		if err != nil {
 			return true, err
 		}
+		pending = pending || ip == ""
 		addresses[infrav1alpha1.DefaultNetworkDevice] = infrav1alpha1.IPAddress{
 			IPV4: ip,
 		}
 	}
 
 	// default network device ipv6.
-	if machineScope.InfraCluster.ProxmoxCluster.Spec.IPv6Config != nil {
-		ip, err := handleIPAddressForDevice(ctx, machineScope, infrav1alpha1.DefaultNetworkDevice, infrav1alpha1.IPV6Format, nil)
-		if err != nil || ip == "" {
+	if hasDefaultIPv6(machineScope) {
+		ip, err := handleDefaultIPAddress(ctx, machineScope, infrav1alpha1.IPV6Format)
+		if err != nil {
 			return true, err
 		}
+		pending = pending || ip == ""
 
 		addr := addresses[infrav1alpha1.DefaultNetworkDevice]
 		addr.IPV6 = ip
 		addresses[infrav1alpha1.DefaultNetworkDevice] = addr
 	}
+	return pending, nil
+}
+
+// handleDefaultIPAddress returns the address of the given format of the default network device,
+// which is either the static address of the machine or claimed from the pool of the cluster.
+func handleDefaultIPAddress(ctx context.Context, machineScope *scope.MachineScope, format string) (string, error) {
+	static := staticIPAddress(machineScope, format)
+	if static == nil {
+		return handleIPAddressForDevice(ctx, machineScope, infrav1alpha1.DefaultNetworkDevice, format, nil)
+	}
+
+	ipConfig := machineScope.InfraCluster.ProxmoxCluster.Spec.IPv4Config
+	if format == infrav1alpha1.IPV6Format {
+		ipConfig = machineScope.InfraCluster.ProxmoxCluster.Spec.IPv6Config
+	}
+	if ipConfig != nil {
+		inPool, err := addressInPool(static.Address, ipConfig.Addresses)
+		if err != nil {
+			return "", errors.Wrapf(err, "unable to parse the addresses of the %s pool", format)
+		}
+		if !inPool {
+			return "", errors.Errorf("static address %s is not within the addresses of the %s pool", static.Address, format)
+		}
+	}
+
+	machineScope.Logger.V(4).Info("using static IPAddress", "ip", static.Address, "device", infrav1alpha1.DefaultNetworkDevice)
+
+	return tagIPAddress(ctx, machineScope, infrav1alpha1.DefaultNetworkDevice, static.Address)
+}
+
+// staticIPAddress returns the static address of the given format of the default network device, if any.
+func staticIPAddress(machineScope *scope.MachineScope, format string) *infrav1alpha1.IPAddressSpec {
+	if format == infrav1alpha1.IPV6Format {
+		return machineScope.ProxmoxMachine.Spec.IPv6
+	}
+	return machineScope.ProxmoxMachine.Spec.IPv4
+}
+
+// hasDefaultIPv4 returns whether the default network device has a static IPv4 address.
+// Addresses acquired by the guest through DHCP are unknown to the controller.
+func hasDefaultIPv4(machineScope *scope.MachineScope) bool {
+	if defaultDHCP4(machineScope) {
+		return false
+	}
+	return machineScope.ProxmoxMachine.Spec.IPv4 != nil || machineScope.InfraCluster.ProxmoxCluster.HasIPv4()
+}
+
+// hasDefaultIPv6 returns whether the default network device has a static IPv6 address.
+// Addresses acquired by the guest through DHCPv6 or SLAAC are unknown to the controller.
+func hasDefaultIPv6(machineScope *scope.MachineScope) bool {
+	if dynamicIPv6(defaultIPv6Mode(machineScope)) {
+		return false
+	}
+	return machineScope.ProxmoxMachine.Spec.IPv6 != nil || machineScope.InfraCluster.ProxmoxCluster.HasIPv6()
+}
+
+// defaultDHCP4 returns whether the default network device acquires the IPv4 address through DHCP.
+func defaultDHCP4(machineScope *scope.MachineScope) bool {
+	network := machineScope.ProxmoxMachine.Spec.Network
+	return network != nil && network.Default != nil && network.Default.DHCP4
+}
+
+// defaultIPv6Mode returns the IPv6Mode of the default network device.
+func defaultIPv6Mode(machineScope *scope.MachineScope) infrav1alpha1.IPv6Mode {
+	if network := machineScope.ProxmoxMachine.Spec.Network; network != nil && network.Default != nil {
+		return network.Default.IPv6Mode
+	}
+	return ""
+}
+
+// dynamicIPv6 returns whether the guest acquires the IPv6 address by itself, instead of claiming it from a pool.
+func dynamicIPv6(mode infrav1alpha1.IPv6Mode) bool {
+	return mode == infrav1alpha1.IPv6ModeDHCP || mode == infrav1alpha1.IPv6ModeSLAAC
+}
+
+// addressInPool checks whether the address is part of the addresses, ranges or CIDRs of an IPConfigSpec.
+func addressInPool(address string, pool []string) (bool, error) {
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		return false, err
+	}
+
+	for _, entry := range pool {
+		switch {
+		case strings.Contains(entry, "-"):
+			ipRange, err := netipx.ParseIPRange(entry)
+			if err != nil {
+				return false, err
+			}
+			if ipRange.Contains(addr) {
+				return true, nil
+			}
+		case strings.Contains(entry, "/"):
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return false, err
+			}
+			if prefix.Contains(addr) {
+				return true, nil
+			}
+		default:
+			ip, err := netip.ParseAddr(entry)
+			if err != nil {
+				return false, err
+			}
+			if ip == addr {
+				return true, nil
+			}
+		}
+	}
 	return false, nil
 }
 
+// handleAdditionalDevices collects the addresses of the additional network devices.
+// It reports whether any of them is still pending.
 func handleAdditionalDevices(ctx context.Context, machineScope *scope.MachineScope, addresses map[string]infrav1alpha1.IPAddress) (bool, error) {
+	pending := false
+
 	// additional network devices.
 	for _, net := range machineScope.ProxmoxMachine.Spec.Network.AdditionalDevices {
-		if net.IPv4PoolRef != nil {
+		if net.IPv4PoolRef != nil && !net.DHCP4 {
 			ip, err := handleIPAddressForDevice(ctx, machineScope, net.Name, infrav1alpha1.IPV4Format, net.IPv4PoolRef)
-			if err != nil || ip == "" {
+			if err != nil {
 				return true, errors.Wrapf(err, "unable to handle IPAddress for device %s", net.Name)
 			}
+			pending = pending || ip == ""
 
 			addresses[net.Name] = infrav1alpha1.IPAddress{
 				IPV4: ip,
 			}
 		}
 
-		if net.IPv6PoolRef != nil {
+		if net.IPv6PoolRef != nil && !dynamicIPv6(net.IPv6Mode) {
 			ip, err := handleIPAddressForDevice(ctx, machineScope, net.Name, infrav1alpha1.IPV6Format, net.IPv6PoolRef)
-			if err != nil || ip == "" {
+			if err != nil {
 				return true, errors.Wrapf(err, "unable to handle IPAddress for device %s", net.Name)
 			}
+			pending = pending || ip == ""
 
-			addresses[net.Name] = infrav1alpha1.IPAddress{
-				IPV6: ip,
-			}
+			addr := addresses[net.Name]
+			addr.IPV6 = ip
+			addresses[net.Name] = addr
 		}
 	}
 
-	return false, nil
+	return pending, nil
+}
+
+// releaseIPAddresses deletes the IPAddressClaims of all network devices of the machine.
+// It reports whether all addresses are released.
+func releaseIPAddresses(ctx context.Context, machineScope *scope.MachineScope) (bool, error) {
+	devices := []string{infrav1alpha1.DefaultNetworkDevice}
+	if network := machineScope.ProxmoxMachine.Spec.Network; network != nil {
+		for _, net := range network.AdditionalDevices {
+			devices = append(devices, net.Name)
+		}
+	}
+
+	released := true
+	for _, device := range devices {
+		for _, format := range []string{infrav1alpha1.IPV4Format, infrav1alpha1.IPV6Format} {
+			ok, err := machineScope.IPAMHelper.ReleaseIPAddressClaim(ctx, machineScope.ProxmoxMachine, device, format)
+			if err != nil {
+				return false, err
+			}
+			released = released && ok
+		}
+	}
+	return released, nil
 }
 
 func isIPV4(ip string) bool {
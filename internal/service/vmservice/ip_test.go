@@ -22,6 +22,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
 
 	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
 )
@@ -56,6 +57,33 @@ func TestReconcileIPAddresses_CreateAdditionalClaim(t *testing.T) {
 	requireConditionIsFalse(t, machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition)
 }
 
+func TestReconcileIPAddresses_CreateAllClaims(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		AdditionalDevices: []infrav1alpha1.AdditionalNetworkDevice{
+			{Name: "net1", InterfaceConfig: infrav1alpha1.InterfaceConfig{IPv4PoolRef: &corev1.TypedLocalObjectReference{Kind: "GlobalInClusterIPPool", Name: "custom"}}},
+		},
+	}
+	machineScope.SetVirtualMachine(newStoppedVM())
+	createIPPools(t, kubeClient, machineScope)
+
+	// requeues before the addresses are allocated don't create further claims.
+	for range 3 {
+		requeue, err := reconcileIPAddresses(context.Background(), machineScope)
+		require.NoError(t, err)
+		require.True(t, requeue)
+		require.Nil(t, machineScope.ProxmoxMachine.Status.IPAddresses)
+	}
+
+	var claims ipamv1.IPAddressClaimList
+	require.NoError(t, kubeClient.List(context.Background(), &claims))
+	names := make([]string, 0, len(claims.Items))
+	for _, claim := range claims.Items {
+		names = append(names, claim.GetName())
+	}
+	require.ElementsMatch(t, []string{"test-net0-inet", "test-net1-inet"}, names)
+}
+
 func TestReconcileIPAddresses_AddIPTag(t *testing.T) {
 	machineScope, proxmoxClient, kubeClient := setupReconcilerTest(t)
 	vm := newStoppedVM()
@@ -124,6 +152,36 @@ func TestReconcileIPAddresses_MultipleDevices(t *testing.T) {
 	requireConditionIsFalse(t, machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition)
 }
 
+func TestReconcileIPAddresses_DualStackAdditionalDevice(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		AdditionalDevices: []infrav1alpha1.AdditionalNetworkDevice{
+			{Name: "net1", InterfaceConfig: infrav1alpha1.InterfaceConfig{
+				IPv4PoolRef: &corev1.TypedLocalObjectReference{Kind: "GlobalInClusterIPPool", Name: "ipv4pool"},
+				IPv6PoolRef: &corev1.TypedLocalObjectReference{Kind: "GlobalInClusterIPPool", Name: "ipv6pool"},
+			}},
+		},
+	}
+
+	vm := newStoppedVM()
+	vm.VirtualMachineConfig.Tags = ipTag
+	machineScope.SetVirtualMachine(vm)
+
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+	createIP4AddressResource(t, kubeClient, machineScope, "net1", "10.100.10.10")
+	createIP6AddressResource(t, kubeClient, machineScope, "net1", "fe80::ffee")
+
+	requeue, err := reconcileIPAddresses(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+
+	expected := map[string]infrav1alpha1.IPAddress{
+		"net0": {IPV4: "10.10.10.10"},
+		"net1": {IPV4: "10.100.10.10", IPV6: "fe80::ffee"},
+	}
+	require.Equal(t, expected, machineScope.ProxmoxMachine.Status.IPAddresses)
+}
+
 func TestReconcileIPAddresses_IPV6(t *testing.T) {
 	machineScope, _, kubeClient := setupReconcilerTest(t)
 	machineScope.InfraCluster.ProxmoxCluster.Spec.IPv6Config = &infrav1alpha1.IPConfigSpec{
@@ -149,3 +207,86 @@ func TestReconcileIPAddresses_IPV6(t *testing.T) {
 	require.True(t, requeue)
 	requireConditionIsFalse(t, machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition)
 }
+
+func TestReconcileIPAddresses_IPv6SLAAC(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.InfraCluster.ProxmoxCluster.Spec.IPv6Config = &infrav1alpha1.IPConfigSpec{
+		Addresses: []string{"fe80::/64"},
+		Prefix:    64,
+		Gateway:   "fe80::1",
+	}
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		Default: &infrav1alpha1.NetworkDevice{Bridge: "vmbr0", IPv6Mode: infrav1alpha1.IPv6ModeSLAAC},
+	}
+	vm := newStoppedVM()
+	vm.VirtualMachineConfig.Tags = ipTag
+	machineScope.SetVirtualMachine(vm)
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+	createIPPools(t, kubeClient, machineScope)
+
+	_, err := reconcileIPAddresses(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.Equal(t, map[string]infrav1alpha1.IPAddress{
+		infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"},
+	}, machineScope.ProxmoxMachine.Status.IPAddresses)
+
+	// the ipv6 address is not claimed from the pool.
+	var claims ipamv1.IPAddressClaimList
+	require.NoError(t, kubeClient.List(context.Background(), &claims))
+	for _, claim := range claims.Items {
+		require.NotContains(t, claim.GetName(), "inet6")
+	}
+}
+
+func TestReconcileIPAddresses_DHCP4(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		Default: &infrav1alpha1.NetworkDevice{Bridge: "vmbr0", DHCP4: true},
+		AdditionalDevices: []infrav1alpha1.AdditionalNetworkDevice{
+			{Name: "net1", NetworkDevice: infrav1alpha1.NetworkDevice{Bridge: "vmbr1", DHCP4: true}},
+		},
+	}
+	machineScope.SetVirtualMachine(newStoppedVM())
+
+	requeue, err := reconcileIPAddresses(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Empty(t, machineScope.ProxmoxMachine.Status.IPAddresses)
+	require.True(t, machineHasIPAddress(machineScope.ProxmoxMachine))
+
+	// no address is claimed.
+	var claims ipamv1.IPAddressClaimList
+	require.NoError(t, kubeClient.List(context.Background(), &claims))
+	require.Empty(t, claims.Items)
+}
+
+func TestReconcileIPAddresses_StaticIPAddress(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.IPv4 = &infrav1alpha1.IPAddressSpec{Address: "10.0.0.15", Prefix: 24, Gateway: "10.0.0.1"}
+	machineScope.ProxmoxMachine.Spec.IPv6 = &infrav1alpha1.IPAddressSpec{Address: "2001:db8::15", Prefix: 64}
+	vm := newStoppedVM()
+	vm.VirtualMachineConfig.Tags = "ip_net0_10.0.0.15"
+	machineScope.SetVirtualMachine(vm)
+
+	requeue, err := reconcileIPAddresses(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Equal(t, map[string]infrav1alpha1.IPAddress{
+		infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.0.0.15", IPV6: "2001:db8::15"},
+	}, machineScope.ProxmoxMachine.Status.IPAddresses)
+
+	// no address is claimed.
+	var claims ipamv1.IPAddressClaimList
+	require.NoError(t, kubeClient.List(context.Background(), &claims))
+	require.Empty(t, claims.Items)
+}
+
+func TestReconcileIPAddresses_StaticIPAddressOutsidePool(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.IPv4 = &infrav1alpha1.IPAddressSpec{Address: "10.0.0.50", Prefix: 24}
+	machineScope.SetVirtualMachine(newStoppedVM())
+
+	_, err := reconcileIPAddresses(context.Background(), machineScope)
+	require.ErrorContains(t, err, "static address 10.0.0.50 is not within the addresses of the ipv4 pool")
+	require.Nil(t, machineScope.ProxmoxMachine.Status.IPAddresses)
+}
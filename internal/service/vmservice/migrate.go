@@ -0,0 +1,92 @@
+/*
+Copyright 2023-2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/service/scheduler"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+// updateMigratedVMLocation looks up the node of the VM once its migration task is done,
+// so the VM is found on the node it was moved to.
+func updateMigratedVMLocation(ctx context.Context, machineScope *scope.MachineScope) error {
+	if machineScope.ProxmoxMachine.Status.TaskRef != nil ||
+		conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMMigratedCondition) != infrav1alpha1.MigratingReason {
+		return nil
+	}
+	return updateVMLocation(ctx, machineScope)
+}
+
+// reconcileMigration migrates the VM to the node requested with the MigrateToNodeAnnotation.
+// The target node is checked for capacity by the scheduler first. Without a target node,
+// the scheduler picks one and the annotation is updated with it.
+func reconcileMigration(ctx context.Context, machineScope *scope.MachineScope) (requeue bool, err error) {
+	annotations := machineScope.ProxmoxMachine.GetAnnotations()
+	target, ok := annotations[infrav1alpha1.MigrateToNodeAnnotation]
+	if !ok {
+		return false, nil
+	}
+
+	vm := machineScope.VirtualMachine
+	if vm.Node == target {
+		machineScope.Logger.Info("virtual machine migrated", "node", target)
+		delete(annotations, infrav1alpha1.MigrateToNodeAnnotation)
+		machineScope.ProxmoxMachine.SetAnnotations(annotations)
+		machineScope.ProxmoxMachine.Status.ProxmoxNode = ptr.To(vm.Node)
+		conditions.MarkTrue(machineScope.ProxmoxMachine, infrav1alpha1.VMMigratedCondition)
+		return false, nil
+	}
+
+	if conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMMigratedCondition) == infrav1alpha1.MigratingReason {
+		// the migration task is done, but the VM was not moved. It is retried with the next reconcile.
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMMigratedCondition, infrav1alpha1.MigrationFailedReason, clusterv1.ConditionSeverityWarning,
+			"virtual machine is still on node %s", vm.Node)
+		return true, nil
+	}
+
+	node, err := scheduler.ScheduleMigration(ctx, machineScope, target)
+	if err != nil {
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMMigratedCondition, infrav1alpha1.MigrationFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return false, errors.Wrapf(err, "unable to schedule migration of VM %s", machineScope.Name())
+	}
+
+	if target == "" {
+		annotations[infrav1alpha1.MigrateToNodeAnnotation] = node
+		machineScope.ProxmoxMachine.SetAnnotations(annotations)
+	}
+
+	machineScope.Logger.Info("migrating virtual machine", "from", vm.Node, "to", node)
+
+	task, err := machineScope.InfraCluster.ProxmoxClient.MigrateVM(ctx, vm, node)
+	if err != nil {
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMMigratedCondition, infrav1alpha1.MigrationFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return false, errors.Wrapf(err, "failed to migrate VM %s", machineScope.Name())
+	}
+
+	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMMigratedCondition, infrav1alpha1.MigratingReason, clusterv1.ConditionSeverityInfo,
+		"migrating from node %s to node %s", vm.Node, node)
+	machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(task.UPID))
+	return true, nil
+}
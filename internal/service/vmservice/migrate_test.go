@@ -0,0 +1,138 @@
+/*
+Copyright 2023-2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luthermonson/go-proxmox"
+	"github.com/stretchr/testify/require"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+)
+
+func TestReconcileMigration_NotRequested(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.SetVirtualMachine(newRunningVM())
+
+	requeue, err := reconcileMigration(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.False(t, conditions.Has(machineScope.ProxmoxMachine, infrav1alpha1.VMMigratedCondition))
+}
+
+func TestReconcileMigration_TargetNode(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.MemoryMiB = 4096
+	machineScope.ProxmoxMachine.SetAnnotations(map[string]string{infrav1alpha1.MigrateToNodeAnnotation: "node2"})
+	vm := newRunningVM()
+	machineScope.SetVirtualMachine(vm)
+
+	proxmoxClient.EXPECT().GetReservableMemoryBytes(context.Background(), "node2", uint64(100)).Return(uint64(8<<30), nil).Once()
+	proxmoxClient.EXPECT().MigrateVM(context.Background(), vm, "node2").Return(newTask(), nil).Once()
+
+	requeue, err := reconcileMigration(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.NotNil(t, machineScope.ProxmoxMachine.Status.TaskRef)
+	require.Equal(t, infrav1alpha1.MigratingReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMMigratedCondition))
+}
+
+func TestReconcileMigration_SchedulerPicksNode(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.InfraCluster.ProxmoxCluster.Spec.AllowedNodes = []string{"node1", "node2"}
+	machineScope.ProxmoxMachine.Spec.MemoryMiB = 4096
+	machineScope.ProxmoxMachine.SetAnnotations(map[string]string{infrav1alpha1.MigrateToNodeAnnotation: ""})
+	vm := newRunningVM()
+	machineScope.SetVirtualMachine(vm)
+
+	proxmoxClient.EXPECT().GetReservableMemoryBytes(context.Background(), "node2", uint64(100)).Return(uint64(8<<30), nil).Once()
+	proxmoxClient.EXPECT().MigrateVM(context.Background(), vm, "node2").Return(newTask(), nil).Once()
+
+	requeue, err := reconcileMigration(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Equal(t, "node2", machineScope.ProxmoxMachine.GetAnnotations()[infrav1alpha1.MigrateToNodeAnnotation])
+}
+
+func TestReconcileMigration_InsufficientMemory(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.MemoryMiB = 4096
+	machineScope.ProxmoxMachine.SetAnnotations(map[string]string{infrav1alpha1.MigrateToNodeAnnotation: "node2"})
+	machineScope.SetVirtualMachine(newRunningVM())
+
+	proxmoxClient.EXPECT().GetReservableMemoryBytes(context.Background(), "node2", uint64(100)).Return(uint64(1<<30), nil).Once()
+
+	_, err := reconcileMigration(context.Background(), machineScope)
+	require.ErrorContains(t, err, "cannot reserve")
+	require.Equal(t, infrav1alpha1.MigrationFailedReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMMigratedCondition))
+}
+
+func TestReconcileMigration_NodeNotAllowed(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.InfraCluster.ProxmoxCluster.Spec.AllowedNodes = []string{"node1", "node2"}
+	machineScope.ProxmoxMachine.SetAnnotations(map[string]string{infrav1alpha1.MigrateToNodeAnnotation: "node3"})
+	machineScope.SetVirtualMachine(newRunningVM())
+
+	_, err := reconcileMigration(context.Background(), machineScope)
+	require.ErrorContains(t, err, "node node3 is not allowed")
+}
+
+func TestReconcileMigration_Done(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.SetAnnotations(map[string]string{infrav1alpha1.MigrateToNodeAnnotation: "node2"})
+	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMMigratedCondition, infrav1alpha1.MigratingReason, clusterv1.ConditionSeverityInfo, "")
+	vm := newRunningVM()
+	vm.Node = "node2"
+	machineScope.SetVirtualMachine(vm)
+
+	requeue, err := reconcileMigration(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.NotContains(t, machineScope.ProxmoxMachine.GetAnnotations(), infrav1alpha1.MigrateToNodeAnnotation)
+	require.Equal(t, "node2", *machineScope.ProxmoxMachine.Status.ProxmoxNode)
+	require.True(t, conditions.IsTrue(machineScope.ProxmoxMachine, infrav1alpha1.VMMigratedCondition))
+}
+
+func TestReconcileMigration_Failed(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.SetAnnotations(map[string]string{infrav1alpha1.MigrateToNodeAnnotation: "node2"})
+	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMMigratedCondition, infrav1alpha1.MigratingReason, clusterv1.ConditionSeverityInfo, "")
+	machineScope.SetVirtualMachine(newRunningVM())
+
+	requeue, err := reconcileMigration(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Equal(t, infrav1alpha1.MigrationFailedReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMMigratedCondition))
+}
+
+func TestUpdateMigratedVMLocation(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.SetVirtualMachineID(123)
+	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMMigratedCondition, infrav1alpha1.MigratingReason, clusterv1.ConditionSeverityInfo, "")
+	vm := newRunningVM()
+	vm.Node = "node2"
+
+	proxmoxClient.EXPECT().FindVMResource(context.Background(), uint64(123)).Return(&proxmox.ClusterResource{Name: "test", Node: "node2"}, nil).Once()
+	proxmoxClient.EXPECT().GetVM(context.Background(), "node2", int64(123)).Return(vm, nil).Once()
+
+	require.NoError(t, updateMigratedVMLocation(context.Background(), machineScope))
+	require.Equal(t, "node2", *machineScope.ProxmoxMachine.Status.ProxmoxNode)
+}
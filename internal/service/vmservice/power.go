@@ -21,6 +21,7 @@ import (
 	"fmt"
 
 	"github.com/luthermonson/go-proxmox"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/ptr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util/conditions"
@@ -38,23 +39,76 @@ func reconcilePowerState(ctx context.Context, machineScope *scope.MachineScope)
 		return true, nil
 	}
 
+	if poweredOff(machineScope) {
+		return reconcilePowerOff(ctx, machineScope)
+	}
+
 	machineScope.V(4).Info("ensuring machine is started")
 	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.PoweringOnReason, clusterv1.ConditionSeverityInfo, "")
 
 	t, err := startVirtualMachine(ctx, machineScope.InfraCluster.ProxmoxClient, machineScope.VirtualMachine)
 	if err != nil {
 		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.PoweringOnFailedReason, clusterv1.ConditionSeverityInfo, err.Error())
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMStartedCondition, infrav1alpha1.PoweringOnFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
 		return false, err
 	}
 
 	if t != nil {
+		machineScope.Eventf("VMStarted", "Started VM %d on node %s", machineScope.VirtualMachine.VMID, machineScope.VirtualMachine.Node)
 		machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(t.UPID))
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMStartedCondition, infrav1alpha1.PoweringOnReason, clusterv1.ConditionSeverityInfo, "")
 		return true, nil
 	}
 
+	conditions.MarkTrue(machineScope.ProxmoxMachine, infrav1alpha1.VMStartedCondition)
 	return false, nil
 }
 
+// poweredOff returns whether the VM is to be kept powered off. The desired power state only applies
+// to provisioned machines, so the VM is bootstrapped before it is powered off.
+func poweredOff(machineScope *scope.MachineScope) bool {
+	return machineScope.ProxmoxMachine.Spec.DesiredPowerState == infrav1alpha1.PowerStateOff &&
+		machineScope.ProxmoxMachine.Status.Ready
+}
+
+// reconcilePowerOff shuts the guest down, or stops the VM if no ShutdownTimeout is set.
+func reconcilePowerOff(ctx context.Context, machineScope *scope.MachineScope) (requeue bool, err error) {
+	vm := machineScope.VirtualMachine
+	if vm.IsStopped() || vm.IsHibernated() {
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMStartedCondition, infrav1alpha1.PoweredOffReason, clusterv1.ConditionSeverityInfo, "desired power state is off")
+		return false, nil
+	}
+
+	machineScope.V(4).Info("ensuring machine is powered off")
+	var t *proxmox.Task
+	timeout := ptr.Deref(machineScope.ProxmoxMachine.Spec.ShutdownTimeout, metav1.Duration{}).Duration
+	if timeout <= 0 || vm.IsPaused() {
+		t, err = machineScope.InfraCluster.ProxmoxClient.StopVM(ctx, vm)
+		if err != nil {
+			return false, fmt.Errorf("unable to stop the virtual machine %d: %w", vm.VMID, err)
+		}
+		machineScope.Eventf("VMStopped", "Stopped VM %d as its desired power state is off", vm.VMID)
+	} else {
+		t, err = machineScope.InfraCluster.ProxmoxClient.ShutdownVM(ctx, vm, timeout)
+		if err != nil {
+			return false, fmt.Errorf("unable to shut down the virtual machine %d: %w", vm.VMID, err)
+		}
+		machineScope.Eventf("ShutdownStarted", "Shutting down VM %d as its desired power state is off", vm.VMID)
+	}
+
+	machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(t.UPID))
+	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMStartedCondition, infrav1alpha1.PoweringOffReason, clusterv1.ConditionSeverityInfo, "")
+	return true, nil
+}
+
+// powerState returns the power state of the VM, preferring the more detailed QEMU state, e.g. paused.
+func powerState(vm *proxmox.VirtualMachine) string {
+	if vm.QMPStatus != "" {
+		return vm.QMPStatus
+	}
+	return vm.Status
+}
+
 func startVirtualMachine(ctx context.Context, client capmox.Client, vm *proxmox.VirtualMachine) (*proxmox.Task, error) {
 	if vm.IsPaused() {
 		t, err := client.ResumeVM(ctx, vm)
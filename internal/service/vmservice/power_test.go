@@ -19,8 +19,13 @@ package vmservice
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/luthermonson/go-proxmox"
 	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/cluster-api/util/conditions"
 
 	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
 )
@@ -40,14 +45,95 @@ func TestReconcilePowerState_SetTaskRef(t *testing.T) {
 	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
 
 	vm := newStoppedVM()
+	vm.VMID = 123
 	task := newTask()
 	machineScope.SetVirtualMachine(vm)
 	proxmoxClient.EXPECT().StartVM(ctx, vm).Return(task, nil).Once()
 
+	recorder := record.NewFakeRecorder(1)
+	machineScope.Recorder = recorder
+
+	requeue, err := reconcilePowerState(ctx, machineScope)
+	require.True(t, requeue)
+	require.NoError(t, err)
+	require.NotEmpty(t, *machineScope.ProxmoxMachine.Status.TaskRef)
+	require.Equal(t, "Normal VMStarted Started VM 123 on node node1", <-recorder.Events)
+}
+
+func TestReconcilePowerState_PowerOff(t *testing.T) {
+	ctx := context.TODO()
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	machineScope.ProxmoxMachine.Status.Ready = true
+	machineScope.ProxmoxMachine.Spec.DesiredPowerState = infrav1alpha1.PowerStateOff
+	machineScope.ProxmoxMachine.Spec.ShutdownTimeout = &metav1.Duration{Duration: 2 * time.Minute}
+
+	vm := newRunningVM()
+	vm.VMID = 123
+	machineScope.SetVirtualMachine(vm)
+	proxmoxClient.EXPECT().ShutdownVM(ctx, vm, 2*time.Minute).Return(newTask(), nil).Once()
+
+	recorder := record.NewFakeRecorder(1)
+	machineScope.Recorder = recorder
+
 	requeue, err := reconcilePowerState(ctx, machineScope)
 	require.True(t, requeue)
 	require.NoError(t, err)
 	require.NotEmpty(t, *machineScope.ProxmoxMachine.Status.TaskRef)
+	require.Equal(t, "Normal ShutdownStarted Shutting down VM 123 as its desired power state is off", <-recorder.Events)
+	require.True(t, conditions.IsFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMStartedCondition))
+	require.Equal(t, infrav1alpha1.PoweringOffReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMStartedCondition))
+}
+
+func TestReconcilePowerState_PowerOffWithoutShutdownTimeout(t *testing.T) {
+	ctx := context.TODO()
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	machineScope.ProxmoxMachine.Status.Ready = true
+	machineScope.ProxmoxMachine.Spec.DesiredPowerState = infrav1alpha1.PowerStateOff
+
+	vm := newRunningVM()
+	vm.VMID = 123
+	machineScope.SetVirtualMachine(vm)
+	proxmoxClient.EXPECT().StopVM(ctx, vm).Return(newTask(), nil).Once()
+
+	recorder := record.NewFakeRecorder(1)
+	machineScope.Recorder = recorder
+
+	requeue, err := reconcilePowerState(ctx, machineScope)
+	require.True(t, requeue)
+	require.NoError(t, err)
+	require.Equal(t, "Normal VMStopped Stopped VM 123 as its desired power state is off", <-recorder.Events)
+}
+
+func TestReconcilePowerState_PoweredOff(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	machineScope.ProxmoxMachine.Status.Ready = true
+	machineScope.ProxmoxMachine.Spec.DesiredPowerState = infrav1alpha1.PowerStateOff
+	machineScope.SetVirtualMachine(newStoppedVM())
+
+	requeue, err := reconcilePowerState(context.TODO(), machineScope)
+	require.False(t, requeue)
+	require.NoError(t, err)
+	require.Nil(t, machineScope.ProxmoxMachine.Status.TaskRef)
+	require.Equal(t, infrav1alpha1.PoweredOffReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMStartedCondition))
+}
+
+func TestReconcilePowerState_PowerOffBeforeProvisioned(t *testing.T) {
+	ctx := context.TODO()
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	machineScope.ProxmoxMachine.Spec.DesiredPowerState = infrav1alpha1.PowerStateOff
+
+	// the VM is started to be bootstrapped first.
+	vm := newStoppedVM()
+	machineScope.SetVirtualMachine(vm)
+	proxmoxClient.EXPECT().StartVM(ctx, vm).Return(newTask(), nil).Once()
+
+	requeue, err := reconcilePowerState(ctx, machineScope)
+	require.True(t, requeue)
+	require.NoError(t, err)
 }
 
 func TestStartVirtualMachine_Paused(t *testing.T) {
@@ -91,3 +177,9 @@ func TestStartVirtualMachine_Started(t *testing.T) {
 	require.NoError(t, err)
 	require.Nil(t, task)
 }
+
+func TestPowerState(t *testing.T) {
+	require.Equal(t, "running", powerState(newRunningVM()))
+	require.Equal(t, "paused", powerState(newPausedVM()))
+	require.Equal(t, "stopped", powerState(&proxmox.VirtualMachine{Status: proxmox.StatusVirtualMachineStopped}))
+}
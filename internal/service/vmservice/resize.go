@@ -0,0 +1,112 @@
+/*
+Copyright 2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+// reconcileResize applies changes of the CPUs and memory to the running VM of a machine with AllowInPlaceResize.
+// Added memory is hot-plugged if the VM supports it. Other changes stay pending in Proxmox,
+// and the VM is rebooted to apply them once the config task is done.
+func reconcileResize(ctx context.Context, machineScope *scope.MachineScope) (requeue bool, err error) {
+	machine := machineScope.ProxmoxMachine
+	if !machine.Spec.AllowInPlaceResize || !machineScope.VirtualMachine.IsRunning() {
+		return false, nil
+	}
+
+	switch conditions.GetReason(machine, infrav1alpha1.VMResizedCondition) {
+	case infrav1alpha1.RebootRequiredReason:
+		return rebootForResize(ctx, machineScope)
+	case infrav1alpha1.RebootingReason:
+		conditions.Set(machine, &clusterv1.Condition{
+			Type:     infrav1alpha1.VMResizedCondition,
+			Status:   corev1.ConditionTrue,
+			Reason:   infrav1alpha1.ResizedWithRebootReason,
+			Severity: clusterv1.ConditionSeverityNone,
+			Message:  "virtual machine was rebooted to apply the cpu and memory changes",
+		})
+	case infrav1alpha1.ResizingReason:
+		conditions.MarkTrue(machine, infrav1alpha1.VMResizedCondition)
+	}
+
+	vmConfig := machineScope.VirtualMachine.VirtualMachineConfig
+	var vmOptions []proxmox.VirtualMachineOption
+	hotplug := true
+	if value := machine.Spec.NumSockets; value > 0 && vmConfig.Sockets != int(value) {
+		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionSockets, Value: value})
+		// Proxmox only hot-plugs vcpus, not sockets or cores.
+		hotplug = false
+	}
+	if value := machine.Spec.NumCores; value > 0 && vmConfig.Cores != int(value) {
+		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionCores, Value: value})
+		hotplug = false
+	}
+	if value := machine.Spec.MemoryMiB; value > 0 && int32(vmConfig.Memory) != value {
+		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionMemory, Value: value})
+		// removing memory from a running guest is unreliable, it is only ever added.
+		if value < int32(vmConfig.Memory) || vmConfig.Numa != 1 || !hotplugEnabled(vmConfig.Hotplug, infrav1alpha1.HotplugMemory) {
+			hotplug = false
+		}
+	}
+
+	if len(vmOptions) == 0 {
+		return false, nil
+	}
+
+	machineScope.Info("resizing running virtual machine", "sockets", machine.Spec.NumSockets, "cores", machine.Spec.NumCores,
+		"memory", machine.Spec.MemoryMiB, "hotplug", hotplug)
+
+	task, err := machineScope.InfraCluster.ProxmoxClient.ConfigureVM(ctx, machineScope.VirtualMachine, vmOptions...)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to resize VM %s", machineScope.Name())
+	}
+
+	if hotplug {
+		conditions.MarkFalse(machine, infrav1alpha1.VMResizedCondition, infrav1alpha1.ResizingReason, clusterv1.ConditionSeverityInfo, "")
+	} else {
+		conditions.MarkFalse(machine, infrav1alpha1.VMResizedCondition, infrav1alpha1.RebootRequiredReason, clusterv1.ConditionSeverityWarning,
+			"the cpu and memory changes can't be hot-plugged, the virtual machine is rebooted to apply them")
+	}
+
+	machine.Status.TaskRef = ptr.To(string(task.UPID))
+	return true, nil
+}
+
+// rebootForResize reboots the VM, which makes Proxmox apply the pending CPU and memory changes.
+func rebootForResize(ctx context.Context, machineScope *scope.MachineScope) (requeue bool, err error) {
+	machineScope.Info("rebooting virtual machine to apply the cpu and memory changes")
+
+	task, err := machineScope.InfraCluster.ProxmoxClient.RebootVM(ctx, machineScope.VirtualMachine)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to reboot VM %s", machineScope.Name())
+	}
+
+	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMResizedCondition, infrav1alpha1.RebootingReason, clusterv1.ConditionSeverityInfo, "")
+	machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(task.UPID))
+	return true, nil
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+func TestReconcileResize_NotAllowed(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.MemoryMiB = 4096
+	vm := newRunningVM()
+	vm.VirtualMachineConfig.Memory = 2048
+	machineScope.SetVirtualMachine(vm)
+
+	requeue, err := reconcileResize(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.False(t, conditions.Has(machineScope.ProxmoxMachine, infrav1alpha1.VMResizedCondition))
+}
+
+func TestReconcileResize_Unchanged(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.AllowInPlaceResize = true
+	machineScope.ProxmoxMachine.Spec.NumCores = 2
+	machineScope.ProxmoxMachine.Spec.MemoryMiB = 2048
+	vm := newRunningVM()
+	vm.VirtualMachineConfig.Cores = 2
+	vm.VirtualMachineConfig.Memory = 2048
+	machineScope.SetVirtualMachine(vm)
+
+	requeue, err := reconcileResize(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.False(t, conditions.Has(machineScope.ProxmoxMachine, infrav1alpha1.VMResizedCondition))
+}
+
+func TestReconcileResize_HotplugMemory(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.AllowInPlaceResize = true
+	machineScope.ProxmoxMachine.Spec.MemoryMiB = 4096
+	vm := newRunningVM()
+	vm.VirtualMachineConfig.Memory = 2048
+	vm.VirtualMachineConfig.Numa = 1
+	vm.VirtualMachineConfig.Hotplug = "network,disk,memory"
+	machineScope.SetVirtualMachine(vm)
+
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, proxmox.VirtualMachineOption{Name: optionMemory, Value: int32(4096)}).Return(newTask(), nil).Once()
+
+	requeue, err := reconcileResize(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Equal(t, infrav1alpha1.ResizingReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMResizedCondition))
+	require.Equal(t, "result", *machineScope.ProxmoxMachine.Status.TaskRef)
+
+	// the memory was hot-plugged.
+	vm.VirtualMachineConfig.Memory = 4096
+	requeue, err = reconcileResize(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.True(t, conditions.IsTrue(machineScope.ProxmoxMachine, infrav1alpha1.VMResizedCondition))
+	require.Empty(t, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMResizedCondition))
+}
+
+func TestReconcileResize_Reboot(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.AllowInPlaceResize = true
+	machineScope.ProxmoxMachine.Spec.NumCores = 4
+	machineScope.ProxmoxMachine.Spec.MemoryMiB = 4096
+	vm := newRunningVM()
+	vm.VirtualMachineConfig.Cores = 2
+	vm.VirtualMachineConfig.Memory = 2048
+	vm.VirtualMachineConfig.Numa = 1
+	vm.VirtualMachineConfig.Hotplug = "network,disk,memory,cpu"
+	machineScope.SetVirtualMachine(vm)
+
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm,
+		proxmox.VirtualMachineOption{Name: optionCores, Value: int32(4)},
+		proxmox.VirtualMachineOption{Name: optionMemory, Value: int32(4096)},
+	).Return(newTask(), nil).Once()
+
+	requeue, err := reconcileResize(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Equal(t, infrav1alpha1.RebootRequiredReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMResizedCondition))
+
+	// the config task is done, the changes are pending until the reboot.
+	proxmoxClient.EXPECT().RebootVM(context.Background(), vm).Return(newTask(), nil).Once()
+
+	requeue, err = reconcileResize(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Equal(t, infrav1alpha1.RebootingReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMResizedCondition))
+
+	// the reboot applied the changes.
+	vm.VirtualMachineConfig.Cores = 4
+	vm.VirtualMachineConfig.Memory = 4096
+	requeue, err = reconcileResize(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.True(t, conditions.IsTrue(machineScope.ProxmoxMachine, infrav1alpha1.VMResizedCondition))
+	require.Equal(t, infrav1alpha1.ResizedWithRebootReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMResizedCondition))
+}
+
+func TestReconcileResize_ShrinkMemoryReboots(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.AllowInPlaceResize = true
+	machineScope.ProxmoxMachine.Spec.MemoryMiB = 2048
+	vm := newRunningVM()
+	vm.VirtualMachineConfig.Memory = 4096
+	vm.VirtualMachineConfig.Numa = 1
+	vm.VirtualMachineConfig.Hotplug = "memory"
+	machineScope.SetVirtualMachine(vm)
+
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, proxmox.VirtualMachineOption{Name: optionMemory, Value: int32(2048)}).Return(newTask(), nil).Once()
+
+	requeue, err := reconcileResize(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.True(t, conditions.IsFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMResizedCondition))
+	require.Equal(t, infrav1alpha1.RebootRequiredReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMResizedCondition))
+	require.Equal(t, clusterv1.ConditionSeverityWarning, *conditions.GetSeverity(machineScope.ProxmoxMachine, infrav1alpha1.VMResizedCondition))
+}
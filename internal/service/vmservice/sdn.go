@@ -0,0 +1,87 @@
+/*
+Copyright 2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"slices"
+
+	"github.com/pkg/errors"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+var (
+	// ErrVNetNotFound is returned if the bridge of a network device is neither a Linux bridge nor a VNet of the SDN.
+	ErrVNetNotFound = errors.New("sdn vnet not found")
+
+	// ErrVNetVLAN is returned if a network device sets a VLAN in a VNet, which manages the VLAN itself.
+	ErrVNetVLAN = errors.New("vlan not allowed in sdn vnet")
+)
+
+// checkVNets checks the network devices attached to VNets of the Proxmox SDN, before the VM is cloned.
+// The SDN tags the traffic of the guests itself, devices may only set their own VLAN in VLAN-aware VNets.
+func checkVNets(ctx context.Context, machineScope *scope.MachineScope) error {
+	devices := networkDevices(machineScope.ProxmoxMachine.Spec.Network)
+	if !slices.ContainsFunc(devices, func(d namedNetworkDevice) bool { return proxmox.IsVNet(d.Bridge) }) {
+		return nil
+	}
+
+	vnets, err := machineScope.InfraCluster.ProxmoxClient.ListVNets(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to list sdn vnets")
+	}
+
+	for _, device := range devices {
+		if !proxmox.IsVNet(device.Bridge) {
+			continue
+		}
+		i := slices.IndexFunc(vnets, func(v proxmox.VNet) bool { return v.Name == device.Bridge })
+		if i < 0 {
+			return errors.Wrapf(ErrVNetNotFound, "bridge %s of network device %s is neither named vmbrN nor a vnet", device.Bridge, device.Name)
+		}
+		if vnet := vnets[i]; device.VLAN != nil && !bool(vnet.VLANAware) {
+			return errors.Wrapf(ErrVNetVLAN, "network device %s sets vlan %d, but vnet %s of zone %s is not vlan-aware",
+				device.Name, *device.VLAN, vnet.Name, vnet.Zone)
+		}
+	}
+	return nil
+}
+
+// namedNetworkDevice is a network device along with the name of its Proxmox device.
+type namedNetworkDevice struct {
+	infrav1alpha1.NetworkDevice
+	Name string
+}
+
+// networkDevices returns the default and additional network devices of the spec.
+func networkDevices(network *infrav1alpha1.NetworkSpec) []namedNetworkDevice {
+	if network == nil {
+		return nil
+	}
+
+	var devices []namedNetworkDevice
+	if network.Default != nil {
+		devices = append(devices, namedNetworkDevice{NetworkDevice: *network.Default, Name: infrav1alpha1.DefaultNetworkDevice})
+	}
+	for _, device := range network.AdditionalDevices {
+		devices = append(devices, namedNetworkDevice{NetworkDevice: device.NetworkDevice, Name: device.Name})
+	}
+	return devices
+}
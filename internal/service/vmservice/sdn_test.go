@@ -0,0 +1,83 @@
+/*
+Copyright 2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+func newVNets() []proxmox.VNet {
+	return []proxmox.VNet{
+		{Name: "prod", Zone: "vlans", Tag: 100},
+		{Name: "trunk", Zone: "vlans", VLANAware: true},
+	}
+}
+
+func TestCheckVNets_LinuxBridges(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		Default: &infrav1alpha1.NetworkDevice{Bridge: "vmbr0", VLAN: ptr.To(uint16(100))},
+	}
+
+	// the vnets are not listed.
+	require.NoError(t, checkVNets(context.Background(), machineScope))
+}
+
+func TestCheckVNets(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		Default: &infrav1alpha1.NetworkDevice{Bridge: "prod"},
+		AdditionalDevices: []infrav1alpha1.AdditionalNetworkDevice{
+			{Name: "net1", NetworkDevice: infrav1alpha1.NetworkDevice{Bridge: "trunk", VLAN: ptr.To(uint16(200))}},
+			{Name: "net2", NetworkDevice: infrav1alpha1.NetworkDevice{Bridge: "vmbr1"}},
+		},
+	}
+	proxmoxClient.EXPECT().ListVNets(context.Background()).Return(newVNets(), nil).Once()
+
+	require.NoError(t, checkVNets(context.Background(), machineScope))
+}
+
+func TestCheckVNets_NotFound(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		Default: &infrav1alpha1.NetworkDevice{Bridge: "staging"},
+	}
+	proxmoxClient.EXPECT().ListVNets(context.Background()).Return(newVNets(), nil).Once()
+
+	err := checkVNets(context.Background(), machineScope)
+	require.ErrorIs(t, err, ErrVNetNotFound)
+	require.ErrorContains(t, err, "bridge staging of network device net0 is neither named vmbrN nor a vnet")
+}
+
+func TestCheckVNets_VLANNotAware(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		Default: &infrav1alpha1.NetworkDevice{Bridge: "prod", VLAN: ptr.To(uint16(100))},
+	}
+	proxmoxClient.EXPECT().ListVNets(context.Background()).Return(newVNets(), nil).Once()
+
+	err := checkVNets(context.Background(), machineScope)
+	require.ErrorIs(t, err, ErrVNetVLAN)
+	require.ErrorContains(t, err, "network device net0 sets vlan 100, but vnet prod of zone vlans is not vlan-aware")
+}
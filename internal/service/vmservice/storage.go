@@ -0,0 +1,141 @@
+/*
+Copyright 2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/luthermonson/go-proxmox"
+	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	capmox "github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+// ErrStorageNotFound is returned if the storage a full clone is placed on is not available on the node of the VM.
+var ErrStorageNotFound = errors.New("storage does not exist")
+
+// ErrStorageUnsuitable is returned if the storage a full clone is placed on cannot hold the disks of VMs.
+var ErrStorageUnsuitable = errors.New("storage cannot hold vm disks")
+
+// ErrInsufficientStorage is returned if the storages of a full clone don't have enough space left for its disks.
+var ErrInsufficientStorage = errors.New("insufficient storage")
+
+// cloneStorage returns the storage the disks of the clone are placed on. The storage of the machine
+// takes precedence over the one of its failure domain and the default of the cluster, which only apply
+// to full clones, as linked clones keep their disks on the storage of the template.
+func cloneStorage(scope *scope.MachineScope, failureDomain *infrav1alpha1.FailureDomainSpec, full bool) string {
+	if scope.ProxmoxMachine.Spec.Storage != nil {
+		return *scope.ProxmoxMachine.Spec.Storage
+	}
+	if !full {
+		return ""
+	}
+	if failureDomain != nil && failureDomain.Storage != nil {
+		return *failureDomain.Storage
+	}
+	return ptr.Deref(scope.InfraCluster.ProxmoxCluster.Spec.Storage, "")
+}
+
+// checkCloneStorage checks that the storage of the clone is enabled on the node the VM is cloned to
+// and holds the disks of VMs. Full clones additionally need enough space left on their storages.
+func checkCloneStorage(ctx context.Context, scope *scope.MachineScope, templateID int32, options capmox.VMCloneRequest) error {
+	if options.Storage == "" && options.Full != 1 {
+		return nil
+	}
+
+	node := options.Target
+	if node == "" {
+		node = options.Node
+	}
+
+	storages, err := scope.InfraCluster.ProxmoxClient.ListStorages(ctx, node)
+	if err != nil {
+		return errors.Wrapf(err, "unable to list storages of node %s", node)
+	}
+
+	if options.Storage != "" {
+		i := slices.IndexFunc(storages, func(storage *proxmox.Storage) bool { return storage.Name == options.Storage })
+		switch {
+		case i < 0:
+			return errors.Wrapf(ErrStorageNotFound, "storage %q on node %s", options.Storage, node)
+		case storages[i].Enabled == 0 || storages[i].Active == 0:
+			return errors.Wrapf(ErrStorageNotFound, "storage %q is not active on node %s", options.Storage, node)
+		case !slices.Contains(strings.Split(storages[i].Content, ","), "images"):
+			return errors.Wrapf(ErrStorageUnsuitable, "storage %q on node %s", options.Storage, node)
+		}
+	}
+
+	if options.Full != 1 {
+		return nil
+	}
+	return checkStorageCapacity(ctx, scope, templateID, options, storages)
+}
+
+// checkStorageCapacity checks that the storages of a full clone have room for the disks of the template,
+// grown to the size of the boot volume, and for the additional volumes, plus the headroom of the cluster.
+// The clone is not started otherwise, as a clone failing halfway leaves its disks behind.
+func checkStorageCapacity(ctx context.Context, scope *scope.MachineScope, templateID int32, options capmox.VMCloneRequest, storages []*proxmox.Storage) error {
+	template, err := scope.InfraCluster.ProxmoxClient.GetVM(ctx, options.Node, int64(templateID))
+	if err != nil {
+		return errors.Wrapf(err, "unable to get vm template %d", templateID)
+	}
+
+	disks := scope.ProxmoxMachine.Spec.Disks
+	required := make(map[string]int64)
+	for device, disk := range template.VirtualMachineConfig.MergeDisks() {
+		if strings.Contains(disk, "media=cdrom") {
+			continue
+		}
+		size, ok := extractDiskSize(disk)
+		if !ok {
+			continue
+		}
+		if disks != nil && disks.BootVolume != nil && disks.BootVolume.Disk == device {
+			size = max(size, int64(disks.BootVolume.SizeGB)*gib)
+		}
+
+		storage := options.Storage
+		if storage == "" {
+			storage, _, _ = strings.Cut(disk, ":")
+		}
+		required[storage] += size
+	}
+	if disks != nil {
+		for _, volume := range disks.AdditionalVolumes {
+			required[volume.StoragePool] += int64(volume.SizeGB) * gib
+		}
+	}
+
+	headroom := int64(ptr.Deref(scope.InfraCluster.ProxmoxCluster.Spec.StorageHeadroomGB, 0)) * gib
+	for _, storage := range storages {
+		size, ok := required[storage.Name]
+		if !ok {
+			continue
+		}
+		if int64(storage.Avail) < size+headroom {
+			return errors.Wrapf(ErrInsufficientStorage, "storage %q on node %s has %dGiB left, but %dGiB are required",
+				storage.Name, storage.Node, int64(storage.Avail)/gib, (size+headroom+gib-1)/gib)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2023-2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/luthermonson/go-proxmox"
+	"github.com/pkg/errors"
+
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/goproxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+var (
+	// ErrTemplateNotFound is returned if the template to clone from does not exist.
+	ErrTemplateNotFound = errors.New("vm template not found")
+
+	// ErrTemplateUnreachable is returned if the node of the template to clone from is not reachable.
+	ErrTemplateUnreachable = errors.New("vm template not reachable")
+)
+
+// resolveTemplate looks up the template of the machine by its TemplateID or TemplateName before it is cloned,
+// and returns its VMID and node. Of templates with the same name, the one on the target node is preferred,
+// then the one on the SourceNode.
+func resolveTemplate(ctx context.Context, scope *scope.MachineScope, target string, full bool) (int32, string, error) {
+	spec := scope.ProxmoxMachine.Spec.VirtualMachineCloneSpec
+
+	var reference string
+	var match func(*proxmox.ClusterResource) bool
+	switch {
+	case spec.TemplateID != nil:
+		reference = fmt.Sprintf("%d", *spec.TemplateID)
+		match = func(r *proxmox.ClusterResource) bool { return r.VMID == uint64(*spec.TemplateID) }
+	case spec.TemplateName != nil:
+		reference = fmt.Sprintf("%q", *spec.TemplateName)
+		match = func(r *proxmox.ClusterResource) bool { return r.Template == 1 && r.Name == *spec.TemplateName }
+	default:
+		return 0, "", errors.Wrap(ErrTemplateNotFound, "neither templateID nor templateName is set")
+	}
+
+	resources, err := scope.InfraCluster.ProxmoxClient.ListVMResources(ctx)
+	if err != nil {
+		return 0, "", errors.Wrapf(err, "unable to look up vm template %s", reference)
+	}
+
+	var candidates []*proxmox.ClusterResource
+	for _, r := range resources {
+		if r.Type == "qemu" && match(r) {
+			candidates = append(candidates, r)
+		}
+	}
+
+	template := pickTemplate(candidates, target, spec.SourceNode)
+	if template == nil {
+		if len(candidates) == 0 {
+			return 0, "", errors.Wrapf(ErrTemplateNotFound, "no vm template %s exists", reference)
+		}
+		nodes := make([]string, 0, len(candidates))
+		for _, c := range candidates {
+			nodes = append(nodes, c.Node)
+		}
+		return 0, "", errors.Wrapf(ErrTemplateNotFound, "vm template %s exists on nodes %s, but neither on node %s nor %s",
+			reference, strings.Join(nodes, ", "), target, spec.SourceNode)
+	}
+
+	// Proxmox reports the VMs of unreachable nodes with an unknown status.
+	if template.Status == "unknown" {
+		return 0, "", errors.Wrapf(ErrTemplateUnreachable, "node %s of vm template %s is not reachable", template.Node, reference)
+	}
+	if !full && template.Template != 1 {
+		return 0, "", errors.Wrapf(goproxmox.ErrLinkedCloneRequiresTemplate, "vm %s", reference)
+	}
+
+	if template.Node != spec.SourceNode {
+		scope.Info("using vm template from another node", "template", template.VMID, "node", template.Node)
+	}
+	return int32(template.VMID), template.Node, nil
+}
+
+// pickTemplate returns the template on the target node, or else on the source node.
+// If neither has one, the template is only picked if there is no other to choose from.
+func pickTemplate(candidates []*proxmox.ClusterResource, target, source string) *proxmox.ClusterResource {
+	for _, node := range []string{target, source} {
+		if i := slices.IndexFunc(candidates, func(r *proxmox.ClusterResource) bool { return node != "" && r.Node == node }); i >= 0 {
+			return candidates[i]
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+	return nil
+}
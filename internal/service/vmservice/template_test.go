@@ -0,0 +1,132 @@
+/*
+Copyright 2023-2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luthermonson/go-proxmox"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/goproxmox"
+)
+
+func TestResolveTemplate_ByID(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	expectVMResources(proxmoxClient, newTemplateResource(100, "node1"), newTemplateResource(123, "node2"))
+
+	templateID, node, err := resolveTemplate(context.Background(), machineScope, "", true)
+	require.NoError(t, err)
+	require.Equal(t, int32(123), templateID)
+	require.Equal(t, "node2", node)
+}
+
+func TestResolveTemplate_ByName(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		templates  []*proxmox.ClusterResource
+		templateID int32
+		node       string
+	}{
+		{
+			name:       "target node",
+			target:     "node2",
+			templates:  []*proxmox.ClusterResource{newTemplateResource(100, "node1"), newTemplateResource(101, "node2")},
+			templateID: 101,
+			node:       "node2",
+		},
+		{
+			name:       "source node",
+			target:     "node3",
+			templates:  []*proxmox.ClusterResource{newTemplateResource(100, "node1"), newTemplateResource(101, "node2")},
+			templateID: 100,
+			node:       "node1",
+		},
+		{
+			name:       "only template",
+			templates:  []*proxmox.ClusterResource{newTemplateResource(101, "node2")},
+			templateID: 101,
+			node:       "node2",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+			machineScope.ProxmoxMachine.Spec.TemplateID = nil
+			machineScope.ProxmoxMachine.Spec.TemplateName = ptr.To("template")
+			expectVMResources(proxmoxClient, test.templates...)
+
+			templateID, node, err := resolveTemplate(context.Background(), machineScope, test.target, true)
+			require.NoError(t, err)
+			require.Equal(t, test.templateID, templateID)
+			require.Equal(t, test.node, node)
+		})
+	}
+}
+
+func TestResolveTemplate_NotFound(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.TemplateID = nil
+	machineScope.ProxmoxMachine.Spec.TemplateName = ptr.To("template")
+	vm := newTemplateResource(100, "node1")
+	vm.Template = 0
+	expectVMResources(proxmoxClient, vm)
+
+	_, _, err := resolveTemplate(context.Background(), machineScope, "", true)
+	require.ErrorIs(t, err, ErrTemplateNotFound)
+	require.ErrorContains(t, err, `no vm template "template" exists`)
+}
+
+func TestResolveTemplate_OtherNodes(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.TemplateID = nil
+	machineScope.ProxmoxMachine.Spec.TemplateName = ptr.To("template")
+	expectVMResources(proxmoxClient, newTemplateResource(100, "node2"), newTemplateResource(101, "node3"))
+
+	_, _, err := resolveTemplate(context.Background(), machineScope, "node4", true)
+	require.ErrorIs(t, err, ErrTemplateNotFound)
+	require.ErrorContains(t, err, `vm template "template" exists on nodes node2, node3, but neither on node node4 nor node1`)
+}
+
+func TestResolveTemplate_NodeUnreachable(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	template := newTemplateResource(123, "node1")
+	template.Status = "unknown"
+	expectVMResources(proxmoxClient, template)
+
+	_, _, err := resolveTemplate(context.Background(), machineScope, "", true)
+	require.ErrorIs(t, err, ErrTemplateUnreachable)
+	require.ErrorContains(t, err, "node node1 of vm template 123 is not reachable")
+}
+
+func TestResolveTemplate_LinkedCloneFromVM(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	vm := newTemplateResource(123, "node1")
+	vm.Template = 0
+	expectVMResources(proxmoxClient, vm)
+	expectVMResources(proxmoxClient, vm)
+
+	templateID, _, err := resolveTemplate(context.Background(), machineScope, "", true)
+	require.NoError(t, err)
+	require.Equal(t, int32(123), templateID)
+
+	_, _, err = resolveTemplate(context.Background(), machineScope, "", false)
+	require.ErrorIs(t, err, goproxmox.ErrLinkedCloneRequiresTemplate)
+}
@@ -19,10 +19,13 @@ package vmservice
 import (
 	"fmt"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"text/template"
 
 	"github.com/google/uuid"
+	"k8s.io/utils/ptr"
 
 	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
@@ -108,6 +111,71 @@ func extractNetworkVLAN(input string) uint16 {
 	return 0
 }
 
+// extractNetworkFirewall returns whether the firewall is enabled in net device input e.g. virtio=A6:23:64:4D:84:CB,bridge=vmbr1,firewall=1.
+func extractNetworkFirewall(input string) bool {
+	return slices.Contains(strings.Split(input, ","), "firewall=1")
+}
+
+// extractNetworkQueues returns the queues out of net device input e.g. virtio=A6:23:64:4D:84:CB,bridge=vmbr1,queues=4.
+func extractNetworkQueues(input string) uint8 {
+	re := regexp.MustCompile(`queues=(\d+)`)
+	match := re.FindStringSubmatch(input)
+	if len(match) > 1 {
+		queues, err := strconv.ParseUint(match[1], 10, 8)
+		if err != nil {
+			return 0
+		}
+		return uint8(queues)
+	}
+
+	return 0
+}
+
+// extractNetworkRate returns the rate limit out of net device input e.g. virtio=A6:23:64:4D:84:CB,bridge=vmbr1,rate=12.5.
+func extractNetworkRate(input string) string {
+	re := regexp.MustCompile(`rate=([0-9.]+)`)
+	match := re.FindStringSubmatch(input)
+	if len(match) > 1 {
+		return match[1]
+	}
+
+	return ""
+}
+
+// networkRateEquals compares the rate limit of a net device with the desired one numerically, as Proxmox
+// may format the value differently.
+func networkRateEquals(current string, desired *string) bool {
+	if desired == nil || current == "" {
+		return desired == nil && current == ""
+	}
+
+	c, err := strconv.ParseFloat(current, 64)
+	if err != nil {
+		return false
+	}
+	d, err := strconv.ParseFloat(*desired, 64)
+	if err != nil {
+		return false
+	}
+	return c == d
+}
+
+// setNetworkRate replaces the rate limit of net device input, keeping all other options like the MAC address.
+func setNetworkRate(input string, rate *string) string {
+	var components []string
+	for _, component := range strings.Split(input, ",") {
+		if !strings.HasPrefix(component, "rate=") {
+			components = append(components, component)
+		}
+	}
+
+	if rate != nil {
+		components = append(components, fmt.Sprintf("rate=%s", *rate))
+	}
+
+	return strings.Join(components, ",")
+}
+
 func shouldUpdateNetworkDevices(machineScope *scope.MachineScope) bool {
 	if machineScope.ProxmoxMachine.Spec.Network == nil {
 		// no network config needed
@@ -146,6 +214,22 @@ func shouldUpdateNetworkDevices(machineScope *scope.MachineScope) bool {
 				return true
 			}
 		}
+
+		if extractNetworkFirewall(net0) != desiredDefault.Firewall {
+			return true
+		}
+
+		if extractNetworkQueues(net0) != ptr.Deref(desiredDefault.Queues, 0) {
+			return true
+		}
+
+		if !networkRateEquals(extractNetworkRate(net0), desiredDefault.RateLimitMBps) {
+			return true
+		}
+
+		if desiredDefault.MACAddress != "" && !strings.EqualFold(extractMACAddress(net0), desiredDefault.MACAddress) {
+			return true
+		}
 	}
 
 	devices := machineScope.ProxmoxMachine.Spec.Network.AdditionalDevices
@@ -179,27 +263,335 @@ func shouldUpdateNetworkDevices(machineScope *scope.MachineScope) bool {
 				return true
 			}
 		}
+
+		if extractNetworkFirewall(net) != v.Firewall {
+			return true
+		}
+
+		if extractNetworkQueues(net) != ptr.Deref(v.Queues, 0) {
+			return true
+		}
+
+		if !networkRateEquals(extractNetworkRate(net), v.RateLimitMBps) {
+			return true
+		}
+
+		if v.MACAddress != "" && !strings.EqualFold(extractMACAddress(net), v.MACAddress) {
+			return true
+		}
 	}
 
 	return false
 }
 
 // formatNetworkDevice formats a network device config
-// example 'virtio,bridge=vmbr0,tag=100'.
-func formatNetworkDevice(model, bridge string, mtu *uint16, vlan *uint16) string {
-	var components = []string{model, fmt.Sprintf("bridge=%s", bridge)}
+// example 'virtio=A6:23:64:4D:84:CB,bridge=vmbr0,tag=100'.
+func formatNetworkDevice(device infrav1alpha1.NetworkDevice) string {
+	model := ptr.Deref(device.Model, "virtio")
+	if device.MACAddress != "" {
+		model = fmt.Sprintf("%s=%s", model, device.MACAddress)
+	}
+
+	var components = []string{model, fmt.Sprintf("bridge=%s", device.Bridge)}
+
+	if device.Firewall {
+		components = append(components, "firewall=1")
+	}
 
-	if mtu != nil {
-		components = append(components, fmt.Sprintf("mtu=%d", *mtu))
+	if device.MTU != nil {
+		components = append(components, fmt.Sprintf("mtu=%d", *device.MTU))
 	}
 
-	if vlan != nil {
-		components = append(components, fmt.Sprintf("tag=%d", *vlan))
+	if device.VLAN != nil {
+		components = append(components, fmt.Sprintf("tag=%d", *device.VLAN))
+	}
+
+	if device.Queues != nil {
+		components = append(components, fmt.Sprintf("queues=%d", *device.Queues))
+	}
+
+	if device.RateLimitMBps != nil {
+		components = append(components, fmt.Sprintf("rate=%s", *device.RateLimitMBps))
 	}
 
 	return strings.Join(components, ",")
 }
 
+// additionalVolumeDevice returns the device name of the additional volume
+// at the given index, starting with scsi1.
+func additionalVolumeDevice(index int) string {
+	return fmt.Sprintf("scsi%d", index+1)
+}
+
+// formatDiskVolume formats a disk allocation for a new volume
+// example 'local-lvm:50,format=raw'.
+func formatDiskVolume(disk infrav1alpha1.DiskSpec) string {
+	volume := fmt.Sprintf("%s:%d", disk.StoragePool, disk.SizeGB)
+	if disk.Format != nil {
+		volume = fmt.Sprintf("%s,format=%s", volume, *disk.Format)
+	}
+	for _, option := range formatDiskOptions(disk.Cache, disk.Discard, disk.SSDEmulation, disk.IOThread) {
+		volume += "," + option
+	}
+	if disk.Throttle != nil {
+		for _, option := range formatDiskThrottle(*disk.Throttle) {
+			volume += "," + option
+		}
+	}
+	return volume
+}
+
+// formatDiskOptions formats the requested cache, discard, ssd and iothread options of a disk
+// example ['cache=writeback', 'discard=on', 'ssd=1', 'iothread=1'].
+func formatDiskOptions(cache infrav1alpha1.DiskCache, discard, ssd, iothread bool) []string {
+	var options []string
+	if cache != "" {
+		options = append(options, "cache="+string(cache))
+	}
+	if discard {
+		options = append(options, "discard=on")
+	}
+	if ssd {
+		options = append(options, "ssd=1")
+	}
+	if iothread {
+		options = append(options, "iothread=1")
+	}
+	return options
+}
+
+// setDiskOptions sets the options of a disk device e.g. local-lvm:vm-100-disk-0,size=10G, keeping all other options.
+// It returns false if the device already has the options.
+func setDiskOptions(device string, options []string) (string, bool) {
+	parts := strings.Split(device, ",")
+	changed := false
+	for _, option := range options {
+		key, _, _ := strings.Cut(option, "=")
+		i := slices.IndexFunc(parts, func(part string) bool {
+			return strings.HasPrefix(part, key+"=")
+		})
+		switch {
+		case i < 0:
+			parts = append(parts, option)
+			changed = true
+		case parts[i] != option:
+			parts[i] = option
+			changed = true
+		}
+	}
+	return strings.Join(parts, ","), changed
+}
+
+// diskThrottleOptions are the options of a disk device managed by a DiskThrottle.
+var diskThrottleOptions = []string{"iops_rd", "iops_wr", "mbps_rd", "mbps_wr"}
+
+// formatDiskThrottle formats the IO limits of a disk in the order of diskThrottleOptions
+// example ['iops_rd=500', 'mbps_wr=100'].
+func formatDiskThrottle(throttle infrav1alpha1.DiskThrottle) []string {
+	var options []string
+	for i, limit := range []*int32{throttle.IOPSRead, throttle.IOPSWrite, throttle.MBpsRead, throttle.MBpsWrite} {
+		if limit != nil {
+			options = append(options, fmt.Sprintf("%s=%d", diskThrottleOptions[i], *limit))
+		}
+	}
+	return options
+}
+
+// applyDiskThrottle replaces the IO limits of a disk device e.g. local-lvm:vm-100-disk-0,iops_rd=100,size=10G.
+// It returns false if the device is already limited as requested.
+func applyDiskThrottle(device string, throttle infrav1alpha1.DiskThrottle) (string, bool) {
+	var options, current []string
+	for _, option := range strings.Split(device, ",") {
+		key, _, _ := strings.Cut(option, "=")
+		if slices.Contains(diskThrottleOptions, key) {
+			current = append(current, option)
+		} else {
+			options = append(options, option)
+		}
+	}
+
+	desired := formatDiskThrottle(throttle)
+	slices.Sort(current)
+	if slices.Equal(current, desired) {
+		return device, false
+	}
+	return strings.Join(append(options, desired...), ","), true
+}
+
+// formatEFIDisk formats the EFI disk of a VM, which Proxmox allocates in its fixed size
+// example 'local-lvm:1,efitype=4m,pre-enrolled-keys=1'.
+func formatEFIDisk(disk infrav1alpha1.EFIDisk) string {
+	volume := fmt.Sprintf("%s:1,efitype=4m", disk.Storage)
+	if disk.PreEnrolledKeys {
+		volume += ",pre-enrolled-keys=1"
+	}
+	return volume
+}
+
+// formatTPMState formats the TPM state device of a VM
+// example 'local-lvm:1,version=v2.0'.
+func formatTPMState(tpm infrav1alpha1.TPMSpec) string {
+	version := tpm.Version
+	if version == "" {
+		version = infrav1alpha1.TPMVersion20
+	}
+	return fmt.Sprintf("%s:1,version=v%s", tpm.StoragePool, version)
+}
+
+// formatStartup formats the startup option e.g. order=1,up=30,down=60.
+func formatStartup(startup infrav1alpha1.StartupSpec) string {
+	var values []string
+	if startup.Order != nil {
+		values = append(values, fmt.Sprintf("order=%d", *startup.Order))
+	}
+	if startup.Up != nil {
+		values = append(values, fmt.Sprintf("up=%d", *startup.Up))
+	}
+	if startup.Down != nil {
+		values = append(values, fmt.Sprintf("down=%d", *startup.Down))
+	}
+	return strings.Join(values, ",")
+}
+
+// pciDeviceName returns the device name of the PCI device at the given index.
+func pciDeviceName(index int) string {
+	return fmt.Sprintf("hostpci%d", index)
+}
+
+// stalePCIDevices returns the sorted names of the PCI devices of the VM config at an index of count or above.
+func stalePCIDevices(hostPCIs map[string]string, count int) []string {
+	var stale []string
+	for name := range hostPCIs {
+		if index, err := strconv.Atoi(strings.TrimPrefix(name, "hostpci")); err == nil && index >= count {
+			stale = append(stale, name)
+		}
+	}
+	slices.Sort(stale)
+	return stale
+}
+
+// formatPCIDevice formats a PCI passthrough device
+// example 'mapping=gpu,pcie=1,x-vga=1'.
+func formatPCIDevice(device infrav1alpha1.PCIDeviceSpec) string {
+	var components []string
+	if device.Mapping != nil {
+		components = append(components, fmt.Sprintf("mapping=%s", *device.Mapping))
+	} else if device.DeviceID != nil {
+		components = append(components, *device.DeviceID)
+	}
+
+	if device.PCIE {
+		components = append(components, "pcie=1")
+	}
+	if device.ROMBar != nil && !*device.ROMBar {
+		components = append(components, "rombar=0")
+	}
+	if device.PrimaryGPU {
+		components = append(components, "x-vga=1")
+	}
+
+	return strings.Join(components, ",")
+}
+
+const gib = int64(1) << 30
+
+// diskSizeRegex matches the size option of a disk device and its unit.
+var diskSizeRegex = regexp.MustCompile(`(?:^|,)size=(\d+)([KMGT]?)(?:,|$)`)
+
+// extractDiskSize returns the size in bytes out of a disk device input e.g. local-lvm:vm-100-disk-0,size=10G.
+func extractDiskSize(input string) (int64, bool) {
+	match := diskSizeRegex.FindStringSubmatch(input)
+	if len(match) != 3 {
+		return 0, false
+	}
+
+	size, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	switch match[2] {
+	case "K":
+		size <<= 10
+	case "M":
+		size <<= 20
+	case "G":
+		size <<= 30
+	case "T":
+		size <<= 40
+	}
+	return size, true
+}
+
+// extractCPUType returns the cpu type out of the cpu option e.g. x86-64-v2-AES,flags=+aes.
+func extractCPUType(input string) string {
+	cpuType, _, _ := strings.Cut(input, ",")
+	return strings.TrimPrefix(cpuType, "cputype=")
+}
+
+// extractVGAType extracts the display type from the vga option e.g. std,memory=32 or type=qxl.
+func extractVGAType(input string) string {
+	vgaType, _, _ := strings.Cut(input, ",")
+	return strings.TrimPrefix(vgaType, "type=")
+}
+
+// hotplugFeatures returns the features enabled by the hotplug option e.g. network,disk,usb.
+// Proxmox enables network, disk and usb hotplug if the option is empty or 1, and disables hotplug with 0.
+func hotplugFeatures(hotplug string) []string {
+	switch hotplug {
+	case "", "1":
+		return []string{"network", "disk", "usb"}
+	case "0":
+		return nil
+	}
+	return strings.Split(hotplug, ",")
+}
+
+// hotplugEnabled returns whether the hotplug option enables the feature.
+func hotplugEnabled(hotplug string, feature infrav1alpha1.HotplugFeature) bool {
+	return slices.Contains(hotplugFeatures(hotplug), string(feature))
+}
+
+// hotplugEquals returns whether the hotplug option enables exactly the features, in any order.
+func hotplugEquals(hotplug string, features []infrav1alpha1.HotplugFeature) bool {
+	current := hotplugFeatures(hotplug)
+	desired := strings.Split(formatHotplug(features), ",")
+	slices.Sort(current)
+	slices.Sort(desired)
+	return slices.Equal(current, desired)
+}
+
+// formatHotplug formats the hotplug option e.g. network,disk.
+func formatHotplug(features []infrav1alpha1.HotplugFeature) string {
+	values := make([]string, 0, len(features))
+	for _, feature := range features {
+		values = append(values, string(feature))
+	}
+	return strings.Join(values, ",")
+}
+
+// formatBootOrder formats the boot option e.g. order=scsi0;net0.
+func formatBootOrder(devices []string) string {
+	return "order=" + strings.Join(devices, ";")
+}
+
+// machineTypeOrDefault extracts the machine type from the machine option e.g. q35,viommu=intel.
+// An empty option stands for the default i440fx machine type pc.
+func machineTypeOrDefault(input string) string {
+	machineType, _, _ := strings.Cut(input, ",")
+	machineType = strings.TrimPrefix(machineType, "type=")
+	if machineType == "" {
+		return "pc"
+	}
+	return machineType
+}
+
+// isAgentEnabled returns whether the QEMU guest agent is enabled in the agent option e.g. 1,fstrim_cloned_disks=1 or enabled=1.
+func isAgentEnabled(input string) bool {
+	enabled, _, _ := strings.Cut(input, ",")
+	return strings.TrimPrefix(enabled, "enabled=") == "1"
+}
+
 // extractMACAddress returns the macaddress out of net device input e.g. virtio=A6:23:64:4D:84:CB,bridge=vmbr1.
 func extractMACAddress(input string) string {
 	re := regexp.MustCompile(`=([^,]+),bridge`)
@@ -209,3 +601,97 @@ func extractMACAddress(input string) string {
 	}
 	return ""
 }
+
+// renderDescription renders the description of the ProxmoxMachine as a template,
+// e.g. "managed by {{ .ClusterName }}". Unknown keys render empty, and the description
+// is used as is if it is no valid template.
+func renderDescription(machineScope *scope.MachineScope) string {
+	return renderTemplate(machineScope, "description", ptr.Deref(machineScope.ProxmoxMachine.Spec.Description, ""))
+}
+
+// snapshotNameRegex matches the characters not allowed in Proxmox snapshot names,
+// which have to start with a letter.
+var (
+	snapshotNameRegex      = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+	snapshotNameStartRegex = regexp.MustCompile(`^[A-Za-z]`)
+)
+
+// renderSnapshotName renders the name of the pre-delete snapshot of the ProxmoxMachine like the description.
+// Proxmox snapshot names start with a letter and consist of up to 40 letters, digits, dashes and underscores.
+func renderSnapshotName(machineScope *scope.MachineScope) string {
+	name := renderTemplate(machineScope, "snapshotName", ptr.Deref(machineScope.ProxmoxMachine.Spec.SnapshotName, infrav1alpha1.DefaultSnapshotName))
+	name = snapshotNameRegex.ReplaceAllString(name, "-")
+	if !snapshotNameStartRegex.MatchString(name) {
+		name = "s" + name
+	}
+	if len(name) > 40 {
+		name = name[:40]
+	}
+	return name
+}
+
+// vmNameRegex matches the characters not allowed in DNS labels, which Proxmox requires for VM names.
+var vmNameRegex = regexp.MustCompile(`[^A-Za-z0-9-]+`)
+
+// renderVMName renders the name of a new VM from the VMNameTemplate of the ProxmoxMachine or the cluster.
+// Invalid characters are replaced with dashes, and the name is cut to 63 characters without leading or trailing dashes.
+// The VM is named after the ProxmoxMachine if there is no template or it renders empty.
+func renderVMName(machineScope *scope.MachineScope) string {
+	text := machineScope.ProxmoxMachine.Spec.VMNameTemplate
+	if text == nil {
+		text = machineScope.InfraCluster.ProxmoxCluster.Spec.VMNameTemplate
+	}
+	if text == nil {
+		return machineScope.ProxmoxMachine.GetName()
+	}
+
+	name := vmNameRegex.ReplaceAllString(renderTemplate(machineScope, "vmName", *text), "-")
+	name = strings.Trim(name, "-")
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-")
+	}
+	if name == "" {
+		return machineScope.ProxmoxMachine.GetName()
+	}
+	return name
+}
+
+// vmName returns the name the VM of the ProxmoxMachine has in Proxmox.
+func vmName(machineScope *scope.MachineScope) string {
+	if name := machineScope.ProxmoxMachine.Status.VMName; name != "" {
+		return name
+	}
+	return machineScope.ProxmoxMachine.GetName()
+}
+
+// renderTemplate renders text as a template with the names of the ProxmoxMachine.
+func renderTemplate(machineScope *scope.MachineScope, name, text string) string {
+	tpl, err := template.New(name).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return text
+	}
+
+	data := map[string]string{
+		"ClusterName": machineScope.InfraCluster.Cluster.GetName(),
+		"Namespace":   machineScope.ProxmoxMachine.GetNamespace(),
+		"MachineName": machineScope.Machine.GetName(),
+		"Name":        machineScope.ProxmoxMachine.GetName(),
+		"Role":        machineScope.Role(),
+		"ShortUID":    shortUID(string(machineScope.ProxmoxMachine.GetUID())),
+	}
+
+	var b strings.Builder
+	if err := tpl.Execute(&b, data); err != nil {
+		return text
+	}
+
+	return b.String()
+}
+
+// shortUID returns the first 8 characters of the UID, which are hex digits for the UUIDs of the API server.
+func shortUID(uid string) string {
+	if len(uid) > 8 {
+		return uid[:8]
+	}
+	return uid
+}
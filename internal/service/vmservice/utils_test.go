@@ -218,6 +218,36 @@ func TestShouldUpdateNetworkDevices_NoUpdate(t *testing.T) {
 	require.False(t, shouldUpdateNetworkDevices(machineScope))
 }
 
+func TestShouldUpdateNetworkDevices_MACAddressChanged(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		Default: &infrav1alpha1.NetworkDevice{Bridge: "vmbr0", Model: ptr.To("virtio"), MACAddress: "02:00:00:00:00:01"},
+	}
+	machineScope.SetVirtualMachine(newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0"))
+
+	require.True(t, shouldUpdateNetworkDevices(machineScope))
+
+	// Proxmox reports MAC addresses in upper case.
+	machineScope.ProxmoxMachine.Spec.Network.Default.MACAddress = "a6:23:64:4d:84:cb"
+	require.False(t, shouldUpdateNetworkDevices(machineScope))
+}
+
+func TestShouldUpdateNetworkDevices_QueuesChanged(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		Default: &infrav1alpha1.NetworkDevice{Bridge: "vmbr0", Model: ptr.To("virtio"), Queues: ptr.To(uint8(4))},
+	}
+	machineScope.SetVirtualMachine(newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0,queues=2"))
+	require.True(t, shouldUpdateNetworkDevices(machineScope))
+
+	machineScope.SetVirtualMachine(newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0,queues=4"))
+	require.False(t, shouldUpdateNetworkDevices(machineScope))
+
+	// removing the queues from the spec resets the device to a single queue.
+	machineScope.ProxmoxMachine.Spec.Network.Default.Queues = nil
+	require.True(t, shouldUpdateNetworkDevices(machineScope))
+}
+
 func TestExtractNetworkVLAN(t *testing.T) {
 	type match struct {
 		test     string
@@ -259,3 +289,152 @@ func TestShouldUpdateNetworkDevices_VLANChanged(t *testing.T) {
 
 	require.True(t, shouldUpdateNetworkDevices(machineScope))
 }
+
+func TestFormatDiskVolume(t *testing.T) {
+	disk := infrav1alpha1.DiskSpec{SizeGB: 50, StoragePool: "local-lvm"}
+	require.Equal(t, "local-lvm:50", formatDiskVolume(disk))
+
+	disk.Format = ptr.To(infrav1alpha1.TargetStorageFormatRaw)
+	disk.Cache = infrav1alpha1.DiskCacheWriteBack
+	disk.Discard = true
+	disk.SSDEmulation = true
+	disk.Throttle = &infrav1alpha1.DiskThrottle{IOPSWrite: ptr.To(int32(200)), MBpsRead: ptr.To(int32(100))}
+	require.Equal(t, "local-lvm:50,format=raw,cache=writeback,discard=on,ssd=1,iops_wr=200,mbps_rd=100", formatDiskVolume(disk))
+}
+
+func TestSetDiskOptions(t *testing.T) {
+	options := formatDiskOptions(infrav1alpha1.DiskCacheWriteBack, true, false, false)
+
+	device, changed := setDiskOptions("local-lvm:vm-100-disk-0,cache=none,size=10G", options)
+	require.True(t, changed)
+	require.Equal(t, "local-lvm:vm-100-disk-0,cache=writeback,size=10G,discard=on", device)
+
+	_, changed = setDiskOptions(device, options)
+	require.False(t, changed)
+}
+
+func TestApplyDiskThrottle(t *testing.T) {
+	throttle := infrav1alpha1.DiskThrottle{IOPSRead: ptr.To(int32(500))}
+
+	device, changed := applyDiskThrottle("local-lvm:vm-100-disk-0,iothread=1,size=10G", throttle)
+	require.True(t, changed)
+	require.Equal(t, "local-lvm:vm-100-disk-0,iothread=1,size=10G,iops_rd=500", device)
+
+	_, changed = applyDiskThrottle(device, throttle)
+	require.False(t, changed)
+
+	// an empty throttle removes all limits.
+	device, changed = applyDiskThrottle("local-lvm:vm-100-disk-0,mbps_wr=10,size=10G", infrav1alpha1.DiskThrottle{})
+	require.True(t, changed)
+	require.Equal(t, "local-lvm:vm-100-disk-0,size=10G", device)
+}
+
+func TestNetworkRate(t *testing.T) {
+	require.Equal(t, "12.5", extractNetworkRate("virtio=A6:23:64:4D:84:CB,bridge=vmbr1,rate=12.5"))
+	require.Equal(t, "", extractNetworkRate("virtio=A6:23:64:4D:84:CB,bridge=vmbr1"))
+
+	require.True(t, networkRateEquals("", nil))
+	require.True(t, networkRateEquals("100", ptr.To("100.0")))
+	require.False(t, networkRateEquals("100", nil))
+	require.False(t, networkRateEquals("", ptr.To("100")))
+
+	require.Equal(t, "virtio=A6:23:64:4D:84:CB,bridge=vmbr1,rate=5", setNetworkRate("virtio=A6:23:64:4D:84:CB,rate=12.5,bridge=vmbr1", ptr.To("5")))
+	require.Equal(t, "virtio=A6:23:64:4D:84:CB,bridge=vmbr1", setNetworkRate("virtio=A6:23:64:4D:84:CB,bridge=vmbr1,rate=12.5", nil))
+}
+
+func TestFormatNetworkDevice(t *testing.T) {
+	require.Equal(t, "virtio,bridge=vmbr0", formatNetworkDevice(infrav1alpha1.NetworkDevice{Model: ptr.To("virtio"), Bridge: "vmbr0"}))
+	require.Equal(t, "virtio,bridge=vmbr0,tag=100", formatNetworkDevice(infrav1alpha1.NetworkDevice{Model: ptr.To("virtio"), Bridge: "vmbr0", VLAN: ptr.To(uint16(100))}))
+	require.Equal(t, "e1000,bridge=vmbr1,mtu=9000,tag=4094", formatNetworkDevice(infrav1alpha1.NetworkDevice{Model: ptr.To("e1000"), Bridge: "vmbr1", MTU: ptr.To(uint16(9000)), VLAN: ptr.To(uint16(4094))}))
+	require.Equal(t, "virtio,bridge=vmbr0,rate=12.5", formatNetworkDevice(infrav1alpha1.NetworkDevice{Model: ptr.To("virtio"), Bridge: "vmbr0", RateLimitMBps: ptr.To("12.5")}))
+	require.Equal(t, "virtio,bridge=vmbr0,firewall=1,tag=100", formatNetworkDevice(infrav1alpha1.NetworkDevice{Model: ptr.To("virtio"), Bridge: "vmbr0", Firewall: true, VLAN: ptr.To(uint16(100))}))
+	require.Equal(t, "virtio,bridge=vmbr0,queues=8", formatNetworkDevice(infrav1alpha1.NetworkDevice{Model: ptr.To("virtio"), Bridge: "vmbr0", Queues: ptr.To(uint8(8))}))
+	require.Equal(t, "e1000=02:00:00:00:00:01,bridge=vmbr0", formatNetworkDevice(infrav1alpha1.NetworkDevice{Model: ptr.To("e1000"), Bridge: "vmbr0", MACAddress: "02:00:00:00:00:01"}))
+}
+
+func TestExtractDiskSize(t *testing.T) {
+	size, ok := extractDiskSize("local-lvm:vm-100-disk-0,size=10G")
+	require.True(t, ok)
+	require.Equal(t, 10*gib, size)
+
+	size, ok = extractDiskSize("local-lvm:vm-100-disk-0,size=512M,ssd=1")
+	require.True(t, ok)
+	require.Equal(t, int64(512)<<20, size)
+
+	_, ok = extractDiskSize("local-lvm:vm-100-disk-0,ssd=1")
+	require.False(t, ok)
+
+	_, ok = extractDiskSize("")
+	require.False(t, ok)
+}
+
+func TestIsAgentEnabled(t *testing.T) {
+	require.True(t, isAgentEnabled("1"))
+	require.True(t, isAgentEnabled("1,fstrim_cloned_disks=1"))
+	require.True(t, isAgentEnabled("enabled=1,type=virtio"))
+	require.False(t, isAgentEnabled("0"))
+	require.False(t, isAgentEnabled("enabled=0"))
+	require.False(t, isAgentEnabled(""))
+}
+
+func TestExtractVGAType(t *testing.T) {
+	require.Equal(t, "", extractVGAType(""))
+	require.Equal(t, "std", extractVGAType("std,memory=32"))
+	require.Equal(t, "qxl", extractVGAType("type=qxl"))
+}
+
+func TestHotplug(t *testing.T) {
+	require.True(t, hotplugEnabled("", infrav1alpha1.HotplugNetwork))
+	require.True(t, hotplugEnabled("1", infrav1alpha1.HotplugUSB))
+	require.False(t, hotplugEnabled("0", infrav1alpha1.HotplugNetwork))
+	require.False(t, hotplugEnabled("disk,usb", infrav1alpha1.HotplugNetwork))
+
+	features := []infrav1alpha1.HotplugFeature{infrav1alpha1.HotplugNetwork, infrav1alpha1.HotplugDisk, infrav1alpha1.HotplugUSB}
+	require.Equal(t, "network,disk,usb", formatHotplug(features))
+	require.True(t, hotplugEquals("", features))
+	require.True(t, hotplugEquals("usb,disk,network", features))
+	require.False(t, hotplugEquals("network,disk", features))
+}
+
+func TestMachineTypeOrDefault(t *testing.T) {
+	require.Equal(t, "pc", machineTypeOrDefault(""))
+	require.Equal(t, "q35", machineTypeOrDefault("q35"))
+	require.Equal(t, "pc-q35-8.1", machineTypeOrDefault("pc-q35-8.1,viommu=intel"))
+	require.Equal(t, "q35", machineTypeOrDefault("type=q35"))
+}
+
+func TestRenderVMName(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.UID = "3f2a9c1e-5b7d-4e8f-9a0b-1c2d3e4f5a6b"
+	require.Equal(t, "test", renderVMName(machineScope))
+
+	machineScope.InfraCluster.ProxmoxCluster.Spec.VMNameTemplate = ptr.To("{{ .ClusterName }}-{{ .Role }}-{{ .ShortUID }}")
+	require.Equal(t, "test-node-3f2a9c1e", renderVMName(machineScope))
+
+	// the template of the machine overrides the one of the cluster.
+	machineScope.ProxmoxMachine.Spec.VMNameTemplate = ptr.To("{{ .Namespace }}/{{ .Name }}")
+	require.Equal(t, "default-test", renderVMName(machineScope))
+
+	// invalid characters are replaced, and the name is cut to 63 characters.
+	machineScope.ProxmoxMachine.Spec.VMNameTemplate = ptr.To("_{{ .Name }}.with.a.name.much.longer.than.sixty.three.characters-in.total")
+	require.Equal(t, "test-with-a-name-much-longer-than-sixty-three-characters-in-tot", renderVMName(machineScope))
+
+	// names rendering empty fall back to the name of the machine.
+	machineScope.ProxmoxMachine.Spec.VMNameTemplate = ptr.To("{{ .Unknown }}")
+	require.Equal(t, "test", renderVMName(machineScope))
+}
+
+func TestRenderDescription(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+
+	machineScope.ProxmoxMachine.Spec.Description = ptr.To("{{ .Namespace }}/{{ .Name }} of cluster {{ .ClusterName }} (machine {{ .MachineName }})")
+	require.Equal(t, "default/test of cluster test (machine test)", renderDescription(machineScope))
+
+	// unknown keys render empty.
+	machineScope.ProxmoxMachine.Spec.Description = ptr.To("owner: {{ .Owner }}")
+	require.Equal(t, "owner: ", renderDescription(machineScope))
+
+	// invalid templates are used as is.
+	machineScope.ProxmoxMachine.Spec.Description = ptr.To("test {{ vm")
+	require.Equal(t, "test {{ vm", renderDescription(machineScope))
+}
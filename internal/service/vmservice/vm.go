@@ -19,7 +19,10 @@ package vmservice
 
 import (
 	"context"
+	"net/netip"
 	"slices"
+	"strings"
+	"unicode"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
@@ -33,6 +36,7 @@ import (
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/inject"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/service/scheduler"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/service/taskservice"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/cloudinit"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/goproxmox"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
@@ -42,14 +46,54 @@ const (
 	// See the following link for a list of available config options:
 	// https://pve.proxmox.com/pve-docs/api-viewer/index.html#/nodes/{node}/qemu/{vmid}/config
 
-	optionSockets = "sockets"
-	optionCores   = "cores"
-	optionMemory  = "memory"
+	optionName        = "name"
+	optionSockets     = "sockets"
+	optionCores       = "cores"
+	optionMemory      = "memory"
+	optionBalloon     = "balloon"
+	optionHugepages   = "hugepages"
+	optionCPU         = "cpu"
+	optionNUMA        = "numa"
+	optionAgent       = "agent"
+	optionTags        = "tags"
+	optionDescription = "description"
+	optionBIOS        = "bios"
+	optionEFIDisk     = "efidisk0"
+	optionMachine     = "machine"
+	optionSCSIHW      = "scsihw"
+	optionSerial0     = "serial0"
+	optionVGA         = "vga"
+	optionHotplug     = "hotplug"
+	optionTPMState    = "tpmstate0"
+	optionCDROM       = "ide2"
+	optionBoot        = "boot"
+	optionOnBoot      = "onboot"
+	optionStartup     = "startup"
+	optionProtection  = "protection"
+	optionDelete      = "delete"
 )
 
+// tagSeparator separates the tags in the VM config. Proxmox also accepts commas and spaces.
+const tagSeparator = ";"
+
+// vmIDAllocationAttempts is the number of attempts to clone a VM, in case other processes take the allocated VMID.
+const vmIDAllocationAttempts = 3
+
 // ErrNoVMIDInRangeFree is returned if no free VMID is found in the specified vmIDRange.
 var ErrNoVMIDInRangeFree = errors.New("No free vmid found in vmIDRange")
 
+// ErrAdditionalVolumeSlotInUse is returned if the template already uses a slot of the additional volumes.
+var ErrAdditionalVolumeSlotInUse = errors.New("additional volume slot is already in use")
+
+// ErrPoolNotFound is returned if the resource pool the VM should be added to does not exist.
+var ErrPoolNotFound = errors.New("resource pool does not exist")
+
+// ErrISONotFound is returned if the ISO image for the CD-ROM drive does not exist on the node of the VM.
+var ErrISONotFound = errors.New("iso image does not exist")
+
+// ErrBootDeviceNotFound is returned if the boot order references a device the VM does not have.
+var ErrBootDeviceNotFound = errors.New("boot device does not exist")
+
 // ReconcileVM makes sure that the VM is in the desired state by:
 //  1. Creating the VM if it does not exist, then...
 //  2. Updating the VM with the bootstrap data, such as the cloud-init meta and user data, before...
@@ -68,18 +112,66 @@ func ReconcileVM(ctx context.Context, scope *scope.MachineScope) (infrav1alpha1.
 		return vm, err
 	}
 
+	if err := updateMigratedVMLocation(ctx, scope); err != nil {
+		return vm, err
+	}
+
 	if requeue, err := ensureVirtualMachine(ctx, scope); err != nil || requeue {
 		return vm, err
 	}
 
+	if requeue, err := reconcileMigration(ctx, scope); err != nil || requeue {
+		return vm, err
+	}
+
 	if requeue, err := reconcileVirtualMachineConfig(ctx, scope); err != nil || requeue {
 		return vm, err
 	}
 
+	if requeue, err := reconcileResize(ctx, scope); err != nil || requeue {
+		return vm, err
+	}
+
+	if requeue, err := reconcileTags(ctx, scope); err != nil || requeue {
+		return vm, err
+	}
+
+	if requeue, err := reconcileDescription(ctx, scope); err != nil || requeue {
+		return vm, err
+	}
+
+	if requeue, err := reconcileStartOnBoot(ctx, scope); err != nil || requeue {
+		return vm, err
+	}
+
+	if requeue, err := reconcileProtection(ctx, scope); err != nil || requeue {
+		return vm, err
+	}
+
+	if requeue, err := reconcileCDROM(ctx, scope); err != nil || requeue {
+		return vm, err
+	}
+
+	if requeue, err := reconcileNetworkHotplug(ctx, scope); err != nil || requeue {
+		return vm, err
+	}
+
+	if requeue, err := reconcileNetworkRates(ctx, scope); err != nil || requeue {
+		return vm, err
+	}
+
+	if err := reconcileFirewall(ctx, scope); err != nil {
+		return vm, err
+	}
+
 	if err := reconcileDisks(ctx, scope); err != nil {
 		return vm, err
 	}
 
+	if requeue, err := reconcileDiskThrottle(ctx, scope); err != nil || requeue {
+		return vm, err
+	}
+
 	if requeue, err := reconcileIPAddresses(ctx, scope); err != nil || requeue {
 		return vm, err
 	}
@@ -92,12 +184,15 @@ func ReconcileVM(ctx context.Context, scope *scope.MachineScope) (infrav1alpha1.
 		return vm, err
 	}
 
-	if err := reconcileMachineAddresses(scope); err != nil {
-		return vm, err
-	}
+	// a powered off VM keeps its addresses, and has no guest to check.
+	if !poweredOff(scope) {
+		if requeue, err := reconcileMachineAddresses(ctx, scope); err != nil || requeue {
+			return vm, err
+		}
 
-	if requeue, err := checkCloudInitStatus(ctx, scope); err != nil || requeue {
-		return vm, err
+		if requeue, err := checkCloudInitStatus(ctx, scope); err != nil || requeue {
+			return vm, err
+		}
 	}
 
 	// if the root machine is ready, we can assume that the VM is ready as well.
@@ -120,11 +215,14 @@ func checkCloudInitStatus(ctx context.Context, machineScope *scope.MachineScope)
 
 	if !machineScope.SkipQemuGuestCheck() {
 		if err := machineScope.InfraCluster.ProxmoxClient.QemuAgentStatus(ctx, machineScope.VirtualMachine); err != nil {
+			conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.GuestAgentReadyCondition, infrav1alpha1.WaitingForGuestAgentReason, clusterv1.ConditionSeverityInfo, err.Error())
 			return true, errors.Wrap(err, "error waiting for agent")
 		}
+		conditions.MarkTrue(machineScope.ProxmoxMachine, infrav1alpha1.GuestAgentReadyCondition)
 	}
 
-	if !machineScope.SkipCloudInitCheck() {
+	// adopted VMs were not bootstrapped with cloud-init by the provider.
+	if !machineScope.SkipCloudInitCheck() && !machineScope.ProxmoxMachine.Status.Adopted {
 		if running, err := machineScope.InfraCluster.ProxmoxClient.CloudInitStatus(ctx, machineScope.VirtualMachine); err != nil || running {
 			if running {
 				return true, nil
@@ -152,6 +250,11 @@ func ensureVirtualMachine(ctx context.Context, machineScope *scope.MachineScope)
 	if err != nil {
 		switch {
 		case errors.Is(err, ErrVMNotFound):
+			if cloneIncomplete(machineScope) {
+				if retry, err := retryMissingClone(ctx, machineScope); err != nil || retry {
+					return true, err
+				}
+			}
 			if err := updateVMLocation(ctx, machineScope); err != nil {
 				return false, errors.Wrap(err, "error trying to locate vm")
 			}
@@ -159,11 +262,19 @@ func ensureVirtualMachine(ctx context.Context, machineScope *scope.MachineScope)
 			// we always want to trigger reconciliation at this point.
 			return false, err
 		case errors.Is(err, ErrVMNotInitialized):
+			// the adopted VM was not renamed yet.
+			if machineScope.ProxmoxMachine.Status.Adopted {
+				return adoptVM(ctx, machineScope)
+			}
 			return true, err
 		case !errors.Is(err, ErrVMNotCreated):
 			return false, err
 		}
 
+		if machineScope.ProxmoxMachine.Spec.ExistingVMID != nil {
+			return adoptVM(ctx, machineScope)
+		}
+
 		// Otherwise, this is a new machine and the VM should be created.
 		// NOTE: We are setting this condition only in case it does not exist, so we avoid to get flickering LastConditionTime
 		// in case of cloning errors or powering on errors.
@@ -174,9 +285,15 @@ func ensureVirtualMachine(ctx context.Context, machineScope *scope.MachineScope)
 		// Create the VM.
 		resp, err := createVM(ctx, machineScope)
 		if err != nil {
-			conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.CloningFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+			reason := infrav1alpha1.CloningFailedReason
+			if errors.Is(err, ErrInsufficientStorage) {
+				reason = infrav1alpha1.InsufficientStorageReason
+			}
+			conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, reason, clusterv1.ConditionSeverityWarning, err.Error())
+			conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMClonedCondition, reason, clusterv1.ConditionSeverityWarning, err.Error())
 			return false, err
 		}
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMClonedCondition, infrav1alpha1.CloningReason, clusterv1.ConditionSeverityInfo, "")
 		machineScope.Logger.V(4).Info("Task created", "taskID", resp.Task.ID)
 
 		// make sure spec.VirtualMachineID is always set.
@@ -186,16 +303,316 @@ func ensureVirtualMachine(ctx context.Context, machineScope *scope.MachineScope)
 		return true, nil
 	}
 
+	// the clone task ended without releasing the VM.
+	if cloneIncomplete(machineScope) && vmRef.Lock == cloneLock {
+		return cleanupIncompleteClone(ctx, machineScope, vmRef)
+	}
+
 	// make sure spec.providerID is always set.
 	biosUUID := extractUUID(vmRef.VirtualMachineConfig.SMBios1)
 	machineScope.SetProviderID(biosUUID)
 
+	// make sure status.vmID is set for machines created before it existed.
+	machineScope.ProxmoxMachine.Status.VMID = ptr.To(machineScope.GetVirtualMachineID())
+
 	// setting the VirtualMachine object for completing the reconciliation.
 	machineScope.SetVirtualMachine(vmRef)
+	conditions.MarkTrue(machineScope.ProxmoxMachine, infrav1alpha1.VMClonedCondition)
+	machineScope.ProxmoxMachine.Status.PowerState = powerState(vmRef)
 
 	return false, nil
 }
 
+// reconcileTags adds the tags of the ProxmoxMachine and the tag of the owning cluster to the VM.
+// Tags are only ever added, so tags set outside of the provider are kept.
+func reconcileTags(ctx context.Context, machineScope *scope.MachineScope) (requeue bool, err error) {
+	tags := splitTags(machineScope.VirtualMachine.VirtualMachineConfig.Tags)
+
+	var changed bool
+	for _, tag := range append([]string{clusterTag(machineScope)}, machineScope.ProxmoxMachine.Spec.Tags...) {
+		// Proxmox compares tags case-insensitively by default.
+		if !slices.ContainsFunc(tags, func(t string) bool { return strings.EqualFold(t, tag) }) {
+			tags = append(tags, tag)
+			changed = true
+		}
+	}
+
+	if !changed {
+		return false, nil
+	}
+
+	machineScope.V(4).Info("reconciling virtual machine tags", "tags", tags)
+
+	task, err := machineScope.InfraCluster.ProxmoxClient.ConfigureVM(ctx, machineScope.VirtualMachine, proxmox.VirtualMachineOption{
+		Name:  optionTags,
+		Value: strings.Join(tags, tagSeparator),
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to tag VM %s", machineScope.Name())
+	}
+
+	machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(task.UPID))
+	return true, nil
+}
+
+// reconcileDescription keeps the description of the VM in line with the rendered description of the ProxmoxMachine.
+// The description of the VM is left alone if the ProxmoxMachine does not define one.
+func reconcileDescription(ctx context.Context, machineScope *scope.MachineScope) (requeue bool, err error) {
+	if machineScope.ProxmoxMachine.Spec.Description == nil {
+		return false, nil
+	}
+
+	// Proxmox may add a trailing newline to the stored description.
+	description := renderDescription(machineScope)
+	if strings.TrimSpace(machineScope.VirtualMachine.VirtualMachineConfig.Description) == strings.TrimSpace(description) {
+		return false, nil
+	}
+
+	machineScope.V(4).Info("reconciling virtual machine description")
+
+	task, err := machineScope.InfraCluster.ProxmoxClient.ConfigureVM(ctx, machineScope.VirtualMachine, proxmox.VirtualMachineOption{
+		Name:  optionDescription,
+		Value: description,
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to update description of VM %s", machineScope.Name())
+	}
+
+	machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(task.UPID))
+	return true, nil
+}
+
+// reconcileStartOnBoot makes the Proxmox node start the VM when it boots, unless the ProxmoxMachine disables it.
+// The startup option is only read from the VM, if the ProxmoxMachine sets one.
+func reconcileStartOnBoot(ctx context.Context, machineScope *scope.MachineScope) (requeue bool, err error) {
+	var vmOptions []proxmox.VirtualMachineOption
+	if onBoot := ptr.Deref(machineScope.ProxmoxMachine.Spec.OnBoot, true); onBoot != (machineScope.VirtualMachine.VirtualMachineConfig.OnBoot == 1) {
+		value := 0
+		if onBoot {
+			value = 1
+		}
+		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionOnBoot, Value: value})
+	}
+
+	if startup := machineScope.ProxmoxMachine.Spec.Startup; startup != nil {
+		current, err := machineScope.InfraCluster.ProxmoxClient.GetVMStartup(ctx, machineScope.VirtualMachine)
+		if err != nil {
+			return false, errors.Wrapf(err, "unable to get startup option of VM %s", machineScope.Name())
+		}
+		if value := formatStartup(*startup); current != value {
+			vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionStartup, Value: value})
+		}
+	}
+
+	if len(vmOptions) == 0 {
+		return false, nil
+	}
+
+	machineScope.V(4).Info("reconciling virtual machine start on boot")
+
+	task, err := machineScope.InfraCluster.ProxmoxClient.ConfigureVM(ctx, machineScope.VirtualMachine, vmOptions...)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to update start on boot of VM %s", machineScope.Name())
+	}
+
+	machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(task.UPID))
+	return true, nil
+}
+
+// reconcileProtection sets the protection flag of the VM to the one of the ProxmoxMachine.
+func reconcileProtection(ctx context.Context, machineScope *scope.MachineScope) (requeue bool, err error) {
+	protection := 0
+	if machineScope.ProxmoxMachine.Spec.Protected {
+		protection = 1
+	}
+	if machineScope.VirtualMachine.VirtualMachineConfig.Protection == protection {
+		return false, nil
+	}
+
+	machineScope.V(4).Info("reconciling virtual machine protection", "protection", protection)
+
+	task, err := machineScope.InfraCluster.ProxmoxClient.ConfigureVM(ctx, machineScope.VirtualMachine, proxmox.VirtualMachineOption{
+		Name:  optionProtection,
+		Value: protection,
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to set protection of VM %s", machineScope.Name())
+	}
+
+	machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(task.UPID))
+	return true, nil
+}
+
+// reconcileCDROM puts the volume of the ProxmoxMachine into the CD-ROM drive of the VM.
+// ISO images are checked for existence on the node first.
+func reconcileCDROM(ctx context.Context, machineScope *scope.MachineScope) (requeue bool, err error) {
+	cdrom := machineScope.ProxmoxMachine.Spec.CDROM
+	if cdrom == nil || cdromContains(machineScope.VirtualMachine.VirtualMachineConfig.IDE2, cdrom.ISO) {
+		return false, nil
+	}
+
+	if strings.Contains(cdrom.ISO, ":iso/") {
+		node := machineScope.VirtualMachine.Node
+		exists, err := machineScope.InfraCluster.ProxmoxClient.ISOExists(ctx, node, cdrom.ISO)
+		if err != nil {
+			return false, errors.Wrapf(err, "unable to check iso image %q", cdrom.ISO)
+		}
+		if !exists {
+			return false, errors.Wrapf(ErrISONotFound, "iso %q on node %s", cdrom.ISO, node)
+		}
+	}
+
+	machineScope.V(4).Info("reconciling virtual machine cdrom", "iso", cdrom.ISO)
+
+	task, err := machineScope.InfraCluster.ProxmoxClient.ConfigureVM(ctx, machineScope.VirtualMachine, proxmox.VirtualMachineOption{
+		Name:  optionCDROM,
+		Value: cdrom.ISO + ",media=cdrom",
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to configure cdrom of VM %s", machineScope.Name())
+	}
+
+	machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(task.UPID))
+	return true, nil
+}
+
+// reconcileNetworkHotplug adds the additional network devices missing on a running VM.
+// With network hotplug, Proxmox attaches them right away. Otherwise the devices stay pending until the VM reboots,
+// which is surfaced in the NetworkDevices condition instead of rebooting the node behind the back of Cluster API.
+func reconcileNetworkHotplug(ctx context.Context, machineScope *scope.MachineScope) (requeue bool, err error) {
+	network := machineScope.ProxmoxMachine.Spec.Network
+	if network == nil || !machineScope.VirtualMachine.IsRunning() {
+		return false, nil
+	}
+
+	vmConfig := machineScope.VirtualMachine.VirtualMachineConfig
+	nets := vmConfig.MergeNets()
+	var vmOptions []proxmox.VirtualMachineOption
+	var names []string
+	for _, device := range network.AdditionalDevices {
+		if nets[device.Name] == "" {
+			vmOptions = append(vmOptions, proxmox.VirtualMachineOption{
+				Name:  device.Name,
+				Value: formatNetworkDevice(device.NetworkDevice),
+			})
+			names = append(names, device.Name)
+		}
+	}
+
+	if len(vmOptions) == 0 {
+		if conditions.Has(machineScope.ProxmoxMachine, infrav1alpha1.NetworkDevicesCondition) {
+			conditions.MarkTrue(machineScope.ProxmoxMachine, infrav1alpha1.NetworkDevicesCondition)
+		}
+		return false, nil
+	}
+
+	hotplug := hotplugEnabled(vmConfig.Hotplug, infrav1alpha1.HotplugNetwork)
+	if !hotplug && conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.NetworkDevicesCondition) == infrav1alpha1.RebootRequiredReason {
+		// the devices are pending already.
+		return false, nil
+	}
+
+	machineScope.V(4).Info("adding network devices to running virtual machine", "devices", names, "hotplug", hotplug)
+
+	task, err := machineScope.InfraCluster.ProxmoxClient.ConfigureVM(ctx, machineScope.VirtualMachine, vmOptions...)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to add network devices to VM %s", machineScope.Name())
+	}
+
+	if !hotplug {
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.NetworkDevicesCondition, infrav1alpha1.RebootRequiredReason, clusterv1.ConditionSeverityWarning,
+			"network hotplug is disabled, devices %s are attached on the next reboot", strings.Join(names, ", "))
+	}
+
+	machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(task.UPID))
+	return true, nil
+}
+
+// reconcileNetworkRates applies the rate limits of the network devices.
+// Proxmox changes the limits of running VMs right away. The devices keep their MAC addresses.
+func reconcileNetworkRates(ctx context.Context, machineScope *scope.MachineScope) (requeue bool, err error) {
+	network := machineScope.ProxmoxMachine.Spec.Network
+	if network == nil {
+		return false, nil
+	}
+
+	devices := make(map[string]infrav1alpha1.NetworkDevice, len(network.AdditionalDevices)+1)
+	names := make([]string, 0, len(network.AdditionalDevices)+1)
+	if network.Default != nil {
+		devices[infrav1alpha1.DefaultNetworkDevice] = *network.Default
+		names = append(names, infrav1alpha1.DefaultNetworkDevice)
+	}
+	for _, device := range network.AdditionalDevices {
+		devices[device.Name] = device.NetworkDevice
+		names = append(names, device.Name)
+	}
+
+	// devices not created yet are skipped.
+	nets := machineScope.VirtualMachine.VirtualMachineConfig.MergeNets()
+	var vmOptions []proxmox.VirtualMachineOption
+	for _, name := range names {
+		net := nets[name]
+		rate := devices[name].RateLimitMBps
+		if net == "" || networkRateEquals(extractNetworkRate(net), rate) {
+			continue
+		}
+		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: name, Value: setNetworkRate(net, rate)})
+	}
+
+	if len(vmOptions) == 0 {
+		return false, nil
+	}
+
+	machineScope.V(4).Info("reconciling virtual machine network rate limits")
+
+	task, err := machineScope.InfraCluster.ProxmoxClient.ConfigureVM(ctx, machineScope.VirtualMachine, vmOptions...)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to configure network rate limits of VM %s", machineScope.Name())
+	}
+
+	machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(task.UPID))
+	return true, nil
+}
+
+// cdromContains checks whether the drive, e.g. 'local:iso/drivers.iso,media=cdrom', contains the volume.
+// Proxmox replaces the cloud-init drive 'local-lvm:cloudinit' with the allocated volume 'local-lvm:vm-100-cloudinit'.
+func cdromContains(drive, volume string) bool {
+	current, _, _ := strings.Cut(drive, ",")
+	if storage, ok := strings.CutSuffix(volume, ":cloudinit"); ok {
+		return strings.HasPrefix(current, storage+":") && strings.Contains(current, "cloudinit")
+	}
+	return current == volume
+}
+
+// serialConsoleEnabled returns whether the VM gets a serial console.
+// Unless configured otherwise, machines bootstrapped with cloud-config get one, as cloud images log to ttyS0.
+func serialConsoleEnabled(ctx context.Context, machineScope *scope.MachineScope) (bool, error) {
+	if value := machineScope.ProxmoxMachine.Spec.SerialConsole; value != nil {
+		return *value, nil
+	}
+	if machineScope.Machine.Spec.Bootstrap.DataSecretName == nil {
+		return false, nil
+	}
+
+	_, format, err := getBootstrapData(ctx, machineScope)
+	if err != nil {
+		return false, err
+	}
+	return ptr.Deref(format, "") == cloudinit.FormatCloudConfig, nil
+}
+
+// clusterTag returns the tag identifying the cluster owning the VM.
+func clusterTag(machineScope *scope.MachineScope) string {
+	return "cluster_" + machineScope.InfraCluster.Cluster.GetName()
+}
+
+// splitTags splits the tags of a VM config, which may be separated by semicolons, commas or spaces.
+func splitTags(tags string) []string {
+	return strings.FieldsFunc(tags, func(r rune) bool {
+		return r == ';' || r == ',' || unicode.IsSpace(r)
+	})
+}
+
 func reconcileDisks(ctx context.Context, machineScope *scope.MachineScope) error {
 	machineScope.V(4).Info("reconciling disks")
 	disks := machineScope.ProxmoxMachine.Spec.Disks
@@ -211,6 +628,22 @@ func reconcileDisks(ctx context.Context, machineScope *scope.MachineScope) error
 	}
 
 	if bv := disks.BootVolume; bv != nil {
+		// Proxmox can only grow disks. Check the size of the cloned disk first, so that
+		// a too small request surfaces as a configuration error.
+		if current, ok := extractDiskSize(vm.VirtualMachineConfig.MergeDisks()[bv.Disk]); ok {
+			requested := int64(bv.SizeGB) * gib
+			if requested < current {
+				err := errors.Errorf("requested size %s of disk %s is smaller than the current size %dG", bv.FormatSize(), bv.Disk, current/gib)
+				conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.VMProvisionFailedReason, clusterv1.ConditionSeverityError, err.Error())
+				machineScope.SetFailureMessage(err)
+				machineScope.SetFailureReason(capierrors.InvalidConfigurationMachineError)
+				return err
+			}
+			if requested == current {
+				return nil
+			}
+		}
+
 		if err := machineScope.InfraCluster.ProxmoxClient.ResizeDisk(ctx, vm, bv.Disk, bv.FormatSize()); err != nil {
 			machineScope.Error(err, "unable to set disk size", "vm", machineScope.VirtualMachine.VMID)
 			return err
@@ -220,6 +653,53 @@ func reconcileDisks(ctx context.Context, machineScope *scope.MachineScope) error
 	return nil
 }
 
+// reconcileDiskThrottle applies the IO limits of the boot volume and the additional volumes.
+// Proxmox updates the limits of running VMs without detaching the disks.
+func reconcileDiskThrottle(ctx context.Context, machineScope *scope.MachineScope) (requeue bool, err error) {
+	disks := machineScope.ProxmoxMachine.Spec.Disks
+	if disks == nil {
+		return false, nil
+	}
+
+	throttles := make(map[string]*infrav1alpha1.DiskThrottle)
+	devices := make([]string, 0, len(disks.AdditionalVolumes)+1)
+	if bv := disks.BootVolume; bv != nil {
+		throttles[bv.Disk] = bv.Throttle
+		devices = append(devices, bv.Disk)
+	}
+	for i, disk := range disks.AdditionalVolumes {
+		throttles[additionalVolumeDevice(i)] = disk.Throttle
+		devices = append(devices, additionalVolumeDevice(i))
+	}
+
+	// disks without a throttle keep their limits, disks not created yet are skipped.
+	current := machineScope.VirtualMachine.VirtualMachineConfig.MergeDisks()
+	var vmOptions []proxmox.VirtualMachineOption
+	for _, device := range devices {
+		value, exists := current[device]
+		if throttles[device] == nil || !exists {
+			continue
+		}
+		if value, changed := applyDiskThrottle(value, *throttles[device]); changed {
+			vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: device, Value: value})
+		}
+	}
+
+	if len(vmOptions) == 0 {
+		return false, nil
+	}
+
+	machineScope.V(4).Info("reconciling virtual machine disk throttle")
+
+	task, err := machineScope.InfraCluster.ProxmoxClient.ConfigureVM(ctx, machineScope.VirtualMachine, vmOptions...)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to configure disk throttle of VM %s", machineScope.Name())
+	}
+
+	machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(task.UPID))
+	return true, nil
+}
+
 func reconcileVirtualMachineConfig(ctx context.Context, machineScope *scope.MachineScope) (requeue bool, err error) {
 	if machineScope.VirtualMachine.IsRunning() || machineScope.ProxmoxMachine.Status.Ready {
 		// We only want to do this before the machine was started or is ready
@@ -236,21 +716,73 @@ func reconcileVirtualMachineConfig(ctx context.Context, machineScope *scope.Mach
 	if value := machineScope.ProxmoxMachine.Spec.NumCores; value > 0 && vmConfig.Cores != int(value) {
 		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionCores, Value: value})
 	}
+	if value := machineScope.ProxmoxMachine.Spec.CPUType; value != "" && extractCPUType(vmConfig.CPU) != value {
+		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionCPU, Value: value})
+	}
+	if machineScope.ProxmoxMachine.Spec.NUMA && vmConfig.Numa != 1 {
+		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionNUMA, Value: 1})
+	}
 	if value := machineScope.ProxmoxMachine.Spec.MemoryMiB; value > 0 && int32(vmConfig.Memory) != value {
 		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionMemory, Value: value})
 	}
+	if value := ptr.Deref(machineScope.ProxmoxMachine.Spec.MinMemoryMiB, 0); vmConfig.Balloon != int(value) {
+		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionBalloon, Value: value})
+	}
+	if value := machineScope.ProxmoxMachine.Spec.Hugepages; value != "" && vmConfig.Hugepages != string(value) {
+		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionHugepages, Value: string(value)})
+	}
+	if machineScope.ProxmoxMachine.Spec.EnableGuestAgent && !isAgentEnabled(vmConfig.Agent) {
+		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionAgent, Value: 1})
+	}
+
+	// Firmware, the EFI disk is only ever created once.
+	if value := machineScope.ProxmoxMachine.Spec.BIOS; value != "" && vmConfig.Bios != string(value) {
+		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionBIOS, Value: string(value)})
+	}
+	if efiDisk := machineScope.ProxmoxMachine.Spec.EFIDisk; machineScope.ProxmoxMachine.Spec.BIOS == infrav1alpha1.BIOSOVMF && efiDisk != nil && vmConfig.EFIDisk0 == "" {
+		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionEFIDisk, Value: formatEFIDisk(*efiDisk)})
+	}
+
+	// Machine type, Proxmox leaves the option empty for the default i440fx machine.
+	if value := machineScope.ProxmoxMachine.Spec.MachineType; value != "" && machineTypeOrDefault(vmConfig.Machine) != value {
+		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionMachine, Value: value})
+	}
+
+	// Serial console and display.
+	if vmConfig.Serial0 == "" {
+		enabled, err := serialConsoleEnabled(ctx, machineScope)
+		if err != nil {
+			return false, err
+		}
+		if enabled {
+			vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionSerial0, Value: "socket"})
+		}
+	}
+	if value := machineScope.ProxmoxMachine.Spec.VGA; value != "" && extractVGAType(vmConfig.VGA) != value {
+		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionVGA, Value: value})
+	}
+
+	// Hotplug.
+	if value := machineScope.ProxmoxMachine.Spec.Hotplug; len(value) > 0 && !hotplugEquals(vmConfig.Hotplug, value) {
+		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionHotplug, Value: formatHotplug(value)})
+	}
+
+	// SCSI controller.
+	if value := machineScope.ProxmoxMachine.Spec.SCSIController; value != "" && vmConfig.SCSIHW != string(value) {
+		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionSCSIHW, Value: string(value)})
+	}
+
+	// TPM state, created only once like the EFI disk.
+	if tpm := machineScope.ProxmoxMachine.Spec.TPM; tpm != nil && vmConfig.TPMState0 == "" {
+		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionTPMState, Value: formatTPMState(*tpm)})
+	}
 
 	// Network vmbrs.
 	if machineScope.ProxmoxMachine.Spec.Network != nil && shouldUpdateNetworkDevices(machineScope) {
 		// adding the default network device.
 		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{
-			Name: infrav1alpha1.DefaultNetworkDevice,
-			Value: formatNetworkDevice(
-				*machineScope.ProxmoxMachine.Spec.Network.Default.Model,
-				machineScope.ProxmoxMachine.Spec.Network.Default.Bridge,
-				machineScope.ProxmoxMachine.Spec.Network.Default.MTU,
-				machineScope.ProxmoxMachine.Spec.Network.Default.VLAN,
-			),
+			Name:  infrav1alpha1.DefaultNetworkDevice,
+			Value: formatNetworkDevice(*machineScope.ProxmoxMachine.Spec.Network.Default),
 		})
 
 		// handing additional network devices.
@@ -258,11 +790,65 @@ func reconcileVirtualMachineConfig(ctx context.Context, machineScope *scope.Mach
 		for _, v := range devices {
 			vmOptions = append(vmOptions, proxmox.VirtualMachineOption{
 				Name:  v.Name,
-				Value: formatNetworkDevice(*v.Model, v.Bridge, v.MTU, v.VLAN),
+				Value: formatNetworkDevice(v.NetworkDevice),
 			})
 		}
 	}
 
+	// PCI passthrough devices. Devices the machine doesn't list anymore are removed,
+	// machines without PCI devices keep the ones of their template.
+	hostPCIs := vmConfig.MergeHostPCIs()
+	for i, device := range machineScope.ProxmoxMachine.Spec.PCIDevices {
+		name := pciDeviceName(i)
+		if value := formatPCIDevice(device); hostPCIs[name] != value {
+			vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: name, Value: value})
+		}
+	}
+	if count := len(machineScope.ProxmoxMachine.Spec.PCIDevices); count > 0 {
+		if stale := stalePCIDevices(hostPCIs, count); len(stale) > 0 {
+			vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionDelete, Value: strings.Join(stale, ",")})
+		}
+	}
+
+	// Additional disks, existing devices are never recreated, only their options are updated.
+	// createVM made sure the template does not use these slots,
+	// so every existing device was created by a previous reconciliation.
+	if disks := machineScope.ProxmoxMachine.Spec.Disks; disks != nil {
+		current := vmConfig.MergeDisks()
+		if bv := disks.BootVolume; bv != nil && current[bv.Disk] != "" {
+			if value, changed := setDiskOptions(current[bv.Disk], formatDiskOptions(bv.Cache, bv.Discard, bv.SSDEmulation, bv.IOThread)); changed {
+				vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: bv.Disk, Value: value})
+			}
+		}
+		for i, disk := range disks.AdditionalVolumes {
+			device := additionalVolumeDevice(i)
+			value, exists := current[device]
+			if !exists {
+				vmOptions = append(vmOptions, proxmox.VirtualMachineOption{
+					Name:  device,
+					Value: formatDiskVolume(disk),
+				})
+				continue
+			}
+			if value, changed := setDiskOptions(value, formatDiskOptions(disk.Cache, disk.Discard, disk.SSDEmulation, disk.IOThread)); changed {
+				vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: device, Value: value})
+			}
+		}
+	}
+
+	// Boot order, checked last, since it may reference the devices added above.
+	if order := machineScope.ProxmoxMachine.Spec.BootOrder; len(order) > 0 {
+		if device := missingBootDevice(machineScope, vmOptions); device != "" {
+			err := errors.Wrapf(ErrBootDeviceNotFound, "vm %s has no device %s", machineScope.Name(), device)
+			machineScope.SetFailureMessage(err)
+			machineScope.SetFailureReason(capierrors.InvalidConfigurationMachineError)
+			return false, err
+		}
+		if value := formatBootOrder(order); vmConfig.Boot != value {
+			vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionBoot, Value: value})
+		}
+	}
+
 	if len(vmOptions) == 0 {
 		return false, nil
 	}
@@ -278,15 +864,130 @@ func reconcileVirtualMachineConfig(ctx context.Context, machineScope *scope.Mach
 	return true, nil
 }
 
-func reconcileMachineAddresses(scope *scope.MachineScope) error {
+// missingBootDevice returns the first device of the boot order, which neither exists in the VM config
+// nor is added by the given options. The CD-ROM drive counts as existing if the spec configures one.
+func missingBootDevice(machineScope *scope.MachineScope, vmOptions []proxmox.VirtualMachineOption) string {
+	vmConfig := machineScope.VirtualMachine.VirtualMachineConfig
+
+	devices := make(map[string]bool)
+	for _, m := range []map[string]string{vmConfig.MergeDisks(), vmConfig.MergeNets(), vmConfig.MergeHostPCIs(), vmConfig.MergeUSBs()} {
+		for name := range m {
+			devices[name] = true
+		}
+	}
+	for _, option := range vmOptions {
+		devices[option.Name] = true
+	}
+	if machineScope.ProxmoxMachine.Spec.CDROM != nil {
+		devices[optionCDROM] = true
+	}
+
+	for _, device := range machineScope.ProxmoxMachine.Spec.BootOrder {
+		if !devices[device] {
+			return device
+		}
+	}
+	return ""
+}
+
+func reconcileMachineAddresses(ctx context.Context, scope *scope.MachineScope) (requeue bool, err error) {
 	addr, err := getMachineAddresses(scope)
 	if err != nil {
 		scope.Error(err, "failed to retrieve machine addresses")
-		return err
+		return false, err
+	}
+
+	if scope.ProxmoxMachine.Spec.EnableGuestAgent {
+		agentAddr, err := getGuestAgentAddresses(ctx, scope)
+		switch {
+		case err == nil:
+			conditions.MarkTrue(scope.ProxmoxMachine, infrav1alpha1.GuestAgentAddressesCondition)
+			addr = appendMissingAddresses(addr, agentAddr)
+		case guestAgentAddressesDiscovered(scope.ProxmoxMachine):
+			// keep the addresses reported earlier, so they don't flap while the agent is busy or restarting.
+			scope.Logger.V(4).Info("guest agent is not responding, keeping known addresses", "error", err.Error())
+			conditions.MarkFalse(scope.ProxmoxMachine, infrav1alpha1.GuestAgentAddressesCondition, infrav1alpha1.GuestAgentUnavailableReason, clusterv1.ConditionSeverityWarning, err.Error())
+			addr = appendMissingAddresses(addr, scope.ProxmoxMachine.Status.Addresses)
+		case scope.SkipQemuGuestCheck():
+			// the template may not ship an agent at all, so the machine must not wait for it.
+			conditions.MarkFalse(scope.ProxmoxMachine, infrav1alpha1.GuestAgentAddressesCondition, infrav1alpha1.WaitingForGuestAgentReason, clusterv1.ConditionSeverityWarning, err.Error())
+		default:
+			// the agent needs some time to come up after the VM was started.
+			scope.Logger.V(4).Info("guest agent is not responding yet", "error", err.Error())
+			conditions.MarkFalse(scope.ProxmoxMachine, infrav1alpha1.GuestAgentAddressesCondition, infrav1alpha1.WaitingForGuestAgentReason, clusterv1.ConditionSeverityInfo, err.Error())
+			scope.SetAddresses(addr)
+			return true, nil
+		}
 	}
 
 	scope.SetAddresses(addr)
-	return nil
+	return false, nil
+}
+
+// guestAgentAddressesDiscovered returns true if the guest agent reported the addresses of the machine before.
+func guestAgentAddressesDiscovered(machine *infrav1alpha1.ProxmoxMachine) bool {
+	return conditions.IsTrue(machine, infrav1alpha1.GuestAgentAddressesCondition) ||
+		conditions.GetReason(machine, infrav1alpha1.GuestAgentAddressesCondition) == infrav1alpha1.GuestAgentUnavailableReason
+}
+
+// appendMissingAddresses appends the addresses of more, which are not in addr yet.
+// An address already in addr keeps its type.
+func appendMissingAddresses(addr, more []clusterv1.MachineAddress) []clusterv1.MachineAddress {
+	for _, a := range more {
+		if !slices.ContainsFunc(addr, func(known clusterv1.MachineAddress) bool { return known.Address == a.Address }) {
+			addr = append(addr, a)
+		}
+	}
+	return addr
+}
+
+// machineAddressType returns ExternalIP for publicly routable addresses, and InternalIP otherwise.
+func machineAddressType(addr netip.Addr) clusterv1.MachineAddressType {
+	if addr.IsGlobalUnicast() && !addr.IsPrivate() {
+		return clusterv1.MachineExternalIP
+	}
+	return clusterv1.MachineInternalIP
+}
+
+// getGuestAgentAddresses returns the addresses reported by the QEMU guest agent for the network devices of the VM,
+// sorted, and skipping loopback and link-local addresses. Interfaces created inside the guest, like docker0,
+// CNI bridges or kube-ipvs0, are ignored, as their addresses are not reachable from outside of the node.
+func getGuestAgentAddresses(ctx context.Context, scope *scope.MachineScope) ([]clusterv1.MachineAddress, error) {
+	ifaces, err := scope.InfraCluster.ProxmoxClient.QemuAgentNetworkInterfaces(ctx, scope.VirtualMachine)
+	if err != nil {
+		return nil, err
+	}
+
+	macs := make(map[string]bool)
+	for _, net := range scope.VirtualMachine.VirtualMachineConfig.MergeNets() {
+		if mac := extractMACAddress(net); mac != "" {
+			macs[strings.ToLower(mac)] = true
+		}
+	}
+
+	var addrs []netip.Addr
+	for _, iface := range ifaces {
+		if !macs[strings.ToLower(iface.HardwareAddress)] {
+			continue
+		}
+		for _, ip := range iface.IPAddresses {
+			addr, err := netip.ParseAddr(ip.IPAddress)
+			if err != nil || addr.IsLoopback() || addr.IsLinkLocalUnicast() {
+				continue
+			}
+			addrs = append(addrs, addr)
+		}
+	}
+	slices.SortFunc(addrs, netip.Addr.Compare)
+
+	addresses := make([]clusterv1.MachineAddress, 0, len(addrs))
+	for _, addr := range slices.Compact(addrs) {
+		addresses = append(addresses, clusterv1.MachineAddress{
+			Type:    machineAddressType(addr),
+			Address: addr.String(),
+		})
+	}
+	return addresses, nil
 }
 
 func getMachineAddresses(scope *scope.MachineScope) ([]clusterv1.MachineAddress, error) {
@@ -305,20 +1006,38 @@ func getMachineAddresses(scope *scope.MachineScope) ([]clusterv1.MachineAddress,
 		},
 	}
 
-	if scope.InfraCluster.ProxmoxCluster.Spec.IPv4Config != nil {
+	if hasDefaultIPv4(scope) {
 		addresses = append(addresses, clusterv1.MachineAddress{
 			Type:    clusterv1.MachineInternalIP,
 			Address: scope.ProxmoxMachine.Status.IPAddresses[infrav1alpha1.DefaultNetworkDevice].IPV4,
 		})
 	}
 
-	if scope.InfraCluster.ProxmoxCluster.Spec.IPv6Config != nil {
+	if hasDefaultIPv6(scope) {
 		addresses = append(addresses, clusterv1.MachineAddress{
 			Type:    clusterv1.MachineInternalIP,
 			Address: scope.ProxmoxMachine.Status.IPAddresses[infrav1alpha1.DefaultNetworkDevice].IPV6,
 		})
 	}
 
+	// the default device carries the cluster network, the addresses of additional devices
+	// are typed by whether they are publicly routable.
+	if scope.ProxmoxMachine.Spec.Network != nil {
+		for _, device := range scope.ProxmoxMachine.Spec.Network.AdditionalDevices {
+			ips := scope.ProxmoxMachine.Status.IPAddresses[device.Name]
+			for _, ip := range []string{ips.IPV4, ips.IPV6} {
+				addr, err := netip.ParseAddr(ip)
+				if err != nil {
+					continue
+				}
+				addresses = appendMissingAddresses(addresses, []clusterv1.MachineAddress{{
+					Type:    machineAddressType(addr),
+					Address: addr.String(),
+				}})
+			}
+		}
+	}
+
 	return addresses, nil
 }
 
@@ -332,14 +1051,21 @@ func createVM(ctx context.Context, scope *scope.MachineScope) (proxmox.VMCloneRe
 		return proxmox.VMCloneResponse{}, err
 	}
 
+	failureDomain, err := scope.GetFailureDomain()
+	if err != nil {
+		scope.SetFailureMessage(err)
+		scope.SetFailureReason(capierrors.InvalidConfigurationMachineError)
+		return proxmox.VMCloneResponse{}, err
+	}
+
 	options := proxmox.VMCloneRequest{
 		Node:  scope.ProxmoxMachine.GetNode(),
 		NewID: int(vmid),
-		Name:  scope.ProxmoxMachine.GetName(),
+		Name:  renderVMName(scope),
 	}
 
 	if scope.ProxmoxMachine.Spec.Description != nil {
-		options.Description = *scope.ProxmoxMachine.Spec.Description
+		options.Description = renderDescription(scope)
 	}
 	if scope.ProxmoxMachine.Spec.Format != nil {
 		options.Format = string(*scope.ProxmoxMachine.Spec.Format)
@@ -351,15 +1077,30 @@ func createVM(ctx context.Context, scope *scope.MachineScope) (proxmox.VMCloneRe
 		}
 		options.Full = full
 	}
-	if scope.ProxmoxMachine.Spec.Pool != nil {
-		options.Pool = *scope.ProxmoxMachine.Spec.Pool
+	if pool := scope.GetPool(); pool != "" {
+		exists, err := scope.InfraCluster.ProxmoxClient.PoolExists(ctx, pool)
+		if err != nil {
+			return proxmox.VMCloneResponse{}, errors.Wrapf(err, "unable to check resource pool %q", pool)
+		}
+		if !exists {
+			err := errors.Wrapf(ErrPoolNotFound, "pool %q", pool)
+			scope.SetFailureMessage(err)
+			scope.SetFailureReason(capierrors.InvalidConfigurationMachineError)
+			return proxmox.VMCloneResponse{}, err
+		}
+		options.Pool = pool
+	}
+	if err := checkVNets(ctx, scope); err != nil {
+		if errors.Is(err, ErrVNetNotFound) || errors.Is(err, ErrVNetVLAN) {
+			scope.SetFailureMessage(err)
+			scope.SetFailureReason(capierrors.InvalidConfigurationMachineError)
+		}
+		return proxmox.VMCloneResponse{}, err
 	}
 	if scope.ProxmoxMachine.Spec.SnapName != nil {
 		options.SnapName = *scope.ProxmoxMachine.Spec.SnapName
 	}
-	if scope.ProxmoxMachine.Spec.Storage != nil {
-		options.Storage = *scope.ProxmoxMachine.Spec.Storage
-	}
+	options.Storage = cloneStorage(scope, failureDomain, options.Full == 1)
 	if scope.ProxmoxMachine.Spec.Target != nil {
 		options.Target = *scope.ProxmoxMachine.Spec.Target
 	}
@@ -368,11 +1109,16 @@ func createVM(ctx context.Context, scope *scope.MachineScope) (proxmox.VMCloneRe
 		scope.InfraCluster.ProxmoxCluster.Status.NodeLocations = new(infrav1alpha1.NodeLocations)
 	}
 
-	// if no target was specified but we have a set of nodes defined in the cluster spec, we want to evenly distribute
-	// the nodes across the cluster.
-	if scope.ProxmoxMachine.Spec.Target == nil && len(scope.InfraCluster.ProxmoxCluster.Spec.AllowedNodes) > 0 {
+	// if no target was specified but we have a set of nodes defined in the machine or cluster spec, we want to evenly
+	// distribute the nodes across the cluster.
+	allowedNodes, err := scope.GetAllowedNodes()
+	if err != nil {
+		scope.SetFailureMessage(err)
+		scope.SetFailureReason(capierrors.InvalidConfigurationMachineError)
+		return proxmox.VMCloneResponse{}, err
+	}
+	if scope.ProxmoxMachine.Spec.Target == nil && len(allowedNodes) > 0 {
 		// select next node as a target
-		var err error
 		options.Target, err = selectNextNode(ctx, scope)
 		if err != nil {
 			if errors.As(err, &scheduler.InsufficientMemoryError{}) {
@@ -383,9 +1129,43 @@ func createVM(ctx context.Context, scope *scope.MachineScope) (proxmox.VMCloneRe
 		}
 	}
 
-	templateID := scope.ProxmoxMachine.GetTemplateID()
-	res, err := scope.InfraCluster.ProxmoxClient.CloneVM(ctx, int(templateID), options)
+	templateID, templateNode, err := resolveTemplate(ctx, scope, options.Target, options.Full == 1)
 	if err != nil {
+		// an unreachable template is retried, a missing one won't appear by itself.
+		if errors.Is(err, ErrTemplateNotFound) || errors.Is(err, goproxmox.ErrLinkedCloneRequiresTemplate) {
+			scope.SetFailureMessage(err)
+			scope.SetFailureReason(capierrors.InvalidConfigurationMachineError)
+		}
+		return proxmox.VMCloneResponse{}, err
+	}
+	options.Node = templateNode
+
+	if err := checkAdditionalVolumeSlots(ctx, scope, templateID, templateNode); err != nil {
+		if errors.Is(err, ErrAdditionalVolumeSlotInUse) {
+			scope.SetFailureMessage(err)
+			scope.SetFailureReason(capierrors.InvalidConfigurationMachineError)
+		}
+		return proxmox.VMCloneResponse{}, err
+	}
+
+	if err := checkCloneStorage(ctx, scope, templateID, options); err != nil {
+		if errors.Is(err, ErrStorageNotFound) || errors.Is(err, ErrStorageUnsuitable) {
+			scope.SetFailureMessage(err)
+			scope.SetFailureReason(capierrors.InvalidConfigurationMachineError)
+		}
+		return proxmox.VMCloneResponse{}, err
+	}
+
+	res, err := cloneVM(ctx, scope, templateID, options)
+	if err != nil {
+		switch {
+		case errors.Is(err, goproxmox.ErrLinkedCloneRequiresTemplate):
+			scope.SetFailureMessage(err)
+			scope.SetFailureReason(capierrors.InvalidConfigurationMachineError)
+		case errors.Is(err, ErrNoVMIDInRangeFree):
+			scope.SetFailureMessage(err)
+			scope.SetFailureReason(capierrors.InsufficientResourcesMachineError)
+		}
 		return res, err
 	}
 
@@ -395,27 +1175,96 @@ func createVM(ctx context.Context, scope *scope.MachineScope) (proxmox.VMCloneRe
 	}
 
 	scope.ProxmoxMachine.Status.ProxmoxNode = ptr.To(node)
+	if options.Name != scope.ProxmoxMachine.GetName() {
+		scope.ProxmoxMachine.Status.VMName = options.Name
+	}
+	scope.Eventf("CloneStarted", "Cloning VM %d from template %d on node %s", res.NewID, templateID, node)
 
 	// if the creation was successful, we store the information about the node in the
 	// cluster status
 	scope.InfraCluster.ProxmoxCluster.AddNodeLocation(infrav1alpha1.NodeLocation{
-		Machine: corev1.LocalObjectReference{Name: options.Name},
+		Machine: corev1.LocalObjectReference{Name: scope.ProxmoxMachine.GetName()},
 		Node:    node,
 	}, util.IsControlPlaneMachine(scope.Machine))
 
 	return res, scope.InfraCluster.PatchObject()
 }
 
+// cloneVM clones the VM from the template. If another process takes the VMID in the meantime,
+// the next free VMID is allocated and the clone is retried up to vmIDAllocationAttempts times.
+func cloneVM(ctx context.Context, scope *scope.MachineScope, templateID int32, options proxmox.VMCloneRequest) (proxmox.VMCloneResponse, error) {
+	for attempt := 1; ; attempt++ {
+		res, err := scope.InfraCluster.ProxmoxClient.CloneVM(ctx, int(templateID), options)
+		if !errors.Is(err, goproxmox.ErrVMIDInUse) || attempt == vmIDAllocationAttempts {
+			return res, err
+		}
+
+		scope.Info("vmid was taken while cloning, retrying", "vmid", options.NewID, "attempt", attempt)
+		if options.NewID == 0 {
+			// go-proxmox asks Proxmox for the next free id again.
+			continue
+		}
+
+		vmid, err := getVMID(ctx, scope)
+		if err != nil {
+			return proxmox.VMCloneResponse{}, err
+		}
+		options.NewID = int(vmid)
+	}
+}
+
+// checkAdditionalVolumeSlots fails if the template already uses a slot of the additional volumes,
+// as the requested disk would otherwise never be created.
+func checkAdditionalVolumeSlots(ctx context.Context, scope *scope.MachineScope, templateID int32, node string) error {
+	disks := scope.ProxmoxMachine.Spec.Disks
+	if disks == nil || len(disks.AdditionalVolumes) == 0 {
+		return nil
+	}
+
+	template, err := scope.InfraCluster.ProxmoxClient.GetVM(ctx, node, int64(templateID))
+	if err != nil {
+		return errors.Wrapf(err, "unable to get vm template %d", templateID)
+	}
+
+	scsis := template.VirtualMachineConfig.MergeSCSIs()
+	for i := range disks.AdditionalVolumes {
+		device := additionalVolumeDevice(i)
+		if _, exists := scsis[device]; exists {
+			return errors.Wrapf(ErrAdditionalVolumeSlotInUse, "vm template %d uses %s", templateID, device)
+		}
+	}
+
+	return nil
+}
+
+// getVMID allocates the VMID of a new VM from the VMIDRange of the machine, or else of the cluster,
+// and records it in the status. A VMID allocated by an earlier attempt is reused as long as it is free.
 func getVMID(ctx context.Context, scope *scope.MachineScope) (int64, error) {
-	if scope.ProxmoxMachine.Spec.VMIDRange != nil {
-		vmIDRangeStart := scope.ProxmoxMachine.Spec.VMIDRange.Start
-		vmIDRangeEnd := scope.ProxmoxMachine.Spec.VMIDRange.End
-		if vmIDRangeStart != 0 && vmIDRangeEnd != 0 {
-			return getNextFreeVMIDfromRange(ctx, scope, vmIDRangeStart, vmIDRangeEnd)
+	vmIDRange := scope.ProxmoxMachine.Spec.VMIDRange
+	if vmIDRange == nil {
+		vmIDRange = scope.InfraCluster.ProxmoxCluster.Spec.VMIDRange
+	}
+	if vmIDRange == nil || vmIDRange.Start == 0 || vmIDRange.End == 0 {
+		// If VMIDRange is not defined, return 0 to let luthermonson/go-proxmox get the next free id.
+		return 0, nil
+	}
+
+	if vmid := scope.ProxmoxMachine.Status.AllocatedVMID; vmid != nil && *vmid >= vmIDRange.Start && *vmid <= vmIDRange.End {
+		vmidFree, err := scope.InfraCluster.ProxmoxClient.CheckID(ctx, *vmid)
+		if err != nil {
+			return 0, err
 		}
+		if vmidFree {
+			return *vmid, nil
+		}
+	}
+
+	vmid, err := getNextFreeVMIDfromRange(ctx, scope, vmIDRange.Start, vmIDRange.End)
+	if err != nil {
+		return 0, err
 	}
-	// If VMIDRange is not defined, return 0 to let luthermonson/go-proxmox get the next free id.
-	return 0, nil
+	scope.ProxmoxMachine.Status.AllocatedVMID = ptr.To(vmid)
+	return vmid, nil
 }
 
 func getNextFreeVMIDfromRange(ctx context.Context, scope *scope.MachineScope, vmIDRangeStart int64, vmIDRangeEnd int64) (int64, error) {
@@ -449,6 +1298,10 @@ func getUsedVMIDs(ctx context.Context, scope *scope.MachineScope) ([]int64, erro
 		if proxmoxMachine.GetVirtualMachineID() != -1 {
 			usedVMIDs = append(usedVMIDs, proxmoxMachine.GetVirtualMachineID())
 		}
+		// VMIDs allocated by other machines which did not clone their VM yet.
+		if vmid := proxmoxMachine.Status.AllocatedVMID; vmid != nil && proxmoxMachine.GetName() != scope.ProxmoxMachine.GetName() {
+			usedVMIDs = append(usedVMIDs, *vmid)
+		}
 	}
 	return usedVMIDs, nil
 }
@@ -27,9 +27,12 @@ import (
 	"k8s.io/utils/ptr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/cluster-api/util/conditions"
 
 	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/service/scheduler"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/cloudinit"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/ignition"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/goproxmox"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
@@ -51,6 +54,34 @@ func TestReconcileVM_EverythingReady(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, infrav1alpha1.VirtualMachineStateReady, result.State)
 	require.Equal(t, "10.10.10.10", machineScope.ProxmoxMachine.Status.Addresses[1].Address)
+
+	for _, cond := range []clusterv1.ConditionType{
+		infrav1alpha1.VMClonedCondition,
+		infrav1alpha1.IPAddressAssignedCondition,
+		infrav1alpha1.BootstrapDataInjectedCondition,
+		infrav1alpha1.VMStartedCondition,
+		infrav1alpha1.GuestAgentReadyCondition,
+	} {
+		require.Truef(t, conditions.IsTrue(machineScope.ProxmoxMachine, cond), "expected condition %s to be true", cond)
+	}
+}
+
+func TestReconcileVM_WaitingForIPAddress(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	vm := newRunningVM()
+	machineScope.SetVirtualMachineID(int64(vm.VMID))
+
+	proxmoxClient.EXPECT().GetVM(context.Background(), "node1", int64(123)).Return(vm, nil).Once()
+
+	result, err := ReconcileVM(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.Equal(t, infrav1alpha1.VirtualMachineStatePending, result.State)
+
+	require.True(t, conditions.IsTrue(machineScope.ProxmoxMachine, infrav1alpha1.VMClonedCondition))
+	requireConditionIsFalse(t, machineScope.ProxmoxMachine, infrav1alpha1.IPAddressAssignedCondition)
+	require.Equal(t, infrav1alpha1.WaitingForStaticIPAllocationReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.IPAddressAssignedCondition))
+	require.False(t, conditions.Has(machineScope.ProxmoxMachine, infrav1alpha1.BootstrapDataInjectedCondition))
+	require.False(t, conditions.Has(machineScope.ProxmoxMachine, infrav1alpha1.VMStartedCondition))
 }
 
 func TestReconcileVM_QemuAgentCheckDisabled(t *testing.T) {
@@ -134,6 +165,10 @@ func TestEnsureVirtualMachine_CreateVM_FullOptions(t *testing.T) {
 		Target:      "node2",
 	}
 	response := proxmox.VMCloneResponse{NewID: 123, Task: newTask()}
+	proxmoxClient.EXPECT().PoolExists(context.Background(), "pool").Return(true, nil).Once()
+	expectVMResources(proxmoxClient, newTemplateResource(123, "node1"))
+	proxmoxClient.EXPECT().ListStorages(context.Background(), "node2").Return(newStorages("storage"), nil).Once()
+	proxmoxClient.EXPECT().GetVM(context.Background(), "node1", int64(123)).Return(newStoppedVM(), nil).Once()
 	proxmoxClient.EXPECT().CloneVM(context.Background(), 123, expectedOptions).Return(response, nil).Once()
 
 	requeue, err := ensureVirtualMachine(context.Background(), machineScope)
@@ -141,10 +176,204 @@ func TestEnsureVirtualMachine_CreateVM_FullOptions(t *testing.T) {
 	require.True(t, requeue)
 
 	require.Equal(t, "node2", *machineScope.ProxmoxMachine.Status.ProxmoxNode)
+	require.Equal(t, int64(123), *machineScope.ProxmoxMachine.Status.VMID)
 	require.True(t, machineScope.InfraCluster.ProxmoxCluster.HasMachine(machineScope.Name(), false))
 	requireConditionIsFalse(t, machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition)
 }
 
+func TestEnsureVirtualMachine_CreateVM_ClusterPool(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.InfraCluster.ProxmoxCluster.Spec.Pool = ptr.To("cluster-pool")
+	expectedOptions := proxmox.VMCloneRequest{
+		Node: "node1",
+		Name: "test",
+		Pool: "cluster-pool",
+	}
+	response := proxmox.VMCloneResponse{NewID: 123, Task: newTask()}
+	proxmoxClient.EXPECT().PoolExists(context.Background(), "cluster-pool").Return(true, nil).Once()
+	expectVMResources(proxmoxClient, newTemplateResource(123, "node1"))
+	proxmoxClient.EXPECT().CloneVM(context.Background(), 123, expectedOptions).Return(response, nil).Once()
+
+	requeue, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+}
+
+func TestEnsureVirtualMachine_CreateVM_ClusterStorage(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Full = ptr.To(true)
+	machineScope.InfraCluster.ProxmoxCluster.Spec.Storage = ptr.To("ceph")
+	expectedOptions := proxmox.VMCloneRequest{
+		Node:    "node1",
+		Name:    "test",
+		Full:    1,
+		Storage: "ceph",
+	}
+	response := proxmox.VMCloneResponse{NewID: 123, Task: newTask()}
+	expectVMResources(proxmoxClient, newTemplateResource(123, "node1"))
+	proxmoxClient.EXPECT().ListStorages(context.Background(), "node1").Return(newStorages("local", "ceph"), nil).Once()
+	proxmoxClient.EXPECT().GetVM(context.Background(), "node1", int64(123)).Return(newStoppedVM(), nil).Once()
+	proxmoxClient.EXPECT().CloneVM(context.Background(), 123, expectedOptions).Return(response, nil).Once()
+
+	requeue, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+}
+
+func TestEnsureVirtualMachine_CreateVM_LinkedCloneIgnoresClusterStorage(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Full = ptr.To(false)
+	machineScope.InfraCluster.ProxmoxCluster.Spec.Storage = ptr.To("ceph")
+	expectedOptions := proxmox.VMCloneRequest{Node: "node1", Name: "test"}
+	response := proxmox.VMCloneResponse{NewID: 123, Task: newTask()}
+	expectVMResources(proxmoxClient, newTemplateResource(123, "node1"))
+	proxmoxClient.EXPECT().CloneVM(context.Background(), 123, expectedOptions).Return(response, nil).Once()
+
+	requeue, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+}
+
+func TestEnsureVirtualMachine_CreateVM_StorageNotFound(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Storage = ptr.To("ceph")
+	expectVMResources(proxmoxClient, newTemplateResource(123, "node1"))
+	proxmoxClient.EXPECT().ListStorages(context.Background(), "node1").Return(newStorages("local"), nil).Once()
+
+	_, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.ErrorIs(t, err, ErrStorageNotFound)
+	require.Equal(t, capierrors.InvalidConfigurationMachineError, *machineScope.ProxmoxMachine.Status.FailureReason)
+	require.Contains(t, *machineScope.ProxmoxMachine.Status.FailureMessage, `storage "ceph" on node node1`)
+}
+
+func TestEnsureVirtualMachine_CreateVM_StorageUnsuitable(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Storage = ptr.To("local")
+	storages := newStorages("local")
+	storages[0].Content = "iso,vztmpl,backup"
+	expectVMResources(proxmoxClient, newTemplateResource(123, "node1"))
+	proxmoxClient.EXPECT().ListStorages(context.Background(), "node1").Return(storages, nil).Once()
+
+	_, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.ErrorIs(t, err, ErrStorageUnsuitable)
+	require.Equal(t, capierrors.InvalidConfigurationMachineError, *machineScope.ProxmoxMachine.Status.FailureReason)
+}
+
+func TestEnsureVirtualMachine_CreateVM_StorageCapacity(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Full = ptr.To(true)
+	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{
+		BootVolume:        &infrav1alpha1.DiskSize{Disk: "scsi0", SizeGB: 100},
+		AdditionalVolumes: []infrav1alpha1.DiskSpec{{SizeGB: 50, StoragePool: "local-lvm"}},
+	}
+	machineScope.InfraCluster.ProxmoxCluster.Spec.StorageHeadroomGB = ptr.To(int32(20))
+	template := newStoppedVM()
+	template.VirtualMachineConfig.SCSI0 = "local-lvm:base-123-disk-0,size=10G"
+	template.VirtualMachineConfig.IDE2 = "local-lvm:vm-123-cloudinit,media=cdrom,size=4M"
+	storages := newStorages("local-lvm")
+	storages[0].Avail = 170 << 30
+
+	expectedOptions := proxmox.VMCloneRequest{Node: "node1", Name: "test", Full: 1}
+	response := proxmox.VMCloneResponse{NewID: 123, Task: newTask()}
+	expectVMResources(proxmoxClient, newTemplateResource(123, "node1"))
+	proxmoxClient.EXPECT().GetVM(context.Background(), "node1", int64(123)).Return(template, nil)
+	proxmoxClient.EXPECT().ListStorages(context.Background(), "node1").Return(storages, nil).Once()
+	proxmoxClient.EXPECT().CloneVM(context.Background(), 123, expectedOptions).Return(response, nil).Once()
+
+	requeue, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+}
+
+func TestEnsureVirtualMachine_CreateVM_InsufficientStorage(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Full = ptr.To(true)
+	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{
+		BootVolume:        &infrav1alpha1.DiskSize{Disk: "scsi0", SizeGB: 100},
+		AdditionalVolumes: []infrav1alpha1.DiskSpec{{SizeGB: 50, StoragePool: "local-lvm"}},
+	}
+	machineScope.InfraCluster.ProxmoxCluster.Spec.StorageHeadroomGB = ptr.To(int32(20))
+	template := newStoppedVM()
+	template.VirtualMachineConfig.SCSI0 = "local-lvm:base-123-disk-0,size=10G"
+	storages := newStorages("local-lvm")
+	storages[0].Avail = 160 << 30
+
+	expectVMResources(proxmoxClient, newTemplateResource(123, "node1"))
+	proxmoxClient.EXPECT().GetVM(context.Background(), "node1", int64(123)).Return(template, nil)
+	proxmoxClient.EXPECT().ListStorages(context.Background(), "node1").Return(storages, nil).Once()
+
+	_, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.ErrorIs(t, err, ErrInsufficientStorage)
+	require.ErrorContains(t, err, `storage "local-lvm" on node node1 has 160GiB left, but 170GiB are required`)
+	require.Nil(t, machineScope.ProxmoxMachine.Status.FailureReason)
+	require.True(t, conditions.IsFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition))
+	require.Equal(t, infrav1alpha1.InsufficientStorageReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition))
+}
+
+func TestEnsureVirtualMachine_CreateVM_VMNameTemplate(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.UID = "3f2a9c1e-5b7d-4e8f-9a0b-1c2d3e4f5a6b"
+	machineScope.InfraCluster.ProxmoxCluster.Spec.VMNameTemplate = ptr.To("{{ .ClusterName }}-{{ .Role }}-{{ .ShortUID }}")
+	expectedOptions := proxmox.VMCloneRequest{
+		Node: "node1",
+		Name: "test-node-3f2a9c1e",
+	}
+	response := proxmox.VMCloneResponse{NewID: 123, Task: newTask()}
+	expectVMResources(proxmoxClient, newTemplateResource(123, "node1"))
+	proxmoxClient.EXPECT().CloneVM(context.Background(), 123, expectedOptions).Return(response, nil).Once()
+
+	requeue, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+
+	require.Equal(t, "test-node-3f2a9c1e", machineScope.ProxmoxMachine.Status.VMName)
+	require.True(t, machineScope.InfraCluster.ProxmoxCluster.HasMachine(machineScope.Name(), false))
+}
+
+func TestEnsureVirtualMachine_CreateVM_PoolNotFound(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Pool = ptr.To("missing")
+	proxmoxClient.EXPECT().PoolExists(context.Background(), "missing").Return(false, nil).Once()
+
+	_, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.ErrorIs(t, err, ErrPoolNotFound)
+	require.Equal(t, capierrors.InvalidConfigurationMachineError, *machineScope.ProxmoxMachine.Status.FailureReason)
+	require.Contains(t, *machineScope.ProxmoxMachine.Status.FailureMessage, `pool "missing"`)
+}
+
+func TestEnsureVirtualMachine_CreateVM_TemplateNotFound(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	expectVMResources(proxmoxClient, newTemplateResource(100, "node1"))
+
+	_, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.ErrorIs(t, err, ErrTemplateNotFound)
+	require.Equal(t, capierrors.InvalidConfigurationMachineError, *machineScope.ProxmoxMachine.Status.FailureReason)
+	require.Contains(t, *machineScope.ProxmoxMachine.Status.FailureMessage, "no vm template 123 exists")
+}
+
+func TestEnsureVirtualMachine_CreateVM_TemplateUnreachable(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	template := newTemplateResource(123, "node1")
+	template.Status = "unknown"
+	expectVMResources(proxmoxClient, template)
+
+	_, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.ErrorIs(t, err, ErrTemplateUnreachable)
+	require.Nil(t, machineScope.ProxmoxMachine.Status.FailureReason)
+}
+
+func TestEnsureVirtualMachine_CreateVM_LinkedCloneFromVM(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Full = ptr.To(false)
+	vm := newTemplateResource(123, "node1")
+	vm.Template = 0
+	expectVMResources(proxmoxClient, vm)
+
+	_, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.ErrorIs(t, err, goproxmox.ErrLinkedCloneRequiresTemplate)
+	require.Equal(t, capierrors.InvalidConfigurationMachineError, *machineScope.ProxmoxMachine.Status.FailureReason)
+}
+
 func TestEnsureVirtualMachine_CreateVM_SelectNode(t *testing.T) {
 	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
 	machineScope.InfraCluster.ProxmoxCluster.Spec.AllowedNodes = []string{"node1", "node2", "node3"}
@@ -156,6 +385,7 @@ func TestEnsureVirtualMachine_CreateVM_SelectNode(t *testing.T) {
 
 	expectedOptions := proxmox.VMCloneRequest{Node: "node1", Name: "test", Target: "node3"}
 	response := proxmox.VMCloneResponse{NewID: 123, Task: newTask()}
+	expectVMResources(proxmoxClient, newTemplateResource(123, "node1"))
 	proxmoxClient.EXPECT().CloneVM(context.Background(), 123, expectedOptions).Return(response, nil).Once()
 
 	requeue, err := ensureVirtualMachine(context.Background(), machineScope)
@@ -167,6 +397,87 @@ func TestEnsureVirtualMachine_CreateVM_SelectNode(t *testing.T) {
 	requireConditionIsFalse(t, machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition)
 }
 
+func TestEnsureVirtualMachine_CreateVM_SelectNode_MachineAllowedNodes(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.AllowedNodes = []string{"node2"}
+
+	selectNextNode = func(_ context.Context, s *scope.MachineScope) (string, error) {
+		nodes, err := s.GetAllowedNodes()
+		if err != nil {
+			return "", err
+		}
+		return nodes[0], nil
+	}
+	t.Cleanup(func() { selectNextNode = scheduler.ScheduleVM })
+
+	expectedOptions := proxmox.VMCloneRequest{Node: "node1", Name: "test", Target: "node2"}
+	response := proxmox.VMCloneResponse{NewID: 123, Task: newTask()}
+	expectVMResources(proxmoxClient, newTemplateResource(123, "node1"))
+	proxmoxClient.EXPECT().CloneVM(context.Background(), 123, expectedOptions).Return(response, nil).Once()
+
+	requeue, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+
+	require.Equal(t, "node2", *machineScope.ProxmoxMachine.Status.ProxmoxNode)
+}
+
+func TestEnsureVirtualMachine_CreateVM_SelectNode_MachineAllowedNodesOutsideCluster(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.InfraCluster.ProxmoxCluster.Spec.AllowedNodes = []string{"node1", "node2"}
+	machineScope.ProxmoxMachine.Spec.AllowedNodes = []string{"node3"}
+
+	_, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.ErrorIs(t, err, scope.ErrNoAllowedNodes)
+	require.Equal(t, capierrors.InvalidConfigurationMachineError, *machineScope.ProxmoxMachine.Status.FailureReason)
+}
+
+func TestEnsureVirtualMachine_CreateVM_FailureDomain(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.Machine.Spec.FailureDomain = ptr.To("dc2")
+	machineScope.ProxmoxMachine.Spec.Full = ptr.To(true)
+	machineScope.InfraCluster.ProxmoxCluster.Spec.FailureDomains = map[string]infrav1alpha1.FailureDomainSpec{
+		"dc1": {Nodes: []string{"node1"}},
+		"dc2": {Nodes: []string{"node2"}, Storage: ptr.To("ceph-dc2")},
+	}
+
+	selectNextNode = func(_ context.Context, s *scope.MachineScope) (string, error) {
+		nodes, err := s.GetAllowedNodes()
+		if err != nil {
+			return "", err
+		}
+		return nodes[0], nil
+	}
+	t.Cleanup(func() { selectNextNode = scheduler.ScheduleVM })
+
+	expectedOptions := proxmox.VMCloneRequest{Node: "node1", Name: "test", Target: "node2", Full: 1, Storage: "ceph-dc2"}
+	response := proxmox.VMCloneResponse{NewID: 123, Task: newTask()}
+	expectVMResources(proxmoxClient, newTemplateResource(123, "node1"))
+	proxmoxClient.EXPECT().ListStorages(context.Background(), "node2").Return(newStorages("ceph-dc2"), nil).Once()
+	proxmoxClient.EXPECT().GetVM(context.Background(), "node1", int64(123)).Return(newStoppedVM(), nil).Once()
+	proxmoxClient.EXPECT().CloneVM(context.Background(), 123, expectedOptions).Return(response, nil).Once()
+
+	requeue, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+
+	require.Equal(t, "node2", *machineScope.ProxmoxMachine.Status.ProxmoxNode)
+}
+
+func TestEnsureVirtualMachine_CreateVM_UnknownFailureDomain(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.Machine.Spec.FailureDomain = ptr.To("dc3")
+	machineScope.InfraCluster.ProxmoxCluster.Spec.AllowedNodes = []string{"node1", "node2"}
+	machineScope.InfraCluster.ProxmoxCluster.Spec.FailureDomains = map[string]infrav1alpha1.FailureDomainSpec{
+		"dc1": {Nodes: []string{"node1"}},
+		"dc2": {Nodes: []string{"node2"}},
+	}
+
+	_, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.ErrorIs(t, err, scope.ErrUnknownFailureDomain)
+	require.Equal(t, capierrors.InvalidConfigurationMachineError, *machineScope.ProxmoxMachine.Status.FailureReason)
+}
+
 func TestEnsureVirtualMachine_CreateVM_SelectNode_InsufficientMemory(t *testing.T) {
 	machineScope, _, _ := setupReconcilerTest(t)
 	machineScope.InfraCluster.ProxmoxCluster.Spec.AllowedNodes = []string{"node1"}
@@ -195,6 +506,7 @@ func TestEnsureVirtualMachine_CreateVM_VMIDRange(t *testing.T) {
 	response := proxmox.VMCloneResponse{Task: newTask(), NewID: int64(1001)}
 	proxmoxClient.Mock.On("CheckID", context.Background(), int64(1000)).Return(false, nil)
 	proxmoxClient.Mock.On("CheckID", context.Background(), int64(1001)).Return(true, nil)
+	expectVMResources(proxmoxClient, newTemplateResource(123, "node1"))
 	proxmoxClient.EXPECT().CloneVM(context.Background(), 123, expectedOptions).Return(response, nil).Once()
 
 	requeue, err := ensureVirtualMachine(context.Background(), machineScope)
@@ -206,6 +518,50 @@ func TestEnsureVirtualMachine_CreateVM_VMIDRange(t *testing.T) {
 	requireConditionIsFalse(t, machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition)
 }
 
+func TestEnsureVirtualMachine_CreateVM_ClusterVMIDRangeTakenWhileCloning(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.InfraCluster.ProxmoxCluster.Spec.VMIDRange = &infrav1alpha1.VMIDRange{
+		Start: 1000,
+		End:   1002,
+	}
+
+	// another process clones a VM to 1000 right after it was checked.
+	proxmoxClient.Mock.On("CheckID", context.Background(), int64(1000)).Return(true, nil).Once()
+	proxmoxClient.Mock.On("CheckID", context.Background(), int64(1000)).Return(false, nil)
+	proxmoxClient.Mock.On("CheckID", context.Background(), int64(1001)).Return(true, nil)
+	expectVMResources(proxmoxClient, newTemplateResource(123, "node1"))
+	proxmoxClient.EXPECT().CloneVM(context.Background(), 123, proxmox.VMCloneRequest{Node: "node1", NewID: 1000, Name: "test"}).
+		Return(proxmox.VMCloneResponse{}, fmt.Errorf("unable to create new vm 1000: %w", goproxmox.ErrVMIDInUse)).Once()
+	proxmoxClient.EXPECT().CloneVM(context.Background(), 123, proxmox.VMCloneRequest{Node: "node1", NewID: 1001, Name: "test"}).
+		Return(proxmox.VMCloneResponse{Task: newTask(), NewID: int64(1001)}, nil).Once()
+
+	requeue, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+
+	require.Equal(t, int64(1001), machineScope.ProxmoxMachine.GetVirtualMachineID())
+	require.Equal(t, ptr.To(int64(1001)), machineScope.ProxmoxMachine.Status.AllocatedVMID)
+}
+
+func TestEnsureVirtualMachine_CreateVM_VMIDRangeReusesAllocatedVMID(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.VMIDRange = &infrav1alpha1.VMIDRange{
+		Start: 1000,
+		End:   1002,
+	}
+	machineScope.ProxmoxMachine.Status.AllocatedVMID = ptr.To(int64(1002))
+
+	proxmoxClient.Mock.On("CheckID", context.Background(), int64(1002)).Return(true, nil).Once()
+	expectVMResources(proxmoxClient, newTemplateResource(123, "node1"))
+	proxmoxClient.EXPECT().CloneVM(context.Background(), 123, proxmox.VMCloneRequest{Node: "node1", NewID: 1002, Name: "test"}).
+		Return(proxmox.VMCloneResponse{Task: newTask(), NewID: int64(1002)}, nil).Once()
+
+	requeue, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Equal(t, int64(1002), machineScope.ProxmoxMachine.GetVirtualMachineID())
+}
+
 func TestEnsureVirtualMachine_CreateVM_VMIDRangeExhausted(t *testing.T) {
 	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
 	machineScope.ProxmoxMachine.Spec.VMIDRange = &infrav1alpha1.VMIDRange{
@@ -272,6 +628,7 @@ func TestEnsureVirtualMachine_CreateVM_VMIDRangeCheckExisting(t *testing.T) {
 
 	expectedOptions := proxmox.VMCloneRequest{Node: "node1", NewID: 1002, Name: "test"}
 	response := proxmox.VMCloneResponse{Task: newTask(), NewID: int64(1002)}
+	expectVMResources(proxmoxClient, newTemplateResource(123, "node1"))
 	proxmoxClient.EXPECT().CloneVM(context.Background(), 123, expectedOptions).Return(response, nil).Once()
 	proxmoxClient.Mock.On("CheckID", context.Background(), int64(1001)).Return(false, nil).Once()
 	proxmoxClient.Mock.On("CheckID", context.Background(), int64(1002)).Return(true, nil).Once()
@@ -284,7 +641,7 @@ func TestEnsureVirtualMachine_CreateVM_VMIDRangeCheckExisting(t *testing.T) {
 
 func TestEnsureVirtualMachine_FindVM(t *testing.T) {
 	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
-	machineScope.SetVirtualMachineID(123)
+	machineScope.ProxmoxMachine.Spec.VirtualMachineID = ptr.To(int64(123))
 	vm := newStoppedVM()
 	vm.VirtualMachineConfig.SMBios1 = "uuid=56603c36-46b9-4608-90ae-c731c15eae64"
 
@@ -296,6 +653,8 @@ func TestEnsureVirtualMachine_FindVM(t *testing.T) {
 
 	require.Equal(t, vm, machineScope.VirtualMachine)
 	require.Equal(t, "proxmox://56603c36-46b9-4608-90ae-c731c15eae64", machineScope.GetProviderID())
+	require.Equal(t, int64(123), *machineScope.ProxmoxMachine.Status.VMID)
+	require.Equal(t, "stopped", machineScope.ProxmoxMachine.Status.PowerState)
 }
 
 func TestEnsureVirtualMachine_UpdateVMLocation_Error(t *testing.T) {
@@ -341,8 +700,8 @@ func TestReconcileVirtualMachineConfig_ApplyConfig(t *testing.T) {
 		proxmox.VirtualMachineOption{Name: optionSockets, Value: machineScope.ProxmoxMachine.Spec.NumSockets},
 		proxmox.VirtualMachineOption{Name: optionCores, Value: machineScope.ProxmoxMachine.Spec.NumCores},
 		proxmox.VirtualMachineOption{Name: optionMemory, Value: machineScope.ProxmoxMachine.Spec.MemoryMiB},
-		proxmox.VirtualMachineOption{Name: "net0", Value: formatNetworkDevice("virtio", "vmbr0", ptr.To(uint16(1500)), nil)},
-		proxmox.VirtualMachineOption{Name: "net1", Value: formatNetworkDevice("virtio", "vmbr1", ptr.To(uint16(1500)), nil)},
+		proxmox.VirtualMachineOption{Name: "net0", Value: formatNetworkDevice(infrav1alpha1.NetworkDevice{Model: ptr.To("virtio"), Bridge: "vmbr0", MTU: ptr.To(uint16(1500))})},
+		proxmox.VirtualMachineOption{Name: "net1", Value: formatNetworkDevice(infrav1alpha1.NetworkDevice{Model: ptr.To("virtio"), Bridge: "vmbr1", MTU: ptr.To(uint16(1500))})},
 	}
 
 	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(task, nil).Once()
@@ -353,69 +712,936 @@ func TestReconcileVirtualMachineConfig_ApplyConfig(t *testing.T) {
 	require.EqualValues(t, task.UPID, *machineScope.ProxmoxMachine.Status.TaskRef)
 }
 
-func TestReconcileDisks_RunningVM(t *testing.T) {
-	machineScope, _, _ := setupReconcilerTest(t)
-	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{
-		BootVolume: &infrav1alpha1.DiskSize{Disk: "ide0", SizeGB: 100},
+func TestReconcileVirtualMachineConfig_Balloon(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.MemoryMiB = 4096
+	machineScope.ProxmoxMachine.Spec.MinMemoryMiB = ptr.To(int32(2048))
+
+	vm := newStoppedVM()
+	vm.VirtualMachineConfig.Memory = 4096
+	task := newTask()
+	machineScope.SetVirtualMachine(vm)
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: optionBalloon, Value: int32(2048)},
 	}
-	machineScope.SetVirtualMachine(newRunningVM())
 
-	require.NoError(t, reconcileDisks(context.Background(), machineScope))
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(task, nil).Once()
+
+	requeue, err := reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
 }
 
-func TestReconcileDisks_ResizeDisk(t *testing.T) {
+func TestReconcileVirtualMachineConfig_OVMF(t *testing.T) {
 	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
-	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{
-		BootVolume: &infrav1alpha1.DiskSize{Disk: "ide0", SizeGB: 100},
-	}
+	machineScope.ProxmoxMachine.Spec.BIOS = infrav1alpha1.BIOSOVMF
+	machineScope.ProxmoxMachine.Spec.EFIDisk = &infrav1alpha1.EFIDisk{Storage: "local-lvm", PreEnrolledKeys: true}
+
 	vm := newStoppedVM()
+	task := newTask()
 	machineScope.SetVirtualMachine(vm)
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: optionBIOS, Value: "ovmf"},
+		proxmox.VirtualMachineOption{Name: optionEFIDisk, Value: "local-lvm:1,efitype=4m,pre-enrolled-keys=1"},
+	}
 
-	proxmoxClient.EXPECT().ResizeDisk(context.Background(), vm, "ide0", machineScope.ProxmoxMachine.Spec.Disks.BootVolume.FormatSize()).Return(nil)
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(task, nil).Once()
 
-	require.NoError(t, reconcileDisks(context.Background(), machineScope))
+	requeue, err := reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
 }
 
-func TestReconcileMachineAddresses_IPV4(t *testing.T) {
+func TestReconcileVirtualMachineConfig_OVMFExistingEFIDisk(t *testing.T) {
 	machineScope, _, _ := setupReconcilerTest(t)
-	vm := newRunningVM()
+	machineScope.ProxmoxMachine.Spec.BIOS = infrav1alpha1.BIOSOVMF
+	machineScope.ProxmoxMachine.Spec.EFIDisk = &infrav1alpha1.EFIDisk{Storage: "local-lvm"}
+
+	vm := newStoppedVM()
+	vm.VirtualMachineConfig.Bios = "ovmf"
+	vm.VirtualMachineConfig.EFIDisk0 = "local-lvm:vm-100-disk-1,efitype=4m,size=4M"
 	machineScope.SetVirtualMachine(vm)
-	machineScope.SetVirtualMachineID(int64(vm.VMID))
-	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
-	machineScope.ProxmoxMachine.Status.BootstrapDataProvided = ptr.To(true)
 
-	require.NoError(t, reconcileMachineAddresses(machineScope))
-	require.Equal(t, machineScope.ProxmoxMachine.Status.Addresses[0].Address, machineScope.ProxmoxMachine.GetName())
-	require.Equal(t, machineScope.ProxmoxMachine.Status.Addresses[1].Address, "10.10.10.10")
+	requeue, err := reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
 }
 
-func TestReconcileMachineAddresses_IPV6(t *testing.T) {
-	machineScope, _, _ := setupReconcilerTest(t)
-	machineScope.InfraCluster.ProxmoxCluster.Spec.IPv4Config = nil
-	machineScope.InfraCluster.ProxmoxCluster.Spec.IPv6Config = &infrav1alpha1.IPConfigSpec{
-		Addresses: []string{"2001:db8::/64"},
-		Prefix:    64,
-		Gateway:   "2001:db8::1",
-	}
+func TestReconcileVirtualMachineConfig_TPM(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.TPM = &infrav1alpha1.TPMSpec{StoragePool: "local-lvm"}
 
-	vm := newRunningVM()
+	vm := newStoppedVM()
+	task := newTask()
 	machineScope.SetVirtualMachine(vm)
-	machineScope.SetVirtualMachineID(int64(vm.VMID))
-	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV6: "2001:db8::2"}}
-	machineScope.ProxmoxMachine.Status.BootstrapDataProvided = ptr.To(true)
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: optionTPMState, Value: "local-lvm:1,version=v2.0"},
+	}
 
-	require.NoError(t, reconcileMachineAddresses(machineScope))
-	require.Equal(t, machineScope.ProxmoxMachine.Status.Addresses[0].Address, machineScope.ProxmoxMachine.GetName())
-	require.Equal(t, machineScope.ProxmoxMachine.Status.Addresses[1].Address, "2001:db8::2")
-}
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(task, nil).Once()
 
-func TestReconcileMachineAddresses_DualStack(t *testing.T) {
-	machineScope, _, _ := setupReconcilerTest(t)
-	machineScope.InfraCluster.ProxmoxCluster.Spec.IPv6Config = &infrav1alpha1.IPConfigSpec{
-		Addresses: []string{"2001:db8::/64"},
-		Prefix:    64,
-		Gateway:   "2001:db8::1",
-	}
+	requeue, err := reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+
+	// an existing TPM state is kept.
+	vm.VirtualMachineConfig.TPMState0 = "local-lvm:vm-100-disk-2,size=4M,version=v2.0"
+	requeue, err = reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+}
+
+func TestReconcileVirtualMachineConfig_MachineType(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.MachineType = "pc-q35-8.1"
+
+	vm := newStoppedVM()
+	task := newTask()
+	machineScope.SetVirtualMachine(vm)
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: optionMachine, Value: "pc-q35-8.1"},
+	}
+
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(task, nil).Once()
+
+	requeue, err := reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+
+	// the default machine type is left empty by Proxmox.
+	vm.VirtualMachineConfig.Machine = ""
+	machineScope.ProxmoxMachine.Spec.MachineType = "pc"
+	requeue, err = reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+}
+
+func TestReconcileVirtualMachineConfig_NUMA(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.NUMA = true
+
+	vm := newStoppedVM()
+	task := newTask()
+	machineScope.SetVirtualMachine(vm)
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: optionNUMA, Value: 1},
+	}
+
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(task, nil).Once()
+
+	requeue, err := reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+
+	vm.VirtualMachineConfig.Numa = 1
+	requeue, err = reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+}
+
+func TestReconcileVirtualMachineConfig_Hugepages(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Hugepages = infrav1alpha1.Hugepages1G
+
+	vm := newStoppedVM()
+	task := newTask()
+	machineScope.SetVirtualMachine(vm)
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: optionHugepages, Value: "1024"},
+	}
+
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(task, nil).Once()
+
+	requeue, err := reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+}
+
+func TestReconcileVirtualMachineConfig_DiskOptions(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{
+		BootVolume: &infrav1alpha1.DiskSize{Disk: "scsi0", SizeGB: 100, Discard: true, SSDEmulation: true},
+		AdditionalVolumes: []infrav1alpha1.DiskSpec{
+			{SizeGB: 50, StoragePool: "local-lvm", Cache: infrav1alpha1.DiskCacheWriteBack, Discard: true},
+		},
+	}
+
+	vm := newStoppedVM()
+	vm.VirtualMachineConfig.SCSI0 = "local-lvm:vm-123-disk-0,size=100G"
+	task := newTask()
+	machineScope.SetVirtualMachine(vm)
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: "scsi0", Value: "local-lvm:vm-123-disk-0,size=100G,discard=on,ssd=1"},
+		proxmox.VirtualMachineOption{Name: "scsi1", Value: "local-lvm:50,cache=writeback,discard=on"},
+	}
+
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(task, nil).Once()
+
+	requeue, err := reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+}
+
+func TestReconcileVirtualMachineConfig_SCSIController(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.SCSIController = infrav1alpha1.SCSIControllerVirtIOSingle
+	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{
+		BootVolume: &infrav1alpha1.DiskSize{Disk: "scsi0", SizeGB: 100, IOThread: true},
+	}
+
+	vm := newStoppedVM()
+	vm.VirtualMachineConfig.SCSIHW = "virtio-scsi-pci"
+	vm.VirtualMachineConfig.SCSI0 = "local-lvm:vm-123-disk-0,size=100G"
+	task := newTask()
+	machineScope.SetVirtualMachine(vm)
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: optionSCSIHW, Value: "virtio-scsi-single"},
+		proxmox.VirtualMachineOption{Name: "scsi0", Value: "local-lvm:vm-123-disk-0,size=100G,iothread=1"},
+	}
+
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(task, nil).Once()
+
+	requeue, err := reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+}
+
+func TestReconcileVirtualMachineConfig_SerialConsoleCloudConfig(t *testing.T) {
+	machineScope, proxmoxClient, kubeClient := setupReconcilerTest(t)
+	createBootstrapSecret(t, kubeClient, machineScope, cloudinit.FormatCloudConfig)
+	machineScope.ProxmoxMachine.Spec.VGA = "serial0"
+
+	vm := newStoppedVM()
+	vm.VirtualMachineConfig.VGA = "std,memory=32"
+	task := newTask()
+	machineScope.SetVirtualMachine(vm)
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: optionSerial0, Value: "socket"},
+		proxmox.VirtualMachineOption{Name: optionVGA, Value: "serial0"},
+	}
+
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(task, nil).Once()
+
+	requeue, err := reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+}
+
+func TestReconcileVirtualMachineConfig_SerialConsoleIgnition(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	createBootstrapSecret(t, kubeClient, machineScope, ignition.FormatIgnition)
+
+	machineScope.SetVirtualMachine(newStoppedVM())
+
+	requeue, err := reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+}
+
+func TestReconcileVirtualMachineConfig_SerialConsoleDisabled(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	createBootstrapSecret(t, kubeClient, machineScope, cloudinit.FormatCloudConfig)
+	machineScope.ProxmoxMachine.Spec.SerialConsole = ptr.To(false)
+
+	machineScope.SetVirtualMachine(newStoppedVM())
+
+	requeue, err := reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+}
+
+func TestReconcileVirtualMachineConfig_Hotplug(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Hotplug = []infrav1alpha1.HotplugFeature{infrav1alpha1.HotplugNetwork, infrav1alpha1.HotplugDisk}
+
+	vm := newStoppedVM()
+	task := newTask()
+	machineScope.SetVirtualMachine(vm)
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: optionHotplug, Value: "network,disk"},
+	}
+
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(task, nil).Once()
+
+	requeue, err := reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+
+	vm.VirtualMachineConfig.Hotplug = "disk,network"
+	requeue, err = reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+}
+
+func TestReconcileVirtualMachineConfig_DisableBalloon(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+
+	vm := newStoppedVM()
+	vm.VirtualMachineConfig.Balloon = 1024
+	task := newTask()
+	machineScope.SetVirtualMachine(vm)
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: optionBalloon, Value: int32(0)},
+	}
+
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(task, nil).Once()
+
+	requeue, err := reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+}
+
+func TestReconcileVirtualMachineConfig_CPUType(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.CPUType = "host"
+
+	vm := newStoppedVM()
+	vm.VirtualMachineConfig.CPU = "x86-64-v2-AES,flags=+aes"
+	task := newTask()
+	machineScope.SetVirtualMachine(vm)
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: optionCPU, Value: "host"},
+	}
+
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(task, nil).Once()
+
+	requeue, err := reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+}
+
+func TestReconcileVirtualMachineConfig_PCIDevices(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.PCIDevices = []infrav1alpha1.PCIDeviceSpec{
+		{Mapping: ptr.To("gpu"), PCIE: true, PrimaryGPU: true},
+		{DeviceID: ptr.To("0000:02:00.0"), ROMBar: ptr.To(false)},
+	}
+
+	vm := newStoppedVM()
+	vm.VirtualMachineConfig.HostPCI0 = "mapping=gpu,pcie=1,x-vga=1"
+	task := newTask()
+	machineScope.SetVirtualMachine(vm)
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: "hostpci1", Value: "0000:02:00.0,rombar=0"},
+	}
+
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(task, nil).Once()
+
+	requeue, err := reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+}
+
+func TestReconcileVirtualMachineConfig_RemovedPCIDevices(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.PCIDevices = []infrav1alpha1.PCIDeviceSpec{
+		{Mapping: ptr.To("gpu"), PCIE: true},
+	}
+
+	vm := newStoppedVM()
+	vm.VirtualMachineConfig.HostPCI0 = "mapping=gpu,pcie=1"
+	vm.VirtualMachineConfig.HostPCI1 = "mapping=nic"
+	vm.VirtualMachineConfig.HostPCI3 = "0000:02:00.0"
+	task := newTask()
+	machineScope.SetVirtualMachine(vm)
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: "delete", Value: "hostpci1,hostpci3"},
+	}
+
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(task, nil).Once()
+
+	requeue, err := reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+}
+
+func TestReconcileVirtualMachineConfig_TemplatePCIDevices(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+
+	vm := newStoppedVM()
+	vm.VirtualMachineConfig.HostPCI0 = "mapping=gpu,pcie=1"
+	machineScope.SetVirtualMachine(vm)
+
+	requeue, err := reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+}
+
+func TestReconcileVirtualMachineConfig_AdditionalVolumes(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{
+		AdditionalVolumes: []infrav1alpha1.DiskSpec{
+			{SizeGB: 50, StoragePool: "local-lvm"},
+			{SizeGB: 100, StoragePool: "local", Format: ptr.To(infrav1alpha1.TargetStorageFormatQcow2)},
+		},
+	}
+
+	vm := newStoppedVM()
+	// scsi1 was already created by a previous reconciliation.
+	vm.VirtualMachineConfig.SCSI1 = "local-lvm:vm-123-disk-1,size=50G"
+	task := newTask()
+	machineScope.SetVirtualMachine(vm)
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: "scsi2", Value: "local:100,format=qcow2"},
+	}
+
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(task, nil).Once()
+
+	requeue, err := reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+}
+
+func TestReconcileVirtualMachineConfig_BootOrder(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.BootOrder = []string{"scsi1", "scsi0", "net0"}
+	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{
+		AdditionalVolumes: []infrav1alpha1.DiskSpec{{SizeGB: 50, StoragePool: "local-lvm"}},
+	}
+
+	vm := newStoppedVM()
+	vm.VirtualMachineConfig.SCSI0 = "local-lvm:vm-123-disk-0,size=20G"
+	vm.VirtualMachineConfig.Net0 = "virtio=BC:24:11:00:00:01,bridge=vmbr0"
+	vm.VirtualMachineConfig.Boot = "order=scsi0;net0"
+	task := newTask()
+	machineScope.SetVirtualMachine(vm)
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: "scsi1", Value: "local-lvm:50"},
+		proxmox.VirtualMachineOption{Name: optionBoot, Value: "order=scsi1;scsi0;net0"},
+	}
+
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(task, nil).Once()
+
+	requeue, err := reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+}
+
+func TestReconcileVirtualMachineConfig_BootOrderUnchanged(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.BootOrder = []string{"scsi0", "ide2"}
+	machineScope.ProxmoxMachine.Spec.CDROM = &infrav1alpha1.CDROMSpec{ISO: "local:iso/rescue.iso"}
+
+	vm := newStoppedVM()
+	vm.VirtualMachineConfig.SCSI0 = "local-lvm:vm-123-disk-0,size=20G"
+	vm.VirtualMachineConfig.Boot = "order=scsi0;ide2"
+	machineScope.SetVirtualMachine(vm)
+
+	requeue, err := reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+}
+
+func TestReconcileVirtualMachineConfig_BootOrderMissingDevice(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.BootOrder = []string{"virtio0", "net0"}
+
+	vm := newStoppedVM()
+	vm.VirtualMachineConfig.SCSI0 = "local-lvm:vm-123-disk-0,size=20G"
+	vm.VirtualMachineConfig.Net0 = "virtio=BC:24:11:00:00:01,bridge=vmbr0"
+	machineScope.SetVirtualMachine(vm)
+
+	_, err := reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.ErrorIs(t, err, ErrBootDeviceNotFound)
+	require.ErrorContains(t, err, "virtio0")
+	require.Equal(t, capierrors.InvalidConfigurationMachineError, *machineScope.ProxmoxMachine.Status.FailureReason)
+}
+
+func TestEnsureVirtualMachine_CreateVM_AdditionalVolumes(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{
+		AdditionalVolumes: []infrav1alpha1.DiskSpec{{SizeGB: 50, StoragePool: "local-lvm"}},
+	}
+
+	expectVMResources(proxmoxClient, newTemplateResource(123, "node1"))
+	proxmoxClient.EXPECT().GetVM(context.Background(), "node1", int64(123)).Return(newStoppedVM(), nil).Once()
+	expectedOptions := proxmox.VMCloneRequest{Node: "node1", Name: "test"}
+	response := proxmox.VMCloneResponse{NewID: 123, Task: newTask()}
+	proxmoxClient.EXPECT().CloneVM(context.Background(), 123, expectedOptions).Return(response, nil).Once()
+
+	requeue, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+}
+
+func TestEnsureVirtualMachine_CreateVM_AdditionalVolumes_SlotInUse(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{
+		AdditionalVolumes: []infrav1alpha1.DiskSpec{{SizeGB: 50, StoragePool: "local-lvm"}},
+	}
+
+	template := newStoppedVM()
+	template.VirtualMachineConfig.SCSI1 = "local-lvm:vm-123-cloudinit,media=cdrom"
+	expectVMResources(proxmoxClient, newTemplateResource(123, "node1"))
+	proxmoxClient.EXPECT().GetVM(context.Background(), "node1", int64(123)).Return(template, nil).Once()
+
+	_, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.ErrorIs(t, err, ErrAdditionalVolumeSlotInUse)
+	require.Equal(t, capierrors.InvalidConfigurationMachineError, *machineScope.ProxmoxMachine.Status.FailureReason)
+}
+
+func TestReconcileTags_MergeTags(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Tags = []string{"capi", "worker"}
+
+	vm := newRunningVM()
+	vm.VirtualMachineConfig.Tags = "external;ip_net0_10.10.10.10,Worker"
+	task := newTask()
+	machineScope.SetVirtualMachine(vm)
+
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm,
+		proxmox.VirtualMachineOption{Name: optionTags, Value: "external;ip_net0_10.10.10.10;Worker;cluster_test;capi"},
+	).Return(task, nil).Once()
+
+	requeue, err := reconcileTags(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.EqualValues(t, task.UPID, *machineScope.ProxmoxMachine.Status.TaskRef)
+}
+
+func TestReconcileTags_NoChanges(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Tags = []string{"capi"}
+
+	vm := newRunningVM()
+	vm.VirtualMachineConfig.Tags = "capi;external;cluster_test"
+	machineScope.SetVirtualMachine(vm)
+
+	requeue, err := reconcileTags(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+}
+
+func TestReconcileDescription_UpdateRunningVM(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Description = ptr.To("cluster {{ .ClusterName }}")
+
+	vm := newRunningVM()
+	vm.VirtualMachineConfig.Description = "outdated"
+	task := newTask()
+	machineScope.SetVirtualMachine(vm)
+
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm,
+		proxmox.VirtualMachineOption{Name: optionDescription, Value: "cluster test"},
+	).Return(task, nil).Once()
+
+	requeue, err := reconcileDescription(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.EqualValues(t, task.UPID, *machineScope.ProxmoxMachine.Status.TaskRef)
+}
+
+func TestReconcileDescription_NoChanges(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	vm := newRunningVM()
+	vm.VirtualMachineConfig.Description = "set in proxmox"
+	machineScope.SetVirtualMachine(vm)
+
+	// without a description in the spec, the description of the VM is kept.
+	requeue, err := reconcileDescription(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+
+	machineScope.ProxmoxMachine.Spec.Description = ptr.To("cluster {{ .ClusterName }}")
+	vm.VirtualMachineConfig.Description = "cluster test\n"
+	requeue, err = reconcileDescription(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+}
+
+func TestReconcileStartOnBoot_DefaultsToTrue(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	vm := newStoppedVM()
+	task := newTask()
+	machineScope.SetVirtualMachine(vm)
+
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm,
+		proxmox.VirtualMachineOption{Name: optionOnBoot, Value: 1},
+	).Return(task, nil).Once()
+
+	requeue, err := reconcileStartOnBoot(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.EqualValues(t, task.UPID, *machineScope.ProxmoxMachine.Status.TaskRef)
+}
+
+func TestReconcileStartOnBoot_Disabled(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.OnBoot = ptr.To(false)
+	vm := newRunningVM()
+	task := newTask()
+	machineScope.SetVirtualMachine(vm)
+
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm,
+		proxmox.VirtualMachineOption{Name: optionOnBoot, Value: 0},
+	).Return(task, nil).Once()
+
+	requeue, err := reconcileStartOnBoot(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+}
+
+func TestReconcileStartOnBoot_Startup(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Startup = &infrav1alpha1.StartupSpec{Order: ptr.To(int32(2)), Up: ptr.To(int32(30))}
+	vm := newRunningVM()
+	task := newTask()
+	machineScope.SetVirtualMachine(vm)
+
+	proxmoxClient.EXPECT().GetVMStartup(context.Background(), vm).Return("order=1", nil).Once()
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm,
+		proxmox.VirtualMachineOption{Name: optionStartup, Value: "order=2,up=30"},
+	).Return(task, nil).Once()
+
+	requeue, err := reconcileStartOnBoot(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+
+	// nothing to do once the VM is up to date.
+	proxmoxClient.EXPECT().GetVMStartup(context.Background(), vm).Return("order=2,up=30", nil).Once()
+	requeue, err = reconcileStartOnBoot(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+}
+
+func TestReconcileProtection(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	vm := newRunningVM()
+	task := newTask()
+	machineScope.SetVirtualMachine(vm)
+
+	// unprotected machines are left alone.
+	requeue, err := reconcileProtection(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+
+	machineScope.ProxmoxMachine.Spec.Protected = true
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm,
+		proxmox.VirtualMachineOption{Name: optionProtection, Value: 1},
+	).Return(task, nil).Once()
+
+	requeue, err = reconcileProtection(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+
+	vm.VirtualMachineConfig.Protection = 1
+	requeue, err = reconcileProtection(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+}
+
+func TestReconcileProtection_Unprotect(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	vm := newRunningVM()
+	vm.VirtualMachineConfig.Protection = 1
+	task := newTask()
+	machineScope.SetVirtualMachine(vm)
+
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm,
+		proxmox.VirtualMachineOption{Name: optionProtection, Value: 0},
+	).Return(task, nil).Once()
+
+	requeue, err := reconcileProtection(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Equal(t, string(task.UPID), *machineScope.ProxmoxMachine.Status.TaskRef)
+
+	vm.VirtualMachineConfig.Protection = 0
+	requeue, err = reconcileProtection(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+}
+
+func TestReconcileCDROM_MountISO(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.CDROM = &infrav1alpha1.CDROMSpec{ISO: "local:iso/drivers.iso"}
+
+	vm := newRunningVM()
+	task := newTask()
+	machineScope.SetVirtualMachine(vm)
+
+	proxmoxClient.EXPECT().ISOExists(context.Background(), "node1", "local:iso/drivers.iso").Return(true, nil).Once()
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm,
+		proxmox.VirtualMachineOption{Name: optionCDROM, Value: "local:iso/drivers.iso,media=cdrom"},
+	).Return(task, nil).Once()
+
+	requeue, err := reconcileCDROM(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+
+	// the mounted image is kept.
+	vm.VirtualMachineConfig.IDE2 = "local:iso/drivers.iso,media=cdrom,size=600M"
+	requeue, err = reconcileCDROM(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+}
+
+func TestReconcileCDROM_MissingISO(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.CDROM = &infrav1alpha1.CDROMSpec{ISO: "local:iso/missing.iso"}
+	machineScope.SetVirtualMachine(newRunningVM())
+
+	proxmoxClient.EXPECT().ISOExists(context.Background(), "node1", "local:iso/missing.iso").Return(false, nil).Once()
+
+	_, err := reconcileCDROM(context.Background(), machineScope)
+	require.ErrorIs(t, err, ErrISONotFound)
+}
+
+func TestReconcileCDROM_Eject(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.CDROM = &infrav1alpha1.CDROMSpec{ISO: "none"}
+
+	vm := newRunningVM()
+	vm.VirtualMachineConfig.IDE2 = "local:iso/drivers.iso,media=cdrom"
+	task := newTask()
+	machineScope.SetVirtualMachine(vm)
+
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm,
+		proxmox.VirtualMachineOption{Name: optionCDROM, Value: "none,media=cdrom"},
+	).Return(task, nil).Once()
+
+	requeue, err := reconcileCDROM(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+}
+
+func TestCDROMContains(t *testing.T) {
+	require.True(t, cdromContains("none,media=cdrom", "none"))
+	require.True(t, cdromContains("local-lvm:vm-100-cloudinit,media=cdrom", "local-lvm:cloudinit"))
+	require.False(t, cdromContains("ceph:vm-100-cloudinit,media=cdrom", "local-lvm:cloudinit"))
+	require.False(t, cdromContains("", "local:iso/drivers.iso"))
+}
+
+func TestReconcileDisks_RunningVM(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{
+		BootVolume: &infrav1alpha1.DiskSize{Disk: "ide0", SizeGB: 100},
+	}
+	machineScope.SetVirtualMachine(newRunningVM())
+
+	require.NoError(t, reconcileDisks(context.Background(), machineScope))
+}
+
+func TestReconcileDisks_ResizeDisk(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{
+		BootVolume: &infrav1alpha1.DiskSize{Disk: "ide0", SizeGB: 100},
+	}
+	vm := newStoppedVM()
+	machineScope.SetVirtualMachine(vm)
+
+	proxmoxClient.EXPECT().ResizeDisk(context.Background(), vm, "ide0", machineScope.ProxmoxMachine.Spec.Disks.BootVolume.FormatSize()).Return(nil)
+
+	require.NoError(t, reconcileDisks(context.Background(), machineScope))
+}
+
+func TestReconcileDisks_SameSize(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{
+		BootVolume: &infrav1alpha1.DiskSize{Disk: "scsi0", SizeGB: 100},
+	}
+	vm := newStoppedVM()
+	vm.VirtualMachineConfig.SCSI0 = "local-lvm:vm-123-disk-0,size=100G"
+	machineScope.SetVirtualMachine(vm)
+
+	require.NoError(t, reconcileDisks(context.Background(), machineScope))
+}
+
+func TestReconcileDisks_ShrinkDisk(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{
+		BootVolume: &infrav1alpha1.DiskSize{Disk: "scsi0", SizeGB: 10},
+	}
+	vm := newStoppedVM()
+	vm.VirtualMachineConfig.SCSI0 = "local-lvm:vm-123-disk-0,iothread=1,size=20G"
+	machineScope.SetVirtualMachine(vm)
+
+	require.ErrorContains(t, reconcileDisks(context.Background(), machineScope), "smaller than the current size 20G")
+	require.Equal(t, capierrors.InvalidConfigurationMachineError, *machineScope.ProxmoxMachine.Status.FailureReason)
+	requireConditionIsFalse(t, machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition)
+}
+
+func TestReconcileNetworkHotplug(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		AdditionalDevices: []infrav1alpha1.AdditionalNetworkDevice{
+			{
+				Name:          "net1",
+				NetworkDevice: infrav1alpha1.NetworkDevice{Bridge: "vmbr1", Model: ptr.To("virtio")},
+			},
+		},
+	}
+	vm := newRunningVM()
+	machineScope.SetVirtualMachine(vm)
+
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: "net1", Value: "virtio,bridge=vmbr1"},
+	}
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(newTask(), nil).Once()
+
+	requeue, err := reconcileNetworkHotplug(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.False(t, conditions.Has(machineScope.ProxmoxMachine, infrav1alpha1.NetworkDevicesCondition))
+}
+
+func TestReconcileNetworkHotplug_RebootRequired(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		AdditionalDevices: []infrav1alpha1.AdditionalNetworkDevice{
+			{
+				Name:          "net1",
+				NetworkDevice: infrav1alpha1.NetworkDevice{Bridge: "vmbr1", Model: ptr.To("virtio")},
+			},
+		},
+	}
+	vm := newRunningVM()
+	vm.VirtualMachineConfig.Hotplug = "disk,usb"
+	machineScope.SetVirtualMachine(vm)
+
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: "net1", Value: "virtio,bridge=vmbr1"},
+	}
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(newTask(), nil).Once()
+
+	requeue, err := reconcileNetworkHotplug(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	requireConditionIsFalse(t, machineScope.ProxmoxMachine, infrav1alpha1.NetworkDevicesCondition)
+	require.Equal(t, infrav1alpha1.RebootRequiredReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.NetworkDevicesCondition))
+
+	// the pending devices are not added again.
+	requeue, err = reconcileNetworkHotplug(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+
+	// after the reboot, the devices are attached.
+	vm.VirtualMachineConfig.Net1 = "virtio=BC:24:11:00:00:01,bridge=vmbr1"
+	requeue, err = reconcileNetworkHotplug(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.True(t, conditions.IsTrue(machineScope.ProxmoxMachine, infrav1alpha1.NetworkDevicesCondition))
+}
+
+func TestReconcileNetworkRates_RunningVM(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		Default: &infrav1alpha1.NetworkDevice{Bridge: "vmbr0", Model: ptr.To("virtio"), RateLimitMBps: ptr.To("12.5")},
+		AdditionalDevices: []infrav1alpha1.AdditionalNetworkDevice{
+			{
+				Name:          "net1",
+				NetworkDevice: infrav1alpha1.NetworkDevice{Bridge: "vmbr1", Model: ptr.To("virtio")},
+			},
+			{
+				Name:          "net2",
+				NetworkDevice: infrav1alpha1.NetworkDevice{Bridge: "vmbr2", Model: ptr.To("virtio"), RateLimitMBps: ptr.To("100")},
+			},
+		},
+	}
+	vm := newRunningVM()
+	vm.VirtualMachineConfig.Net0 = "virtio=BC:24:11:00:00:00,bridge=vmbr0"
+	vm.VirtualMachineConfig.Net1 = "virtio=BC:24:11:00:00:01,bridge=vmbr1,rate=50"
+	vm.VirtualMachineConfig.Net2 = "virtio=BC:24:11:00:00:02,bridge=vmbr2,rate=100.0"
+	machineScope.SetVirtualMachine(vm)
+
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: "net0", Value: "virtio=BC:24:11:00:00:00,bridge=vmbr0,rate=12.5"},
+		proxmox.VirtualMachineOption{Name: "net1", Value: "virtio=BC:24:11:00:00:01,bridge=vmbr1"},
+	}
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(newTask(), nil).Once()
+
+	requeue, err := reconcileNetworkRates(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+}
+
+func TestReconcileDiskThrottle_RunningVM(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{
+		BootVolume: &infrav1alpha1.DiskSize{Disk: "scsi0", SizeGB: 100, Throttle: &infrav1alpha1.DiskThrottle{IOPSRead: ptr.To(int32(500))}},
+		AdditionalVolumes: []infrav1alpha1.DiskSpec{
+			{SizeGB: 50, StoragePool: "local-lvm", Throttle: &infrav1alpha1.DiskThrottle{MBpsWrite: ptr.To(int32(100))}},
+			{SizeGB: 50, StoragePool: "local-lvm"},
+		},
+	}
+	vm := newRunningVM()
+	vm.VirtualMachineConfig.SCSI0 = "local-lvm:vm-123-disk-0,iops_wr=100,size=100G"
+	vm.VirtualMachineConfig.SCSI1 = "local-lvm:vm-123-disk-1,size=50G"
+	vm.VirtualMachineConfig.SCSI2 = "local-lvm:vm-123-disk-2,iops_rd=10,size=50G"
+	machineScope.SetVirtualMachine(vm)
+
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: "scsi0", Value: "local-lvm:vm-123-disk-0,size=100G,iops_rd=500"},
+		proxmox.VirtualMachineOption{Name: "scsi1", Value: "local-lvm:vm-123-disk-1,size=50G,mbps_wr=100"},
+	}
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(newTask(), nil).Once()
+
+	requeue, err := reconcileDiskThrottle(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+}
+
+func TestReconcileDiskThrottle_NoChanges(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{
+		BootVolume: &infrav1alpha1.DiskSize{Disk: "scsi0", SizeGB: 100, Throttle: &infrav1alpha1.DiskThrottle{IOPSRead: ptr.To(int32(500)), IOPSWrite: ptr.To(int32(100))}},
+		AdditionalVolumes: []infrav1alpha1.DiskSpec{
+			{SizeGB: 50, StoragePool: "local-lvm", Throttle: &infrav1alpha1.DiskThrottle{}},
+		},
+	}
+	vm := newRunningVM()
+	vm.VirtualMachineConfig.SCSI0 = "local-lvm:vm-123-disk-0,iops_wr=100,iops_rd=500,size=100G"
+	machineScope.SetVirtualMachine(vm)
+
+	requeue, err := reconcileDiskThrottle(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+}
+
+func TestReconcileMachineAddresses_IPV4(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	vm := newRunningVM()
+	machineScope.SetVirtualMachine(vm)
+	machineScope.SetVirtualMachineID(int64(vm.VMID))
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	machineScope.ProxmoxMachine.Status.BootstrapDataProvided = ptr.To(true)
+
+	requeue, err := reconcileMachineAddresses(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.Equal(t, machineScope.ProxmoxMachine.Status.Addresses[0].Address, machineScope.ProxmoxMachine.GetName())
+	require.Equal(t, machineScope.ProxmoxMachine.Status.Addresses[1].Address, "10.10.10.10")
+}
+
+func TestReconcileMachineAddresses_IPV6(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.InfraCluster.ProxmoxCluster.Spec.IPv4Config = nil
+	machineScope.InfraCluster.ProxmoxCluster.Spec.IPv6Config = &infrav1alpha1.IPConfigSpec{
+		Addresses: []string{"2001:db8::/64"},
+		Prefix:    64,
+		Gateway:   "2001:db8::1",
+	}
+
+	vm := newRunningVM()
+	machineScope.SetVirtualMachine(vm)
+	machineScope.SetVirtualMachineID(int64(vm.VMID))
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV6: "2001:db8::2"}}
+	machineScope.ProxmoxMachine.Status.BootstrapDataProvided = ptr.To(true)
+
+	requeue, err := reconcileMachineAddresses(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.Equal(t, machineScope.ProxmoxMachine.Status.Addresses[0].Address, machineScope.ProxmoxMachine.GetName())
+	require.Equal(t, machineScope.ProxmoxMachine.Status.Addresses[1].Address, "2001:db8::2")
+}
+
+func TestReconcileMachineAddresses_DualStack(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.InfraCluster.ProxmoxCluster.Spec.IPv6Config = &infrav1alpha1.IPConfigSpec{
+		Addresses: []string{"2001:db8::/64"},
+		Prefix:    64,
+		Gateway:   "2001:db8::1",
+	}
 
 	vm := newRunningVM()
 	machineScope.SetVirtualMachine(vm)
@@ -423,12 +1649,266 @@ func TestReconcileMachineAddresses_DualStack(t *testing.T) {
 	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10", IPV6: "2001:db8::2"}}
 	machineScope.ProxmoxMachine.Status.BootstrapDataProvided = ptr.To(true)
 
-	require.NoError(t, reconcileMachineAddresses(machineScope))
+	requeue, err := reconcileMachineAddresses(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
 	require.Equal(t, machineScope.ProxmoxMachine.Status.Addresses[0].Address, machineScope.ProxmoxMachine.GetName())
 	require.Equal(t, machineScope.ProxmoxMachine.Status.Addresses[1].Address, "10.10.10.10")
 	require.Equal(t, machineScope.ProxmoxMachine.Status.Addresses[2].Address, "2001:db8::2")
 }
 
+func TestReconcileMachineAddresses_AdditionalDevices(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		AdditionalDevices: []infrav1alpha1.AdditionalNetworkDevice{
+			{Name: "net1", NetworkDevice: infrav1alpha1.NetworkDevice{Bridge: "vmbr1"}},
+			{Name: "net2", NetworkDevice: infrav1alpha1.NetworkDevice{Bridge: "vmbr2"}},
+		},
+	}
+
+	vm := newRunningVM()
+	machineScope.SetVirtualMachine(vm)
+	machineScope.SetVirtualMachineID(int64(vm.VMID))
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{
+		infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"},
+		"net2":                             {IPV4: "203.0.113.10"},
+		"net1":                             {IPV4: "172.16.0.10"},
+	}
+
+	requeue, err := reconcileMachineAddresses(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.Equal(t, []clusterv1.MachineAddress{
+		{Type: clusterv1.MachineHostName, Address: machineScope.ProxmoxMachine.GetName()},
+		{Type: clusterv1.MachineInternalIP, Address: "10.10.10.10"},
+		{Type: clusterv1.MachineInternalIP, Address: "172.16.0.10"},
+		{Type: clusterv1.MachineExternalIP, Address: "203.0.113.10"},
+	}, machineScope.ProxmoxMachine.Status.Addresses)
+}
+
+func TestReconcileMachineAddresses_AdditionalDevicesDualStack(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.EnableGuestAgent = true
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		AdditionalDevices: []infrav1alpha1.AdditionalNetworkDevice{
+			{Name: "net1", NetworkDevice: infrav1alpha1.NetworkDevice{Bridge: "vmbr1"}},
+		},
+	}
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0", "virtio=A6:23:64:4D:84:CD,bridge=vmbr1")
+	machineScope.SetVirtualMachine(vm)
+	machineScope.SetVirtualMachineID(int64(vm.VMID))
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{
+		infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10", IPV6: "fd00::10"},
+		"net1":                             {IPV4: "172.16.0.10", IPV6: "2001:db8::10"},
+	}
+
+	ifaces := []*proxmox.AgentNetworkIface{
+		{
+			Name:            "eth1",
+			HardwareAddress: "a6:23:64:4d:84:cd",
+			IPAddresses: []*proxmox.AgentNetworkIPAddress{
+				{IPAddressType: "ipv6", IPAddress: "2001:db8::abcd", Prefix: 64},
+				{IPAddressType: "ipv4", IPAddress: "172.16.0.10", Prefix: 24},
+				{IPAddressType: "ipv6", IPAddress: "2001:db8::10", Prefix: 64},
+			},
+		},
+	}
+	proxmoxClient.EXPECT().QemuAgentNetworkInterfaces(context.Background(), vm).Return(ifaces, nil).Once()
+
+	requeue, err := reconcileMachineAddresses(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.Equal(t, []clusterv1.MachineAddress{
+		{Type: clusterv1.MachineHostName, Address: machineScope.ProxmoxMachine.GetName()},
+		{Type: clusterv1.MachineInternalIP, Address: "10.10.10.10"},
+		{Type: clusterv1.MachineInternalIP, Address: "fd00::10"},
+		{Type: clusterv1.MachineInternalIP, Address: "172.16.0.10"},
+		{Type: clusterv1.MachineExternalIP, Address: "2001:db8::10"},
+		{Type: clusterv1.MachineExternalIP, Address: "2001:db8::abcd"},
+	}, machineScope.ProxmoxMachine.Status.Addresses)
+}
+
+func TestReconcileMachineAddresses_GuestAgent(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.EnableGuestAgent = true
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0", "virtio=A6:23:64:4D:84:CD,bridge=vmbr1")
+	machineScope.SetVirtualMachine(vm)
+	machineScope.SetVirtualMachineID(int64(vm.VMID))
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+
+	ifaces := []*proxmox.AgentNetworkIface{
+		{
+			Name: "lo",
+			IPAddresses: []*proxmox.AgentNetworkIPAddress{
+				{IPAddressType: "ipv4", IPAddress: "127.0.0.1", Prefix: 8},
+			},
+		},
+		{
+			Name:            "eth1",
+			HardwareAddress: "a6:23:64:4d:84:cd",
+			IPAddresses: []*proxmox.AgentNetworkIPAddress{
+				{IPAddressType: "ipv4", IPAddress: "192.168.1.50", Prefix: 24},
+				{IPAddressType: "ipv4", IPAddress: "169.254.0.3", Prefix: 16},
+			},
+		},
+		{
+			Name:            "eth0",
+			HardwareAddress: "a6:23:64:4d:84:cb",
+			IPAddresses: []*proxmox.AgentNetworkIPAddress{
+				{IPAddressType: "ipv4", IPAddress: "10.10.10.10", Prefix: 24},
+				{IPAddressType: "ipv6", IPAddress: "fe80::1", Prefix: 64},
+			},
+		},
+		{
+			Name:            "docker0",
+			HardwareAddress: "02:42:ac:11:00:01",
+			IPAddresses: []*proxmox.AgentNetworkIPAddress{
+				{IPAddressType: "ipv4", IPAddress: "172.17.0.1", Prefix: 16},
+			},
+		},
+		{
+			Name:            "kube-ipvs0",
+			HardwareAddress: "ee:5a:9b:3c:21:07",
+			IPAddresses: []*proxmox.AgentNetworkIPAddress{
+				{IPAddressType: "ipv4", IPAddress: "10.96.0.1", Prefix: 32},
+			},
+		},
+	}
+	proxmoxClient.EXPECT().QemuAgentNetworkInterfaces(context.Background(), vm).Return(ifaces, nil).Once()
+
+	requeue, err := reconcileMachineAddresses(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.Equal(t, []clusterv1.MachineAddress{
+		{Type: clusterv1.MachineHostName, Address: machineScope.ProxmoxMachine.GetName()},
+		{Type: clusterv1.MachineInternalIP, Address: "10.10.10.10"},
+		{Type: clusterv1.MachineInternalIP, Address: "192.168.1.50"},
+	}, machineScope.ProxmoxMachine.Status.Addresses)
+	require.True(t, conditions.IsTrue(machineScope.ProxmoxMachine, infrav1alpha1.GuestAgentAddressesCondition))
+}
+
+func TestReconcileMachineAddresses_DHCP4(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.EnableGuestAgent = true
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		Default: &infrav1alpha1.NetworkDevice{Bridge: "vmbr0", DHCP4: true},
+	}
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0")
+	machineScope.SetVirtualMachine(vm)
+	machineScope.SetVirtualMachineID(int64(vm.VMID))
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{}
+
+	ifaces := []*proxmox.AgentNetworkIface{
+		{
+			Name:            "eth0",
+			HardwareAddress: "a6:23:64:4d:84:cb",
+			IPAddresses: []*proxmox.AgentNetworkIPAddress{
+				{IPAddressType: "ipv4", IPAddress: "192.168.1.50", Prefix: 24},
+			},
+		},
+	}
+	proxmoxClient.EXPECT().QemuAgentNetworkInterfaces(context.Background(), vm).Return(ifaces, nil).Once()
+
+	requeue, err := reconcileMachineAddresses(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.Equal(t, []clusterv1.MachineAddress{
+		{Type: clusterv1.MachineHostName, Address: machineScope.ProxmoxMachine.GetName()},
+		{Type: clusterv1.MachineInternalIP, Address: "192.168.1.50"},
+	}, machineScope.ProxmoxMachine.Status.Addresses)
+}
+
+func TestReconcileMachineAddresses_GuestAgentNotResponding(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.EnableGuestAgent = true
+
+	vm := newRunningVM()
+	machineScope.SetVirtualMachine(vm)
+	machineScope.SetVirtualMachineID(int64(vm.VMID))
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+
+	proxmoxClient.EXPECT().QemuAgentNetworkInterfaces(context.Background(), vm).Return(nil, fmt.Errorf("QEMU guest agent is not running")).Once()
+
+	requeue, err := reconcileMachineAddresses(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Len(t, machineScope.ProxmoxMachine.Status.Addresses, 2)
+	require.Equal(t, infrav1alpha1.WaitingForGuestAgentReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.GuestAgentAddressesCondition))
+}
+
+func TestReconcileMachineAddresses_GuestAgentNotResponding_KeepsDiscoveredAddresses(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.EnableGuestAgent = true
+
+	vm := newRunningVM()
+	machineScope.SetVirtualMachine(vm)
+	machineScope.SetVirtualMachineID(int64(vm.VMID))
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	discovered := []clusterv1.MachineAddress{
+		{Type: clusterv1.MachineHostName, Address: machineScope.ProxmoxMachine.GetName()},
+		{Type: clusterv1.MachineInternalIP, Address: "10.10.10.10"},
+		{Type: clusterv1.MachineInternalIP, Address: "192.168.1.50"},
+	}
+	machineScope.SetAddresses(discovered)
+	conditions.MarkTrue(machineScope.ProxmoxMachine, infrav1alpha1.GuestAgentAddressesCondition)
+
+	proxmoxClient.EXPECT().QemuAgentNetworkInterfaces(context.Background(), vm).Return(nil, fmt.Errorf("QEMU guest agent is not running")).Once()
+
+	requeue, err := reconcileMachineAddresses(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.Equal(t, discovered, machineScope.ProxmoxMachine.Status.Addresses)
+	require.Equal(t, infrav1alpha1.GuestAgentUnavailableReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.GuestAgentAddressesCondition))
+}
+
+func TestReconcileMachineAddresses_GuestAgentNotResponding_SkipQemuGuestAgent(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.EnableGuestAgent = true
+	machineScope.ProxmoxMachine.Spec.Checks = &infrav1alpha1.ProxmoxMachineChecks{
+		SkipQemuGuestAgent: ptr.To(true),
+	}
+
+	vm := newRunningVM()
+	machineScope.SetVirtualMachine(vm)
+	machineScope.SetVirtualMachineID(int64(vm.VMID))
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+
+	proxmoxClient.EXPECT().QemuAgentNetworkInterfaces(context.Background(), vm).Return(nil, fmt.Errorf("QEMU guest agent is not running")).Once()
+
+	requeue, err := reconcileMachineAddresses(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.Len(t, machineScope.ProxmoxMachine.Status.Addresses, 2)
+	require.Equal(t, ptr.To(clusterv1.ConditionSeverityWarning), conditions.GetSeverity(machineScope.ProxmoxMachine, infrav1alpha1.GuestAgentAddressesCondition))
+}
+
+func TestReconcileVirtualMachineConfig_GuestAgent(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.EnableGuestAgent = true
+
+	vm := newStoppedVM()
+	task := newTask()
+	machineScope.SetVirtualMachine(vm)
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: optionAgent, Value: 1},
+	}
+
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(task, nil).Once()
+
+	requeue, err := reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+
+	vm.VirtualMachineConfig.Agent = "enabled=1,fstrim_cloned_disks=1"
+	machineScope.ProxmoxMachine.Status.TaskRef = nil
+	requeue, err = reconcileVirtualMachineConfig(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+}
+
 func TestReconcileVirtualMachineConfigVLAN(t *testing.T) {
 	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
 	machineScope.ProxmoxMachine.Spec.NumSockets = 4
@@ -451,8 +1931,8 @@ func TestReconcileVirtualMachineConfigVLAN(t *testing.T) {
 		proxmox.VirtualMachineOption{Name: optionSockets, Value: machineScope.ProxmoxMachine.Spec.NumSockets},
 		proxmox.VirtualMachineOption{Name: optionCores, Value: machineScope.ProxmoxMachine.Spec.NumCores},
 		proxmox.VirtualMachineOption{Name: optionMemory, Value: machineScope.ProxmoxMachine.Spec.MemoryMiB},
-		proxmox.VirtualMachineOption{Name: "net0", Value: formatNetworkDevice("virtio", "vmbr0", nil, ptr.To(uint16(100)))},
-		proxmox.VirtualMachineOption{Name: "net1", Value: formatNetworkDevice("virtio", "vmbr1", nil, ptr.To(uint16(100)))},
+		proxmox.VirtualMachineOption{Name: "net0", Value: formatNetworkDevice(infrav1alpha1.NetworkDevice{Model: ptr.To("virtio"), Bridge: "vmbr0", VLAN: ptr.To(uint16(100))})},
+		proxmox.VirtualMachineOption{Name: "net1", Value: formatNetworkDevice(infrav1alpha1.NetworkDevice{Model: ptr.To("virtio"), Bridge: "vmbr1", VLAN: ptr.To(uint16(100))})},
 	}
 
 	proxmoxClient.EXPECT().ConfigureVM(context.TODO(), vm, expectedOptions...).Return(task, nil).Once()
@@ -3,7 +3,9 @@
 package tlshelper
 
 import (
+	"bytes"
 	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"os"
 )
@@ -26,8 +28,9 @@ func SystemRootsWithFile(filepath string) (*x509.CertPool, error) {
 }
 
 // SystemRootsWithCert tries to load the system root store and to
-// append the given certificate from a PEM encoded block into it. In
-// case there is no pool an empty pool is used to add the certificate.
+// append the given certificates from a PEM encoded bundle into it. In
+// case there is no pool an empty pool is used to add the certificates.
+// Every block of the bundle must be a valid certificate.
 func SystemRootsWithCert(pemBlock []byte) (*x509.CertPool, error) {
 	if len(pemBlock) == 0 {
 		// Zero length / nil, we fall back to default behavior: returning
@@ -35,6 +38,11 @@ func SystemRootsWithCert(pemBlock []byte) (*x509.CertPool, error) {
 		return nil, nil
 	}
 
+	certs, err := parseCertificates(pemBlock)
+	if err != nil {
+		return nil, err
+	}
+
 	rootCerts, err := x509.SystemCertPool()
 	if err != nil && !os.IsNotExist(err) {
 		return nil, fmt.Errorf("loading system cert pool: %w", err)
@@ -46,9 +54,34 @@ func SystemRootsWithCert(pemBlock []byte) (*x509.CertPool, error) {
 		rootCerts = x509.NewCertPool()
 	}
 
-	if !rootCerts.AppendCertsFromPEM(pemBlock) {
-		return nil, fmt.Errorf("certificate was not appended")
+	for _, cert := range certs {
+		rootCerts.AddCert(cert)
 	}
 
 	return rootCerts, nil
 }
+
+// parseCertificates parses all certificates of a PEM encoded bundle.
+// Unlike x509.CertPool.AppendCertsFromPEM, it fails on broken blocks,
+// instead of skipping them.
+func parseCertificates(pemBlock []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for rest := pemBlock; len(bytes.TrimSpace(rest)) > 0; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, fmt.Errorf("certificate %d is not PEM encoded", len(certs)+1)
+		}
+		if block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("certificate %d has unexpected PEM type %q", len(certs)+1, block.Type)
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing certificate %d: %w", len(certs)+1, err)
+		}
+		certs = append(certs, cert)
+	}
+
+	return certs, nil
+}
@@ -18,6 +18,7 @@ limitations under the License.
 package webhook
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"net/netip"
@@ -26,13 +27,16 @@ import (
 
 	"github.com/pkg/errors"
 	"go4.org/netipx"
+	"golang.org/x/crypto/ssh"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/cluster-api/util/annotations"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/kubernetes/ipam"
 )
 
 var _ admission.CustomValidator = &ProxmoxCluster{}
@@ -65,11 +69,45 @@ func (*ProxmoxCluster) ValidateCreate(_ context.Context, obj runtime.Object) (wa
 		return warnings, err
 	}
 
+	if err := validateIPPoolRefs(cluster); err != nil {
+		warnings = append(warnings, fmt.Sprintf("cannot create proxmox cluster %s", cluster.GetName()))
+		return warnings, err
+	}
+
+	if err := validateExternallyManagedCluster(cluster); err != nil {
+		warnings = append(warnings, fmt.Sprintf("cannot create proxmox cluster %s", cluster.GetName()))
+		return warnings, err
+	}
+
 	if err := validateControlPlaneEndpoint(cluster); err != nil {
 		warnings = append(warnings, fmt.Sprintf("cannot create proxmox cluster %s", cluster.GetName()))
 		return warnings, err
 	}
 
+	if err := validateIPConfigs(cluster); err != nil {
+		warnings = append(warnings, fmt.Sprintf("cannot create proxmox cluster %s", cluster.GetName()))
+		return warnings, err
+	}
+
+	if err := validateClusterNTPServers(cluster); err != nil {
+		warnings = append(warnings, fmt.Sprintf("cannot create proxmox cluster %s", cluster.GetName()))
+		return warnings, err
+	}
+	if err := validateClusterSSHAuthorizedKeys(cluster); err != nil {
+		warnings = append(warnings, fmt.Sprintf("cannot create proxmox cluster %s", cluster.GetName()))
+		return warnings, err
+	}
+
+	if err := validateClusterVMNameTemplate(cluster); err != nil {
+		warnings = append(warnings, fmt.Sprintf("cannot create proxmox cluster %s", cluster.GetName()))
+		return warnings, err
+	}
+
+	if err := validateMachineDefaults(cluster); err != nil {
+		warnings = append(warnings, fmt.Sprintf("cannot create proxmox cluster %s", cluster.GetName()))
+		return warnings, err
+	}
+
 	return warnings, nil
 }
 
@@ -85,22 +123,82 @@ func (*ProxmoxCluster) ValidateUpdate(_ context.Context, _ runtime.Object, newOb
 		return warnings, apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxCluster but got %T", newCluster))
 	}
 
+	if err := validateIPPoolRefs(newCluster); err != nil {
+		warnings = append(warnings, fmt.Sprintf("cannot update proxmox cluster %s", newCluster.GetName()))
+		return warnings, err
+	}
+
+	if err := validateExternallyManagedCluster(newCluster); err != nil {
+		warnings = append(warnings, fmt.Sprintf("cannot update proxmox cluster %s", newCluster.GetName()))
+		return warnings, err
+	}
+
 	if err := validateControlPlaneEndpoint(newCluster); err != nil {
 		warnings = append(warnings, fmt.Sprintf("cannot update proxmox cluster %s", newCluster.GetName()))
 		return warnings, err
 	}
 
+	if err := validateIPConfigs(newCluster); err != nil {
+		warnings = append(warnings, fmt.Sprintf("cannot update proxmox cluster %s", newCluster.GetName()))
+		return warnings, err
+	}
+
+	if err := validateClusterNTPServers(newCluster); err != nil {
+		warnings = append(warnings, fmt.Sprintf("cannot update proxmox cluster %s", newCluster.GetName()))
+		return warnings, err
+	}
+	if err := validateClusterSSHAuthorizedKeys(newCluster); err != nil {
+		warnings = append(warnings, fmt.Sprintf("cannot update proxmox cluster %s", newCluster.GetName()))
+		return warnings, err
+	}
+
+	if err := validateClusterVMNameTemplate(newCluster); err != nil {
+		warnings = append(warnings, fmt.Sprintf("cannot update proxmox cluster %s", newCluster.GetName()))
+		return warnings, err
+	}
+
+	if err := validateMachineDefaults(newCluster); err != nil {
+		warnings = append(warnings, fmt.Sprintf("cannot update proxmox cluster %s", newCluster.GetName()))
+		return warnings, err
+	}
+
 	return warnings, nil
 }
 
 func validateControlPlaneEndpoint(cluster *infrav1.ProxmoxCluster) error {
+	gk, name := cluster.GroupVersionKind().GroupKind(), cluster.GetName()
+
 	// Skipping the validation of the Control Plane endpoint in case of externally managed Control Plane:
-	// the Cluster API Control Plane provider will eventually provide the LB.
-	if cluster.Spec.ExternalManagedControlPlane {
+	// the Cluster API Control Plane provider, or the user, will eventually provide the LB.
+	// The same holds for the endpoint of an externally managed cluster.
+	if cluster.Spec.ExternalManagedControlPlane || annotations.IsExternallyManaged(cluster) {
+		if cluster.Spec.ControlPlaneEndpointIPAM != nil {
+			return apierrors.NewInvalid(
+				gk,
+				name,
+				field.ErrorList{
+					field.Forbidden(
+						field.NewPath("spec", "controlPlaneEndpointIPAM"), "an externally managed control plane endpoint is not allocated"),
+				})
+		}
 		return nil
 	}
 
-	gk, name := cluster.GroupVersionKind().GroupKind(), cluster.GetName()
+	// Allocated endpoints are taken from the pools of the cluster, so they are neither
+	// provided by the user nor excluded from the pools.
+	if endpointIPAM := cluster.Spec.ControlPlaneEndpointIPAM; endpointIPAM != nil {
+		if endpointIPAM.PrimaryFamily == "ipv6" && !cluster.HasIPv6() ||
+			endpointIPAM.PrimaryFamily != "ipv6" && !cluster.HasIPv4() {
+			return apierrors.NewInvalid(
+				gk,
+				name,
+				field.ErrorList{
+					field.Invalid(
+						field.NewPath("spec", "controlPlaneEndpointIPAM", "primaryFamily"), endpointIPAM.PrimaryFamily, "primary family requires an ip config or pool of the same family"),
+				})
+		}
+		return nil
+	}
 
 	endpoint := cluster.Spec.ControlPlaneEndpoint.Host
 
@@ -188,27 +286,11 @@ func buildSetFromAddresses(addresses []string) (*netipx.IPSet, error) {
 	builder := netipx.IPSetBuilder{}
 
 	for _, address := range addresses {
-		switch {
-		case strings.Contains(address, "-"):
-			ipRange, err := netipx.ParseIPRange(address)
-			if err != nil {
-				return nil, err
-			}
-			builder.AddRange(ipRange)
-		case strings.Contains(address, "/"):
-			ipPref, err := netip.ParsePrefix(address)
-			if err != nil {
-				return nil, err
-			}
-			builder.AddPrefix(ipPref)
-		default:
-			ipAddress, err := netip.ParseAddr(address)
-			if err != nil {
-				return nil, err
-			}
-
-			builder.Add(ipAddress)
+		ipRange, err := ipam.ParseAddressRange(address)
+		if err != nil {
+			return nil, err
 		}
+		builder.AddRange(ipRange)
 	}
 
 	set, err := builder.IPSet()
@@ -219,8 +301,143 @@ func buildSetFromAddresses(addresses []string) (*netipx.IPSet, error) {
 	return set, nil
 }
 
+// validateIPConfigs makes sure the addresses and gateways of the ip configs are consistent, see ipam.ValidateIPConfig.
+func validateIPConfigs(cluster *infrav1.ProxmoxCluster) error {
+	var allErrs field.ErrorList
+	if cluster.Spec.IPv4Config != nil {
+		allErrs = append(allErrs, ipam.ValidateIPConfig(field.NewPath("spec", "ipv4Config"), cluster.Spec.IPv4Config, true)...)
+	}
+	if cluster.Spec.IPv6Config != nil {
+		allErrs = append(allErrs, ipam.ValidateIPConfig(field.NewPath("spec", "ipv6Config"), cluster.Spec.IPv6Config, false)...)
+	}
+
+	if len(allErrs) > 0 {
+		return apierrors.NewInvalid(cluster.GroupVersionKind().GroupKind(), cluster.GetName(), allErrs)
+	}
+	return nil
+}
+
+// validateIPPoolRefs ensures that each address family is served either by an in-cluster pool or by an external pool.
+func validateIPPoolRefs(cluster *infrav1.ProxmoxCluster) error {
+	var allErrs field.ErrorList
+	if cluster.Spec.IPv4Config != nil && cluster.Spec.IPv4PoolRef != nil {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "ipv4PoolRef"), "cannot be combined with ipv4Config"))
+	}
+	if cluster.Spec.IPv6Config != nil && cluster.Spec.IPv6PoolRef != nil {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "ipv6PoolRef"), "cannot be combined with ipv6Config"))
+	}
+
+	if len(allErrs) > 0 {
+		return apierrors.NewInvalid(cluster.GroupVersionKind().GroupKind(), cluster.GetName(), allErrs)
+	}
+	return nil
+}
+
+// validateExternallyManagedCluster rejects the ip configs of externally managed clusters,
+// since their pools are not created. The machines take their addresses from pool references instead.
+func validateExternallyManagedCluster(cluster *infrav1.ProxmoxCluster) error {
+	if !annotations.IsExternallyManaged(cluster) {
+		return nil
+	}
+
+	var allErrs field.ErrorList
+	if cluster.Spec.IPv4Config != nil {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "ipv4Config"), "the pools of an externally managed cluster are not created, use ipv4PoolRef"))
+	}
+	if cluster.Spec.IPv6Config != nil {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "ipv6Config"), "the pools of an externally managed cluster are not created, use ipv6PoolRef"))
+	}
+
+	if len(allErrs) > 0 {
+		return apierrors.NewInvalid(cluster.GroupVersionKind().GroupKind(), cluster.GetName(), allErrs)
+	}
+	return nil
+}
+
+// validateClusterNTPServers makes sure the NTP servers of the cluster are hostnames or IP addresses.
+func validateClusterNTPServers(cluster *infrav1.ProxmoxCluster) error {
+	if allErrs := validateNTPServers(field.NewPath("spec", "ntpServers"), cluster.Spec.NTPServers); len(allErrs) > 0 {
+		return apierrors.NewInvalid(cluster.GroupVersionKind().GroupKind(), cluster.GetName(), allErrs)
+	}
+	return nil
+}
+
+// validateNTPServers returns an error for every server that is neither a hostname nor an IP address.
+func validateNTPServers(path *field.Path, servers []string) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, server := range servers {
+		if _, err := netip.ParseAddr(server); err == nil || isHostname(server) {
+			continue
+		}
+		allErrs = append(allErrs, field.Invalid(path.Index(i), server, "must be a hostname or an IP address"))
+	}
+	return allErrs
+}
+
+// validateClusterSSHAuthorizedKeys makes sure the SSH keys of the cluster are OpenSSH public keys.
+func validateClusterSSHAuthorizedKeys(cluster *infrav1.ProxmoxCluster) error {
+	if allErrs := validateSSHAuthorizedKeys(field.NewPath("spec", "sshAuthorizedKeys"), cluster.Spec.SSHAuthorizedKeys); len(allErrs) > 0 {
+		return apierrors.NewInvalid(cluster.GroupVersionKind().GroupKind(), cluster.GetName(), allErrs)
+	}
+	return nil
+}
+
+// validateSSHAuthorizedKeys returns an error for every key that does not parse as a single OpenSSH public key.
+func validateSSHAuthorizedKeys(path *field.Path, keys []string) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, key := range keys {
+		if _, _, _, rest, err := ssh.ParseAuthorizedKey([]byte(key)); err != nil || len(bytes.TrimSpace(rest)) > 0 {
+			allErrs = append(allErrs, field.Invalid(path.Index(i), key, "must be a single OpenSSH public key"))
+		}
+	}
+	return allErrs
+}
+
+// validateClusterVMNameTemplate makes sure the VM name template of the cluster is a valid template.
+func validateClusterVMNameTemplate(cluster *infrav1.ProxmoxCluster) error {
+	if allErrs := validateVMNameTemplate(field.NewPath("spec", "vmNameTemplate"), cluster.Spec.VMNameTemplate); len(allErrs) > 0 {
+		return apierrors.NewInvalid(cluster.GroupVersionKind().GroupKind(), cluster.GetName(), allErrs)
+	}
+	return nil
+}
+
+// validateMachineDefaults makes sure the machine defaults pass the validation of the ProxmoxMachines.
+// The defaults are written to the spec of the machines, whose updates would be rejected from then on.
+func validateMachineDefaults(cluster *infrav1.ProxmoxCluster) error {
+	if cluster.Spec.MachineDefaults == nil {
+		return nil
+	}
+
+	machine := &infrav1.ProxmoxMachine{}
+	cluster.Spec.MachineDefaults.ApplyTo(&machine.Spec)
+	for _, validate := range machineValidators {
+		err := validate(machine)
+		if err == nil {
+			continue
+		}
+
+		// the errors of the machine are reported for the fields of the defaults.
+		var status apierrors.APIStatus
+		if !errors.As(err, &status) || status.Status().Details == nil {
+			return err
+		}
+		var allErrs field.ErrorList
+		for _, cause := range status.Status().Details.Causes {
+			errType := field.ErrorType(cause.Type)
+			allErrs = append(allErrs, &field.Error{
+				Type:     errType,
+				Field:    "spec.machineDefaults" + strings.TrimPrefix(cause.Field, "spec"),
+				BadValue: field.OmitValueType{},
+				Detail:   strings.TrimPrefix(cause.Message, errType.String()+": "),
+			})
+		}
+		return apierrors.NewInvalid(cluster.GroupVersionKind().GroupKind(), cluster.GetName(), allErrs)
+	}
+	return nil
+}
+
 func hasNoIPPoolConfig(cluster *infrav1.ProxmoxCluster) bool {
-	return cluster.Spec.IPv4Config == nil && cluster.Spec.IPv6Config == nil
+	return !cluster.HasIPv4() && !cluster.HasIPv6()
 }
 
 func isHostname(h string) bool {
@@ -21,7 +21,9 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -80,6 +82,133 @@ var _ = Describe("Controller Test", func() {
 			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(Succeed())
 		})
 
+		It("should allow an allocated endpoint without host", func() {
+			cluster := validProxmoxCluster("succeed-test-cluster-with-endpoint-ipam")
+			cluster.Spec.ControlPlaneEndpoint = nil
+			cluster.Spec.ControlPlaneEndpointIPAM = &infrav1.ControlPlaneEndpointIPAM{}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(Succeed())
+		})
+
+		It("should disallow an allocated endpoint without ip config of the primary family", func() {
+			cluster := validProxmoxCluster("test-cluster")
+			cluster.Spec.ControlPlaneEndpoint = nil
+			cluster.Spec.ControlPlaneEndpointIPAM = &infrav1.ControlPlaneEndpointIPAM{PrimaryFamily: "ipv6"}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("primary family requires an ip config or pool of the same family")))
+		})
+
+		It("should allow an externally managed endpoint without host", func() {
+			cluster := validProxmoxCluster("succeed-test-cluster-with-external-endpoint")
+			cluster.Spec.ControlPlaneEndpoint = nil
+			cluster.Spec.ExternalManagedControlPlane = true
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(Succeed())
+		})
+
+		It("should disallow allocating an externally managed endpoint", func() {
+			cluster := validProxmoxCluster("test-cluster")
+			cluster.Spec.ControlPlaneEndpoint = nil
+			cluster.Spec.ExternalManagedControlPlane = true
+			cluster.Spec.ControlPlaneEndpointIPAM = &infrav1.ControlPlaneEndpointIPAM{}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("an externally managed control plane endpoint is not allocated")))
+		})
+
+		It("should allow an external IP pool without ip config", func() {
+			cluster := validProxmoxCluster("succeed-test-cluster-with-pool-ref")
+			cluster.Spec.IPv4Config = nil
+			cluster.Spec.IPv4PoolRef = &corev1.TypedLocalObjectReference{
+				APIGroup: ptr.To("ipam.cluster.x-k8s.io"),
+				Kind:     "InfobloxIPPool",
+				Name:     "infoblox-v4",
+			}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(Succeed())
+		})
+
+		It("should disallow an external IP pool combined with ip config of the same family", func() {
+			cluster := validProxmoxCluster("test-cluster")
+			cluster.Spec.IPv4PoolRef = &corev1.TypedLocalObjectReference{
+				APIGroup: ptr.To("ipam.cluster.x-k8s.io"),
+				Kind:     "InfobloxIPPool",
+				Name:     "infoblox-v4",
+			}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("cannot be combined with ipv4Config")))
+		})
+
+		It("should allow an externally managed cluster with an external IP pool", func() {
+			cluster := validProxmoxCluster("succeed-test-cluster-externally-managed")
+			cluster.SetAnnotations(map[string]string{clusterv1.ManagedByAnnotation: "external"})
+			cluster.Spec.ControlPlaneEndpoint = nil
+			cluster.Spec.IPv4Config = nil
+			cluster.Spec.IPv4PoolRef = &corev1.TypedLocalObjectReference{
+				APIGroup: ptr.To("ipam.cluster.x-k8s.io"),
+				Kind:     "InfobloxIPPool",
+				Name:     "infoblox-v4",
+			}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(Succeed())
+		})
+
+		It("should disallow ip configs of an externally managed cluster", func() {
+			cluster := validProxmoxCluster("test-cluster")
+			cluster.SetAnnotations(map[string]string{clusterv1.ManagedByAnnotation: "external"})
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("the pools of an externally managed cluster are not created, use ipv4PoolRef")))
+		})
+
+		It("should allow NTP servers given as hostnames and IP addresses", func() {
+			cluster := validProxmoxCluster("succeed-test-cluster-with-ntp-servers")
+			cluster.Spec.NTPServers = []string{"0.pool.ntp.org", "10.0.0.1", "2001:db8::123"}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(Succeed())
+		})
+
+		It("should disallow invalid NTP servers", func() {
+			cluster := validProxmoxCluster("test-cluster")
+			cluster.Spec.NTPServers = []string{"0.pool.ntp.org", "ntp server"}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("spec.ntpServers[1]: Invalid value")))
+		})
+
+		It("should allow OpenSSH public keys", func() {
+			cluster := validProxmoxCluster("succeed-test-cluster-with-ssh-keys")
+			cluster.Spec.SSHAuthorizedKeys = []string{"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIGm1XHyhBL7XHl8rwNk+hTOqMo6nmxj0K7ZgwsGL+RA9 break-glass"}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(Succeed())
+		})
+
+		It("should disallow invalid SSH keys", func() {
+			cluster := validProxmoxCluster("test-cluster")
+			cluster.Spec.SSHAuthorizedKeys = []string{"ssh-ed25519 not-a-key"}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("spec.sshAuthorizedKeys[0]: Invalid value")))
+		})
+
+		It("should disallow an invalid VM name template", func() {
+			cluster := validProxmoxCluster("test-cluster")
+			cluster.Spec.VMNameTemplate = ptr.To("{{ .ClusterName }")
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("spec.vmNameTemplate: Invalid value")))
+		})
+
+		It("should allow valid machine defaults", func() {
+			cluster := validProxmoxCluster("succeed-test-cluster-with-machine-defaults")
+			cluster.Spec.MachineDefaults = &infrav1.ProxmoxMachineDefaults{
+				CPUType:   "x86-64-v2-AES",
+				MemoryMiB: 4096,
+				Disks: &infrav1.DefaultStorage{
+					BootVolume: &infrav1.DiskSize{Disk: "scsi0", SizeGB: 20},
+				},
+			}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(Succeed())
+		})
+
+		It("should disallow machine defaults the machines would reject", func() {
+			cluster := validProxmoxCluster("test-cluster")
+			cluster.Spec.MachineDefaults = &infrav1.ProxmoxMachineDefaults{CPUType: "X86-64-v2-AES"}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(And(
+				ContainSubstring("spec.machineDefaults.cpuType: Invalid value"),
+				ContainSubstring(`did you mean "x86-64-v2-AES"?`))))
+
+			cluster.Spec.MachineDefaults = &infrav1.ProxmoxMachineDefaults{
+				Disks: &infrav1.DefaultStorage{
+					BootVolume:        &infrav1.DiskSize{Disk: "scsi1", SizeGB: 20},
+					AdditionalVolumes: []infrav1.DiskSpec{{SizeGB: 10, StoragePool: "local-lvm"}},
+				},
+			}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("spec.machineDefaults.disks.bootVolume.disk: Invalid value")))
+		})
+
 		It("should disallow invalid IPV4 IPs", func() {
 			cluster := invalidProxmoxCluster("test-cluster")
 			cluster.Spec.IPv4Config.Addresses = []string{"invalid"}
@@ -96,6 +225,18 @@ var _ = Describe("Controller Test", func() {
 			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("provided addresses are not valid IP addresses, ranges or CIDRs")))
 		})
 
+		It("should disallow overlapping addresses", func() {
+			cluster := validProxmoxCluster("test-cluster")
+			cluster.Spec.IPv4Config.Addresses = []string{"10.10.10.2-10.10.10.10", "10.10.10.8/29"}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("spec.ipv4Config.addresses[1]: Invalid value: \"10.10.10.8/29\": overlaps with 10.10.10.2-10.10.10.10 at index 0")))
+		})
+
+		It("should disallow a gateway outside of the prefix", func() {
+			cluster := validProxmoxCluster("test-cluster")
+			cluster.Spec.IPv4Config.Gateway = "10.10.11.1"
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("not within 10.10.10.0/24 of 10.10.10.2-10.10.10.10 at index 0")))
+		})
+
 		It("should disallow endpoint IP to intersect with node IPs", func() {
 			cluster := invalidProxmoxCluster("test-cluster")
 			cluster.Spec.ControlPlaneEndpoint.Host = "2001:db8::1"
@@ -18,17 +18,30 @@ limitations under the License.
 package webhook
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/netip"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/cloudinit"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/types"
 )
 
 // ProxmoxMachine is a type that implements
@@ -46,6 +59,34 @@ func (p *ProxmoxMachine) SetupWebhookWithManager(mgr ctrl.Manager) error {
 
 //+kubebuilder:webhook:verbs=create;update,path=/validate-infrastructure-cluster-x-k8s-io-v1alpha1-proxmoxmachine,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=proxmoxmachines,versions=v1alpha1,name=validation.proxmoxmachine.infrastructure.cluster.x-k8s.io,admissionReviewVersions=v1
 
+// machineValidators are run on every created and updated ProxmoxMachine.
+var machineValidators = []func(*infrav1.ProxmoxMachine) error{
+	validateNetworks,
+	validateNetworkFormat,
+	validateMemory,
+	validateCPUType,
+	validateCloneMode,
+	validateTemplateSource,
+	validatePCIDevices,
+	validateDisks,
+	validateDescription,
+	validateMachineNTPServers,
+	validateMounts,
+	validateMachineSSHAuthorizedKeys,
+	validateDefaultUser,
+	validateAdditionalUserData,
+	validateVendorData,
+	validateAdoption,
+	validateSnapshot,
+	validateMachineVMNameTemplate,
+	validateBIOS,
+	validateMachineType,
+	validateIOThreads,
+	validateVGA,
+	validateFirewallRules,
+	validateStaticIPAddresses,
+}
+
 // ValidateCreate implements the creation validation function.
 func (p *ProxmoxMachine) ValidateCreate(_ context.Context, obj runtime.Object) (warnings admission.Warnings, err error) {
 	machine, ok := obj.(*infrav1.ProxmoxMachine)
@@ -53,29 +94,64 @@ func (p *ProxmoxMachine) ValidateCreate(_ context.Context, obj runtime.Object) (
 		return warnings, apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxMachine but got %T", obj))
 	}
 
-	err = validateNetworks(machine)
-	if err != nil {
-		warnings = append(warnings, fmt.Sprintf("cannot create proxmox machine %s", machine.GetName()))
-		return warnings, err
+	for _, validate := range machineValidators {
+		err = validate(machine)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("cannot create proxmox machine %s", machine.GetName()))
+			return warnings, err
+		}
 	}
 
-	return warnings, nil
+	return machineWarnings(machine), nil
 }
 
 // ValidateUpdate implements the update validation function.
-func (p *ProxmoxMachine) ValidateUpdate(_ context.Context, _, newObj runtime.Object) (warnings admission.Warnings, err error) {
+func (p *ProxmoxMachine) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (warnings admission.Warnings, err error) {
 	newMachine, ok := newObj.(*infrav1.ProxmoxMachine)
 	if !ok {
 		return warnings, apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxMachine but got %T", newObj))
 	}
+	oldMachine, ok := oldObj.(*infrav1.ProxmoxMachine)
+	if !ok {
+		return warnings, apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxMachine but got %T", oldObj))
+	}
 
-	err = validateNetworks(newMachine)
+	err = validateImmutableFields(oldMachine, newMachine)
 	if err != nil {
 		warnings = append(warnings, fmt.Sprintf("cannot update proxmox machine %s", newMachine.GetName()))
 		return warnings, err
 	}
 
-	return warnings, nil
+	for _, validate := range machineValidators {
+		err = validate(newMachine)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("cannot update proxmox machine %s", newMachine.GetName()))
+			return warnings, err
+		}
+	}
+
+	return machineWarnings(newMachine), nil
+}
+
+// machineWarnings returns the warnings for valid, but likely unintended settings of a ProxmoxMachine.
+func machineWarnings(machine *infrav1.ProxmoxMachine) (warnings admission.Warnings) {
+	if machine.Spec.NUMA && machine.Spec.NumSockets == 1 {
+		warnings = append(warnings, fmt.Sprintf("proxmox machine %s enables numa with a single socket, which exposes only one numa node", machine.GetName()))
+	}
+
+	if len(machine.Spec.FirewallRules) > 0 && !firewallEnabled(machine) {
+		warnings = append(warnings, fmt.Sprintf("proxmox machine %s has firewall rules, but no network device enables the firewall", machine.GetName()))
+	}
+
+	if defaultDHCP4(machine) && !machine.Spec.EnableGuestAgent {
+		warnings = append(warnings, fmt.Sprintf("proxmox machine %s acquires its ipv4 address through dhcp, which is only reported with the guest agent enabled", machine.GetName()))
+	}
+
+	if machine.Spec.PreDeleteSnapshot && !machine.Spec.RetainDisks {
+		warnings = append(warnings, fmt.Sprintf("proxmox machine %s takes a snapshot before deletion, but it is deleted along with the VM unless retainDisks is enabled", machine.GetName()))
+	}
+
+	return warnings
 }
 
 // ValidateDelete implements the deletion validation function.
@@ -83,6 +159,632 @@ func (p *ProxmoxMachine) ValidateDelete(_ context.Context, _ runtime.Object) (wa
 	return nil, nil
 }
 
+func validateMemory(machine *infrav1.ProxmoxMachine) error {
+	if machine.Spec.MemoryMiB == 0 {
+		return nil
+	}
+
+	// the memory has to consist of whole pages, any size needs at least 2 MiB pages.
+	if pageSize := hugepageSize(machine.Spec.Hugepages); pageSize > 0 && machine.Spec.MemoryMiB%pageSize != 0 {
+		return apierrors.NewInvalid(
+			machine.GroupVersionKind().GroupKind(),
+			machine.GetName(),
+			field.ErrorList{
+				field.Invalid(
+					field.NewPath("spec", "memoryMiB"), machine.Spec.MemoryMiB,
+					fmt.Sprintf("memoryMiB must be a multiple of the hugepage size of %d MiB", pageSize)),
+			})
+	}
+
+	minMemory := machine.Spec.MinMemoryMiB
+	if minMemory != nil && *minMemory > machine.Spec.MemoryMiB {
+		return apierrors.NewInvalid(
+			machine.GroupVersionKind().GroupKind(),
+			machine.GetName(),
+			field.ErrorList{
+				field.Invalid(
+					field.NewPath("spec", "minMemoryMiB"), *minMemory,
+					fmt.Sprintf("minMemoryMiB must be less than or equal to memoryMiB %d", machine.Spec.MemoryMiB)),
+			})
+	}
+
+	return nil
+}
+
+// hugepageSize returns the size of the hugepages in MiB, or 0 if hugepages are not used.
+func hugepageSize(hugepages infrav1.Hugepages) int32 {
+	switch hugepages {
+	case infrav1.Hugepages1G:
+		return 1024
+	case infrav1.Hugepages2M, infrav1.HugepagesAny:
+		return 2
+	default:
+		return 0
+	}
+}
+
+func validateCloneMode(machine *infrav1.ProxmoxMachine) error {
+	// linked clones always stay on the storage of the template.
+	if machine.Spec.Full == nil || *machine.Spec.Full || machine.Spec.Storage == nil {
+		return nil
+	}
+
+	return apierrors.NewInvalid(
+		machine.GroupVersionKind().GroupKind(),
+		machine.GetName(),
+		field.ErrorList{
+			field.Invalid(field.NewPath("spec", "storage"), *machine.Spec.Storage,
+				"storage can only be set for full clones, linked clones stay on the storage of the template"),
+		})
+}
+
+func validateTemplateSource(machine *infrav1.ProxmoxMachine) error {
+	if machine.Spec.TemplateID == nil || machine.Spec.TemplateName == nil {
+		return nil
+	}
+
+	return apierrors.NewInvalid(
+		machine.GroupVersionKind().GroupKind(),
+		machine.GetName(),
+		field.ErrorList{
+			field.Forbidden(field.NewPath("spec", "templateName"), "templateID and templateName are mutually exclusive"),
+		})
+}
+
+// validateImmutableFields rejects changes to the clone source of a machine once its VM is cloned,
+// as they only apply to new VMs. Disks can't shrink either.
+func validateImmutableFields(oldMachine, newMachine *infrav1.ProxmoxMachine) error {
+	// the VM was not cloned yet.
+	if oldMachine.Spec.VirtualMachineID == nil {
+		return nil
+	}
+
+	var allErrs field.ErrorList
+	oldSpec, newSpec := oldMachine.Spec.VirtualMachineCloneSpec, newMachine.Spec.VirtualMachineCloneSpec
+	for _, f := range []struct {
+		name               string
+		oldValue, newValue any
+	}{
+		{"sourceNode", oldSpec.SourceNode, newSpec.SourceNode},
+		{"templateID", oldSpec.TemplateID, newSpec.TemplateID},
+		{"templateName", oldSpec.TemplateName, newSpec.TemplateName},
+		{"full", oldSpec.Full, newSpec.Full},
+		{"format", oldSpec.Format, newSpec.Format},
+		{"snapName", oldSpec.SnapName, newSpec.SnapName},
+		{"storage", oldSpec.Storage, newSpec.Storage},
+		{"target", oldSpec.Target, newSpec.Target},
+		{"pool", oldSpec.Pool, newSpec.Pool},
+	} {
+		if !reflect.DeepEqual(f.oldValue, f.newValue) {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", f.name),
+				"cannot be changed once the VM is cloned, replace the machine instead"))
+		}
+	}
+
+	if oldDisks, newDisks := oldMachine.Spec.Disks, newMachine.Spec.Disks; oldDisks != nil && newDisks != nil {
+		path := field.NewPath("spec", "disks")
+		if oldDisks.BootVolume != nil && newDisks.BootVolume != nil && newDisks.BootVolume.SizeGB < oldDisks.BootVolume.SizeGB {
+			allErrs = append(allErrs, field.Invalid(path.Child("bootVolume", "sizeGb"), newDisks.BootVolume.SizeGB,
+				fmt.Sprintf("cannot shrink from %dG, replace the machine instead", oldDisks.BootVolume.SizeGB)))
+		}
+		for i := range min(len(oldDisks.AdditionalVolumes), len(newDisks.AdditionalVolumes)) {
+			if oldSize, newSize := oldDisks.AdditionalVolumes[i].SizeGB, newDisks.AdditionalVolumes[i].SizeGB; newSize < oldSize {
+				allErrs = append(allErrs, field.Invalid(path.Child("additionalVolumes").Index(i).Child("sizeGb"), newSize,
+					fmt.Sprintf("cannot shrink from %dG, replace the machine instead", oldSize)))
+			}
+		}
+	}
+
+	if len(allErrs) > 0 {
+		return apierrors.NewInvalid(newMachine.GroupVersionKind().GroupKind(), newMachine.GetName(), allErrs)
+	}
+	return nil
+}
+
+func validatePCIDevices(machine *infrav1.ProxmoxMachine) error {
+	if machine.Spec.Target != nil {
+		return nil
+	}
+
+	// raw device ids are only valid on the node they were taken from.
+	for i, device := range machine.Spec.PCIDevices {
+		if device.DeviceID != nil {
+			return apierrors.NewInvalid(
+				machine.GroupVersionKind().GroupKind(),
+				machine.GetName(),
+				field.ErrorList{
+					field.Invalid(field.NewPath("spec", "pciDevices").Index(i).Child("deviceID"), *device.DeviceID,
+						"raw pci device ids require a target node, use a resource mapping instead"),
+				})
+		}
+	}
+
+	return nil
+}
+
+// validateDisks makes sure the boot volume does not occupy a slot of the additional volumes,
+// which are attached as scsi1 to scsiN.
+func validateDisks(machine *infrav1.ProxmoxMachine) error {
+	disks := machine.Spec.Disks
+	if disks == nil || disks.BootVolume == nil {
+		return nil
+	}
+
+	for i := range disks.AdditionalVolumes {
+		if disks.BootVolume.Disk == fmt.Sprintf("scsi%d", i+1) {
+			return apierrors.NewInvalid(
+				machine.GroupVersionKind().GroupKind(),
+				machine.GetName(),
+				field.ErrorList{
+					field.Invalid(field.NewPath("spec", "disks", "bootVolume", "disk"), disks.BootVolume.Disk,
+						fmt.Sprintf("collides with additional volume %d", i)),
+				})
+		}
+	}
+
+	// additional volumes are always scsi disks, which support both options.
+	bus := strings.TrimRight(disks.BootVolume.Disk, "0123456789")
+	if disks.BootVolume.Discard && bus != "scsi" && bus != "virtio" {
+		return apierrors.NewInvalid(
+			machine.GroupVersionKind().GroupKind(),
+			machine.GetName(),
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "disks", "bootVolume", "discard"), disks.BootVolume.Discard,
+					fmt.Sprintf("discard is only supported on scsi and virtio disks, not %s", disks.BootVolume.Disk)),
+			})
+	}
+	if disks.BootVolume.SSDEmulation && bus == "virtio" {
+		return apierrors.NewInvalid(
+			machine.GroupVersionKind().GroupKind(),
+			machine.GetName(),
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "disks", "bootVolume", "ssd"), disks.BootVolume.SSDEmulation,
+					fmt.Sprintf("ssd emulation is not supported on virtio disks like %s", disks.BootVolume.Disk)),
+			})
+	}
+
+	return nil
+}
+
+// validateDescription makes sure the description is a valid template.
+func validateDescription(machine *infrav1.ProxmoxMachine) error {
+	if machine.Spec.Description == nil {
+		return nil
+	}
+
+	if _, err := template.New("description").Parse(*machine.Spec.Description); err != nil {
+		return apierrors.NewInvalid(
+			machine.GroupVersionKind().GroupKind(),
+			machine.GetName(),
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "description"), *machine.Spec.Description,
+					fmt.Sprintf("invalid template: %s", err)),
+			})
+	}
+
+	return nil
+}
+
+// validateMachineNTPServers makes sure the NTP servers of the machine are hostnames or IP addresses.
+func validateMachineNTPServers(machine *infrav1.ProxmoxMachine) error {
+	if allErrs := validateNTPServers(field.NewPath("spec", "ntpServers"), machine.Spec.NTPServers); len(allErrs) > 0 {
+		return apierrors.NewInvalid(machine.GroupVersionKind().GroupKind(), machine.GetName(), allErrs)
+	}
+	return nil
+}
+
+// validateMachineSSHAuthorizedKeys makes sure the SSH keys of the machine are OpenSSH public keys.
+func validateMachineSSHAuthorizedKeys(machine *infrav1.ProxmoxMachine) error {
+	if allErrs := validateSSHAuthorizedKeys(field.NewPath("spec", "sshAuthorizedKeys"), machine.Spec.SSHAuthorizedKeys); len(allErrs) > 0 {
+		return apierrors.NewInvalid(machine.GroupVersionKind().GroupKind(), machine.GetName(), allErrs)
+	}
+	return nil
+}
+
+// validateDefaultUser makes sure a machine without the default user keeps a user to log in with.
+func validateDefaultUser(machine *infrav1.ProxmoxMachine) error {
+	var allErrs field.ErrorList
+	switch {
+	case machine.Spec.DisableDefaultUser && machine.Spec.LoginUser == "":
+		allErrs = append(allErrs, field.Required(field.NewPath("spec", "loginUser"), "disabling the default user requires an existing user to log in with"))
+	case !machine.Spec.DisableDefaultUser && machine.Spec.LoginUser != "":
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "loginUser"), "only applies with disableDefaultUser"))
+	}
+	if len(allErrs) > 0 {
+		return apierrors.NewInvalid(machine.GroupVersionKind().GroupKind(), machine.GetName(), allErrs)
+	}
+	return nil
+}
+
+// validateAdditionalUserData makes sure the additional user data is valid cloud-config.
+func validateAdditionalUserData(machine *infrav1.ProxmoxMachine) error {
+	if machine.Spec.AdditionalUserData == "" {
+		return nil
+	}
+
+	if err := cloudinit.ValidateUserData(machine.Spec.AdditionalUserData); err != nil {
+		return apierrors.NewInvalid(
+			machine.GroupVersionKind().GroupKind(),
+			machine.GetName(),
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "additionalUserData"), machine.Spec.AdditionalUserData, err.Error()),
+			})
+	}
+
+	return nil
+}
+
+// validateVendorData makes sure the vendor data is valid cloud-config.
+func validateVendorData(machine *infrav1.ProxmoxMachine) error {
+	if machine.Spec.VendorData == "" {
+		return nil
+	}
+
+	if err := cloudinit.ValidateUserData(machine.Spec.VendorData); err != nil {
+		return apierrors.NewInvalid(
+			machine.GroupVersionKind().GroupKind(),
+			machine.GetName(),
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "vendorData"), machine.Spec.VendorData, err.Error()),
+			})
+	}
+
+	return nil
+}
+
+// validateMounts makes sure every mount has a distinct device below /dev, and a distinct
+// mount point unless it is swap.
+func validateMounts(machine *infrav1.ProxmoxMachine) error {
+	var errs field.ErrorList
+	devices := make(map[string]struct{}, len(machine.Spec.Mounts))
+	mountPoints := make(map[string]struct{}, len(machine.Spec.Mounts))
+	for i, mount := range machine.Spec.Mounts {
+		path := field.NewPath("spec", "mounts").Index(i)
+
+		if !strings.HasPrefix(mount.Device, "/dev/") || filepath.Clean(mount.Device) != mount.Device {
+			errs = append(errs, field.Invalid(path.Child("device"), mount.Device, "must be a clean path below /dev"))
+		}
+		if _, ok := devices[mount.Device]; ok {
+			errs = append(errs, field.Duplicate(path.Child("device"), mount.Device))
+		}
+		devices[mount.Device] = struct{}{}
+
+		if mount.FSType == infrav1.FilesystemSwap {
+			if mount.MountPoint != "" {
+				errs = append(errs, field.Forbidden(path.Child("mountPoint"), "swap is not mounted at a path"))
+			}
+			continue
+		}
+
+		switch {
+		case mount.MountPoint == "":
+			errs = append(errs, field.Required(path.Child("mountPoint"), "must be set, except for swap"))
+		case !filepath.IsAbs(mount.MountPoint) || filepath.Clean(mount.MountPoint) != mount.MountPoint || mount.MountPoint == "/":
+			errs = append(errs, field.Invalid(path.Child("mountPoint"), mount.MountPoint, "must be a clean absolute path other than /"))
+		}
+		if _, ok := mountPoints[mount.MountPoint]; ok && mount.MountPoint != "" {
+			errs = append(errs, field.Duplicate(path.Child("mountPoint"), mount.MountPoint))
+		}
+		mountPoints[mount.MountPoint] = struct{}{}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(machine.GroupVersionKind().GroupKind(), machine.GetName(), errs)
+}
+
+// validateAdoption makes sure the deletion policy is only set for adopted VMs.
+func validateAdoption(machine *infrav1.ProxmoxMachine) error {
+	if machine.Spec.DeletionPolicy == "" || machine.Spec.ExistingVMID != nil {
+		return nil
+	}
+
+	return apierrors.NewInvalid(
+		machine.GroupVersionKind().GroupKind(),
+		machine.GetName(),
+		field.ErrorList{
+			field.Invalid(field.NewPath("spec", "deletionPolicy"), machine.Spec.DeletionPolicy,
+				"only applies to VMs adopted with existingVMID"),
+		})
+}
+
+// validateSnapshot makes sure the snapshot name is a valid template, and the TTL only applies to retained VMs.
+func validateSnapshot(machine *infrav1.ProxmoxMachine) error {
+	var errs field.ErrorList
+	if name := machine.Spec.SnapshotName; name != nil {
+		if _, err := template.New("snapshotName").Parse(*name); err != nil {
+			errs = append(errs, field.Invalid(field.NewPath("spec", "snapshotName"), *name,
+				fmt.Sprintf("invalid template: %s", err)))
+		}
+	}
+
+	if ttl := machine.Spec.SnapshotTTL; ttl != nil {
+		if ttl.Duration <= 0 {
+			errs = append(errs, field.Invalid(field.NewPath("spec", "snapshotTTL"), ttl.Duration.String(), "must be positive"))
+		}
+		if !machine.Spec.RetainDisks {
+			errs = append(errs, field.Invalid(field.NewPath("spec", "snapshotTTL"), ttl.Duration.String(), "requires retainDisks"))
+		}
+	}
+
+	if len(errs) > 0 {
+		return apierrors.NewInvalid(machine.GroupVersionKind().GroupKind(), machine.GetName(), errs)
+	}
+	return nil
+}
+
+// validateMachineVMNameTemplate makes sure the VM name template of the machine is a valid template.
+func validateMachineVMNameTemplate(machine *infrav1.ProxmoxMachine) error {
+	if allErrs := validateVMNameTemplate(field.NewPath("spec", "vmNameTemplate"), machine.Spec.VMNameTemplate); len(allErrs) > 0 {
+		return apierrors.NewInvalid(machine.GroupVersionKind().GroupKind(), machine.GetName(), allErrs)
+	}
+	return nil
+}
+
+// validateVMNameTemplate returns an error if the VM name template does not parse.
+func validateVMNameTemplate(path *field.Path, text *string) field.ErrorList {
+	if text == nil {
+		return nil
+	}
+	if _, err := template.New("vmName").Parse(*text); err != nil {
+		return field.ErrorList{field.Invalid(path, *text, fmt.Sprintf("invalid template: %s", err))}
+	}
+	return nil
+}
+
+// validateBIOS makes sure VMs booting with OVMF get an EFI disk on a storage.
+func validateBIOS(machine *infrav1.ProxmoxMachine) error {
+	if machine.Spec.BIOS != infrav1.BIOSOVMF || machine.Spec.EFIDisk != nil {
+		return nil
+	}
+
+	return apierrors.NewInvalid(
+		machine.GroupVersionKind().GroupKind(),
+		machine.GetName(),
+		field.ErrorList{
+			field.Required(field.NewPath("spec", "efiDisk"), "ovmf requires an efi disk with a storage"),
+		})
+}
+
+// validateMachineType makes sure PCI express devices are only passed through to q35 machines.
+// VMs without a machine type inherit the one of their template, which is not checked.
+func validateMachineType(machine *infrav1.ProxmoxMachine) error {
+	machineType := machine.Spec.MachineType
+	if machineType == "" || machineType == "q35" || strings.HasPrefix(machineType, "pc-q35-") {
+		return nil
+	}
+
+	for i, device := range machine.Spec.PCIDevices {
+		if device.PCIE {
+			return apierrors.NewInvalid(
+				machine.GroupVersionKind().GroupKind(),
+				machine.GetName(),
+				field.ErrorList{
+					field.Invalid(field.NewPath("spec", "pciDevices").Index(i).Child("pcie"), device.PCIE,
+						fmt.Sprintf("pcie requires the q35 machine type, not %s", machineType)),
+				})
+		}
+	}
+
+	return nil
+}
+
+// validateIOThreads makes sure SCSI disks only get IO threads with the virtio-scsi-single controller.
+// VMs without a SCSI controller inherit the one of their template, which is not checked.
+func validateIOThreads(machine *infrav1.ProxmoxMachine) error {
+	controller := machine.Spec.SCSIController
+	disks := machine.Spec.Disks
+	if controller == "" || controller == infrav1.SCSIControllerVirtIOSingle || disks == nil {
+		return nil
+	}
+
+	invalid := func(path *field.Path) error {
+		return apierrors.NewInvalid(
+			machine.GroupVersionKind().GroupKind(),
+			machine.GetName(),
+			field.ErrorList{
+				field.Invalid(path, true, fmt.Sprintf("iothread on scsi disks requires the virtio-scsi-single controller, not %s", controller)),
+			})
+	}
+
+	if bv := disks.BootVolume; bv != nil && bv.IOThread && strings.HasPrefix(bv.Disk, "scsi") {
+		return invalid(field.NewPath("spec", "disks", "bootVolume", "iothread"))
+	}
+	for i, disk := range disks.AdditionalVolumes {
+		if disk.IOThread {
+			return invalid(field.NewPath("spec", "disks", "additionalVolumes").Index(i).Child("iothread"))
+		}
+	}
+
+	return nil
+}
+
+// validateVGA makes sure the serial console is not disabled when it is used as display.
+func validateVGA(machine *infrav1.ProxmoxMachine) error {
+	if machine.Spec.VGA != "serial0" || ptr.Deref(machine.Spec.SerialConsole, true) {
+		return nil
+	}
+
+	return apierrors.NewInvalid(
+		machine.GroupVersionKind().GroupKind(),
+		machine.GetName(),
+		field.ErrorList{
+			field.Invalid(field.NewPath("spec", "vga"), machine.Spec.VGA, "serial0 requires the serial console"),
+		})
+}
+
+// validateFirewallRules ensures Proxmox accepts the ports and addresses of the firewall rules.
+func validateFirewallRules(machine *infrav1.ProxmoxMachine) error {
+	var errs field.ErrorList
+	for i, rule := range machine.Spec.FirewallRules {
+		path := field.NewPath("spec", "firewallRules").Index(i)
+
+		if rule.Port != "" {
+			if rule.Protocol != "tcp" && rule.Protocol != "udp" {
+				errs = append(errs, field.Invalid(path.Child("port"), rule.Port, "port requires the tcp or udp protocol"))
+			} else if err := validateFirewallPorts(rule.Port); err != nil {
+				errs = append(errs, field.Invalid(path.Child("port"), rule.Port, err.Error()))
+			}
+		}
+
+		source, err := parseFirewallAddress(rule.Source)
+		if err != nil {
+			errs = append(errs, field.Invalid(path.Child("source"), rule.Source, err.Error()))
+		}
+		destination, err := parseFirewallAddress(rule.Destination)
+		if err != nil {
+			errs = append(errs, field.Invalid(path.Child("destination"), rule.Destination, err.Error()))
+		}
+
+		if source.IsValid() && destination.IsValid() && source.Addr().Is4() != destination.Addr().Is4() {
+			errs = append(errs, field.Invalid(path.Child("destination"), rule.Destination, "source and destination must be of the same ip family"))
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(machine.GroupVersionKind().GroupKind(), machine.GetName(), errs)
+}
+
+// validateStaticIPAddresses ensures the static addresses of the default network device match their ip family.
+func validateStaticIPAddresses(machine *infrav1.ProxmoxMachine) error {
+	var errs field.ErrorList
+	for _, static := range []struct {
+		spec *infrav1.IPAddressSpec
+		path *field.Path
+		ipv4 bool
+	}{
+		{machine.Spec.IPv4, field.NewPath("spec", "ipv4"), true},
+		{machine.Spec.IPv6, field.NewPath("spec", "ipv6"), false},
+	} {
+		if static.spec == nil {
+			continue
+		}
+
+		addr, err := netip.ParseAddr(static.spec.Address)
+		if err != nil || addr.Is4() != static.ipv4 || addr.Zone() != "" {
+			errs = append(errs, field.Invalid(static.path.Child("address"), static.spec.Address, "address is not a valid ip of this family"))
+			continue
+		}
+		if static.spec.Prefix < 0 || static.spec.Prefix > addr.BitLen() {
+			errs = append(errs, field.Invalid(static.path.Child("prefix"), static.spec.Prefix, fmt.Sprintf("prefix must be between 0 and %d", addr.BitLen())))
+		}
+		if static.spec.Gateway != "" {
+			gateway, err := netip.ParseAddr(static.spec.Gateway)
+			if err != nil || gateway.Is4() != static.ipv4 {
+				errs = append(errs, field.Invalid(static.path.Child("gateway"), static.spec.Gateway, "gateway is not a valid ip of this family"))
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(machine.GroupVersionKind().GroupKind(), machine.GetName(), errs)
+}
+
+// validateFirewallPorts validates a comma separated list of ports and port ranges like 80,8000:8080.
+func validateFirewallPorts(ports string) error {
+	for _, port := range strings.Split(ports, ",") {
+		low, high, isRange := strings.Cut(port, ":")
+		if !isRange {
+			high = low
+		}
+
+		l, errLow := strconv.ParseUint(low, 10, 16)
+		h, errHigh := strconv.ParseUint(high, 10, 16)
+		if errLow != nil || errHigh != nil || l == 0 || l > h {
+			return fmt.Errorf("invalid port or port range %q", port)
+		}
+	}
+	return nil
+}
+
+// parseFirewallAddress parses an ip address or CIDR of a firewall rule. Empty addresses match any address.
+func parseFirewallAddress(address string) (netip.Prefix, error) {
+	if address == "" {
+		return netip.Prefix{}, nil
+	}
+
+	if prefix, err := netip.ParsePrefix(address); err == nil {
+		return prefix, nil
+	}
+
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%q is neither an ip address nor a CIDR", address)
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// firewallEnabled returns whether any network device of the machine enables the firewall.
+func firewallEnabled(machine *infrav1.ProxmoxMachine) bool {
+	network := machine.Spec.Network
+	if network == nil {
+		return false
+	}
+
+	if network.Default != nil && network.Default.Firewall {
+		return true
+	}
+
+	return slices.ContainsFunc(network.AdditionalDevices, func(device infrav1.AdditionalNetworkDevice) bool {
+		return device.Firewall
+	})
+}
+
+// defaultDHCP4 returns whether the default network device acquires its ipv4 address through dhcp.
+func defaultDHCP4(machine *infrav1.ProxmoxMachine) bool {
+	network := machine.Spec.Network
+	return network != nil && network.Default != nil && network.Default.DHCP4
+}
+
+// knownCPUTypes contains the CPU types supported by Proxmox VE.
+// Custom CPU models are prefixed with "custom-".
+var knownCPUTypes = []string{
+	"486", "athlon", "Broadwell", "Broadwell-IBRS", "Broadwell-noTSX", "Broadwell-noTSX-IBRS",
+	"Cascadelake-Server", "Cascadelake-Server-noTSX", "Cascadelake-Server-v2", "Cascadelake-Server-v4",
+	"Cascadelake-Server-v5", "Conroe", "Cooperlake", "Cooperlake-v2", "core2duo", "coreduo",
+	"EPYC", "EPYC-Genoa", "EPYC-IBPB", "EPYC-Milan", "EPYC-Milan-v2", "EPYC-Rome", "EPYC-Rome-v2",
+	"EPYC-Rome-v3", "EPYC-Rome-v4", "EPYC-v3", "EPYC-v4", "GraniteRapids", "Haswell", "Haswell-IBRS",
+	"Haswell-noTSX", "Haswell-noTSX-IBRS", "host", "Icelake-Client", "Icelake-Client-noTSX",
+	"Icelake-Server", "Icelake-Server-noTSX", "Icelake-Server-v3", "Icelake-Server-v4",
+	"Icelake-Server-v5", "Icelake-Server-v6", "IvyBridge", "IvyBridge-IBRS", "KnightsMill",
+	"kvm32", "kvm64", "max", "Nehalem", "Nehalem-IBRS", "Opteron_G1", "Opteron_G2", "Opteron_G3",
+	"Opteron_G4", "Opteron_G5", "Penryn", "pentium", "pentium2", "pentium3", "phenom", "qemu32",
+	"qemu64", "SandyBridge", "SandyBridge-IBRS", "SapphireRapids", "SapphireRapids-v2",
+	"Skylake-Client", "Skylake-Client-IBRS", "Skylake-Client-noTSX-IBRS", "Skylake-Client-v4",
+	"Skylake-Server", "Skylake-Server-IBRS", "Skylake-Server-noTSX-IBRS", "Skylake-Server-v4",
+	"Skylake-Server-v5", "Westmere", "Westmere-IBRS", "x86-64-v2", "x86-64-v2-AES", "x86-64-v3",
+	"x86-64-v4",
+}
+
+func validateCPUType(machine *infrav1.ProxmoxMachine) error {
+	cpuType := machine.Spec.CPUType
+	if cpuType == "" || strings.HasPrefix(cpuType, "custom-") || slices.Contains(knownCPUTypes, cpuType) {
+		return nil
+	}
+
+	msg := fmt.Sprintf("unknown cpu type %q", cpuType)
+	for _, known := range knownCPUTypes {
+		if strings.EqualFold(known, cpuType) {
+			msg = fmt.Sprintf("%s, did you mean %q?", msg, known)
+			break
+		}
+	}
+
+	return apierrors.NewInvalid(
+		machine.GroupVersionKind().GroupKind(),
+		machine.GetName(),
+		field.ErrorList{
+			field.Invalid(field.NewPath("spec", "cpuType"), cpuType, msg),
+		})
+}
+
 func validateNetworks(machine *infrav1.ProxmoxMachine) error {
 	if machine.Spec.Network == nil {
 		return nil
@@ -101,6 +803,54 @@ func validateNetworks(machine *infrav1.ProxmoxMachine) error {
 						field.NewPath("spec", "network", "default", "mtu"), machine.Spec.Network.Default, err.Error()),
 				})
 		}
+		err = validateNetworkDeviceMAC(machine.Spec.Network.Default)
+		if err != nil {
+			return apierrors.NewInvalid(
+				gk,
+				name,
+				field.ErrorList{
+					field.Invalid(
+						field.NewPath("spec", "network", "default", "macAddress"), machine.Spec.Network.Default.MACAddress, err.Error()),
+				})
+		}
+		err = validateNetworkDeviceQueues(machine.Spec.Network.Default)
+		if err != nil {
+			return apierrors.NewInvalid(
+				gk,
+				name,
+				field.ErrorList{
+					field.Invalid(
+						field.NewPath("spec", "network", "default", "queues"), machine.Spec.Network.Default.Queues, err.Error()),
+				})
+		}
+		if dynamicIPv6(machine.Spec.Network.Default.IPv6Mode) && machine.Spec.IPv6 != nil {
+			return apierrors.NewInvalid(
+				gk,
+				name,
+				field.ErrorList{
+					field.Invalid(
+						field.NewPath("spec", "network", "default", "ipv6Mode"), machine.Spec.Network.Default.IPv6Mode, "dhcp and slaac modes don't allow a static ipv6 address"),
+				})
+		}
+		if machine.Spec.Network.Default.DHCP4 && machine.Spec.IPv4 != nil {
+			return apierrors.NewInvalid(
+				gk,
+				name,
+				field.ErrorList{
+					field.Invalid(
+						field.NewPath("spec", "network", "default", "dhcp4"), machine.Spec.Network.Default.DHCP4, "dhcp4 doesn't allow a static ipv4 address"),
+				})
+		}
+	}
+
+	if err := validateRoutes(machine.Spec.Network.Routes); err != nil {
+		return apierrors.NewInvalid(
+			gk,
+			name,
+			field.ErrorList{
+				field.Invalid(
+					field.NewPath("spec", "network", "routes"), machine.Spec.Network.Routes, err.Error()),
+			})
 	}
 
 	for i := range machine.Spec.Network.AdditionalDevices {
@@ -114,6 +864,45 @@ func validateNetworks(machine *infrav1.ProxmoxMachine) error {
 						field.NewPath("spec", "network", "additionalDevices", fmt.Sprint(i), "mtu"), machine.Spec.Network.AdditionalDevices[i], err.Error()),
 				})
 		}
+		err = validateNetworkDeviceMAC(&machine.Spec.Network.AdditionalDevices[i].NetworkDevice)
+		if err != nil {
+			return apierrors.NewInvalid(
+				gk,
+				name,
+				field.ErrorList{
+					field.Invalid(
+						field.NewPath("spec", "network", "additionalDevices", fmt.Sprint(i), "macAddress"), machine.Spec.Network.AdditionalDevices[i].MACAddress, err.Error()),
+				})
+		}
+		err = validateNetworkDeviceQueues(&machine.Spec.Network.AdditionalDevices[i].NetworkDevice)
+		if err != nil {
+			return apierrors.NewInvalid(
+				gk,
+				name,
+				field.ErrorList{
+					field.Invalid(
+						field.NewPath("spec", "network", "additionalDevices", fmt.Sprint(i), "queues"), machine.Spec.Network.AdditionalDevices[i].Queues, err.Error()),
+				})
+		}
+		err = validateAdditionalDeviceIPv6Mode(&machine.Spec.Network.AdditionalDevices[i])
+		if err != nil {
+			return apierrors.NewInvalid(
+				gk,
+				name,
+				field.ErrorList{
+					field.Invalid(
+						field.NewPath("spec", "network", "additionalDevices", fmt.Sprint(i), "ipv6Mode"), machine.Spec.Network.AdditionalDevices[i].IPv6Mode, err.Error()),
+				})
+		}
+		if machine.Spec.Network.AdditionalDevices[i].DHCP4 && machine.Spec.Network.AdditionalDevices[i].IPv4PoolRef != nil {
+			return apierrors.NewInvalid(
+				gk,
+				name,
+				field.ErrorList{
+					field.Invalid(
+						field.NewPath("spec", "network", "additionalDevices", fmt.Sprint(i), "dhcp4"), machine.Spec.Network.AdditionalDevices[i].DHCP4, "dhcp4 doesn't allow an ipv4PoolRef"),
+				})
+		}
 		err = validateInterfaceConfigMTU(&machine.Spec.Network.AdditionalDevices[i].InterfaceConfig)
 		if err != nil {
 			return apierrors.NewInvalid(
@@ -134,6 +923,16 @@ func validateNetworks(machine *infrav1.ProxmoxMachine) error {
 						field.NewPath("spec", "network", "additionalDevices", fmt.Sprint(i), "routingPolicy"), machine.Spec.Network.AdditionalDevices[i], err.Error()),
 				})
 		}
+		err = validateRoutes(machine.Spec.Network.AdditionalDevices[i].InterfaceConfig.Routes)
+		if err != nil {
+			return apierrors.NewInvalid(
+				gk,
+				name,
+				field.ErrorList{
+					field.Invalid(
+						field.NewPath("spec", "network", "additionalDevices", fmt.Sprint(i), "routes"), machine.Spec.Network.AdditionalDevices[i], err.Error()),
+				})
+		}
 	}
 
 	for i := range machine.Spec.Network.VirtualNetworkDevices.VRFs {
@@ -147,8 +946,231 @@ func validateNetworks(machine *infrav1.ProxmoxMachine) error {
 						field.NewPath("spec", "network", "VirtualNetworkDevices", "VRFs", fmt.Sprint(i), "Table"), machine.Spec.Network.VirtualNetworkDevices.VRFs[i], err.Error()),
 				})
 		}
+		err = validateRoutes(machine.Spec.Network.VirtualNetworkDevices.VRFs[i].Routes)
+		if err != nil {
+			return apierrors.NewInvalid(
+				gk,
+				name,
+				field.ErrorList{
+					field.Invalid(
+						field.NewPath("spec", "network", "VirtualNetworkDevices", "VRFs", fmt.Sprint(i), "Routes"), machine.Spec.Network.VirtualNetworkDevices.VRFs[i], err.Error()),
+				})
+		}
 	}
 
+	// enslaved maps the devices to the bond or bridge they are part of.
+	enslaved := make(map[string]string)
+	for i, bond := range machine.Spec.Network.VirtualNetworkDevices.Bonds {
+		err := validateBondInterfaces(machine.Spec.Network, &bond, enslaved)
+		if err != nil {
+			return apierrors.NewInvalid(
+				gk,
+				name,
+				field.ErrorList{
+					field.Invalid(
+						field.NewPath("spec", "network", "bonds", fmt.Sprint(i), "interfaces"), bond.Interfaces, err.Error()),
+				})
+		}
+	}
+
+	for i, bridge := range machine.Spec.Network.VirtualNetworkDevices.Bridges {
+		err := validateBridgeInterfaces(machine.Spec.Network, &bridge, enslaved)
+		if err != nil {
+			return apierrors.NewInvalid(
+				gk,
+				name,
+				field.ErrorList{
+					field.Invalid(
+						field.NewPath("spec", "network", "bridges", fmt.Sprint(i), "interfaces"), bridge.Interfaces, err.Error()),
+				})
+		}
+	}
+
+	for i, vlan := range machine.Spec.Network.VirtualNetworkDevices.VLANs {
+		err := validateVLANLink(machine.Spec.Network, &vlan, enslaved)
+		if err != nil {
+			return apierrors.NewInvalid(
+				gk,
+				name,
+				field.ErrorList{
+					field.Invalid(
+						field.NewPath("spec", "network", "vlans", fmt.Sprint(i), "link"), vlan.Link, err.Error()),
+				})
+		}
+		if vlan.ID < 1 || vlan.ID > 4094 {
+			return apierrors.NewInvalid(
+				gk,
+				name,
+				field.ErrorList{
+					field.Invalid(
+						field.NewPath("spec", "network", "vlans", fmt.Sprint(i), "id"), vlan.ID, "vlan id must be between 1 and 4094"),
+				})
+		}
+		err = validateVLANAddresses(&vlan)
+		if err != nil {
+			return apierrors.NewInvalid(
+				gk,
+				name,
+				field.ErrorList{
+					field.Invalid(
+						field.NewPath("spec", "network", "vlans", fmt.Sprint(i)), vlan, err.Error()),
+				})
+		}
+	}
+
+	return nil
+}
+
+// validateNetworkFormat rejects the network configuration which network-config version 1 and the network data
+// of a config drive can't express, instead of failing the bootstrap of the machine.
+func validateNetworkFormat(machine *infrav1.ProxmoxMachine) error {
+	if machine.Spec.Network == nil {
+		return nil
+	}
+
+	var format string
+	switch {
+	case machine.Spec.CloudInitType == infrav1.CloudInitTypeConfigDrive2:
+		format = "not supported by config drive network data, use cloudInitType nocloud"
+	case machine.Spec.NetworkConfigVersion == infrav1.NetworkConfigVersionV1:
+		format = "not supported by network-config version 1, use networkConfigVersion v2"
+	default:
+		return nil
+	}
+
+	path, value := unsupportedNetworkConfig(machine.Spec.Network)
+	if path == nil {
+		return nil
+	}
+	return apierrors.NewInvalid(
+		machine.GroupVersionKind().GroupKind(),
+		machine.GetName(),
+		field.ErrorList{
+			field.Invalid(path, value, format),
+		})
+}
+
+// unsupportedNetworkConfig returns the path and value of the first vrf, bond, bridge, vlan, routing table or
+// routing policy of the network, which only network-config version 2 supports.
+func unsupportedNetworkConfig(network *infrav1.NetworkSpec) (*field.Path, any) {
+	devices := network.VirtualNetworkDevices
+	switch {
+	case len(devices.VRFs) > 0:
+		return field.NewPath("spec", "network", "vrfs"), devices.VRFs
+	case len(devices.Bonds) > 0:
+		return field.NewPath("spec", "network", "bonds"), devices.Bonds
+	case len(devices.Bridges) > 0:
+		return field.NewPath("spec", "network", "bridges"), devices.Bridges
+	case len(devices.VLANs) > 0:
+		return field.NewPath("spec", "network", "vlans"), devices.VLANs
+	}
+
+	for i, route := range network.Routes {
+		if route.Table != 0 {
+			return field.NewPath("spec", "network", "routes").Index(i).Child("table"), route.Table
+		}
+	}
+	for i, device := range network.AdditionalDevices {
+		path := field.NewPath("spec", "network", "additionalDevices").Index(i)
+		if len(device.RoutingPolicy) > 0 {
+			return path.Child("routingPolicy"), device.RoutingPolicy
+		}
+		for j, route := range device.Routes {
+			if route.Table != 0 {
+				return path.Child("routes").Index(j).Child("table"), route.Table
+			}
+		}
+	}
+	return nil, nil
+}
+
+// validateVLANLink checks that the link of a vlan is a network device, bond or bridge of the machine,
+// and not enslaved to a bond or bridge.
+func validateVLANLink(network *infrav1.NetworkSpec, vlan *infrav1.GuestVLANSpec, enslaved map[string]string) error {
+	if master, ok := enslaved[vlan.Link]; ok {
+		return fmt.Errorf("vlan %s: link %s is part of %s", vlan.Name, vlan.Link, master)
+	}
+
+	exists := isNetworkDevice(network, vlan.Link) || isBond(network, vlan.Link)
+	exists = exists || slices.ContainsFunc(network.Bridges, func(bridge infrav1.GuestBridgeSpec) bool {
+		return bridge.Name == vlan.Link
+	})
+	if !exists {
+		return fmt.Errorf("vlan %s: link %s is neither a network device, a bond nor a bridge", vlan.Name, vlan.Link)
+	}
+	return nil
+}
+
+// validateVLANAddresses checks that the static addresses of a vlan belong to their family.
+func validateVLANAddresses(vlan *infrav1.GuestVLANSpec) error {
+	if vlan.IPv4 != nil {
+		addr, err := netip.ParseAddr(vlan.IPv4.Address)
+		if err != nil || !addr.Is4() {
+			return fmt.Errorf("vlan %s: %q is not an ipv4 address", vlan.Name, vlan.IPv4.Address)
+		}
+	}
+	if vlan.IPv6 != nil {
+		addr, err := netip.ParseAddr(vlan.IPv6.Address)
+		if err != nil || !addr.Is6() {
+			return fmt.Errorf("vlan %s: %q is not an ipv6 address", vlan.Name, vlan.IPv6.Address)
+		}
+	}
+	return nil
+}
+
+// validateBondInterfaces checks that the interfaces of a bond are network devices of the machine,
+// which are not enslaved to another bond yet.
+func validateBondInterfaces(network *infrav1.NetworkSpec, bond *infrav1.BondSpec, enslaved map[string]string) error {
+	for _, child := range bond.Interfaces {
+		if !isNetworkDevice(network, child) {
+			return fmt.Errorf("bond %s: interface %s is not a network device", bond.Name, child)
+		}
+		if other, ok := enslaved[child]; ok {
+			return fmt.Errorf("bond %s: interface %s is already part of %s", bond.Name, child, other)
+		}
+		enslaved[child] = "bond " + bond.Name
+	}
+	return nil
+}
+
+// validateBridgeInterfaces checks that the interfaces of a bridge are network devices or bonds of the machine,
+// which are not enslaved to a bond or another bridge yet.
+func validateBridgeInterfaces(network *infrav1.NetworkSpec, bridge *infrav1.GuestBridgeSpec, enslaved map[string]string) error {
+	for _, child := range bridge.Interfaces {
+		if !isNetworkDevice(network, child) && !isBond(network, child) {
+			return fmt.Errorf("bridge %s: interface %s is neither a network device nor a bond", bridge.Name, child)
+		}
+		if other, ok := enslaved[child]; ok {
+			return fmt.Errorf("bridge %s: interface %s is already part of %s", bridge.Name, child, other)
+		}
+		enslaved[child] = "bridge " + bridge.Name
+	}
+	return nil
+}
+
+// isNetworkDevice returns whether name is a network device of the machine, like net0.
+func isNetworkDevice(network *infrav1.NetworkSpec, name string) bool {
+	if name == infrav1.DefaultNetworkDevice && network.Default != nil {
+		return true
+	}
+	return slices.ContainsFunc(network.AdditionalDevices, func(device infrav1.AdditionalNetworkDevice) bool {
+		return device.Name == name
+	})
+}
+
+// isBond returns whether name is a bond of the machine.
+func isBond(network *infrav1.NetworkSpec, name string) bool {
+	return slices.ContainsFunc(network.Bonds, func(bond infrav1.BondSpec) bool {
+		return bond.Name == name
+	})
+}
+
+func validateRoutes(routes []infrav1.RouteSpec) error {
+	for i, route := range routes {
+		if err := cloudinit.ValidRoute(types.RoutingData{To: route.To, Via: route.Via}); err != nil {
+			return fmt.Errorf("route [%d] to %q via %q: %w", i, route.To, route.Via, err)
+		}
+	}
 	return nil
 }
 
@@ -187,6 +1209,54 @@ func validateInterfaceConfigMTU(ifconfig *infrav1.InterfaceConfig) error {
 	return nil
 }
 
+// validateNetworkDeviceMAC ensures an explicit MAC address can be assigned to a NIC,
+// which rules out multicast and broadcast addresses.
+func validateNetworkDeviceMAC(device *infrav1.NetworkDevice) error {
+	if device.MACAddress == "" {
+		return nil
+	}
+
+	mac, err := net.ParseMAC(device.MACAddress)
+	if err != nil || len(mac) != 6 {
+		return fmt.Errorf("mac address %q is malformed", device.MACAddress)
+	}
+
+	if bytes.Equal(mac, net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}) {
+		return fmt.Errorf("mac address %q is the broadcast address", device.MACAddress)
+	}
+
+	if mac[0]&0x01 != 0 {
+		return fmt.Errorf("mac address %q is a multicast address", device.MACAddress)
+	}
+
+	return nil
+}
+
+// validateNetworkDeviceQueues ensures multiple queues are only requested for virtio devices.
+func validateNetworkDeviceQueues(device *infrav1.NetworkDevice) error {
+	if device.Queues != nil && ptr.Deref(device.Model, "virtio") != "virtio" {
+		return fmt.Errorf("queues require the virtio model, but model is %s", *device.Model)
+	}
+
+	return nil
+}
+
+// validateAdditionalDeviceIPv6Mode ensures only static mode claims the ipv6 address from a pool.
+func validateAdditionalDeviceIPv6Mode(device *infrav1.AdditionalNetworkDevice) error {
+	switch {
+	case device.IPv6Mode == infrav1.IPv6ModeStatic && device.IPv6PoolRef == nil:
+		return errors.New("static mode requires an ipv6PoolRef")
+	case dynamicIPv6(device.IPv6Mode) && device.IPv6PoolRef != nil:
+		return errors.New("dhcp and slaac modes don't allow an ipv6PoolRef")
+	}
+	return nil
+}
+
+// dynamicIPv6 returns whether the guest acquires the ipv6 address of a device by itself.
+func dynamicIPv6(mode infrav1.IPv6Mode) bool {
+	return mode == infrav1.IPv6ModeDHCP || mode == infrav1.IPv6ModeSLAAC
+}
+
 func validateNetworkDeviceMTU(device *infrav1.NetworkDevice) error {
 	if device.MTU != nil {
 		// special value '1' to inherit the MTU value from the underlying bridge
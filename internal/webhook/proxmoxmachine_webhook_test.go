@@ -49,6 +49,99 @@ var _ = Describe("Controller Test", func() {
 			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("mtu must be at least 1280 or 1, but was 1000")))
 		})
 
+		It("should disallow multicast mac addresses", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.Network.Default.MACAddress = "01:00:5E:00:00:01"
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("is a multicast address")))
+		})
+
+		It("should disallow the broadcast mac address for additional devices", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.Network.AdditionalDevices[0].MACAddress = "FF:FF:FF:FF:FF:FF"
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("spec.network.additionalDevices.0.macAddress: Invalid value")))
+		})
+
+		It("should disallow queues on non-virtio devices", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.Network.Default.Model = ptr.To("e1000")
+			machine.Spec.Network.Default.Queues = ptr.To(uint8(4))
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("queues require the virtio model")))
+		})
+
+		It("should disallow firewall ports without tcp or udp", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.FirewallRules = []infrav1.FirewallRuleSpec{{Direction: "in", Protocol: "icmp", Port: "22"}}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("port requires the tcp or udp protocol")))
+		})
+
+		It("should disallow invalid firewall addresses", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.FirewallRules = []infrav1.FirewallRuleSpec{
+				{Direction: "in", Protocol: "tcp", Port: "8000:8080", Source: "10.0.0.0/8"},
+				{Direction: "out", Destination: "10.0.0.300"},
+			}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("spec.firewallRules[1].destination: Invalid value")))
+		})
+
+		It("should disallow static addresses of the wrong ip family", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.IPv4 = &infrav1.IPAddressSpec{Address: "2001:db8::10", Prefix: 64}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("spec.ipv4.address: Invalid value")))
+		})
+
+		It("should disallow static ipv4 addresses with an ipv6 prefix", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.IPv4 = &infrav1.IPAddressSpec{Address: "10.10.10.10", Prefix: 64, Gateway: "10.10.10.1"}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("prefix must be between 0 and 32")))
+		})
+
+		It("should disallow a static ipv6 address with slaac", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.Network.Default.IPv6Mode = infrav1.IPv6ModeSLAAC
+			machine.Spec.IPv6 = &infrav1.IPAddressSpec{Address: "2001:db8::10", Prefix: 64}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("dhcp and slaac modes don't allow a static ipv6 address")))
+		})
+
+		It("should disallow static ipv6 mode without an ipv6PoolRef", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.Network.AdditionalDevices[0].IPv6Mode = infrav1.IPv6ModeStatic
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("static mode requires an ipv6PoolRef")))
+		})
+
+		It("should disallow dhcp4 with a static ipv4 address", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.Network.Default.DHCP4 = true
+			machine.Spec.IPv4 = &infrav1.IPAddressSpec{Address: "10.10.10.10", Prefix: 24, Gateway: "10.10.10.1"}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("dhcp4 doesn't allow a static ipv4 address")))
+		})
+
+		It("should disallow dhcp4 with an ipv4PoolRef", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.Network.AdditionalDevices[0].DHCP4 = true
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("dhcp4 doesn't allow an ipv4PoolRef")))
+		})
+
+		It("should warn about dhcp4 without the guest agent", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.Network.Default.DHCP4 = true
+			warnings, err := (&ProxmoxMachine{}).ValidateCreate(testEnv.GetContext(), &machine)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(warnings).To(ContainElement(ContainSubstring("only reported with the guest agent enabled")))
+		})
+
+		It("should warn about firewall rules without firewall enabled devices", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.FirewallRules = []infrav1.FirewallRuleSpec{{Direction: "in", Protocol: "tcp", Port: "6443"}}
+			warnings, err := (&ProxmoxMachine{}).ValidateCreate(testEnv.GetContext(), &machine)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(warnings).To(ContainElement(ContainSubstring("no network device enables the firewall")))
+
+			machine.Spec.Network.Default.Firewall = true
+			warnings, err = (&ProxmoxMachine{}).ValidateCreate(testEnv.GetContext(), &machine)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(warnings).To(BeEmpty())
+		})
+
 		It("should create a valid proxmox machine", func() {
 			machine := validProxmoxMachine("test-machine")
 			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(Succeed())
@@ -72,11 +165,329 @@ var _ = Describe("Controller Test", func() {
 			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("VRF vrf-green: device/rule routing table mismatch 665 != 667")))
 		})
 
+		It("should disallow bonding unknown network devices", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.Network.Bonds = []infrav1.BondSpec{{Name: "bond0", Interfaces: []string{"net0", "net2"}, Mode: infrav1.BondModeActiveBackup}}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("bond bond0: interface net2 is not a network device")))
+		})
+
+		It("should disallow bonding a network device twice", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.Network.Bonds = []infrav1.BondSpec{
+				{Name: "bond0", Interfaces: []string{"net0", "net1"}, Mode: infrav1.BondModeActiveBackup},
+				{Name: "bond1", Interfaces: []string{"net1"}, Mode: infrav1.BondModeActiveBackup},
+			}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("bond bond1: interface net1 is already part of bond bond0")))
+		})
+
+		It("should disallow unsupported bond modes", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.Network.Bonds = []infrav1.BondSpec{{Name: "bond0", Interfaces: []string{"net0", "net1"}, Mode: "lacp"}}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("Unsupported value")))
+		})
+
+		It("should disallow bridging unknown interfaces", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.Network.Bridges = []infrav1.GuestBridgeSpec{{Name: "br0", Interfaces: []string{"net0", "bond0"}}}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("bridge br0: interface bond0 is neither a network device nor a bond")))
+		})
+
+		It("should disallow bridging members of a bond", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.Network.Bonds = []infrav1.BondSpec{{Name: "bond0", Interfaces: []string{"net1"}, Mode: infrav1.BondModeActiveBackup}}
+			machine.Spec.Network.Bridges = []infrav1.GuestBridgeSpec{{Name: "br0", Interfaces: []string{"net0", "net1"}}}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("bridge br0: interface net1 is already part of bond bond0")))
+		})
+
+		It("should allow bridging a bond", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.Network.Bonds = []infrav1.BondSpec{{Name: "bond0", Interfaces: []string{"net1"}, Mode: infrav1.BondModeActiveBackup}}
+			machine.Spec.Network.Bridges = []infrav1.GuestBridgeSpec{{Name: "br0", Interfaces: []string{"net0", "bond0"}, STP: ptr.To(true)}}
+			_, err := (&ProxmoxMachine{}).ValidateCreate(testEnv.GetContext(), &machine)
+			g.Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should disallow vlans on unknown links", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.Network.VLANs = []infrav1.GuestVLANSpec{{Name: "eth2.100", Link: "net2", ID: 100}}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("vlan eth2.100: link net2 is neither a network device, a bond nor a bridge")))
+		})
+
+		It("should disallow vlans on members of a bond", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.Network.Bonds = []infrav1.BondSpec{{Name: "bond0", Interfaces: []string{"net0", "net1"}, Mode: infrav1.BondModeActiveBackup}}
+			machine.Spec.Network.VLANs = []infrav1.GuestVLANSpec{{Name: "eth1.100", Link: "net1", ID: 100}}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("vlan eth1.100: link net1 is part of bond bond0")))
+		})
+
+		It("should disallow bonds with network-config version 1", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.NetworkConfigVersion = infrav1.NetworkConfigVersionV1
+			machine.Spec.Network.VRFs = nil
+			machine.Spec.Network.Bonds = []infrav1.BondSpec{{Name: "bond0", Interfaces: []string{"net0", "net1"}, Mode: infrav1.BondModeActiveBackup}}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(And(
+				ContainSubstring("spec.network.bonds"),
+				ContainSubstring("not supported by network-config version 1"))))
+		})
+
+		It("should disallow vlans on config drives", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.CloudInitType = infrav1.CloudInitTypeConfigDrive2
+			machine.Spec.Network.VRFs = nil
+			machine.Spec.Network.VLANs = []infrav1.GuestVLANSpec{{Name: "eth0.200", Link: "net0", ID: 200}}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(And(
+				ContainSubstring("spec.network.vlans"),
+				ContainSubstring("not supported by config drive network data"))))
+		})
+
+		It("should disallow routing policies with network-config version 1", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.NetworkConfigVersion = infrav1.NetworkConfigVersionV1
+			machine.Spec.Network.VRFs = nil
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("spec.network.additionalDevices[0].routingPolicy")))
+		})
+
+		It("should allow plain network devices with network-config version 1", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.NetworkConfigVersion = infrav1.NetworkConfigVersionV1
+			machine.Spec.Network.VRFs = nil
+			machine.Spec.Network.AdditionalDevices[0].RoutingPolicy = nil
+			_, err := (&ProxmoxMachine{}).ValidateCreate(testEnv.GetContext(), &machine)
+			g.Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should allow vlans on top of a bond", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.Network.Bonds = []infrav1.BondSpec{{Name: "bond0", Interfaces: []string{"net0", "net1"}, Mode: infrav1.BondModeActiveBackup}}
+			machine.Spec.Network.VLANs = []infrav1.GuestVLANSpec{{Name: "bond0.100", Link: "bond0", ID: 100, IPv4: &infrav1.IPAddressSpec{Address: "10.20.0.5", Prefix: 24}}}
+			_, err := (&ProxmoxMachine{}).ValidateCreate(testEnv.GetContext(), &machine)
+			g.Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should disallow invalid vlan ids and addresses", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.Network.VLANs = []infrav1.GuestVLANSpec{{Name: "eth0.4095", Link: "net0", ID: 4095}}
+			_, err := (&ProxmoxMachine{}).ValidateCreate(testEnv.GetContext(), &machine)
+			g.Expect(err).To(MatchError(ContainSubstring("vlan id must be between 1 and 4094")))
+
+			machine.Spec.Network.VLANs = []infrav1.GuestVLANSpec{{Name: "eth0.100", Link: "net0", ID: 100, IPv4: &infrav1.IPAddressSpec{Address: "2001:db8::5", Prefix: 64}}}
+			_, err = (&ProxmoxMachine{}).ValidateCreate(testEnv.GetContext(), &machine)
+			g.Expect(err).To(MatchError(ContainSubstring(`vlan eth0.100: "2001:db8::5" is not an ipv4 address`)))
+		})
+
+		It("should disallow min memory greater than memory", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.MinMemoryMiB = ptr.To(int32(2048))
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("minMemoryMiB must be less than or equal to memoryMiB 1024")))
+		})
+
+		It("should disallow unknown cpu types", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.CPUType = "HOST"
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring(`unknown cpu type "HOST", did you mean "host"?`)))
+		})
+
+		It("should disallow target storage for linked clones", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.Full = ptr.To(false)
+			machine.Spec.Storage = ptr.To("local-lvm")
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("storage can only be set for full clones")))
+		})
+
+		It("should disallow referencing the template by id and name", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.TemplateID = ptr.To[int32](100)
+			machine.Spec.TemplateName = ptr.To("ubuntu-2204")
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("templateID and templateName are mutually exclusive")))
+		})
+
+		It("should disallow raw pci device ids without target node", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.PCIDevices = []infrav1.PCIDeviceSpec{{DeviceID: ptr.To("0000:01:00.0")}}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("raw pci device ids require a target node")))
+		})
+
 		It("should disallow routing policy without table", func() {
 			machine := validProxmoxMachine("test-machine")
 			machine.Spec.Network.AdditionalDevices[0].InterfaceConfig.Routing.RoutingPolicy[0].Table = nil
 			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("routing policy [0] requires a table")))
 		})
+
+		It("should disallow a boot volume on an additional volume slot", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.Disks.BootVolume.Disk = "scsi1"
+			machine.Spec.Disks.AdditionalVolumes = []infrav1.DiskSpec{{SizeGB: 50, StoragePool: "local-lvm"}}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("collides with additional volume 0")))
+		})
+
+		It("should disallow discard on ide boot volumes", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.Disks.BootVolume.Disk = "ide0"
+			machine.Spec.Disks.BootVolume.Discard = true
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("spec.disks.bootVolume.discard: Invalid value")))
+		})
+
+		It("should disallow ssd emulation on virtio boot volumes", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.Disks.BootVolume.Disk = "virtio0"
+			machine.Spec.Disks.BootVolume.SSDEmulation = true
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("spec.disks.bootVolume.ssd: Invalid value")))
+		})
+
+		It("should disallow iothreads without the virtio-scsi-single controller", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.SCSIController = infrav1.SCSIControllerVirtIO
+			machine.Spec.Disks.AdditionalVolumes = []infrav1.DiskSpec{{SizeGB: 50, StoragePool: "local-lvm", IOThread: true}}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("spec.disks.additionalVolumes[0].iothread: Invalid value")))
+		})
+
+		It("should disallow a serial display without serial console", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.VGA = "serial0"
+			machine.Spec.SerialConsole = ptr.To(false)
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("serial0 requires the serial console")))
+		})
+
+		It("should disallow a description which is no valid template", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.Description = ptr.To("cluster {{ .ClusterName")
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("spec.description: Invalid value")))
+		})
+
+		It("should disallow invalid NTP servers", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.NTPServers = []string{"10.0.0.1/24"}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("spec.ntpServers[0]: Invalid value")))
+		})
+
+		It("should disallow multiple SSH keys in one entry", func() {
+			machine := validProxmoxMachine("test-machine")
+			key := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIGm1XHyhBL7XHl8rwNk+hTOqMo6nmxj0K7ZgwsGL+RA9"
+			machine.Spec.SSHAuthorizedKeys = []string{key + "\n" + key}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("spec.sshAuthorizedKeys[0]: Invalid value")))
+		})
+
+		It("should disallow disabling the default user without a login user", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.DisableDefaultUser = true
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("spec.loginUser: Required value")))
+		})
+
+		It("should disallow a login user with the default user", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.LoginUser = "admin"
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("only applies with disableDefaultUser")))
+		})
+
+		It("should disallow an invalid VM name template", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.VMNameTemplate = ptr.To("{{ .Role }")
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("spec.vmNameTemplate: Invalid value")))
+		})
+
+		It("should disallow additional user data which is no cloud-config mapping", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.AdditionalUserData = "#cloud-config\n- echo hello\n"
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("spec.additionalUserData: Invalid value")))
+		})
+
+		It("should disallow vendor data which is no cloud-config mapping", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.VendorData = "#cloud-config\n- echo hello\n"
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("spec.vendorData: Invalid value")))
+		})
+
+		It("should disallow mounts at the same mount point", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.Mounts = []infrav1.MountSpec{
+				{Device: "/dev/sdb", FSType: infrav1.FilesystemExt4, MountPoint: "/var/lib/data"},
+				{Device: "/dev/sdc", FSType: infrav1.FilesystemXFS, MountPoint: "/var/lib/data"},
+			}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("spec.mounts[1].mountPoint: Duplicate value")))
+		})
+
+		It("should disallow a mount point which is no clean path", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.Mounts = []infrav1.MountSpec{
+				{Device: "/dev/sdb", FSType: infrav1.FilesystemExt4, MountPoint: "/var/../data"},
+			}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("spec.mounts[0].mountPoint: Invalid value")))
+		})
+
+		It("should disallow a deletion policy without an existing vm", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.DeletionPolicy = infrav1.VMDeletionPolicyDetach
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("only applies to VMs adopted with existingVMID")))
+		})
+
+		It("should disallow a snapshot name which is no valid template", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.SnapshotName = ptr.To("{{ .Name")
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("spec.snapshotName: Invalid value")))
+		})
+
+		It("should disallow a snapshot ttl without retained disks", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.PreDeleteSnapshot = true
+			machine.Spec.SnapshotTTL = &metav1.Duration{Duration: time.Hour}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("requires retainDisks")))
+		})
+
+		It("should warn about a pre-delete snapshot without retained disks", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.PreDeleteSnapshot = true
+			warnings, err := (&ProxmoxMachine{}).ValidateCreate(testEnv.GetContext(), &machine)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(warnings).To(ContainElement(ContainSubstring("unless retainDisks is enabled")))
+
+			machine.Spec.RetainDisks = true
+			warnings, err = (&ProxmoxMachine{}).ValidateCreate(testEnv.GetContext(), &machine)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(warnings).To(BeEmpty())
+		})
+
+		It("should disallow ovmf without an efi disk", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.BIOS = infrav1.BIOSOVMF
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("spec.efiDisk: Required value")))
+		})
+
+		It("should disallow pcie devices on i440fx machines", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.MachineType = "pc-i440fx-8.1"
+			machine.Spec.PCIDevices = []infrav1.PCIDeviceSpec{{Mapping: ptr.To("gpu"), PCIE: true}}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("spec.pciDevices[0].pcie: Invalid value")))
+		})
+
+		It("should disallow memory not aligned to the hugepage size", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.MemoryMiB = 4104
+			machine.Spec.Hugepages = infrav1.Hugepages1G
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("hugepage size of 1024 MiB")))
+		})
+
+		It("should warn about numa with a single socket", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.NUMA = true
+			warnings, err := (&ProxmoxMachine{}).ValidateCreate(testEnv.GetContext(), &machine)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(warnings).To(ContainElement(ContainSubstring("single socket")))
+		})
+
+		It("should disallow routes with an invalid destination", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.Network.Routes = []infrav1.RouteSpec{{To: "10.200.0.0/33", Via: "10.10.10.254"}}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("route [0] to \"10.200.0.0/33\" via \"10.10.10.254\": route is malformed")))
+		})
+
+		It("should disallow routes with an invalid gateway", func() {
+			machine := validProxmoxMachine("test-machine")
+			machine.Spec.Network.AdditionalDevices[0].InterfaceConfig.Routing.Routes = []infrav1.RouteSpec{
+				{To: "default", Via: "10.10.10.1"},
+				{To: "172.24.16.0/24", Via: "gateway"},
+			}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("route [1] to \"172.24.16.0/24\" via \"gateway\": route is malformed")))
+		})
 	})
 
 	Context("update proxmox cluster", func() {
@@ -99,6 +510,55 @@ var _ = Describe("Controller Test", func() {
 				WithPolling(time.Second).
 				Should(Succeed())
 		})
+
+		It("should disallow changing the clone source of a cloned VM", func() {
+			oldMachine := validProxmoxMachine("test-machine")
+			oldMachine.Spec.TemplateID = ptr.To[int32](100)
+			oldMachine.Spec.VirtualMachineID = ptr.To[int64](1000)
+
+			machine := oldMachine.DeepCopy()
+			machine.Spec.SourceNode = "pve2"
+			machine.Spec.TemplateID = ptr.To[int32](101)
+			_, err := (&ProxmoxMachine{}).ValidateUpdate(testEnv.GetContext(), &oldMachine, machine)
+			g.Expect(err).To(MatchError(ContainSubstring("spec.sourceNode: Forbidden: cannot be changed once the VM is cloned, replace the machine instead")))
+			g.Expect(err).To(MatchError(ContainSubstring("spec.templateID: Forbidden")))
+		})
+
+		It("should allow changing the clone source before the VM is cloned", func() {
+			oldMachine := validProxmoxMachine("test-machine")
+			oldMachine.Spec.TemplateID = ptr.To[int32](100)
+
+			machine := oldMachine.DeepCopy()
+			machine.Spec.SourceNode = "pve2"
+			machine.Spec.TemplateID = ptr.To[int32](101)
+			_, err := (&ProxmoxMachine{}).ValidateUpdate(testEnv.GetContext(), &oldMachine, machine)
+			g.Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should allow changing tags and description of a cloned VM", func() {
+			oldMachine := validProxmoxMachine("test-machine")
+			oldMachine.Spec.VirtualMachineID = ptr.To[int64](1000)
+
+			machine := oldMachine.DeepCopy()
+			machine.Spec.Tags = []string{"worker"}
+			machine.Spec.Description = ptr.To("{{ .Name }}")
+			machine.Spec.Disks.BootVolume.SizeGB = 20
+			_, err := (&ProxmoxMachine{}).ValidateUpdate(testEnv.GetContext(), &oldMachine, machine)
+			g.Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should disallow shrinking the disks of a cloned VM", func() {
+			oldMachine := validProxmoxMachine("test-machine")
+			oldMachine.Spec.VirtualMachineID = ptr.To[int64](1000)
+			oldMachine.Spec.Disks.AdditionalVolumes = []infrav1.DiskSpec{{SizeGB: 50, StoragePool: "local-lvm"}}
+
+			machine := oldMachine.DeepCopy()
+			machine.Spec.Disks.BootVolume.SizeGB = 5
+			machine.Spec.Disks.AdditionalVolumes[0].SizeGB = 40
+			_, err := (&ProxmoxMachine{}).ValidateUpdate(testEnv.GetContext(), &oldMachine, machine)
+			g.Expect(err).To(MatchError(ContainSubstring("spec.disks.bootVolume.sizeGb: Invalid value: 5: cannot shrink from 10G")))
+			g.Expect(err).To(MatchError(ContainSubstring("spec.disks.additionalVolumes[0].sizeGb: Invalid value: 40: cannot shrink from 50G")))
+		})
 	})
 })
 
@@ -0,0 +1,285 @@
+/*
+Copyright 2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/luthermonson/go-proxmox"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	capmox "github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+// backendTimeout bounds the requests to the Proxmox API, so they fit into the timeout of the webhook.
+const backendTimeout = 5 * time.Second
+
+var _ admission.CustomValidator = &ProxmoxMachineTemplate{}
+
+// ProxmoxMachineTemplate is a type that implements
+// the interfaces from the admission package.
+type ProxmoxMachineTemplate struct {
+	// ValidateBackend enables the check of the template and nodes against the Proxmox API
+	// of the cluster the template is labeled with.
+	ValidateBackend bool
+
+	// Client reads the cluster of the template and its credentials.
+	Client client.Client
+
+	// ProxmoxClient is used for clusters without a credentialsRef.
+	ProxmoxClient capmox.Client
+}
+
+// SetupWebhookWithManager sets up the webhook with the
+// custom interfaces.
+func (p *ProxmoxMachineTemplate) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	if p.Client == nil {
+		p.Client = mgr.GetClient()
+	}
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&infrav1.ProxmoxMachineTemplate{}).
+		WithValidator(p).
+		Complete()
+}
+
+//+kubebuilder:webhook:verbs=create;update,path=/validate-infrastructure-cluster-x-k8s-io-v1alpha1-proxmoxmachinetemplate,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=proxmoxmachinetemplates,versions=v1alpha1,name=validation.proxmoxmachinetemplate.infrastructure.cluster.x-k8s.io,admissionReviewVersions=v1
+
+// ValidateCreate implements the creation validation function.
+func (p *ProxmoxMachineTemplate) ValidateCreate(ctx context.Context, obj runtime.Object) (warnings admission.Warnings, err error) {
+	template, ok := obj.(*infrav1.ProxmoxMachineTemplate)
+	if !ok {
+		return warnings, apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxMachineTemplate but got %T", obj))
+	}
+
+	if err = validateTemplateMachine(template); err != nil {
+		warnings = append(warnings, fmt.Sprintf("cannot create proxmox machine template %s", template.GetName()))
+		return warnings, err
+	}
+
+	return p.validateBackend(ctx, template)
+}
+
+// ValidateUpdate implements the update validation function.
+func (p *ProxmoxMachineTemplate) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (warnings admission.Warnings, err error) {
+	newTemplate, ok := newObj.(*infrav1.ProxmoxMachineTemplate)
+	if !ok {
+		return warnings, apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxMachineTemplate but got %T", newObj))
+	}
+	oldTemplate, ok := oldObj.(*infrav1.ProxmoxMachineTemplate)
+	if !ok {
+		return warnings, apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxMachineTemplate but got %T", oldObj))
+	}
+
+	if err = validateTemplateMachine(newTemplate); err != nil {
+		warnings = append(warnings, fmt.Sprintf("cannot update proxmox machine template %s", newTemplate.GetName()))
+		return warnings, err
+	}
+
+	// a template which no longer matches the backend must still accept changes to its metadata.
+	if reflect.DeepEqual(oldTemplate.Spec, newTemplate.Spec) {
+		return nil, nil
+	}
+	return p.validateBackend(ctx, newTemplate)
+}
+
+// ValidateDelete implements the deletion validation function.
+func (p *ProxmoxMachineTemplate) ValidateDelete(_ context.Context, _ runtime.Object) (warnings admission.Warnings, err error) {
+	return nil, nil
+}
+
+// validateTemplateMachine runs the validation of ProxmoxMachines on the machine spec of the template.
+func validateTemplateMachine(template *infrav1.ProxmoxMachineTemplate) error {
+	machine := &infrav1.ProxmoxMachine{
+		ObjectMeta: *template.ObjectMeta.DeepCopy(),
+		Spec:       *template.Spec.Template.Spec.DeepCopy(),
+	}
+	machine.SetGroupVersionKind(infrav1.GroupVersion.WithKind("ProxmoxMachine"))
+
+	for _, validate := range machineValidators {
+		if err := validate(machine); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateBackend checks that the nodes and the vm template of the machine spec exist in the Proxmox cluster.
+// Templates which can't be checked, like those of unknown clusters or with an unreachable Proxmox API,
+// are admitted with a warning.
+func (p *ProxmoxMachineTemplate) validateBackend(ctx context.Context, template *infrav1.ProxmoxMachineTemplate) (admission.Warnings, error) {
+	if !p.ValidateBackend {
+		return nil, nil
+	}
+
+	proxmoxClient, err := p.proxmoxClient(ctx, template)
+	if err != nil {
+		return admission.Warnings{fmt.Sprintf("proxmox machine template %s is not checked against the proxmox api: %s", template.GetName(), err)}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, backendTimeout)
+	defer cancel()
+
+	errs, err := backendErrors(ctx, proxmoxClient, &template.Spec.Template.Spec)
+	if err != nil {
+		return admission.Warnings{fmt.Sprintf("proxmox machine template %s is not checked against the proxmox api, which is not reachable: %s", template.GetName(), err)}, nil
+	}
+	if len(errs) > 0 {
+		return admission.Warnings{fmt.Sprintf("cannot admit proxmox machine template %s", template.GetName())},
+			apierrors.NewInvalid(template.GroupVersionKind().GroupKind(), template.GetName(), errs)
+	}
+	return nil, nil
+}
+
+// proxmoxClient returns the Proxmox client of the cluster the template is labeled with.
+func (p *ProxmoxMachineTemplate) proxmoxClient(ctx context.Context, template *infrav1.ProxmoxMachineTemplate) (capmox.Client, error) {
+	clusterName := template.GetLabels()[clusterv1.ClusterNameLabel]
+	if clusterName == "" {
+		return nil, fmt.Errorf("it has no %s label", clusterv1.ClusterNameLabel)
+	}
+
+	cluster := &clusterv1.Cluster{}
+	if err := p.Client.Get(ctx, client.ObjectKey{Namespace: template.GetNamespace(), Name: clusterName}, cluster); err != nil {
+		return nil, fmt.Errorf("unable to get cluster %s: %w", clusterName, err)
+	}
+	if cluster.Spec.InfrastructureRef == nil {
+		return nil, fmt.Errorf("cluster %s has no infrastructureRef yet", clusterName)
+	}
+
+	proxmoxCluster := &infrav1.ProxmoxCluster{}
+	key := client.ObjectKey{Namespace: cluster.GetNamespace(), Name: cluster.Spec.InfrastructureRef.Name}
+	if err := p.Client.Get(ctx, key, proxmoxCluster); err != nil {
+		return nil, fmt.Errorf("unable to get proxmox cluster %s: %w", key.Name, err)
+	}
+
+	if proxmoxCluster.Spec.CredentialsRef != nil {
+		return scope.ProxmoxClientForCluster(ctx, p.Client, proxmoxCluster)
+	}
+	if p.ProxmoxClient == nil {
+		return nil, fmt.Errorf("proxmox cluster %s has no credentialsRef", key.Name)
+	}
+	return p.ProxmoxClient, nil
+}
+
+// vnetErrors returns the network devices attached to VNets of the Proxmox SDN, which don't exist
+// or set a VLAN in a VNet which is not VLAN-aware.
+func vnetErrors(ctx context.Context, proxmoxClient capmox.Client, path *field.Path, network *infrav1.NetworkSpec) (field.ErrorList, error) {
+	if network == nil {
+		return nil, nil
+	}
+
+	type vnetDevice struct {
+		path   *field.Path
+		device *infrav1.NetworkDevice
+	}
+	var devices []vnetDevice
+	if network.Default != nil && capmox.IsVNet(network.Default.Bridge) {
+		devices = append(devices, vnetDevice{path.Child("default"), network.Default})
+	}
+	for i := range network.AdditionalDevices {
+		if device := &network.AdditionalDevices[i].NetworkDevice; capmox.IsVNet(device.Bridge) {
+			devices = append(devices, vnetDevice{path.Child("additionalDevices").Index(i), device})
+		}
+	}
+	if len(devices) == 0 {
+		return nil, nil
+	}
+
+	vnets, err := proxmoxClient.ListVNets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs field.ErrorList
+	for _, d := range devices {
+		i := slices.IndexFunc(vnets, func(v capmox.VNet) bool { return v.Name == d.device.Bridge })
+		switch {
+		case i < 0:
+			errs = append(errs, field.NotFound(d.path.Child("bridge"), fmt.Sprintf("%s (neither named vmbrN nor a vnet)", d.device.Bridge)))
+		case d.device.VLAN != nil && !bool(vnets[i].VLANAware):
+			errs = append(errs, field.Invalid(d.path.Child("vlan"), *d.device.VLAN,
+				fmt.Sprintf("vnet %s of zone %s is not vlan-aware, the sdn sets the vlan", d.device.Bridge, vnets[i].Zone)))
+		}
+	}
+	return errs, nil
+}
+
+// backendErrors returns the fields of the machine spec which refer to nodes or vm templates
+// which don't exist. The error is only set if the Proxmox API could not be queried.
+func backendErrors(ctx context.Context, proxmoxClient capmox.Client, spec *infrav1.ProxmoxMachineSpec) (field.ErrorList, error) {
+	specPath := field.NewPath("spec", "template", "spec")
+
+	nodes, err := proxmoxClient.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs field.ErrorList
+	checkNode := func(path *field.Path, node string) {
+		if node != "" && !slices.Contains(nodes, node) {
+			errs = append(errs, field.NotFound(path, fmt.Sprintf("%s (available nodes: %s)", node, strings.Join(nodes, ", "))))
+		}
+	}
+	checkNode(specPath.Child("sourceNode"), spec.SourceNode)
+	if spec.Target != nil {
+		checkNode(specPath.Child("target"), *spec.Target)
+	}
+	for i, node := range spec.AllowedNodes {
+		checkNode(specPath.Child("allowedNodes").Index(i), node)
+	}
+
+	vnetErrs, err := vnetErrors(ctx, proxmoxClient, specPath.Child("network"), spec.Network)
+	if err != nil {
+		return nil, err
+	}
+	errs = append(errs, vnetErrs...)
+
+	if spec.TemplateID == nil && spec.TemplateName == nil {
+		return errs, nil
+	}
+
+	resources, err := proxmoxClient.ListVMResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case spec.TemplateID != nil:
+		if !slices.ContainsFunc(resources, func(r *proxmox.ClusterResource) bool { return r.Type == "qemu" && r.VMID == uint64(*spec.TemplateID) }) {
+			errs = append(errs, field.NotFound(specPath.Child("templateID"), *spec.TemplateID))
+		}
+	case spec.TemplateName != nil:
+		if !slices.ContainsFunc(resources, func(r *proxmox.ClusterResource) bool {
+			return r.Type == "qemu" && r.Template == 1 && r.Name == *spec.TemplateName
+		}) {
+			errs = append(errs, field.NotFound(specPath.Child("templateName"), *spec.TemplateName))
+		}
+	}
+	return errs, nil
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"errors"
+
+	"github.com/luthermonson/go-proxmox"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	capmox "github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/proxmoxtest"
+)
+
+var _ = Describe("ProxmoxMachineTemplate Test", func() {
+	g := NewWithT(GinkgoT())
+
+	Context("create proxmox machine template", func() {
+		It("should disallow an invalid machine spec", func() {
+			template := validProxmoxMachineTemplate("test-template")
+			template.Spec.Template.Spec.Network.Default.MTU = ptr.To(uint16(1000))
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &template)).To(MatchError(ContainSubstring("spec.network.default.mtu: Invalid value")))
+		})
+
+		It("should create a valid proxmox machine template", func() {
+			template := validProxmoxMachineTemplate("test-template")
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &template)).To(Succeed())
+			g.Expect(k8sClient.Delete(testEnv.GetContext(), &template)).To(Succeed())
+		})
+	})
+
+	Context("check proxmox machine template against the proxmox api", func() {
+		var proxmoxClient *proxmoxtest.MockClient
+		var validator *ProxmoxMachineTemplate
+
+		BeforeEach(func() {
+			proxmoxClient = proxmoxtest.NewMockClient(GinkgoT())
+			cluster := &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+				Spec: clusterv1.ClusterSpec{
+					InfrastructureRef: &corev1.ObjectReference{Kind: "ProxmoxCluster", Name: "test"},
+				},
+			}
+			proxmoxCluster := &infrav1.ProxmoxCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			}
+			validator = &ProxmoxMachineTemplate{
+				ValidateBackend: true,
+				Client:          fake.NewClientBuilder().WithScheme(testEnv.GetScheme()).WithObjects(cluster, proxmoxCluster).Build(),
+				ProxmoxClient:   proxmoxClient,
+			}
+		})
+
+		It("should admit a template whose node and vm template exist", func() {
+			template := validProxmoxMachineTemplate("test-template")
+			template.Spec.Template.Spec.TemplateID = ptr.To[int32](100)
+			proxmoxClient.EXPECT().ListNodes(mock.Anything).Return([]string{"pve"}, nil).Once()
+			proxmoxClient.EXPECT().ListVMResources(mock.Anything).Return([]*proxmox.ClusterResource{{Type: "qemu", VMID: 100, Node: "pve", Template: 1}}, nil).Once()
+
+			warnings, err := validator.ValidateCreate(testEnv.GetContext(), &template)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(warnings).To(BeEmpty())
+		})
+
+		It("should disallow unknown nodes and vm templates", func() {
+			template := validProxmoxMachineTemplate("test-template")
+			template.Spec.Template.Spec.TemplateName = ptr.To("ubuntu")
+			template.Spec.Template.Spec.AllowedNodes = []string{"pve", "pve3"}
+			proxmoxClient.EXPECT().ListNodes(mock.Anything).Return([]string{"pve", "pve2"}, nil).Once()
+			proxmoxClient.EXPECT().ListVMResources(mock.Anything).Return([]*proxmox.ClusterResource{{Type: "qemu", VMID: 100, Name: "debian", Node: "pve", Template: 1}}, nil).Once()
+
+			_, err := validator.ValidateCreate(testEnv.GetContext(), &template)
+			g.Expect(err).To(MatchError(ContainSubstring(`spec.template.spec.allowedNodes[1]: Not found: "pve3 (available nodes: pve, pve2)"`)))
+			g.Expect(err).To(MatchError(ContainSubstring(`spec.template.spec.templateName: Not found: "ubuntu"`)))
+		})
+
+		It("should check the devices attached to sdn vnets", func() {
+			template := validProxmoxMachineTemplate("test-template")
+			template.Spec.Template.Spec.Network.Default.Bridge = "prod"
+			template.Spec.Template.Spec.Network.AdditionalDevices[0].Bridge = "staging"
+			proxmoxClient.EXPECT().ListNodes(mock.Anything).Return([]string{"pve"}, nil).Once()
+			proxmoxClient.EXPECT().ListVNets(mock.Anything).Return([]capmox.VNet{{Name: "prod", Zone: "vlans", Tag: 100}}, nil).Once()
+
+			_, err := validator.ValidateCreate(testEnv.GetContext(), &template)
+			g.Expect(err).To(MatchError(ContainSubstring(`spec.template.spec.network.default.vlan: Invalid value: 100: vnet prod of zone vlans is not vlan-aware, the sdn sets the vlan`)))
+			g.Expect(err).To(MatchError(ContainSubstring(`spec.template.spec.network.additionalDevices[0].bridge: Not found: "staging (neither named vmbrN nor a vnet)"`)))
+		})
+
+		It("should admit the template with a warning if the proxmox api is not reachable", func() {
+			template := validProxmoxMachineTemplate("test-template")
+			template.Spec.Template.Spec.SourceNode = "pve3"
+			proxmoxClient.EXPECT().ListNodes(mock.Anything).Return(nil, errors.New("connection refused")).Once()
+
+			warnings, err := validator.ValidateCreate(testEnv.GetContext(), &template)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(warnings).To(ConsistOf(ContainSubstring("which is not reachable: connection refused")))
+		})
+
+		It("should admit the template with a warning if it has no cluster", func() {
+			template := validProxmoxMachineTemplate("test-template")
+			template.SetLabels(nil)
+
+			warnings, err := validator.ValidateCreate(testEnv.GetContext(), &template)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(warnings).To(ConsistOf(ContainSubstring("it has no cluster.x-k8s.io/cluster-name label")))
+		})
+
+		It("should skip the check if it is disabled", func() {
+			validator.ValidateBackend = false
+			template := validProxmoxMachineTemplate("test-template")
+			template.Spec.Template.Spec.SourceNode = "pve3"
+
+			warnings, err := validator.ValidateCreate(testEnv.GetContext(), &template)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(warnings).To(BeEmpty())
+		})
+
+		It("should skip the check on updates which leave the spec alone", func() {
+			oldTemplate := validProxmoxMachineTemplate("test-template")
+			oldTemplate.Spec.Template.Spec.SourceNode = "pve3"
+			newTemplate := oldTemplate.DeepCopy()
+			newTemplate.SetAnnotations(map[string]string{"foo": "bar"})
+
+			warnings, err := validator.ValidateUpdate(testEnv.GetContext(), &oldTemplate, newTemplate)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(warnings).To(BeEmpty())
+		})
+	})
+})
+
+func validProxmoxMachineTemplate(name string) infrav1.ProxmoxMachineTemplate {
+	machine := validProxmoxMachine(name)
+	return infrav1.ProxmoxMachineTemplate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{clusterv1.ClusterNameLabel: "test"},
+		},
+		Spec: infrav1.ProxmoxMachineTemplateSpec{
+			Template: infrav1.ProxmoxMachineTemplateResource{
+				Spec: machine.Spec,
+			},
+		},
+	}
+}
@@ -69,6 +69,9 @@ var _ = BeforeSuite(func() {
 	err = (&ProxmoxMachine{}).SetupWebhookWithManager(testEnv.Manager)
 	Expect(err).NotTo(HaveOccurred())
 
+	err = (&ProxmoxMachineTemplate{}).SetupWebhookWithManager(testEnv.Manager)
+	Expect(err).NotTo(HaveOccurred())
+
 	//+kubebuilder:scaffold:webhook
 
 	go func() {
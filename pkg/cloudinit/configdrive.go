@@ -0,0 +1,251 @@
+/*
+Copyright 2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+
+	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/types"
+)
+
+// configDriveMetadata is the meta_data.json of an OpenStack config drive.
+// cloud-init copies the uuid to the instance-id, and the hostname to the local-hostname.
+type configDriveMetadata struct {
+	UUID              string `json:"uuid"`
+	Hostname          string `json:"hostname"`
+	ProviderID        string `json:"provider-id,omitempty"`
+	KubernetesVersion string `json:"kubernetes-version,omitempty"`
+}
+
+// ConfigDriveMetadata provides functionality to render machine metadata of an OpenStack config drive.
+type ConfigDriveMetadata struct {
+	Metadata
+}
+
+// NewConfigDriveMetadata returns a new ConfigDriveMetadata object.
+func NewConfigDriveMetadata(instanceID, hostname string, kubernetesVersion string, injectProviderID bool) *ConfigDriveMetadata {
+	return &ConfigDriveMetadata{Metadata: *NewMetadata(instanceID, hostname, kubernetesVersion, injectProviderID)}
+}
+
+// Render returns rendered meta_data.json.
+func (r *ConfigDriveMetadata) Render() ([]byte, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	metadata := configDriveMetadata{
+		UUID:              r.data.InstanceID,
+		Hostname:          r.data.Hostname,
+		KubernetesVersion: r.data.KubernetesVersion,
+	}
+	if r.data.ProviderIDInjection {
+		metadata.ProviderID = fmt.Sprintf("proxmox://%s", r.data.InstanceID)
+	}
+	return json.Marshal(metadata)
+}
+
+// networkData is the network_data.json schema of an OpenStack config drive.
+type networkData struct {
+	Links    []networkDataLink    `json:"links"`
+	Networks []networkDataNetwork `json:"networks"`
+	Services []networkDataService `json:"services,omitempty"`
+}
+
+type networkDataLink struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name,omitempty"`
+	Type       string  `json:"type"`
+	MacAddress string  `json:"ethernet_mac_address"`
+	MTU        *uint16 `json:"mtu,omitempty"`
+}
+
+type networkDataNetwork struct {
+	ID        string             `json:"id"`
+	Type      string             `json:"type"`
+	Link      string             `json:"link"`
+	IPAddress string             `json:"ip_address,omitempty"`
+	Netmask   string             `json:"netmask,omitempty"`
+	Routes    []networkDataRoute `json:"routes,omitempty"`
+	DNSSearch []string           `json:"dns_search,omitempty"`
+}
+
+type networkDataRoute struct {
+	Network string `json:"network"`
+	Netmask string `json:"netmask"`
+	Gateway string `json:"gateway,omitempty"`
+	Metric  uint32 `json:"metric,omitempty"`
+}
+
+type networkDataService struct {
+	Type    string `json:"type"`
+	Address string `json:"address"`
+}
+
+// NetworkData provides functionality to render machine network_data.json of an OpenStack config drive.
+type NetworkData struct {
+	data BaseCloudInitData
+}
+
+// NewNetworkData returns a new NetworkData object.
+func NewNetworkData(configs []types.NetworkConfigData) *NetworkData {
+	nd := new(NetworkData)
+	nd.data = BaseCloudInitData{
+		NetworkConfigData: configs,
+	}
+	return nd
+}
+
+// Render returns rendered network_data.json.
+func (r *NetworkData) Render() ([]byte, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	data := networkData{Networks: []networkDataNetwork{}}
+	for _, d := range r.data.NetworkConfigData {
+		data.Links = append(data.Links, networkDataLink{
+			ID:         d.Name,
+			Name:       d.Name,
+			Type:       "phy",
+			MacAddress: d.MacAddress,
+			MTU:        d.LinkMTU,
+		})
+		data.Networks = append(data.Networks, networksOfLink(d, len(data.Networks))...)
+
+		for _, server := range d.DNSServers {
+			service := networkDataService{Type: "dns", Address: server}
+			if !slices.Contains(data.Services, service) {
+				data.Services = append(data.Services, service)
+			}
+		}
+	}
+
+	rendered, err := json.Marshal(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to render network data")
+	}
+	return rendered, nil
+}
+
+func (r *NetworkData) validate() error {
+	if err := validNetworkConfigData(r.data.NetworkConfigData); err != nil {
+		return err
+	}
+
+	for _, d := range r.data.NetworkConfigData {
+		if d.Type != "ethernet" || len(d.FIBRules) > 0 {
+			return ErrUnsupportedNetworkData
+		}
+		for _, route := range d.Routes {
+			if route.Table != 0 {
+				return ErrUnsupportedNetworkData
+			}
+		}
+	}
+	return nil
+}
+
+// networksOfLink translates the network config data of an ethernet device to the networks of its link.
+// Their ids continue after the networks of the previous links.
+func networksOfLink(d types.NetworkConfigData, offset int) []networkDataNetwork {
+	var networks []networkDataNetwork
+	add := func(network networkDataNetwork) int {
+		network.ID = fmt.Sprintf("network%d", offset+len(networks))
+		network.Link = d.Name
+		network.DNSSearch = d.SearchDomains
+		networks = append(networks, network)
+		return len(networks) - 1
+	}
+
+	// index of the network carrying the routes of each address family.
+	ipv4, ipv6 := -1, -1
+
+	switch {
+	case d.DHCP4:
+		ipv4 = add(networkDataNetwork{Type: "ipv4_dhcp"})
+	case d.IPAddress != "":
+		ipv4 = add(staticNetwork("ipv4", d.IPAddress))
+	}
+
+	switch {
+	case d.DHCP6:
+		ipv6 = add(networkDataNetwork{Type: "ipv6_dhcp"})
+	case d.AcceptRA:
+		ipv6 = add(networkDataNetwork{Type: "ipv6_slaac"})
+	}
+	if !d.DHCP6 && d.IPV6Address != "" {
+		ipv6 = add(staticNetwork("ipv6", d.IPV6Address))
+	}
+
+	if d.Gateway != "" && ipv4 >= 0 {
+		networks[ipv4].Routes = append(networks[ipv4].Routes, networkDataRoute{
+			Network: "0.0.0.0",
+			Netmask: "0.0.0.0",
+			Gateway: d.Gateway,
+			Metric:  ptr.Deref(d.Metric, 0),
+		})
+	}
+	if d.Gateway6 != "" && ipv6 >= 0 {
+		networks[ipv6].Routes = append(networks[ipv6].Routes, networkDataRoute{
+			Network: "::",
+			Netmask: "::",
+			Gateway: d.Gateway6,
+			Metric:  ptr.Deref(d.Metric6, 0),
+		})
+	}
+
+	for _, route := range d.Routes {
+		is4 := routeIsIPv4(route)
+		network := ipv6
+		if is4 {
+			network = ipv4
+		}
+		if network < 0 {
+			network = 0
+		}
+		prefix := netip.MustParsePrefix(routeNetworkV1(route.To, is4)).Masked()
+		networks[network].Routes = append(networks[network].Routes, networkDataRoute{
+			Network: prefix.Addr().String(),
+			Netmask: netmask(prefix),
+			Gateway: route.Via,
+			Metric:  route.Metric,
+		})
+	}
+	return networks
+}
+
+// staticNetwork returns a static network of the address in CIDR notation, with the netmask split off.
+func staticNetwork(networkType, address string) networkDataNetwork {
+	prefix := netip.MustParsePrefix(address)
+	return networkDataNetwork{
+		Type:      networkType,
+		IPAddress: prefix.Addr().String(),
+		Netmask:   netmask(prefix),
+	}
+}
+
+// netmask returns the netmask of a prefix, e.g. 255.255.255.0 for a /24.
+func netmask(prefix netip.Prefix) string {
+	return net.IP(net.CIDRMask(prefix.Bits(), prefix.Addr().BitLen())).String()
+}
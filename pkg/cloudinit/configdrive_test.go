@@ -0,0 +1,119 @@
+/*
+Copyright 2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/types"
+)
+
+func TestConfigDriveMetadata_Render(t *testing.T) {
+	metadata, err := NewConfigDriveMetadata("9a82e2ca-4294-11ee-be56-0242ac120002", "proxmox-control-plane", "1.2.3", true).Render()
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"uuid": "9a82e2ca-4294-11ee-be56-0242ac120002",
+		"hostname": "proxmox-control-plane",
+		"provider-id": "proxmox://9a82e2ca-4294-11ee-be56-0242ac120002",
+		"kubernetes-version": "1.2.3"
+	}`, string(metadata))
+
+	metadata, err = NewConfigDriveMetadata("9a82e2ca-4294-11ee-be56-0242ac120002", "proxmox-control-plane", "", false).Render()
+	require.NoError(t, err)
+	require.JSONEq(t, `{"uuid": "9a82e2ca-4294-11ee-be56-0242ac120002", "hostname": "proxmox-control-plane"}`, string(metadata))
+
+	_, err = NewConfigDriveMetadata("9a82e2ca-4294-11ee-be56-0242ac120002", "", "", false).Render()
+	require.ErrorIs(t, err, ErrMissingHostname)
+}
+
+func TestNetworkData_Render(t *testing.T) {
+	data, err := NewNetworkData([]types.NetworkConfigData{
+		{
+			Type:          "ethernet",
+			Name:          "eth0",
+			MacAddress:    "92:60:a0:5b:22:c2",
+			IPAddress:     "10.10.10.12/24",
+			Gateway:       "10.10.10.1",
+			IPV6Address:   "2001:db8::2/64",
+			Gateway6:      "2001:db8::1",
+			DNSServers:    []string{"8.8.8.8", "8.8.4.4"},
+			SearchDomains: []string{"example.com"},
+			LinkMTU:       ptr.To(uint16(9000)),
+		},
+		{
+			Type:       "ethernet",
+			Name:       "eth1",
+			MacAddress: "b4:87:18:bf:a3:60",
+			DHCP4:      true,
+			DNSServers: []string{"8.8.8.8"},
+			Routes:     []types.RoutingData{{To: "172.16.0.0/16", Via: "10.20.0.1", Metric: 100}},
+		},
+	}).Render()
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"links": [
+			{"id": "eth0", "name": "eth0", "type": "phy", "ethernet_mac_address": "92:60:a0:5b:22:c2", "mtu": 9000},
+			{"id": "eth1", "name": "eth1", "type": "phy", "ethernet_mac_address": "b4:87:18:bf:a3:60"}
+		],
+		"networks": [
+			{
+				"id": "network0", "type": "ipv4", "link": "eth0",
+				"ip_address": "10.10.10.12", "netmask": "255.255.255.0",
+				"routes": [{"network": "0.0.0.0", "netmask": "0.0.0.0", "gateway": "10.10.10.1"}],
+				"dns_search": ["example.com"]
+			},
+			{
+				"id": "network1", "type": "ipv6", "link": "eth0",
+				"ip_address": "2001:db8::2", "netmask": "ffff:ffff:ffff:ffff::",
+				"routes": [{"network": "::", "netmask": "::", "gateway": "2001:db8::1"}],
+				"dns_search": ["example.com"]
+			},
+			{
+				"id": "network2", "type": "ipv4_dhcp", "link": "eth1",
+				"routes": [{"network": "172.16.0.0", "netmask": "255.255.0.0", "gateway": "10.20.0.1", "metric": 100}]
+			}
+		],
+		"services": [
+			{"type": "dns", "address": "8.8.8.8"},
+			{"type": "dns", "address": "8.8.4.4"}
+		]
+	}`, string(data))
+}
+
+func TestNetworkData_RenderUnsupported(t *testing.T) {
+	_, err := NewNetworkData([]types.NetworkConfigData{
+		{
+			Type:       "ethernet",
+			Name:       "eth0",
+			MacAddress: "92:60:a0:5b:22:c2",
+			DHCP4:      true,
+		},
+		{
+			Type:       "vrf",
+			Name:       "vrf-blue",
+			Table:      500,
+			Interfaces: []string{"eth0"},
+		},
+	}).Render()
+	require.ErrorIs(t, err, ErrUnsupportedNetworkData)
+
+	_, err = NewNetworkData(nil).Render()
+	require.ErrorIs(t, err, ErrMissingNetworkConfigData)
+}
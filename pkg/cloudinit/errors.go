@@ -51,4 +51,16 @@ var (
 
 	// ErrMalformedFIBRule is returned if a FIB rule can not be assembled by netplan.
 	ErrMalformedFIBRule = errors.New("routing policy is malformed")
+
+	// ErrUnsupportedNetworkConfigV1 is returned if network-config version 1 can not express the configuration.
+	ErrUnsupportedNetworkConfigV1 = errors.New("vrfs, bonds, bridges, vlans, routing tables and routing policies are not supported by network-config version 1")
+
+	// ErrUnsupportedNetworkData is returned if the network data of an OpenStack config drive can not express the configuration.
+	ErrUnsupportedNetworkData = errors.New("vrfs, bonds, bridges, vlans, routing tables and routing policies are not supported by config drive network data")
+
+	// ErrMalformedUserData is returned if user data is not a valid cloud-config.
+	ErrMalformedUserData = errors.New("user data is not a valid cloud-config")
+
+	// ErrMissingLoginUser is returned if the default user is disabled without a user to log in with.
+	ErrMissingLoginUser = errors.New("login user is not set")
 )
@@ -37,6 +37,63 @@ const (
       {{- template "commonSettings" $element }}
   {{- end -}}
 {{- end -}}
+{{- $bond := 0 -}}
+{{- range $index, $element := .NetworkConfigData }}
+  {{- if eq $element.Type "bond" }}
+  {{- if eq $bond 0 }}
+  bonds:
+  {{- $bond = 1 }}
+  {{- end }}
+    {{ $element.Name }}:
+      interfaces:
+      {{- range $element.Interfaces }}
+        - {{ . }}
+      {{- end }}
+      parameters:
+        mode: {{ $element.BondMode }}
+        {{- if $element.BondMIIMon }}
+        mii-monitor-interval: {{ $element.BondMIIMon }}
+        {{- end }}
+      {{- template "commonSettings" $element }}
+  {{- end -}}
+{{- end -}}
+{{- $bridge := 0 -}}
+{{- range $index, $element := .NetworkConfigData }}
+  {{- if eq $element.Type "bridge" }}
+  {{- if eq $bridge 0 }}
+  bridges:
+  {{- $bridge = 1 }}
+  {{- end }}
+    {{ $element.Name }}:
+      interfaces:
+      {{- range $element.Interfaces }}
+        - {{ . }}
+      {{- end }}
+      {{- if or $element.BridgeSTP $element.ForwardDelay }}
+      parameters:
+        {{- if $element.BridgeSTP }}
+        stp: {{ $element.BridgeSTP }}
+        {{- end }}
+        {{- if $element.ForwardDelay }}
+        forward-delay: {{ $element.ForwardDelay }}
+        {{- end }}
+      {{- end }}
+      {{- template "commonSettings" $element }}
+  {{- end -}}
+{{- end -}}
+{{- $vlan := 0 -}}
+{{- range $index, $element := .NetworkConfigData }}
+  {{- if eq $element.Type "vlan" }}
+  {{- if eq $vlan 0 }}
+  vlans:
+  {{- $vlan = 1 }}
+  {{- end }}
+    {{ $element.Name }}:
+      id: {{ $element.VLANID }}
+      link: {{ $element.Link }}
+      {{- template "commonSettings" $element }}
+  {{- end -}}
+{{- end -}}
 {{- $vrf := 0 -}}
 {{- range $index, $element := .NetworkConfigData }}
   {{- if eq $element.Type "vrf" }}
@@ -58,18 +115,29 @@ const (
 {{- end -}}
 
   {{- define "dns" }}
-    {{- if .DNSServers }}
+    {{- if or .DNSServers .SearchDomains }}
       nameservers:
+      {{- if .DNSServers }}
         addresses:
         {{- range .DNSServers }}
           - '{{ . }}'
         {{- end -}}
+      {{- end }}
+      {{- if .SearchDomains }}
+        search:
+        {{- range .SearchDomains }}
+          - '{{ . }}'
+        {{- end -}}
+      {{- end -}}
     {{- end -}}
   {{- end -}}
 
 {{- define "dhcp" }}
       dhcp4: {{ if .DHCP4 }}true{{ else }}false{{ end }}
       dhcp6: {{ if .DHCP6 }}true{{ else }}false{{ end }}
+      {{- if .AcceptRA }}
+      accept-ra: true
+      {{- end }}
 {{- end -}}
 
 {{- define "rules" }}
@@ -173,7 +241,12 @@ func (r *NetworkConfig) Render() ([]byte, error) {
 }
 
 func (r *NetworkConfig) validate() error {
-	if len(r.data.NetworkConfigData) == 0 {
+	return validNetworkConfigData(r.data.NetworkConfigData)
+}
+
+// validNetworkConfigData checks the network config data shared by all network-config versions.
+func validNetworkConfigData(data []types.NetworkConfigData) error {
+	if len(data) == 0 {
 		return ErrMissingNetworkConfigData
 	}
 	metrics := make(map[uint32]*struct {
@@ -181,9 +254,9 @@ func (r *NetworkConfig) validate() error {
 		ipv6 bool
 	})
 
-	for i, d := range r.data.NetworkConfigData {
+	for i, d := range data {
 		// TODO: refactor this when network configuration is unified
-		if d.Type != "ethernet" {
+		if d.Type != "ethernet" && d.Type != "bond" && d.Type != "bridge" && d.Type != "vlan" {
 			err := validRoutes(d.Routes)
 			if err != nil {
 				return err
@@ -195,14 +268,19 @@ func (r *NetworkConfig) validate() error {
 			continue
 		}
 
-		if !d.DHCP4 && !d.DHCP6 && len(d.IPAddress) == 0 && len(d.IPV6Address) == 0 {
+		// the members of a bond or bridge have no addresses, the bond or bridge carries them.
+		if !d.DHCP4 && !d.DHCP6 && !d.AcceptRA && len(d.IPAddress) == 0 && len(d.IPV6Address) == 0 && d.Bond == "" && d.Bridge == "" {
 			return ErrMissingIPAddress
 		}
 
-		if d.MacAddress == "" {
+		if d.MacAddress == "" && d.Type == "ethernet" {
 			return ErrMissingMacAddress
 		}
 
+		if err := validRoutes(d.Routes); err != nil {
+			return err
+		}
+
 		if !d.DHCP4 && len(d.IPAddress) > 0 {
 			err := validIPAddress(d.IPAddress)
 			if err != nil {
@@ -252,24 +330,29 @@ func (r *NetworkConfig) validate() error {
 }
 
 func validRoutes(input []types.RoutingData) error {
-	if len(input) == 0 {
-		return nil
+	for _, route := range input {
+		if err := ValidRoute(route); err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+// ValidRoute returns ErrMalformedRoute if netplan can not assemble the route.
+func ValidRoute(route types.RoutingData) error {
 	// No support for blackhole, etc.pp. Add iff you require this.
-	for _, route := range input {
-		if route.To != "default" {
-			// An IP address is a valid route (implicit smallest subnet)
-			_, errPrefix := netip.ParsePrefix(route.To)
-			_, errAddr := netip.ParseAddr(route.To)
-			if errPrefix != nil && errAddr != nil {
-				return ErrMalformedRoute
-			}
+	if route.To != "default" {
+		// An IP address is a valid route (implicit smallest subnet)
+		_, errPrefix := netip.ParsePrefix(route.To)
+		_, errAddr := netip.ParseAddr(route.To)
+		if errPrefix != nil && errAddr != nil {
+			return ErrMalformedRoute
 		}
-		if route.Via != "" {
-			_, err := netip.ParseAddr(route.Via)
-			if err != nil {
-				return ErrMalformedRoute
-			}
+	}
+	if route.Via != "" {
+		_, err := netip.ParseAddr(route.Via)
+		if err != nil {
+			return ErrMalformedRoute
 		}
 	}
 	return nil
@@ -84,6 +84,47 @@ const (
           metric: 100
           via: 10.10.10.1`
 
+	expectedValidNetworkConfigWithSearchDomains = `network:
+  version: 2
+  renderer: networkd
+  ethernets:
+    eth0:
+      match:
+        macaddress: 92:60:a0:5b:22:c2
+      dhcp4: false
+      dhcp6: false
+      addresses:
+        - 10.10.10.12/24
+      routes:
+        - to: 0.0.0.0/0
+          metric: 100
+          via: 10.10.10.1
+      nameservers:
+        addresses:
+          - '8.8.8.8'
+        search:
+          - 'example.com'
+          - 'cluster.local'`
+
+	expectedValidNetworkConfigWithSearchDomainsWithoutDNS = `network:
+  version: 2
+  renderer: networkd
+  ethernets:
+    eth0:
+      match:
+        macaddress: 92:60:a0:5b:22:c2
+      dhcp4: false
+      dhcp6: false
+      addresses:
+        - 10.10.10.12/24
+      routes:
+        - to: 0.0.0.0/0
+          metric: 100
+          via: 10.10.10.1
+      nameservers:
+        search:
+          - 'example.com'`
+
 	expectedValidNetworkConfigMultipleNics = `network:
   version: 2
   renderer: networkd
@@ -200,6 +241,26 @@ const (
         macaddress: 92:60:a0:5b:22:c2
       dhcp4: false
       dhcp6: true
+      nameservers:
+        addresses:
+          - '8.8.8.8'
+          - '8.8.4.4'`
+
+	expectedValidNetworkConfigSLAAC = `network:
+  version: 2
+  renderer: networkd
+  ethernets:
+    eth0:
+      match:
+        macaddress: 92:60:a0:5b:22:c2
+      dhcp4: false
+      dhcp6: false
+      accept-ra: true
+      addresses:
+        - 10.10.10.12/24
+      routes:
+        - to: 0.0.0.0/0
+          via: 10.10.10.1
       nameservers:
         addresses:
           - '8.8.8.8'
@@ -408,6 +469,118 @@ const (
       interfaces:
         - eth1`
 
+	expectedValidNetworkConfigBond = `network:
+  version: 2
+  renderer: networkd
+  ethernets:
+    eth0:
+      match:
+        macaddress: 92:60:a0:5b:22:c2
+      dhcp4: false
+      dhcp6: false
+    eth1:
+      match:
+        macaddress: b4:87:18:bf:a3:60
+      dhcp4: false
+      dhcp6: false
+  bonds:
+    bond0:
+      interfaces:
+        - eth0
+        - eth1
+      parameters:
+        mode: active-backup
+        mii-monitor-interval: 100
+      dhcp4: false
+      dhcp6: false
+      addresses:
+        - 10.10.10.12/24
+      routes:
+        - to: 0.0.0.0/0
+          via: 10.10.10.1
+      nameservers:
+        addresses:
+          - '8.8.8.8'
+          - '8.8.4.4'`
+
+	expectedValidNetworkConfigVLANOverBond = `network:
+  version: 2
+  renderer: networkd
+  ethernets:
+    eth0:
+      match:
+        macaddress: 92:60:a0:5b:22:c2
+      dhcp4: false
+      dhcp6: false
+    eth1:
+      match:
+        macaddress: b4:87:18:bf:a3:60
+      dhcp4: false
+      dhcp6: false
+  bonds:
+    bond0:
+      interfaces:
+        - eth0
+        - eth1
+      parameters:
+        mode: 802.3ad
+      dhcp4: false
+      dhcp6: false
+      addresses:
+        - 10.10.10.12/24
+      routes:
+        - to: 0.0.0.0/0
+          via: 10.10.10.1
+  vlans:
+    bond0.100:
+      id: 100
+      link: bond0
+      dhcp4: false
+      dhcp6: false
+      addresses:
+        - 10.20.0.5/24`
+
+	expectedValidNetworkConfigBridge = `network:
+  version: 2
+  renderer: networkd
+  ethernets:
+    eth0:
+      match:
+        macaddress: 92:60:a0:5b:22:c2
+      dhcp4: false
+      dhcp6: false
+    eth1:
+      match:
+        macaddress: b4:87:18:bf:a3:60
+      dhcp4: false
+      dhcp6: false
+  bonds:
+    bond0:
+      interfaces:
+        - eth1
+      parameters:
+        mode: active-backup
+      dhcp4: false
+      dhcp6: false
+  bridges:
+    br0:
+      interfaces:
+        - eth0
+        - bond0
+      parameters:
+        stp: true
+        forward-delay: 4
+      dhcp4: false
+      dhcp6: false
+      addresses:
+        - 10.10.10.12/24
+      routes:
+        - to: 0.0.0.0/0
+          via: 10.10.10.1
+      nameservers:
+        addresses:
+          - '8.8.8.8'`
+
 	expectedValidNetworkConfigValidFIBRule = `network:
   version: 2
   renderer: networkd
@@ -733,6 +906,47 @@ func TestNetworkConfig_Render(t *testing.T) {
 				err:     nil,
 			},
 		},
+		"ValidNetworkConfigWithSearchDomains": {
+			reason: "valid config with dns search domains",
+			args: args{
+				nics: []types.NetworkConfigData{
+					{
+						Type:          "ethernet",
+						Name:          "eth0",
+						MacAddress:    "92:60:a0:5b:22:c2",
+						IPAddress:     "10.10.10.12/24",
+						Gateway:       "10.10.10.1",
+						Metric:        ptr.To(uint32(100)),
+						DNSServers:    []string{"8.8.8.8"},
+						SearchDomains: []string{"example.com", "cluster.local"},
+					},
+				},
+			},
+			want: want{
+				network: expectedValidNetworkConfigWithSearchDomains,
+				err:     nil,
+			},
+		},
+		"ValidNetworkConfigWithSearchDomainsWithoutDNS": {
+			reason: "valid config with dns search domains but without nameservers",
+			args: args{
+				nics: []types.NetworkConfigData{
+					{
+						Type:          "ethernet",
+						Name:          "eth0",
+						MacAddress:    "92:60:a0:5b:22:c2",
+						IPAddress:     "10.10.10.12/24",
+						Gateway:       "10.10.10.1",
+						Metric:        ptr.To(uint32(100)),
+						SearchDomains: []string{"example.com"},
+					},
+				},
+			},
+			want: want{
+				network: expectedValidNetworkConfigWithSearchDomainsWithoutDNS,
+				err:     nil,
+			},
+		},
 		"ValidNetworkConfigMultipleNics": {
 			reason: "valid config multiple nics",
 			args: args{
@@ -872,6 +1086,183 @@ func TestNetworkConfig_Render(t *testing.T) {
 				err:     nil,
 			},
 		},
+		"ValidNetworkConfigSLAAC": {
+			reason: "render valid network-config with static ipv4 and slaac",
+			args: args{
+				nics: []types.NetworkConfigData{
+					{
+						Type:       "ethernet",
+						Name:       "eth0",
+						MacAddress: "92:60:a0:5b:22:c2",
+						AcceptRA:   true,
+						IPAddress:  "10.10.10.12/24",
+						Gateway:    "10.10.10.1",
+						DNSServers: []string{"8.8.8.8", "8.8.4.4"},
+					},
+				},
+			},
+			want: want{
+				network: expectedValidNetworkConfigSLAAC,
+				err:     nil,
+			},
+		},
+		"ValidNetworkConfigBond": {
+			reason: "valid config with the addresses moved from the nics to their bond",
+			args: args{
+				nics: []types.NetworkConfigData{
+					{
+						Type:       "ethernet",
+						Name:       "eth0",
+						MacAddress: "92:60:a0:5b:22:c2",
+						Bond:       "bond0",
+					},
+					{
+						Type:       "ethernet",
+						Name:       "eth1",
+						MacAddress: "b4:87:18:bf:a3:60",
+						Bond:       "bond0",
+					},
+					{
+						Type:       "bond",
+						Name:       "bond0",
+						Interfaces: []string{"eth0", "eth1"},
+						BondMode:   "active-backup",
+						BondMIIMon: ptr.To(uint32(100)),
+						IPAddress:  "10.10.10.12/24",
+						Gateway:    "10.10.10.1",
+						DNSServers: []string{"8.8.8.8", "8.8.4.4"},
+					},
+				},
+			},
+			want: want{
+				network: expectedValidNetworkConfigBond,
+				err:     nil,
+			},
+		},
+		"InvalidNetworkConfigBondWithoutIP": {
+			reason: "a bond requires an address",
+			args: args{
+				nics: []types.NetworkConfigData{
+					{
+						Type:       "ethernet",
+						Name:       "eth0",
+						MacAddress: "92:60:a0:5b:22:c2",
+						Bond:       "bond0",
+					},
+					{
+						Type:       "bond",
+						Name:       "bond0",
+						Interfaces: []string{"eth0"},
+						BondMode:   "active-backup",
+					},
+				},
+			},
+			want: want{
+				network: "",
+				err:     ErrMissingIPAddress,
+			},
+		},
+		"ValidNetworkConfigBridge": {
+			reason: "valid config with the addresses moved from a nic and a bond to their bridge",
+			args: args{
+				nics: []types.NetworkConfigData{
+					{
+						Type:       "ethernet",
+						Name:       "eth0",
+						MacAddress: "92:60:a0:5b:22:c2",
+						Bridge:     "br0",
+					},
+					{
+						Type:       "ethernet",
+						Name:       "eth1",
+						MacAddress: "b4:87:18:bf:a3:60",
+						Bond:       "bond0",
+					},
+					{
+						Type:       "bond",
+						Name:       "bond0",
+						Interfaces: []string{"eth1"},
+						BondMode:   "active-backup",
+						Bridge:     "br0",
+					},
+					{
+						Type:         "bridge",
+						Name:         "br0",
+						Interfaces:   []string{"eth0", "bond0"},
+						BridgeSTP:    ptr.To(true),
+						ForwardDelay: ptr.To(uint32(4)),
+						IPAddress:    "10.10.10.12/24",
+						Gateway:      "10.10.10.1",
+						DNSServers:   []string{"8.8.8.8"},
+					},
+				},
+			},
+			want: want{
+				network: expectedValidNetworkConfigBridge,
+				err:     nil,
+			},
+		},
+		"ValidNetworkConfigVLANOverBond": {
+			reason: "valid config with a vlan on top of a bond",
+			args: args{
+				nics: []types.NetworkConfigData{
+					{
+						Type:       "ethernet",
+						Name:       "eth0",
+						MacAddress: "92:60:a0:5b:22:c2",
+						Bond:       "bond0",
+					},
+					{
+						Type:       "ethernet",
+						Name:       "eth1",
+						MacAddress: "b4:87:18:bf:a3:60",
+						Bond:       "bond0",
+					},
+					{
+						Type:       "bond",
+						Name:       "bond0",
+						Interfaces: []string{"eth0", "eth1"},
+						BondMode:   "802.3ad",
+						IPAddress:  "10.10.10.12/24",
+						Gateway:    "10.10.10.1",
+					},
+					{
+						Type:      "vlan",
+						Name:      "bond0.100",
+						Link:      "bond0",
+						VLANID:    100,
+						IPAddress: "10.20.0.5/24",
+					},
+				},
+			},
+			want: want{
+				network: expectedValidNetworkConfigVLANOverBond,
+				err:     nil,
+			},
+		},
+		"InvalidNetworkConfigVLANWithoutIP": {
+			reason: "a vlan requires an address",
+			args: args{
+				nics: []types.NetworkConfigData{
+					{
+						Type:       "ethernet",
+						Name:       "eth0",
+						MacAddress: "92:60:a0:5b:22:c2",
+						DHCP4:      true,
+					},
+					{
+						Type:   "vlan",
+						Name:   "eth0.100",
+						Link:   "eth0",
+						VLANID: 100,
+					},
+				},
+			},
+			want: want{
+				network: "",
+				err:     ErrMissingIPAddress,
+			},
+		},
 		"ValidNetworkConfigMultipleNicsVRF": {
 			reason: "valid config multiple nics enslaved to VRF",
 			args: args{
@@ -1005,6 +1396,28 @@ func TestNetworkConfig_Render(t *testing.T) {
 				err:     nil,
 			},
 		},
+		"InvalidNetworkConfigMalformedRouteGateway": {
+			reason: "invalid config malformed route gateway",
+			args: args{
+				nics: []types.NetworkConfigData{
+					{
+						Type:       "ethernet",
+						Name:       "eth0",
+						MacAddress: "92:60:a0:5b:22:c2",
+						IPAddress:  "10.10.10.12/24",
+						Gateway:    "10.10.10.1",
+						Routes: []types.RoutingData{{
+							To:  "10.200.0.0/16",
+							Via: "10.10.10",
+						}},
+					},
+				},
+			},
+			want: want{
+				network: "",
+				err:     ErrMalformedRoute,
+			},
+		},
 		"InvalidNetworkConfigMalformedFIBRule": {
 			reason: "invalid config malformed routing policy",
 			args: args{
@@ -1036,3 +1449,248 @@ func TestNetworkConfig_Render(t *testing.T) {
 		})
 	}
 }
+
+const (
+	expectedValidNetworkConfigV1 = `version: 1
+config:
+  - type: physical
+    name: eth0
+    mac_address: 92:60:a0:5b:22:c2
+    mtu: 9001
+    subnets:
+      - type: static
+        address: 10.10.10.12/24
+        gateway: 10.10.10.1
+        dns_nameservers:
+          - 8.8.8.8
+          - 8.8.4.4
+        dns_search:
+          - example.com
+`
+
+	expectedValidNetworkConfigV1WithLinkMTU = `version: 1
+config:
+  - type: physical
+    name: eth0
+    mac_address: 92:60:a0:5b:22:c2
+    mtu: 9001
+    subnets:
+      - type: static
+        address: 10.10.10.12/24
+        dns_nameservers:
+          - 8.8.8.8
+          - 8.8.4.4
+        routes:
+          - network: 0.0.0.0/0
+            gateway: 10.10.10.1
+            metric: 100
+`
+
+	expectedValidNetworkConfigV1WithRoutes = `version: 1
+config:
+  - type: physical
+    name: eth0
+    mac_address: 92:60:a0:5b:22:c2
+    subnets:
+      - type: static
+        address: 10.10.10.12/24
+        routes:
+          - network: 0.0.0.0/0
+            gateway: 10.10.10.1
+            metric: 100
+          - network: 172.16.24.0/24
+            gateway: 10.10.10.254
+            metric: 50
+          - network: 10.20.0.1/32
+            gateway: 10.10.10.253
+      - type: static6
+        address: 2001:db8::12/64
+        gateway: 2001:db8::1
+        routes:
+          - network: 2001:db8:1::/48
+            gateway: 2001:db8::254
+`
+
+	expectedValidNetworkConfigV1WithDHCP = `version: 1
+config:
+  - type: physical
+    name: eth0
+    mac_address: 92:60:a0:5b:22:c2
+    subnets:
+      - type: dhcp4
+      - type: ipv6_slaac
+  - type: physical
+    name: eth1
+    mac_address: b4:87:18:bf:a3:60
+    subnets:
+      - type: dhcp6
+  - type: nameserver
+    address:
+      - 8.8.8.8
+`
+)
+
+func TestNetworkConfigV1_Render(t *testing.T) {
+	type args struct {
+		nics []types.NetworkConfigData
+	}
+
+	type want struct {
+		network string
+		err     error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"ValidStaticNetworkConfig": {
+			reason: "render valid network-config with static ip, dns and mtu",
+			args: args{
+				nics: []types.NetworkConfigData{
+					{
+						Type:          "ethernet",
+						Name:          "eth0",
+						MacAddress:    "92:60:a0:5b:22:c2",
+						IPAddress:     "10.10.10.12/24",
+						Gateway:       "10.10.10.1",
+						DNSServers:    []string{"8.8.8.8", "8.8.4.4"},
+						SearchDomains: []string{"example.com"},
+						LinkMTU:       ptr.To(uint16(9001)),
+					},
+				},
+			},
+			want: want{
+				network: expectedValidNetworkConfigV1,
+			},
+		},
+		"ValidNetworkConfigWithRoutes": {
+			reason: "render valid network-config with gateway metrics and static routes",
+			args: args{
+				nics: []types.NetworkConfigData{
+					{
+						Type:        "ethernet",
+						Name:        "eth0",
+						MacAddress:  "92:60:a0:5b:22:c2",
+						IPAddress:   "10.10.10.12/24",
+						Gateway:     "10.10.10.1",
+						Metric:      ptr.To(uint32(100)),
+						IPV6Address: "2001:db8::12/64",
+						Gateway6:    "2001:db8::1",
+						Routes: []types.RoutingData{
+							{To: "172.16.24.0/24", Via: "10.10.10.254", Metric: 50},
+							{To: "2001:db8:1::/48", Via: "2001:db8::254"},
+							{To: "10.20.0.1", Via: "10.10.10.253"},
+						},
+					},
+				},
+			},
+			want: want{
+				network: expectedValidNetworkConfigV1WithRoutes,
+			},
+		},
+		"ValidDHCPNetworkConfig": {
+			reason: "render valid network-config with dhcp, slaac and global nameservers",
+			args: args{
+				nics: []types.NetworkConfigData{
+					{
+						Type:       "ethernet",
+						Name:       "eth0",
+						MacAddress: "92:60:a0:5b:22:c2",
+						DHCP4:      true,
+						AcceptRA:   true,
+						DNSServers: []string{"8.8.8.8"},
+					},
+					{
+						Type:       "ethernet",
+						Name:       "eth1",
+						MacAddress: "b4:87:18:bf:a3:60",
+						DHCP6:      true,
+					},
+				},
+			},
+			want: want{
+				network: expectedValidNetworkConfigV1WithDHCP,
+			},
+		},
+		"InvalidNetworkConfigData": {
+			reason: "network config data is not set",
+			args:   args{},
+			want: want{
+				err: ErrMissingNetworkConfigData,
+			},
+		},
+		"UnsupportedVRF": {
+			reason: "vrfs can not be expressed in version 1",
+			args: args{
+				nics: []types.NetworkConfigData{
+					{
+						Type:       "ethernet",
+						Name:       "eth0",
+						MacAddress: "92:60:a0:5b:22:c2",
+						DHCP4:      true,
+					},
+					{
+						Type:       "vrf",
+						Name:       "vrf-blue",
+						Table:      500,
+						Interfaces: []string{"eth0"},
+					},
+				},
+			},
+			want: want{
+				err: ErrUnsupportedNetworkConfigV1,
+			},
+		},
+		"UnsupportedRoutingTable": {
+			reason: "routing tables can not be expressed in version 1",
+			args: args{
+				nics: []types.NetworkConfigData{
+					{
+						Type:       "ethernet",
+						Name:       "eth0",
+						MacAddress: "92:60:a0:5b:22:c2",
+						DHCP4:      true,
+						Routes:     []types.RoutingData{{To: "172.16.24.0/24", Via: "10.10.10.254", Table: 100}},
+					},
+				},
+			},
+			want: want{
+				err: ErrUnsupportedNetworkConfigV1,
+			},
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			nc := NewNetworkConfigV1(tc.args.nics)
+			network, err := nc.Render()
+			require.ErrorIs(t, err, tc.want.err)
+			require.Equal(t, tc.want.network, string(network))
+		})
+	}
+}
+
+func TestNetworkConfig_RenderVersions(t *testing.T) {
+	nics := []types.NetworkConfigData{
+		{
+			Type:       "ethernet",
+			Name:       "eth0",
+			MacAddress: "92:60:a0:5b:22:c2",
+			IPAddress:  "10.10.10.12/24",
+			Gateway:    "10.10.10.1",
+			Metric:     ptr.To(uint32(100)),
+			DNSServers: []string{"8.8.8.8", "8.8.4.4"},
+			LinkMTU:    ptr.To(uint16(9001)),
+		},
+	}
+
+	v2, err := NewNetworkConfig(nics).Render()
+	require.NoError(t, err)
+	require.Equal(t, expectedValidNetworkConfigWithLinkMTU, string(v2))
+
+	v1, err := NewNetworkConfigV1(nics).Render()
+	require.NoError(t, err)
+	require.Equal(t, expectedValidNetworkConfigV1WithLinkMTU, string(v1))
+}
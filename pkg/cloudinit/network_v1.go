@@ -0,0 +1,243 @@
+/*
+Copyright 2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"bytes"
+	"net/netip"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	"k8s.io/utils/ptr"
+
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/types"
+)
+
+// networkConfigV1 is the network-config version 1 schema of cloud-init.
+type networkConfigV1 struct {
+	Version int                    `yaml:"version"`
+	Config  []networkConfigV1Entry `yaml:"config"`
+}
+
+// networkConfigV1Entry is either a physical interface or a global nameserver.
+type networkConfigV1Entry struct {
+	Type       string                  `yaml:"type"`
+	Name       string                  `yaml:"name,omitempty"`
+	MacAddress string                  `yaml:"mac_address,omitempty"`
+	MTU        *uint16                 `yaml:"mtu,omitempty"`
+	Subnets    []networkConfigV1Subnet `yaml:"subnets,omitempty"`
+	Address    []string                `yaml:"address,omitempty"`
+	Search     []string                `yaml:"search,omitempty"`
+}
+
+type networkConfigV1Subnet struct {
+	Type           string                 `yaml:"type"`
+	Address        string                 `yaml:"address,omitempty"`
+	Gateway        string                 `yaml:"gateway,omitempty"`
+	DNSNameservers []string               `yaml:"dns_nameservers,omitempty"`
+	DNSSearch      []string               `yaml:"dns_search,omitempty"`
+	Routes         []networkConfigV1Route `yaml:"routes,omitempty"`
+}
+
+type networkConfigV1Route struct {
+	Network string `yaml:"network"`
+	Gateway string `yaml:"gateway,omitempty"`
+	Metric  uint32 `yaml:"metric,omitempty"`
+}
+
+// NetworkConfigV1 provides functionality to render machine network-config version 1,
+// for images whose cloud-init does not understand version 2.
+type NetworkConfigV1 struct {
+	data BaseCloudInitData
+}
+
+// NewNetworkConfigV1 returns a new NetworkConfigV1 object.
+func NewNetworkConfigV1(configs []types.NetworkConfigData) *NetworkConfigV1 {
+	nc := new(NetworkConfigV1)
+	nc.data = BaseCloudInitData{
+		NetworkConfigData: configs,
+	}
+	return nc
+}
+
+// Render returns rendered network-config version 1.
+func (r *NetworkConfigV1) Render() ([]byte, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	config := networkConfigV1{Version: 1}
+	var nameservers []networkConfigV1Entry
+	for _, d := range r.data.NetworkConfigData {
+		iface, nameserver := physicalInterfaceV1(d)
+		config.Config = append(config.Config, iface)
+		if nameserver != nil {
+			nameservers = append(nameservers, *nameserver)
+		}
+	}
+	config.Config = append(config.Config, nameservers...)
+
+	buffer := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(config); err != nil {
+		return nil, errors.Wrap(err, "failed to render network-config")
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to render network-config")
+	}
+	return buffer.Bytes(), nil
+}
+
+func (r *NetworkConfigV1) validate() error {
+	if err := validNetworkConfigData(r.data.NetworkConfigData); err != nil {
+		return err
+	}
+
+	for _, d := range r.data.NetworkConfigData {
+		if d.Type != "ethernet" || len(d.FIBRules) > 0 {
+			return ErrUnsupportedNetworkConfigV1
+		}
+		for _, route := range d.Routes {
+			if route.Table != 0 {
+				return ErrUnsupportedNetworkConfigV1
+			}
+		}
+	}
+	return nil
+}
+
+// physicalInterfaceV1 translates the network config data of an ethernet device to a physical interface.
+// The nameservers end up in the first static subnet, as cloud-init ignores them on DHCP subnets.
+// Without a static subnet, they are returned as a global nameserver entry.
+func physicalInterfaceV1(d types.NetworkConfigData) (networkConfigV1Entry, *networkConfigV1Entry) {
+	iface := networkConfigV1Entry{
+		Type:       "physical",
+		Name:       d.Name,
+		MacAddress: d.MacAddress,
+		MTU:        d.LinkMTU,
+	}
+
+	// index of the subnet carrying the routes of each address family.
+	ipv4, ipv6 := -1, -1
+
+	switch {
+	case d.DHCP4:
+		iface.Subnets = append(iface.Subnets, networkConfigV1Subnet{Type: "dhcp4"})
+		ipv4 = len(iface.Subnets) - 1
+	case d.IPAddress != "":
+		iface.Subnets = append(iface.Subnets, networkConfigV1Subnet{Type: "static", Address: d.IPAddress})
+		ipv4 = len(iface.Subnets) - 1
+	}
+
+	switch {
+	case d.DHCP6:
+		iface.Subnets = append(iface.Subnets, networkConfigV1Subnet{Type: "dhcp6"})
+		ipv6 = len(iface.Subnets) - 1
+	case d.AcceptRA:
+		iface.Subnets = append(iface.Subnets, networkConfigV1Subnet{Type: "ipv6_slaac"})
+		ipv6 = len(iface.Subnets) - 1
+	}
+	if !d.DHCP6 && d.IPV6Address != "" {
+		iface.Subnets = append(iface.Subnets, networkConfigV1Subnet{Type: "static6", Address: d.IPV6Address})
+		ipv6 = len(iface.Subnets) - 1
+	}
+
+	// a subnet gateway has no metric, so gateways with a metric become default routes.
+	if d.Gateway != "" && ipv4 >= 0 {
+		if d.Metric == nil && iface.Subnets[ipv4].Type == "static" {
+			iface.Subnets[ipv4].Gateway = d.Gateway
+		} else {
+			iface.Subnets[ipv4].Routes = append(iface.Subnets[ipv4].Routes, networkConfigV1Route{
+				Network: "0.0.0.0/0",
+				Gateway: d.Gateway,
+				Metric:  ptr.Deref(d.Metric, 0),
+			})
+		}
+	}
+	if d.Gateway6 != "" && ipv6 >= 0 {
+		if d.Metric6 == nil && iface.Subnets[ipv6].Type == "static6" {
+			iface.Subnets[ipv6].Gateway = d.Gateway6
+		} else {
+			iface.Subnets[ipv6].Routes = append(iface.Subnets[ipv6].Routes, networkConfigV1Route{
+				Network: "::/0",
+				Gateway: d.Gateway6,
+				Metric:  ptr.Deref(d.Metric6, 0),
+			})
+		}
+	}
+
+	for _, route := range d.Routes {
+		is4 := routeIsIPv4(route)
+		subnet := ipv6
+		if is4 {
+			subnet = ipv4
+		}
+		if subnet < 0 {
+			subnet = 0
+		}
+		iface.Subnets[subnet].Routes = append(iface.Subnets[subnet].Routes, networkConfigV1Route{
+			Network: routeNetworkV1(route.To, is4),
+			Gateway: route.Via,
+			Metric:  route.Metric,
+		})
+	}
+
+	if len(d.DNSServers) == 0 && len(d.SearchDomains) == 0 {
+		return iface, nil
+	}
+	for i := range iface.Subnets {
+		if iface.Subnets[i].Type == "static" || iface.Subnets[i].Type == "static6" {
+			iface.Subnets[i].DNSNameservers = d.DNSServers
+			iface.Subnets[i].DNSSearch = d.SearchDomains
+			return iface, nil
+		}
+	}
+	return iface, &networkConfigV1Entry{
+		Type:    "nameserver",
+		Address: d.DNSServers,
+		Search:  d.SearchDomains,
+	}
+}
+
+// routeIsIPv4 returns whether a route belongs to IPv4, judged by its gateway, or its destination without one.
+func routeIsIPv4(route types.RoutingData) bool {
+	if via, err := netip.ParseAddr(route.Via); err == nil {
+		return via.Is4()
+	}
+	if prefix, err := netip.ParsePrefix(route.To); err == nil {
+		return prefix.Addr().Is4()
+	}
+	if addr, err := netip.ParseAddr(route.To); err == nil {
+		return addr.Is4()
+	}
+	return true
+}
+
+// routeNetworkV1 returns the destination of a route in CIDR notation, which version 1 requires.
+func routeNetworkV1(to string, is4 bool) string {
+	if to == "default" {
+		if is4 {
+			return "0.0.0.0/0"
+		}
+		return "::/0"
+	}
+	if addr, err := netip.ParseAddr(to); err == nil {
+		return netip.PrefixFrom(addr, addr.BitLen()).String()
+	}
+	return to
+}
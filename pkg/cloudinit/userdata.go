@@ -0,0 +1,379 @@
+/*
+Copyright 2023-2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// cloudConfigHeader is the first line cloud-init expects in cloud-config user data.
+const cloudConfigHeader = "#cloud-config"
+
+// ValidateUserData checks that the given user data is a cloud-config mapping, like `runcmd: [...]`.
+func ValidateUserData(userData string) error {
+	_, body := splitHeader([]byte(userData))
+	_, err := parseCloudConfig(body)
+	return err
+}
+
+// File is a file written by the write_files module of cloud-init.
+type File struct {
+	Path        string `yaml:"path"`
+	Content     string `yaml:"content"`
+	Permissions string `yaml:"permissions,omitempty"`
+	Owner       string `yaml:"owner,omitempty"`
+}
+
+// MergeUserData merges additional cloud-config into the user data of the bootstrap provider.
+// Lists in both, like write_files and runcmd, are concatenated, with the entries of the user data first.
+// Any other key of the additional cloud-config replaces the one of the user data.
+// The comment lines heading the user data, like `## template: jinja`, are kept,
+// and the `#cloud-config` header is added if they lack it.
+func MergeUserData(userData []byte, additional string) ([]byte, error) {
+	if strings.TrimSpace(additional) == "" {
+		return userData, nil
+	}
+
+	_, extraBody := splitHeader([]byte(additional))
+	extra, err := parseCloudConfig(extraBody)
+	if err != nil {
+		return nil, errors.Wrap(err, "additional user data")
+	}
+	return mergeCloudConfig(userData, extra)
+}
+
+// AddFiles adds files to the write_files of the user data, after the files of the bootstrap provider.
+// cloud-init writes them before any command of the bootstrap provider runs.
+func AddFiles(userData []byte, files []File) ([]byte, error) {
+	if len(files) == 0 {
+		return userData, nil
+	}
+
+	extra := &yaml.Node{}
+	if err := extra.Encode(map[string][]File{"write_files": files}); err != nil {
+		return nil, errors.Wrap(err, "unable to render files")
+	}
+	return mergeCloudConfig(userData, extra)
+}
+
+// Mount is a device which cloud-init partitions, formats and mounts on first boot.
+type Mount struct {
+	Device     string
+	FSType     string
+	MountPoint string
+	Options    string
+}
+
+// diskSetup is the partition table the disk_setup module of cloud-init creates on a device.
+type diskSetup struct {
+	TableType string `yaml:"table_type"`
+	Layout    bool   `yaml:"layout"`
+	Overwrite bool   `yaml:"overwrite"`
+}
+
+// fsSetup is a filesystem the fs_setup module of cloud-init creates.
+type fsSetup struct {
+	Label      string `yaml:"label"`
+	Filesystem string `yaml:"filesystem"`
+	Device     string `yaml:"device"`
+	Partition  string `yaml:"partition"`
+	Overwrite  bool   `yaml:"overwrite"`
+}
+
+// AddMounts adds a single partition to the devices of the mounts with the disk_setup module of cloud-init,
+// formats it with the fs_setup module and mounts it with the mounts module. Devices which already have
+// a partition table or a filesystem are not overwritten. The filesystems are mounted by their label,
+// as the name of a partition depends on the name of its device, e.g. /dev/sdb1 or /dev/disk/by-id/...-part1.
+func AddMounts(userData []byte, mounts []Mount) ([]byte, error) {
+	if len(mounts) == 0 {
+		return userData, nil
+	}
+
+	disks := make(map[string]diskSetup, len(mounts))
+	filesystems := make([]fsSetup, 0, len(mounts))
+	entries := make([][]string, 0, len(mounts))
+	for i, mount := range mounts {
+		label := fmt.Sprintf("capmox%d", i)
+		disks[mount.Device] = diskSetup{TableType: "gpt", Layout: true}
+		filesystems = append(filesystems, fsSetup{Label: label, Filesystem: mount.FSType, Device: mount.Device, Partition: "auto"})
+
+		mountPoint, options, pass := mount.MountPoint, mount.Options, "2"
+		if mount.FSType == "swap" {
+			mountPoint, pass = "none", "0"
+			if options == "" {
+				options = "sw"
+			}
+		}
+		if options == "" {
+			options = "defaults,nofail"
+		}
+		entries = append(entries, []string{"LABEL=" + label, mountPoint, mount.FSType, options, "0", pass})
+	}
+
+	header, body := splitHeader(userData)
+	base, err := parseCloudConfig(body)
+	if err != nil {
+		return nil, errors.Wrap(err, "bootstrap user data")
+	}
+
+	setup := &yaml.Node{}
+	if err := setup.Encode(disks); err != nil {
+		return nil, errors.Wrap(err, "unable to render disk setup")
+	}
+	// disk_setup maps devices to their partition table, the devices are added to the ones of the bootstrap data.
+	existing := false
+	for i := 0; i+1 < len(base.Content); i += 2 {
+		if base.Content[i].Value == "disk_setup" && base.Content[i+1].Kind == yaml.MappingNode {
+			mergeMappings(base.Content[i+1], setup)
+			existing = true
+			break
+		}
+	}
+	if !existing {
+		mergeMappings(base, &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: "disk_setup"}, setup,
+		}})
+	}
+
+	extra := &yaml.Node{}
+	if err := extra.Encode(struct {
+		FSSetup []fsSetup  `yaml:"fs_setup"`
+		Mounts  [][]string `yaml:"mounts"`
+	}{filesystems, entries}); err != nil {
+		return nil, errors.Wrap(err, "unable to render mounts")
+	}
+	mergeMappings(base, extra)
+	return renderCloudConfig(header, base)
+}
+
+// ntpConfig is the configuration of the ntp module of cloud-init.
+type ntpConfig struct {
+	Enabled bool     `yaml:"enabled"`
+	Servers []string `yaml:"servers"`
+}
+
+// SetNTPServers enables the ntp module of cloud-init with the given servers,
+// replacing any ntp configuration of the bootstrap provider.
+func SetNTPServers(userData []byte, servers []string) ([]byte, error) {
+	if len(servers) == 0 {
+		return userData, nil
+	}
+
+	extra := &yaml.Node{}
+	if err := extra.Encode(map[string]ntpConfig{"ntp": {Enabled: true, Servers: servers}}); err != nil {
+		return nil, errors.Wrap(err, "unable to render ntp config")
+	}
+	return mergeCloudConfig(userData, extra)
+}
+
+// AddSSHAuthorizedKeys adds the keys to the ssh_authorized_keys of the default user,
+// after the keys already present. Keys which are already authorized are skipped.
+func AddSSHAuthorizedKeys(userData []byte, keys []string) ([]byte, error) {
+	if len(keys) == 0 {
+		return userData, nil
+	}
+
+	header, body := splitHeader(userData)
+	base, err := parseCloudConfig(body)
+	if err != nil {
+		return nil, errors.Wrap(err, "bootstrap user data")
+	}
+
+	var authorized *yaml.Node
+	for i := 0; i+1 < len(base.Content); i += 2 {
+		if base.Content[i].Value == "ssh_authorized_keys" && base.Content[i+1].Kind == yaml.SequenceNode {
+			authorized = base.Content[i+1]
+			break
+		}
+	}
+	if authorized == nil {
+		authorized = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		mergeMappings(base, &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: "ssh_authorized_keys"}, authorized,
+		}})
+	}
+
+	for _, key := range keys {
+		if slices.ContainsFunc(authorized.Content, func(n *yaml.Node) bool { return sameSSHKey(n.Value, key) }) {
+			continue
+		}
+		authorized.Content = append(authorized.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: strings.TrimSpace(key)})
+	}
+	return renderCloudConfig(header, base)
+}
+
+// DisableDefaultUser removes the default user from the users of the user data, so cloud-init does not create it.
+// The ssh_authorized_keys of the user data and the given keys are authorized for the existing user instead,
+// as cloud-init only adds ssh_authorized_keys to the default user. The password of the user is left untouched.
+func DisableDefaultUser(userData []byte, user string, keys []string) ([]byte, error) {
+	if user == "" {
+		return nil, ErrMissingLoginUser
+	}
+
+	header, body := splitHeader(userData)
+	base, err := parseCloudConfig(body)
+	if err != nil {
+		return nil, errors.Wrap(err, "bootstrap user data")
+	}
+
+	var users *yaml.Node
+	var authorized []string
+	for i := 0; i+1 < len(base.Content); {
+		key, value := base.Content[i], base.Content[i+1]
+		switch {
+		case key.Value == "users" && value.Kind == yaml.SequenceNode:
+			users = value
+		case key.Value == "ssh_authorized_keys" && value.Kind == yaml.SequenceNode:
+			for _, n := range value.Content {
+				authorized = append(authorized, n.Value)
+			}
+			base.Content = slices.Delete(base.Content, i, i+2)
+			continue
+		}
+		i += 2
+	}
+	if users == nil {
+		users = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		mergeMappings(base, &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: "users"}, users,
+		}})
+	}
+	users.Content = slices.DeleteFunc(users.Content, func(n *yaml.Node) bool {
+		return n.Kind == yaml.ScalarNode && n.Value == "default"
+	})
+
+	for _, key := range keys {
+		if slices.ContainsFunc(authorized, func(k string) bool { return sameSSHKey(k, key) }) {
+			continue
+		}
+		authorized = append(authorized, strings.TrimSpace(key))
+	}
+	if len(authorized) > 0 {
+		login := &yaml.Node{}
+		if err := login.Encode(loginUser{Name: user, LockPasswd: false, SSHAuthorizedKeys: authorized}); err != nil {
+			return nil, errors.Wrap(err, "unable to render login user")
+		}
+		users.Content = append(users.Content, login)
+	}
+	return renderCloudConfig(header, base)
+}
+
+// loginUser is an entry of the users of cloud-init. cloud-init skips the creation of existing users,
+// but still authorizes their keys and locks their password unless told otherwise.
+type loginUser struct {
+	Name              string   `yaml:"name"`
+	LockPasswd        bool     `yaml:"lock_passwd"`
+	SSHAuthorizedKeys []string `yaml:"ssh_authorized_keys"`
+}
+
+// sameSSHKey reports whether two authorized keys have the same type and key, regardless of their comments.
+func sameSSHKey(a, b string) bool {
+	fa, fb := strings.Fields(a), strings.Fields(b)
+	if len(fa) < 2 || len(fb) < 2 {
+		return strings.TrimSpace(a) == strings.TrimSpace(b)
+	}
+	return fa[0] == fb[0] && fa[1] == fb[1]
+}
+
+// mergeCloudConfig merges the mapping extra into the user data.
+func mergeCloudConfig(userData []byte, extra *yaml.Node) ([]byte, error) {
+	header, body := splitHeader(userData)
+	base, err := parseCloudConfig(body)
+	if err != nil {
+		return nil, errors.Wrap(err, "bootstrap user data")
+	}
+
+	mergeMappings(base, extra)
+	return renderCloudConfig(header, base)
+}
+
+// renderCloudConfig renders the mapping of cloud-config modules below the header.
+func renderCloudConfig(header []byte, base *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write(header)
+	if !bytes.Contains(header, []byte(cloudConfigHeader)) {
+		buf.WriteString(cloudConfigHeader + "\n")
+	}
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(base); err != nil {
+		return nil, errors.Wrap(err, "unable to render merged user data")
+	}
+	if err := enc.Close(); err != nil {
+		return nil, errors.Wrap(err, "unable to render merged user data")
+	}
+	return buf.Bytes(), nil
+}
+
+// splitHeader splits the leading comment lines from the rest of the user data.
+func splitHeader(userData []byte) (header, body []byte) {
+	rest := userData
+	for len(rest) > 0 {
+		line, next, _ := bytes.Cut(rest, []byte("\n"))
+		if !bytes.HasPrefix(bytes.TrimSpace(line), []byte("#")) {
+			break
+		}
+		rest = next
+	}
+	return userData[:len(userData)-len(rest)], rest
+}
+
+// parseCloudConfig parses cloud-config without its header into a mapping node.
+// Empty cloud-config results in an empty mapping.
+func parseCloudConfig(body []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(body, &doc); err != nil {
+		return nil, errors.Wrap(ErrMalformedUserData, err.Error())
+	}
+	if len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}, nil
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.Wrap(ErrMalformedUserData, "expected a mapping of cloud-config modules")
+	}
+	return doc.Content[0], nil
+}
+
+// mergeMappings merges the keys of extra into base, keeping the order of both.
+func mergeMappings(base, extra *yaml.Node) {
+	for i := 0; i+1 < len(extra.Content); i += 2 {
+		key, value := extra.Content[i], extra.Content[i+1]
+
+		j := 0
+		for ; j+1 < len(base.Content); j += 2 {
+			if base.Content[j].Value == key.Value {
+				break
+			}
+		}
+
+		switch {
+		case j+1 >= len(base.Content):
+			base.Content = append(base.Content, key, value)
+		case base.Content[j+1].Kind == yaml.SequenceNode && value.Kind == yaml.SequenceNode:
+			base.Content[j+1].Content = append(base.Content[j+1].Content, value.Content...)
+		default:
+			base.Content[j+1] = value
+		}
+	}
+}
@@ -0,0 +1,292 @@
+/*
+Copyright 2023-2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const kubeadmUserData = `## template: jinja
+#cloud-config
+
+write_files:
+  - path: /run/kubeadm/kubeadm.yaml
+    owner: root:root
+    permissions: '0640'
+    content: |
+      ---
+      nodeRegistration:
+        name: '{{ ds.meta_data.local_hostname }}'
+runcmd:
+  - kubeadm init --config /run/kubeadm/kubeadm.yaml
+users:
+  - name: capmox
+`
+
+func TestMergeUserData(t *testing.T) {
+	additional := `#cloud-config
+write_files:
+  - path: /etc/containerd/certs.d/docker.io/hosts.toml
+    content: |
+      server = "https://registry.example.com"
+runcmd:
+  - systemctl restart containerd
+users:
+  - default
+timezone: Europe/Berlin
+`
+
+	expected := `## template: jinja
+#cloud-config
+write_files:
+  - path: /run/kubeadm/kubeadm.yaml
+    owner: root:root
+    permissions: '0640'
+    content: |
+      ---
+      nodeRegistration:
+        name: '{{ ds.meta_data.local_hostname }}'
+  - path: /etc/containerd/certs.d/docker.io/hosts.toml
+    content: |
+      server = "https://registry.example.com"
+runcmd:
+  - kubeadm init --config /run/kubeadm/kubeadm.yaml
+  - systemctl restart containerd
+users:
+  - name: capmox
+  - default
+timezone: Europe/Berlin
+`
+
+	merged, err := MergeUserData([]byte(kubeadmUserData), additional)
+	require.NoError(t, err)
+	require.Equal(t, expected, string(merged))
+}
+
+func TestMergeUserData_ReplacesNonListValues(t *testing.T) {
+	merged, err := MergeUserData([]byte("#cloud-config\nntp:\n  enabled: false\n"), "ntp:\n  enabled: true\n")
+	require.NoError(t, err)
+	require.Equal(t, "#cloud-config\nntp:\n  enabled: true\n", string(merged))
+}
+
+func TestMergeUserData_AddsHeader(t *testing.T) {
+	merged, err := MergeUserData([]byte("runcmd:\n  - echo bootstrap\n"), "runcmd:\n  - echo additional\n")
+	require.NoError(t, err)
+	require.Equal(t, "#cloud-config\nruncmd:\n  - echo bootstrap\n  - echo additional\n", string(merged))
+}
+
+func TestMergeUserData_Empty(t *testing.T) {
+	merged, err := MergeUserData([]byte(kubeadmUserData), "  \n")
+	require.NoError(t, err)
+	require.Equal(t, kubeadmUserData, string(merged))
+}
+
+func TestValidateUserData(t *testing.T) {
+	require.NoError(t, ValidateUserData("#cloud-config\nruncmd:\n  - echo hello\n"))
+	require.NoError(t, ValidateUserData("#cloud-config\n"))
+	require.ErrorIs(t, ValidateUserData("- echo hello\n"), ErrMalformedUserData)
+	require.ErrorIs(t, ValidateUserData("runcmd: [echo"), ErrMalformedUserData)
+}
+
+func TestAddFiles(t *testing.T) {
+	files := []File{
+		{Path: "/etc/sysctl.d/90-kubelet.conf", Content: "vm.overcommit_memory=1\nkernel.panic=10\n", Permissions: "0644"},
+		{Path: "/etc/containerd/conf.d/mirror.toml", Content: "mirror", Owner: "root:root"},
+	}
+
+	expected := `#cloud-config
+write_files:
+  - path: /run/kubeadm/kubeadm.yaml
+    content: kubeadm
+  - path: /etc/sysctl.d/90-kubelet.conf
+    content: |
+      vm.overcommit_memory=1
+      kernel.panic=10
+    permissions: "0644"
+  - path: /etc/containerd/conf.d/mirror.toml
+    content: mirror
+    owner: root:root
+runcmd:
+  - kubeadm join
+`
+
+	merged, err := AddFiles([]byte("#cloud-config\nwrite_files:\n  - path: /run/kubeadm/kubeadm.yaml\n    content: kubeadm\nruncmd:\n  - kubeadm join\n"), files)
+	require.NoError(t, err)
+	require.Equal(t, expected, string(merged))
+}
+
+func TestAddMounts(t *testing.T) {
+	userData := "#cloud-config\ndisk_setup:\n  /dev/sdc:\n    table_type: mbr\n    layout: true\nruncmd:\n  - kubeadm join\n"
+	mounts := []Mount{
+		{Device: "/dev/disk/by-id/scsi-0QEMU_QEMU_HARDDISK_drive-scsi1", FSType: "xfs", MountPoint: "/var/lib/data"},
+		{Device: "/dev/sdd", FSType: "swap"},
+	}
+
+	expected := `#cloud-config
+disk_setup:
+  /dev/sdc:
+    table_type: mbr
+    layout: true
+  /dev/disk/by-id/scsi-0QEMU_QEMU_HARDDISK_drive-scsi1:
+    table_type: gpt
+    layout: true
+    overwrite: false
+  /dev/sdd:
+    table_type: gpt
+    layout: true
+    overwrite: false
+runcmd:
+  - kubeadm join
+fs_setup:
+  - label: capmox0
+    filesystem: xfs
+    device: /dev/disk/by-id/scsi-0QEMU_QEMU_HARDDISK_drive-scsi1
+    partition: auto
+    overwrite: false
+  - label: capmox1
+    filesystem: swap
+    device: /dev/sdd
+    partition: auto
+    overwrite: false
+mounts:
+  - - LABEL=capmox0
+    - /var/lib/data
+    - xfs
+    - defaults,nofail
+    - "0"
+    - "2"
+  - - LABEL=capmox1
+    - none
+    - swap
+    - sw
+    - "0"
+    - "0"
+`
+
+	out, err := AddMounts([]byte(userData), mounts)
+	require.NoError(t, err)
+	require.Equal(t, expected, string(out))
+}
+
+func TestAddMounts_Empty(t *testing.T) {
+	out, err := AddMounts([]byte(kubeadmUserData), nil)
+	require.NoError(t, err)
+	require.Equal(t, kubeadmUserData, string(out))
+}
+
+func TestSetNTPServers(t *testing.T) {
+	expected := `#cloud-config
+runcmd:
+  - kubeadm join
+ntp:
+  enabled: true
+  servers:
+    - 0.pool.ntp.org
+    - 10.0.0.1
+`
+
+	merged, err := SetNTPServers([]byte("#cloud-config\nruncmd:\n  - kubeadm join\n"), []string{"0.pool.ntp.org", "10.0.0.1"})
+	require.NoError(t, err)
+	require.Equal(t, expected, string(merged))
+}
+
+func TestSetNTPServers_Empty(t *testing.T) {
+	merged, err := SetNTPServers([]byte(kubeadmUserData), nil)
+	require.NoError(t, err)
+	require.Equal(t, kubeadmUserData, string(merged))
+}
+
+func TestAddSSHAuthorizedKeys(t *testing.T) {
+	userData := `#cloud-config
+users:
+  - name: capmox
+ssh_authorized_keys:
+  - ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBootstrap bootstrap
+`
+
+	expected := `#cloud-config
+users:
+  - name: capmox
+ssh_authorized_keys:
+  - ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBootstrap bootstrap
+  - ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBreakGlass break-glass
+`
+
+	merged, err := AddSSHAuthorizedKeys([]byte(userData), []string{
+		"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBreakGlass break-glass",
+		"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBootstrap other-comment",
+		"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBreakGlass break-glass\n",
+	})
+	require.NoError(t, err)
+	require.Equal(t, expected, string(merged))
+}
+
+func TestAddSSHAuthorizedKeys_NoKeysPresent(t *testing.T) {
+	merged, err := AddSSHAuthorizedKeys([]byte("#cloud-config\nruncmd:\n  - kubeadm join\n"), []string{"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBreakGlass"})
+	require.NoError(t, err)
+	require.Equal(t, "#cloud-config\nruncmd:\n  - kubeadm join\nssh_authorized_keys:\n  - ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBreakGlass\n", string(merged))
+}
+
+func TestAddSSHAuthorizedKeys_Empty(t *testing.T) {
+	merged, err := AddSSHAuthorizedKeys([]byte(kubeadmUserData), nil)
+	require.NoError(t, err)
+	require.Equal(t, kubeadmUserData, string(merged))
+}
+
+func TestDisableDefaultUser(t *testing.T) {
+	userData := `#cloud-config
+users:
+  - default
+  - name: capmox
+ssh_authorized_keys:
+  - ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBootstrap bootstrap
+runcmd:
+  - kubeadm join
+`
+
+	expected := `#cloud-config
+users:
+  - name: capmox
+  - name: admin
+    lock_passwd: false
+    ssh_authorized_keys:
+      - ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBootstrap bootstrap
+      - ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBreakGlass break-glass
+runcmd:
+  - kubeadm join
+`
+
+	merged, err := DisableDefaultUser([]byte(userData), "admin", []string{
+		"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBreakGlass break-glass",
+		"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBootstrap other-comment",
+	})
+	require.NoError(t, err)
+	require.Equal(t, expected, string(merged))
+}
+
+func TestDisableDefaultUser_NoKeys(t *testing.T) {
+	merged, err := DisableDefaultUser([]byte("#cloud-config\nruncmd:\n  - kubeadm join\n"), "admin", nil)
+	require.NoError(t, err)
+	require.Equal(t, "#cloud-config\nruncmd:\n  - kubeadm join\nusers: []\n", string(merged))
+}
+
+func TestDisableDefaultUser_MissingUser(t *testing.T) {
+	_, err := DisableDefaultUser([]byte(kubeadmUserData), "", nil)
+	require.ErrorIs(t, err, ErrMissingLoginUser)
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// VendorData provides functionality to render machine vendor-data.
+// cloud-init applies vendor-data before user data, so any key of the user data overrides it.
+type VendorData struct {
+	data string
+}
+
+// NewVendorData returns a new VendorData object.
+func NewVendorData(data string) *VendorData {
+	return &VendorData{data: data}
+}
+
+// Render returns rendered vendor-data, which is empty without any data.
+func (r *VendorData) Render() ([]byte, error) {
+	if strings.TrimSpace(r.data) == "" {
+		return nil, nil
+	}
+
+	header, body := splitHeader([]byte(r.data))
+	if _, err := parseCloudConfig(body); err != nil {
+		return nil, errors.Wrap(err, "vendor data")
+	}
+
+	if bytes.Contains(header, []byte(cloudConfigHeader)) {
+		return []byte(r.data), nil
+	}
+	return append([]byte(cloudConfigHeader+"\n"), r.data...), nil
+}
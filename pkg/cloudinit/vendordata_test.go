@@ -0,0 +1,69 @@
+/*
+Copyright 2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVendorData_Render(t *testing.T) {
+	type args struct {
+		data string
+	}
+
+	type want struct {
+		vendorData string
+		err        error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Empty": {
+			reason: "no vendor-data is rendered without data",
+			args:   args{data: " \n"},
+			want:   want{vendorData: ""},
+		},
+		"CloudConfig": {
+			reason: "cloud-config is kept as is",
+			args:   args{data: "#cloud-config\npackage_update: true\n"},
+			want:   want{vendorData: "#cloud-config\npackage_update: true\n"},
+		},
+		"MissingHeader": {
+			reason: "the cloud-config header is added",
+			args:   args{data: "timezone: Europe/Berlin\n"},
+			want:   want{vendorData: "#cloud-config\ntimezone: Europe/Berlin\n"},
+		},
+		"Malformed": {
+			reason: "vendor-data has to be a mapping of cloud-config modules",
+			args:   args{data: "#cloud-config\n- echo hello\n"},
+			want:   want{err: ErrMalformedUserData},
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			vendorData, err := NewVendorData(tc.args.data).Render()
+			require.ErrorIs(t, err, tc.want.err, tc.reason)
+			require.Equal(t, tc.want.vendorData, string(vendorData), tc.reason)
+		})
+	}
+}
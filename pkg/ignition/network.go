@@ -10,9 +10,21 @@ import (
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/types"
 )
 
+var (
+	// ErrUnsupportedBond is returned for bonds, which are not rendered into networkd units.
+	ErrUnsupportedBond = errors.New("bonds are not supported by ignition")
+	// ErrUnsupportedBridge is returned for bridges, which are not rendered into networkd units.
+	ErrUnsupportedBridge = errors.New("bridges are not supported by ignition")
+	// ErrUnsupportedVLAN is returned for vlans, which are not rendered into networkd units.
+	ErrUnsupportedVLAN = errors.New("vlans are not supported by ignition")
+)
+
 const (
 	networkTypeEthernet = "ethernet"
 	networkTypeVRF      = "vrf"
+	networkTypeBond     = "bond"
+	networkTypeVLAN     = "vlan"
+	networkTypeBridge   = "bridge"
 
 	// networkConfigTPlNetworkd is a Go template to generate systemd-networkd unit files
 	// based on the data schema provided for network-config v2.
@@ -38,6 +50,9 @@ DHCP=ipv4
 {{- else if $element.DHCP6 }}
 DHCP=ipv6
 {{- end }}
+{{- if $element.AcceptRA }}
+IPv6AcceptRA=yes
+{{- end }}
 
 {{- template "dns" . }}
 
@@ -87,6 +102,9 @@ Name={{ $element.Name }}
 DNS={{ $dnsServer }}
 {{- end }}
 {{- end }}
+{{- if .SearchDomains }}
+Domains={{ range $index, $domain := .SearchDomains }}{{ if $index }} {{ end }}{{ $domain }}{{ end }}
+{{- end }}
 {{- end }}
 
 {{- define "rules" }}
@@ -135,6 +153,17 @@ Table={{ $element.Table }}
 func RenderNetworkConfigData(data []types.NetworkConfigData) (map[string][]byte, error) {
 	configs := make(map[string][]byte)
 
+	for _, networkConfig := range data {
+		switch networkConfig.Type {
+		case networkTypeBond:
+			return nil, ErrUnsupportedBond
+		case networkTypeVLAN:
+			return nil, ErrUnsupportedVLAN
+		case networkTypeBridge:
+			return nil, ErrUnsupportedBridge
+		}
+	}
+
 	// adjust VRFs
 	adjustVrfs(data)
 
@@ -50,6 +50,40 @@ Table=500
 `),
 	}
 
+	expectedValidNetworkConfigWithSearchDomains = map[string][]byte{
+		"00-eth0.network": []byte(`[Match]
+MACAddress=E2:B8:FE:E7:50:75
+
+[Network]
+DNS=10.0.1.1
+Domains=example.com cluster.local
+[Address]
+Address=10.0.0.98/25
+
+[Route]
+Destination=0.0.0.0/0
+Gateway=10.0.0.1
+Metric=100
+`),
+	}
+
+	expectedValidNetworkConfigSLAAC = map[string][]byte{
+		"00-eth0.network": []byte(`[Match]
+MACAddress=E2:B8:FE:E7:50:75
+
+[Network]
+IPv6AcceptRA=yes
+DNS=10.0.1.1
+[Address]
+Address=10.0.0.98/25
+
+[Route]
+Destination=0.0.0.0/0
+Gateway=10.0.0.1
+Metric=100
+`),
+	}
+
 	expectedValidNetworkConfigWithVRFPolicies = map[string][]byte{
 		"00-vrf0.netdev": []byte(`[NetDev]
 Name=vrf0
@@ -162,6 +196,50 @@ func TestRenderNetworkConfigData(t *testing.T) {
 				err:   nil,
 			},
 		},
+		"ValidNetworkdConfigWithSearchDomains": {
+			reason: "render valid networkd with dns search domains",
+			args: args{
+				nics: []types.NetworkConfigData{
+					{
+						Type:          "ethernet",
+						Name:          "eth0",
+						MacAddress:    "E2:B8:FE:E7:50:75",
+						IPAddress:     "10.0.0.98/25",
+						Gateway:       "10.0.0.1",
+						ProxName:      "net0",
+						DNSServers:    []string{"10.0.1.1"},
+						SearchDomains: []string{"example.com", "cluster.local"},
+						Metric:        ptr.To(uint32(100)),
+					},
+				},
+			},
+			want: want{
+				units: expectedValidNetworkConfigWithSearchDomains,
+				err:   nil,
+			},
+		},
+		"ValidNetworkdConfigSLAAC": {
+			reason: "render valid networkd with static ipv4 and slaac",
+			args: args{
+				nics: []types.NetworkConfigData{
+					{
+						Type:       "ethernet",
+						Name:       "eth0",
+						MacAddress: "E2:B8:FE:E7:50:75",
+						AcceptRA:   true,
+						IPAddress:  "10.0.0.98/25",
+						Gateway:    "10.0.0.1",
+						ProxName:   "net0",
+						DNSServers: []string{"10.0.1.1"},
+						Metric:     ptr.To(uint32(100)),
+					},
+				},
+			},
+			want: want{
+				units: expectedValidNetworkConfigSLAAC,
+				err:   nil,
+			},
+		},
 		"ValidNetworkdConfigWithVRFPolicies": {
 			reason: "render valid networkd with static ip and VRF and policies",
 			args: args{
@@ -212,6 +290,42 @@ func TestRenderNetworkConfigData(t *testing.T) {
 				err:   nil,
 			},
 		},
+		"UnsupportedBond": {
+			reason: "bonds are not rendered into networkd units",
+			args: args{
+				nics: []types.NetworkConfigData{
+					{Type: "ethernet", Name: "eth0", MacAddress: "E2:B8:FE:E7:50:75", ProxName: "net0", Bond: "bond0"},
+					{Type: "bond", Name: "bond0", Interfaces: []string{"eth0"}, BondMode: "active-backup", IPAddress: "10.0.0.98/25", Gateway: "10.0.0.1"},
+				},
+			},
+			want: want{
+				err: ErrUnsupportedBond,
+			},
+		},
+		"UnsupportedVLAN": {
+			reason: "vlans are not rendered into networkd units",
+			args: args{
+				nics: []types.NetworkConfigData{
+					{Type: "ethernet", Name: "eth0", MacAddress: "E2:B8:FE:E7:50:75", ProxName: "net0", DHCP4: true},
+					{Type: "vlan", Name: "eth0.100", Link: "eth0", VLANID: 100, IPAddress: "10.0.0.98/25"},
+				},
+			},
+			want: want{
+				err: ErrUnsupportedVLAN,
+			},
+		},
+		"UnsupportedBridge": {
+			reason: "bridges are not rendered into networkd units",
+			args: args{
+				nics: []types.NetworkConfigData{
+					{Type: "ethernet", Name: "eth0", MacAddress: "E2:B8:FE:E7:50:75", ProxName: "net0", Bridge: "br0"},
+					{Type: "bridge", Name: "br0", Interfaces: []string{"eth0"}, IPAddress: "10.0.0.98/25", Gateway: "10.0.0.1"},
+				},
+			},
+			want: want{
+				err: ErrUnsupportedBridge,
+			},
+		},
 	}
 
 	for n, tc := range cases {
@@ -24,6 +24,7 @@ import (
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -218,26 +219,30 @@ func (h *Helper) GetIPPoolAnnotations(ctx context.Context, ipAddress *ipamv1.IPA
 	return annotations, err
 }
 
-// CreateIPAddressClaim creates an IPAddressClaim for a given object.
-func (h *Helper) CreateIPAddressClaim(ctx context.Context, owner client.Object, device, format, clusterNameLabel string, ref *corev1.TypedLocalObjectReference) error {
-	var gvk schema.GroupVersionKind
-	key := client.ObjectKey{
-		Namespace: owner.GetNamespace(),
-		Name:      owner.GetName(),
-	}
+// IPAddressClaimName returns the name of the IPAddressClaim, and of the IPAddress fulfilling it,
+// for the address of the given format of a network device of the owner.
+func IPAddressClaimName(owner client.Object, device, format string) string {
 	suffix := infrav1.DefaultSuffix
 	if format == infrav1.IPV6Format {
 		suffix += "6"
 	}
+	return fmt.Sprintf("%s-%s-%s", owner.GetName(), device, suffix)
+}
+
+// CreateIPAddressClaim creates an IPAddressClaim for a given object.
+// It returns early if the claim exists already, without looking up the pool again,
+// so repeated reconciles of a machine waiting for its address stay cheap.
+func (h *Helper) CreateIPAddressClaim(ctx context.Context, owner client.Object, device, format, clusterNameLabel string, ref *corev1.TypedLocalObjectReference) error {
+	name := IPAddressClaimName(owner, device, format)
+	exists, err := h.ipAddressClaimExists(ctx, owner.GetNamespace(), name)
+	if err != nil || exists {
+		return err
+	}
 
+	var poolRef corev1.TypedLocalObjectReference
 	switch {
 	case device == infrav1.DefaultNetworkDevice:
-		pool, err := h.GetDefaultInClusterIPPool(ctx, format)
-		if err != nil {
-			return errors.Wrapf(err, "unable to find inclusterpool for cluster %s", h.cluster.Name)
-		}
-		key.Name = pool.GetName()
-		gvk, err = gvkForObject(pool, h.ctrlClient.Scheme())
+		poolRef, err = h.defaultPoolRef(ctx, format)
 		if err != nil {
 			return err
 		}
@@ -246,8 +251,7 @@ func (h *Helper) CreateIPAddressClaim(ctx context.Context, owner client.Object,
 		if err != nil {
 			return errors.Wrapf(err, "unable to find inclusterpool for cluster %s", h.cluster.Name)
 		}
-		key.Name = pool.GetName()
-		gvk, err = gvkForObject(pool, h.ctrlClient.Scheme())
+		poolRef, err = poolRefForObject(pool, h.ctrlClient.Scheme())
 		if err != nil {
 			return err
 		}
@@ -256,8 +260,7 @@ func (h *Helper) CreateIPAddressClaim(ctx context.Context, owner client.Object,
 		if err != nil {
 			return errors.Wrapf(err, "unable to find global inclusterpool for cluster %s", h.cluster.Name)
 		}
-		key.Name = pool.GetName()
-		gvk, err = gvkForObject(pool, h.ctrlClient.Scheme())
+		poolRef, err = poolRefForObject(pool, h.ctrlClient.Scheme())
 		if err != nil {
 			return err
 		}
@@ -265,6 +268,142 @@ func (h *Helper) CreateIPAddressClaim(ctx context.Context, owner client.Object,
 		return errors.Errorf("unsupported pool type %s", ref.Kind)
 	}
 
+	return h.createIPAddressClaim(ctx, owner, name, clusterNameLabel, poolRef)
+}
+
+// ipAddressClaimExists reports whether the IPAddressClaim exists.
+func (h *Helper) ipAddressClaimExists(ctx context.Context, namespace, name string) (bool, error) {
+	err := h.ctrlClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &ipamv1.IPAddressClaim{})
+	switch {
+	case apierrors.IsNotFound(err):
+		return false, nil
+	case err != nil:
+		return false, errors.Wrapf(err, "unable to get ipaddressclaim %s", name)
+	}
+	return true, nil
+}
+
+// ReleaseIPAddressClaim deletes the IPAddressClaim for the address of the given format of a network device of the owner.
+// It reports whether the address is released, i.e. neither the claim nor the IPAddress fulfilling it exist anymore.
+func (h *Helper) ReleaseIPAddressClaim(ctx context.Context, owner client.Object, device, format string) (bool, error) {
+	key := client.ObjectKey{Namespace: owner.GetNamespace(), Name: IPAddressClaimName(owner, device, format)}
+
+	claim := &ipamv1.IPAddressClaim{}
+	err := h.ctrlClient.Get(ctx, key, claim)
+	switch {
+	case apierrors.IsNotFound(err):
+	case err != nil:
+		return false, errors.Wrapf(err, "unable to get ipaddressclaim %s", key.Name)
+	default:
+		if claim.DeletionTimestamp.IsZero() {
+			if err := h.ctrlClient.Delete(ctx, claim); client.IgnoreNotFound(err) != nil {
+				return false, errors.Wrapf(err, "unable to delete ipaddressclaim %s", key.Name)
+			}
+		}
+		return false, nil
+	}
+
+	// the IPAM provider deletes the IPAddress, before the claim is gone.
+	// Wait for the address anyway, in case the claim was removed without its finalizer.
+	if err := h.ctrlClient.Get(ctx, key, &ipamv1.IPAddress{}); err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, errors.Wrapf(err, "unable to get ipaddress %s", key.Name)
+	}
+	return false, nil
+}
+
+// DeleteOrphanedIPAddressClaims deletes the IPAddressClaims of the cluster, which are owned by a ProxmoxMachine
+// that doesn't exist anymore, e.g. because its finalizer was removed by hand.
+func (h *Helper) DeleteOrphanedIPAddressClaims(ctx context.Context, clusterNameLabel string) error {
+	claims := &ipamv1.IPAddressClaimList{}
+	if err := h.ctrlClient.List(ctx, claims,
+		client.InNamespace(h.cluster.GetNamespace()),
+		client.MatchingLabels{clusterv1.ClusterNameLabel: clusterNameLabel}); err != nil {
+		return errors.Wrap(err, "unable to list ipaddressclaims")
+	}
+
+	for i := range claims.Items {
+		claim := &claims.Items[i]
+		owner := metav1.GetControllerOf(claim)
+		if owner == nil || owner.Kind != "ProxmoxMachine" || owner.APIVersion != infrav1.GroupVersion.String() || !claim.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		machine := &infrav1.ProxmoxMachine{}
+		err := h.ctrlClient.Get(ctx, client.ObjectKey{Namespace: claim.GetNamespace(), Name: owner.Name}, machine)
+		if client.IgnoreNotFound(err) != nil {
+			return errors.Wrapf(err, "unable to get owner of ipaddressclaim %s", claim.GetName())
+		}
+		if err == nil && machine.GetUID() == owner.UID {
+			continue
+		}
+
+		if err := h.ctrlClient.Delete(ctx, claim); client.IgnoreNotFound(err) != nil {
+			return errors.Wrapf(err, "unable to delete orphaned ipaddressclaim %s", claim.GetName())
+		}
+	}
+	return nil
+}
+
+// defaultPoolRef returns the pool providing the addresses of the given format for the default network devices,
+// which is either an external pool referenced by the cluster or the InClusterIPPool managed by the cluster.
+func (h *Helper) defaultPoolRef(ctx context.Context, format string) (corev1.TypedLocalObjectReference, error) {
+	ref := h.cluster.Spec.IPv4PoolRef
+	if format == infrav1.IPV6Format {
+		ref = h.cluster.Spec.IPv6PoolRef
+	}
+	if ref != nil {
+		return *ref, nil
+	}
+
+	pool, err := h.GetDefaultInClusterIPPool(ctx, format)
+	if err != nil {
+		return corev1.TypedLocalObjectReference{}, errors.Wrapf(err, "unable to find inclusterpool for cluster %s", h.cluster.Name)
+	}
+	return poolRefForObject(pool, h.ctrlClient.Scheme())
+}
+
+// ControlPlaneEndpointClaimName returns the name of the IPAddressClaim, and of the IPAddress fulfilling it,
+// for the control plane endpoint address of the given format.
+func ControlPlaneEndpointClaimName(cluster *infrav1.ProxmoxCluster, format string) string {
+	suffix := infrav1.DefaultSuffix
+	if format == infrav1.IPV6Format {
+		suffix += "6"
+	}
+	return fmt.Sprintf("%s-endpoint-%s", cluster.GetName(), suffix)
+}
+
+// CreateControlPlaneEndpointClaim claims an address of the given format for the control plane endpoint
+// from the pool of the default network devices.
+func (h *Helper) CreateControlPlaneEndpointClaim(ctx context.Context, format, clusterNameLabel string) error {
+	poolRef, err := h.defaultPoolRef(ctx, format)
+	if err != nil {
+		return err
+	}
+
+	return h.createIPAddressClaim(ctx, h.cluster, ControlPlaneEndpointClaimName(h.cluster, format), clusterNameLabel, poolRef)
+}
+
+// DeleteControlPlaneEndpointClaims deletes the IPAddressClaims of the control plane endpoint,
+// which releases the addresses.
+func (h *Helper) DeleteControlPlaneEndpointClaims(ctx context.Context) error {
+	for _, format := range []string{infrav1.IPV4Format, infrav1.IPV6Format} {
+		claim := &ipamv1.IPAddressClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ControlPlaneEndpointClaimName(h.cluster, format),
+				Namespace: h.cluster.GetNamespace(),
+			},
+		}
+		if err := h.ctrlClient.Delete(ctx, claim); client.IgnoreNotFound(err) != nil {
+			return errors.Wrapf(err, "unable to delete ipaddressclaim %s", claim.GetName())
+		}
+	}
+	return nil
+}
+
+func (h *Helper) createIPAddressClaim(ctx context.Context, owner client.Object, name, clusterNameLabel string, poolRef corev1.TypedLocalObjectReference) error {
 	// Ensures that the claim has a reference to the cluster of the VM to
 	// support pausing reconciliation.
 	labels := map[string]string{
@@ -273,24 +412,25 @@ func (h *Helper) CreateIPAddressClaim(ctx context.Context, owner client.Object,
 
 	desired := &ipamv1.IPAddressClaim{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("%s-%s-%s", owner.GetName(), device, suffix),
+			Name:      name,
 			Namespace: owner.GetNamespace(),
 			Labels:    labels,
 		},
 		Spec: ipamv1.IPAddressClaimSpec{
-			PoolRef: corev1.TypedLocalObjectReference{
-				APIGroup: ptr.To(gvk.Group),
-				Kind:     gvk.Kind,
-				Name:     key.Name,
-			},
+			PoolRef: poolRef,
 		},
 	}
-	_, err := controllerutil.CreateOrUpdate(ctx, h.ctrlClient, desired, func() error {
-		// set the owner reference to the cluster
-		return controllerutil.SetControllerReference(owner, desired, h.ctrlClient.Scheme())
-	})
+	// set the owner reference to the cluster
+	if err := controllerutil.SetControllerReference(owner, desired, h.ctrlClient.Scheme()); err != nil {
+		return err
+	}
 
-	return err
+	// claim names are derived from the owner, so a claim created by an earlier reconcile,
+	// which is not in the cache yet, makes the create fail instead of leaking a second claim.
+	if err := h.ctrlClient.Create(ctx, desired); err != nil && !apierrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "unable to create ipaddressclaim %s", name)
+	}
+	return nil
 }
 
 // GetIPAddress attempts to retrieve the IPAddress.
@@ -304,6 +444,19 @@ func (h *Helper) GetIPAddress(ctx context.Context, key client.ObjectKey) (*ipamv
 	return out, nil
 }
 
+func poolRefForObject(pool client.Object, scheme *runtime.Scheme) (corev1.TypedLocalObjectReference, error) {
+	gvk, err := gvkForObject(pool, scheme)
+	if err != nil {
+		return corev1.TypedLocalObjectReference{}, err
+	}
+
+	return corev1.TypedLocalObjectReference{
+		APIGroup: ptr.To(gvk.Group),
+		Kind:     gvk.Kind,
+		Name:     pool.GetName(),
+	}, nil
+}
+
 func gvkForObject(obj runtime.Object, scheme *runtime.Scheme) (schema.GroupVersionKind, error) {
 	gvk, err := apiutil.GVKForObject(obj, scheme)
 	if err != nil {
@@ -35,6 +35,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 
 	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
 )
@@ -420,6 +421,160 @@ func (s *IPAMTestSuite) Test_CreateIPAddressClaim() {
 	s.NoError(err)
 }
 
+func (s *IPAMTestSuite) Test_CreateIPAddressClaim_ExternalPool() {
+	poolRef := corev1.TypedLocalObjectReference{
+		APIGroup: ptr.To("ipam.cluster.x-k8s.io"),
+		Kind:     "InfobloxIPPool",
+		Name:     "infoblox-v6",
+	}
+	s.cluster.Spec.IPv6PoolRef = &poolRef
+
+	// no InClusterIPPool is required for the external pool.
+	device := "net0"
+	err := s.helper.CreateIPAddressClaim(s.ctx, getCluster(), device, infrav1.IPV6Format, "test-cluster", nil)
+	s.NoError(err)
+
+	var claim ipamv1.IPAddressClaim
+	name := fmt.Sprintf("%s-%s-%s6", getCluster().GetName(), device, infrav1.DefaultSuffix)
+	s.NoError(s.cl.Get(s.ctx, types.NamespacedName{Name: name, Namespace: getCluster().GetNamespace()}, &claim))
+	s.Equal(poolRef, claim.Spec.PoolRef)
+}
+
+func (s *IPAMTestSuite) Test_CreateIPAddressClaim_ManyMachines() {
+	s.NoError(s.helper.CreateOrUpdateInClusterIPPool(s.ctx))
+
+	// count the requests to ensure repeated reconciles don't create claims or look up pools again.
+	var creates, poolGets int
+	cl := interceptor.NewClient(s.cl.(client.WithWatch), interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			if _, ok := obj.(*ipamv1.IPAddressClaim); ok {
+				creates++
+			}
+			return c.Create(ctx, obj, opts...)
+		},
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if _, ok := obj.(*ipamicv1.InClusterIPPool); ok {
+				poolGets++
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+	})
+	helper := NewHelper(cl, s.cluster)
+
+	const machines = 100
+	for i := range machines {
+		machine := &infrav1.ProxmoxMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("machine-%d", i), Namespace: "test", UID: types.UID(fmt.Sprintf("uid-%d", i))},
+		}
+		// rapid requeues of the machine while its address is pending.
+		for range 5 {
+			s.NoError(helper.CreateIPAddressClaim(s.ctx, machine, "net0", infrav1.IPV4Format, "test-cluster", nil))
+		}
+	}
+
+	s.Equal(machines, creates)
+	s.Equal(machines, poolGets)
+
+	var claims ipamv1.IPAddressClaimList
+	s.NoError(s.cl.List(s.ctx, &claims, client.InNamespace("test")))
+	s.Len(claims.Items, machines)
+	for _, claim := range claims.Items {
+		owner := metav1.GetControllerOf(&claim)
+		s.NotNil(owner)
+		s.Equal(owner.Name+"-net0-inet", claim.GetName())
+	}
+}
+
+func (s *IPAMTestSuite) Test_CreateIPAddressClaim_AlreadyExists() {
+	s.NoError(s.helper.CreateOrUpdateInClusterIPPool(s.ctx))
+	owner := getCluster()
+
+	// a stale cache doesn't see the claim created by an earlier reconcile.
+	cl := interceptor.NewClient(s.cl.(client.WithWatch), interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if _, ok := obj.(*ipamv1.IPAddressClaim); ok {
+				return apierrors.NewNotFound(ipamv1.GroupVersion.WithResource("ipaddressclaims").GroupResource(), key.Name)
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+	})
+	helper := NewHelper(cl, s.cluster)
+
+	s.NoError(helper.CreateIPAddressClaim(s.ctx, owner, "net0", infrav1.IPV4Format, "test-cluster", nil))
+	s.NoError(helper.CreateIPAddressClaim(s.ctx, owner, "net0", infrav1.IPV4Format, "test-cluster", nil))
+
+	var claims ipamv1.IPAddressClaimList
+	s.NoError(s.cl.List(s.ctx, &claims, client.InNamespace("test")))
+	s.Len(claims.Items, 1)
+}
+
+func (s *IPAMTestSuite) Test_ReleaseIPAddressClaim() {
+	s.NoError(s.helper.CreateOrUpdateInClusterIPPool(s.ctx))
+	owner := getCluster()
+	s.NoError(s.helper.CreateIPAddressClaim(s.ctx, owner, "net0", infrav1.IPV4Format, "test-cluster", nil))
+
+	released, err := s.helper.ReleaseIPAddressClaim(s.ctx, owner, "net0", infrav1.IPV4Format)
+	s.NoError(err)
+	s.False(released)
+
+	var claim ipamv1.IPAddressClaim
+	err = s.cl.Get(s.ctx, types.NamespacedName{Name: IPAddressClaimName(owner, "net0", infrav1.IPV4Format), Namespace: "test"}, &claim)
+	s.True(apierrors.IsNotFound(err))
+
+	// releasing is idempotent.
+	released, err = s.helper.ReleaseIPAddressClaim(s.ctx, owner, "net0", infrav1.IPV4Format)
+	s.NoError(err)
+	s.True(released)
+}
+
+func (s *IPAMTestSuite) Test_DeleteOrphanedIPAddressClaims() {
+	machine := &infrav1.ProxmoxMachine{
+		ObjectMeta: metav1.ObjectMeta{Name: "live", Namespace: "test", UID: "live-uid"},
+	}
+	s.NoError(s.cl.Create(s.ctx, machine))
+
+	newClaim := func(name string, owner metav1.OwnerReference) {
+		s.NoError(s.cl.Create(s.ctx, &ipamv1.IPAddressClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       "test",
+				Labels:          map[string]string{clusterv1.ClusterNameLabel: "test-cluster"},
+				OwnerReferences: []metav1.OwnerReference{owner},
+			},
+		}))
+	}
+	machineRef := func(name string, uid types.UID) metav1.OwnerReference {
+		return metav1.OwnerReference{
+			APIVersion: infrav1.GroupVersion.String(),
+			Kind:       "ProxmoxMachine",
+			Name:       name,
+			UID:        uid,
+			Controller: ptr.To(true),
+		}
+	}
+
+	newClaim("live-net0-inet", machineRef("live", "live-uid"))
+	newClaim("gone-net0-inet", machineRef("gone", "gone-uid"))
+	newClaim("recreated-live-net0-inet", machineRef("live", "old-uid"))
+	newClaim("test-cluster-endpoint-inet", metav1.OwnerReference{
+		APIVersion: infrav1.GroupVersion.String(),
+		Kind:       "ProxmoxCluster",
+		Name:       "test-cluster",
+		UID:        "cluster-uid",
+		Controller: ptr.To(true),
+	})
+
+	s.NoError(s.helper.DeleteOrphanedIPAddressClaims(s.ctx, "test-cluster"))
+
+	var claims ipamv1.IPAddressClaimList
+	s.NoError(s.cl.List(s.ctx, &claims, client.InNamespace("test")))
+	names := make([]string, 0, len(claims.Items))
+	for _, claim := range claims.Items {
+		names = append(names, claim.GetName())
+	}
+	s.ElementsMatch([]string{"live-net0-inet", "test-cluster-endpoint-inet"}, names)
+}
+
 func (s *IPAMTestSuite) Test_GetIPAddress() {
 	s.NoError(s.helper.CreateOrUpdateInClusterIPPool(s.ctx))
 
@@ -0,0 +1,127 @@
+/*
+Copyright 2023-2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipam
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"go4.org/netipx"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+)
+
+// ParseAddressRange parses an entry of the addresses of an IPConfigSpec,
+// which is either a single IP address, a range like `10.0.0.10-10.0.0.20`, or a CIDR.
+func ParseAddressRange(address string) (netipx.IPRange, error) {
+	switch {
+	case strings.Contains(address, "-"):
+		return netipx.ParseIPRange(address)
+	case strings.Contains(address, "/"):
+		ipPref, err := netip.ParsePrefix(address)
+		if err != nil {
+			return netipx.IPRange{}, err
+		}
+		return netipx.RangeOfPrefix(ipPref), nil
+	default:
+		ipAddress, err := netip.ParseAddr(address)
+		if err != nil {
+			return netipx.IPRange{}, err
+		}
+		return netipx.IPRangeFrom(ipAddress, ipAddress), nil
+	}
+}
+
+// ValidateIPConfig makes sure the addresses of an ip config are of the right family and do not overlap,
+// as the in-cluster pool would otherwise hand out addresses twice. Every entry has to lie within a single network
+// of the prefix, which has to contain the gateway as well, otherwise the machines have no default route.
+func ValidateIPConfig(path *field.Path, config *infrav1.IPConfigSpec, ipv4 bool) field.ErrorList {
+	family, bits := "IPv6", 128
+	if ipv4 {
+		family, bits = "IPv4", 32
+	}
+
+	type addressEntry struct {
+		index   int
+		address string
+		ipRange netipx.IPRange
+	}
+
+	var allErrs field.ErrorList
+	validPrefix := config.Prefix >= 0 && config.Prefix <= bits
+	if !validPrefix {
+		allErrs = append(allErrs, field.Invalid(path.Child("prefix"), config.Prefix, fmt.Sprintf("not a valid %s prefix length", family)))
+	}
+
+	var entries []addressEntry
+	for i, address := range config.Addresses {
+		ipRange, err := ParseAddressRange(address)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Child("addresses").Index(i), address, "not a valid IP address, range or CIDR"))
+			continue
+		}
+		// IPv4-mapped IPv6 addresses would overlap with the addresses of the ipv4Config.
+		if from := ipRange.From(); from.Is4() != ipv4 || from.Is4In6() {
+			allErrs = append(allErrs, field.Invalid(path.Child("addresses").Index(i), address, fmt.Sprintf("not an %s address, range or CIDR", family)))
+			continue
+		}
+		if validPrefix {
+			network := netip.PrefixFrom(ipRange.From(), config.Prefix).Masked()
+			if !network.Contains(ipRange.To()) {
+				allErrs = append(allErrs, field.Invalid(path.Child("addresses").Index(i), address,
+					fmt.Sprintf("not within a single network of prefix /%d", config.Prefix)))
+				continue
+			}
+		}
+
+		for _, entry := range entries {
+			if entry.ipRange.Overlaps(ipRange) {
+				allErrs = append(allErrs, field.Invalid(path.Child("addresses").Index(i), address,
+					fmt.Sprintf("overlaps with %s at index %d", entry.address, entry.index)))
+				break
+			}
+		}
+		entries = append(entries, addressEntry{index: i, address: address, ipRange: ipRange})
+	}
+
+	if config.Gateway == "" || !validPrefix {
+		return allErrs
+	}
+	gateway, err := netip.ParseAddr(config.Gateway)
+	if err != nil || gateway.Is4() != ipv4 || gateway.Is4In6() {
+		return append(allErrs, field.Invalid(path.Child("gateway"), config.Gateway, fmt.Sprintf("not a valid %s address", family)))
+	}
+	for _, entry := range entries {
+		network := netip.PrefixFrom(entry.ipRange.From(), config.Prefix).Masked()
+		if !network.Contains(gateway) {
+			return append(allErrs, field.Invalid(path.Child("gateway"), config.Gateway,
+				fmt.Sprintf("not within %s of %s at index %d", network, entry.address, entry.index)))
+		}
+	}
+	// Only point-to-point IPv4 networks (/31) use their first and last address for hosts.
+	if ipv4 && config.Prefix < 31 {
+		network := netip.PrefixFrom(gateway, config.Prefix).Masked()
+		if gateway == network.Addr() || gateway == netipx.PrefixLastIP(network) {
+			allErrs = append(allErrs, field.Invalid(path.Child("gateway"), config.Gateway,
+				fmt.Sprintf("the network or broadcast address of %s", network)))
+		}
+	}
+
+	return allErrs
+}
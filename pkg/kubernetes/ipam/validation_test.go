@@ -0,0 +1,187 @@
+/*
+Copyright 2023-2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipam
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+)
+
+func TestValidateIPConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		ipv4   bool
+		config infrav1.IPConfigSpec
+		errs   []string
+	}{
+		{
+			name:   "ranges",
+			ipv4:   true,
+			config: infrav1.IPConfigSpec{Addresses: []string{"10.0.0.10-10.0.0.20", "10.0.0.21-10.0.0.30"}, Prefix: 24, Gateway: "10.0.0.1"},
+		},
+		{
+			name:   "overlapping ranges",
+			ipv4:   true,
+			config: infrav1.IPConfigSpec{Addresses: []string{"10.0.0.10-10.0.0.20", "10.0.0.20-10.0.0.30"}, Prefix: 24, Gateway: "10.0.0.1"},
+			errs:   []string{"addresses[1]: Invalid value: \"10.0.0.20-10.0.0.30\": overlaps with 10.0.0.10-10.0.0.20 at index 0"},
+		},
+		{
+			name:   "overlapping cidrs",
+			ipv4:   true,
+			config: infrav1.IPConfigSpec{Addresses: []string{"10.0.0.0/25", "10.0.0.64/26"}, Prefix: 24, Gateway: "10.0.0.1"},
+			errs:   []string{"addresses[1]: Invalid value: \"10.0.0.64/26\": overlaps with 10.0.0.0/25 at index 0"},
+		},
+		{
+			name:   "mixed formats",
+			ipv4:   true,
+			config: infrav1.IPConfigSpec{Addresses: []string{"10.0.0.5", "10.0.0.10-10.0.0.20", "10.0.0.32/28"}, Prefix: 24, Gateway: "10.0.0.1"},
+		},
+		{
+			name:   "address within range",
+			ipv4:   true,
+			config: infrav1.IPConfigSpec{Addresses: []string{"10.0.0.10-10.0.0.20", "10.0.0.15"}, Prefix: 24},
+			errs:   []string{"addresses[1]: Invalid value: \"10.0.0.15\": overlaps with 10.0.0.10-10.0.0.20 at index 0"},
+		},
+		{
+			name:   "duplicate address",
+			ipv4:   true,
+			config: infrav1.IPConfigSpec{Addresses: []string{"10.0.0.5", "10.0.0.6", "10.0.0.5"}, Prefix: 24},
+			errs:   []string{"addresses[2]: Invalid value: \"10.0.0.5\": overlaps with 10.0.0.5 at index 0"},
+		},
+		{
+			name:   "invalid range",
+			ipv4:   true,
+			config: infrav1.IPConfigSpec{Addresses: []string{"10.0.0.20-10.0.0.10"}, Prefix: 24},
+			errs:   []string{"addresses[0]: Invalid value: \"10.0.0.20-10.0.0.10\": not a valid IP address, range or CIDR"},
+		},
+		{
+			name:   "ipv6 address in ipv4 config",
+			ipv4:   true,
+			config: infrav1.IPConfigSpec{Addresses: []string{"10.0.0.10-10.0.0.20", "2001:db8::10"}, Prefix: 24},
+			errs:   []string{"addresses[1]: Invalid value: \"2001:db8::10\": not an IPv4 address, range or CIDR"},
+		},
+		{
+			name:   "ipv4-mapped address in ipv6 config",
+			config: infrav1.IPConfigSpec{Addresses: []string{"::ffff:10.0.0.10-::ffff:10.0.0.20"}, Prefix: 120},
+			errs:   []string{"addresses[0]: Invalid value: \"::ffff:10.0.0.10-::ffff:10.0.0.20\": not an IPv6 address, range or CIDR"},
+		},
+		{
+			name:   "ipv6 mixed formats",
+			config: infrav1.IPConfigSpec{Addresses: []string{"2001:db8::10", "2001:db8::20-2001:db8::30", "2001:db8::1:0/112"}, Prefix: 64, Gateway: "2001:db8::1"},
+		},
+		{
+			name:   "ipv6 overlapping cidr and range",
+			config: infrav1.IPConfigSpec{Addresses: []string{"2001:db8::/120", "2001:db8::ff-2001:db8::1ff"}, Prefix: 64, Gateway: "2001:db8::1"},
+			errs:   []string{"addresses[1]: Invalid value: \"2001:db8::ff-2001:db8::1ff\": overlaps with 2001:db8::/120 at index 0"},
+		},
+		{
+			name:   "gateway outside of prefix",
+			ipv4:   true,
+			config: infrav1.IPConfigSpec{Addresses: []string{"10.0.0.10-10.0.0.20"}, Prefix: 24, Gateway: "10.0.1.1"},
+			errs:   []string{"gateway: Invalid value: \"10.0.1.1\": not within 10.0.0.0/24 of 10.0.0.10-10.0.0.20 at index 0"},
+		},
+		{
+			name:   "gateway of other family",
+			config: infrav1.IPConfigSpec{Addresses: []string{"2001:db8::10"}, Prefix: 64, Gateway: "10.0.0.1"},
+			errs:   []string{"gateway: Invalid value: \"10.0.0.1\": not a valid IPv6 address"},
+		},
+		{
+			name:   "prefix too long",
+			ipv4:   true,
+			config: infrav1.IPConfigSpec{Addresses: []string{"10.0.0.10"}, Prefix: 64, Gateway: "10.0.0.1"},
+			errs:   []string{"prefix: Invalid value: 64: not a valid IPv4 prefix length"},
+		},
+		{
+			name:   "cidr larger than prefix",
+			ipv4:   true,
+			config: infrav1.IPConfigSpec{Addresses: []string{"10.0.0.0/23"}, Prefix: 24, Gateway: "10.0.0.1"},
+			errs:   []string{"addresses[0]: Invalid value: \"10.0.0.0/23\": not within a single network of prefix /24"},
+		},
+		{
+			name:   "range across networks",
+			ipv4:   true,
+			config: infrav1.IPConfigSpec{Addresses: []string{"10.0.0.200-10.0.1.10"}, Prefix: 24, Gateway: "10.0.0.1"},
+			errs:   []string{"addresses[0]: Invalid value: \"10.0.0.200-10.0.1.10\": not within a single network of prefix /24"},
+		},
+		{
+			name:   "entries in different networks",
+			ipv4:   true,
+			config: infrav1.IPConfigSpec{Addresses: []string{"10.0.0.10-10.0.0.20", "10.0.1.10-10.0.1.20"}, Prefix: 24, Gateway: "10.0.0.1"},
+			errs:   []string{"gateway: Invalid value: \"10.0.0.1\": not within 10.0.1.0/24 of 10.0.1.10-10.0.1.20 at index 1"},
+		},
+		{
+			name:   "gateway is network address",
+			ipv4:   true,
+			config: infrav1.IPConfigSpec{Addresses: []string{"10.0.0.10-10.0.0.20"}, Prefix: 24, Gateway: "10.0.0.0"},
+			errs:   []string{"gateway: Invalid value: \"10.0.0.0\": the network or broadcast address of 10.0.0.0/24"},
+		},
+		{
+			name:   "gateway is broadcast address",
+			ipv4:   true,
+			config: infrav1.IPConfigSpec{Addresses: []string{"10.0.0.5"}, Prefix: 30, Gateway: "10.0.0.7"},
+			errs:   []string{"gateway: Invalid value: \"10.0.0.7\": the network or broadcast address of 10.0.0.4/30"},
+		},
+		{
+			name:   "point-to-point",
+			ipv4:   true,
+			config: infrav1.IPConfigSpec{Addresses: []string{"10.0.0.0"}, Prefix: 31, Gateway: "10.0.0.1"},
+		},
+		{
+			name:   "point-to-point cidr",
+			ipv4:   true,
+			config: infrav1.IPConfigSpec{Addresses: []string{"10.0.0.0/31"}, Prefix: 31, Gateway: "10.0.0.1"},
+		},
+		{
+			name:   "point-to-point gateway in other network",
+			ipv4:   true,
+			config: infrav1.IPConfigSpec{Addresses: []string{"10.0.0.1"}, Prefix: 31, Gateway: "10.0.0.2"},
+			errs:   []string{"gateway: Invalid value: \"10.0.0.2\": not within 10.0.0.0/31 of 10.0.0.1 at index 0"},
+		},
+		{
+			name:   "ipv6 point-to-point",
+			config: infrav1.IPConfigSpec{Addresses: []string{"2001:db8::"}, Prefix: 127, Gateway: "2001:db8::1"},
+		},
+		{
+			name:   "ipv6 point-to-point gateway in other network",
+			config: infrav1.IPConfigSpec{Addresses: []string{"2001:db8::1"}, Prefix: 127, Gateway: "2001:db8::2"},
+			errs:   []string{"gateway: Invalid value: \"2001:db8::2\": not within 2001:db8::/127 of 2001:db8::1 at index 0"},
+		},
+		{
+			name:   "ipv6 cidr larger than prefix",
+			config: infrav1.IPConfigSpec{Addresses: []string{"2001:db8::/56"}, Prefix: 64, Gateway: "2001:db8::1"},
+			errs:   []string{"addresses[0]: Invalid value: \"2001:db8::/56\": not within a single network of prefix /64"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			errs := ValidateIPConfig(nil, &test.config, test.ipv4)
+			messages := make([]string, 0, len(errs))
+			for _, err := range errs {
+				messages = append(messages, err.Error())
+			}
+			if len(test.errs) == 0 {
+				require.Empty(t, messages)
+			} else {
+				require.Equal(t, test.errs, messages)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2023-2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxmox
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/luthermonson/go-proxmox"
+)
+
+// DefaultCacheTTL is how long the clients created by the manager cache the answers of the Proxmox API
+// which rarely change. It is meant to be set once, while the manager starts.
+var DefaultCacheTTL = 30 * time.Second
+
+// Keys of the cached answers. Keys of answers for a given resource have the resource appended.
+const (
+	cacheKeyNodes = "nodes"
+	cacheKeyVMs   = "vms"
+	cacheKeyPool  = "pool/"
+	cacheKeyISO   = "iso/"
+	cacheKeyVNets = "vnets"
+)
+
+// CachedClient caches the answers of the Proxmox API which rarely change, like the nodes of the cluster,
+// the VMs to look up templates in, the resource pools and the ISO images of the storages,
+// so they are not requested again by every reconcile. Calls of the client which change them
+// invalidate the affected answers. Changes made outside of the client are seen once the answers expire.
+type CachedClient struct {
+	Client
+
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	expires time.Time
+	value   any
+}
+
+// NewCachedClient wraps the client, so its answers which rarely change are cached for the ttl.
+// A ttl of zero disables the cache, and the client is returned as is.
+func NewCachedClient(client Client, ttl time.Duration) Client {
+	if ttl <= 0 {
+		return client
+	}
+	return &CachedClient{Client: client, ttl: ttl, now: time.Now, entries: make(map[string]cacheEntry)}
+}
+
+// Invalidate removes the cached answers whose keys start with one of the prefixes, or all of them without prefixes.
+func (c *CachedClient) Invalidate(prefixes ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.entries {
+		if len(prefixes) == 0 || slices.ContainsFunc(prefixes, func(prefix string) bool { return strings.HasPrefix(key, prefix) }) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// cached returns the cached answer of the key, or fetches and caches it. Errors are not cached.
+// The lock is not held while fetching, so concurrent misses of the same key fetch it more than once.
+func cached[T any](ctx context.Context, c *CachedClient, key string, fetch func(context.Context) (T, error)) (T, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && c.now().Before(entry.expires) {
+		return entry.value.(T), nil
+	}
+
+	value, err := fetch(ctx)
+	if err != nil {
+		return value, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = cacheEntry{expires: now.Add(c.ttl), value: value}
+	return value, nil
+}
+
+// ListNodes returns the cached names of all nodes in the Proxmox cluster.
+func (c *CachedClient) ListNodes(ctx context.Context) ([]string, error) {
+	return cached(ctx, c, cacheKeyNodes, c.Client.ListNodes)
+}
+
+// ListVMResources returns the cached VMs of the Proxmox cluster.
+func (c *CachedClient) ListVMResources(ctx context.Context) ([]*proxmox.ClusterResource, error) {
+	return cached(ctx, c, cacheKeyVMs, c.Client.ListVMResources)
+}
+
+// ListVNets returns the cached VNets of the Proxmox SDN.
+func (c *CachedClient) ListVNets(ctx context.Context) ([]VNet, error) {
+	return cached(ctx, c, cacheKeyVNets, c.Client.ListVNets)
+}
+
+// PoolExists returns whether the resource pool exists, as cached.
+func (c *CachedClient) PoolExists(ctx context.Context, poolID string) (bool, error) {
+	return cached(ctx, c, cacheKeyPool+poolID, func(ctx context.Context) (bool, error) {
+		return c.Client.PoolExists(ctx, poolID)
+	})
+}
+
+// ISOExists returns whether the ISO image exists on the node, as cached.
+func (c *CachedClient) ISOExists(ctx context.Context, nodeName, volumeID string) (bool, error) {
+	return cached(ctx, c, cacheKeyISO+nodeName+"/"+volumeID, func(ctx context.Context) (bool, error) {
+		return c.Client.ISOExists(ctx, nodeName, volumeID)
+	})
+}
+
+// CloneVM clones the VM, and invalidates the cached VMs.
+func (c *CachedClient) CloneVM(ctx context.Context, templateID int, clone VMCloneRequest) (VMCloneResponse, error) {
+	defer c.Invalidate(cacheKeyVMs)
+	return c.Client.CloneVM(ctx, templateID, clone)
+}
+
+// DeleteVM deletes the VM, and invalidates the cached VMs.
+func (c *CachedClient) DeleteVM(ctx context.Context, nodeName string, vmID int64) (*proxmox.Task, error) {
+	defer c.Invalidate(cacheKeyVMs)
+	return c.Client.DeleteVM(ctx, nodeName, vmID)
+}
+
+// MigrateVM migrates the VM, and invalidates the cached VMs.
+func (c *CachedClient) MigrateVM(ctx context.Context, vm *proxmox.VirtualMachine, target string) (*proxmox.Task, error) {
+	defer c.Invalidate(cacheKeyVMs)
+	return c.Client.MigrateVM(ctx, vm, target)
+}
+
+// MountCloudInitISO uploads and mounts the cloud-init ISO of the VM, and invalidates the cached ISO images.
+func (c *CachedClient) MountCloudInitISO(ctx context.Context, vm *proxmox.VirtualMachine, device, path string) error {
+	defer c.Invalidate(cacheKeyISO)
+	return c.Client.MountCloudInitISO(ctx, vm, device, path)
+}
+
+// UnmountCloudInitISO unmounts and deletes the cloud-init ISO of the VM, and invalidates the cached ISO images.
+func (c *CachedClient) UnmountCloudInitISO(ctx context.Context, vm *proxmox.VirtualMachine, device string) error {
+	defer c.Invalidate(cacheKeyISO)
+	return c.Client.UnmountCloudInitISO(ctx, vm, device)
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2023-2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxmox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/luthermonson/go-proxmox"
+	"github.com/stretchr/testify/require"
+)
+
+// countingClient counts the calls of the methods whose answers are cached.
+type countingClient struct {
+	Client
+	calls map[string]int
+	err   error
+}
+
+func (c *countingClient) ListNodes(context.Context) ([]string, error) {
+	c.calls["ListNodes"]++
+	return []string{"pve1", "pve2"}, c.err
+}
+
+func (c *countingClient) ListVMResources(context.Context) ([]*proxmox.ClusterResource, error) {
+	c.calls["ListVMResources"]++
+	return []*proxmox.ClusterResource{{VMID: 100}}, c.err
+}
+
+func (c *countingClient) PoolExists(context.Context, string) (bool, error) {
+	c.calls["PoolExists"]++
+	return true, c.err
+}
+
+func (c *countingClient) CloneVM(context.Context, int, VMCloneRequest) (VMCloneResponse, error) {
+	return VMCloneResponse{NewID: 101}, nil
+}
+
+func newTestCachedClient() (*CachedClient, *countingClient, *time.Time) {
+	upstream := &countingClient{calls: map[string]int{}}
+	now := time.Now()
+	client := NewCachedClient(upstream, time.Minute).(*CachedClient)
+	client.now = func() time.Time { return now }
+	return client, upstream, &now
+}
+
+func TestCachedClient_Hit(t *testing.T) {
+	client, upstream, _ := newTestCachedClient()
+
+	for range 3 {
+		nodes, err := client.ListNodes(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []string{"pve1", "pve2"}, nodes)
+	}
+	require.Equal(t, 1, upstream.calls["ListNodes"])
+}
+
+func TestCachedClient_KeyedByResource(t *testing.T) {
+	client, upstream, _ := newTestCachedClient()
+
+	for _, pool := range []string{"pool1", "pool2", "pool1"} {
+		exists, err := client.PoolExists(context.Background(), pool)
+		require.NoError(t, err)
+		require.True(t, exists)
+	}
+	require.Equal(t, 2, upstream.calls["PoolExists"])
+}
+
+func TestCachedClient_Expiry(t *testing.T) {
+	client, upstream, now := newTestCachedClient()
+
+	_, err := client.ListNodes(context.Background())
+	require.NoError(t, err)
+
+	*now = now.Add(59 * time.Second)
+	_, err = client.ListNodes(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, upstream.calls["ListNodes"])
+
+	*now = now.Add(time.Second)
+	_, err = client.ListNodes(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, upstream.calls["ListNodes"])
+}
+
+func TestCachedClient_ErrorsAreNotCached(t *testing.T) {
+	client, upstream, _ := newTestCachedClient()
+	upstream.err = errors.New("596 connection timed out")
+
+	_, err := client.ListNodes(context.Background())
+	require.Error(t, err)
+
+	upstream.err = nil
+	_, err = client.ListNodes(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, upstream.calls["ListNodes"])
+}
+
+func TestCachedClient_CloneInvalidatesVMs(t *testing.T) {
+	client, upstream, _ := newTestCachedClient()
+
+	_, err := client.ListVMResources(context.Background())
+	require.NoError(t, err)
+	_, err = client.ListNodes(context.Background())
+	require.NoError(t, err)
+
+	_, err = client.CloneVM(context.Background(), 100, VMCloneRequest{NewID: 101})
+	require.NoError(t, err)
+
+	_, err = client.ListVMResources(context.Background())
+	require.NoError(t, err)
+	_, err = client.ListNodes(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, upstream.calls["ListVMResources"])
+	require.Equal(t, 1, upstream.calls["ListNodes"])
+}
+
+func TestCachedClient_Invalidate(t *testing.T) {
+	client, upstream, _ := newTestCachedClient()
+
+	_, err := client.ListNodes(context.Background())
+	require.NoError(t, err)
+	client.Invalidate()
+	_, err = client.ListNodes(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, upstream.calls["ListNodes"])
+}
+
+func TestNewCachedClient_Disabled(t *testing.T) {
+	upstream := &countingClient{calls: map[string]int{}}
+	require.Same(t, upstream, NewCachedClient(upstream, 0))
+}
@@ -19,6 +19,7 @@ package proxmox
 
 import (
 	"context"
+	"time"
 
 	"github.com/luthermonson/go-proxmox"
 )
@@ -31,27 +32,71 @@ type Client interface {
 
 	FindVMResource(ctx context.Context, vmID uint64) (*proxmox.ClusterResource, error)
 
+	ListVMResources(ctx context.Context) ([]*proxmox.ClusterResource, error)
+
 	CheckID(ctx context.Context, vmID int64) (bool, error)
 
 	GetVM(ctx context.Context, nodeName string, vmID int64) (*proxmox.VirtualMachine, error)
 
+	GetVMStartup(ctx context.Context, vm *proxmox.VirtualMachine) (string, error)
+
 	DeleteVM(ctx context.Context, nodeName string, vmID int64) (*proxmox.Task, error)
 
 	GetTask(ctx context.Context, upID string) (*proxmox.Task, error)
 
+	GetTaskLog(ctx context.Context, upID string, lines int) ([]string, error)
+
+	StopTask(ctx context.Context, upID string) error
+
+	GetFirewallRules(ctx context.Context, vm *proxmox.VirtualMachine) ([]*proxmox.FirewallRule, error)
+
+	AddFirewallRule(ctx context.Context, vm *proxmox.VirtualMachine, rule *proxmox.FirewallRule) error
+
+	DeleteFirewallRule(ctx context.Context, vm *proxmox.VirtualMachine, pos int) error
+
+	EnableFirewall(ctx context.Context, vm *proxmox.VirtualMachine, policyIn string) error
+
 	GetReservableMemoryBytes(ctx context.Context, nodeName string, nodeMemoryAdjustment uint64) (uint64, error)
 
+	GetNodeCPUUsage(ctx context.Context, nodeName string) (float64, error)
+
+	ListNodes(ctx context.Context) ([]string, error)
+
+	PoolExists(ctx context.Context, poolID string) (bool, error)
+
+	ISOExists(ctx context.Context, nodeName, volumeID string) (bool, error)
+
+	ListStorages(ctx context.Context, nodeName string) ([]*proxmox.Storage, error)
+
+	ListVNets(ctx context.Context) ([]VNet, error)
+
 	ResizeDisk(ctx context.Context, vm *proxmox.VirtualMachine, disk, size string) error
 
+	MigrateVM(ctx context.Context, vm *proxmox.VirtualMachine, target string) (*proxmox.Task, error)
+
 	ResumeVM(ctx context.Context, vm *proxmox.VirtualMachine) (*proxmox.Task, error)
 
+	RebootVM(ctx context.Context, vm *proxmox.VirtualMachine) (*proxmox.Task, error)
+
+	CreateSnapshot(ctx context.Context, vm *proxmox.VirtualMachine, name, description string) (*proxmox.Task, error)
+
+	SnapshotExists(ctx context.Context, vm *proxmox.VirtualMachine, name string) (bool, error)
+
 	StartVM(ctx context.Context, vm *proxmox.VirtualMachine) (*proxmox.Task, error)
 
+	ShutdownVM(ctx context.Context, vm *proxmox.VirtualMachine, timeout time.Duration) (*proxmox.Task, error)
+
+	StopVM(ctx context.Context, vm *proxmox.VirtualMachine) (*proxmox.Task, error)
+
 	TagVM(ctx context.Context, vm *proxmox.VirtualMachine, tag string) (*proxmox.Task, error)
 
+	MountCloudInitISO(ctx context.Context, vm *proxmox.VirtualMachine, device, path string) error
+
 	UnmountCloudInitISO(ctx context.Context, vm *proxmox.VirtualMachine, device string) error
 
 	CloudInitStatus(ctx context.Context, vm *proxmox.VirtualMachine) (bool, error)
 
 	QemuAgentStatus(ctx context.Context, vm *proxmox.VirtualMachine) error
+
+	QemuAgentNetworkInterfaces(ctx context.Context, vm *proxmox.VirtualMachine) ([]*proxmox.AgentNetworkIface, error)
 }
@@ -21,7 +21,10 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/luthermonson/go-proxmox"
@@ -77,6 +80,11 @@ func (c *APIClient) CloneVM(ctx context.Context, templateID int, clone capmox.VM
 		return capmox.VMCloneResponse{}, fmt.Errorf("unable to find vm template: %w", err)
 	}
 
+	// Proxmox silently falls back to a full clone for regular VMs.
+	if clone.Full == 0 && !bool(vmTemplate.Template) {
+		return capmox.VMCloneResponse{}, fmt.Errorf("unable to clone vm %d: %w", templateID, ErrLinkedCloneRequiresTemplate)
+	}
+
 	vmOptions := proxmox.VirtualMachineCloneOptions{
 		NewID:       clone.NewID,
 		Description: clone.Description,
@@ -90,6 +98,10 @@ func (c *APIClient) CloneVM(ctx context.Context, templateID int, clone capmox.VM
 	}
 	newID, task, err := vmTemplate.Clone(ctx, &vmOptions)
 	if err != nil {
+		// Proxmox reports the VMID being taken in the status of the response, e.g. "500 VM 101 already exists on node 'pve'".
+		if strings.Contains(err.Error(), "already exists") {
+			return capmox.VMCloneResponse{}, fmt.Errorf("unable to create new vm %d: %w: %s", clone.NewID, ErrVMIDInUse, err)
+		}
 		return capmox.VMCloneResponse{}, fmt.Errorf("unable to create new vm: %w", err)
 	}
 
@@ -120,16 +132,25 @@ func (c *APIClient) GetVM(ctx context.Context, nodeName string, vmID int64) (*pr
 	return vm, nil
 }
 
-// FindVMResource tries to find a VM by its ID on the whole cluster.
-func (c *APIClient) FindVMResource(ctx context.Context, vmID uint64) (*proxmox.ClusterResource, error) {
-	cluster, err := c.Cluster(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("cannot get cluster status: %w", err)
+// vmStartup holds the startup option of a VM, which go-proxmox does not expose.
+type vmStartup struct {
+	Startup string `json:"startup"`
+}
+
+// GetVMStartup returns the startup option of the VM e.g. order=1,up=30. It is empty if unset.
+func (c *APIClient) GetVMStartup(ctx context.Context, vm *proxmox.VirtualMachine) (string, error) {
+	config := &vmStartup{}
+	if err := c.Get(ctx, fmt.Sprintf("/nodes/%s/qemu/%d/config", vm.Node, vm.VMID), config); err != nil {
+		return "", fmt.Errorf("cannot get config of vm %d: %w", vm.VMID, err)
 	}
+	return config.Startup, nil
+}
 
-	vmResources, err := cluster.Resources(ctx, "vm")
+// FindVMResource tries to find a VM by its ID on the whole cluster.
+func (c *APIClient) FindVMResource(ctx context.Context, vmID uint64) (*proxmox.ClusterResource, error) {
+	vmResources, err := c.ListVMResources(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("could not list vm resources: %w", err)
+		return nil, err
 	}
 
 	for _, vm := range vmResources {
@@ -141,7 +162,22 @@ func (c *APIClient) FindVMResource(ctx context.Context, vmID uint64) (*proxmox.C
 	return nil, fmt.Errorf("unable to find VM with ID %d on any of the nodes", vmID)
 }
 
+// ListVMResources lists the VMs and templates on all nodes of the cluster.
+func (c *APIClient) ListVMResources(ctx context.Context) ([]*proxmox.ClusterResource, error) {
+	cluster, err := c.Cluster(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get cluster status: %w", err)
+	}
+
+	vmResources, err := cluster.Resources(ctx, "vm")
+	if err != nil {
+		return nil, fmt.Errorf("could not list vm resources: %w", err)
+	}
+	return vmResources, nil
+}
+
 // DeleteVM deletes a VM based on the nodeName and vmID.
+// The protection of the VM is removed first, since it only guards against manual deletion.
 func (c *APIClient) DeleteVM(ctx context.Context, nodeName string, vmID int64) (*proxmox.Task, error) {
 	// A vmID can not be lower than 100.
 	// If the provided vmID is lower (like -1 in issue #31), just error out without calling the API.
@@ -176,6 +212,14 @@ func (c *APIClient) DeleteVM(ctx context.Context, nodeName string, vmID int64) (
 		}
 	}
 
+	if vm.VirtualMachineConfig != nil && vm.VirtualMachineConfig.Protection == 1 {
+		c.logger.Info("vm is protected, removing the protection to delete it", "vmid", vmID, "node", nodeName)
+		// PUT applies the config synchronously, so the protection is gone before the VM is destroyed.
+		if err := c.Put(ctx, fmt.Sprintf("/nodes/%s/qemu/%d/config", nodeName, vmID), map[string]int{"protection": 0}, nil); err != nil {
+			return nil, fmt.Errorf("cannot remove protection of vm with id %d: %w", vmID, err)
+		}
+	}
+
 	task, err := vm.Delete(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("cannot delete vm with id %d: %w", vmID, err)
@@ -206,6 +250,44 @@ func (c *APIClient) GetTask(ctx context.Context, upID string) (*proxmox.Task, er
 	return task, nil
 }
 
+// taskLogLimit is the number of task log lines fetched to find the last ones.
+const taskLogLimit = 10000
+
+// GetTaskLog returns the last lines of the log of the task associated with upID.
+func (c *APIClient) GetTaskLog(ctx context.Context, upID string, lines int) ([]string, error) {
+	task := proxmox.NewTask(proxmox.UPID(upID), c.Client)
+
+	log, err := task.Log(ctx, 0, taskLogLimit)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get log of task with UPID %s: %w", upID, err)
+	}
+
+	numbers := make([]int, 0, len(log))
+	for n := range log {
+		numbers = append(numbers, n)
+	}
+	slices.Sort(numbers)
+	if len(numbers) > lines {
+		numbers = numbers[len(numbers)-lines:]
+	}
+
+	excerpt := make([]string, 0, len(numbers))
+	for _, n := range numbers {
+		excerpt = append(excerpt, log[n])
+	}
+	return excerpt, nil
+}
+
+// StopTask stops the task associated with upID.
+func (c *APIClient) StopTask(ctx context.Context, upID string) error {
+	task := proxmox.NewTask(proxmox.UPID(upID), c.Client)
+
+	if err := task.Stop(ctx); err != nil {
+		return fmt.Errorf("cannot stop task with UPID %s: %w", upID, err)
+	}
+	return nil
+}
+
 // GetReservableMemoryBytes returns the memory that can be reserved by a new VM, in bytes.
 func (c *APIClient) GetReservableMemoryBytes(ctx context.Context, nodeName string, nodeMemoryAdjustment uint64) (uint64, error) {
 	node, err := c.Client.Node(ctx, nodeName)
@@ -252,26 +334,278 @@ func (c *APIClient) GetReservableMemoryBytes(ctx context.Context, nodeName strin
 	return reservableMemory, nil
 }
 
+// GetNodeCPUUsage returns the current CPU usage of a node, as a fraction between 0 and 1.
+func (c *APIClient) GetNodeCPUUsage(ctx context.Context, nodeName string) (float64, error) {
+	node, err := c.Client.Node(ctx, nodeName)
+	if err != nil {
+		return 0, fmt.Errorf("cannot find node with name %s: %w", nodeName, err)
+	}
+
+	return node.CPU, nil
+}
+
+// ListNodes returns the names of all nodes in the Proxmox cluster.
+func (c *APIClient) ListNodes(ctx context.Context) ([]string, error) {
+	nodes, err := c.Client.Nodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list nodes: %w", err)
+	}
+
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		names = append(names, node.Node)
+	}
+
+	return names, nil
+}
+
+// PoolExists checks whether a resource pool with the given ID exists.
+func (c *APIClient) PoolExists(ctx context.Context, poolID string) (bool, error) {
+	pools, err := c.Client.Pools(ctx)
+	if err != nil {
+		return false, fmt.Errorf("cannot list pools: %w", err)
+	}
+
+	for _, pool := range pools {
+		if pool.PoolID == poolID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// ISOExists checks whether an ISO image, given by its volume id like 'local:iso/drivers.iso', exists on the node.
+func (c *APIClient) ISOExists(ctx context.Context, nodeName, volumeID string) (bool, error) {
+	storageName, _, ok := strings.Cut(volumeID, ":")
+	if !ok {
+		return false, fmt.Errorf("invalid volume id %q", volumeID)
+	}
+
+	node, err := c.Node(ctx, nodeName)
+	if err != nil {
+		return false, fmt.Errorf("cannot find node with name %s: %w", nodeName, err)
+	}
+
+	storage, err := node.Storage(ctx, storageName)
+	if err != nil {
+		return false, fmt.Errorf("cannot find storage %s on node %s: %w", storageName, nodeName, err)
+	}
+
+	content, err := storage.GetContent(ctx)
+	if err != nil {
+		return false, fmt.Errorf("cannot list content of storage %s: %w", storageName, err)
+	}
+
+	for _, volume := range content {
+		if volume.Volid == volumeID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// ListStorages lists the storages of the node, including the shared storages available on it.
+func (c *APIClient) ListStorages(ctx context.Context, nodeName string) ([]*proxmox.Storage, error) {
+	node, err := c.Node(ctx, nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find node with name %s: %w", nodeName, err)
+	}
+
+	storages, err := node.Storages(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list storages of node %s: %w", nodeName, err)
+	}
+	return storages, nil
+}
+
+// ListVNets returns the VNets of the Proxmox SDN.
+func (c *APIClient) ListVNets(ctx context.Context) ([]capmox.VNet, error) {
+	var vnets []capmox.VNet
+	if err := c.Get(ctx, "/cluster/sdn/vnets", &vnets); err != nil {
+		return nil, fmt.Errorf("cannot list sdn vnets: %w", err)
+	}
+	return vnets, nil
+}
+
+// GetFirewallRules returns the firewall rules of the VM, ordered by their position.
+func (c *APIClient) GetFirewallRules(ctx context.Context, vm *proxmox.VirtualMachine) ([]*proxmox.FirewallRule, error) {
+	rules, err := vm.FirewallGetRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list firewall rules of vm %d: %w", vm.VMID, err)
+	}
+	return rules, nil
+}
+
+// AddFirewallRule adds a firewall rule to the VM. Proxmox inserts new rules at the top.
+func (c *APIClient) AddFirewallRule(ctx context.Context, vm *proxmox.VirtualMachine, rule *proxmox.FirewallRule) error {
+	if err := vm.FirewallRulesCreate(ctx, rule); err != nil {
+		return fmt.Errorf("cannot add firewall rule to vm %d: %w", vm.VMID, err)
+	}
+	return nil
+}
+
+// DeleteFirewallRule deletes the firewall rule at the given position of the VM.
+func (c *APIClient) DeleteFirewallRule(ctx context.Context, vm *proxmox.VirtualMachine, pos int) error {
+	if err := vm.FirewallRulesDelete(ctx, pos); err != nil {
+		return fmt.Errorf("cannot delete firewall rule %d of vm %d: %w", pos, vm.VMID, err)
+	}
+	return nil
+}
+
+// EnableFirewall enables the firewall of the VM with the given inbound policy, unless it already is.
+func (c *APIClient) EnableFirewall(ctx context.Context, vm *proxmox.VirtualMachine, policyIn string) error {
+	// vm.FirewallOptionGet decodes into a nil pointer, so the options are fetched directly.
+	options := &proxmox.FirewallVirtualMachineOption{}
+	err := c.Get(ctx, fmt.Sprintf("/nodes/%s/qemu/%d/firewall/options", vm.Node, vm.VMID), options)
+	if err != nil {
+		return fmt.Errorf("cannot get firewall options of vm %d: %w", vm.VMID, err)
+	}
+
+	if options.Enable && options.PolicyIn == policyIn {
+		return nil
+	}
+
+	err = vm.FirewallOptionSet(ctx, &proxmox.FirewallVirtualMachineOption{Enable: true, PolicyIn: policyIn})
+	if err != nil {
+		return fmt.Errorf("cannot enable firewall of vm %d: %w", vm.VMID, err)
+	}
+	return nil
+}
+
 // ResizeDisk resizes a VM disk to the specified size.
 func (c *APIClient) ResizeDisk(ctx context.Context, vm *proxmox.VirtualMachine, disk, size string) error {
 	return vm.ResizeDisk(ctx, disk, size)
 }
 
+// migratePrecondition is the result of the migration precondition check of a VM.
+type migratePrecondition struct {
+	LocalDisks []struct {
+		VolID string `json:"volid"`
+	} `json:"local_disks"`
+}
+
+// MigrateVM migrates the VM to the target node. Running VMs are migrated online.
+// Disks on local storage are migrated along with the VM.
+func (c *APIClient) MigrateVM(ctx context.Context, vm *proxmox.VirtualMachine, target string) (*proxmox.Task, error) {
+	precondition := &migratePrecondition{}
+	err := c.Get(ctx, fmt.Sprintf("/nodes/%s/qemu/%d/migrate?target=%s", vm.Node, vm.VMID, url.QueryEscape(target)), precondition)
+	if err != nil {
+		return nil, fmt.Errorf("cannot check migration of vm %d to node %s: %w", vm.VMID, target, err)
+	}
+
+	task, err := vm.Migrate(ctx, &proxmox.VirtualMachineMigrateOptions{
+		Target:         target,
+		Online:         proxmox.IntOrBool(vm.IsRunning()),
+		WithLocalDisks: proxmox.IntOrBool(len(precondition.LocalDisks) > 0),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot migrate vm %d to node %s: %w", vm.VMID, target, err)
+	}
+	return task, nil
+}
+
 // ResumeVM resumes the VM.
 func (c *APIClient) ResumeVM(ctx context.Context, vm *proxmox.VirtualMachine) (*proxmox.Task, error) {
 	return vm.Resume(ctx)
 }
 
+// RebootVM reboots the VM, which applies its pending config changes.
+func (c *APIClient) RebootVM(ctx context.Context, vm *proxmox.VirtualMachine) (*proxmox.Task, error) {
+	return vm.Reboot(ctx)
+}
+
+// CreateSnapshot takes a snapshot of the VM with the given name and description.
+func (c *APIClient) CreateSnapshot(ctx context.Context, vm *proxmox.VirtualMachine, name, description string) (*proxmox.Task, error) {
+	var upid proxmox.UPID
+	options := map[string]string{"snapname": name, "description": description}
+	if err := c.Post(ctx, fmt.Sprintf("/nodes/%s/qemu/%d/snapshot", vm.Node, vm.VMID), options, &upid); err != nil {
+		return nil, fmt.Errorf("cannot create snapshot %s of vm %d: %w", name, vm.VMID, err)
+	}
+	return proxmox.NewTask(upid, c.Client), nil
+}
+
+// SnapshotExists checks if the VM has a snapshot with the given name.
+func (c *APIClient) SnapshotExists(ctx context.Context, vm *proxmox.VirtualMachine, name string) (bool, error) {
+	snapshots, err := vm.Snapshots(ctx)
+	if err != nil {
+		return false, fmt.Errorf("cannot list snapshots of vm %d: %w", vm.VMID, err)
+	}
+
+	for _, snapshot := range snapshots {
+		if snapshot.Name == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // StartVM starts the VM.
 func (c *APIClient) StartVM(ctx context.Context, vm *proxmox.VirtualMachine) (*proxmox.Task, error) {
 	return vm.Start(ctx)
 }
 
+// ShutdownVM shuts the guest of the VM down via ACPI, or the QEMU guest agent if enabled.
+// The task fails if the VM is still running after the timeout, it does not fall back to stopping the VM.
+func (c *APIClient) ShutdownVM(ctx context.Context, vm *proxmox.VirtualMachine, timeout time.Duration) (*proxmox.Task, error) {
+	var upid proxmox.UPID
+	options := map[string]int64{"timeout": int64(timeout.Seconds())}
+	if err := c.Post(ctx, fmt.Sprintf("/nodes/%s/qemu/%d/status/shutdown", vm.Node, vm.VMID), options, &upid); err != nil {
+		return nil, fmt.Errorf("cannot shut down vm %d: %w", vm.VMID, err)
+	}
+	return proxmox.NewTask(upid, c.Client), nil
+}
+
+// StopVM stops the VM.
+func (c *APIClient) StopVM(ctx context.Context, vm *proxmox.VirtualMachine) (*proxmox.Task, error) {
+	return vm.Stop(ctx)
+}
+
 // TagVM tags the VM.
 func (c *APIClient) TagVM(ctx context.Context, vm *proxmox.VirtualMachine, tag string) (*proxmox.Task, error) {
 	return vm.AddTag(ctx, tag)
 }
 
+// MountCloudInitISO uploads the cloud-init iso at path to the iso storage of the node of the VM,
+// and mounts it at device, the same way the NoCloud iso of go-proxmox is mounted.
+func (c *APIClient) MountCloudInitISO(ctx context.Context, vm *proxmox.VirtualMachine, device, path string) error {
+	node, err := c.Node(ctx, vm.Node)
+	if err != nil {
+		return fmt.Errorf("cannot find node with name %s: %w", vm.Node, err)
+	}
+
+	storage, err := node.StorageISO(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot find iso storage on node %s: %w", vm.Node, err)
+	}
+
+	task, err := storage.Upload("iso", path)
+	if err != nil {
+		return fmt.Errorf("unable to upload cloud-init iso: %w", err)
+	}
+	if err := task.WaitFor(ctx, 5); err != nil {
+		return fmt.Errorf("unable to upload cloud-init iso: %w", err)
+	}
+
+	// the tag marks the iso to be deleted by UnmountCloudInitISO.
+	if _, err := vm.AddTag(ctx, proxmox.MakeTag(proxmox.TagCloudInit)); err != nil && !proxmox.IsErrNoop(err) {
+		return fmt.Errorf("unable to tag VM: %w", err)
+	}
+
+	task, err = vm.Config(ctx, proxmox.VirtualMachineOption{
+		Name:  device,
+		Value: fmt.Sprintf("%s:iso/%s,media=cdrom", storage.Name, filepath.Base(path)),
+	}, proxmox.VirtualMachineOption{
+		Name:  "boot",
+		Value: fmt.Sprintf("%s;%s", vm.VirtualMachineConfig.Boot, device),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to mount cloud-init iso: %w", err)
+	}
+	return task.WaitFor(ctx, 2)
+}
+
 // UnmountCloudInitISO unmounts the cloud-init iso from VM.
 func (c *APIClient) UnmountCloudInitISO(ctx context.Context, vm *proxmox.VirtualMachine, device string) error {
 	err := vm.UnmountCloudInitISO(ctx, device)
@@ -319,3 +653,13 @@ func (c *APIClient) QemuAgentStatus(ctx context.Context, vm *proxmox.VirtualMach
 
 	return nil
 }
+
+// QemuAgentNetworkInterfaces returns the network interfaces reported by the qemu-agent of the VM.
+func (c *APIClient) QemuAgentNetworkInterfaces(ctx context.Context, vm *proxmox.VirtualMachine) ([]*proxmox.AgentNetworkIface, error) {
+	ifaces, err := vm.AgentGetNetworkIFaces(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get network interfaces from agent")
+	}
+
+	return ifaces, nil
+}
@@ -18,9 +18,11 @@ package goproxmox
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/jarcoal/httpmock"
@@ -49,6 +51,33 @@ func newJSONResponder(status int, data any) httpmock.Responder {
 	return httpmock.NewJsonResponderOrPanic(status, map[string]any{"data": data}).Once()
 }
 
+func TestProxmoxAPIClient_GetTaskLog(t *testing.T) {
+	client := newTestClient(t)
+	upid := "UPID:test:000D6BDA:041E0A54:654A5A1D:qmclone:101:root@pam:"
+
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/test/tasks/`+upid+`/log`,
+		newJSONResponder(200, []map[string]any{
+			{"n": 1, "t": "create full clone of drive scsi0"},
+			{"n": 2, "t": "transferred 1.0 GiB of 32.0 GiB"},
+			{"n": 3, "t": "clone failed: no space left on device"},
+			{"n": 4, "t": "TASK ERROR: clone failed"},
+		}))
+
+	lines, err := client.GetTaskLog(context.Background(), upid, 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"clone failed: no space left on device", "TASK ERROR: clone failed"}, lines)
+}
+
+func TestProxmoxAPIClient_StopTask(t *testing.T) {
+	client := newTestClient(t)
+	upid := "UPID:test:000D6BDA:041E0A54:654A5A1D:qmclone:101:root@pam:"
+
+	httpmock.RegisterResponder(http.MethodDelete, `=~/nodes/test/tasks/`+upid,
+		newJSONResponder(200, nil))
+
+	require.NoError(t, client.StopTask(context.Background(), upid))
+}
+
 func TestProxmoxAPIClient_GetReservableMemoryBytes(t *testing.T) {
 	tests := []struct {
 		name                 string
@@ -180,6 +209,107 @@ func TestProxmoxAPIClient_GetReservableMemoryBytes(t *testing.T) {
 	})
 }
 
+func TestProxmoxAPIClient_ListNodes(t *testing.T) {
+	client := newTestClient(t)
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes$`,
+		newJSONResponder(200, proxmox.NodeStatuses{{Node: "pve1"}, {Node: "pve2"}}))
+
+	nodes, err := client.ListNodes(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"pve1", "pve2"}, nodes)
+}
+
+func TestProxmoxAPIClient_PoolExists(t *testing.T) {
+	client := newTestClient(t)
+	httpmock.RegisterResponder(http.MethodGet, `=~/pools$`,
+		newJSONResponder(200, proxmox.Pools{{PoolID: "capi"}, {PoolID: "infra"}}))
+
+	exists, err := client.PoolExists(context.Background(), "infra")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	exists, err = client.PoolExists(context.Background(), "missing")
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+func TestProxmoxAPIClient_ISOExists(t *testing.T) {
+	client := newTestClient(t)
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/test/status`,
+		newJSONResponder(200, proxmox.Node{}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/test/storage/local/status`,
+		newJSONResponder(200, proxmox.Storage{}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/test/storage/local/content`,
+		newJSONResponder(200, []*proxmox.StorageContent{{Volid: "local:iso/drivers.iso"}}))
+
+	exists, err := client.ISOExists(context.Background(), "test", "local:iso/drivers.iso")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	exists, err = client.ISOExists(context.Background(), "test", "local:iso/missing.iso")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	_, err = client.ISOExists(context.Background(), "test", "drivers.iso")
+	require.Error(t, err)
+}
+
+func TestProxmoxAPIClient_ListStorages(t *testing.T) {
+	client := newTestClient(t)
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/test/status`,
+		newJSONResponder(200, proxmox.Node{}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/test/storage$`,
+		newJSONResponder(200, proxmox.Storages{
+			{Name: "local", Content: "iso,vztmpl", Enabled: 1, Active: 1},
+			{Name: "ceph", Content: "images,rootdir", Enabled: 1, Active: 1, Shared: 1},
+		}))
+
+	storages, err := client.ListStorages(context.Background(), "test")
+	require.NoError(t, err)
+	require.Len(t, storages, 2)
+	require.Equal(t, "ceph", storages[1].Name)
+	require.Equal(t, 1, storages[1].Shared)
+	require.Equal(t, "test", storages[1].Node)
+}
+
+func TestProxmoxAPIClient_ListVNets(t *testing.T) {
+	client := newTestClient(t)
+	httpmock.RegisterResponder(http.MethodGet, `=~/cluster/sdn/vnets$`,
+		newJSONResponder(200, []map[string]any{
+			{"vnet": "prod", "zone": "vlanzone", "tag": 100, "type": "vnet"},
+			{"vnet": "trunk", "zone": "vlanzone", "vlanaware": 1, "type": "vnet"},
+		}))
+
+	vnets, err := client.ListVNets(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []capmox.VNet{
+		{Name: "prod", Zone: "vlanzone", Tag: 100},
+		{Name: "trunk", Zone: "vlanzone", VLANAware: true},
+	}, vnets)
+}
+
+func TestProxmoxAPIClient_GetNodeCPUUsage(t *testing.T) {
+	client := newTestClient(t)
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/test/status`,
+		newJSONResponder(200, proxmox.Node{CPU: 0.25}))
+
+	usage, err := client.GetNodeCPUUsage(context.Background(), "test")
+	require.NoError(t, err)
+	require.InDelta(t, 0.25, usage, 0.0001)
+
+	t.Run("Fail to access endpoint", func(t *testing.T) {
+		client := newTestClient(t)
+		httpmock.RegisterResponder(http.MethodGet, `=~/nodes/test/status`,
+			newJSONResponder(401, "Forbidden"))
+
+		_, err := client.GetNodeCPUUsage(context.Background(), "test")
+		require.Error(t, err)
+		require.Equal(t,
+			"cannot find node with name test: not authorized to access endpoint",
+			err.Error())
+	})
+}
+
 func TestProxmoxAPIClient_CloneVM(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -214,7 +344,7 @@ func TestProxmoxAPIClient_CloneVM(t *testing.T) {
 			httpmock.RegisterResponder(http.MethodGet, `=~/cluster/nextid`,
 				newJSONResponder(test.http[5], "101"))
 
-			clone := capmox.VMCloneRequest{Node: "test"}
+			clone := capmox.VMCloneRequest{Node: "test", Full: 1}
 			cloneresponse, err := client.CloneVM(context.Background(), 100, clone)
 
 			if test.fails {
@@ -229,6 +359,64 @@ func TestProxmoxAPIClient_CloneVM(t *testing.T) {
 	}
 }
 
+func TestProxmoxAPIClient_CloneVM_LinkedCloneRequiresTemplate(t *testing.T) {
+	client := newTestClient(t)
+
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/test/status`,
+		newJSONResponder(200, proxmox.Node{}))
+	// proxmox reports an empty string for regular VMs.
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/test/qemu/100/status/current`,
+		newJSONResponder(200, map[string]any{"node": "test", "template": ""}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/test/qemu/100/config`,
+		newJSONResponder(200, proxmox.VirtualMachineConfig{CPU: "kvm64"}))
+
+	_, err := client.CloneVM(context.Background(), 100, capmox.VMCloneRequest{Node: "test", Full: 0})
+	require.ErrorIs(t, err, ErrLinkedCloneRequiresTemplate)
+}
+
+func TestProxmoxAPIClient_CloneVM_LinkedCloneFromTemplate(t *testing.T) {
+	client := newTestClient(t)
+
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/test/status`,
+		newJSONResponder(200, proxmox.Node{}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/test/qemu/100/status/current`,
+		newJSONResponder(200, proxmox.VirtualMachine{Node: "test", Template: true}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/test/qemu/100/config`,
+		newJSONResponder(200, proxmox.VirtualMachineConfig{CPU: "kvm64"}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/cluster/status`,
+		newJSONResponder(200, proxmox.NodeStatuses{{Name: "test"}}))
+	httpmock.RegisterResponder(http.MethodPost, `=~/nodes/test/qemu/0/clone`,
+		newJSONResponder(200, nil))
+	httpmock.RegisterResponder(http.MethodGet, `=~/cluster/nextid`,
+		newJSONResponder(200, "101"))
+
+	res, err := client.CloneVM(context.Background(), 100, capmox.VMCloneRequest{Node: "test", Full: 0})
+	require.NoError(t, err)
+	require.Equal(t, capmox.VMCloneResponse{NewID: 101, Task: nil}, res)
+}
+
+func TestProxmoxAPIClient_CloneVM_VMIDInUse(t *testing.T) {
+	client := newTestClient(t)
+
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/test/status`,
+		newJSONResponder(200, proxmox.Node{}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/test/qemu/100/status/current`,
+		newJSONResponder(200, proxmox.VirtualMachine{Node: "test", Template: true}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/test/qemu/100/config`,
+		newJSONResponder(200, proxmox.VirtualMachineConfig{CPU: "kvm64"}))
+	httpmock.RegisterResponder(http.MethodPost, `=~/nodes/test/qemu/0/clone`,
+		func(*http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(http.StatusInternalServerError, "")
+			resp.Status = "500 VM 101 already exists on node 'test'"
+			return resp, nil
+		})
+
+	_, err := client.CloneVM(context.Background(), 100, capmox.VMCloneRequest{Node: "test", NewID: 101})
+	require.ErrorIs(t, err, ErrVMIDInUse)
+	require.ErrorContains(t, err, "unable to create new vm 101")
+	require.ErrorContains(t, err, "VM 101 already exists")
+}
+
 func TestProxmoxAPIClient_ConfigureVM(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -371,6 +559,24 @@ func TestProxmoxAPIClient_FindVMResource(t *testing.T) {
 	}
 }
 
+func TestProxmoxAPIClient_ListVMResources(t *testing.T) {
+	client := newTestClient(t)
+
+	httpmock.RegisterResponder(http.MethodGet, `=~/cluster/status`,
+		newJSONResponder(200, proxmox.NodeStatuses{{Name: "test"}}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/cluster/resources`,
+		newJSONResponder(200, proxmox.ClusterResources{
+			&proxmox.ClusterResource{VMID: 100, Name: "ubuntu-2204", Node: "test", Template: 1},
+			&proxmox.ClusterResource{VMID: 101, Name: "worker", Node: "test"},
+		}))
+
+	resources, err := client.ListVMResources(context.Background())
+	require.NoError(t, err)
+	require.Len(t, resources, 2)
+	require.Equal(t, uint64(1), resources[0].Template)
+	require.Equal(t, "worker", resources[1].Name)
+}
+
 func TestProxmoxAPIClient_DeleteVM(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -428,6 +634,38 @@ func TestProxmoxAPIClient_DeleteVM(t *testing.T) {
 	}
 }
 
+func TestProxmoxAPIClient_DeleteVM_Protected(t *testing.T) {
+	client := newTestClient(t)
+
+	upid := "UPID:test:000D6BDA:041E0A54:654A5A1D:qmdestroy:101:root@pam:"
+	httpmock.RegisterResponder(http.MethodGet, `=~/cluster/nextid`,
+		newJSONResponder(400, "VM 101 already exists"))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/test/status`,
+		newJSONResponder(200, proxmox.Node{}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/test/qemu/101/status/current`,
+		newJSONResponder(200, proxmox.VirtualMachine{Node: "test", VMID: 101}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/test/qemu/101/config`,
+		newJSONResponder(200, proxmox.VirtualMachineConfig{Protection: 1}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/cluster/status`,
+		newJSONResponder(200, proxmox.NodeStatuses{{Name: "test"}}))
+
+	var options map[string]any
+	httpmock.RegisterResponder(http.MethodPut, `=~/nodes/test/qemu/101/config`,
+		func(req *http.Request) (*http.Response, error) {
+			if err := json.NewDecoder(req.Body).Decode(&options); err != nil {
+				return nil, err
+			}
+			return httpmock.NewJsonResponse(200, map[string]any{"data": nil})
+		})
+	httpmock.RegisterResponder(http.MethodDelete, `=~/nodes/test/qemu/101`,
+		newJSONResponder(200, upid))
+
+	task, err := client.DeleteVM(context.Background(), "test", 101)
+	require.NoError(t, err)
+	require.Equal(t, "qmdestroy", task.Type)
+	require.Equal(t, float64(0), options["protection"])
+}
+
 func TestProxmoxAPIClient_GetTask(t *testing.T) {
 	// "UPID:$node:$pid:$pstart:$startime:$dtype:$id:$user"
 	upid := "UPID:test:000D6BDA:041E0A54:654A5A1D:qmdestroy:101:root@pam:"
@@ -575,3 +813,182 @@ func TestProxmoxAPIClient_CloudInitStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestProxmoxAPIClient_EnableFirewall(t *testing.T) {
+	client := newTestClient(t)
+
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve/status`,
+		newJSONResponder(200, proxmox.Node{Name: "pve"}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve/qemu/1111/status/current`,
+		newJSONResponder(200, proxmox.VirtualMachine{VMID: 1111, Name: "legit-worker", Node: "pve"}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve/qemu/1111/config`,
+		newJSONResponder(200, proxmox.VirtualMachineConfig{Name: "legit-worker"}))
+
+	vm, err := client.GetVM(context.Background(), "pve", 1111)
+	require.NoError(t, err)
+
+	// already enabled, nothing to update.
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve/qemu/1111/firewall/options`,
+		newJSONResponder(200, proxmox.FirewallVirtualMachineOption{Enable: true, PolicyIn: "DROP"}))
+	require.NoError(t, client.EnableFirewall(context.Background(), vm, "DROP"))
+
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve/qemu/1111/firewall/options`,
+		newJSONResponder(200, proxmox.FirewallVirtualMachineOption{}))
+	httpmock.RegisterResponder(http.MethodPut, `=~/nodes/pve/qemu/1111/firewall/options`,
+		newJSONResponder(200, nil))
+	require.NoError(t, client.EnableFirewall(context.Background(), vm, "DROP"))
+	require.Equal(t, 1, httpmock.GetCallCountInfo()[`PUT =~/nodes/pve/qemu/1111/firewall/options`])
+}
+
+func TestProxmoxAPIClient_GetVMStartup(t *testing.T) {
+	client := newTestClient(t)
+
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve/status`,
+		newJSONResponder(200, proxmox.Node{Name: "pve"}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve/qemu/1111/status/current`,
+		newJSONResponder(200, proxmox.VirtualMachine{VMID: 1111, Name: "legit-worker", Node: "pve"}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve/qemu/1111/config`,
+		newJSONResponder(200, map[string]any{"name": "legit-worker", "onboot": 1, "startup": "order=1,up=30"}))
+
+	vm, err := client.GetVM(context.Background(), "pve", 1111)
+	require.NoError(t, err)
+
+	startup, err := client.GetVMStartup(context.Background(), vm)
+	require.NoError(t, err)
+	require.Equal(t, "order=1,up=30", startup)
+}
+
+func TestProxmoxAPIClient_MigrateVM(t *testing.T) {
+	client := newTestClient(t)
+
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve/status`,
+		newJSONResponder(200, proxmox.Node{Name: "pve"}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve/qemu/1111/status/current`,
+		newJSONResponder(200, proxmox.VirtualMachine{VMID: 1111, Name: "legit-worker", Node: "pve", Status: proxmox.StatusVirtualMachineRunning}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve/qemu/1111/config`,
+		newJSONResponder(200, proxmox.VirtualMachineConfig{Name: "legit-worker"}))
+
+	vm, err := client.GetVM(context.Background(), "pve", 1111)
+	require.NoError(t, err)
+
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve/qemu/1111/migrate`,
+		newJSONResponder(200, map[string]any{"local_disks": []map[string]any{{"volid": "local-lvm:vm-1111-disk-0"}}}))
+
+	var options map[string]any
+	upid := "UPID:pve:000D6BDA:041E0A54:654A5A1D:qmigrate:1111:root@pam:"
+	httpmock.RegisterResponder(http.MethodPost, `=~/nodes/pve/qemu/1111/migrate`,
+		func(req *http.Request) (*http.Response, error) {
+			if err := json.NewDecoder(req.Body).Decode(&options); err != nil {
+				return nil, err
+			}
+			return httpmock.NewJsonResponse(200, map[string]any{"data": upid})
+		})
+
+	task, err := client.MigrateVM(context.Background(), vm, "pve2")
+	require.NoError(t, err)
+	require.Equal(t, "qmigrate", task.Type)
+	require.Equal(t, "pve2", options["target"])
+	require.Equal(t, true, options["online"])
+	require.Equal(t, true, options["with-local-disks"])
+}
+
+func TestProxmoxAPIClient_Snapshots(t *testing.T) {
+	client := newTestClient(t)
+
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve/status`,
+		newJSONResponder(200, proxmox.Node{Name: "pve"}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve/qemu/1111/status/current`,
+		newJSONResponder(200, proxmox.VirtualMachine{VMID: 1111, Name: "legit-worker", Node: "pve"}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve/qemu/1111/config`,
+		newJSONResponder(200, proxmox.VirtualMachineConfig{Name: "legit-worker"}))
+
+	vm, err := client.GetVM(context.Background(), "pve", 1111)
+	require.NoError(t, err)
+
+	var options map[string]any
+	upid := "UPID:pve:000D6BDA:041E0A54:654A5A1D:qmsnapshot:1111:root@pam:"
+	httpmock.RegisterResponder(http.MethodPost, `=~/nodes/pve/qemu/1111/snapshot`,
+		func(req *http.Request) (*http.Response, error) {
+			if err := json.NewDecoder(req.Body).Decode(&options); err != nil {
+				return nil, err
+			}
+			return httpmock.NewJsonResponse(200, map[string]any{"data": upid})
+		})
+
+	task, err := client.CreateSnapshot(context.Background(), vm, "capmox-predelete", "before deletion")
+	require.NoError(t, err)
+	require.Equal(t, "qmsnapshot", task.Type)
+	require.Equal(t, "capmox-predelete", options["snapname"])
+	require.Equal(t, "before deletion", options["description"])
+
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve/qemu/1111/snapshot`,
+		newJSONResponder(200, []*proxmox.Snapshot{{Name: "capmox-predelete"}, {Name: "current"}}))
+
+	exists, err := client.SnapshotExists(context.Background(), vm, "capmox-predelete")
+	require.NoError(t, err)
+	require.True(t, exists)
+}
+
+func TestProxmoxAPIClient_ShutdownVM(t *testing.T) {
+	client := newTestClient(t)
+
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve/status`,
+		newJSONResponder(200, proxmox.Node{Name: "pve"}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve/qemu/1111/status/current`,
+		newJSONResponder(200, proxmox.VirtualMachine{VMID: 1111, Name: "legit-worker", Node: "pve"}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve/qemu/1111/config`,
+		newJSONResponder(200, proxmox.VirtualMachineConfig{Name: "legit-worker"}))
+
+	vm, err := client.GetVM(context.Background(), "pve", 1111)
+	require.NoError(t, err)
+
+	var options map[string]any
+	upid := "UPID:pve:000D6BDA:041E0A54:654A5A1D:qmshutdown:1111:root@pam:"
+	httpmock.RegisterResponder(http.MethodPost, `=~/nodes/pve/qemu/1111/status/shutdown`,
+		func(req *http.Request) (*http.Response, error) {
+			if err := json.NewDecoder(req.Body).Decode(&options); err != nil {
+				return nil, err
+			}
+			return httpmock.NewJsonResponse(200, map[string]any{"data": upid})
+		})
+
+	task, err := client.ShutdownVM(context.Background(), vm, 2*time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, "qmshutdown", task.Type)
+	require.Equal(t, float64(120), options["timeout"])
+}
+
+func TestProxmoxAPIClient_QemuAgentNetworkInterfaces(t *testing.T) {
+	client := newTestClient(t)
+
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve/status`,
+		newJSONResponder(200, proxmox.Node{Name: "pve"}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve/qemu/1111/status/current`,
+		newJSONResponder(200, proxmox.VirtualMachine{VMID: 1111, Name: "legit-worker", Node: "pve"}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve/qemu/1111/config`,
+		newJSONResponder(200, proxmox.VirtualMachineConfig{Name: "legit-worker"}))
+
+	vm, err := client.GetVM(context.Background(), "pve", 1111)
+	require.NoError(t, err)
+
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve/qemu/1111/agent/network-get-interfaces`,
+		newJSONResponder(200, map[string][]*proxmox.AgentNetworkIface{
+			"result": {
+				{Name: "lo", IPAddresses: []*proxmox.AgentNetworkIPAddress{{IPAddressType: "ipv4", IPAddress: "127.0.0.1"}}},
+				{Name: "eth0", IPAddresses: []*proxmox.AgentNetworkIPAddress{{IPAddressType: "ipv4", IPAddress: "10.0.0.5"}}},
+			},
+		}))
+
+	ifaces, err := client.QemuAgentNetworkInterfaces(context.Background(), vm)
+	require.NoError(t, err)
+	require.Len(t, ifaces, 1)
+	require.Equal(t, "eth0", ifaces[0].Name)
+
+	t.Run("agent not running", func(t *testing.T) {
+		httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve/qemu/1111/agent/network-get-interfaces`,
+			newJSONResponder(500, "QEMU guest agent is not running"))
+
+		_, err := client.QemuAgentNetworkInterfaces(context.Background(), vm)
+		require.ErrorContains(t, err, "unable to get network interfaces from agent")
+	})
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2023-2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goproxmox
+
+import (
+	"net/http"
+
+	"github.com/luthermonson/go-proxmox"
+)
+
+// Credentials contains the authentication data used to access the Proxmox API.
+// Either an API token (TokenID and Secret) or a Username and Password must be set.
+type Credentials struct {
+	// TokenID is the API token id in the form user@realm!tokenid.
+	TokenID string
+	// Secret is the secret (uuid) of the API token.
+	Secret string
+
+	// Username is used for ticket based authentication in the form user@realm.
+	Username string
+	// Password is the password of Username.
+	Password string
+}
+
+// IsEmpty returns true if no authentication data is set at all.
+func (c Credentials) IsEmpty() bool {
+	return c.TokenID == "" && c.Secret == "" && c.Username == "" && c.Password == ""
+}
+
+// Validate returns an error unless exactly one authentication method is set completely.
+func (c Credentials) Validate() error {
+	hasToken := c.TokenID != "" || c.Secret != ""
+	hasLogin := c.Username != "" || c.Password != ""
+
+	switch {
+	case hasToken && hasLogin:
+		return ErrAmbiguousCredentials
+	case hasToken:
+		if c.TokenID == "" || c.Secret == "" {
+			return ErrIncompleteCredentials
+		}
+	case hasLogin:
+		if c.Username == "" || c.Password == "" {
+			return ErrIncompleteCredentials
+		}
+	default:
+		return ErrMissingCredentials
+	}
+	return nil
+}
+
+// Options validates the credentials and returns the go-proxmox options configuring httpClient
+// with the matching authentication method. Tickets of a username and password are requested and
+// renewed by the transport of the client.
+func (c Credentials) Options(baseURL string, httpClient *http.Client) ([]proxmox.Option, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	if c.TokenID != "" {
+		return []proxmox.Option{proxmox.WithHTTPClient(httpClient), proxmox.WithAPIToken(c.TokenID, c.Secret)}, nil
+	}
+
+	transport, err := newTicketTransport(baseURL, c.Username, c.Password, httpClient.Transport)
+	if err != nil {
+		return nil, err
+	}
+	ticketClient := *httpClient
+	ticketClient.Transport = transport
+	return []proxmox.Option{proxmox.WithHTTPClient(&ticketClient)}, nil
+}
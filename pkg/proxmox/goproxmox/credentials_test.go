@@ -0,0 +1,59 @@
+/*
+Copyright 2023-2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goproxmox
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredentials_Options(t *testing.T) {
+	tests := []struct {
+		name  string
+		creds Credentials
+		err   error
+	}{
+		{"token", Credentials{TokenID: "root@pam!capmox", Secret: "uuid"}, nil},
+		{"username and password", Credentials{Username: "root@pam", Password: "secret"}, nil},
+		{"empty", Credentials{}, ErrMissingCredentials},
+		{"both", Credentials{TokenID: "root@pam!capmox", Secret: "uuid", Username: "root@pam", Password: "secret"}, ErrAmbiguousCredentials},
+		{"token without secret", Credentials{TokenID: "root@pam!capmox"}, ErrIncompleteCredentials},
+		{"username without password", Credentials{Username: "root@pam"}, ErrIncompleteCredentials},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.ErrorIs(t, test.creds.Validate(), test.err)
+
+			opts, err := test.creds.Options("https://pve.local.test:8006", &http.Client{})
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				require.Nil(t, opts)
+				return
+			}
+			require.NoError(t, err)
+			require.NotEmpty(t, opts)
+		})
+	}
+}
+
+func TestCredentials_IsEmpty(t *testing.T) {
+	require.True(t, Credentials{}.IsEmpty())
+	require.False(t, Credentials{Username: "root@pam"}.IsEmpty())
+}
@@ -5,4 +5,19 @@ import "github.com/pkg/errors"
 var (
 	// ErrCloudInitFailed is returned when cloud-init failed execution.
 	ErrCloudInitFailed = errors.New("cloud-init failed execution")
+
+	// ErrLinkedCloneRequiresTemplate is returned when a linked clone is requested from a VM which is not a template.
+	ErrLinkedCloneRequiresTemplate = errors.New("linked clones require the source vm to be a template")
+
+	// ErrVMIDInUse is returned when a VM is cloned to a VMID which another VM took in the meantime.
+	ErrVMIDInUse = errors.New("vmid is already in use")
+
+	// ErrMissingCredentials is returned when neither an API token nor a username/password is configured.
+	ErrMissingCredentials = errors.New("no proxmox credentials configured, either token/secret or username/password is required")
+
+	// ErrAmbiguousCredentials is returned when both an API token and a username/password are configured.
+	ErrAmbiguousCredentials = errors.New("both proxmox token and username/password configured, only one authentication method is allowed")
+
+	// ErrIncompleteCredentials is returned when only one half of a credential pair is configured.
+	ErrIncompleteCredentials = errors.New("incomplete proxmox credentials, token requires secret and username requires password")
 )
@@ -0,0 +1,69 @@
+/*
+Copyright 2023-2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goproxmox
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	retriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "capmox_proxmox_api_retries_total",
+		Help: "Number of retried requests to the Proxmox API, by method and reason.",
+	}, []string{"method", "reason"})
+
+	// requests are not labelled by path, since it contains VM ids and task UPIDs.
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "capmox_proxmox_api_request_duration_seconds",
+		Help:    "Latency of requests to the Proxmox API, by method and status code. Failed connections have code \"error\".",
+		Buckets: prometheus.ExponentialBuckets(0.01, 2, 12),
+	}, []string{"method", "code"})
+
+	rateLimitWait = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "capmox_proxmox_api_rate_limit_wait_seconds",
+		Help:    "Time requests to the Proxmox API waited for the client side rate limit.",
+		Buckets: prometheus.ExponentialBuckets(0.001, 4, 10),
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(retriesTotal, requestDuration, rateLimitWait)
+}
+
+// metricsTransport records the latency and status of every request.
+type metricsTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	res, err := t.next.RoundTrip(req)
+
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(res.StatusCode)
+	}
+	requestDuration.WithLabelValues(req.Method, code).Observe(time.Since(start).Seconds())
+
+	return res, err
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2023-2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goproxmox
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// RateLimitOptions limits the rate of requests to the Proxmox API.
+type RateLimitOptions struct {
+	// QPS is the number of requests per second of all clients. Zero disables the limit.
+	QPS float32
+	// Burst is the number of requests which are sent at once, before the limit applies.
+	Burst int
+	// NodeQPS is the number of requests per second to each node, in addition to QPS. Zero disables the limit.
+	NodeQPS float32
+	// NodeBurst is the number of requests which are sent at once to a node, before the node limit applies.
+	NodeBurst int
+}
+
+// DefaultRateLimitOptions are the rate limit options shared by the transports created by NewRetryTransport.
+// They are meant to be set once, while the manager starts.
+var DefaultRateLimitOptions = RateLimitOptions{
+	QPS:   20,
+	Burst: 40,
+}
+
+// defaultRateLimiter is the rate limiter of all transports created by NewRetryTransport,
+// so the limit holds regardless of the number of clients.
+var defaultRateLimiter = sync.OnceValue(func() *rateLimiter {
+	return newRateLimiter(DefaultRateLimitOptions)
+})
+
+// rateLimiter limits the rate of requests globally, and per node.
+type rateLimiter struct {
+	options RateLimitOptions
+	global  flowcontrol.RateLimiter
+
+	mu    sync.Mutex
+	nodes map[string]flowcontrol.RateLimiter
+}
+
+func newRateLimiter(options RateLimitOptions) *rateLimiter {
+	l := &rateLimiter{options: options, nodes: make(map[string]flowcontrol.RateLimiter)}
+	if options.QPS > 0 {
+		l.global = flowcontrol.NewTokenBucketRateLimiter(options.QPS, max(options.Burst, 1))
+	}
+	return l
+}
+
+// wait blocks until a request to the node is within the limits, or the context is done.
+// An empty node only waits for the global limit.
+func (l *rateLimiter) wait(ctx context.Context, node string) error {
+	start := time.Now()
+	defer func() { rateLimitWait.Observe(time.Since(start).Seconds()) }()
+
+	if l.global != nil {
+		if err := l.global.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	if limiter := l.node(node); limiter != nil {
+		return limiter.Wait(ctx)
+	}
+	return nil
+}
+
+// node returns the rate limiter of the node, or nil if requests to nodes are not limited.
+func (l *rateLimiter) node(node string) flowcontrol.RateLimiter {
+	if node == "" || l.options.NodeQPS <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limiter, ok := l.nodes[node]
+	if !ok {
+		limiter = flowcontrol.NewTokenBucketRateLimiter(l.options.NodeQPS, max(l.options.NodeBurst, 1))
+		l.nodes[node] = limiter
+	}
+	return limiter
+}
+
+// rateLimitTransport delays requests which exceed the rate limit, until they are within it again.
+type rateLimitTransport struct {
+	next    http.RoundTripper
+	limiter *rateLimiter
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	node := requestNode(req)
+	if node != "" {
+		// nodes of different Proxmox clusters may have the same name.
+		node = req.URL.Host + "/" + node
+	}
+	if err := t.limiter.wait(req.Context(), node); err != nil {
+		return nil, err
+	}
+	return t.next.RoundTrip(req)
+}
+
+// requestNode returns the node in the path of the request, like /api2/json/nodes/pve/qemu/100,
+// or an empty string for requests which are not sent to a node.
+func requestNode(req *http.Request) string {
+	_, rest, ok := strings.Cut(req.URL.Path, "/nodes/")
+	if !ok {
+		return ""
+	}
+	node, _, _ := strings.Cut(rest, "/")
+	return node
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2023-2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goproxmox
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRequest(t *testing.T, path string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://pve.local.test/api2/json"+path, nil)
+	require.NoError(t, err)
+	return req
+}
+
+func TestRateLimitTransport_DelaysRequests(t *testing.T) {
+	var calls int
+	transport := &rateLimitTransport{next: newFlakyTransport(&calls), limiter: newRateLimiter(RateLimitOptions{QPS: 1, Burst: 1})}
+
+	_, err := transport.RoundTrip(newTestRequest(t, "/version"))
+	require.NoError(t, err)
+
+	// the burst is used up, so the next request would wait for about a second.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = transport.RoundTrip(newTestRequest(t, "/version").WithContext(ctx))
+	require.Error(t, err)
+	require.Equal(t, 1, calls)
+}
+
+func TestRateLimitTransport_LimitsNodes(t *testing.T) {
+	var calls int
+	transport := &rateLimitTransport{next: newFlakyTransport(&calls), limiter: newRateLimiter(RateLimitOptions{NodeQPS: 1, NodeBurst: 1})}
+
+	_, err := transport.RoundTrip(newTestRequest(t, "/nodes/pve1/qemu/100/status/current"))
+	require.NoError(t, err)
+	// other nodes and cluster wide requests have their own limits.
+	_, err = transport.RoundTrip(newTestRequest(t, "/nodes/pve2/qemu/101/status/current"))
+	require.NoError(t, err)
+	_, err = transport.RoundTrip(newTestRequest(t, "/cluster/resources"))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = transport.RoundTrip(newTestRequest(t, "/nodes/pve1/qemu/100/config").WithContext(ctx))
+	require.Error(t, err)
+	require.Equal(t, 3, calls)
+}
+
+func TestRequestNode(t *testing.T) {
+	require.Equal(t, "pve", requestNode(newTestRequest(t, "/nodes/pve/qemu/100")))
+	require.Equal(t, "pve", requestNode(newTestRequest(t, "/nodes/pve")))
+	require.Empty(t, requestNode(newTestRequest(t, "/cluster/resources")))
+}
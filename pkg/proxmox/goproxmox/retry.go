@@ -0,0 +1,135 @@
+/*
+Copyright 2023-2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goproxmox
+
+import (
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryOptions configures the retries of requests to the Proxmox API.
+type RetryOptions struct {
+	// MaxRetries is the number of times a request is retried. Zero disables retries.
+	MaxRetries int
+	// InitialBackoff is the time to wait before the first retry. It doubles with every retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the time to wait between retries.
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryOptions are the retry options of the transports created by NewRetryTransport.
+// They are meant to be set once, while the manager starts.
+var DefaultRetryOptions = RetryOptions{
+	MaxRetries:     3,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     10 * time.Second,
+}
+
+// transientStatusCodes are the status codes of errors which are expected to go away by themselves.
+// 595 and 596 are returned by pveproxy when it cannot reach the node a request is proxied to.
+// 500 is not part of it, since Proxmox uses it for permanent errors too, e.g. for a VM which does not exist.
+var transientStatusCodes = map[int]bool{
+	http.StatusTooManyRequests:    true,
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+	595:                           true,
+	596:                           true,
+}
+
+// retryTransport retries idempotent requests which failed with a transient error.
+type retryTransport struct {
+	next    http.RoundTripper
+	options RetryOptions
+}
+
+// NewRetryTransport wraps next, so idempotent requests are retried with an exponential backoff
+// on network errors and transient status codes. Other requests, like creating a VM, are never retried.
+// Every attempt counts towards the rate limit of DefaultRateLimitOptions, which all transports share,
+// and is recorded in the request metrics.
+func NewRetryTransport(next http.RoundTripper) http.RoundTripper {
+	return &retryTransport{
+		next:    &rateLimitTransport{next: &metricsTransport{next: next}, limiter: defaultRateLimiter()},
+		options: DefaultRetryOptions,
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isIdempotent(req.Method) {
+		return t.next.RoundTrip(req)
+	}
+
+	for attempt := 0; ; attempt++ {
+		res, err := t.next.RoundTrip(req)
+		reason, retry := retryReason(req, res, err)
+		if !retry || attempt >= t.options.MaxRetries {
+			return res, err
+		}
+
+		if res != nil {
+			_, _ = io.Copy(io.Discard, res.Body)
+			_ = res.Body.Close()
+		}
+		retriesTotal.WithLabelValues(req.Method, reason).Inc()
+
+		timer := time.NewTimer(t.options.backoff(attempt))
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the time to wait before the retry following the given attempt.
+// Half of it is random, so many clients failing at once do not retry at once.
+func (o RetryOptions) backoff(attempt int) time.Duration {
+	d := o.InitialBackoff << attempt
+	if d <= 0 || d > o.MaxBackoff {
+		d = o.MaxBackoff
+	}
+	if d <= 1 {
+		return d
+	}
+	return d/2 + rand.N(d/2)
+}
+
+// retryReason returns whether the outcome of a request is worth a retry, and why.
+func retryReason(req *http.Request, res *http.Response, err error) (string, bool) {
+	if err != nil {
+		// a cancelled request is not retried.
+		return "error", req.Context().Err() == nil
+	}
+	if transientStatusCodes[res.StatusCode] {
+		return strconv.Itoa(res.StatusCode), true
+	}
+	return "", false
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2023-2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goproxmox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// newFlakyTransport returns a transport which answers with the given status codes in order,
+// and with 200 afterwards.
+func newFlakyTransport(calls *int, statusCodes ...int) http.RoundTripper {
+	return roundTripFunc(func(_ *http.Request) (*http.Response, error) {
+		*calls++
+		status := http.StatusOK
+		if *calls <= len(statusCodes) {
+			status = statusCodes[*calls-1]
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(""))}, nil
+	})
+}
+
+func newTestRetryTransport(next http.RoundTripper) *retryTransport {
+	return &retryTransport{next: next, options: RetryOptions{MaxRetries: 3, InitialBackoff: time.Millisecond, MaxBackoff: 4 * time.Millisecond}}
+}
+
+func TestRetryTransport_RetriesTransientErrors(t *testing.T) {
+	var calls int
+	transport := newTestRetryTransport(newFlakyTransport(&calls, 596, http.StatusServiceUnavailable))
+	before := testutil.ToFloat64(retriesTotal.WithLabelValues(http.MethodGet, "596"))
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://pve.local.test/api2/json/version", nil)
+	require.NoError(t, err)
+
+	res, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, 3, calls)
+	require.Equal(t, before+1, testutil.ToFloat64(retriesTotal.WithLabelValues(http.MethodGet, "596")))
+}
+
+func TestRetryTransport_GivesUp(t *testing.T) {
+	var calls int
+	transport := newTestRetryTransport(newFlakyTransport(&calls, 595, 595, 595, 595, 595))
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://pve.local.test/api2/json/version", nil)
+	require.NoError(t, err)
+
+	res, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	require.Equal(t, 595, res.StatusCode)
+	require.Equal(t, 4, calls)
+}
+
+func TestRetryTransport_DoesNotRetryPermanentErrors(t *testing.T) {
+	var calls int
+	transport := newTestRetryTransport(newFlakyTransport(&calls, http.StatusInternalServerError))
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://pve.local.test/api2/json/nodes/pve/qemu/100/config", nil)
+	require.NoError(t, err)
+
+	res, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	require.Equal(t, 1, calls)
+}
+
+func TestRetryTransport_DoesNotRetryNonIdempotentRequests(t *testing.T) {
+	var calls int
+	transport := newTestRetryTransport(newFlakyTransport(&calls, 596))
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "http://pve.local.test/api2/json/nodes/pve/qemu/100/clone", nil)
+	require.NoError(t, err)
+
+	res, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	require.Equal(t, 596, res.StatusCode)
+	require.Equal(t, 1, calls)
+}
+
+func TestRetryTransport_HonorsContextCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int
+	transport := &retryTransport{
+		next: roundTripFunc(func(_ *http.Request) (*http.Response, error) {
+			calls++
+			cancel()
+			return nil, errors.New("connection reset by peer")
+		}),
+		options: RetryOptions{MaxRetries: 3, InitialBackoff: time.Hour, MaxBackoff: time.Hour},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://pve.local.test/api2/json/version", nil)
+	require.NoError(t, err)
+
+	_, err = transport.RoundTrip(req)
+	require.Error(t, err)
+	require.Equal(t, 1, calls)
+}
+
+func TestRetryOptions_Backoff(t *testing.T) {
+	options := RetryOptions{InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}
+
+	for attempt, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second} {
+		d := options.backoff(attempt)
+		require.GreaterOrEqual(t, d, want/2)
+		require.Less(t, d, want)
+	}
+}
+
+func TestNewRetryTransport_RecordsRequests(t *testing.T) {
+	var calls int
+	transport := NewRetryTransport(newFlakyTransport(&calls))
+	before := testutil.CollectAndCount(requestDuration)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodDelete, "http://pve.local.test/api2/json/nodes/pve/qemu/100", nil)
+	require.NoError(t, err)
+
+	_, err = transport.RoundTrip(req)
+	require.NoError(t, err)
+	require.Equal(t, before+1, testutil.CollectAndCount(requestDuration))
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2023-2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goproxmox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/luthermonson/go-proxmox"
+	"github.com/pkg/errors"
+)
+
+// ticketRenewal is the age after which a ticket is renewed. Proxmox tickets expire after two hours.
+const ticketRenewal = time.Hour
+
+// ticketTransport authenticates requests with a ticket obtained from a username and password.
+// go-proxmox logs in only once and keeps sending the ticket after it expired, so the transport manages
+// the ticket instead. It is renewed once it gets old, and once the Proxmox API rejects it.
+type ticketTransport struct {
+	next      http.RoundTripper
+	ticketURL string
+	username  string
+	password  string
+	now       func() time.Time
+
+	mu      sync.Mutex
+	session *proxmox.Session
+	issued  time.Time
+}
+
+func newTicketTransport(baseURL, username, password string, next http.RoundTripper) (*ticketTransport, error) {
+	ticketURL, err := url.JoinPath(baseURL, "api2", "json", "access", "ticket")
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxmox base URL %q: %w", baseURL, err)
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &ticketTransport{
+		next:      next,
+		ticketURL: ticketURL,
+		username:  username,
+		password:  password,
+		now:       time.Now,
+	}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *ticketTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	session, err := t.ticket(req.Context(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := t.next.RoundTrip(withTicket(req, session))
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+
+	// the ticket was rejected, e.g. since it expired early. The request is sent again with a new
+	// ticket, unless its body cannot be read again.
+	if req.Body != nil && req.GetBody == nil {
+		return res, nil
+	}
+	_, _ = io.Copy(io.Discard, res.Body)
+	_ = res.Body.Close()
+
+	if session, err = t.ticket(req.Context(), session); err != nil {
+		return nil, err
+	}
+	retry := withTicket(req, session)
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	return t.next.RoundTrip(retry)
+}
+
+// ticket returns the current ticket. A new ticket is requested if there is none yet, the current one is due
+// for renewal, or it is the rejected one. Requests which were rejected at once only log in once.
+func (t *ticketTransport) ticket(ctx context.Context, rejected *proxmox.Session) (*proxmox.Session, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.session != nil && t.session != rejected && t.now().Sub(t.issued) < ticketRenewal {
+		return t.session, nil
+	}
+
+	session, err := t.login(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t.session, t.issued = session, t.now()
+	return session, nil
+}
+
+// login requests a new ticket.
+func (t *ticketTransport) login(ctx context.Context) (*proxmox.Session, error) {
+	body, err := json.Marshal(proxmox.Credentials{Username: t.username, Password: t.password})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.ticketURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to log in to the proxmox api")
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusUnauthorized:
+		return nil, proxmox.ErrNotAuthorized
+	case res.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("unable to log in to the proxmox api: %s", res.Status)
+	}
+
+	var data struct {
+		Data *proxmox.Session `json:"data"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&data); err != nil {
+		return nil, errors.Wrap(err, "unable to decode proxmox ticket")
+	}
+	if data.Data == nil || data.Data.Ticket == "" {
+		return nil, errors.New("proxmox api returned no ticket")
+	}
+	return data.Data, nil
+}
+
+// withTicket returns a copy of req authenticated with the ticket of session.
+func withTicket(req *http.Request, session *proxmox.Session) *http.Request {
+	req = req.Clone(req.Context())
+	req.Header.Set("Cookie", "PVEAuthCookie="+session.Ticket)
+	req.Header.Set("CSRFPreventionToken", session.CSRFPreventionToken)
+	return req
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2023-2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goproxmox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/luthermonson/go-proxmox"
+	"github.com/stretchr/testify/require"
+)
+
+// ticketServer is a Proxmox API which hands out tickets, and only accepts the latest one.
+type ticketServer struct {
+	mu      sync.Mutex
+	logins  int
+	ticket  string
+	expired bool
+}
+
+func (s *ticketServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path == "/api2/json/access/ticket" {
+		var creds proxmox.Credentials
+		if err := json.NewDecoder(r.Body).Decode(&creds); err != nil || creds.Password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		s.logins++
+		s.ticket, s.expired = fmt.Sprintf("PVE:root@pam:%d", s.logins), false
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"data": proxmox.Session{Username: creds.Username, Ticket: s.ticket, CSRFPreventionToken: "csrf"},
+		})
+		return
+	}
+
+	if s.expired || r.Header.Get("Cookie") != "PVEAuthCookie="+s.ticket || r.Header.Get("CSRFPreventionToken") != "csrf" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"release": "8.1"}})
+}
+
+func (s *ticketServer) expire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expired = true
+}
+
+func newTicketClient(t *testing.T, server *httptest.Server, password string) (*APIClient, error) {
+	t.Helper()
+	opts, err := Credentials{Username: "root@pam", Password: password}.Options(server.URL, server.Client())
+	require.NoError(t, err)
+	return NewAPIClient(context.Background(), logr.Discard(), server.URL, opts...)
+}
+
+func TestTicketTransport_RenewsExpiredTicket(t *testing.T) {
+	pve := &ticketServer{}
+	server := httptest.NewServer(pve)
+	defer server.Close()
+
+	client, err := newTicketClient(t, server, "secret")
+	require.NoError(t, err)
+	require.Equal(t, 1, pve.logins)
+
+	_, err = client.Version(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, pve.logins)
+
+	// the ticket expired, so the client logs in again and repeats the request.
+	pve.expire()
+	_, err = client.Version(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, pve.logins)
+}
+
+func TestTicketTransport_RenewsOldTicket(t *testing.T) {
+	pve := &ticketServer{}
+	server := httptest.NewServer(pve)
+	defer server.Close()
+
+	transport, err := newTicketTransport(server.URL, "root@pam", "secret", server.Client().Transport)
+	require.NoError(t, err)
+	now := time.Now()
+	transport.now = func() time.Time { return now }
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL+"/api2/json/version", nil)
+	require.NoError(t, err)
+	res, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	now = now.Add(ticketRenewal)
+	res, err = transport.RoundTrip(req)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, 2, pve.logins)
+}
+
+func TestTicketTransport_InvalidPassword(t *testing.T) {
+	server := httptest.NewServer(&ticketServer{})
+	defer server.Close()
+
+	_, err := newTicketClient(t, server, "wrong")
+	require.ErrorIs(t, err, proxmox.ErrNotAuthorized)
+}
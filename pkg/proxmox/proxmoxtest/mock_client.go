@@ -5,6 +5,8 @@ package proxmoxtest
 import (
 	context "context"
 
+	time "time"
+
 	go_proxmox "github.com/luthermonson/go-proxmox"
 	mock "github.com/stretchr/testify/mock"
 
@@ -24,6 +26,50 @@ func (_m *MockClient) EXPECT() *MockClient_Expecter {
 	return &MockClient_Expecter{mock: &_m.Mock}
 }
 
+// AddFirewallRule provides a mock function with given fields: ctx, vm, rule
+func (_m *MockClient) AddFirewallRule(ctx context.Context, vm *go_proxmox.VirtualMachine, rule *go_proxmox.FirewallRule) error {
+	ret := _m.Called(ctx, vm, rule)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, *go_proxmox.FirewallRule) error); ok {
+		r0 = rf(ctx, vm, rule)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockClient_AddFirewallRule_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddFirewallRule'
+type MockClient_AddFirewallRule_Call struct {
+	*mock.Call
+}
+
+// AddFirewallRule is a helper method to define mock.On call
+//   - ctx context.Context
+//   - vm *go_proxmox.VirtualMachine
+//   - rule *go_proxmox.FirewallRule
+func (_e *MockClient_Expecter) AddFirewallRule(ctx interface{}, vm interface{}, rule interface{}) *MockClient_AddFirewallRule_Call {
+	return &MockClient_AddFirewallRule_Call{Call: _e.mock.On("AddFirewallRule", ctx, vm, rule)}
+}
+
+func (_c *MockClient_AddFirewallRule_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine, rule *go_proxmox.FirewallRule)) *MockClient_AddFirewallRule_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine), args[2].(*go_proxmox.FirewallRule))
+	})
+	return _c
+}
+
+func (_c *MockClient_AddFirewallRule_Call) Return(_a0 error) *MockClient_AddFirewallRule_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockClient_AddFirewallRule_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine, *go_proxmox.FirewallRule) error) *MockClient_AddFirewallRule_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // CheckID provides a mock function with given fields: ctx, vmID
 func (_m *MockClient) CheckID(ctx context.Context, vmID int64) (bool, error) {
 	ret := _m.Called(ctx, vmID)
@@ -254,6 +300,107 @@ func (_c *MockClient_ConfigureVM_Call) RunAndReturn(run func(context.Context, *g
 	return _c
 }
 
+// CreateSnapshot provides a mock function with given fields: ctx, vm, name, description
+func (_m *MockClient) CreateSnapshot(ctx context.Context, vm *go_proxmox.VirtualMachine, name string, description string) (*go_proxmox.Task, error) {
+	ret := _m.Called(ctx, vm, name, description)
+
+	var r0 *go_proxmox.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, string, string) (*go_proxmox.Task, error)); ok {
+		return rf(ctx, vm, name, description)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, string, string) *go_proxmox.Task); ok {
+		r0 = rf(ctx, vm, name, description)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*go_proxmox.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine, string, string) error); ok {
+		r1 = rf(ctx, vm, name, description)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_CreateSnapshot_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateSnapshot'
+type MockClient_CreateSnapshot_Call struct {
+	*mock.Call
+}
+
+// CreateSnapshot is a helper method to define mock.On call
+//   - ctx context.Context
+//   - vm *go_proxmox.VirtualMachine
+//   - name string
+//   - description string
+func (_e *MockClient_Expecter) CreateSnapshot(ctx interface{}, vm interface{}, name interface{}, description interface{}) *MockClient_CreateSnapshot_Call {
+	return &MockClient_CreateSnapshot_Call{Call: _e.mock.On("CreateSnapshot", ctx, vm, name, description)}
+}
+
+func (_c *MockClient_CreateSnapshot_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine, name string, description string)) *MockClient_CreateSnapshot_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine), args[2].(string), args[3].(string))
+	})
+	return _c
+}
+
+func (_c *MockClient_CreateSnapshot_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_CreateSnapshot_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_CreateSnapshot_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine, string, string) (*go_proxmox.Task, error)) *MockClient_CreateSnapshot_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteFirewallRule provides a mock function with given fields: ctx, vm, pos
+func (_m *MockClient) DeleteFirewallRule(ctx context.Context, vm *go_proxmox.VirtualMachine, pos int) error {
+	ret := _m.Called(ctx, vm, pos)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, int) error); ok {
+		r0 = rf(ctx, vm, pos)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockClient_DeleteFirewallRule_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteFirewallRule'
+type MockClient_DeleteFirewallRule_Call struct {
+	*mock.Call
+}
+
+// DeleteFirewallRule is a helper method to define mock.On call
+//   - ctx context.Context
+//   - vm *go_proxmox.VirtualMachine
+//   - pos int
+func (_e *MockClient_Expecter) DeleteFirewallRule(ctx interface{}, vm interface{}, pos interface{}) *MockClient_DeleteFirewallRule_Call {
+	return &MockClient_DeleteFirewallRule_Call{Call: _e.mock.On("DeleteFirewallRule", ctx, vm, pos)}
+}
+
+func (_c *MockClient_DeleteFirewallRule_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine, pos int)) *MockClient_DeleteFirewallRule_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine), args[2].(int))
+	})
+	return _c
+}
+
+func (_c *MockClient_DeleteFirewallRule_Call) Return(_a0 error) *MockClient_DeleteFirewallRule_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockClient_DeleteFirewallRule_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine, int) error) *MockClient_DeleteFirewallRule_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // DeleteVM provides a mock function with given fields: ctx, nodeName, vmID
 func (_m *MockClient) DeleteVM(ctx context.Context, nodeName string, vmID int64) (*go_proxmox.Task, error) {
 	ret := _m.Called(ctx, nodeName, vmID)
@@ -310,6 +457,50 @@ func (_c *MockClient_DeleteVM_Call) RunAndReturn(run func(context.Context, strin
 	return _c
 }
 
+// EnableFirewall provides a mock function with given fields: ctx, vm, policyIn
+func (_m *MockClient) EnableFirewall(ctx context.Context, vm *go_proxmox.VirtualMachine, policyIn string) error {
+	ret := _m.Called(ctx, vm, policyIn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, string) error); ok {
+		r0 = rf(ctx, vm, policyIn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockClient_EnableFirewall_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'EnableFirewall'
+type MockClient_EnableFirewall_Call struct {
+	*mock.Call
+}
+
+// EnableFirewall is a helper method to define mock.On call
+//   - ctx context.Context
+//   - vm *go_proxmox.VirtualMachine
+//   - policyIn string
+func (_e *MockClient_Expecter) EnableFirewall(ctx interface{}, vm interface{}, policyIn interface{}) *MockClient_EnableFirewall_Call {
+	return &MockClient_EnableFirewall_Call{Call: _e.mock.On("EnableFirewall", ctx, vm, policyIn)}
+}
+
+func (_c *MockClient_EnableFirewall_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine, policyIn string)) *MockClient_EnableFirewall_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockClient_EnableFirewall_Call) Return(_a0 error) *MockClient_EnableFirewall_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockClient_EnableFirewall_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine, string) error) *MockClient_EnableFirewall_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // FindVMResource provides a mock function with given fields: ctx, vmID
 func (_m *MockClient) FindVMResource(ctx context.Context, vmID uint64) (*go_proxmox.ClusterResource, error) {
 	ret := _m.Called(ctx, vmID)
@@ -365,6 +556,114 @@ func (_c *MockClient_FindVMResource_Call) RunAndReturn(run func(context.Context,
 	return _c
 }
 
+// GetFirewallRules provides a mock function with given fields: ctx, vm
+func (_m *MockClient) GetFirewallRules(ctx context.Context, vm *go_proxmox.VirtualMachine) ([]*go_proxmox.FirewallRule, error) {
+	ret := _m.Called(ctx, vm)
+
+	var r0 []*go_proxmox.FirewallRule
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) ([]*go_proxmox.FirewallRule, error)); ok {
+		return rf(ctx, vm)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) []*go_proxmox.FirewallRule); ok {
+		r0 = rf(ctx, vm)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*go_proxmox.FirewallRule)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine) error); ok {
+		r1 = rf(ctx, vm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_GetFirewallRules_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetFirewallRules'
+type MockClient_GetFirewallRules_Call struct {
+	*mock.Call
+}
+
+// GetFirewallRules is a helper method to define mock.On call
+//   - ctx context.Context
+//   - vm *go_proxmox.VirtualMachine
+func (_e *MockClient_Expecter) GetFirewallRules(ctx interface{}, vm interface{}) *MockClient_GetFirewallRules_Call {
+	return &MockClient_GetFirewallRules_Call{Call: _e.mock.On("GetFirewallRules", ctx, vm)}
+}
+
+func (_c *MockClient_GetFirewallRules_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine)) *MockClient_GetFirewallRules_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine))
+	})
+	return _c
+}
+
+func (_c *MockClient_GetFirewallRules_Call) Return(_a0 []*go_proxmox.FirewallRule, _a1 error) *MockClient_GetFirewallRules_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_GetFirewallRules_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine) ([]*go_proxmox.FirewallRule, error)) *MockClient_GetFirewallRules_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetNodeCPUUsage provides a mock function with given fields: ctx, nodeName
+func (_m *MockClient) GetNodeCPUUsage(ctx context.Context, nodeName string) (float64, error) {
+	ret := _m.Called(ctx, nodeName)
+
+	var r0 float64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (float64, error)); ok {
+		return rf(ctx, nodeName)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) float64); ok {
+		r0 = rf(ctx, nodeName)
+	} else {
+		r0 = ret.Get(0).(float64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, nodeName)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_GetNodeCPUUsage_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetNodeCPUUsage'
+type MockClient_GetNodeCPUUsage_Call struct {
+	*mock.Call
+}
+
+// GetNodeCPUUsage is a helper method to define mock.On call
+//   - ctx context.Context
+//   - nodeName string
+func (_e *MockClient_Expecter) GetNodeCPUUsage(ctx interface{}, nodeName interface{}) *MockClient_GetNodeCPUUsage_Call {
+	return &MockClient_GetNodeCPUUsage_Call{Call: _e.mock.On("GetNodeCPUUsage", ctx, nodeName)}
+}
+
+func (_c *MockClient_GetNodeCPUUsage_Call) Run(run func(ctx context.Context, nodeName string)) *MockClient_GetNodeCPUUsage_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockClient_GetNodeCPUUsage_Call) Return(_a0 float64, _a1 error) *MockClient_GetNodeCPUUsage_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_GetNodeCPUUsage_Call) RunAndReturn(run func(context.Context, string) (float64, error)) *MockClient_GetNodeCPUUsage_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetReservableMemoryBytes provides a mock function with given fields: ctx, nodeName, nodeMemoryAdjustment
 func (_m *MockClient) GetReservableMemoryBytes(ctx context.Context, nodeName string, nodeMemoryAdjustment uint64) (uint64, error) {
 	ret := _m.Called(ctx, nodeName, nodeMemoryAdjustment)
@@ -474,6 +773,62 @@ func (_c *MockClient_GetTask_Call) RunAndReturn(run func(context.Context, string
 	return _c
 }
 
+// GetTaskLog provides a mock function with given fields: ctx, upID, lines
+func (_m *MockClient) GetTaskLog(ctx context.Context, upID string, lines int) ([]string, error) {
+	ret := _m.Called(ctx, upID, lines)
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) ([]string, error)); ok {
+		return rf(ctx, upID, lines)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) []string); ok {
+		r0 = rf(ctx, upID, lines)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, upID, lines)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_GetTaskLog_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTaskLog'
+type MockClient_GetTaskLog_Call struct {
+	*mock.Call
+}
+
+// GetTaskLog is a helper method to define mock.On call
+//   - ctx context.Context
+//   - upID string
+//   - lines int
+func (_e *MockClient_Expecter) GetTaskLog(ctx interface{}, upID interface{}, lines interface{}) *MockClient_GetTaskLog_Call {
+	return &MockClient_GetTaskLog_Call{Call: _e.mock.On("GetTaskLog", ctx, upID, lines)}
+}
+
+func (_c *MockClient_GetTaskLog_Call) Run(run func(ctx context.Context, upID string, lines int)) *MockClient_GetTaskLog_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(int))
+	})
+	return _c
+}
+
+func (_c *MockClient_GetTaskLog_Call) Return(_a0 []string, _a1 error) *MockClient_GetTaskLog_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_GetTaskLog_Call) RunAndReturn(run func(context.Context, string, int) ([]string, error)) *MockClient_GetTaskLog_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetVM provides a mock function with given fields: ctx, nodeName, vmID
 func (_m *MockClient) GetVM(ctx context.Context, nodeName string, vmID int64) (*go_proxmox.VirtualMachine, error) {
 	ret := _m.Called(ctx, nodeName, vmID)
@@ -530,21 +885,554 @@ func (_c *MockClient_GetVM_Call) RunAndReturn(run func(context.Context, string,
 	return _c
 }
 
-// QemuAgentStatus provides a mock function with given fields: ctx, vm
-func (_m *MockClient) QemuAgentStatus(ctx context.Context, vm *go_proxmox.VirtualMachine) error {
+// GetVMStartup provides a mock function with given fields: ctx, vm
+func (_m *MockClient) GetVMStartup(ctx context.Context, vm *go_proxmox.VirtualMachine) (string, error) {
 	ret := _m.Called(ctx, vm)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) error); ok {
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) (string, error)); ok {
+		return rf(ctx, vm)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) string); ok {
 		r0 = rf(ctx, vm)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(string)
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine) error); ok {
+		r1 = rf(ctx, vm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// MockClient_QemuAgentStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'QemuAgentStatus'
+// MockClient_GetVMStartup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetVMStartup'
+type MockClient_GetVMStartup_Call struct {
+	*mock.Call
+}
+
+// GetVMStartup is a helper method to define mock.On call
+//   - ctx context.Context
+//   - vm *go_proxmox.VirtualMachine
+func (_e *MockClient_Expecter) GetVMStartup(ctx interface{}, vm interface{}) *MockClient_GetVMStartup_Call {
+	return &MockClient_GetVMStartup_Call{Call: _e.mock.On("GetVMStartup", ctx, vm)}
+}
+
+func (_c *MockClient_GetVMStartup_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine)) *MockClient_GetVMStartup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine))
+	})
+	return _c
+}
+
+func (_c *MockClient_GetVMStartup_Call) Return(_a0 string, _a1 error) *MockClient_GetVMStartup_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_GetVMStartup_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine) (string, error)) *MockClient_GetVMStartup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ISOExists provides a mock function with given fields: ctx, nodeName, volumeID
+func (_m *MockClient) ISOExists(ctx context.Context, nodeName string, volumeID string) (bool, error) {
+	ret := _m.Called(ctx, nodeName, volumeID)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (bool, error)); ok {
+		return rf(ctx, nodeName, volumeID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) bool); ok {
+		r0 = rf(ctx, nodeName, volumeID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, nodeName, volumeID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_ISOExists_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ISOExists'
+type MockClient_ISOExists_Call struct {
+	*mock.Call
+}
+
+// ISOExists is a helper method to define mock.On call
+//   - ctx context.Context
+//   - nodeName string
+//   - volumeID string
+func (_e *MockClient_Expecter) ISOExists(ctx interface{}, nodeName interface{}, volumeID interface{}) *MockClient_ISOExists_Call {
+	return &MockClient_ISOExists_Call{Call: _e.mock.On("ISOExists", ctx, nodeName, volumeID)}
+}
+
+func (_c *MockClient_ISOExists_Call) Run(run func(ctx context.Context, nodeName string, volumeID string)) *MockClient_ISOExists_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockClient_ISOExists_Call) Return(_a0 bool, _a1 error) *MockClient_ISOExists_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_ISOExists_Call) RunAndReturn(run func(context.Context, string, string) (bool, error)) *MockClient_ISOExists_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListNodes provides a mock function with given fields: ctx
+func (_m *MockClient) ListNodes(ctx context.Context) ([]string, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]string, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []string); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_ListNodes_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListNodes'
+type MockClient_ListNodes_Call struct {
+	*mock.Call
+}
+
+// ListNodes is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockClient_Expecter) ListNodes(ctx interface{}) *MockClient_ListNodes_Call {
+	return &MockClient_ListNodes_Call{Call: _e.mock.On("ListNodes", ctx)}
+}
+
+func (_c *MockClient_ListNodes_Call) Run(run func(ctx context.Context)) *MockClient_ListNodes_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockClient_ListNodes_Call) Return(_a0 []string, _a1 error) *MockClient_ListNodes_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_ListNodes_Call) RunAndReturn(run func(context.Context) ([]string, error)) *MockClient_ListNodes_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListStorages provides a mock function with given fields: ctx, nodeName
+func (_m *MockClient) ListStorages(ctx context.Context, nodeName string) ([]*go_proxmox.Storage, error) {
+	ret := _m.Called(ctx, nodeName)
+
+	var r0 []*go_proxmox.Storage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]*go_proxmox.Storage, error)); ok {
+		return rf(ctx, nodeName)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*go_proxmox.Storage); ok {
+		r0 = rf(ctx, nodeName)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*go_proxmox.Storage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, nodeName)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_ListStorages_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListStorages'
+type MockClient_ListStorages_Call struct {
+	*mock.Call
+}
+
+// ListStorages is a helper method to define mock.On call
+//   - ctx context.Context
+//   - nodeName string
+func (_e *MockClient_Expecter) ListStorages(ctx interface{}, nodeName interface{}) *MockClient_ListStorages_Call {
+	return &MockClient_ListStorages_Call{Call: _e.mock.On("ListStorages", ctx, nodeName)}
+}
+
+func (_c *MockClient_ListStorages_Call) Run(run func(ctx context.Context, nodeName string)) *MockClient_ListStorages_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockClient_ListStorages_Call) Return(_a0 []*go_proxmox.Storage, _a1 error) *MockClient_ListStorages_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_ListStorages_Call) RunAndReturn(run func(context.Context, string) ([]*go_proxmox.Storage, error)) *MockClient_ListStorages_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListVMResources provides a mock function with given fields: ctx
+func (_m *MockClient) ListVMResources(ctx context.Context) ([]*go_proxmox.ClusterResource, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []*go_proxmox.ClusterResource
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*go_proxmox.ClusterResource, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []*go_proxmox.ClusterResource); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*go_proxmox.ClusterResource)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_ListVMResources_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListVMResources'
+type MockClient_ListVMResources_Call struct {
+	*mock.Call
+}
+
+// ListVMResources is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockClient_Expecter) ListVMResources(ctx interface{}) *MockClient_ListVMResources_Call {
+	return &MockClient_ListVMResources_Call{Call: _e.mock.On("ListVMResources", ctx)}
+}
+
+func (_c *MockClient_ListVMResources_Call) Run(run func(ctx context.Context)) *MockClient_ListVMResources_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockClient_ListVMResources_Call) Return(_a0 []*go_proxmox.ClusterResource, _a1 error) *MockClient_ListVMResources_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_ListVMResources_Call) RunAndReturn(run func(context.Context) ([]*go_proxmox.ClusterResource, error)) *MockClient_ListVMResources_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListVNets provides a mock function with given fields: ctx
+func (_m *MockClient) ListVNets(ctx context.Context) ([]proxmox.VNet, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []proxmox.VNet
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]proxmox.VNet, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []proxmox.VNet); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]proxmox.VNet)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_ListVNets_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListVNets'
+type MockClient_ListVNets_Call struct {
+	*mock.Call
+}
+
+// ListVNets is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockClient_Expecter) ListVNets(ctx interface{}) *MockClient_ListVNets_Call {
+	return &MockClient_ListVNets_Call{Call: _e.mock.On("ListVNets", ctx)}
+}
+
+func (_c *MockClient_ListVNets_Call) Run(run func(ctx context.Context)) *MockClient_ListVNets_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockClient_ListVNets_Call) Return(_a0 []proxmox.VNet, _a1 error) *MockClient_ListVNets_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_ListVNets_Call) RunAndReturn(run func(context.Context) ([]proxmox.VNet, error)) *MockClient_ListVNets_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MigrateVM provides a mock function with given fields: ctx, vm, target
+func (_m *MockClient) MigrateVM(ctx context.Context, vm *go_proxmox.VirtualMachine, target string) (*go_proxmox.Task, error) {
+	ret := _m.Called(ctx, vm, target)
+
+	var r0 *go_proxmox.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, string) (*go_proxmox.Task, error)); ok {
+		return rf(ctx, vm, target)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, string) *go_proxmox.Task); ok {
+		r0 = rf(ctx, vm, target)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*go_proxmox.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine, string) error); ok {
+		r1 = rf(ctx, vm, target)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_MigrateVM_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MigrateVM'
+type MockClient_MigrateVM_Call struct {
+	*mock.Call
+}
+
+// MigrateVM is a helper method to define mock.On call
+//   - ctx context.Context
+//   - vm *go_proxmox.VirtualMachine
+//   - target string
+func (_e *MockClient_Expecter) MigrateVM(ctx interface{}, vm interface{}, target interface{}) *MockClient_MigrateVM_Call {
+	return &MockClient_MigrateVM_Call{Call: _e.mock.On("MigrateVM", ctx, vm, target)}
+}
+
+func (_c *MockClient_MigrateVM_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine, target string)) *MockClient_MigrateVM_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockClient_MigrateVM_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_MigrateVM_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_MigrateVM_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine, string) (*go_proxmox.Task, error)) *MockClient_MigrateVM_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MountCloudInitISO provides a mock function with given fields: ctx, vm, device, path
+func (_m *MockClient) MountCloudInitISO(ctx context.Context, vm *go_proxmox.VirtualMachine, device string, path string) error {
+	ret := _m.Called(ctx, vm, device, path)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, string, string) error); ok {
+		r0 = rf(ctx, vm, device, path)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockClient_MountCloudInitISO_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MountCloudInitISO'
+type MockClient_MountCloudInitISO_Call struct {
+	*mock.Call
+}
+
+// MountCloudInitISO is a helper method to define mock.On call
+//   - ctx context.Context
+//   - vm *go_proxmox.VirtualMachine
+//   - device string
+//   - path string
+func (_e *MockClient_Expecter) MountCloudInitISO(ctx interface{}, vm interface{}, device interface{}, path interface{}) *MockClient_MountCloudInitISO_Call {
+	return &MockClient_MountCloudInitISO_Call{Call: _e.mock.On("MountCloudInitISO", ctx, vm, device, path)}
+}
+
+func (_c *MockClient_MountCloudInitISO_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine, device string, path string)) *MockClient_MountCloudInitISO_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine), args[2].(string), args[3].(string))
+	})
+	return _c
+}
+
+func (_c *MockClient_MountCloudInitISO_Call) Return(_a0 error) *MockClient_MountCloudInitISO_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockClient_MountCloudInitISO_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine, string, string) error) *MockClient_MountCloudInitISO_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PoolExists provides a mock function with given fields: ctx, poolID
+func (_m *MockClient) PoolExists(ctx context.Context, poolID string) (bool, error) {
+	ret := _m.Called(ctx, poolID)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, poolID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, poolID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, poolID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_PoolExists_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PoolExists'
+type MockClient_PoolExists_Call struct {
+	*mock.Call
+}
+
+// PoolExists is a helper method to define mock.On call
+//   - ctx context.Context
+//   - poolID string
+func (_e *MockClient_Expecter) PoolExists(ctx interface{}, poolID interface{}) *MockClient_PoolExists_Call {
+	return &MockClient_PoolExists_Call{Call: _e.mock.On("PoolExists", ctx, poolID)}
+}
+
+func (_c *MockClient_PoolExists_Call) Run(run func(ctx context.Context, poolID string)) *MockClient_PoolExists_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockClient_PoolExists_Call) Return(_a0 bool, _a1 error) *MockClient_PoolExists_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_PoolExists_Call) RunAndReturn(run func(context.Context, string) (bool, error)) *MockClient_PoolExists_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// QemuAgentNetworkInterfaces provides a mock function with given fields: ctx, vm
+func (_m *MockClient) QemuAgentNetworkInterfaces(ctx context.Context, vm *go_proxmox.VirtualMachine) ([]*go_proxmox.AgentNetworkIface, error) {
+	ret := _m.Called(ctx, vm)
+
+	var r0 []*go_proxmox.AgentNetworkIface
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) ([]*go_proxmox.AgentNetworkIface, error)); ok {
+		return rf(ctx, vm)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) []*go_proxmox.AgentNetworkIface); ok {
+		r0 = rf(ctx, vm)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*go_proxmox.AgentNetworkIface)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine) error); ok {
+		r1 = rf(ctx, vm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_QemuAgentNetworkInterfaces_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'QemuAgentNetworkInterfaces'
+type MockClient_QemuAgentNetworkInterfaces_Call struct {
+	*mock.Call
+}
+
+// QemuAgentNetworkInterfaces is a helper method to define mock.On call
+//   - ctx context.Context
+//   - vm *go_proxmox.VirtualMachine
+func (_e *MockClient_Expecter) QemuAgentNetworkInterfaces(ctx interface{}, vm interface{}) *MockClient_QemuAgentNetworkInterfaces_Call {
+	return &MockClient_QemuAgentNetworkInterfaces_Call{Call: _e.mock.On("QemuAgentNetworkInterfaces", ctx, vm)}
+}
+
+func (_c *MockClient_QemuAgentNetworkInterfaces_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine)) *MockClient_QemuAgentNetworkInterfaces_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine))
+	})
+	return _c
+}
+
+func (_c *MockClient_QemuAgentNetworkInterfaces_Call) Return(_a0 []*go_proxmox.AgentNetworkIface, _a1 error) *MockClient_QemuAgentNetworkInterfaces_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_QemuAgentNetworkInterfaces_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine) ([]*go_proxmox.AgentNetworkIface, error)) *MockClient_QemuAgentNetworkInterfaces_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// QemuAgentStatus provides a mock function with given fields: ctx, vm
+func (_m *MockClient) QemuAgentStatus(ctx context.Context, vm *go_proxmox.VirtualMachine) error {
+	ret := _m.Called(ctx, vm)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) error); ok {
+		r0 = rf(ctx, vm)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockClient_QemuAgentStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'QemuAgentStatus'
 type MockClient_QemuAgentStatus_Call struct {
 	*mock.Call
 }
@@ -573,6 +1461,61 @@ func (_c *MockClient_QemuAgentStatus_Call) RunAndReturn(run func(context.Context
 	return _c
 }
 
+// RebootVM provides a mock function with given fields: ctx, vm
+func (_m *MockClient) RebootVM(ctx context.Context, vm *go_proxmox.VirtualMachine) (*go_proxmox.Task, error) {
+	ret := _m.Called(ctx, vm)
+
+	var r0 *go_proxmox.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) (*go_proxmox.Task, error)); ok {
+		return rf(ctx, vm)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) *go_proxmox.Task); ok {
+		r0 = rf(ctx, vm)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*go_proxmox.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine) error); ok {
+		r1 = rf(ctx, vm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_RebootVM_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RebootVM'
+type MockClient_RebootVM_Call struct {
+	*mock.Call
+}
+
+// RebootVM is a helper method to define mock.On call
+//   - ctx context.Context
+//   - vm *go_proxmox.VirtualMachine
+func (_e *MockClient_Expecter) RebootVM(ctx interface{}, vm interface{}) *MockClient_RebootVM_Call {
+	return &MockClient_RebootVM_Call{Call: _e.mock.On("RebootVM", ctx, vm)}
+}
+
+func (_c *MockClient_RebootVM_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine)) *MockClient_RebootVM_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine))
+	})
+	return _c
+}
+
+func (_c *MockClient_RebootVM_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_RebootVM_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_RebootVM_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine) (*go_proxmox.Task, error)) *MockClient_RebootVM_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ResizeDisk provides a mock function with given fields: ctx, vm, disk, size
 func (_m *MockClient) ResizeDisk(ctx context.Context, vm *go_proxmox.VirtualMachine, disk string, size string) error {
 	ret := _m.Called(ctx, vm, disk, size)
@@ -673,6 +1616,116 @@ func (_c *MockClient_ResumeVM_Call) RunAndReturn(run func(context.Context, *go_p
 	return _c
 }
 
+// ShutdownVM provides a mock function with given fields: ctx, vm, timeout
+func (_m *MockClient) ShutdownVM(ctx context.Context, vm *go_proxmox.VirtualMachine, timeout time.Duration) (*go_proxmox.Task, error) {
+	ret := _m.Called(ctx, vm, timeout)
+
+	var r0 *go_proxmox.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, time.Duration) (*go_proxmox.Task, error)); ok {
+		return rf(ctx, vm, timeout)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, time.Duration) *go_proxmox.Task); ok {
+		r0 = rf(ctx, vm, timeout)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*go_proxmox.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine, time.Duration) error); ok {
+		r1 = rf(ctx, vm, timeout)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_ShutdownVM_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ShutdownVM'
+type MockClient_ShutdownVM_Call struct {
+	*mock.Call
+}
+
+// ShutdownVM is a helper method to define mock.On call
+//   - ctx context.Context
+//   - vm *go_proxmox.VirtualMachine
+//   - timeout time.Duration
+func (_e *MockClient_Expecter) ShutdownVM(ctx interface{}, vm interface{}, timeout interface{}) *MockClient_ShutdownVM_Call {
+	return &MockClient_ShutdownVM_Call{Call: _e.mock.On("ShutdownVM", ctx, vm, timeout)}
+}
+
+func (_c *MockClient_ShutdownVM_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine, timeout time.Duration)) *MockClient_ShutdownVM_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine), args[2].(time.Duration))
+	})
+	return _c
+}
+
+func (_c *MockClient_ShutdownVM_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_ShutdownVM_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_ShutdownVM_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine, time.Duration) (*go_proxmox.Task, error)) *MockClient_ShutdownVM_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SnapshotExists provides a mock function with given fields: ctx, vm, name
+func (_m *MockClient) SnapshotExists(ctx context.Context, vm *go_proxmox.VirtualMachine, name string) (bool, error) {
+	ret := _m.Called(ctx, vm, name)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, string) (bool, error)); ok {
+		return rf(ctx, vm, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, string) bool); ok {
+		r0 = rf(ctx, vm, name)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine, string) error); ok {
+		r1 = rf(ctx, vm, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_SnapshotExists_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SnapshotExists'
+type MockClient_SnapshotExists_Call struct {
+	*mock.Call
+}
+
+// SnapshotExists is a helper method to define mock.On call
+//   - ctx context.Context
+//   - vm *go_proxmox.VirtualMachine
+//   - name string
+func (_e *MockClient_Expecter) SnapshotExists(ctx interface{}, vm interface{}, name interface{}) *MockClient_SnapshotExists_Call {
+	return &MockClient_SnapshotExists_Call{Call: _e.mock.On("SnapshotExists", ctx, vm, name)}
+}
+
+func (_c *MockClient_SnapshotExists_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine, name string)) *MockClient_SnapshotExists_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockClient_SnapshotExists_Call) Return(_a0 bool, _a1 error) *MockClient_SnapshotExists_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_SnapshotExists_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine, string) (bool, error)) *MockClient_SnapshotExists_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // StartVM provides a mock function with given fields: ctx, vm
 func (_m *MockClient) StartVM(ctx context.Context, vm *go_proxmox.VirtualMachine) (*go_proxmox.Task, error) {
 	ret := _m.Called(ctx, vm)
@@ -728,6 +1781,104 @@ func (_c *MockClient_StartVM_Call) RunAndReturn(run func(context.Context, *go_pr
 	return _c
 }
 
+// StopTask provides a mock function with given fields: ctx, upID
+func (_m *MockClient) StopTask(ctx context.Context, upID string) error {
+	ret := _m.Called(ctx, upID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, upID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockClient_StopTask_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StopTask'
+type MockClient_StopTask_Call struct {
+	*mock.Call
+}
+
+// StopTask is a helper method to define mock.On call
+//   - ctx context.Context
+//   - upID string
+func (_e *MockClient_Expecter) StopTask(ctx interface{}, upID interface{}) *MockClient_StopTask_Call {
+	return &MockClient_StopTask_Call{Call: _e.mock.On("StopTask", ctx, upID)}
+}
+
+func (_c *MockClient_StopTask_Call) Run(run func(ctx context.Context, upID string)) *MockClient_StopTask_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockClient_StopTask_Call) Return(_a0 error) *MockClient_StopTask_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockClient_StopTask_Call) RunAndReturn(run func(context.Context, string) error) *MockClient_StopTask_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// StopVM provides a mock function with given fields: ctx, vm
+func (_m *MockClient) StopVM(ctx context.Context, vm *go_proxmox.VirtualMachine) (*go_proxmox.Task, error) {
+	ret := _m.Called(ctx, vm)
+
+	var r0 *go_proxmox.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) (*go_proxmox.Task, error)); ok {
+		return rf(ctx, vm)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) *go_proxmox.Task); ok {
+		r0 = rf(ctx, vm)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*go_proxmox.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine) error); ok {
+		r1 = rf(ctx, vm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_StopVM_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StopVM'
+type MockClient_StopVM_Call struct {
+	*mock.Call
+}
+
+// StopVM is a helper method to define mock.On call
+//   - ctx context.Context
+//   - vm *go_proxmox.VirtualMachine
+func (_e *MockClient_Expecter) StopVM(ctx interface{}, vm interface{}) *MockClient_StopVM_Call {
+	return &MockClient_StopVM_Call{Call: _e.mock.On("StopVM", ctx, vm)}
+}
+
+func (_c *MockClient_StopVM_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine)) *MockClient_StopVM_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine))
+	})
+	return _c
+}
+
+func (_c *MockClient_StopVM_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_StopVM_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_StopVM_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine) (*go_proxmox.Task, error)) *MockClient_StopVM_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // TagVM provides a mock function with given fields: ctx, vm, tag
 func (_m *MockClient) TagVM(ctx context.Context, vm *go_proxmox.VirtualMachine, tag string) (*go_proxmox.Task, error) {
 	ret := _m.Called(ctx, vm, tag)
@@ -16,7 +16,11 @@ limitations under the License.
 
 package proxmox
 
-import "github.com/luthermonson/go-proxmox"
+import (
+	"regexp"
+
+	"github.com/luthermonson/go-proxmox"
+)
 
 // VMCloneRequest Is the object used to clone a VM.
 type VMCloneRequest struct {
@@ -40,3 +44,22 @@ type VMCloneResponse struct {
 
 // VirtualMachineOption is an alias for VirtualMachineOption to prevent import conflicts.
 type VirtualMachineOption = proxmox.VirtualMachineOption
+
+// VNet is a virtual network of the Proxmox SDN, which guests are attached to like to a Linux bridge.
+type VNet struct {
+	Name string `json:"vnet"`
+	Zone string `json:"zone"`
+	// Tag is the VLAN or VXLAN tag of the VNet, which the SDN sets for the guests.
+	Tag int `json:"tag,omitempty"`
+	// VLANAware allows guests to set their own VLAN tag within the VNet.
+	VLANAware proxmox.IntOrBool `json:"vlanaware,omitempty"`
+}
+
+// linuxBridge matches the names Proxmox allows for Linux and OVS bridges.
+var linuxBridge = regexp.MustCompile(`^vmbr\d+$`)
+
+// IsVNet returns whether the bridge of a network device is a VNet of the SDN,
+// as every other bridge is named vmbrN.
+func IsVNet(bridge string) bool {
+	return !linuxBridge.MatchString(bridge)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2023-2024 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"sync"
+
+	capmox "github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+// proxmoxClients caches the clients built from credentials secrets, so ProxmoxClusters
+// sharing a Proxmox endpoint do not connect and authenticate on every reconcile.
+var proxmoxClients = &clientCache{clients: map[string]*cachedClient{}}
+
+// clientCache holds one client per Proxmox endpoint, identity and secret data.
+// A client is dropped once no ProxmoxCluster uses it anymore, e.g. after its credentials were
+// rotated or its clusters deleted, so no stale credentials are kept in memory.
+type clientCache struct {
+	mu      sync.Mutex
+	clients map[string]*cachedClient
+}
+
+type cachedClient struct {
+	client capmox.Client
+	// clusters are the ProxmoxClusters using the client.
+	clusters map[string]struct{}
+}
+
+// get returns the client cached for key and records that the cluster uses it.
+func (c *clientCache) get(key, cluster string) capmox.Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.clients[key]
+	if !ok {
+		return nil
+	}
+	c.use(key, cluster)
+	return cached.client
+}
+
+func (c *clientCache) set(key, cluster string, client capmox.Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cached, ok := c.clients[key]; ok {
+		// another cluster built a client for the same secret data in the meantime, keep its users.
+		cached.client = client
+	} else {
+		c.clients[key] = &cachedClient{client: client, clusters: map[string]struct{}{}}
+	}
+	c.use(key, cluster)
+}
+
+// release drops the cluster from the users of the cached clients, and the clients no cluster uses anymore.
+func (c *clientCache) release(cluster string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.releaseExcept(cluster, "")
+}
+
+// use records the cluster as user of the client of key, and releases any other client it used before,
+// e.g. if its credentialsRef was changed.
+func (c *clientCache) use(key, cluster string) {
+	c.clients[key].clusters[cluster] = struct{}{}
+	c.releaseExcept(cluster, key)
+}
+
+func (c *clientCache) releaseExcept(cluster, key string) {
+	for k, cached := range c.clients {
+		if k == key {
+			continue
+		}
+		delete(cached.clusters, cluster)
+		if len(cached.clusters) == 0 {
+			delete(c.clients, k)
+		}
+	}
+}
+
+// clientCacheKey returns the key of the client for the given endpoint, identity and secret data.
+// Clusters using different secrets for the same endpoint and identity, e.g. with another root_ca, get their own clients.
+func clientCacheKey(url, identity, fingerprint string) string {
+	return url + "|" + identity + "|" + fingerprint
+}
+
+// secretFingerprint returns a hash over all the data of a credentials secret.
+func secretFingerprint(data map[string][]byte) string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	hash := sha256.New()
+	for _, key := range keys {
+		hash.Write([]byte(key))
+		hash.Write([]byte{0})
+		hash.Write(data[key])
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
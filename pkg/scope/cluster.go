@@ -26,7 +26,6 @@ import (
 	"strings"
 
 	"github.com/go-logr/logr"
-	"github.com/luthermonson/go-proxmox"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -45,6 +44,9 @@ import (
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/goproxmox"
 )
 
+// ErrMissingProxmoxURL is returned if the credentials secret of a ProxmoxCluster has no url.
+var ErrMissingProxmoxURL = errors.New("credentials secret is missing url")
+
 // ClusterScopeParams defines the input parameters used to create a new Scope.
 type ClusterScopeParams struct {
 	Client         client.Client
@@ -151,35 +153,96 @@ func (s *ClusterScope) setupProxmoxClient(ctx context.Context) (capmox.Client, e
 		return nil, errors.Wrap(err, "failed to get credentials secret")
 	}
 
-	token := string(secret.Data["token"])
-	tokenSecret := string(secret.Data["secret"])
+	creds := goproxmox.Credentials{
+		TokenID:  string(secret.Data["token"]),
+		Secret:   string(secret.Data["secret"]),
+		Username: string(secret.Data["username"]),
+		Password: string(secret.Data["password"]),
+	}
+	if err := creds.Validate(); err != nil {
+		s.ProxmoxCluster.Status.FailureMessage = ptr.To(fmt.Sprintf("invalid credentials secret: %s", err))
+		s.ProxmoxCluster.Status.FailureReason = ptr.To(clustererrors.InvalidConfigurationClusterError)
+		return nil, errors.Wrap(err, "invalid credentials secret")
+	}
+
 	url := string(secret.Data["url"])
+	if url == "" {
+		s.ProxmoxCluster.Status.FailureMessage = ptr.To("invalid credentials secret: missing url")
+		s.ProxmoxCluster.Status.FailureReason = ptr.To(clustererrors.InvalidConfigurationClusterError)
+		return nil, ErrMissingProxmoxURL
+	}
+
+	// the client is reused as long as the secret does not change.
+	cacheKey := clientCacheKey(url, creds.TokenID+creds.Username, secretFingerprint(secret.Data))
+	if pmoxClient := proxmoxClients.get(cacheKey, s.cacheUser()); pmoxClient != nil {
+		return pmoxClient, nil
+	}
 
 	tlsInsecure, tlsInsecureSet := secret.Data["insecure"]
 	tlsRootCA := secret.Data["root_ca"]
 
 	rootCerts, err := tlshelper.SystemRootsWithCert(tlsRootCA)
 	if err != nil {
+		s.ProxmoxCluster.Status.FailureMessage = ptr.To(fmt.Sprintf("invalid root_ca in credentials secret: %s", err))
+		s.ProxmoxCluster.Status.FailureReason = ptr.To(clustererrors.InvalidConfigurationClusterError)
 		return nil, fmt.Errorf("loading cert pool: %w", err)
 	}
 
+	// When "insecure" is unset we retain the pre-v0.7 behavior of
+	// setting the connection insecure, even with a root CA. If it is set
+	// we compare against YAML true-ish values.
+	insecureSkipVerify := !tlsInsecureSet || slices.Contains([]string{"1", "on", "true", "yes", "y"}, strings.ToLower(string(tlsInsecure)))
+	if insecureSkipVerify {
+		s.Logger.Info("TLS verification of the Proxmox API is disabled, set insecure: false in the credentials secret to enable it", "url", url)
+	}
+
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{
-			// When "insecure" is unset we retain the pre-v0.7 behavior of
-			// setting the connection insecure. If it is set we compare
-			// against YAML true-ish values.
-			//
-			//#nosec:G402 // Intended to enable insecure mode for unknown CAs
-			InsecureSkipVerify: !tlsInsecureSet || slices.Contains([]string{"1", "on", "true", "yes", "y"}, strings.ToLower(string(tlsInsecure))),
+			InsecureSkipVerify: insecureSkipVerify, //#nosec:G402 // Intended to enable insecure mode for unknown CAs
 			RootCAs:            rootCerts,
 		},
 	}
 
-	httpClient := &http.Client{Transport: tr}
-	return goproxmox.NewAPIClient(ctx, *s.Logger, url,
-		proxmox.WithHTTPClient(httpClient),
-		proxmox.WithAPIToken(token, tokenSecret),
-	)
+	options, err := creds.Options(url, &http.Client{Transport: goproxmox.NewRetryTransport(tr)})
+	if err != nil {
+		return nil, err
+	}
+	pmoxClient, err := goproxmox.NewAPIClient(ctx, *s.Logger, url, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	cachedClient := capmox.NewCachedClient(pmoxClient, capmox.DefaultCacheTTL)
+	proxmoxClients.set(cacheKey, s.cacheUser(), cachedClient)
+	return cachedClient, nil
+}
+
+// ProxmoxClientForCluster returns the Proxmox client of a cluster with a credentialsRef, without
+// touching the ProxmoxCluster. It shares the client cache with the cluster scopes.
+func ProxmoxClientForCluster(ctx context.Context, c client.Client, proxmoxCluster *infrav1alpha1.ProxmoxCluster) (capmox.Client, error) {
+	if proxmoxCluster.Spec.CredentialsRef == nil {
+		return nil, errors.New("ProxmoxCluster missing credentialsRef")
+	}
+
+	logger := log.FromContext(ctx)
+	s := &ClusterScope{
+		Logger: &logger,
+		client: c,
+		// the failure reasons set on errors must not leak into the cluster.
+		ProxmoxCluster: proxmoxCluster.DeepCopy(),
+	}
+	return s.setupProxmoxClient(ctx)
+}
+
+// ReleaseProxmoxClient drops the cached Proxmox client of the cluster, unless other clusters use it.
+// It is called once the ProxmoxCluster is deleted.
+func (s *ClusterScope) ReleaseProxmoxClient() {
+	proxmoxClients.release(s.cacheUser())
+}
+
+// cacheUser identifies the ProxmoxCluster as user of a cached Proxmox client.
+func (s *ClusterScope) cacheUser() string {
+	return client.ObjectKeyFromObject(s.ProxmoxCluster).String()
 }
 
 // Name returns the CAPI cluster name.
@@ -136,6 +136,166 @@ func TestNewClusterScope_SetupProxmoxClient(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestNewClusterScope_AmbiguousCredentials(t *testing.T) {
+	k8sClient := getFakeClient(t)
+
+	proxmoxCluster := &infrav1alpha1.ProxmoxCluster{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: infrav1alpha1.GroupVersion.String(),
+			Kind:       "ProxmoxCluster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "proxmoxcluster",
+			Namespace: "default",
+		},
+		Spec: infrav1alpha1.ProxmoxClusterSpec{
+			CredentialsRef: &corev1.SecretReference{
+				Name:      "test-secret",
+				Namespace: "default",
+			},
+		},
+	}
+
+	creds := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-secret",
+			Namespace: "default",
+		},
+		Data: map[string][]byte{
+			"url":      []byte("https://localhost:8006"),
+			"token":    []byte("test-token"),
+			"secret":   []byte("test-secret"),
+			"username": []byte("root@pam"),
+			"password": []byte("password"),
+		},
+	}
+	require.NoError(t, k8sClient.Create(context.Background(), &creds))
+
+	params := ClusterScopeParams{Client: k8sClient, Cluster: &clusterv1.Cluster{}, ProxmoxCluster: proxmoxCluster, IPAMHelper: &ipam.Helper{}}
+	_, err := NewClusterScope(params)
+	require.ErrorIs(t, err, goproxmox.ErrAmbiguousCredentials)
+	require.Equal(t, ptr.To(clustererrors.InvalidConfigurationClusterError), proxmoxCluster.Status.FailureReason)
+}
+
+func TestNewClusterScope_MissingURL(t *testing.T) {
+	k8sClient := getFakeClient(t)
+
+	proxmoxCluster := &infrav1alpha1.ProxmoxCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "proxmoxcluster",
+			Namespace: "default",
+		},
+		Spec: infrav1alpha1.ProxmoxClusterSpec{
+			CredentialsRef: &corev1.SecretReference{
+				Name:      "test-secret",
+				Namespace: "default",
+			},
+		},
+	}
+
+	creds := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-secret",
+			Namespace: "default",
+		},
+		Data: map[string][]byte{
+			"token":  []byte("test-token"),
+			"secret": []byte("test-secret"),
+		},
+	}
+	require.NoError(t, k8sClient.Create(context.Background(), &creds))
+
+	params := ClusterScopeParams{Client: k8sClient, Cluster: &clusterv1.Cluster{}, ProxmoxCluster: proxmoxCluster, IPAMHelper: &ipam.Helper{}}
+	_, err := NewClusterScope(params)
+	require.ErrorIs(t, err, ErrMissingProxmoxURL)
+	require.Equal(t, ptr.To(clustererrors.InvalidConfigurationClusterError), proxmoxCluster.Status.FailureReason)
+}
+
+func TestNewClusterScope_CachedProxmoxClient(t *testing.T) {
+	k8sClient := getFakeClient(t)
+	proxmoxClient := proxmoxtest.NewMockClient(t)
+
+	proxmoxCluster := &infrav1alpha1.ProxmoxCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "proxmoxcluster",
+			Namespace: "default",
+		},
+		Spec: infrav1alpha1.ProxmoxClusterSpec{
+			CredentialsRef: &corev1.SecretReference{
+				Name:      "test-secret",
+				Namespace: "default",
+			},
+		},
+	}
+
+	data := map[string][]byte{
+		"url":    []byte("https://pve-cached.local.test:8006"),
+		"token":  []byte("test-token"),
+		"secret": []byte("test-secret"),
+	}
+	creds := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-secret",
+			Namespace: "default",
+		},
+		Data: data,
+	}
+	require.NoError(t, k8sClient.Create(context.Background(), &creds))
+
+	key := clientCacheKey("https://pve-cached.local.test:8006", "test-token", secretFingerprint(data))
+	proxmoxClients.set(key, "default/other", proxmoxClient)
+
+	params := ClusterScopeParams{Client: k8sClient, Cluster: &clusterv1.Cluster{}, ProxmoxCluster: proxmoxCluster, IPAMHelper: &ipam.Helper{}}
+	clusterScope, err := NewClusterScope(params)
+	require.NoError(t, err)
+	require.Equal(t, proxmoxClient, clusterScope.ProxmoxClient)
+
+	// the client is kept as long as any cluster uses it.
+	clusterScope.ReleaseProxmoxClient()
+	require.Equal(t, proxmoxClient, proxmoxClients.get(key, "default/other"))
+	proxmoxClients.release("default/other")
+	require.NotContains(t, proxmoxClients.clients, key)
+
+	// rotated credentials are not served from the cache.
+	proxmoxClients.set(key, "default/other", proxmoxClient)
+	rotated := clientCacheKey("https://pve-cached.local.test:8006", "test-token",
+		secretFingerprint(map[string][]byte{"url": data["url"], "token": data["token"], "secret": []byte("rotated")}))
+	require.Nil(t, proxmoxClients.get(rotated, "default/other"))
+
+	// once the cluster switched to the client of the rotated secret, the stale client is dropped.
+	proxmoxClients.set(rotated, "default/other", proxmoxtest.NewMockClient(t))
+	require.NotContains(t, proxmoxClients.clients, key)
+	proxmoxClients.release("default/other")
+}
+
+func TestClientCache_SameIdentityDifferentSecrets(t *testing.T) {
+	cache := &clientCache{clients: map[string]*cachedClient{}}
+	url, identity := "https://pve-shared.local.test:8006", "test-token"
+
+	first := proxmoxtest.NewMockClient(t)
+	firstKey := clientCacheKey(url, identity, secretFingerprint(map[string][]byte{"secret": []byte("test-secret")}))
+	second := proxmoxtest.NewMockClient(t)
+	secondKey := clientCacheKey(url, identity, secretFingerprint(map[string][]byte{"secret": []byte("test-secret"), "root_ca": []byte("ca")}))
+
+	cache.set(firstKey, "default/first", first)
+	cache.set(secondKey, "default/second", second)
+
+	// the clusters keep their own clients, instead of evicting each other on every reconcile.
+	for range 2 {
+		require.Equal(t, first, cache.get(firstKey, "default/first"))
+		require.Equal(t, second, cache.get(secondKey, "default/second"))
+	}
+
+	// a cluster sharing the client of another cluster does not drop it when it moves on.
+	require.Equal(t, first, cache.get(firstKey, "default/third"))
+	require.Equal(t, second, cache.get(secondKey, "default/third"))
+	require.Equal(t, first, cache.get(firstKey, "default/first"))
+
+	cache.release("default/first")
+	require.Contains(t, cache.clients, secondKey)
+	require.NotContains(t, cache.clients, firstKey)
+}
+
 func TestListProxmoxMachinesForCluster(t *testing.T) {
 	k8sClient := getFakeClient(t)
 	proxmoxClient := proxmoxtest.NewMockClient(t)
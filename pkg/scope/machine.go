@@ -19,12 +19,15 @@ package scope
 import (
 	"context"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/luthermonson/go-proxmox"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	capierrors "sigs.k8s.io/cluster-api/errors"
@@ -38,6 +41,14 @@ import (
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/kubernetes/ipam"
 )
 
+var (
+	// ErrNoAllowedNodes is returned if the node restrictions of a machine leave no node to schedule on.
+	ErrNoAllowedNodes = errors.New("no allowed nodes left")
+
+	// ErrUnknownFailureDomain is returned if a machine was assigned a failure domain the ProxmoxCluster does not define.
+	ErrUnknownFailureDomain = errors.New("failure domain is not defined in the ProxmoxCluster")
+)
+
 // MachineScopeParams defines the input parameters used to create a new MachineScope.
 type MachineScopeParams struct {
 	Client         client.Client
@@ -47,6 +58,8 @@ type MachineScopeParams struct {
 	InfraCluster   *ClusterScope
 	ProxmoxMachine *infrav1alpha1.ProxmoxMachine
 	IPAMHelper     *ipam.Helper
+	TaskTimeout    time.Duration
+	Recorder       record.EventRecorder
 }
 
 // MachineScope defines a scope defined around a machine and its cluster.
@@ -61,6 +74,11 @@ type MachineScope struct {
 	ProxmoxMachine *infrav1alpha1.ProxmoxMachine
 	IPAMHelper     *ipam.Helper
 	VirtualMachine *proxmox.VirtualMachine
+
+	// TaskTimeout is the time after which a running Proxmox task is stopped. Zero means no timeout.
+	TaskTimeout time.Duration
+	// Recorder records events on the ProxmoxMachine.
+	Recorder record.EventRecorder
 }
 
 // NewMachineScope creates a new MachineScope from the supplied parameters.
@@ -88,6 +106,10 @@ func NewMachineScope(params MachineScopeParams) (*MachineScope, error) {
 		logger := log.FromContext(context.Background())
 		params.Logger = &logger
 	}
+	if params.Recorder == nil {
+		// drops all events.
+		params.Recorder = &record.FakeRecorder{}
+	}
 
 	helper, err := patch.NewHelper(params.ProxmoxMachine, params.Client)
 	if err != nil {
@@ -103,6 +125,8 @@ func NewMachineScope(params MachineScopeParams) (*MachineScope, error) {
 		InfraCluster:   params.InfraCluster,
 		ProxmoxMachine: params.ProxmoxMachine,
 		IPAMHelper:     params.IPAMHelper,
+		TaskTimeout:    params.TaskTimeout,
+		Recorder:       params.Recorder,
 	}, nil
 }
 
@@ -143,6 +167,66 @@ func (m *MachineScope) LocateProxmoxNode() string {
 	return node
 }
 
+// GetAllowedNodes returns the Proxmox nodes the machine may be scheduled on.
+// The nodes of the failure domain take precedence over the ones of the ProxmoxCluster.
+// The AllowedNodes of the ProxmoxMachine can only narrow these down further.
+func (m *MachineScope) GetAllowedNodes() ([]string, error) {
+	fd, err := m.GetFailureDomain()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := m.InfraCluster.ProxmoxCluster.Spec.AllowedNodes
+	if fd != nil {
+		nodes = fd.Nodes
+	}
+
+	machineNodes := m.ProxmoxMachine.Spec.AllowedNodes
+	if len(machineNodes) == 0 {
+		return nodes, nil
+	}
+	if len(nodes) == 0 {
+		return machineNodes, nil
+	}
+
+	allowed := make([]string, 0, len(machineNodes))
+	for _, node := range machineNodes {
+		if slices.Contains(nodes, node) {
+			allowed = append(allowed, node)
+		}
+	}
+	if len(allowed) == 0 {
+		return nil, errors.Wrapf(ErrNoAllowedNodes, "none of the machine's allowed nodes %v is in %v", machineNodes, nodes)
+	}
+
+	return allowed, nil
+}
+
+// GetFailureDomain returns the failure domain Cluster API assigned to the machine,
+// or nil if there is none. It fails if the ProxmoxCluster does not define the failure domain,
+// as the machine would otherwise end up outside of it.
+func (m *MachineScope) GetFailureDomain() (*infrav1alpha1.FailureDomainSpec, error) {
+	if m.Machine == nil || m.Machine.Spec.FailureDomain == nil {
+		return nil, nil
+	}
+
+	fd, ok := m.InfraCluster.ProxmoxCluster.Spec.FailureDomains[*m.Machine.Spec.FailureDomain]
+	if !ok {
+		return nil, errors.Wrapf(ErrUnknownFailureDomain, "failure domain %q", *m.Machine.Spec.FailureDomain)
+	}
+
+	return &fd, nil
+}
+
+// GetPool returns the Proxmox resource pool of the machine, falling back to the one of the ProxmoxCluster.
+// An empty string means the VM is not added to any pool.
+func (m *MachineScope) GetPool() string {
+	if m.ProxmoxMachine.Spec.Pool != nil {
+		return *m.ProxmoxMachine.Spec.Pool
+	}
+	return ptr.Deref(m.InfraCluster.ProxmoxCluster.Spec.Pool, "")
+}
+
 // GetProviderID returns the ProxmoxMachine providerID from the spec.
 func (m *MachineScope) GetProviderID() string {
 	if m.ProxmoxMachine.Spec.ProviderID != nil {
@@ -162,9 +246,10 @@ func (m *MachineScope) SetProviderID(biosUUID string) {
 	m.ProxmoxMachine.Spec.ProviderID = ptr.To(providerID)
 }
 
-// SetVirtualMachineID sets the ProxmoxMachine instanceID in spec.
+// SetVirtualMachineID sets the ProxmoxMachine instanceID in spec and status.
 func (m *MachineScope) SetVirtualMachineID(vmID int64) {
 	m.ProxmoxMachine.Spec.VirtualMachineID = ptr.To(vmID)
+	m.ProxmoxMachine.Status.VMID = ptr.To(vmID)
 }
 
 // SetReady sets the ProxmoxMachine Ready Status.
@@ -177,6 +262,16 @@ func (m *MachineScope) SetNotReady() {
 	m.ProxmoxMachine.Status.Ready = false
 }
 
+// Eventf records a Normal event on the ProxmoxMachine.
+func (m *MachineScope) Eventf(reason, messageFmt string, args ...interface{}) {
+	m.Recorder.Eventf(m.ProxmoxMachine, corev1.EventTypeNormal, reason, messageFmt, args...)
+}
+
+// Warnf records a Warning event on the ProxmoxMachine.
+func (m *MachineScope) Warnf(reason, messageFmt string, args ...interface{}) {
+	m.Recorder.Eventf(m.ProxmoxMachine, corev1.EventTypeWarning, reason, messageFmt, args...)
+}
+
 // SetFailureMessage sets the ProxmoxMachine status failure message.
 func (m *MachineScope) SetFailureMessage(v error) {
 	m.ProxmoxMachine.Status.FailureMessage = ptr.To(v.Error())
@@ -187,6 +282,12 @@ func (m *MachineScope) SetFailureReason(v capierrors.MachineStatusError) {
 	m.ProxmoxMachine.Status.FailureReason = &v
 }
 
+// ClearFailure clears the ProxmoxMachine status failure reason and message.
+func (m *MachineScope) ClearFailure() {
+	m.ProxmoxMachine.Status.FailureReason = nil
+	m.ProxmoxMachine.Status.FailureMessage = nil
+}
+
 // SetAnnotation sets a key value annotation on the ProxmoxMachine.
 func (m *MachineScope) SetAnnotation(key, value string) {
 	if m.ProxmoxMachine.Annotations == nil {
@@ -211,6 +312,11 @@ func (m *MachineScope) PatchObject() error {
 	conditions.SetSummary(m.ProxmoxMachine,
 		conditions.WithConditions(
 			infrav1alpha1.VMProvisionedCondition,
+			infrav1alpha1.VMClonedCondition,
+			infrav1alpha1.IPAddressAssignedCondition,
+			infrav1alpha1.BootstrapDataInjectedCondition,
+			infrav1alpha1.VMStartedCondition,
+			infrav1alpha1.GuestAgentReadyCondition,
 		),
 	)
 
@@ -221,6 +327,13 @@ func (m *MachineScope) PatchObject() error {
 		patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{
 			clusterv1.ReadyCondition,
 			infrav1alpha1.VMProvisionedCondition,
+			infrav1alpha1.VMClonedCondition,
+			infrav1alpha1.IPAddressAssignedCondition,
+			infrav1alpha1.BootstrapDataInjectedCondition,
+			infrav1alpha1.VMStartedCondition,
+			infrav1alpha1.GuestAgentReadyCondition,
+			infrav1alpha1.GuestAgentAddressesCondition,
+			infrav1alpha1.NetworkDevicesCondition,
 		}})
 }
 
@@ -244,6 +357,38 @@ func (m *MachineScope) GetBootstrapSecret(ctx context.Context, secret *corev1.Se
 	return m.client.Get(ctx, secretKey, secret)
 }
 
+// GetFileContent returns the content of a file from the key of the Secret or ConfigMap it references.
+func (m *MachineScope) GetFileContent(ctx context.Context, source infrav1alpha1.FileContentSource) (string, error) {
+	key := types.NamespacedName{
+		Namespace: m.ProxmoxMachine.GetNamespace(),
+		Name:      source.Name,
+	}
+
+	switch source.Kind {
+	case infrav1alpha1.FileContentSourceConfigMap:
+		configMap := &corev1.ConfigMap{}
+		if err := m.client.Get(ctx, key, configMap); err != nil {
+			return "", err
+		}
+		if value, ok := configMap.Data[source.Key]; ok {
+			return value, nil
+		}
+		if value, ok := configMap.BinaryData[source.Key]; ok {
+			return string(value), nil
+		}
+	default:
+		secret := &corev1.Secret{}
+		if err := m.client.Get(ctx, key, secret); err != nil {
+			return "", err
+		}
+		if value, ok := secret.Data[source.Key]; ok {
+			return string(value), nil
+		}
+	}
+
+	return "", errors.Errorf("%s %s has no key %s", source.Kind, source.Name, source.Key)
+}
+
 // SkipQemuGuestCheck check whether qemu-agent status check is enabled.
 func (m *MachineScope) SkipQemuGuestCheck() bool {
 	if m.ProxmoxMachine.Spec.Checks != nil {
@@ -82,6 +82,89 @@ func TestMachineScope_GetProviderID(t *testing.T) {
 	require.Equal(t, scope.GetProviderID(), "proxmox://6b08012f-f589-4c3f-bffa-cf9fa8b29e02")
 }
 
+func TestMachineScope_GetAllowedNodes(t *testing.T) {
+	p := infrav1alpha1.ProxmoxMachine{
+		Spec: infrav1alpha1.ProxmoxMachineSpec{},
+	}
+	c := infrav1alpha1.ProxmoxCluster{
+		Spec: infrav1alpha1.ProxmoxClusterSpec{
+			AllowedNodes: []string{"pve1", "pve2"},
+		},
+	}
+	scope := MachineScope{
+		ProxmoxMachine: &p,
+		InfraCluster:   &ClusterScope{ProxmoxCluster: &c},
+	}
+
+	nodes, err := scope.GetAllowedNodes()
+	require.NoError(t, err)
+	require.Equal(t, []string{"pve1", "pve2"}, nodes)
+
+	// the machine can only narrow down the nodes of the cluster.
+	p.Spec.AllowedNodes = []string{"pve2", "pve3"}
+	nodes, err = scope.GetAllowedNodes()
+	require.NoError(t, err)
+	require.Equal(t, []string{"pve2"}, nodes)
+
+	p.Spec.AllowedNodes = []string{"pve3"}
+	_, err = scope.GetAllowedNodes()
+	require.ErrorIs(t, err, ErrNoAllowedNodes)
+
+	c.Spec.AllowedNodes = nil
+	nodes, err = scope.GetAllowedNodes()
+	require.NoError(t, err)
+	require.Equal(t, []string{"pve3"}, nodes)
+}
+
+func TestMachineScope_GetAllowedNodes_FailureDomain(t *testing.T) {
+	m := clusterv1.Machine{
+		Spec: clusterv1.MachineSpec{
+			FailureDomain: ptr.To("dc2"),
+		},
+	}
+	p := infrav1alpha1.ProxmoxMachine{
+		Spec: infrav1alpha1.ProxmoxMachineSpec{},
+	}
+	c := infrav1alpha1.ProxmoxCluster{
+		Spec: infrav1alpha1.ProxmoxClusterSpec{
+			AllowedNodes: []string{"pve1", "pve2", "pve3"},
+			FailureDomains: map[string]infrav1alpha1.FailureDomainSpec{
+				"dc1": {Nodes: []string{"pve1"}},
+				"dc2": {Nodes: []string{"pve2", "pve3"}, Storage: ptr.To("ceph-dc2")},
+			},
+		},
+	}
+	scope := MachineScope{
+		Machine:        &m,
+		ProxmoxMachine: &p,
+		InfraCluster:   &ClusterScope{ProxmoxCluster: &c},
+	}
+
+	nodes, err := scope.GetAllowedNodes()
+	require.NoError(t, err)
+	require.Equal(t, []string{"pve2", "pve3"}, nodes)
+	fd, err := scope.GetFailureDomain()
+	require.NoError(t, err)
+	require.Equal(t, "ceph-dc2", *fd.Storage)
+
+	// the machine can only narrow down the nodes of its failure domain.
+	p.Spec.AllowedNodes = []string{"pve1", "pve3"}
+	nodes, err = scope.GetAllowedNodes()
+	require.NoError(t, err)
+	require.Equal(t, []string{"pve3"}, nodes)
+
+	p.Spec.AllowedNodes = []string{"pve1"}
+	_, err = scope.GetAllowedNodes()
+	require.ErrorIs(t, err, ErrNoAllowedNodes)
+
+	p.Spec.AllowedNodes = nil
+	m.Spec.FailureDomain = ptr.To("unknown")
+	_, err = scope.GetFailureDomain()
+	require.ErrorIs(t, err, ErrUnknownFailureDomain)
+	_, err = scope.GetAllowedNodes()
+	require.ErrorIs(t, err, ErrUnknownFailureDomain)
+}
+
 func TestMachineScope_GetVirtualMachineID(t *testing.T) {
 	p := infrav1alpha1.ProxmoxMachine{
 		Spec: infrav1alpha1.ProxmoxMachineSpec{},
@@ -96,6 +179,27 @@ func TestMachineScope_GetVirtualMachineID(t *testing.T) {
 	require.Equal(t, scope.GetVirtualMachineID(), int64(100))
 }
 
+func TestMachineScope_GetPool(t *testing.T) {
+	p := infrav1alpha1.ProxmoxMachine{
+		Spec: infrav1alpha1.ProxmoxMachineSpec{},
+	}
+	c := infrav1alpha1.ProxmoxCluster{
+		Spec: infrav1alpha1.ProxmoxClusterSpec{},
+	}
+	scope := MachineScope{
+		ProxmoxMachine: &p,
+		InfraCluster:   &ClusterScope{ProxmoxCluster: &c},
+	}
+
+	require.Empty(t, scope.GetPool())
+
+	c.Spec.Pool = ptr.To("cluster-pool")
+	require.Equal(t, "cluster-pool", scope.GetPool())
+
+	p.Spec.Pool = ptr.To("machine-pool")
+	require.Equal(t, "machine-pool", scope.GetPool())
+}
+
 func TestMachineScope_SetReady(t *testing.T) {
 	p := infrav1alpha1.ProxmoxMachine{
 		Spec: infrav1alpha1.ProxmoxMachineSpec{},
@@ -19,25 +19,35 @@ package types
 
 // NetworkConfigData is used to render network-config.
 type NetworkConfigData struct {
-	ProxName    string // Device name in Proxmox
-	MacAddress  string
-	DHCP4       bool
-	DHCP6       bool
-	IPAddress   string
-	IPV6Address string
-	Gateway     string
-	Metric      *uint32
-	Gateway6    string
-	Metric6     *uint32
-	DNSServers  []string
-	Type        string
-	Name        string
-	Interfaces  []string // Interfaces controlled by this one.
-	Table       uint32   // linux routing table number for VRF.
-	Routes      []RoutingData
-	FIBRules    []FIBRuleData // Forwarding information block for routing.
-	LinkMTU     *uint16       // linux network device MTU
-	VRF         string        // linux VRF name // only used in networkd config.
+	ProxName      string // Device name in Proxmox
+	MacAddress    string
+	DHCP4         bool
+	DHCP6         bool
+	AcceptRA      bool // accept IPv6 router advertisements, e.g. for SLAAC.
+	IPAddress     string
+	IPV6Address   string
+	Gateway       string
+	Metric        *uint32
+	Gateway6      string
+	Metric6       *uint32
+	DNSServers    []string
+	SearchDomains []string
+	Type          string
+	Name          string
+	Interfaces    []string // Interfaces controlled by this one.
+	Table         uint32   // linux routing table number for VRF.
+	Routes        []RoutingData
+	FIBRules      []FIBRuleData // Forwarding information block for routing.
+	LinkMTU       *uint16       // linux network device MTU
+	VRF           string        // linux VRF name // only used in networkd config.
+	Bond          string        // name of the bond the device is a member of.
+	BondMode      string        // linux bonding mode.
+	BondMIIMon    *uint32       // link monitoring interval of a bond in milliseconds.
+	Bridge        string        // name of the bridge the device is a member of.
+	BridgeSTP     *bool         // spanning tree protocol of a bridge.
+	ForwardDelay  *uint32       // forward delay of a bridge in seconds.
+	Link          string        // parent interface of a VLAN.
+	VLANID        uint16        // 802.1q id of a VLAN.
 }
 
 // RoutingData stores routing configuration.